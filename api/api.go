@@ -4,49 +4,170 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
+	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/session"
 	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/user"
+	"github.com/ankeesler/anwork/webhook"
 	"github.com/tedsuo/rata"
 )
 
 // This is the major verison of the API.
-const Version = 1
+const Version = 2
 
 //go:generate counterfeiter . Authenticator
 
 // Authenticator is an object that performs authentication for the ANWORK API.
 type Authenticator interface {
 	// Authenticate performs auth on a token string. If it passes, it should
-	// return a nil error. If it fails, it should return an error.
-	Authenticate(token string) error
-	// Generate a token for authentication. This token should probably be used
-	// in the Authenticate method.
-	Token() (string, error)
+	// return the subject of the token (i.e., the name of a user, or "" for no
+	// one in particular) and a nil error. If it fails, it should return an error.
+	Authenticate(token string) (string, error)
+	// Generate a token for authentication of a subject. This token should
+	// probably be used in the Authenticate method.
+	Token(subject string) (string, error)
+}
+
+//go:generate counterfeiter . SessionManager
+
+// SessionManager is an Authenticator that keeps a session for each token that
+// it generates, which can be ended before the token expires. When the
+// Authenticator passed to New is a SessionManager, users can log out, and
+// list and revoke sessions.
+type SessionManager interface {
+	Authenticator
+	// Revoke ends the session of a token, after which Authenticate should fail
+	// for it.
+	Revoke(token string) error
+	// Sessions returns the sessions that are active.
+	Sessions() ([]*session.Session, error)
+	// RevokeSession ends the session with an ID.
+	RevokeSession(id string) error
 }
 
 type api struct {
 	logger        lager.Logger
 	repo          task.Repo
 	authenticator Authenticator
+	contexts      task.Contexts
+	clock         clock.Clock
+	hub           *hub
+	dispatcher    *webhook.Dispatcher
+	users         user.Repo
 }
 
-var routes = rata.Routes{
+// contextRoutePrefix is the prefix of the name of the copy of each of the repoRoutes that uses
+// the task.Repo of a context, e.g., "context_get_tasks" for "get_tasks".
+const contextRoutePrefix = "context_"
+
+var routes = append(append(rata.Routes{
 	{Name: "auth", Method: rata.POST, Path: "/api/v1/auth"},
+	{Name: "logout", Method: rata.DELETE, Path: "/api/v1/auth"},
 	{Name: "health", Method: rata.GET, Path: "/api/v1/health"},
 
+	{Name: "get_sessions", Method: rata.GET, Path: "/api/v1/sessions"},
+	{Name: "delete_session", Method: rata.DELETE, Path: "/api/v1/sessions/:id"},
+
+	{Name: "get_contexts", Method: rata.GET, Path: "/api/v1/contexts"},
+	{Name: "create_context", Method: rata.POST, Path: "/api/v1/contexts"},
+	{Name: "delete_context", Method: rata.DELETE, Path: "/api/v1/contexts/:context"},
+}, repoRoutes...), contextRoutes()...)
+
+// repoRoutes are the routes that use the task.Repo passed to New.
+var repoRoutes = rata.Routes{
 	{Name: "get_tasks", Method: rata.GET, Path: "/api/v1/tasks"},
 	{Name: "create_task", Method: rata.POST, Path: "/api/v1/tasks"},
 	{Name: "get_task", Method: rata.GET, Path: "/api/v1/tasks/:id"},
 	{Name: "update_task", Method: rata.PUT, Path: "/api/v1/tasks/:id"},
+	{Name: "patch_task", Method: rata.PATCH, Path: "/api/v1/tasks/:id"},
 	{Name: "delete_task", Method: rata.DELETE, Path: "/api/v1/tasks/:id"},
 
 	{Name: "get_events", Method: rata.GET, Path: "/api/v1/events"},
 	{Name: "create_event", Method: rata.POST, Path: "/api/v1/events"},
+	// This must come before get_event, which would otherwise match it.
+	{Name: "stream_events", Method: rata.GET, Path: "/api/v1/events/stream"},
 	{Name: "get_event", Method: rata.GET, Path: "/api/v1/events/:id"},
 	{Name: "delete_event", Method: rata.DELETE, Path: "/api/v1/events/:id"},
+
+	{Name: "get_dependencies", Method: rata.GET, Path: "/api/v1/dependencies"},
+	{Name: "create_dependency", Method: rata.POST, Path: "/api/v1/dependencies"},
+	{Name: "get_dependency", Method: rata.GET, Path: "/api/v1/dependencies/:id"},
+	{Name: "delete_dependency", Method: rata.DELETE, Path: "/api/v1/dependencies/:id"},
+
+	{Name: "get_webhooks", Method: rata.GET, Path: "/api/v1/webhooks"},
+	{Name: "create_webhook", Method: rata.POST, Path: "/api/v1/webhooks"},
+	{Name: "get_webhook", Method: rata.GET, Path: "/api/v1/webhooks/:id"},
+	{Name: "delete_webhook", Method: rata.DELETE, Path: "/api/v1/webhooks/:id"},
+
+	{Name: "get_dead_letters", Method: rata.GET, Path: "/api/v1/dead-letters"},
+
+	// These are the routes that perform an operation of a manager.Manager; see operations.
+	{Name: "create", Method: rata.POST, Path: "/api/v2/create"},
+	{Name: "set_state", Method: rata.POST, Path: "/api/v2/set-state"},
+	{Name: "set_priority", Method: rata.POST, Path: "/api/v2/set-priority"},
+	{Name: "note", Method: rata.POST, Path: "/api/v2/note"},
+	{Name: "rename", Method: rata.POST, Path: "/api/v2/rename"},
+	{Name: "delete", Method: rata.POST, Path: "/api/v2/delete"},
+	{Name: "archive", Method: rata.POST, Path: "/api/v2/archive"},
+}
+
+// contextRoutes returns a copy of each of the repoRoutes under /api/vN/contexts/:context, where
+// vN is the version of the route.
+func contextRoutes() rata.Routes {
+	routes := make(rata.Routes, 0, len(repoRoutes))
+	for _, route := range repoRoutes {
+		// e.g., ["", "api", "v1", "tasks/:id"]
+		segments := strings.SplitN(route.Path, "/", 4)
+		routes = append(routes, rata.Route{
+			Name:   contextRoutePrefix + route.Name,
+			Method: route.Method,
+			Path:   "/api/" + segments[2] + "/contexts/:context/" + segments[3],
+		})
+	}
+	return routes
+}
+
+// An Option configures optional behavior of the http.Handler returned from New.
+type Option func(*api)
+
+// WithContexts sets the task.Contexts that serve the /api/v1/contexts endpoints. By default, there
+// are no contexts, and those endpoints respond with a 404.
+func WithContexts(contexts task.Contexts) Option {
+	return func(a *api) {
+		a.contexts = contexts
+	}
+}
+
+// WithClock sets the clock.Clock that dates the task.Event's created by the /api/v2 endpoints. By
+// default, the real time is used.
+func WithClock(clock clock.Clock) Option {
+	return func(a *api) {
+		a.clock = clock
+	}
+}
+
+// WithDispatcher sets the webhook.Dispatcher that delivers each task.Event created through the API
+// to the webhook.Webhook's of its task.Repo, when that task.Repo is a webhook.Repo. By default, a
+// webhook.Dispatcher with the clock.Clock of the API is used.
+func WithDispatcher(dispatcher *webhook.Dispatcher) Option {
+	return func(a *api) {
+		a.dispatcher = dispatcher
+	}
+}
+
+// WithUsers sets the user.Repo that stores the user.User's who can log in to the API. Once it has
+// a user.User, every request must be made with a token for one of them, and each user.User can
+// only see their own task.Task's (see task.Task.Owner). By default, there are no users, and every
+// token is for no one in particular.
+func WithUsers(users user.Repo) Option {
+	return func(a *api) {
+		a.users = users
+	}
 }
 
 // New creates an http.Handler that will perform the ANWORK API functionality.
@@ -54,12 +175,22 @@ func New(
 	logger lager.Logger,
 	repo task.Repo,
 	authenticator Authenticator,
+	options ...Option,
 ) http.Handler {
-	return &api{
+	a := &api{
 		logger:        logger,
 		repo:          repo,
 		authenticator: authenticator,
+		clock:         clock.NewClock(),
+		hub:           newHub(),
 	}
+	for _, option := range options {
+		option(a)
+	}
+	if a.dispatcher == nil {
+		a.dispatcher = webhook.NewDispatcher(logger.Session("webhooks"), webhook.WithClock(a.clock))
+	}
+	return a
 }
 
 func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -68,11 +199,12 @@ func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		lager.Data{"method": r.Method, "path": r.URL.Path, "query": r.URL.RawQuery},
 	)
 
-	if err, statusCode := a.authenticate(r); err != nil {
+	subject, err, statusCode := a.authenticate(r)
+	if err != nil {
 		respondWithError(a.logger, w, statusCode, err)
 		return
 	}
-	a.logger.Debug("authenticated")
+	a.logger.Debug("authenticated", lager.Data{"subject": subject})
 
 	if strings.HasPrefix(r.URL.Path, "/debug/pprof") {
 		handleDebug(w, r)
@@ -80,19 +212,29 @@ func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	handlers := rata.Handlers{
-		"auth":   &authHandler{a.logger, a.authenticator},
+		"auth":   &authHandler{a.logger, a.authenticator, a.users},
+		"logout": &logoutHandler{a.logger, a.authenticator},
 		"health": &healthHandler{},
 
-		"get_tasks":   &getTasksHandler{a.logger, a.repo},
-		"create_task": &createTaskHandler{a.logger, a.repo},
-		"get_task":    &getTaskHandler{a.logger, a.repo},
-		"update_task": &updateTaskHandler{a.logger, a.repo},
-		"delete_task": &deleteTaskHandler{a.logger, a.repo},
+		"get_sessions":   &getSessionsHandler{a.logger, a.authenticator, subject},
+		"delete_session": &deleteSessionHandler{a.logger, a.authenticator, subject},
 
-		"get_events":   &getEventsHandler{a.logger, a.repo},
-		"create_event": &createEventHandler{a.logger, a.repo},
-		"get_event":    &getEventHandler{a.logger, a.repo},
-		"delete_event": &deleteEventHandler{a.logger, a.repo},
+		"get_contexts":   &getContextsHandler{a.logger, a.contexts},
+		"create_context": &createContextHandler{a.logger, a.contexts, subject},
+		"delete_context": &deleteContextHandler{a.logger, a.contexts, subject},
+	}
+	stream := stream{a.hub, "", a.repo, a.dispatcher}
+	for name, handler := range repoHandlers(a.logger, a.repo, subject, a.clock, stream) {
+		handlers[name] = handler
+		handlers[contextRoutePrefix+name] = &contextHandler{
+			a.logger,
+			a.contexts,
+			a.clock,
+			a.hub,
+			a.dispatcher,
+			subject,
+			name,
+		}
 	}
 	router, err := rata.NewRouter(routes, handlers)
 	if err != nil {
@@ -103,30 +245,125 @@ func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	router.ServeHTTP(w, r)
 }
 
-func (a *api) authenticate(r *http.Request) (error, int) {
-	if r.URL.Path == "/api/v1/auth" || r.URL.Path == "/api/v1/health" {
-		return nil, 0
+// repoHandlers returns the handlers of the repoRoutes for a task.Repo, as seen by a user (or by
+// everyone, if the user is ""). Everything that they change in the task.Repo is published to the
+// stream.
+func repoHandlers(
+	logger lager.Logger,
+	repo task.Repo,
+	user string,
+	clock clock.Clock,
+	stream stream,
+) rata.Handlers {
+	// The webhook handlers need the webhook.Repo, which the ownedRepo and publishingRepo hide.
+	webhooks := repo
+	sees := func(*Change) bool { return true }
+	if user != "" {
+		owned := &ownedRepo{Repo: repo, user: user}
+		repo, sees = owned, owned.seesChange(logger)
+
+		if w, ok := webhooks.(webhook.Repo); ok {
+			webhooks = &ownedWebhookRepo{Repo: webhooks, webhooks: w, user: user}
+		}
+	}
+	repo = &publishingRepo{Repo: repo, publish: stream.publish}
+	handlers := rata.Handlers{
+		"get_tasks":   &getTasksHandler{logger, repo},
+		"create_task": &createTaskHandler{logger, repo},
+		"get_task":    &getTaskHandler{logger, repo},
+		"update_task": &updateTaskHandler{logger, repo},
+		"patch_task":  &patchTaskHandler{logger, repo},
+		"delete_task": &deleteTaskHandler{logger, repo},
+
+		"get_events":    &getEventsHandler{logger, repo},
+		"create_event":  &createEventHandler{logger, repo},
+		"get_event":     &getEventHandler{logger, repo},
+		"stream_events": &streamEventsHandler{logger, repo, stream, sees},
+		"delete_event":  &deleteEventHandler{logger, repo},
+
+		"get_dependencies":  &getDependenciesHandler{logger, repo},
+		"create_dependency": &createDependencyHandler{logger, repo},
+		"get_dependency":    &getDependencyHandler{logger, repo},
+		"delete_dependency": &deleteDependencyHandler{logger, repo},
+
+		"get_webhooks":   &getWebhooksHandler{logger, webhooks},
+		"create_webhook": &createWebhookHandler{logger, webhooks},
+		"get_webhook":    &getWebhookHandler{logger, webhooks},
+		"delete_webhook": &deleteWebhookHandler{logger, webhooks},
+
+		"get_dead_letters": &getDeadLettersHandler{logger, webhooks},
+	}
+	for name := range operations {
+		handlers[name] = &operationHandler{logger, repo, clock, user, name}
+	}
+	return handlers
+}
+
+// authenticate returns the user who made a request (or "" for no one in particular), or an error
+// and the status code with which to respond.
+func (a *api) authenticate(r *http.Request) (string, error, int) {
+	// Logging out (i.e., DELETE /api/v1/auth) needs a token, but logging in does not.
+	if (r.URL.Path == "/api/v1/auth" && r.Method == http.MethodPost) || r.URL.Path == "/api/v1/health" {
+		return "", nil, 0
 	}
 
+	token, err, statusCode := bearerToken(r)
+	if err != nil {
+		return "", err, statusCode
+	}
+
+	subject, err := a.authenticator.Authenticate(token)
+	if err != nil {
+		return "", err, http.StatusForbidden
+	}
+
+	if subject == "" {
+		if err, statusCode := requireNoUsers(a.users); err != nil {
+			return "", err, statusCode
+		}
+		return "", nil, 0
+	}
+
+	if a.users == nil {
+		return "", errNoUsers, http.StatusForbidden
+	}
+	u, err := a.users.FindUserByName(subject)
+	if err != nil {
+		return "", err, http.StatusInternalServerError
+	} else if u == nil {
+		return "", fmt.Errorf("unknown user '%s'", subject), http.StatusForbidden
+	}
+	return subject, nil, 0
+}
+
+// bearerToken returns the token in the Authorization header of a request, or an error and the
+// status code with which to respond.
+func bearerToken(r *http.Request) (string, error, int) {
 	tokenData := r.Header.Get("Authorization")
 	if tokenData == "" {
-		return errors.New("missing authorization header"), http.StatusUnauthorized
+		return "", errors.New("missing authorization header"), http.StatusUnauthorized
 	}
 
 	splitData := strings.Split(tokenData, " ")
 	if len(splitData) != 2 || splitData[0] != "bearer" {
-		return errors.New("invalid authorization data"), http.StatusBadRequest
+		return "", errors.New("invalid authorization data"), http.StatusBadRequest
 	}
 
-	return a.authenticator.Authenticate(splitData[1]), http.StatusForbidden
+	return splitData[1], nil, 0
 }
 
+// respondWithError responds with an Error. If a user tried to do something that only the owner of
+// a task.Task can do, the status code is always 403.
 func respondWithError(
 	logger lager.Logger,
 	w http.ResponseWriter,
 	statusCode int,
 	err error,
 ) {
+	var notOwner *notOwnerError
+	if errors.As(err, &notOwner) {
+		statusCode = http.StatusForbidden
+	}
 	respond(logger, w, statusCode, Error{Message: err.Error()})
 }
 
@@ -128,7 +128,7 @@ var _ = Describe("API", func() {
 
 		Context("failed authentication", func() {
 			BeforeEach(func() {
-				authenticator.AuthenticateReturnsOnCall(0, errors.New("some auth error"))
+				authenticator.AuthenticateReturnsOnCall(0, "", errors.New("some auth error"))
 			})
 
 			It("returns an error and a 403", func() {
@@ -182,6 +182,10 @@ func deletee(path string) (*http.Response, error) {
 }
 
 func do(method, path string, body interface{}) (*http.Response, error) {
+	return doWithHeader(method, path, body, http.Header{})
+}
+
+func doWithHeader(method, path string, body interface{}, header http.Header) (*http.Response, error) {
 	url := fmt.Sprintf("http://127.0.0.1:12345%s", path)
 
 	var data []byte
@@ -195,6 +199,7 @@ func do(method, path string, body interface{}) (*http.Response, error) {
 	req, err := http.NewRequest(method, url, buf)
 	ExpectWithOffset(1, err).NotTo(HaveOccurred())
 
+	req.Header = header
 	req.Header.Set("Authorization", "bearer some-token")
 
 	return http.DefaultClient.Do(req)
@@ -249,3 +254,23 @@ func assertEvent(rsp *http.Response, task *taskpkg.Event) {
 
 	ExpectWithOffset(1, actualEvent).To(Equal(*task))
 }
+
+func assertDependencies(rsp *http.Response, dependencies []*taskpkg.Dependency) {
+	bytes, err := ioutil.ReadAll(rsp.Body)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+	actualDependencies := make([]*taskpkg.Dependency, 1)
+	ExpectWithOffset(1, json.Unmarshal(bytes, &actualDependencies)).NotTo(HaveOccurred())
+
+	ExpectWithOffset(1, actualDependencies).To(Equal(dependencies))
+}
+
+func assertDependency(rsp *http.Response, dependency *taskpkg.Dependency) {
+	bytes, err := ioutil.ReadAll(rsp.Body)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+	var actualDependency taskpkg.Dependency
+	ExpectWithOffset(1, json.Unmarshal(bytes, &actualDependency)).NotTo(HaveOccurred())
+
+	ExpectWithOffset(1, actualDependency).To(Equal(*dependency))
+}
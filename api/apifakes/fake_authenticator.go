@@ -8,20 +8,23 @@ import (
 )
 
 type FakeAuthenticator struct {
-	AuthenticateStub        func(string) error
+	AuthenticateStub        func(string) (string, error)
 	authenticateMutex       sync.RWMutex
 	authenticateArgsForCall []struct {
 		arg1 string
 	}
 	authenticateReturns struct {
-		result1 error
+		result1 string
+		result2 error
 	}
 	authenticateReturnsOnCall map[int]struct {
-		result1 error
+		result1 string
+		result2 error
 	}
-	TokenStub        func() (string, error)
+	TokenStub        func(string) (string, error)
 	tokenMutex       sync.RWMutex
 	tokenArgsForCall []struct {
+		arg1 string
 	}
 	tokenReturns struct {
 		result1 string
@@ -35,7 +38,7 @@ type FakeAuthenticator struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeAuthenticator) Authenticate(arg1 string) error {
+func (fake *FakeAuthenticator) Authenticate(arg1 string) (string, error) {
 	fake.authenticateMutex.Lock()
 	ret, specificReturn := fake.authenticateReturnsOnCall[len(fake.authenticateArgsForCall)]
 	fake.authenticateArgsForCall = append(fake.authenticateArgsForCall, struct {
@@ -47,10 +50,10 @@ func (fake *FakeAuthenticator) Authenticate(arg1 string) error {
 		return fake.AuthenticateStub(arg1)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
 	fakeReturns := fake.authenticateReturns
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
 func (fake *FakeAuthenticator) AuthenticateCallCount() int {
@@ -59,7 +62,7 @@ func (fake *FakeAuthenticator) AuthenticateCallCount() int {
 	return len(fake.authenticateArgsForCall)
 }
 
-func (fake *FakeAuthenticator) AuthenticateCalls(stub func(string) error) {
+func (fake *FakeAuthenticator) AuthenticateCalls(stub func(string) (string, error)) {
 	fake.authenticateMutex.Lock()
 	defer fake.authenticateMutex.Unlock()
 	fake.AuthenticateStub = stub
@@ -72,38 +75,42 @@ func (fake *FakeAuthenticator) AuthenticateArgsForCall(i int) string {
 	return argsForCall.arg1
 }
 
-func (fake *FakeAuthenticator) AuthenticateReturns(result1 error) {
+func (fake *FakeAuthenticator) AuthenticateReturns(result1 string, result2 error) {
 	fake.authenticateMutex.Lock()
 	defer fake.authenticateMutex.Unlock()
 	fake.AuthenticateStub = nil
 	fake.authenticateReturns = struct {
-		result1 error
-	}{result1}
+		result1 string
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeAuthenticator) AuthenticateReturnsOnCall(i int, result1 error) {
+func (fake *FakeAuthenticator) AuthenticateReturnsOnCall(i int, result1 string, result2 error) {
 	fake.authenticateMutex.Lock()
 	defer fake.authenticateMutex.Unlock()
 	fake.AuthenticateStub = nil
 	if fake.authenticateReturnsOnCall == nil {
 		fake.authenticateReturnsOnCall = make(map[int]struct {
-			result1 error
+			result1 string
+			result2 error
 		})
 	}
 	fake.authenticateReturnsOnCall[i] = struct {
-		result1 error
-	}{result1}
+		result1 string
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeAuthenticator) Token() (string, error) {
+func (fake *FakeAuthenticator) Token(arg1 string) (string, error) {
 	fake.tokenMutex.Lock()
 	ret, specificReturn := fake.tokenReturnsOnCall[len(fake.tokenArgsForCall)]
 	fake.tokenArgsForCall = append(fake.tokenArgsForCall, struct {
-	}{})
-	fake.recordInvocation("Token", []interface{}{})
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Token", []interface{}{arg1})
 	fake.tokenMutex.Unlock()
 	if fake.TokenStub != nil {
-		return fake.TokenStub()
+		return fake.TokenStub(arg1)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -118,12 +125,19 @@ func (fake *FakeAuthenticator) TokenCallCount() int {
 	return len(fake.tokenArgsForCall)
 }
 
-func (fake *FakeAuthenticator) TokenCalls(stub func() (string, error)) {
+func (fake *FakeAuthenticator) TokenCalls(stub func(string) (string, error)) {
 	fake.tokenMutex.Lock()
 	defer fake.tokenMutex.Unlock()
 	fake.TokenStub = stub
 }
 
+func (fake *FakeAuthenticator) TokenArgsForCall(i int) string {
+	fake.tokenMutex.RLock()
+	defer fake.tokenMutex.RUnlock()
+	argsForCall := fake.tokenArgsForCall[i]
+	return argsForCall.arg1
+}
+
 func (fake *FakeAuthenticator) TokenReturns(result1 string, result2 error) {
 	fake.tokenMutex.Lock()
 	defer fake.tokenMutex.Unlock()
@@ -0,0 +1,409 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package apifakes
+
+import (
+	sync "sync"
+
+	api "github.com/ankeesler/anwork/api"
+	session "github.com/ankeesler/anwork/session"
+)
+
+type FakeSessionManager struct {
+	AuthenticateStub        func(string) (string, error)
+	authenticateMutex       sync.RWMutex
+	authenticateArgsForCall []struct {
+		arg1 string
+	}
+	authenticateReturns struct {
+		result1 string
+		result2 error
+	}
+	authenticateReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	RevokeStub        func(string) error
+	revokeMutex       sync.RWMutex
+	revokeArgsForCall []struct {
+		arg1 string
+	}
+	revokeReturns struct {
+		result1 error
+	}
+	revokeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RevokeSessionStub        func(string) error
+	revokeSessionMutex       sync.RWMutex
+	revokeSessionArgsForCall []struct {
+		arg1 string
+	}
+	revokeSessionReturns struct {
+		result1 error
+	}
+	revokeSessionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SessionsStub        func() ([]*session.Session, error)
+	sessionsMutex       sync.RWMutex
+	sessionsArgsForCall []struct {
+	}
+	sessionsReturns struct {
+		result1 []*session.Session
+		result2 error
+	}
+	sessionsReturnsOnCall map[int]struct {
+		result1 []*session.Session
+		result2 error
+	}
+	TokenStub        func(string) (string, error)
+	tokenMutex       sync.RWMutex
+	tokenArgsForCall []struct {
+		arg1 string
+	}
+	tokenReturns struct {
+		result1 string
+		result2 error
+	}
+	tokenReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeSessionManager) Authenticate(arg1 string) (string, error) {
+	fake.authenticateMutex.Lock()
+	ret, specificReturn := fake.authenticateReturnsOnCall[len(fake.authenticateArgsForCall)]
+	fake.authenticateArgsForCall = append(fake.authenticateArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Authenticate", []interface{}{arg1})
+	fake.authenticateMutex.Unlock()
+	if fake.AuthenticateStub != nil {
+		return fake.AuthenticateStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.authenticateReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSessionManager) AuthenticateCallCount() int {
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	return len(fake.authenticateArgsForCall)
+}
+
+func (fake *FakeSessionManager) AuthenticateCalls(stub func(string) (string, error)) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = stub
+}
+
+func (fake *FakeSessionManager) AuthenticateArgsForCall(i int) string {
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	argsForCall := fake.authenticateArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSessionManager) AuthenticateReturns(result1 string, result2 error) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = nil
+	fake.authenticateReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSessionManager) AuthenticateReturnsOnCall(i int, result1 string, result2 error) {
+	fake.authenticateMutex.Lock()
+	defer fake.authenticateMutex.Unlock()
+	fake.AuthenticateStub = nil
+	if fake.authenticateReturnsOnCall == nil {
+		fake.authenticateReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.authenticateReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSessionManager) Revoke(arg1 string) error {
+	fake.revokeMutex.Lock()
+	ret, specificReturn := fake.revokeReturnsOnCall[len(fake.revokeArgsForCall)]
+	fake.revokeArgsForCall = append(fake.revokeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Revoke", []interface{}{arg1})
+	fake.revokeMutex.Unlock()
+	if fake.RevokeStub != nil {
+		return fake.RevokeStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.revokeReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeSessionManager) RevokeCallCount() int {
+	fake.revokeMutex.RLock()
+	defer fake.revokeMutex.RUnlock()
+	return len(fake.revokeArgsForCall)
+}
+
+func (fake *FakeSessionManager) RevokeCalls(stub func(string) error) {
+	fake.revokeMutex.Lock()
+	defer fake.revokeMutex.Unlock()
+	fake.RevokeStub = stub
+}
+
+func (fake *FakeSessionManager) RevokeArgsForCall(i int) string {
+	fake.revokeMutex.RLock()
+	defer fake.revokeMutex.RUnlock()
+	argsForCall := fake.revokeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSessionManager) RevokeReturns(result1 error) {
+	fake.revokeMutex.Lock()
+	defer fake.revokeMutex.Unlock()
+	fake.RevokeStub = nil
+	fake.revokeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSessionManager) RevokeReturnsOnCall(i int, result1 error) {
+	fake.revokeMutex.Lock()
+	defer fake.revokeMutex.Unlock()
+	fake.RevokeStub = nil
+	if fake.revokeReturnsOnCall == nil {
+		fake.revokeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.revokeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSessionManager) RevokeSession(arg1 string) error {
+	fake.revokeSessionMutex.Lock()
+	ret, specificReturn := fake.revokeSessionReturnsOnCall[len(fake.revokeSessionArgsForCall)]
+	fake.revokeSessionArgsForCall = append(fake.revokeSessionArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("RevokeSession", []interface{}{arg1})
+	fake.revokeSessionMutex.Unlock()
+	if fake.RevokeSessionStub != nil {
+		return fake.RevokeSessionStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.revokeSessionReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeSessionManager) RevokeSessionCallCount() int {
+	fake.revokeSessionMutex.RLock()
+	defer fake.revokeSessionMutex.RUnlock()
+	return len(fake.revokeSessionArgsForCall)
+}
+
+func (fake *FakeSessionManager) RevokeSessionCalls(stub func(string) error) {
+	fake.revokeSessionMutex.Lock()
+	defer fake.revokeSessionMutex.Unlock()
+	fake.RevokeSessionStub = stub
+}
+
+func (fake *FakeSessionManager) RevokeSessionArgsForCall(i int) string {
+	fake.revokeSessionMutex.RLock()
+	defer fake.revokeSessionMutex.RUnlock()
+	argsForCall := fake.revokeSessionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSessionManager) RevokeSessionReturns(result1 error) {
+	fake.revokeSessionMutex.Lock()
+	defer fake.revokeSessionMutex.Unlock()
+	fake.RevokeSessionStub = nil
+	fake.revokeSessionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSessionManager) RevokeSessionReturnsOnCall(i int, result1 error) {
+	fake.revokeSessionMutex.Lock()
+	defer fake.revokeSessionMutex.Unlock()
+	fake.RevokeSessionStub = nil
+	if fake.revokeSessionReturnsOnCall == nil {
+		fake.revokeSessionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.revokeSessionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeSessionManager) Sessions() ([]*session.Session, error) {
+	fake.sessionsMutex.Lock()
+	ret, specificReturn := fake.sessionsReturnsOnCall[len(fake.sessionsArgsForCall)]
+	fake.sessionsArgsForCall = append(fake.sessionsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Sessions", []interface{}{})
+	fake.sessionsMutex.Unlock()
+	if fake.SessionsStub != nil {
+		return fake.SessionsStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.sessionsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSessionManager) SessionsCallCount() int {
+	fake.sessionsMutex.RLock()
+	defer fake.sessionsMutex.RUnlock()
+	return len(fake.sessionsArgsForCall)
+}
+
+func (fake *FakeSessionManager) SessionsCalls(stub func() ([]*session.Session, error)) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = stub
+}
+
+func (fake *FakeSessionManager) SessionsReturns(result1 []*session.Session, result2 error) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = nil
+	fake.sessionsReturns = struct {
+		result1 []*session.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSessionManager) SessionsReturnsOnCall(i int, result1 []*session.Session, result2 error) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = nil
+	if fake.sessionsReturnsOnCall == nil {
+		fake.sessionsReturnsOnCall = make(map[int]struct {
+			result1 []*session.Session
+			result2 error
+		})
+	}
+	fake.sessionsReturnsOnCall[i] = struct {
+		result1 []*session.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSessionManager) Token(arg1 string) (string, error) {
+	fake.tokenMutex.Lock()
+	ret, specificReturn := fake.tokenReturnsOnCall[len(fake.tokenArgsForCall)]
+	fake.tokenArgsForCall = append(fake.tokenArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Token", []interface{}{arg1})
+	fake.tokenMutex.Unlock()
+	if fake.TokenStub != nil {
+		return fake.TokenStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.tokenReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeSessionManager) TokenCallCount() int {
+	fake.tokenMutex.RLock()
+	defer fake.tokenMutex.RUnlock()
+	return len(fake.tokenArgsForCall)
+}
+
+func (fake *FakeSessionManager) TokenCalls(stub func(string) (string, error)) {
+	fake.tokenMutex.Lock()
+	defer fake.tokenMutex.Unlock()
+	fake.TokenStub = stub
+}
+
+func (fake *FakeSessionManager) TokenArgsForCall(i int) string {
+	fake.tokenMutex.RLock()
+	defer fake.tokenMutex.RUnlock()
+	argsForCall := fake.tokenArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeSessionManager) TokenReturns(result1 string, result2 error) {
+	fake.tokenMutex.Lock()
+	defer fake.tokenMutex.Unlock()
+	fake.TokenStub = nil
+	fake.tokenReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSessionManager) TokenReturnsOnCall(i int, result1 string, result2 error) {
+	fake.tokenMutex.Lock()
+	defer fake.tokenMutex.Unlock()
+	fake.TokenStub = nil
+	if fake.tokenReturnsOnCall == nil {
+		fake.tokenReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.tokenReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeSessionManager) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.authenticateMutex.RLock()
+	defer fake.authenticateMutex.RUnlock()
+	fake.revokeMutex.RLock()
+	defer fake.revokeMutex.RUnlock()
+	fake.revokeSessionMutex.RLock()
+	defer fake.revokeSessionMutex.RUnlock()
+	fake.sessionsMutex.RLock()
+	defer fake.sessionsMutex.RUnlock()
+	fake.tokenMutex.RLock()
+	defer fake.tokenMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeSessionManager) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ api.SessionManager = new(FakeSessionManager)
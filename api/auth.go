@@ -1,18 +1,61 @@
 package api
 
 import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"net/http"
 
 	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/user"
 )
 
+// errNoUsers is returned when someone tries to log in to an API that has no user.Repo.
+var errNoUsers = errors.New("users are not supported by this API")
+
+// requireNoUsers returns an error, and the status code with which to respond, if a user.Repo has
+// any user.User's, in which case everyone must log in as one of them.
+func requireNoUsers(users user.Repo) (error, int) {
+	if users == nil {
+		return nil, 0
+	}
+
+	us, err := users.Users()
+	if err != nil {
+		return err, http.StatusInternalServerError
+	} else if len(us) > 0 {
+		return errors.New("missing user (log in with a name and password)"), http.StatusUnauthorized
+	}
+	return nil, 0
+}
+
 type authHandler struct {
 	logger        lager.Logger
 	authenticator Authenticator
+	users         user.Repo
 }
 
 func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	token, err := h.authenticator.Token()
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var credentials Credentials
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &credentials); err != nil {
+			respondWithError(h.logger, w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if err, statusCode := h.login(&credentials); err != nil {
+		respondWithError(h.logger, w, statusCode, err)
+		return
+	}
+
+	token, err := h.authenticator.Token(credentials.Name)
 	if err != nil {
 		respondWithError(h.logger, w, http.StatusInternalServerError, err)
 		return
@@ -21,3 +64,26 @@ func (h *authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	auth := Auth{Token: token}
 	respond(h.logger, w, http.StatusOK, auth)
 }
+
+// login returns an error, and the status code with which to respond, if Credentials are not
+// those of a user.User. Empty Credentials are only accepted when there are no user.User's.
+func (h *authHandler) login(credentials *Credentials) (error, int) {
+	if credentials.Name == "" {
+		return requireNoUsers(h.users)
+	}
+
+	if h.users == nil {
+		return errNoUsers, http.StatusNotImplemented
+	}
+
+	u, err := h.users.FindUserByName(credentials.Name)
+	if err != nil {
+		return err, http.StatusInternalServerError
+	} else if u == nil || !u.CheckPassword(credentials.Password) {
+		h.logger.Debug("invalid-credentials", lager.Data{"name": credentials.Name})
+		return errors.New("invalid name or password"), http.StatusUnauthorized
+	}
+
+	h.logger.Debug("logged-in", lager.Data{"name": credentials.Name})
+	return nil, 0
+}
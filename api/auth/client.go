@@ -48,9 +48,8 @@ func (c *Client) Validate(token string) (string, error) {
 	}
 
 	if err := claims.Validate(jwt.Expected{
-		Issuer:  "anwork",
-		Subject: "andrew",
-		Time:    time.Now(),
+		Issuer: "anwork",
+		Time:   time.Now(),
 	}); err != nil {
 		return "", fmt.Errorf("invalid claims: %s", err.Error())
 	}
@@ -87,10 +87,6 @@ var _ = Describe("Client", func() {
 			claims.Issuer = "wrong-issuer"
 		})
 
-		testInvalidClaim("subject claim (sub)", func(claims *jwt.Claims) {
-			claims.Subject = "wrong-subject"
-		})
-
 		testInvalidClaim("not valid yet (nbf)", func(claims *jwt.Claims) {
 			claims.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Hour * 24))
 		})
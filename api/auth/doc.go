@@ -1,12 +1,15 @@
 // Package auth provides an authentication mechanism for the ANWORK project.
 //
-// This authentication mechanism is for single-occupancy use, i.e., only
-// one identity exists and it is tied to an RSA key/32-byte secret pair. It
+// This authentication mechanism is tied to an RSA key/32-byte secret pair. It
 // generates RSA public key encrypted tokens that can only be consumed by
-// those who have access to the matching RSA private key.
+// those who have access to the matching RSA private key. Each token carries
+// the subject (i.e., the user) for which it was generated.
 //
 // The package provides two main types: Server and Client. The Server object
 // provides the ability to generate encrypted tokens (Token()) and validate
-// decrypted tokens (Authenticate()). The Client provides the ability to
-// validate encrypted tokens (Authenticate()).
+// decrypted tokens, returning their subject (Authenticate()). It keeps a
+// session.Session for each token, so that many tokens can be valid at once,
+// and any of them can be revoked (Revoke(), RevokeSession()) before it
+// expires. The Client provides the ability to validate encrypted tokens
+// (Authenticate()).
 package auth
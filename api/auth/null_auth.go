@@ -4,5 +4,5 @@ package auth
 // every token is valid. It should only be used for testing.
 type NullAuth struct{}
 
-func (na NullAuth) Authenticate(token string) error { return nil }
-func (na NullAuth) Token() (string, error)          { return "", nil }
+func (na NullAuth) Authenticate(token string) (string, error) { return "", nil }
+func (na NullAuth) Token(subject string) (string, error)      { return "", nil }
@@ -5,9 +5,14 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"os"
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/session"
+	"github.com/tedsuo/ifrit"
 	"gopkg.in/square/go-jose.v2/jwt"
 )
 
@@ -17,63 +22,79 @@ import (
 // matching RSA private key to decrypt the tokens. The Authenticate() method
 // expects the token to be decrypted.
 //
+// Each token carries the subject (i.e., the name of the user) for which it
+// was generated, and Authenticate() returns it. Any number of tokens can be
+// valid at the same time, each with its own session.Session, and a Server
+// can be used by many goroutines at once.
+//
 // This implementation uses JWT tokens according to RFC 7519. Then tokens are
 // both encrypted and signed, according to RFC 7516 and 7515.
 type Server struct {
 	clock clock.Clock
 	rand  io.Reader
+	// This is locked while random bytes are read from rand, which may not be goroutine-safe.
+	randLock sync.Mutex
 
 	publicKey *rsa.PublicKey
 	secret    []byte
 
-	currentJTI string
+	sessions session.Repo
 }
 
 // NewServer creates a new Server with a publicKey and a secret. It will use
 // the provided clock to fill in the time-related claims of the JWT and the
-// rand to populate the JWT ID (jti) field of the JWT.
+// rand to populate the JWT ID (jti) field of the JWT. It starts a
+// session.Session in the session.Repo for each token that it generates, and
+// a token is only valid while its session.Session is in the session.Repo.
 func NewServer(
 	clock clock.Clock,
 	rand io.Reader,
 	publicKey *rsa.PublicKey,
 	secret []byte,
+	sessions session.Repo,
 ) *Server {
 	return &Server{
 		clock:     clock,
 		rand:      rand,
 		publicKey: publicKey,
 		secret:    secret,
+		sessions:  sessions,
 	}
 }
 
-func (s *Server) Authenticate(token string) error {
-	parsed, err := jwt.ParseSigned(token)
+// Authenticate validates a decrypted token and returns its subject.
+func (s *Server) Authenticate(token string) (string, error) {
+	claims, err := s.claims(token)
 	if err != nil {
-		return fmt.Errorf("could not parse token: %s", err.Error())
-	}
-
-	claims := jwt.Claims{}
-	if err := parsed.Claims(s.secret, &claims); err != nil {
-		return fmt.Errorf("could not get claims: %s", err.Error())
+		return "", err
 	}
 
-	if len(s.currentJTI) == 0 {
-		return fmt.Errorf("null jti")
+	sess, err := s.sessions.FindSessionByID(claims.ID)
+	if err != nil {
+		return "", fmt.Errorf("could not find session: %s", err.Error())
+	} else if sess == nil {
+		// The session.Session was revoked, or it expired and was cleaned up, or it never existed.
+		return "", fmt.Errorf("invalid claims: %s", jwt.ErrInvalidID.Error())
 	}
 
 	if err := claims.Validate(jwt.Expected{
-		Issuer:  "anwork",
-		Subject: "andrew",
-		Time:    s.clock.Now(),
-		ID:      s.currentJTI,
+		Issuer: "anwork",
+		Time:   s.clock.Now(),
+		ID:     sess.ID,
 	}); err != nil {
-		return fmt.Errorf("invalid claims: %s", err.Error())
+		return "", fmt.Errorf("invalid claims: %s", err.Error())
+	}
+
+	// The subject may be empty, which jwt.Expected does not check, so it is checked here.
+	if claims.Subject != sess.Subject {
+		return "", fmt.Errorf("invalid claims: %s", jwt.ErrInvalidSubject.Error())
 	}
 
-	return nil
+	return claims.Subject, nil
 }
 
-func (s *Server) Token() (string, error) {
+// Token generates an encrypted token for a subject, and starts its session.Session.
+func (s *Server) Token(subject string) (string, error) {
 	signer, err := signer(s.secret)
 	if err != nil {
 		return "", err
@@ -86,24 +107,112 @@ func (s *Server) Token() (string, error) {
 
 	// TODO: how big should this be?
 	r := make([]byte, 32)
-	if _, err := io.ReadFull(s.rand, r); err != nil {
+	s.randLock.Lock()
+	_, err = io.ReadFull(s.rand, r)
+	s.randLock.Unlock()
+	if err != nil {
 		return "", fmt.Errorf("could not get %d random bytes: %s", len(r), err.Error())
 	}
 
 	now := s.clock.Now()
-	s.currentJTI = hex.EncodeToString(r)
 	claims := jwt.Claims{
 		Issuer:    "anwork",
-		Subject:   "andrew",
+		Subject:   subject,
 		Expiry:    jwt.NewNumericDate(now.Add(time.Hour)),
 		NotBefore: jwt.NewNumericDate(now),
 		IssuedAt:  jwt.NewNumericDate(now),
-		ID:        s.currentJTI,
+		ID:        hex.EncodeToString(r),
 	}
 	token, err := jwt.SignedAndEncrypted(signer, encrypter).Claims(claims).CompactSerialize()
 	if err != nil {
 		return "", fmt.Errorf("could not sign and encrypt: %s", err.Error())
 	}
 
+	if err := s.sessions.CreateSession(&session.Session{
+		ID:       claims.ID,
+		Subject:  subject,
+		IssuedAt: claims.IssuedAt.Time().Unix(),
+		Expiry:   claims.Expiry.Time().Unix(),
+	}); err != nil {
+		return "", fmt.Errorf("could not start session: %s", err.Error())
+	}
+
 	return token, nil
 }
+
+// Revoke ends the session.Session of a decrypted token, e.g., when its user
+// logs out, so that the token is no longer valid.
+func (s *Server) Revoke(token string) error {
+	claims, err := s.claims(token)
+	if err != nil {
+		return err
+	}
+
+	return s.sessions.DeleteSession(&session.Session{ID: claims.ID})
+}
+
+// Sessions returns the session.Session's that have not expired.
+func (s *Server) Sessions() ([]*session.Session, error) {
+	sessions, err := s.sessions.Sessions()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now().Unix()
+	active := make([]*session.Session, 0, len(sessions))
+	for _, sess := range sessions {
+		if !sess.Expired(now) {
+			active = append(active, sess)
+		}
+	}
+	return active, nil
+}
+
+// RevokeSession ends the session.Session with an ID, so that its token is no
+// longer valid. If there is no such session.Session, it returns nil.
+func (s *Server) RevokeSession(id string) error {
+	return s.sessions.DeleteSession(&session.Session{ID: id})
+}
+
+// Cleaner returns an ifrit.Runner that deletes the expired session.Session's
+// each time that an interval passes on the clock of the Server, until it is
+// signaled.
+func (s *Server) Cleaner(logger lager.Logger, interval time.Duration) ifrit.Runner {
+	return ifrit.RunFunc(func(signals <-chan os.Signal, ready chan<- struct{}) error {
+		ticker := s.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		close(ready)
+		for {
+			select {
+			case <-ticker.C():
+				now := s.clock.Now().Unix()
+				if err := s.sessions.DeleteExpiredSessions(now); err != nil {
+					// The expired session.Session's are no longer valid anyway, so the
+					// Server keeps going, and tries again next time.
+					logger.Error("delete-expired-sessions", err)
+				} else {
+					logger.Debug("deleted-expired-sessions", lager.Data{"now": now})
+				}
+			case <-signals:
+				return nil
+			}
+		}
+	})
+}
+
+// claims returns the claims of a decrypted token, which have been verified
+// with the secret, but not yet validated.
+func (s *Server) claims(token string) (*jwt.Claims, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse token: %s", err.Error())
+	}
+
+	claims := jwt.Claims{}
+	if err := parsed.Claims(s.secret, &claims); err != nil {
+		return nil, fmt.Errorf("could not get claims: %s", err.Error())
+	}
+
+	return &claims, nil
+}
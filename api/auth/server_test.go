@@ -1,15 +1,21 @@
 package auth_test
 
 import (
+	"crypto/rand"
 	"crypto/rsa"
 	"encoding/hex"
 	"fmt"
+	"os"
 	"time"
 
 	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
 	"github.com/ankeesler/anwork/api/auth"
+	"github.com/ankeesler/anwork/session"
+	"github.com/ankeesler/anwork/task/memory"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
 	"gopkg.in/square/go-jose.v2/jwt"
 )
 
@@ -21,6 +27,7 @@ var _ = Describe("Server", func() {
 
 		publicKey *rsa.PublicKey
 		secret    []byte
+		sessions  session.Repo
 	)
 
 	BeforeEach(func() {
@@ -30,28 +37,101 @@ var _ = Describe("Server", func() {
 
 		publicKey = getPublicKey()
 		secret = getSecret()
+		sessions = memory.New().(session.Repo)
 
-		s = auth.NewServer(clock, rand, publicKey, secret)
+		s = auth.NewServer(clock, rand, publicKey, secret, sessions)
 	})
 
+	// decrypt returns the token that a Client would send back for an encrypted token.
+	decrypt := func(token string) string {
+		return generateValidTokenWithClaims(secret, parseClaims(token, getPrivateKey(), secret))
+	}
+
 	Describe("Authenticate", func() {
 		Context("when Token() has been called", func() {
 			BeforeEach(func() {
-				_, err := s.Token() // generate token to set currentJTI
+				_, err := s.Token("andrew") // generate token to set currentJTI
 				Expect(err).NotTo(HaveOccurred())
 			})
 
-			It("returns no error on a valid token", func() {
+			It("returns the subject of a valid token", func() {
 				validToken := generateValidToken(secret)
-				Expect(s.Authenticate(validToken)).To(Succeed())
+				Expect(s.Authenticate(validToken)).To(Equal("andrew"))
+			})
+
+			Context("when the session of the token has been revoked", func() {
+				BeforeEach(func() {
+					Expect(s.Revoke(generateValidToken(secret))).To(Succeed())
+				})
+
+				It("returns an error", func() {
+					_, err := s.Authenticate(generateValidToken(secret))
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("ID claim (jti)"))
+				})
+			})
+		})
+
+		Context("when the token was generated for no subject", func() {
+			BeforeEach(func() {
+				_, err := s.Token("")
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("returns an error on a token with a subject", func() {
+				_, err := s.Authenticate(generateValidToken(secret))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("subject claim (sub)"))
 			})
 		})
 
 		Context("when Token() has not been called yet", func() {
 			It("returns an error", func() {
 				validToken := generateValidToken(secret)
-				err := s.Authenticate(validToken)
-				Expect(err).To(MatchError("null jti"))
+				_, err := s.Authenticate(validToken)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("ID claim (jti)"))
+			})
+		})
+
+		Context("when many tokens have been generated", func() {
+			BeforeEach(func() {
+				s = auth.NewServer(clock, rand.Reader, publicKey, secret, sessions)
+			})
+
+			It("accepts each of them, even from many goroutines at once", func() {
+				tokens := make(chan string, 10)
+				for i := 0; i < cap(tokens); i++ {
+					go func(i int) {
+						defer GinkgoRecover()
+						token, err := s.Token(fmt.Sprintf("user-%d", i))
+						Expect(err).NotTo(HaveOccurred())
+						tokens <- token
+					}(i)
+				}
+
+				subjects := []string{}
+				for i := 0; i < cap(tokens); i++ {
+					subject, err := s.Authenticate(decrypt(<-tokens))
+					Expect(err).NotTo(HaveOccurred())
+					subjects = append(subjects, subject)
+				}
+				Expect(subjects).To(HaveLen(10))
+				Expect(subjects).To(ContainElement("user-0"))
+				Expect(subjects).To(ContainElement("user-9"))
+			})
+
+			It("only rejects the tokens whose sessions have been revoked", func() {
+				tokenA, err := s.Token("user-a")
+				Expect(err).NotTo(HaveOccurred())
+				tokenB, err := s.Token("user-b")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(s.Revoke(decrypt(tokenA))).To(Succeed())
+
+				_, err = s.Authenticate(decrypt(tokenA))
+				Expect(err).To(HaveOccurred())
+				Expect(s.Authenticate(decrypt(tokenB))).To(Equal("user-b"))
 			})
 		})
 
@@ -59,7 +139,7 @@ var _ = Describe("Server", func() {
 			It("returns an error", func() {
 				unencryptedToken := generateEncryptedToken(publicKey, secret)
 
-				err := s.Authenticate(unencryptedToken)
+				_, err := s.Authenticate(unencryptedToken)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(HavePrefix("could not parse token:"))
 			})
@@ -70,7 +150,7 @@ var _ = Describe("Server", func() {
 				wrongSecret := getWrongSecret()
 				wrongKeyToken := generateValidToken(wrongSecret)
 
-				err := s.Authenticate(wrongKeyToken)
+				_, err := s.Authenticate(wrongKeyToken)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(HavePrefix("could not get claims"))
 			})
@@ -80,14 +160,14 @@ var _ = Describe("Server", func() {
 			testInvalidClaim := func(which string, invalidateClaimsFunc func(*jwt.Claims)) {
 				Context(fmt.Sprintf("%s is wrong", which), func() {
 					It("returns an error", func() {
-						_, err := s.Token() // generate token to set currentJTI
+						_, err := s.Token("andrew") // generate token to set currentJTI
 						Expect(err).NotTo(HaveOccurred())
 
 						claims := generateValidClaims()
 						invalidateClaimsFunc(&claims)
 						token := generateValidTokenWithClaims(secret, claims)
 
-						err = s.Authenticate(token)
+						_, err = s.Authenticate(token)
 						Expect(err).To(HaveOccurred())
 						Expect(err.Error()).To(HavePrefix("invalid claims"))
 						Expect(err.Error()).To(ContainSubstring(which))
@@ -117,9 +197,68 @@ var _ = Describe("Server", func() {
 		})
 	})
 
+	Describe("Sessions", func() {
+		It("returns the sessions that have not expired", func() {
+			Expect(sessions.CreateSession(&session.Session{
+				ID:     "expired",
+				Expiry: clock.Now().Unix(),
+			})).To(Succeed())
+
+			_, err := s.Token("andrew")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(s.Sessions()).To(Equal([]*session.Session{
+				&session.Session{
+					ID:       hex.EncodeToString([]byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")),
+					Subject:  "andrew",
+					IssuedAt: clock.Now().Unix(),
+					Expiry:   clock.Now().Add(time.Hour).Unix(),
+				},
+			}))
+		})
+	})
+
+	Describe("RevokeSession", func() {
+		It("revokes the session with the ID", func() {
+			_, err := s.Token("andrew")
+			Expect(err).NotTo(HaveOccurred())
+
+			id := hex.EncodeToString([]byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"))
+			Expect(s.RevokeSession(id)).To(Succeed())
+			Expect(s.Sessions()).To(BeEmpty())
+
+			_, err = s.Authenticate(generateValidToken(secret))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("Cleaner", func() {
+		var process ifrit.Process
+
+		BeforeEach(func() {
+			process = ifrit.Invoke(s.Cleaner(lagertest.NewTestLogger("auth"), time.Minute))
+		})
+
+		AfterEach(func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+		})
+
+		It("deletes the expired sessions each interval", func() {
+			_, err := s.Token("andrew")
+			Expect(err).NotTo(HaveOccurred())
+
+			clock.WaitForWatcherAndIncrement(time.Minute)
+			Consistently(sessions.Sessions).Should(HaveLen(1))
+
+			clock.Increment(time.Hour)
+			Eventually(sessions.Sessions).Should(BeEmpty())
+		})
+	})
+
 	Describe("Token", func() {
 		It("returns an encrypted and signed token with the correct claims", func() {
-			token, err := s.Token()
+			token, err := s.Token("andrew")
 			Expect(err).NotTo(HaveOccurred())
 
 			privateKey := getPrivateKey()
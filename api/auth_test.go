@@ -9,7 +9,10 @@ import (
 	"code.cloudfoundry.org/lager/lagertest"
 	"github.com/ankeesler/anwork/api"
 	"github.com/ankeesler/anwork/api/apifakes"
+	taskpkg "github.com/ankeesler/anwork/task"
 	"github.com/ankeesler/anwork/task/taskfakes"
+	"github.com/ankeesler/anwork/user"
+	"github.com/ankeesler/anwork/user/userfakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/tedsuo/ifrit"
@@ -20,6 +23,7 @@ var _ = Describe("Tasks", func() {
 	var (
 		repo          *taskfakes.FakeRepo
 		authenticator *apifakes.FakeAuthenticator
+		options       []api.Option
 
 		process ifrit.Process
 	)
@@ -27,8 +31,11 @@ var _ = Describe("Tasks", func() {
 	BeforeEach(func() {
 		repo = &taskfakes.FakeRepo{}
 		authenticator = &apifakes.FakeAuthenticator{}
+		options = nil
+	})
 
-		a := api.New(lagertest.NewTestLogger("api"), repo, authenticator)
+	JustBeforeEach(func() {
+		a := api.New(lagertest.NewTestLogger("api"), repo, authenticator, options...)
 		runner := http_server.New("127.0.0.1:12345", a)
 		process = ifrit.Invoke(runner)
 	})
@@ -55,6 +62,118 @@ var _ = Describe("Tasks", func() {
 			Expect(token).To(Equal(api.Auth{Token: "here is a token"}))
 
 			Expect(authenticator.TokenCallCount()).To(Equal(1))
+			Expect(authenticator.TokenArgsForCall(0)).To(Equal(""))
+		})
+
+		Context("when credentials are sent", func() {
+			It("returns a 501", func() {
+				rsp, err := post("/api/v1/auth", api.Credentials{Name: "user-a", Password: "some-password"})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusNotImplemented))
+				assertError(rsp, "users are not supported by this API")
+
+				Expect(authenticator.TokenCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the API has users", func() {
+			var users *userfakes.FakeRepo
+
+			BeforeEach(func() {
+				u := &user.User{Name: "user-a"}
+				Expect(u.SetPassword("some-password")).To(Succeed())
+
+				users = &userfakes.FakeRepo{}
+				users.UsersReturns([]*user.User{u}, nil)
+				users.FindUserByNameStub = func(name string) (*user.User, error) {
+					if name == "user-a" {
+						return u, nil
+					}
+					return nil, nil
+				}
+				options = append(options, api.WithUsers(users))
+			})
+
+			It("returns a token for the user", func() {
+				rsp, err := post("/api/v1/auth", api.Credentials{Name: "user-a", Password: "some-password"})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+
+				var token api.Auth
+				Expect(json.NewDecoder(rsp.Body).Decode(&token)).To(Succeed())
+				Expect(token).To(Equal(api.Auth{Token: "here is a token"}))
+
+				Expect(authenticator.TokenCallCount()).To(Equal(1))
+				Expect(authenticator.TokenArgsForCall(0)).To(Equal("user-a"))
+			})
+
+			It("returns a 401 on the wrong password", func() {
+				rsp, err := post("/api/v1/auth", api.Credentials{Name: "user-a", Password: "wrong-password"})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusUnauthorized))
+				assertError(rsp, "invalid name or password")
+
+				Expect(authenticator.TokenCallCount()).To(Equal(0))
+			})
+
+			It("returns a 401 for an unknown user", func() {
+				rsp, err := post("/api/v1/auth", api.Credentials{Name: "user-b", Password: "some-password"})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusUnauthorized))
+				assertError(rsp, "invalid name or password")
+			})
+
+			It("returns a 401 without credentials", func() {
+				rsp, err := post("/api/v1/auth", nil)
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusUnauthorized))
+				assertError(rsp, "missing user (log in with a name and password)")
+
+				Expect(authenticator.TokenCallCount()).To(Equal(0))
+			})
+
+			It("accepts a token for the user", func() {
+				authenticator.AuthenticateReturns("user-a", nil)
+				repo.TasksReturns([]*taskpkg.Task{}, nil)
+
+				rsp, err := get("/api/v1/tasks")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+			})
+
+			It("rejects a token for no one in particular", func() {
+				authenticator.AuthenticateReturns("", nil)
+
+				rsp, err := get("/api/v1/tasks")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusUnauthorized))
+				assertError(rsp, "missing user (log in with a name and password)")
+			})
+
+			It("rejects a token for a user who no longer exists", func() {
+				authenticator.AuthenticateReturns("user-b", nil)
+
+				rsp, err := get("/api/v1/tasks")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusForbidden))
+				assertError(rsp, "unknown user 'user-b'")
+			})
 		})
 
 		Context("when the authenticator fails", func() {
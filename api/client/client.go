@@ -2,10 +2,15 @@ package client
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/query"
 	"github.com/ankeesler/anwork/task"
 )
 
@@ -36,21 +41,46 @@ type client struct {
 	tokenCache    Cache
 
 	address string
+	// This is the context whose task.Repo the client uses, or "" for the task.Repo of the API.
+	context string
+
+	// These are copies of the task.Task's that the client last read or wrote, by ID, so that
+	// UpdateTask can send only the fields that the caller changed.
+	read     map[int]task.Task
+	readLock sync.Mutex
 }
 
-// New returns a new API client pointed at an ANWORK API address.
+// An Option configures optional behavior of the task.Repo returned from New.
+type Option func(*client)
+
+// WithContext sets the context whose task.Repo the client uses, i.e., the client uses the
+// /api/v1/contexts/:context endpoints. By default, the client uses the task.Repo of the API.
+func WithContext(context string) Option {
+	return func(c *client) {
+		c.context = context
+	}
+}
+
+// New returns a new API client pointed at an ANWORK API address. It is also a webhook.Repo, which
+// stores the webhook.Webhook's through the /api/v1/webhooks endpoints.
 func New(
 	logger lager.Logger,
 	address string,
 	authenticator Authenticator,
 	cache Cache,
+	options ...Option,
 ) task.Repo {
-	return &client{
+	c := &client{
 		logger:        logger,
 		address:       address,
 		authenticator: authenticator,
 		tokenCache:    cache,
+		read:          make(map[int]task.Task),
+	}
+	for _, option := range options {
+		option(c)
 	}
+	return c
 }
 
 func (c *client) CreateTask(task *task.Task) error {
@@ -63,16 +93,33 @@ func (c *client) CreateTask(task *task.Task) error {
 	if location == "" || !parseID(location, &task.ID) {
 		return fmt.Errorf("could not parse ID from Location response header: %s", location)
 	}
+	parseETag(rsp.Header.Get("ETag"), &task.Version)
+	c.remember(task)
 
 	return nil
 }
 
 func (c *client) Tasks() ([]*task.Task, error) {
-	tasks := make([]*task.Task, 10)
+	tasks := make([]*task.Task, 0)
 	if err := c.do(http.MethodGet, c.tasksURL(), nil, &tasks); err != nil {
 		return nil, err
 	}
 
+	c.remember(tasks...)
+	return tasks, nil
+}
+
+// TasksMatching implements query.Repo so that the API server finds the tasks that match the
+// query.Query.
+func (c *client) TasksMatching(q *query.Query) ([]*task.Task, error) {
+	tasks := make([]*task.Task, 0)
+
+	url := fmt.Sprintf("%s?q=%s", c.tasksURL(), url.QueryEscape(q.String()))
+	if err := c.do(http.MethodGet, url, nil, &tasks); err != nil {
+		return nil, err
+	}
+
+	c.remember(tasks...)
 	return tasks, nil
 }
 
@@ -85,6 +132,7 @@ func (c *client) FindTaskByID(id int) (*task.Task, error) {
 	} else if err != nil {
 		return nil, err
 	} else {
+		c.remember(&task)
 		return &task, nil
 	}
 }
@@ -100,18 +148,58 @@ func (c *client) FindTaskByName(name string) (*task.Task, error) {
 	if len(tasks) == 0 {
 		return nil, nil
 	} else {
+		c.remember(tasks[0])
 		return tasks[0], nil
 	}
 }
 
+// UpdateTask only sends the fields of the task.Task that the caller changed since the client read
+// it, as a JSON merge patch, so that it does not undo the changes that someone else made to the
+// other fields. It only updates the task.Task if it has not been changed since its
+// task.Task.Version was read. Otherwise, it returns a *ConflictError.
 func (c *client) UpdateTask(task *task.Task) error {
-	return c.do(http.MethodPut, c.taskURL(task.ID), task, nil)
+	read, ok := c.recall(task.ID)
+	if !ok || (task.Version != 0 && task.Version != read.Version) {
+		current, err := c.FindTaskByID(task.ID)
+		if err != nil {
+			return err
+		} else if current == nil {
+			return fmt.Errorf("unknown task with ID %d", task.ID)
+		} else if task.Version != 0 && task.Version != current.Version {
+			return &ConflictError{Name: task.Name, ID: task.ID, Version: task.Version}
+		}
+		read = current
+	}
+
+	patch, err := mergePatch(read, task)
+	if err != nil {
+		return err
+	}
+
+	header := ifMatch(task.Version)
+	header.Set("Content-Type", api.MergePatchContentType)
+	rsp, err := c.doWithHeader(http.MethodPatch, c.taskURL(task.ID), header, patch, task)
+	if rsp != nil && rsp.StatusCode == http.StatusPreconditionFailed {
+		return &ConflictError{Name: task.Name, ID: task.ID, Version: task.Version}
+	} else if err != nil {
+		return err
+	}
+
+	c.remember(task)
+	return nil
 }
 
+// DeleteTask only deletes the task.Task if it has not been changed since its task.Task.Version was
+// read. Otherwise, it returns a *ConflictError.
 func (c *client) DeleteTask(task *task.Task) error {
-	rsp, err := c.doExt(http.MethodDelete, c.taskURL(task.ID), nil, nil)
+	rsp, err := c.doWithHeader(http.MethodDelete, c.taskURL(task.ID), ifMatch(task.Version), nil, nil)
+	if err == nil || (rsp != nil && rsp.StatusCode == http.StatusNotFound) {
+		c.forget(task.ID)
+	}
 	if rsp != nil && rsp.StatusCode == http.StatusNotFound {
 		return nil
+	} else if rsp != nil && rsp.StatusCode == http.StatusPreconditionFailed {
+		return &ConflictError{Name: task.Name, ID: task.ID, Version: task.Version}
 	} else {
 		return err
 	}
@@ -132,7 +220,7 @@ func (c *client) CreateEvent(event *task.Event) error {
 }
 
 func (c *client) Events() ([]*task.Event, error) {
-	events := make([]*task.Event, 10)
+	events := make([]*task.Event, 0)
 	if err := c.do(http.MethodGet, c.eventsURL(), nil, &events); err != nil {
 		return nil, err
 	}
@@ -140,6 +228,41 @@ func (c *client) Events() ([]*task.Event, error) {
 	return events, nil
 }
 
+// EventsMatching implements query.EventRepo so that the API server finds the events that the
+// query.EventQuery selects.
+func (c *client) EventsMatching(q *query.EventQuery) ([]*task.Event, error) {
+	values := url.Values{}
+	if q.TaskID != nil {
+		values.Set("task_id", strconv.Itoa(*q.TaskID))
+	}
+	for _, t := range q.Types {
+		values.Add("type", strconv.Itoa(int(t)))
+	}
+	if q.Since != 0 {
+		values.Set("since", strconv.FormatInt(q.Since, 10))
+	}
+	if q.Until != 0 {
+		values.Set("until", strconv.FormatInt(q.Until, 10))
+	}
+	if q.Cursor != nil {
+		values.Set("cursor", strconv.Itoa(*q.Cursor))
+	}
+	if q.Limit != 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if q.Order != "" {
+		values.Set("order", string(q.Order))
+	}
+
+	events := make([]*task.Event, 0)
+	url := fmt.Sprintf("%s?%s", c.eventsURL(), values.Encode())
+	if err := c.do(http.MethodGet, url, nil, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
 func (c *client) FindEventByID(id int) (*task.Event, error) {
 	var event task.Event
 
@@ -162,20 +285,121 @@ func (c *client) DeleteEvent(event *task.Event) error {
 	}
 }
 
+func (c *client) CreateDependency(dependency *task.Dependency) error {
+	rsp, err := c.doExt(http.MethodPost, c.dependenciesURL(), dependency, nil)
+	if err != nil {
+		return err
+	}
+
+	location := rsp.Header.Get("Location")
+	if location == "" || !parseID(location, &dependency.ID) {
+		return fmt.Errorf("could not parse ID from Location response header: %s", location)
+	}
+
+	return nil
+}
+
+func (c *client) Dependencies() ([]*task.Dependency, error) {
+	dependencies := make([]*task.Dependency, 0)
+	if err := c.do(http.MethodGet, c.dependenciesURL(), nil, &dependencies); err != nil {
+		return nil, err
+	}
+
+	return dependencies, nil
+}
+
+func (c *client) FindDependencyByID(id int) (*task.Dependency, error) {
+	var dependency task.Dependency
+
+	rsp, err := c.doExt(http.MethodGet, c.dependencyURL(id), nil, &dependency)
+	if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else {
+		return &dependency, nil
+	}
+}
+
+func (c *client) DeleteDependency(dependency *task.Dependency) error {
+	rsp, err := c.doExt(http.MethodDelete, c.dependencyURL(dependency.ID), nil, nil)
+	if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+		return nil
+	} else {
+		return err
+	}
+}
+
+// remember keeps a copy of each task.Task that the client read or wrote.
+func (c *client) remember(tasks ...*task.Task) {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	for _, t := range tasks {
+		read := *t
+		read.Tags = append([]string(nil), t.Tags...)
+		read.SharedWith = append([]string(nil), t.SharedWith...)
+		c.read[t.ID] = read
+	}
+}
+
+// recall returns the copy of the task.Task with an ID that the client last read or wrote, if there
+// is one.
+func (c *client) recall(id int) (*task.Task, bool) {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	read, ok := c.read[id]
+	return &read, ok
+}
+
+// forget forgets the task.Task with an ID, e.g., after it is deleted.
+func (c *client) forget(id int) {
+	c.readLock.Lock()
+	defer c.readLock.Unlock()
+
+	delete(c.read, id)
+}
+
+// repoURL returns the URL of the API endpoints that use the task.Repo of the context of the
+// client, e.g., http://some-address/api/v1/contexts/some-context.
+func (c *client) repoURL() string {
+	if c.context == "" {
+		return fmt.Sprintf("http://%s/api/v1", c.address)
+	}
+	return c.contextURL(c.context)
+}
+
 func (c *client) tasksURL() string {
-	return fmt.Sprintf("http://%s/api/v1/tasks", c.address)
+	return fmt.Sprintf("%s/tasks", c.repoURL())
 }
 
 func (c *client) taskURL(id int) string {
-	return fmt.Sprintf("http://%s/api/v1/tasks/%d", c.address, id)
+	return fmt.Sprintf("%s/tasks/%d", c.repoURL(), id)
 }
 
 func (c *client) eventsURL() string {
-	return fmt.Sprintf("http://%s/api/v1/events", c.address)
+	return fmt.Sprintf("%s/events", c.repoURL())
 }
 
 func (c *client) eventURL(id int) string {
-	return fmt.Sprintf("http://%s/api/v1/events/%d", c.address, id)
+	return fmt.Sprintf("%s/events/%d", c.repoURL(), id)
+}
+
+func (c *client) dependenciesURL() string {
+	return fmt.Sprintf("%s/dependencies", c.repoURL())
+}
+
+func (c *client) dependencyURL(id int) string {
+	return fmt.Sprintf("%s/dependencies/%d", c.repoURL(), id)
+}
+
+func (c *client) contextsURL() string {
+	return fmt.Sprintf("http://%s/api/v1/contexts", c.address)
+}
+
+func (c *client) contextURL(name string) string {
+	return fmt.Sprintf("%s/%s", c.contextsURL(), url.PathEscape(name))
 }
 
 func (c *client) authURL() string {
@@ -188,6 +412,15 @@ func (c *client) do(method, url string, input interface{}, output interface{}) e
 }
 
 func (c *client) doExt(method, url string, input interface{}, output interface{}) (*http.Response, error) {
+	return c.doWithHeader(method, url, http.Header{}, input, output)
+}
+
+func (c *client) doWithHeader(
+	method, url string,
+	header http.Header,
+	input interface{},
+	output interface{},
+) (*http.Response, error) {
 	body, err := encodeBody(input)
 	if err != nil {
 		return nil, err
@@ -197,9 +430,10 @@ func (c *client) doExt(method, url string, input interface{}, output interface{}
 	if err != nil {
 		return nil, err
 	}
+	req.Header = header
 
-	if input != nil {
-		req.Header.Add("Content-Type", "application/json")
+	if input != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
 	}
 	if output != nil {
 		req.Header.Add("Accept", "application/json")
@@ -219,7 +453,9 @@ func (c *client) doExt(method, url string, input interface{}, output interface{}
 	defer rsp.Body.Close()
 	c.logger.Debug("response", lager.Data{"status": rsp.Status})
 
-	if is5xxStatus(rsp) {
+	if rsp.StatusCode == http.StatusNotImplemented {
+		return rsp, &NotSupportedError{Message: decodeError(rsp.Body)}
+	} else if is5xxStatus(rsp) {
 		return rsp, &badResponseError{code: rsp.Status, message: decodeError(rsp.Body)}
 	} else if is4xxStatus(rsp) {
 		return rsp, &badResponseError{code: rsp.Status}
@@ -228,6 +464,26 @@ func (c *client) doExt(method, url string, input interface{}, output interface{}
 	return rsp, decodeBody(rsp.Body, output)
 }
 
+// Login logs in to the ANWORK API at an address as the user.User with a name and password, and
+// caches their token, so that the clients that use the same Cache make their requests as that
+// user.User.
+func Login(
+	logger lager.Logger,
+	address string,
+	authenticator Authenticator,
+	cache Cache,
+	name, password string,
+) error {
+	c := New(logger, address, authenticator, cache).(*client)
+	encryptedToken, _, err := c.reallyGetToken(&api.Credentials{Name: name, Password: password})
+	if err != nil {
+		return err
+	}
+	cache.Set(encryptedToken)
+
+	return nil
+}
+
 func (c *client) getToken() (string, error) {
 	if encryptedToken, ok := c.tokenCache.Get(); ok {
 		if decryptedToken, err := c.authenticator.Validate(encryptedToken); err != nil {
@@ -239,7 +495,7 @@ func (c *client) getToken() (string, error) {
 		c.logger.Debug("token-cache-miss")
 	}
 
-	encryptedToken, decryptedToken, err := c.reallyGetToken()
+	encryptedToken, decryptedToken, err := c.reallyGetToken(nil)
 	if err != nil {
 		return "", err
 	}
@@ -248,12 +504,25 @@ func (c *client) getToken() (string, error) {
 	return decryptedToken, nil
 }
 
-func (c *client) reallyGetToken() (string, string, error) {
-	req, err := http.NewRequest(http.MethodPost, c.authURL(), nil)
+// reallyGetToken gets a new token from the API, for the user.User with some Credentials, or for
+// no one in particular if they are nil.
+func (c *client) reallyGetToken(credentials *api.Credentials) (string, string, error) {
+	var body io.Reader
+	if credentials != nil {
+		var err error
+		if body, err = encodeBody(credentials); err != nil {
+			return "", "", err
+		}
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.authURL(), body)
 	if err != nil {
 		return "", "", err
 	}
 
+	if credentials != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	req.Header.Add("Accept", "application/json")
 
 	c.logger.Debug("request", lager.Data{"method": req.Method, "url": req.URL})
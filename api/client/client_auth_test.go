@@ -293,4 +293,46 @@ var _ = Describe("Client (auth)", func() {
 			})
 		})
 	})
+
+	Describe("Login", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/api/v1/auth"),
+				ghttp.VerifyJSONRepresenting(api.Credentials{Name: "user-a", Password: "some-password"}),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, api.Auth{Token: "some-encrypted-token"}),
+			))
+
+			authenticator.ValidateReturns("some-token", nil)
+		})
+
+		It("gets a token for the user and caches it", func() {
+			Expect(clientpkg.Login(
+				makeLogger(),
+				server.Addr(),
+				authenticator,
+				cache,
+				"user-a",
+				"some-password",
+			)).To(Succeed())
+
+			Expect(authenticator.ValidateArgsForCall(0)).To(Equal("some-encrypted-token"))
+			Expect(cache.SetCallCount()).To(Equal(1))
+			Expect(cache.SetArgsForCall(0)).To(Equal("some-encrypted-token"))
+		})
+
+		Context("when the credentials are wrong", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.RespondWithJSONEncoded(
+					http.StatusUnauthorized,
+					api.Error{Message: "invalid name or password"},
+				))
+			})
+
+			It("returns the error and does not cache anything", func() {
+				err := clientpkg.Login(makeLogger(), server.Addr(), authenticator, cache, "user-a", "wrong")
+				Expect(err).To(MatchError(ContainSubstring("invalid name or password")))
+				Expect(cache.SetCallCount()).To(Equal(0))
+			})
+		})
+	})
 })
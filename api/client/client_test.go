@@ -6,6 +6,7 @@ import (
 	"github.com/ankeesler/anwork/api"
 	clientpkg "github.com/ankeesler/anwork/api/client"
 	"github.com/ankeesler/anwork/api/client/clientfakes"
+	"github.com/ankeesler/anwork/query"
 	taskpkg "github.com/ankeesler/anwork/task"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -20,8 +21,9 @@ var _ = Describe("Client", func() {
 		client taskpkg.Repo
 		server *ghttp.Server
 
-		tasks  []*taskpkg.Task
-		events []*taskpkg.Event
+		tasks        []*taskpkg.Task
+		events       []*taskpkg.Event
+		dependencies []*taskpkg.Dependency
 	)
 
 	testBadURL := func(clientFunc func(c taskpkg.Repo) error) {
@@ -180,6 +182,11 @@ var _ = Describe("Client", func() {
 			&taskpkg.Event{Title: "event-b", ID: 2},
 			&taskpkg.Event{Title: "event-c", ID: 3},
 		}
+		dependencies = []*taskpkg.Dependency{
+			&taskpkg.Dependency{TaskID: 1, DependsOnID: 2, ID: 1},
+			&taskpkg.Dependency{TaskID: 1, DependsOnID: 3, ID: 2},
+			&taskpkg.Dependency{TaskID: 2, DependsOnID: 3, ID: 3},
+		}
 	})
 
 	AfterEach(func() {
@@ -220,6 +227,18 @@ var _ = Describe("Client", func() {
 			Expect(cache.GetCallCount()).To(Equal(1))
 		})
 
+		It("sets the provided task's version from the ETag", func() {
+			server.SetHandler(0, ghttp.RespondWith(
+				http.StatusCreated,
+				nil,
+				http.Header{"Location": {"/api/v1/tasks/10"}, "ETag": {`"1"`}},
+			))
+
+			task := tasks[0]
+			Expect(client.CreateTask(task)).To(Succeed())
+			Expect(task.Version).To(Equal(1))
+		})
+
 		Context("when the returned location is invalid", func() {
 			BeforeEach(func() {
 				server.SetHandler(0, ghttp.CombineHandlers(
@@ -286,6 +305,43 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("TasksMatching", func() {
+		var q *query.Query
+		BeforeEach(func() {
+			var err error
+			q, err = query.Parse(`tag:infra name~"deploy service"`)
+			Expect(err).NotTo(HaveOccurred())
+
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(
+					http.MethodGet,
+					"/api/v1/tasks",
+					"q=tag%3Ainfra+name~%22deploy+service%22",
+				),
+				ghttp.VerifyHeaderKV("Accept", "application/json"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWithJSONEncoded(
+					http.StatusOK,
+					tasks[:1],
+					http.Header{"Content-Type": {"application/json"}},
+				),
+			))
+		})
+
+		It("gets the tasks that match the query from the server", func() {
+			rspTasks, err := client.(query.Repo).TasksMatching(q)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rspTasks).To(Equal(tasks[:1]))
+
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		testAllCommonFailures(func(c taskpkg.Repo) error {
+			_, err := c.(query.Repo).TasksMatching(q)
+			return err
+		})
+	})
+
 	Describe("FindTaskByID", func() {
 		BeforeEach(func() {
 			server.AppendHandlers(ghttp.CombineHandlers(
@@ -398,25 +454,136 @@ var _ = Describe("Client", func() {
 	Describe("UpdateTask", func() {
 		var task taskpkg.Task
 		BeforeEach(func() {
+			authenticator.ValidateReturns("some-token", nil)
+			cache.GetReturns("some-cached-token", true)
+
+			tasks[0].Priority = 5
+			tasks[0].Tags = []string{"tag-a"}
 			task = *tasks[0]
 			task.Name = "updated-task-a"
+			task.Tags = nil
 
-			server.AppendHandlers(ghttp.CombineHandlers(
-				ghttp.VerifyRequest(http.MethodPut, "/api/v1/tasks/1"),
-				ghttp.VerifyHeaderKV("Content-Type", "application/json"),
-				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
-				ghttp.VerifyJSONRepresenting(task),
-				ghttp.RespondWith(http.StatusNoContent, nil),
-			))
+			updatedTask := task
+			updatedTask.Version = 1
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodGet, "/api/v1/tasks/1"),
+					ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, tasks[0]),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPatch, "/api/v1/tasks/1"),
+					ghttp.VerifyHeaderKV("Content-Type", "application/merge-patch+json"),
+					ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+					ghttp.VerifyBody([]byte(`{"name":"updated-task-a","tags":null}`)),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, updatedTask),
+				),
+			)
 		})
 
-		It("updates a task", func() {
+		It("only sends the fields of the task that changed", func() {
 			err := client.UpdateTask(&task)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(server.ReceivedRequests()).To(HaveLen(1))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+			Expect(server.ReceivedRequests()[1].Header.Get("If-Match")).To(BeEmpty())
+		})
 
-			Expect(cache.GetCallCount()).To(Equal(1))
+		It("sets the task to the one that the API responds with", func() {
+			Expect(client.UpdateTask(&task)).To(Succeed())
+			Expect(task.Version).To(Equal(1))
+		})
+
+		Context("when the client has already read the task", func() {
+			It("only sends the fields that changed since then, without reading it again", func() {
+				read, err := client.FindTaskByID(1)
+				Expect(err).NotTo(HaveOccurred())
+
+				read.Name = "updated-task-a"
+				read.Tags = nil
+				Expect(client.UpdateTask(read)).To(Succeed())
+
+				Expect(server.ReceivedRequests()).To(HaveLen(2))
+			})
+
+			It("sends the changes to the users with whom the task is shared", func() {
+				tasks[0].SharedWith = []string{"user-b"}
+				server.SetHandler(0, ghttp.RespondWithJSONEncoded(http.StatusOK, tasks[0]))
+				server.SetHandler(1, ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPatch, "/api/v1/tasks/1"),
+					ghttp.VerifyBody([]byte(`{"sharedWith":["user-c"]}`)),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, tasks[0]),
+				))
+
+				read, err := client.FindTaskByID(1)
+				Expect(err).NotTo(HaveOccurred())
+
+				read.SharedWith[0] = "user-c"
+				Expect(client.UpdateTask(read)).To(Succeed())
+
+				Expect(server.ReceivedRequests()).To(HaveLen(2))
+			})
+		})
+
+		Context("when the task does not exist", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.RespondWith(http.StatusNotFound, nil))
+			})
+
+			It("returns an error", func() {
+				Expect(client.UpdateTask(&task)).To(MatchError("unknown task with ID 1"))
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		Context("when the task has a version", func() {
+			BeforeEach(func() {
+				tasks[0].Version = 3
+				task.Version = 3
+				server.SetHandler(0, ghttp.RespondWithJSONEncoded(http.StatusOK, tasks[0]))
+				server.SetHandler(1, ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPatch, "/api/v1/tasks/1"),
+					ghttp.VerifyHeaderKV("If-Match", `"3"`),
+					ghttp.RespondWithJSONEncoded(
+						http.StatusOK,
+						taskpkg.Task{Name: "updated-task-a", ID: 1, Priority: 5, Version: 4},
+						http.Header{"ETag": {`"4"`}},
+					),
+				))
+			})
+
+			It("only updates that version of the task, and sets the new version", func() {
+				Expect(client.UpdateTask(&task)).To(Succeed())
+				Expect(task.Version).To(Equal(4))
+			})
+
+			Context("when someone else has changed the task before it is read", func() {
+				BeforeEach(func() {
+					tasks[0].Version = 4
+					server.SetHandler(0, ghttp.RespondWithJSONEncoded(http.StatusOK, tasks[0]))
+				})
+
+				It("returns a ConflictError without changing it", func() {
+					err := client.UpdateTask(&task)
+					Expect(err).To(Equal(&clientpkg.ConflictError{Name: "updated-task-a", ID: 1, Version: 3}))
+					Expect(task.Version).To(Equal(3))
+
+					Expect(server.ReceivedRequests()).To(HaveLen(1))
+				})
+			})
+
+			Context("when someone else has changed the task after it is read", func() {
+				BeforeEach(func() {
+					server.SetHandler(1, ghttp.RespondWith(http.StatusPreconditionFailed, nil))
+				})
+
+				It("returns a ConflictError", func() {
+					err := client.UpdateTask(&task)
+					Expect(err).To(Equal(&clientpkg.ConflictError{Name: "updated-task-a", ID: 1, Version: 3}))
+					Expect(err).To(MatchError("task 'updated-task-a' (ID 1) was changed by someone else after version 3 was read"))
+					Expect(task.Version).To(Equal(3))
+				})
+			})
 		})
 
 		testAllCommonFailures(func(c taskpkg.Repo) error {
@@ -442,6 +609,21 @@ var _ = Describe("Client", func() {
 			Expect(cache.GetCallCount()).To(Equal(1))
 		})
 
+		Context("when someone else has changed the task", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.VerifyHeaderKV("If-Match", `"2"`),
+					ghttp.RespondWith(http.StatusPreconditionFailed, nil),
+				))
+			})
+
+			It("returns a ConflictError", func() {
+				tasks[0].ID = 10
+				tasks[0].Version = 2
+				Expect(client.DeleteTask(tasks[0])).To(Equal(&clientpkg.ConflictError{Name: "task-a", ID: 10, Version: 2}))
+			})
+		})
+
 		testAllCommonFailures(func(c taskpkg.Repo) error {
 			tasks[0].ID = 10
 			return c.DeleteTask(tasks[0])
@@ -542,6 +724,50 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("EventsMatching", func() {
+		var q *query.EventQuery
+		BeforeEach(func() {
+			taskID, cursor := 3, 10
+			q = &query.EventQuery{
+				TaskID: &taskID,
+				Types:  []taskpkg.EventType{taskpkg.EventTypeCreate, taskpkg.EventTypeNote},
+				Since:  100,
+				Until:  200,
+				Cursor: &cursor,
+				Limit:  5,
+				Order:  query.OrderDescending,
+			}
+
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(
+					http.MethodGet,
+					"/api/v1/events",
+					"cursor=10&limit=5&order=desc&since=100&task_id=3&type=0&type=3&until=200",
+				),
+				ghttp.VerifyHeaderKV("Accept", "application/json"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWithJSONEncoded(
+					http.StatusOK,
+					events[:1],
+					http.Header{"Content-Type": {"application/json"}},
+				),
+			))
+		})
+
+		It("gets the events that the query selects from the server", func() {
+			rspEvents, err := client.(query.EventRepo).EventsMatching(q)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rspEvents).To(Equal(events[:1]))
+
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		testAllCommonFailures(func(c taskpkg.Repo) error {
+			_, err := c.(query.EventRepo).EventsMatching(q)
+			return err
+		})
+	})
+
 	Describe("FindEventByID", func() {
 		BeforeEach(func() {
 			server.AppendHandlers(ghttp.CombineHandlers(
@@ -617,4 +843,198 @@ var _ = Describe("Client", func() {
 			return c.DeleteEvent(events[0])
 		})
 	})
+
+	Describe("CreateDependency", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/api/v1/dependencies"),
+				ghttp.VerifyJSONRepresenting(dependencies[0]),
+				ghttp.VerifyHeaderKV("Content-Type", "application/json"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWith(
+					http.StatusCreated,
+					nil,
+					http.Header{"Location": {"/api/v1/dependencies/10"}}),
+			))
+		})
+
+		It("POSTs to /api/v1/dependencies", func() {
+			dependency := dependencies[0]
+			Expect(client.CreateDependency(dependency)).To(Succeed())
+
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("sets the provided dependency's ID to the newly allocated ID", func() {
+			dependency := dependencies[0]
+			Expect(client.CreateDependency(dependency)).To(Succeed())
+
+			Expect(dependency.ID).To(Equal(10))
+
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		Context("when the returned location is invalid", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.VerifyRequest(http.MethodPost, "/api/v1/dependencies"),
+					ghttp.VerifyJSONRepresenting(dependencies[0]),
+					ghttp.VerifyHeaderKV("Content-Type", "application/json"),
+					ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+					ghttp.RespondWith(
+						http.StatusCreated,
+						nil,
+						http.Header{"Location": {"/api/v1/dependencies/tuna"}}),
+				))
+			})
+
+			It("returns an error", func() {
+				dependency := dependencies[0]
+				err := client.CreateDependency(dependency)
+				Expect(err).To(HaveOccurred())
+				Expect(err).To(MatchError("could not parse ID from Location response header: /api/v1/dependencies/tuna"))
+
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+			})
+		})
+
+		testAllCommonFailures(func(c taskpkg.Repo) error {
+			return c.CreateDependency(dependencies[0])
+		})
+	})
+
+	Describe("Dependencies", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/dependencies"),
+				ghttp.VerifyHeaderKV("Accept", "application/json"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWithJSONEncoded(
+					http.StatusOK,
+					dependencies,
+					http.Header{"Content-Type": {"application/json"}},
+				),
+			))
+		})
+
+		It("gets the dependencies from the server", func() {
+			rspDependencies, err := client.Dependencies()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(rspDependencies).To(Equal(dependencies))
+
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			Expect(cache.GetCallCount()).To(Equal(1))
+		})
+
+		testBad2xxResponseBody(func(c taskpkg.Repo) error {
+			_, err := c.Dependencies()
+			return err
+		})
+
+		testAllCommonFailures(func(c taskpkg.Repo) error {
+			_, err := c.Dependencies()
+			return err
+		})
+	})
+
+	Describe("FindDependencyByID", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/dependencies/10"),
+				ghttp.VerifyHeaderKV("Accept", "application/json"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWithJSONEncoded(
+					http.StatusOK,
+					dependencies[0],
+					http.Header{"Content-Type": {"application/json"}}),
+			))
+		})
+
+		It("gets the dependency by ID", func() {
+			dependency, err := client.FindDependencyByID(10)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dependency).To(Equal(dependencies[0]))
+
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			Expect(cache.GetCallCount()).To(Equal(1))
+		})
+
+		Context("on 404 not found response", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.CombineHandlers(
+					ghttp.RespondWith(http.StatusNotFound, nil),
+				))
+			})
+
+			It("returns nil, nil", func() {
+				dependency, err := client.FindDependencyByID(10)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependency).To(BeNil())
+
+				Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+				Expect(cache.GetCallCount()).To(Equal(1))
+			})
+		})
+
+		testBad2xxResponseBody(func(c taskpkg.Repo) error {
+			_, err := c.FindDependencyByID(10)
+			return err
+		})
+
+		testAllCommonFailures(func(c taskpkg.Repo) error {
+			_, err := c.FindDependencyByID(10)
+			return err
+		})
+	})
+
+	Describe("DeleteDependency", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodDelete, "/api/v1/dependencies/10"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWith(http.StatusNoContent, nil),
+			))
+		})
+
+		It("deletes a dependency by ID", func() {
+			dependencies[0].ID = 10
+			Expect(client.DeleteDependency(dependencies[0])).To(Succeed())
+
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			Expect(cache.GetCallCount()).To(Equal(1))
+		})
+
+		testAllCommonFailures(func(c taskpkg.Repo) error {
+			dependencies[0].ID = 10
+			return c.DeleteDependency(dependencies[0])
+		})
+	})
+
+	Describe("WithContext", func() {
+		BeforeEach(func() {
+			client = clientpkg.New(
+				makeLogger(),
+				server.Addr(),
+				authenticator,
+				cache,
+				clientpkg.WithContext("some-context"),
+			)
+
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/contexts/some-context/tasks"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, tasks),
+			))
+		})
+
+		It("uses the endpoints of the context", func() {
+			Expect(client.Tasks()).To(Equal(tasks))
+
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
 })
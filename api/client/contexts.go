@@ -0,0 +1,91 @@
+package client
+
+import (
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/task"
+)
+
+type contexts struct {
+	client *client
+}
+
+// NewContexts returns a new API client for the contexts of an ANWORK API. The task.Repo of each
+// context is a client returned from New with WithContext.
+func NewContexts(
+	logger lager.Logger,
+	address string,
+	authenticator Authenticator,
+	cache Cache,
+) task.Contexts {
+	return &contexts{
+		client: New(logger, address, authenticator, cache).(*client),
+	}
+}
+
+func (c *contexts) Repo(name string) (task.Repo, error) {
+	if err := task.ValidateContextName(name); err != nil {
+		return nil, err
+	}
+
+	return New(
+		c.client.logger,
+		c.client.address,
+		c.client.authenticator,
+		c.client.tokenCache,
+		WithContext(name),
+	), nil
+}
+
+func (c *contexts) Contexts() ([]string, error) {
+	names := make([]string, 0)
+	if err := c.client.do(http.MethodGet, c.client.contextsURL(), nil, &names); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+func (c *contexts) CreateContext(name string) error {
+	if err := task.ValidateContextName(name); err != nil {
+		return err
+	}
+
+	rsp, err := c.client.doExt(http.MethodPost, c.client.contextsURL(), &api.Context{Name: name}, nil)
+	return contextError(rsp, err, "", name)
+}
+
+func (c *contexts) CopyContext(from, to string) error {
+	if err := task.ValidateContextName(to); err != nil {
+		return err
+	}
+
+	context := &api.Context{Name: to, CopyFrom: from}
+	rsp, err := c.client.doExt(http.MethodPost, c.client.contextsURL(), context, nil)
+	return contextError(rsp, err, from, to)
+}
+
+func (c *contexts) DeleteContext(name string) error {
+	if err := task.ValidateContextName(name); err != nil {
+		return err
+	}
+
+	rsp, err := c.client.doExt(http.MethodDelete, c.client.contextURL(name), nil, nil)
+	return contextError(rsp, err, name, name)
+}
+
+// contextError translates the response to a request for the contexts of the API into the errors
+// that are returned from task.Contexts. The unknown and duplicate names are the names of the
+// contexts that are reported when the API responds with a 404 or a 409, respectively. If the
+// unknown name is "", a 404 means that the API does not support contexts.
+func contextError(rsp *http.Response, err error, unknown, duplicate string) error {
+	if rsp != nil && rsp.StatusCode == http.StatusNotFound && unknown != "" {
+		return &task.UnknownContextError{Name: unknown}
+	} else if rsp != nil && rsp.StatusCode == http.StatusConflict {
+		return &task.DuplicateContextError{Name: duplicate}
+	} else {
+		return err
+	}
+}
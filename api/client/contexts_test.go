@@ -0,0 +1,133 @@
+package client_test
+
+import (
+	"net/http"
+
+	"github.com/ankeesler/anwork/api"
+	clientpkg "github.com/ankeesler/anwork/api/client"
+	"github.com/ankeesler/anwork/api/client/clientfakes"
+	taskpkg "github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Contexts", func() {
+	var (
+		authenticator *clientfakes.FakeAuthenticator
+		cache         *clientfakes.FakeCache
+
+		contexts taskpkg.Contexts
+		server   *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		authenticator = &clientfakes.FakeAuthenticator{}
+		authenticator.ValidateReturns("some-token", nil)
+
+		cache = &clientfakes.FakeCache{}
+		cache.GetReturns("some-cached-token", true)
+
+		server = ghttp.NewServer()
+		contexts = clientpkg.NewContexts(makeLogger(), server.Addr(), authenticator, cache)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Repo", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/contexts/some-context/events"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []*taskpkg.Event{}),
+			))
+		})
+
+		It("returns a client that uses the endpoints of the context", func() {
+			repo, err := contexts.Repo("some-context")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repo.Events()).To(BeEmpty())
+
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("fails when the name of the context is invalid", func() {
+			_, err := contexts.Repo("some context")
+			Expect(err).To(MatchError("invalid context name 'some context'"))
+		})
+	})
+
+	Describe("Contexts", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/contexts"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []string{"context-a", "context-b"}),
+			))
+		})
+
+		It("returns the names of the contexts", func() {
+			Expect(contexts.Contexts()).To(Equal([]string{"context-a", "context-b"}))
+		})
+	})
+
+	Describe("CreateContext", func() {
+		It("creates the context", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/api/v1/contexts"),
+				ghttp.VerifyJSONRepresenting(api.Context{Name: "context-a"}),
+				ghttp.RespondWith(http.StatusCreated, nil),
+			))
+
+			Expect(contexts.CreateContext("context-a")).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns a *task.DuplicateContextError on a 409", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusConflict, nil))
+
+			Expect(contexts.CreateContext("context-a")).To(MatchError(
+				&taskpkg.DuplicateContextError{Name: "context-a"}))
+		})
+	})
+
+	Describe("CopyContext", func() {
+		It("creates the context with a copy of the other context", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/api/v1/contexts"),
+				ghttp.VerifyJSONRepresenting(api.Context{Name: "context-b", CopyFrom: "context-a"}),
+				ghttp.RespondWith(http.StatusCreated, nil),
+			))
+
+			Expect(contexts.CopyContext("context-a", "context-b")).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns a *task.UnknownContextError on a 404", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, nil))
+
+			Expect(contexts.CopyContext("context-a", "context-b")).To(MatchError(
+				&taskpkg.UnknownContextError{Name: "context-a"}))
+		})
+	})
+
+	Describe("DeleteContext", func() {
+		It("deletes the context", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodDelete, "/api/v1/contexts/context-a"),
+				ghttp.RespondWith(http.StatusNoContent, nil),
+			))
+
+			Expect(contexts.DeleteContext("context-a")).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("returns a *task.UnknownContextError on a 404", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, nil))
+
+			Expect(contexts.DeleteContext("context-a")).To(MatchError(
+				&taskpkg.UnknownContextError{Name: "context-a"}))
+		})
+	})
+})
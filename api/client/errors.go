@@ -10,3 +10,34 @@ type badResponseError struct {
 func (bre *badResponseError) Error() string {
 	return fmt.Sprintf("unexpected response: %s: %s", bre.code, bre.message)
 }
+
+// A NotSupportedError is returned when the API cannot do what was asked of it, e.g., when its
+// task.Repo cannot store webhooks. Its message is the one from the API.
+type NotSupportedError struct {
+	Message string
+}
+
+func (nse *NotSupportedError) Error() string {
+	return nse.Message
+}
+
+// A ConflictError is returned when a task.Task cannot be updated or deleted because someone else
+// changed it after it was read, i.e., when its task.Task.Version is no longer the latest one. The
+// task.Task can be read again to get the latest version.
+type ConflictError struct {
+	// This is the name of the task.Task.
+	Name string
+	// This is the ID of the task.Task.
+	ID int
+	// This is the version of the task.Task that was read.
+	Version int
+}
+
+func (ce *ConflictError) Error() string {
+	return fmt.Sprintf(
+		"task '%s' (ID %d) was changed by someone else after version %d was read",
+		ce.Name,
+		ce.ID,
+		ce.Version,
+	)
+}
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/manager"
+	"github.com/ankeesler/anwork/task"
+)
+
+type clientManager struct {
+	// This performs the operations that do not have an /api/v2 endpoint.
+	manager.Manager
+
+	client *client
+	actor  string
+}
+
+// NewManager returns a manager.Manager that performs Create, Delete, Archive, Note, SetPriority,
+// SetState, and Rename with the /api/v2 endpoints of an ANWORK API, so that the API creates the
+// task.Event's that record them. Everything else is done by the provided manager.Manager, which
+// should use the task.Repo returned from New with the same Option's. The actor is recorded in the
+// task.Event's that the API creates.
+func NewManager(
+	logger lager.Logger,
+	address string,
+	authenticator Authenticator,
+	cache Cache,
+	m manager.Manager,
+	actor string,
+	options ...Option,
+) manager.Manager {
+	c := &client{
+		logger:        logger,
+		address:       address,
+		authenticator: authenticator,
+		tokenCache:    cache,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return &clientManager{Manager: m, client: c, actor: actor}
+}
+
+func (m *clientManager) Create(name string) error {
+	return m.perform("create", &api.Operation{Name: name})
+}
+
+func (m *clientManager) Delete(name string) error {
+	return m.perform("delete", &api.Operation{Name: name})
+}
+
+func (m *clientManager) Archive() error {
+	return m.perform("archive", &api.Operation{})
+}
+
+func (m *clientManager) Note(name, note string) error {
+	return m.perform("note", &api.Operation{Name: name, Note: note})
+}
+
+func (m *clientManager) SetPriority(name string, priority int) error {
+	return m.perform("set-priority", &api.Operation{Name: name, Priority: priority})
+}
+
+func (m *clientManager) SetState(name string, state task.State) error {
+	return m.perform("set-state", &api.Operation{Name: name, State: state})
+}
+
+func (m *clientManager) Rename(from, to string) error {
+	return m.perform("rename", &api.Operation{Name: from, To: to})
+}
+
+// perform POSTs an api.Operation to one of the /api/v2 endpoints, e.g., set-state.
+func (m *clientManager) perform(path string, o *api.Operation) error {
+	o.Actor = m.actor
+
+	var result api.Result
+	rsp, err := m.client.doExt(http.MethodPost, m.client.operationURL(path), o, &result)
+	if rsp != nil && rsp.StatusCode == http.StatusNotFound && o.Name != "" {
+		return fmt.Errorf("unknown task with name '%s'", o.Name)
+	}
+	return err
+}
+
+// operationURL returns the URL of one of the /api/v2 endpoints in the context of the client.
+func (c *client) operationURL(path string) string {
+	if c.context == "" {
+		return fmt.Sprintf("http://%s/api/v2/%s", c.address, path)
+	}
+	return fmt.Sprintf("http://%s/api/v2/contexts/%s/%s", c.address, url.PathEscape(c.context), path)
+}
@@ -0,0 +1,129 @@
+package client_test
+
+import (
+	"net/http"
+
+	"github.com/ankeesler/anwork/api"
+	clientpkg "github.com/ankeesler/anwork/api/client"
+	"github.com/ankeesler/anwork/api/client/clientfakes"
+	"github.com/ankeesler/anwork/manager"
+	"github.com/ankeesler/anwork/manager/managerfakes"
+	taskpkg "github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Manager", func() {
+	var (
+		authenticator *clientfakes.FakeAuthenticator
+		cache         *clientfakes.FakeCache
+		fallback      *managerfakes.FakeManager
+		options       []clientpkg.Option
+
+		m      manager.Manager
+		server *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		authenticator = &clientfakes.FakeAuthenticator{}
+		authenticator.ValidateReturns("some-token", nil)
+
+		cache = &clientfakes.FakeCache{}
+		cache.GetReturns("some-cached-token", true)
+
+		fallback = &managerfakes.FakeManager{}
+		options = nil
+
+		server = ghttp.NewServer()
+	})
+
+	JustBeforeEach(func() {
+		m = clientpkg.NewManager(makeLogger(), server.Addr(), authenticator, cache, fallback, "some-user", options...)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	// expectOperation makes the server expect an api.Operation at an /api/v2 endpoint.
+	expectOperation := func(path string, o api.Operation) {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest(http.MethodPost, path),
+			ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+			ghttp.VerifyJSONRepresenting(o),
+			ghttp.RespondWithJSONEncoded(http.StatusOK, api.Result{}),
+		))
+	}
+
+	It("performs the operations with the /api/v2 endpoints", func() {
+		expectOperation("/api/v2/create", api.Operation{Name: "task-a", Actor: "some-user"})
+		expectOperation("/api/v2/set-state", api.Operation{Name: "task-a", State: taskpkg.StateRunning, Actor: "some-user"})
+		expectOperation("/api/v2/set-priority", api.Operation{Name: "task-a", Priority: 5, Actor: "some-user"})
+		expectOperation("/api/v2/note", api.Operation{Name: "task-a", Note: "some note", Actor: "some-user"})
+		expectOperation("/api/v2/rename", api.Operation{Name: "task-a", To: "task-b", Actor: "some-user"})
+		expectOperation("/api/v2/delete", api.Operation{Name: "task-b", Actor: "some-user"})
+		expectOperation("/api/v2/archive", api.Operation{Actor: "some-user"})
+
+		Expect(m.Create("task-a")).To(Succeed())
+		Expect(m.SetState("task-a", taskpkg.StateRunning)).To(Succeed())
+		Expect(m.SetPriority("task-a", 5)).To(Succeed())
+		Expect(m.Note("task-a", "some note")).To(Succeed())
+		Expect(m.Rename("task-a", "task-b")).To(Succeed())
+		Expect(m.Delete("task-b")).To(Succeed())
+		Expect(m.Archive()).To(Succeed())
+
+		Expect(server.ReceivedRequests()).To(HaveLen(7))
+	})
+
+	It("uses the manager.Manager for everything else", func() {
+		fallback.FindByNameReturns(&taskpkg.Task{Name: "task-a"}, nil)
+
+		Expect(m.FindByName("task-a")).To(Equal(&taskpkg.Task{Name: "task-a"}))
+		Expect(m.Undo(1)).To(Succeed())
+
+		Expect(fallback.FindByNameCallCount()).To(Equal(1))
+		Expect(fallback.UndoCallCount()).To(Equal(1))
+		Expect(server.ReceivedRequests()).To(BeEmpty())
+	})
+
+	Context("when the task does not exist", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/api/v2/set-state"),
+				ghttp.RespondWithJSONEncoded(http.StatusNotFound, api.Error{Message: "unknown task with name 'task-a'"}),
+			))
+		})
+
+		It("returns an error", func() {
+			Expect(m.SetState("task-a", taskpkg.StateRunning)).To(MatchError("unknown task with name 'task-a'"))
+		})
+	})
+
+	Context("when the API fails", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/api/v2/create"),
+				ghttp.RespondWithJSONEncoded(http.StatusInternalServerError, api.Error{Message: "some message"}),
+			))
+		})
+
+		It("returns an error", func() {
+			err := m.Create("task-a")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("some message"))
+		})
+	})
+
+	Context("when it is used with a context", func() {
+		BeforeEach(func() {
+			options = []clientpkg.Option{clientpkg.WithContext("some-context")}
+			expectOperation("/api/v2/contexts/some-context/create", api.Operation{Name: "task-a", Actor: "some-user"})
+		})
+
+		It("uses the endpoints of the context", func() {
+			Expect(m.Create("task-a")).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+	})
+})
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/session"
+)
+
+// Sessions manages the session.Session's of an ANWORK API, i.e., the tokens that it accepts, with
+// the /api/v1/sessions endpoints.
+type Sessions struct {
+	client *client
+}
+
+// NewSessions returns a new Sessions for an ANWORK API address.
+func NewSessions(
+	logger lager.Logger,
+	address string,
+	authenticator Authenticator,
+	cache Cache,
+) *Sessions {
+	return &Sessions{
+		client: New(logger, address, authenticator, cache).(*client),
+	}
+}
+
+// Sessions returns the active session.Session's: those of the user of the cached token, or all of
+// them, if the API has no users.
+func (s *Sessions) Sessions() ([]*session.Session, error) {
+	sessions := make([]*session.Session, 0)
+	if err := s.client.do(http.MethodGet, s.client.sessionsURL(), nil, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes the session.Session with an ID, after which the API no longer accepts its
+// token.
+func (s *Sessions) RevokeSession(id string) error {
+	rsp, err := s.client.doExt(http.MethodDelete, s.client.sessionURL(id), nil, nil)
+	if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("unknown session with ID %s", id)
+	}
+	return err
+}
+
+// Logout revokes the cached token, and then empties the Cache, so that the next request gets a
+// new token.
+func (s *Sessions) Logout() error {
+	if err := s.client.do(http.MethodDelete, s.client.authURL(), nil, nil); err != nil {
+		return err
+	}
+
+	s.client.tokenCache.Set("")
+	return nil
+}
+
+func (c *client) sessionsURL() string {
+	return fmt.Sprintf("http://%s/api/v1/sessions", c.address)
+}
+
+func (c *client) sessionURL(id string) string {
+	return fmt.Sprintf("%s/%s", c.sessionsURL(), url.PathEscape(id))
+}
@@ -0,0 +1,105 @@
+package client_test
+
+import (
+	"net/http"
+
+	"github.com/ankeesler/anwork/api"
+	clientpkg "github.com/ankeesler/anwork/api/client"
+	"github.com/ankeesler/anwork/api/client/clientfakes"
+	"github.com/ankeesler/anwork/session"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Sessions", func() {
+	var (
+		authenticator *clientfakes.FakeAuthenticator
+		cache         *clientfakes.FakeCache
+
+		sessions *clientpkg.Sessions
+		server   *ghttp.Server
+	)
+
+	BeforeEach(func() {
+		authenticator = &clientfakes.FakeAuthenticator{}
+		authenticator.ValidateReturns("some-token", nil)
+
+		cache = &clientfakes.FakeCache{}
+		cache.GetReturns("some-cached-token", true)
+
+		server = ghttp.NewServer()
+		sessions = clientpkg.NewSessions(makeLogger(), server.Addr(), authenticator, cache)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Sessions", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/sessions"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, []*session.Session{
+					{ID: "session-a", Subject: "user-a", IssuedAt: 100, Expiry: 200},
+				}),
+			))
+		})
+
+		It("returns the sessions", func() {
+			Expect(sessions.Sessions()).To(Equal([]*session.Session{
+				{ID: "session-a", Subject: "user-a", IssuedAt: 100, Expiry: 200},
+			}))
+		})
+	})
+
+	Describe("RevokeSession", func() {
+		It("revokes the session", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodDelete, "/api/v1/sessions/session-a"),
+				ghttp.RespondWith(http.StatusNoContent, nil),
+			))
+
+			Expect(sessions.RevokeSession("session-a")).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("fails on an unknown session", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodDelete, "/api/v1/sessions/session-z"),
+				ghttp.RespondWithJSONEncoded(http.StatusNotFound, api.Error{Message: "unknown session with ID session-z"}),
+			))
+
+			Expect(sessions.RevokeSession("session-z")).To(MatchError("unknown session with ID session-z"))
+		})
+	})
+
+	Describe("Logout", func() {
+		It("revokes the cached token and empties the cache", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodDelete, "/api/v1/auth"),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWith(http.StatusNoContent, nil),
+			))
+
+			Expect(sessions.Logout()).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+
+			Expect(cache.SetCallCount()).To(Equal(1))
+			Expect(cache.SetArgsForCall(0)).To(Equal(""))
+		})
+
+		It("leaves the cache alone when it fails", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodDelete, "/api/v1/auth"),
+				ghttp.RespondWithJSONEncoded(http.StatusNotImplemented, api.Error{Message: "sessions are not supported by this authenticator"}),
+			))
+
+			err := sessions.Logout()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("sessions are not supported by this authenticator"))
+			Expect(cache.SetCallCount()).To(Equal(0))
+		})
+	})
+})
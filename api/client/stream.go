@@ -0,0 +1,138 @@
+package client
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/task"
+)
+
+// An EventStream follows the task.Event's that are created through an ANWORK API, with the
+// /api/v1/events/stream endpoint.
+type EventStream struct {
+	client *client
+}
+
+// NewEventStream returns a new EventStream for an ANWORK API address.
+func NewEventStream(
+	logger lager.Logger,
+	address string,
+	authenticator Authenticator,
+	cache Cache,
+	options ...Option,
+) *EventStream {
+	c := &client{
+		logger:        logger,
+		address:       address,
+		authenticator: authenticator,
+		tokenCache:    cache,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return &EventStream{client: c}
+}
+
+// Follow calls handle with each task.Event that is created after the one with the lastEventID,
+// in order, until handle returns an error, which Follow then returns. When the API ends the
+// stream, Follow opens it again after the last task.Event that it handled. Follow returns an error
+// if the stream cannot be opened or read.
+func (s *EventStream) Follow(lastEventID int, handle func(*task.Event) error) error {
+	for {
+		body, err := s.client.openStream(lastEventID)
+		if err != nil {
+			return err
+		}
+
+		err = readChanges(body, func(change *api.Change) error {
+			if change.Action != api.ActionCreate || change.Event == nil {
+				return nil
+			}
+			if err := handle(change.Event); err != nil {
+				return err
+			}
+			lastEventID = change.Event.ID
+			return nil
+		})
+		body.Close()
+		if err != nil {
+			return err
+		}
+
+		s.client.logger.Debug("stream-ended", lager.Data{"lastEventID": lastEventID})
+	}
+}
+
+// openStream opens the /api/v1/events/stream endpoint after the task.Event with the lastEventID.
+func (c *client) openStream(lastEventID int) (io.ReadCloser, error) {
+	req, err := http.NewRequest(http.MethodGet, c.eventsStreamURL(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Accept", "text/event-stream")
+	req.Header.Add("Last-Event-ID", strconv.Itoa(lastEventID))
+
+	token, err := c.getToken()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("bearer %s", token))
+
+	c.logger.Debug("request", lager.Data{"method": req.Method, "url": req.URL})
+	rsp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	c.logger.Debug("response", lager.Data{"status": rsp.Status})
+
+	if is5xxStatus(rsp) || is4xxStatus(rsp) {
+		defer rsp.Body.Close()
+		return nil, &badResponseError{code: rsp.Status, message: decodeError(rsp.Body)}
+	}
+
+	return rsp.Body, nil
+}
+
+// readChanges calls handle with the api.Change in each server-sent event that is read from the
+// body, until the body ends or handle returns an error.
+func readChanges(body io.Reader, handle func(*api.Change) error) error {
+	data := []string{}
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			// Only the data matters; the name and the ID of the event are also in the api.Change.
+			if strings.HasPrefix(line, "data:") {
+				data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+			}
+			continue
+		}
+
+		if len(data) == 0 {
+			continue
+		}
+
+		var change api.Change
+		if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &change); err != nil {
+			return fmt.Errorf("cannot unmarshal change (%s): '%s'", err.Error(), strings.Join(data, "\n"))
+		}
+		data = data[:0]
+
+		if err := handle(&change); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (c *client) eventsStreamURL() string {
+	return fmt.Sprintf("%s/events/stream", c.repoURL())
+}
@@ -0,0 +1,158 @@
+package client_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ankeesler/anwork/api"
+	clientpkg "github.com/ankeesler/anwork/api/client"
+	"github.com/ankeesler/anwork/api/client/clientfakes"
+	taskpkg "github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("EventStream", func() {
+	var (
+		authenticator *clientfakes.FakeAuthenticator
+		cache         *clientfakes.FakeCache
+		options       []clientpkg.Option
+
+		stream *clientpkg.EventStream
+		server *ghttp.Server
+
+		handled []*taskpkg.Event
+		handle  func(*taskpkg.Event) error
+	)
+
+	BeforeEach(func() {
+		authenticator = &clientfakes.FakeAuthenticator{}
+		authenticator.ValidateReturns("some-token", nil)
+
+		cache = &clientfakes.FakeCache{}
+		cache.GetReturns("some-cached-token", true)
+
+		options = nil
+
+		server = ghttp.NewServer()
+
+		// Stop following after the second task.Event.
+		handled = nil
+		handle = func(e *taskpkg.Event) error {
+			handled = append(handled, e)
+			if len(handled) == 2 {
+				return errors.New("some handle error")
+			}
+			return nil
+		}
+	})
+
+	JustBeforeEach(func() {
+		stream = clientpkg.NewEventStream(makeLogger(), server.Addr(), authenticator, cache, options...)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	// serverSentEvents returns the server-sent events that carry the api.Change's.
+	serverSentEvents := func(changes ...*api.Change) string {
+		body := ""
+		for _, change := range changes {
+			data, err := json.Marshal(change)
+			ExpectWithOffset(1, err).NotTo(HaveOccurred())
+			if change.Event != nil {
+				body += fmt.Sprintf("id: %d\n", change.Event.ID)
+			}
+			body += fmt.Sprintf("event: %s\ndata: %s\n\n", change.Name(), data)
+		}
+		return body
+	}
+
+	// expectStream makes the server expect the stream to be opened after the lastEventID.
+	expectStream := func(path, lastEventID string, changes ...*api.Change) {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest(http.MethodGet, path),
+			ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+			ghttp.VerifyHeaderKV("Last-Event-ID", lastEventID),
+			ghttp.RespondWith(http.StatusOK, serverSentEvents(changes...), http.Header{
+				"Content-Type": []string{"text/event-stream"},
+			}),
+		))
+	}
+
+	It("calls the function with each created event until it fails", func() {
+		expectStream(
+			"/api/v1/events/stream",
+			"3",
+			&api.Change{Action: api.ActionCreate, Task: &taskpkg.Task{ID: 1, Name: "task-a"}},
+			&api.Change{Action: api.ActionCreate, Event: &taskpkg.Event{ID: 4, Title: "event-a"}},
+			&api.Change{Action: api.ActionDelete, Event: &taskpkg.Event{ID: 2, Title: "event-b"}},
+			&api.Change{Action: api.ActionCreate, Event: &taskpkg.Event{ID: 5, Title: "event-c"}},
+			&api.Change{Action: api.ActionCreate, Event: &taskpkg.Event{ID: 6, Title: "event-d"}},
+		)
+
+		Expect(stream.Follow(3, handle)).To(MatchError("some handle error"))
+		Expect(handled).To(Equal([]*taskpkg.Event{
+			&taskpkg.Event{ID: 4, Title: "event-a"},
+			&taskpkg.Event{ID: 5, Title: "event-c"},
+		}))
+	})
+
+	Context("when the stream ends", func() {
+		BeforeEach(func() {
+			expectStream(
+				"/api/v1/events/stream",
+				"-1",
+				&api.Change{Action: api.ActionCreate, Event: &taskpkg.Event{ID: 0, Title: "event-a"}},
+			)
+			expectStream(
+				"/api/v1/events/stream",
+				"0",
+				&api.Change{Action: api.ActionCreate, Event: &taskpkg.Event{ID: 1, Title: "event-b"}},
+			)
+		})
+
+		It("opens it again after the last event", func() {
+			Expect(stream.Follow(-1, handle)).To(MatchError("some handle error"))
+			Expect(handled).To(HaveLen(2))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+	})
+
+	Context("when the stream cannot be opened", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/events/stream"),
+				ghttp.RespondWithJSONEncoded(http.StatusBadRequest, api.Error{Message: "some message"}),
+			))
+		})
+
+		It("returns an error", func() {
+			err := stream.Follow(-1, handle)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("some message"))
+			Expect(handled).To(BeEmpty())
+		})
+	})
+
+	Context("when it is used with a context", func() {
+		BeforeEach(func() {
+			options = []clientpkg.Option{clientpkg.WithContext("some-context")}
+			expectStream(
+				"/api/v1/contexts/some-context/events/stream",
+				"-1",
+				&api.Change{Action: api.ActionCreate, Event: &taskpkg.Event{ID: 0, Title: "event-a"}},
+				&api.Change{Action: api.ActionCreate, Event: &taskpkg.Event{ID: 1, Title: "event-b"}},
+			)
+		})
+
+		It("follows the events of the context", func() {
+			Expect(stream.Follow(-1, handle)).To(MatchError("some handle error"))
+			Expect(handled).To(HaveLen(2))
+		})
+	})
+})
@@ -7,10 +7,12 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"reflect"
 	"strconv"
 	"strings"
 
 	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/task"
 )
 
 func encodeBody(input interface{}) (io.Reader, error) {
@@ -74,3 +76,64 @@ func parseID(location string, id *int) bool {
 	*id = idN
 	return true
 }
+
+// parseETag sets the version of a task.Task from the ETag response header, if there is one.
+func parseETag(etag string, version *int) {
+	if s, err := strconv.Unquote(etag); err == nil {
+		if n, err := strconv.Atoi(s); err == nil {
+			*version = n
+		}
+	}
+}
+
+// ifMatch returns the If-Match request header that makes sure that a task.Task with a version has
+// not been changed by someone else. A task.Task without a version is changed unconditionally.
+func ifMatch(version int) http.Header {
+	header := http.Header{}
+	if version != 0 {
+		header.Set("If-Match", strconv.Quote(strconv.Itoa(version)))
+	}
+	return header
+}
+
+// mergePatch returns the JSON merge patch (see RFC 7396) that changes one task.Task into another.
+// It only has the fields that are different, and never has the ID or version.
+func mergePatch(from, to *task.Task) (map[string]interface{}, error) {
+	fromFields, err := fields(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := fields(to)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := make(map[string]interface{})
+	for name, value := range toFields {
+		if !reflect.DeepEqual(value, fromFields[name]) {
+			patch[name] = value
+		}
+	}
+	for name := range fromFields {
+		if _, ok := toFields[name]; !ok {
+			patch[name] = nil
+		}
+	}
+	delete(patch, "id")
+	delete(patch, "version")
+	return patch, nil
+}
+
+// fields returns the JSON representation of a task.Task as a map of its fields.
+func fields(t *task.Task) (map[string]interface{}, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ankeesler/anwork/webhook"
+)
+
+func (c *client) CreateWebhook(w *webhook.Webhook) error {
+	rsp, err := c.doExt(http.MethodPost, c.webhooksURL(), w, nil)
+	if err != nil {
+		return err
+	}
+
+	location := rsp.Header.Get("Location")
+	if location == "" || !parseID(location, &w.ID) {
+		return fmt.Errorf("could not parse ID from Location response header: %s", location)
+	}
+
+	return nil
+}
+
+func (c *client) Webhooks() ([]*webhook.Webhook, error) {
+	webhooks := make([]*webhook.Webhook, 0)
+	if err := c.do(http.MethodGet, c.webhooksURL(), nil, &webhooks); err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (c *client) FindWebhookByID(id int) (*webhook.Webhook, error) {
+	var w webhook.Webhook
+
+	rsp, err := c.doExt(http.MethodGet, c.webhookURL(id), nil, &w)
+	if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else {
+		return &w, nil
+	}
+}
+
+func (c *client) DeleteWebhook(w *webhook.Webhook) error {
+	rsp, err := c.doExt(http.MethodDelete, c.webhookURL(w.ID), nil, nil)
+	if rsp != nil && rsp.StatusCode == http.StatusNotFound {
+		return nil
+	} else {
+		return err
+	}
+}
+
+// CreateDeadLetter always fails, since only the API records a webhook.DeadLetter, when it cannot
+// deliver a task.Event to a webhook.Webhook.
+func (c *client) CreateDeadLetter(d *webhook.DeadLetter) error {
+	return errors.New("dead letters can only be recorded by the API")
+}
+
+func (c *client) DeadLetters() ([]*webhook.DeadLetter, error) {
+	deadLetters := make([]*webhook.DeadLetter, 0)
+	if err := c.do(http.MethodGet, c.deadLettersURL(), nil, &deadLetters); err != nil {
+		return nil, err
+	}
+
+	return deadLetters, nil
+}
+
+func (c *client) webhooksURL() string {
+	return fmt.Sprintf("%s/webhooks", c.repoURL())
+}
+
+func (c *client) webhookURL(id int) string {
+	return fmt.Sprintf("%s/webhooks/%d", c.repoURL(), id)
+}
+
+func (c *client) deadLettersURL() string {
+	return fmt.Sprintf("%s/dead-letters", c.repoURL())
+}
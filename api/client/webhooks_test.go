@@ -0,0 +1,205 @@
+package client_test
+
+import (
+	"net/http"
+
+	"github.com/ankeesler/anwork/api"
+	clientpkg "github.com/ankeesler/anwork/api/client"
+	"github.com/ankeesler/anwork/api/client/clientfakes"
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+)
+
+var _ = Describe("Webhooks", func() {
+	var (
+		authenticator *clientfakes.FakeAuthenticator
+		cache         *clientfakes.FakeCache
+
+		client webhook.Repo
+		server *ghttp.Server
+
+		webhooks    []*webhook.Webhook
+		deadLetters []*webhook.DeadLetter
+	)
+
+	BeforeEach(func() {
+		authenticator = &clientfakes.FakeAuthenticator{}
+		authenticator.ValidateReturns("some-token", nil)
+
+		cache = &clientfakes.FakeCache{}
+		cache.GetReturns("some-cached-token", true)
+
+		server = ghttp.NewServer()
+
+		var ok bool
+		client, ok = clientpkg.New(makeLogger(), server.Addr(), authenticator, cache).(webhook.Repo)
+		Expect(ok).To(BeTrue())
+
+		webhooks = []*webhook.Webhook{
+			&webhook.Webhook{ID: 1, URL: "https://example.com/a"},
+			&webhook.Webhook{
+				ID:         2,
+				URL:        "https://example.com/b",
+				EventTypes: []taskpkg.EventType{taskpkg.EventTypeSetState},
+				States:     []taskpkg.State{taskpkg.StateBlocked},
+			},
+		}
+		deadLetters = []*webhook.DeadLetter{
+			&webhook.DeadLetter{
+				ID:        1,
+				WebhookID: 1,
+				URL:       "https://example.com/a",
+				Event:     &taskpkg.Event{ID: 5, Title: "some event"},
+				Attempts:  5,
+				Error:     "some error",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("CreateWebhook", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodPost, "/api/v1/webhooks"),
+				ghttp.VerifyJSONRepresenting(webhook.Webhook{URL: "https://example.com/a", Secret: "some-secret"}),
+				ghttp.VerifyHeaderKV("Authorization", "bearer some-token"),
+				ghttp.RespondWith(
+					http.StatusCreated,
+					nil,
+					http.Header{"Location": {"/api/v1/webhooks/10"}}),
+			))
+		})
+
+		It("POSTs to /api/v1/webhooks and sets the ID of the webhook", func() {
+			w := &webhook.Webhook{URL: "https://example.com/a", Secret: "some-secret"}
+			Expect(client.CreateWebhook(w)).To(Succeed())
+			Expect(w.ID).To(Equal(10))
+
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		Context("when the API responds with an error", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.RespondWith(http.StatusBadRequest, nil))
+			})
+
+			It("returns an error", func() {
+				err := client.CreateWebhook(&webhook.Webhook{URL: "https://example.com/a"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("400 Bad Request"))
+			})
+		})
+	})
+
+	Describe("Webhooks", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/webhooks"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, webhooks),
+			))
+		})
+
+		It("gets the webhooks from the server", func() {
+			Expect(client.Webhooks()).To(Equal(webhooks))
+		})
+
+		Context("when the repo of the server does not support webhooks", func() {
+			BeforeEach(func() {
+				server.SetHandler(0, ghttp.RespondWithJSONEncoded(
+					http.StatusNotImplemented,
+					api.Error{Message: "webhooks are not supported by this repo"},
+				))
+			})
+
+			It("returns a NotSupportedError with the message of the server", func() {
+				_, err := client.Webhooks()
+				Expect(err).To(Equal(&clientpkg.NotSupportedError{Message: "webhooks are not supported by this repo"}))
+			})
+		})
+	})
+
+	Describe("FindWebhookByID", func() {
+		It("gets the webhook by ID", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/webhooks/2"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, webhooks[1]),
+			))
+
+			Expect(client.FindWebhookByID(2)).To(Equal(webhooks[1]))
+		})
+
+		It("returns nil, nil on a 404", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, nil))
+
+			w, err := client.FindWebhookByID(2)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(w).To(BeNil())
+		})
+	})
+
+	Describe("DeleteWebhook", func() {
+		It("deletes the webhook by ID", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodDelete, "/api/v1/webhooks/2"),
+				ghttp.RespondWith(http.StatusNoContent, nil),
+			))
+
+			Expect(client.DeleteWebhook(webhooks[1])).To(Succeed())
+			Expect(server.ReceivedRequests()).To(HaveLen(1))
+		})
+
+		It("succeeds on a 404", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, nil))
+
+			Expect(client.DeleteWebhook(webhooks[1])).To(Succeed())
+		})
+	})
+
+	Describe("CreateDeadLetter", func() {
+		It("fails without asking the server, since only the API records dead letters", func() {
+			d := &webhook.DeadLetter{WebhookID: 1, Event: &taskpkg.Event{ID: 5}}
+			Expect(client.CreateDeadLetter(d)).To(MatchError("dead letters can only be recorded by the API"))
+			Expect(server.ReceivedRequests()).To(BeEmpty())
+		})
+	})
+
+	Describe("DeadLetters", func() {
+		BeforeEach(func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/dead-letters"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, deadLetters),
+			))
+		})
+
+		It("gets the dead letters from the server", func() {
+			Expect(client.DeadLetters()).To(Equal(deadLetters))
+		})
+	})
+
+	Describe("WithContext", func() {
+		BeforeEach(func() {
+			client = clientpkg.New(
+				makeLogger(),
+				server.Addr(),
+				authenticator,
+				cache,
+				clientpkg.WithContext("some-context"),
+			).(webhook.Repo)
+
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest(http.MethodGet, "/api/v1/contexts/some-context/webhooks"),
+				ghttp.RespondWithJSONEncoded(http.StatusOK, webhooks),
+			))
+		})
+
+		It("uses the webhooks of the context", func() {
+			Expect(client.Webhooks()).To(Equal(webhooks))
+		})
+	})
+})
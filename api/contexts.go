@@ -0,0 +1,239 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+	"github.com/tedsuo/rata"
+)
+
+// errNoContexts is returned from the /api/v1/contexts endpoints when the API has no task.Contexts.
+var errNoContexts = errors.New("contexts are not supported")
+
+// respondWithContextError responds with the status code that matches an error from
+// task.Contexts.
+func respondWithContextError(logger lager.Logger, w http.ResponseWriter, err error) {
+	statusCode := http.StatusInternalServerError
+	switch err.(type) {
+	case *task.UnknownContextError:
+		statusCode = http.StatusNotFound
+	case *task.DuplicateContextError:
+		statusCode = http.StatusConflict
+	}
+	respondWithError(logger, w, statusCode, err)
+}
+
+// validContextName responds with a 400 if the name of a context is invalid, or a 404 if there are
+// no contexts, and returns whether it did not respond.
+func validContextName(
+	logger lager.Logger,
+	contexts task.Contexts,
+	w http.ResponseWriter,
+	name string,
+) bool {
+	if contexts == nil {
+		respondWithError(logger, w, http.StatusNotFound, errNoContexts)
+		return false
+	}
+
+	if err := task.ValidateContextName(name); err != nil {
+		respondWithError(logger, w, http.StatusBadRequest, err)
+		return false
+	}
+
+	return true
+}
+
+// checkContextOwners returns a *notOwnerError if a user cannot copy a context, since it has
+// task.Task's that they cannot see, or cannot delete it, since it has something that belongs to
+// someone else (see task.Task.Owner and webhook.Webhook.Owner). When the API has no users, i.e.,
+// when the user is "", anyone can copy or delete any context.
+func checkContextOwners(contexts task.Contexts, name, user string, deleting bool) error {
+	if user == "" {
+		return nil
+	}
+
+	repo, err := contexts.Repo(name)
+	if err != nil {
+		return err
+	}
+	notOwner := func(what string) error {
+		return &notOwnerError{fmt.Sprintf("context '%s' has %s that belong to someone else", name, what)}
+	}
+
+	hidden, err := (&ownedRepo{Repo: repo, user: user}).hiddenNow()
+	if err != nil {
+		return err
+	}
+	for _, h := range hidden {
+		if h {
+			return notOwner("tasks")
+		}
+	}
+
+	if !deleting {
+		return nil
+	}
+
+	// The task.Task's that are shared with the user can be seen, but not deleted, by them.
+	tasks, err := repo.Tasks()
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if t.Owner != "" && t.Owner != user {
+			return notOwner("tasks")
+		}
+	}
+
+	if webhooks, ok := repo.(webhook.Repo); ok {
+		owned := &ownedWebhookRepo{Repo: repo, webhooks: webhooks, user: user}
+
+		hooks, err := webhooks.Webhooks()
+		if err != nil {
+			return err
+		}
+		for _, w := range hooks {
+			if !owned.sees(w.Owner) {
+				return notOwner("webhooks")
+			}
+		}
+
+		deadLetters, err := webhooks.DeadLetters()
+		if err != nil {
+			return err
+		}
+		for _, d := range deadLetters {
+			if !owned.sees(d.Owner) {
+				return notOwner("dead letters")
+			}
+		}
+	}
+
+	return nil
+}
+
+type getContextsHandler struct {
+	logger   lager.Logger
+	contexts task.Contexts
+}
+
+func (h *getContextsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.contexts == nil {
+		respondWithError(h.logger, w, http.StatusNotFound, errNoContexts)
+		return
+	}
+
+	names, err := h.contexts.Contexts()
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond(h.logger, w, http.StatusOK, names)
+}
+
+type createContextHandler struct {
+	logger   lager.Logger
+	contexts task.Contexts
+	user     string
+}
+
+func (h *createContextHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var context Context
+	if err := json.Unmarshal(data, &context); err != nil {
+		respondWithError(h.logger, w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !validContextName(h.logger, h.contexts, w, context.Name) {
+		return
+	}
+
+	if context.CopyFrom != "" {
+		if err := checkContextOwners(h.contexts, context.CopyFrom, h.user, false); err != nil {
+			respondWithError(h.logger, w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	h.logger.Debug("creating-context", lager.Data{"context": context})
+	if context.CopyFrom == "" {
+		err = h.contexts.CreateContext(context.Name)
+	} else {
+		err = h.contexts.CopyContext(context.CopyFrom, context.Name)
+	}
+	if err != nil {
+		respondWithContextError(h.logger, w, err)
+		return
+	}
+
+	w.Header().Add("Location", fmt.Sprintf("%s/%s", r.URL.Path, context.Name))
+	respond(h.logger, w, http.StatusCreated, nil)
+}
+
+type deleteContextHandler struct {
+	logger   lager.Logger
+	contexts task.Contexts
+	user     string
+}
+
+func (h *deleteContextHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := rata.Param(r, "context")
+	if !validContextName(h.logger, h.contexts, w, name) {
+		return
+	}
+
+	if err := checkContextOwners(h.contexts, name, h.user, true); err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if err := h.contexts.DeleteContext(name); err != nil {
+		respondWithContextError(h.logger, w, err)
+		return
+	}
+
+	respond(h.logger, w, http.StatusNoContent, nil)
+}
+
+// contextHandler serves one of the repoRoutes with the task.Repo of the context in the path.
+type contextHandler struct {
+	logger     lager.Logger
+	contexts   task.Contexts
+	clock      clock.Clock
+	hub        *hub
+	dispatcher *webhook.Dispatcher
+	user       string
+	name       string
+}
+
+func (h *contextHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := rata.Param(r, "context")
+	if !validContextName(h.logger, h.contexts, w, name) {
+		return
+	}
+
+	repo, err := h.contexts.Repo(name)
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	logger := h.logger.WithData(lager.Data{"context": name})
+	stream := stream{h.hub, name, repo, h.dispatcher}
+	repoHandlers(logger, repo, h.user, h.clock, stream)[h.name].ServeHTTP(w, r)
+}
@@ -0,0 +1,356 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/api/apifakes"
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/memory"
+	"github.com/ankeesler/anwork/task/taskfakes"
+	"github.com/ankeesler/anwork/user"
+	"github.com/ankeesler/anwork/webhook"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+var _ = Describe("Contexts", func() {
+	var (
+		repo          *taskfakes.FakeRepo
+		contextRepo   *taskfakes.FakeRepo
+		contexts      *taskfakes.FakeContexts
+		authenticator *apifakes.FakeAuthenticator
+
+		options []api.Option
+
+		process ifrit.Process
+	)
+
+	BeforeEach(func() {
+		repo = &taskfakes.FakeRepo{}
+		contextRepo = &taskfakes.FakeRepo{}
+		contexts = &taskfakes.FakeContexts{}
+		contexts.RepoReturns(contextRepo, nil)
+		authenticator = &apifakes.FakeAuthenticator{}
+
+		options = []api.Option{api.WithContexts(contexts)}
+	})
+
+	JustBeforeEach(func() {
+		a := api.New(lagertest.NewTestLogger("api"), repo, authenticator, options...)
+		runner := http_server.New("127.0.0.1:12345", a)
+		process = ifrit.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	Describe("Get", func() {
+		BeforeEach(func() {
+			contexts.ContextsReturns([]string{"context-a", "context-b"}, nil)
+		})
+
+		It("responds with the names of the contexts", func() {
+			rsp, err := get("/api/v1/contexts")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+
+			data, err := ioutil.ReadAll(rsp.Body)
+			Expect(err).NotTo(HaveOccurred())
+			var names []string
+			Expect(json.Unmarshal(data, &names)).To(Succeed())
+			Expect(names).To(Equal([]string{"context-a", "context-b"}))
+		})
+
+		Context("when getting the contexts fails", func() {
+			BeforeEach(func() {
+				contexts.ContextsReturns(nil, errors.New("some contexts error"))
+			})
+
+			It("returns a 500 with an error", func() {
+				rsp, err := get("/api/v1/contexts")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusInternalServerError))
+				assertError(rsp, "some contexts error")
+			})
+		})
+
+		Context("when there are no contexts", func() {
+			BeforeEach(func() {
+				options = nil
+			})
+
+			It("returns a 404 with an error", func() {
+				rsp, err := get("/api/v1/contexts")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+				assertError(rsp, "contexts are not supported")
+			})
+		})
+	})
+
+	Describe("Create", func() {
+		It("creates the context and responds with the location", func() {
+			rsp, err := post("/api/v1/contexts", api.Context{Name: "context-a"})
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusCreated))
+			Expect(rsp.Header.Get("Location")).To(Equal("/api/v1/contexts/context-a"))
+
+			Expect(contexts.CreateContextCallCount()).To(Equal(1))
+			Expect(contexts.CreateContextArgsForCall(0)).To(Equal("context-a"))
+		})
+
+		It("copies the context when CopyFrom is set", func() {
+			rsp, err := post("/api/v1/contexts", api.Context{Name: "context-b", CopyFrom: "context-a"})
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusCreated))
+
+			Expect(contexts.CreateContextCallCount()).To(Equal(0))
+			Expect(contexts.CopyContextCallCount()).To(Equal(1))
+			from, to := contexts.CopyContextArgsForCall(0)
+			Expect(from).To(Equal("context-a"))
+			Expect(to).To(Equal("context-b"))
+		})
+
+		Context("when the name is invalid", func() {
+			It("responds with a 400", func() {
+				rsp, err := post("/api/v1/contexts", api.Context{Name: ".context-a"})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+				assertError(rsp, "invalid context name '.context-a'")
+				Expect(contexts.CreateContextCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the context already exists", func() {
+			BeforeEach(func() {
+				contexts.CreateContextReturns(&taskpkg.DuplicateContextError{Name: "context-a"})
+			})
+
+			It("responds with a 409", func() {
+				rsp, err := post("/api/v1/contexts", api.Context{Name: "context-a"})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusConflict))
+				assertError(rsp, "context 'context-a' already exists")
+			})
+		})
+
+		Context("when the context to copy does not exist", func() {
+			BeforeEach(func() {
+				contexts.CopyContextReturns(&taskpkg.UnknownContextError{Name: "context-a"})
+			})
+
+			It("responds with a 404", func() {
+				rsp, err := post("/api/v1/contexts", api.Context{Name: "context-b", CopyFrom: "context-a"})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+				assertError(rsp, "unknown context 'context-a'")
+			})
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes the context", func() {
+			rsp, err := deletee("/api/v1/contexts/context-a")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+
+			Expect(contexts.DeleteContextCallCount()).To(Equal(1))
+			Expect(contexts.DeleteContextArgsForCall(0)).To(Equal("context-a"))
+		})
+
+		Context("when the context does not exist", func() {
+			BeforeEach(func() {
+				contexts.DeleteContextReturns(&taskpkg.UnknownContextError{Name: "context-a"})
+			})
+
+			It("responds with a 404", func() {
+				rsp, err := deletee("/api/v1/contexts/context-a")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+				assertError(rsp, "unknown context 'context-a'")
+			})
+		})
+	})
+
+	Context("when the API has users", func() {
+		BeforeEach(func() {
+			users := memory.New().(user.Repo)
+			Expect(users.CreateUser(&user.User{Name: "user-a"})).To(Succeed())
+			options = append(options, api.WithUsers(users))
+			authenticator.AuthenticateReturns("user-a", nil)
+
+			contextRepo.TasksReturns([]*taskpkg.Task{
+				{ID: 1, Name: "task-a", Owner: "user-a"},
+				{ID: 2, Name: "task-b"},
+				{ID: 3, Name: "task-c", Owner: "user-b", SharedWith: []string{"user-a"}},
+			}, nil)
+		})
+
+		It("copies a context in which the user can see every task", func() {
+			rsp, err := post("/api/v1/contexts", api.Context{Name: "context-b", CopyFrom: "context-a"})
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusCreated))
+			Expect(contexts.RepoArgsForCall(0)).To(Equal("context-a"))
+			Expect(contexts.CopyContextCallCount()).To(Equal(1))
+		})
+
+		It("does not delete a context with tasks that belong to someone else", func() {
+			rsp, err := deletee("/api/v1/contexts/context-a")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusForbidden))
+			assertError(rsp, "context 'context-a' has tasks that belong to someone else")
+			Expect(contexts.DeleteContextCallCount()).To(Equal(0))
+		})
+
+		Context("when the user cannot see one of the tasks", func() {
+			BeforeEach(func() {
+				contextRepo.TasksReturns([]*taskpkg.Task{
+					{ID: 1, Name: "task-a", Owner: "user-a"},
+					{ID: 2, Name: "task-b", Owner: "user-b"},
+				}, nil)
+			})
+
+			It("does not copy the context", func() {
+				rsp, err := post("/api/v1/contexts", api.Context{Name: "context-b", CopyFrom: "context-a"})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusForbidden))
+				assertError(rsp, "context 'context-a' has tasks that belong to someone else")
+				Expect(contexts.CopyContextCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when every task belongs to the user, or to no one", func() {
+			BeforeEach(func() {
+				contextRepo.TasksReturns([]*taskpkg.Task{
+					{ID: 1, Name: "task-a", Owner: "user-a"},
+					{ID: 2, Name: "task-b"},
+				}, nil)
+			})
+
+			It("deletes the context", func() {
+				rsp, err := deletee("/api/v1/contexts/context-a")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+				Expect(contexts.DeleteContextCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the context has a webhook that belongs to someone else", func() {
+			BeforeEach(func() {
+				webhookRepo := memory.New()
+				Expect(webhookRepo.CreateTask(&taskpkg.Task{Name: "task-a", Owner: "user-a"})).To(Succeed())
+				Expect(webhookRepo.(webhook.Repo).CreateWebhook(&webhook.Webhook{Owner: "user-b"})).To(Succeed())
+				contexts.RepoReturns(webhookRepo, nil)
+			})
+
+			It("does not delete the context", func() {
+				rsp, err := deletee("/api/v1/contexts/context-a")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusForbidden))
+				assertError(rsp, "context 'context-a' has webhooks that belong to someone else")
+				Expect(contexts.DeleteContextCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("a resource in a context", func() {
+		var tasks []*taskpkg.Task
+
+		BeforeEach(func() {
+			tasks = []*taskpkg.Task{&taskpkg.Task{Name: "task-a", ID: 1}}
+			contextRepo.TasksReturns(tasks, nil)
+			contextRepo.CreateTaskStub = func(t *taskpkg.Task) error {
+				t.ID = 10
+				return nil
+			}
+		})
+
+		It("uses the repo of the context", func() {
+			rsp, err := get("/api/v1/contexts/context-a/tasks")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+			assertTasks(rsp, tasks)
+
+			Expect(contexts.RepoCallCount()).To(Equal(1))
+			Expect(contexts.RepoArgsForCall(0)).To(Equal("context-a"))
+			Expect(repo.TasksCallCount()).To(Equal(0))
+		})
+
+		It("responds with the location in the context", func() {
+			rsp, err := post("/api/v1/contexts/context-a/tasks", &taskpkg.Task{Name: "task-b"})
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusCreated))
+			Expect(rsp.Header.Get("Location")).To(Equal("/api/v1/contexts/context-a/tasks/10"))
+		})
+
+		Context("when the name is invalid", func() {
+			It("responds with a 400", func() {
+				rsp, err := get("/api/v1/contexts/.context-a/tasks")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+				Expect(contexts.RepoCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when getting the repo fails", func() {
+			BeforeEach(func() {
+				contexts.RepoReturns(nil, errors.New("some repo error"))
+			})
+
+			It("returns a 500 with an error", func() {
+				rsp, err := get("/api/v1/contexts/context-a/tasks")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusInternalServerError))
+				assertError(rsp, "some repo error")
+			})
+		})
+	})
+})
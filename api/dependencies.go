@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/task"
+)
+
+type getDependenciesHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+}
+
+func (h *getDependenciesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	dependencies, err := h.repo.Dependencies()
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond(h.logger, w, http.StatusOK, dependencies)
+}
+
+type createDependencyHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+}
+
+func (h *createDependencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var dependency task.Dependency
+	if err := json.Unmarshal(data, &dependency); err != nil {
+		respondWithError(h.logger, w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.repo.CreateDependency(&dependency); err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Add("Location", fmt.Sprintf("%s/%d", r.URL.Path, dependency.ID))
+	respond(h.logger, w, http.StatusCreated, nil)
+}
@@ -0,0 +1,129 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/api/apifakes"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/taskfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+var _ = Describe("Dependencies", func() {
+	var (
+		repo          *taskfakes.FakeRepo
+		authenticator *apifakes.FakeAuthenticator
+
+		process ifrit.Process
+	)
+
+	BeforeEach(func() {
+		repo = &taskfakes.FakeRepo{}
+		authenticator = &apifakes.FakeAuthenticator{}
+
+		a := api.New(lagertest.NewTestLogger("api"), repo, authenticator)
+		runner := http_server.New("127.0.0.1:12345", a)
+		process = ifrit.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	Describe("Get", func() {
+		var dependencies []*task.Dependency
+		BeforeEach(func() {
+			dependencies = []*task.Dependency{
+				&task.Dependency{TaskID: 10, DependsOnID: 11, ID: 1},
+				&task.Dependency{TaskID: 10, DependsOnID: 12, ID: 2},
+				&task.Dependency{TaskID: 11, DependsOnID: 12, ID: 3},
+			}
+			repo.DependenciesReturnsOnCall(0, dependencies, nil)
+		})
+
+		It("responds with the dependencies that the repo returns", func() {
+			rsp, err := get("/api/v1/dependencies")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+			assertDependencies(rsp, dependencies)
+
+			Expect(repo.DependenciesCallCount()).To(Equal(1))
+		})
+
+		Context("when getting the dependencies fails", func() {
+			BeforeEach(func() {
+				repo.DependenciesReturnsOnCall(0, nil, errors.New("some dependencies error"))
+			})
+
+			It("returns a 500 with an error", func() {
+				rsp, err := get("/api/v1/dependencies")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusInternalServerError))
+				assertError(rsp, "some dependencies error")
+			})
+		})
+	})
+
+	Describe("Post", func() {
+		var dependency *task.Dependency
+		BeforeEach(func() {
+			dependency = &task.Dependency{TaskID: 10, DependsOnID: 11, ID: 1}
+
+			repo.CreateDependencyStub = func(d *task.Dependency) error {
+				d.ID = 10
+				return nil
+			}
+		})
+
+		It("creates a dependency and responds with the location", func() {
+			rsp, err := post("/api/v1/dependencies", dependency)
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusCreated))
+			Expect(rsp.Header.Get("Location")).To(Equal("/api/v1/dependencies/10"))
+
+			dependency.ID = 10
+			Expect(repo.CreateDependencyCallCount()).To(Equal(1))
+			Expect(repo.CreateDependencyArgsForCall(0)).To(Equal(dependency))
+		})
+
+		Context("when the request payload is invalid", func() {
+			It("responds with a 400 bad request", func() {
+				rsp, err := post("/api/v1/dependencies", "askjdnflkajnsfd")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("when we fail to create the dependency", func() {
+			BeforeEach(func() {
+				repo.CreateDependencyReturnsOnCall(0, errors.New("some create error"))
+			})
+
+			It("responds with a 500 internal server error", func() {
+
+				rsp, err := post("/api/v1/dependencies", dependency)
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusInternalServerError))
+				assertError(rsp, "some create error")
+			})
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/task"
+	"github.com/tedsuo/rata"
+)
+
+func findDependency(
+	logger lager.Logger,
+	repo task.Repo,
+	w http.ResponseWriter,
+	r *http.Request,
+) (*task.Dependency, int) {
+	id := rata.Param(r, "id")
+	idN, err := strconv.Atoi(id)
+	if err != nil {
+		respondWithError(logger, w, http.StatusBadRequest, err)
+		return nil, 0
+	}
+
+	dependency, err := repo.FindDependencyByID(idN)
+	if err != nil {
+		respondWithError(logger, w, http.StatusInternalServerError, err)
+		return nil, 0
+	}
+
+	if dependency == nil {
+		respondWithError(logger, w, http.StatusNotFound, fmt.Errorf("unknown dependency with ID %d", idN))
+		return nil, 0
+	}
+
+	return dependency, idN
+}
+
+type getDependencyHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+}
+
+func (h *getDependencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if dependency, _ := findDependency(h.logger, h.repo, w, r); dependency != nil {
+		respond(h.logger, w, http.StatusOK, dependency)
+	}
+}
+
+type deleteDependencyHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+}
+
+func (h *deleteDependencyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if dependency, _ := findDependency(h.logger, h.repo, w, r); dependency != nil {
+		if err := h.repo.DeleteDependency(dependency); err != nil {
+			respondWithError(h.logger, w, http.StatusInternalServerError, err)
+			return
+		}
+
+		respond(h.logger, w, http.StatusNoContent, dependency)
+	}
+}
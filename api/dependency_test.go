@@ -0,0 +1,142 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"os"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/api/apifakes"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/taskfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+var _ = Describe("Dependency", func() {
+	var (
+		repo          *taskfakes.FakeRepo
+		authenticator *apifakes.FakeAuthenticator
+
+		process ifrit.Process
+	)
+
+	testAllCommonFailures := func(doFunc func(path string) (*http.Response, error)) {
+		Context("when the id in the path is invalid", func() {
+			It("returns with 400 bad request", func() {
+				rsp, err := doFunc("/api/v1/dependencies/tuna")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+
+		Context("when the repo fails to get the dependency", func() {
+			BeforeEach(func() {
+				repo.FindDependencyByIDReturnsOnCall(0, nil, errors.New("some find error"))
+			})
+
+			It("responds with a 500 internal server error plus the error", func() {
+				rsp, err := doFunc("/api/v1/dependencies/10")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusInternalServerError))
+				assertError(rsp, "some find error")
+			})
+		})
+
+		Context("when the dependency does not exist", func() {
+			BeforeEach(func() {
+				repo.FindDependencyByIDReturnsOnCall(0, nil, nil)
+			})
+
+			It("responds with a 404 not found", func() {
+				rsp, err := doFunc("/api/v1/dependencies/10")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+			})
+		})
+	}
+
+	BeforeEach(func() {
+		repo = &taskfakes.FakeRepo{}
+		authenticator = &apifakes.FakeAuthenticator{}
+
+		a := api.New(lagertest.NewTestLogger("api"), repo, authenticator)
+		runner := http_server.New("127.0.0.1:12345", a)
+		process = ifrit.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	Describe("Get", func() {
+		var dependency *task.Dependency
+		BeforeEach(func() {
+			dependency = &task.Dependency{TaskID: 10, DependsOnID: 11, ID: 1}
+			repo.FindDependencyByIDReturnsOnCall(0, dependency, nil)
+		})
+
+		It("responds with the dependency", func() {
+			rsp, err := get("/api/v1/dependencies/10")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+			assertDependency(rsp, dependency)
+
+			Expect(repo.FindDependencyByIDCallCount()).To(Equal(1))
+			Expect(repo.FindDependencyByIDArgsForCall(0)).To(Equal(10))
+		})
+
+		testAllCommonFailures(get)
+	})
+
+	Describe("Delete", func() {
+		var dependency *task.Dependency
+		BeforeEach(func() {
+			dependency = &task.Dependency{TaskID: 10, DependsOnID: 11, ID: 1}
+			repo.FindDependencyByIDReturnsOnCall(0, dependency, nil)
+		})
+
+		It("deletes the dependency", func() {
+			rsp, err := deletee("/api/v1/dependencies/10")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+
+			Expect(repo.FindDependencyByIDCallCount()).To(Equal(1))
+			Expect(repo.FindDependencyByIDArgsForCall(0)).To(Equal(10))
+
+			Expect(repo.DeleteDependencyCallCount()).To(Equal(1))
+			Expect(repo.DeleteDependencyArgsForCall(0)).To(Equal(dependency))
+		})
+
+		Context("when the repo fails to delete the dependency", func() {
+			BeforeEach(func() {
+				repo.DeleteDependencyReturnsOnCall(0, errors.New("some delete failure"))
+			})
+
+			It("responds with a 500 and the error", func() {
+				rsp, err := deletee("/api/v1/dependencies/10")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusInternalServerError))
+				assertError(rsp, "some delete failure")
+			})
+		})
+
+		testAllCommonFailures(deletee)
+	})
+})
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/query"
 	"github.com/ankeesler/anwork/task"
 )
 
@@ -16,15 +19,84 @@ type getEventsHandler struct {
 }
 
 func (h *getEventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	events, err := h.repo.Events()
+	q, err := parseEventQuery(r.URL.Query())
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusBadRequest, err)
+		return
+	}
+
+	events, err := query.EventsMatching(h.repo, q)
 	if err != nil {
 		respondWithError(h.logger, w, http.StatusInternalServerError, err)
 		return
 	}
 
+	// A full page may be followed by another one, which starts after its last task.Event.
+	if q.Limit != 0 && len(events) == q.Limit {
+		values := r.URL.Query()
+		values.Set("cursor", strconv.Itoa(events[len(events)-1].ID))
+		w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, values.Encode()))
+	}
+
 	respond(h.logger, w, http.StatusOK, events)
 }
 
+// parseEventQuery reads a query.EventQuery from the query parameters of a request to get_events.
+func parseEventQuery(values url.Values) (*query.EventQuery, error) {
+	q := &query.EventQuery{}
+
+	if taskID := values.Get("task_id"); taskID != "" {
+		id, err := strconv.Atoi(taskID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid task_id '%s'", taskID)
+		}
+		q.TaskID = &id
+	}
+
+	for _, t := range values["type"] {
+		eventType, err := strconv.Atoi(t)
+		if err != nil {
+			return nil, fmt.Errorf("invalid type '%s'", t)
+		}
+		q.Types = append(q.Types, task.EventType(eventType))
+	}
+
+	for name, value := range map[string]*int64{"since": &q.Since, "until": &q.Until} {
+		if s := values.Get(name); s != "" {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s '%s'", name, s)
+			}
+			*value = n
+		}
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		id, err := strconv.Atoi(cursor)
+		if err != nil || id < 0 {
+			return nil, fmt.Errorf("invalid cursor '%s'", cursor)
+		}
+		q.Cursor = &id
+	}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid limit '%s'", limit)
+		}
+		q.Limit = n
+	}
+
+	switch order := query.Order(values.Get("order")); order {
+	case "", query.OrderAscending, query.OrderDescending:
+		q.Order = order
+	default:
+		return nil, fmt.Errorf("invalid order '%s'", order)
+	}
+
+	return q, nil
+}
+
 type createEventHandler struct {
 	logger lager.Logger
 	repo   task.Repo
@@ -48,6 +120,6 @@ func (h *createEventHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Add("Location", fmt.Sprintf("/api/v1/events/%d", event.ID))
+	w.Header().Add("Location", fmt.Sprintf("%s/%d", r.URL.Path, event.ID))
 	respond(h.logger, w, http.StatusCreated, nil)
 }
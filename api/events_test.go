@@ -11,6 +11,7 @@ import (
 	"github.com/ankeesler/anwork/task"
 	"github.com/ankeesler/anwork/task/taskfakes"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/http_server"
@@ -60,6 +61,84 @@ var _ = Describe("Events", func() {
 			Expect(repo.EventsCallCount()).To(Equal(1))
 		})
 
+		Context("when the request has query parameters", func() {
+			BeforeEach(func() {
+				events = []*task.Event{
+					&task.Event{Title: "event-a", ID: 1, TaskID: 1, Type: task.EventTypeCreate, Date: 100},
+					&task.Event{Title: "event-b", ID: 2, TaskID: 2, Type: task.EventTypeCreate, Date: 200},
+					&task.Event{Title: "event-c", ID: 3, TaskID: 1, Type: task.EventTypeNote, Date: 300},
+					&task.Event{Title: "event-d", ID: 4, TaskID: 1, Type: task.EventTypeSetState, Date: 400},
+				}
+				for i := 0; i < 3; i++ {
+					repo.EventsReturnsOnCall(i, events, nil)
+				}
+			})
+
+			It("responds with the events that they select", func() {
+				rsp, err := get("/api/v1/events?task_id=1&type=3&type=2&since=200&until=500")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+				assertEvents(rsp, []*task.Event{events[2], events[3]})
+			})
+
+			It("links to the next page when the page is full", func() {
+				rsp, err := get("/api/v1/events?order=desc&limit=2")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+				assertEvents(rsp, []*task.Event{events[3], events[2]})
+				Expect(rsp.Header.Get("Link")).To(Equal(`</api/v1/events?cursor=3&limit=2&order=desc>; rel="next"`))
+
+				rsp, err = get("/api/v1/events?cursor=3&limit=2&order=desc")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+				assertEvents(rsp, []*task.Event{events[1], events[0]})
+				Expect(rsp.Header.Get("Link")).To(Equal(`</api/v1/events?cursor=1&limit=2&order=desc>; rel="next"`))
+
+				rsp, err = get("/api/v1/events?cursor=1&limit=2&order=desc")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+				assertEvents(rsp, []*task.Event{})
+				Expect(rsp.Header.Get("Link")).To(BeEmpty())
+			})
+
+			It("starts the page after a cursor of 0, since that can be the ID of an event", func() {
+				events[0].ID = 0
+				rsp, err := get("/api/v1/events?cursor=0&limit=1")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+				assertEvents(rsp, []*task.Event{events[1]})
+			})
+
+			DescribeTable(
+				"responds with a 400 when a query parameter is invalid",
+				func(rawQuery, message string) {
+					rsp, err := get("/api/v1/events?" + rawQuery)
+					Expect(err).NotTo(HaveOccurred())
+					defer rsp.Body.Close()
+
+					Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+					assertError(rsp, message)
+				},
+				Entry("task_id", "task_id=a", "invalid task_id 'a'"),
+				Entry("type", "type=1&type=b", "invalid type 'b'"),
+				Entry("since", "since=yesterday", "invalid since 'yesterday'"),
+				Entry("until", "until=1.5", "invalid until '1.5'"),
+				Entry("cursor", "cursor=-1", "invalid cursor '-1'"),
+				Entry("limit", "limit=lots", "invalid limit 'lots'"),
+				Entry("order", "order=random", "invalid order 'random'"),
+			)
+		})
+
 		Context("when getting the events fails", func() {
 			BeforeEach(func() {
 				repo.EventsReturnsOnCall(0, nil, errors.New("some events error"))
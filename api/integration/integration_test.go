@@ -14,8 +14,13 @@ import (
 	"github.com/ankeesler/anwork/api/auth"
 	"github.com/ankeesler/anwork/api/client"
 	"github.com/ankeesler/anwork/api/client/cache"
+	"github.com/ankeesler/anwork/manager"
+	"github.com/ankeesler/anwork/session"
 	"github.com/ankeesler/anwork/task"
 	"github.com/ankeesler/anwork/task/fs"
+	"github.com/ankeesler/anwork/task/memory"
+	"github.com/ankeesler/anwork/task/repotest"
+	"github.com/ankeesler/anwork/user"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/tedsuo/ifrit"
@@ -48,10 +53,11 @@ var _ = Describe("Repo", func() {
 			rand.Reader,
 			&privateKey.PublicKey,
 			secret,
+			memory.New().(session.Repo),
 		)
 
 		logger = lagertest.NewTestLogger("api")
-		a := api.New(logger, repo, auth)
+		a := api.New(logger, repo, auth, api.WithContexts(fs.NewContexts(dir)))
 		runner := http_server.New("127.0.0.1:12345", a)
 		process = ifrit.Invoke(runner)
 
@@ -65,12 +71,230 @@ var _ = Describe("Repo", func() {
 		Expect(os.RemoveAll(dir)).To(Succeed())
 	})
 
-	task.RunRepoTests(func() task.Repo {
+	newClient := func() task.Repo {
 		return client.New(
 			logger,
 			"127.0.0.1:12345",
 			auth.NewClient(clock.NewClock(), privateKey, secret),
 			cache.New(cacheFile),
 		)
+	}
+
+	repotest.RunRepoTests(newClient)
+
+	Describe("Contexts", func() {
+		repotest.RunContextsTests(func() task.Contexts {
+			return client.NewContexts(
+				logger,
+				"127.0.0.1:12345",
+				auth.NewClient(clock.NewClock(), privateKey, secret),
+				cache.New(cacheFile),
+			)
+		})
+	})
+
+	Describe("undo and redo through a manager", func() {
+		It("restores a deleted task, and deletes it again", func() {
+			m := manager.New(newClient(), clock.NewClock())
+			Expect(m.Create("task-a")).To(Succeed())
+			Expect(m.Create("task-b")).To(Succeed())
+			Expect(m.AddDependency("task-a", "task-b")).To(Succeed())
+			Expect(m.Delete("task-b")).To(Succeed())
+
+			Expect(m.Undo(1)).To(Succeed())
+			restored, err := m.FindByName("task-b")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(restored).NotTo(BeNil())
+			Expect(m.Dependencies("task-a")).To(Equal([]*task.Task{restored}))
+
+			Expect(m.Redo()).To(Succeed())
+			Expect(m.FindByName("task-b")).To(BeNil())
+		})
+	})
+
+	Describe("two clients that change the same task", func() {
+		It("only lets the first one change it", func() {
+			a, b := newClient(), newClient()
+			Expect(a.CreateTask(&task.Task{Name: "task-a"})).To(Succeed())
+
+			taskA, err := a.FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			taskB, err := b.FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+
+			taskA.Priority = 5
+			Expect(a.UpdateTask(taskA)).To(Succeed())
+
+			taskB.Priority = 20
+			err = b.UpdateTask(taskB)
+			Expect(err).To(BeAssignableToTypeOf(&client.ConflictError{}))
+			Expect(b.DeleteTask(taskB)).To(BeAssignableToTypeOf(&client.ConflictError{}))
+
+			taskB, err = b.FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(taskB.Priority).To(Equal(5))
+			Expect(taskB.Version).To(Equal(2))
+			Expect(b.DeleteTask(taskB)).To(Succeed())
+		})
+
+		It("keeps both changes when they change different fields of an unversioned task", func() {
+			a, b := newClient(), newClient()
+			Expect(a.CreateTask(&task.Task{Name: "task-a", State: task.StateReady})).To(Succeed())
+
+			taskA, err := a.FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			taskB, err := b.FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+
+			taskA.Version, taskB.Version = 0, 0
+			taskA.Priority = 5
+			Expect(a.UpdateTask(taskA)).To(Succeed())
+			taskB.State = task.StateRunning
+			Expect(b.UpdateTask(taskB)).To(Succeed())
+
+			t, err := a.FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t.Priority).To(Equal(5))
+			Expect(t.State).To(BeEquivalentTo(task.StateRunning))
+			Expect(t.Version).To(Equal(3))
+			Expect(a.DeleteTask(t)).To(Succeed())
+		})
+	})
+
+	Describe("a manager that uses the /api/v2 endpoints", func() {
+		It("performs the operations on the server, which can then be undone", func() {
+			repo := newClient()
+			m := client.NewManager(
+				logger,
+				"127.0.0.1:12345",
+				auth.NewClient(clock.NewClock(), privateKey, secret),
+				cache.New(cacheFile),
+				manager.New(repo, clock.NewClock()),
+				"some-user",
+			)
+			Expect(m.Create("task-a")).To(Succeed())
+			Expect(m.SetState("task-a", task.StateFinished)).To(Succeed())
+
+			events, err := m.Events()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(2))
+			Expect(events[1].Title).To(Equal("Set state on task 'task-a' from Ready to Finished"))
+			Expect(events[1].Actor).To(Equal("some-user"))
+
+			Expect(m.Archive()).To(Succeed())
+			Expect(m.FindByName("task-a")).To(BeNil())
+
+			Expect(m.Undo(1)).To(Succeed())
+			Expect(m.FindByName("task-a")).NotTo(BeNil())
+		})
+	})
+})
+
+var _ = Describe("Users", func() {
+	var (
+		dir string
+
+		logger     lager.Logger
+		privateKey *rsa.PrivateKey
+		secret     []byte
+
+		process ifrit.Process
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "anwork-api-integration")
+		Expect(err).NotTo(HaveOccurred())
+
+		repo := memory.New()
+		users := repo.(user.Repo)
+		for _, name := range []string{"user-a", "user-b"} {
+			u := &user.User{Name: name}
+			Expect(u.SetPassword(name + "-password")).To(Succeed())
+			Expect(users.CreateUser(u)).To(Succeed())
+		}
+
+		privateKey = generatePrivateKey()
+		secret = generateSecret()
+		auth := auth.NewServer(
+			clock.NewClock(),
+			rand.Reader,
+			&privateKey.PublicKey,
+			secret,
+			repo.(session.Repo),
+		)
+
+		logger = lagertest.NewTestLogger("api")
+		a := api.New(logger, repo, auth, api.WithUsers(users))
+		runner := http_server.New("127.0.0.1:12345", a)
+		process = ifrit.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		Eventually(process.Wait()).Should(Receive())
+
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	// login logs in as a user, and returns a client that makes its requests as them.
+	login := func(name, password string) (task.Repo, error) {
+		authenticator := auth.NewClient(clock.NewClock(), privateKey, secret)
+		c := cache.New(filepath.Join(dir, name+"-cache"))
+		if err := client.Login(logger, "127.0.0.1:12345", authenticator, c, name, password); err != nil {
+			return nil, err
+		}
+		return client.New(logger, "127.0.0.1:12345", authenticator, c), nil
+	}
+
+	It("only shows each user their own tasks", func() {
+		a, err := login("user-a", "user-a-password")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(a.CreateTask(&task.Task{Name: "task-a", State: task.StateReady})).To(Succeed())
+		tasks, err := a.Tasks()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tasks).To(HaveLen(1))
+		Expect(tasks[0].Owner).To(Equal("user-a"))
+
+		b, err := login("user-b", "user-b-password")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(b.Tasks()).To(BeEmpty())
+		Expect(b.CreateTask(&task.Task{Name: "task-a", State: task.StateReady})).To(Succeed())
+		tasks, err = b.Tasks()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tasks).To(HaveLen(1))
+		Expect(tasks[0].Owner).To(Equal("user-b"))
+	})
+
+	It("keeps each user logged in until they log out", func() {
+		a, err := login("user-a", "user-a-password")
+		Expect(err).NotTo(HaveOccurred())
+		b, err := login("user-b", "user-b-password")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(a.Tasks()).To(BeEmpty())
+		Expect(b.Tasks()).To(BeEmpty())
+
+		sessions := client.NewSessions(
+			logger,
+			"127.0.0.1:12345",
+			auth.NewClient(clock.NewClock(), privateKey, secret),
+			cache.New(filepath.Join(dir, "user-a-cache")),
+		)
+		ss, err := sessions.Sessions()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ss).To(HaveLen(1))
+		Expect(ss[0].Subject).To(Equal("user-a"))
+
+		// The client of user-a still has the token, which is no longer accepted.
+		Expect(sessions.Logout()).To(Succeed())
+		_, err = a.Tasks()
+		Expect(err).To(MatchError(ContainSubstring("403")))
+		Expect(b.Tasks()).To(BeEmpty())
+	})
+
+	It("does not log in with the wrong password", func() {
+		_, err := login("user-a", "user-b-password")
+		Expect(err).To(MatchError(ContainSubstring("invalid name or password")))
 	})
 })
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/manager"
+	taskpkg "github.com/ankeesler/anwork/task"
+)
+
+// An operation is what one of the /api/v2 routes does with a manager.Manager.
+type operation struct {
+	perform func(manager.Manager, *Operation) error
+}
+
+var operations = map[string]operation{
+	"create": {
+		perform: func(m manager.Manager, o *Operation) error { return m.Create(o.Name) },
+	},
+	"set_state": {
+		perform: func(m manager.Manager, o *Operation) error { return m.SetState(o.Name, o.State) },
+	},
+	"set_priority": {
+		perform: func(m manager.Manager, o *Operation) error { return m.SetPriority(o.Name, o.Priority) },
+	},
+	"note": {
+		perform: func(m manager.Manager, o *Operation) error { return m.Note(o.Name, o.Note) },
+	},
+	"rename": {
+		perform: func(m manager.Manager, o *Operation) error { return m.Rename(o.Name, o.To) },
+	},
+	"delete": {
+		perform: func(m manager.Manager, o *Operation) error { return m.Delete(o.Name) },
+	},
+	"archive": {
+		perform: func(m manager.Manager, o *Operation) error { return m.Archive() },
+	},
+}
+
+// operationHandler serves one of the /api/v2 routes by performing its operation with a
+// manager.Manager that uses the task.Repo, as a user (or as no one in particular, if the user is
+// "").
+type operationHandler struct {
+	logger lager.Logger
+	repo   taskpkg.Repo
+	clock  clock.Clock
+	user   string
+	name   string
+}
+
+func (h *operationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var o Operation
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &o); err != nil {
+			respondWithError(h.logger, w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	op := operations[h.name]
+	if err, statusCode := h.validate(&o); err != nil {
+		respondWithError(h.logger, w, statusCode, err)
+		return
+	}
+
+	// Someone who has logged in can only act as themselves.
+	if h.user != "" {
+		o.Actor = h.user
+	}
+
+	h.logger.Debug("performing-operation", lager.Data{"operation": h.name, "arguments": o})
+	result := &Result{Tasks: []*taskpkg.Task{}, Events: []*taskpkg.Event{}}
+	m := manager.New(&recorder{Repo: h.repo, result: result}, h.clock, manager.WithActor(o.Actor))
+	if err := op.perform(m, &o); err != nil {
+		respondWithError(h.logger, w, operationErrorStatusCode(err), err)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if h.name == "create" {
+		statusCode = http.StatusCreated
+	}
+	respond(h.logger, w, statusCode, result)
+}
+
+// validate returns an error, and the status code with which to respond, if an Operation cannot be
+// performed.
+func (h *operationHandler) validate(o *Operation) (error, int) {
+	if h.name != "archive" && o.Name == "" {
+		return errors.New("missing task name"), http.StatusBadRequest
+	}
+
+	return nil, 0
+}
+
+// operationErrorStatusCode returns the status code with which to respond when a manager.Manager
+// fails to perform an operation: the errors that are caused by the Operation are the client's
+// fault, and the rest are the server's.
+func operationErrorStatusCode(err error) int {
+	var (
+		unknownTaskError      manager.UnknownTaskError
+		invalidStateError     manager.InvalidStateError
+		cyclicDependencyError manager.CyclicDependencyError
+	)
+	switch {
+	case errors.As(err, &unknownTaskError):
+		return http.StatusNotFound
+	case errors.As(err, &invalidStateError), errors.As(err, &cyclicDependencyError):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// A recorder is a task.Repo that records the task.Task's and task.Event's that are changed through
+// it in a Result.
+type recorder struct {
+	taskpkg.Repo
+	result *Result
+}
+
+func (r *recorder) CreateTask(task *taskpkg.Task) error {
+	if err := r.Repo.CreateTask(task); err != nil {
+		return err
+	}
+	r.recordTask(task)
+	return nil
+}
+
+func (r *recorder) UpdateTask(task *taskpkg.Task) error {
+	if err := r.Repo.UpdateTask(task); err != nil {
+		return err
+	}
+	r.recordTask(task)
+	return nil
+}
+
+func (r *recorder) DeleteTask(task *taskpkg.Task) error {
+	if err := r.Repo.DeleteTask(task); err != nil {
+		return err
+	}
+	r.recordTask(task)
+	return nil
+}
+
+func (r *recorder) CreateEvent(event *taskpkg.Event) error {
+	if err := r.Repo.CreateEvent(event); err != nil {
+		return err
+	}
+	eventCopy := *event
+	r.result.Events = append(r.result.Events, &eventCopy)
+	return nil
+}
+
+// WithTx makes the recorder a task.Transactor when its task.Repo is one, so that the
+// manager.Manager still makes each operation all-or-nothing.
+func (r *recorder) WithTx(do func(taskpkg.Repo) error) error {
+	transactor, ok := r.Repo.(taskpkg.Transactor)
+	if !ok {
+		return do(r)
+	}
+
+	return transactor.WithTx(func(repo taskpkg.Repo) error {
+		return do(&recorder{Repo: repo, result: r.result})
+	})
+}
+
+// recordTask records the latest values of a task.Task.
+func (r *recorder) recordTask(task *taskpkg.Task) {
+	taskCopy := *task
+	for i, t := range r.result.Tasks {
+		if t.ID == task.ID {
+			r.result.Tasks[i] = &taskCopy
+			return
+		}
+	}
+	r.result.Tasks = append(r.result.Tasks, &taskCopy)
+}
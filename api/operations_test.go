@@ -0,0 +1,295 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/api/apifakes"
+	"github.com/ankeesler/anwork/manager"
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/memory"
+	"github.com/ankeesler/anwork/task/taskfakes"
+	"github.com/ankeesler/anwork/user"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+var _ = Describe("Operations", func() {
+	var (
+		repo          taskpkg.Repo
+		contexts      taskpkg.Contexts
+		authenticator *apifakes.FakeAuthenticator
+		now           time.Time
+		options       []api.Option
+
+		process ifrit.Process
+	)
+
+	BeforeEach(func() {
+		repo = memory.New()
+		contexts = memory.NewContexts()
+		authenticator = &apifakes.FakeAuthenticator{}
+		now = time.Now()
+		options = nil
+	})
+
+	JustBeforeEach(func() {
+		options = append(options, api.WithContexts(contexts), api.WithClock(fakeclock.NewFakeClock(now)))
+		a := api.New(lagertest.NewTestLogger("api"), repo, authenticator, options...)
+		runner := http_server.New("127.0.0.1:12345", a)
+		process = ifrit.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	// perform POSTs an api.Operation and returns the api.Result.
+	perform := func(path string, o api.Operation, statusCode int) *api.Result {
+		rsp, err := post(path, o)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+
+		ExpectWithOffset(1, rsp.StatusCode).To(Equal(statusCode))
+
+		data, err := ioutil.ReadAll(rsp.Body)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		var result api.Result
+		ExpectWithOffset(1, json.Unmarshal(data, &result)).To(Succeed())
+		return &result
+	}
+
+	Describe("Create", func() {
+		It("creates the task with an event and responds with both", func() {
+			result := perform("/api/v2/create", api.Operation{Name: "task-a", Actor: "some-user"}, http.StatusCreated)
+
+			task, err := repo.FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task).NotTo(BeNil())
+			Expect(task.State).To(Equal(taskpkg.StateReady))
+			Expect(result.Tasks).To(Equal([]*taskpkg.Task{task}))
+
+			events, err := repo.Events()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Title).To(Equal("Created task 'task-a'"))
+			Expect(events[0].Date).To(Equal(now.Unix()))
+			Expect(events[0].Actor).To(Equal("some-user"))
+			Expect(result.Events).To(Equal(events))
+		})
+
+		Context("when the name is missing", func() {
+			It("responds with a 400", func() {
+				rsp, err := post("/api/v2/create", api.Operation{})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+				assertError(rsp, "missing task name")
+			})
+		})
+
+		Context("when the repo fails", func() {
+			BeforeEach(func() {
+				fakeRepo := &taskfakes.FakeRepo{}
+				fakeRepo.CreateTaskReturns(errors.New("some create task error"))
+				repo = fakeRepo
+			})
+
+			It("responds with a 500", func() {
+				rsp, err := post("/api/v2/create", api.Operation{Name: "task-a"})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusInternalServerError))
+				assertError(rsp, "some create task error")
+			})
+		})
+	})
+
+	Context("when the API has users", func() {
+		BeforeEach(func() {
+			users := memory.New().(user.Repo)
+			Expect(users.CreateUser(&user.User{Name: "user-a"})).To(Succeed())
+			options = append(options, api.WithUsers(users))
+			authenticator.AuthenticateReturns("user-a", nil)
+		})
+
+		It("records the user as the actor, whoever the operation says it is", func() {
+			result := perform("/api/v2/create", api.Operation{Name: "task-a", Actor: "user-b"}, http.StatusCreated)
+
+			Expect(result.Events).To(HaveLen(1))
+			Expect(result.Events[0].Actor).To(Equal("user-a"))
+		})
+	})
+
+	Context("when the manager finds a dependency cycle", func() {
+		BeforeEach(func() {
+			fakeRepo := &taskfakes.FakeRepo{}
+			fakeRepo.FindTaskByNameReturns(&taskpkg.Task{Name: "task-a"}, nil)
+			fakeRepo.UpdateTaskReturns(manager.CyclicDependencyError{Name: "task-a", DependsOn: "task-b"})
+			repo = fakeRepo
+		})
+
+		It("responds with a 400", func() {
+			rsp, err := post("/api/v2/set-priority", api.Operation{Name: "task-a", Priority: 3})
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+			assertError(rsp, "task 'task-a' cannot depend on task 'task-b' since it would create a cycle")
+		})
+	})
+
+	Context("when there are tasks", func() {
+		var taskA, taskB *taskpkg.Task
+
+		JustBeforeEach(func() {
+			perform("/api/v2/create", api.Operation{Name: "task-a"}, http.StatusCreated)
+			perform("/api/v2/create", api.Operation{Name: "task-b"}, http.StatusCreated)
+
+			var err error
+			taskA, err = repo.FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			taskB, err = repo.FindTaskByName("task-b")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Describe("SetState", func() {
+			It("sets the state and responds with the task and the event", func() {
+				result := perform("/api/v2/set-state", api.Operation{Name: "task-a", State: taskpkg.StateRunning}, http.StatusOK)
+
+				Expect(result.Tasks).To(HaveLen(1))
+				Expect(result.Tasks[0].Name).To(Equal("task-a"))
+				Expect(result.Tasks[0].State).To(BeEquivalentTo(taskpkg.StateRunning))
+
+				Expect(result.Events).To(HaveLen(1))
+				Expect(result.Events[0].Type).To(BeEquivalentTo(taskpkg.EventTypeSetState))
+				Expect(result.Events[0].NewValue).To(Equal("Running"))
+			})
+
+			Context("when the state is invalid", func() {
+				It("responds with a 400", func() {
+					rsp, err := post("/api/v2/set-state", api.Operation{Name: "task-a", State: "Sleeping"})
+					Expect(err).NotTo(HaveOccurred())
+					defer rsp.Body.Close()
+
+					Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+					assertError(rsp, "invalid state 'Sleeping'")
+
+					Expect(repo.FindTaskByName("task-a")).To(Equal(taskA))
+				})
+			})
+
+			Context("when the task does not exist", func() {
+				It("responds with a 404", func() {
+					rsp, err := post("/api/v2/set-state", api.Operation{Name: "task-c", State: taskpkg.StateRunning})
+					Expect(err).NotTo(HaveOccurred())
+					defer rsp.Body.Close()
+
+					Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+					assertError(rsp, "unknown task with name 'task-c'")
+				})
+			})
+		})
+
+		Describe("SetPriority", func() {
+			It("sets the priority", func() {
+				result := perform("/api/v2/set-priority", api.Operation{Name: "task-b", Priority: 3}, http.StatusOK)
+
+				Expect(result.Tasks).To(HaveLen(1))
+				Expect(result.Tasks[0].Priority).To(Equal(3))
+				Expect(result.Events).To(HaveLen(1))
+				Expect(result.Events[0].Title).To(Equal("Set priority on task 'task-b' from 10 to 3"))
+			})
+		})
+
+		Describe("Note", func() {
+			It("adds the note without changing a task", func() {
+				result := perform("/api/v2/note", api.Operation{Name: "task-a", Note: "some note"}, http.StatusOK)
+
+				Expect(result.Tasks).To(BeEmpty())
+				Expect(result.Events).To(HaveLen(1))
+				Expect(result.Events[0].Note).To(Equal("some note"))
+			})
+		})
+
+		Describe("Rename", func() {
+			It("renames the task", func() {
+				result := perform("/api/v2/rename", api.Operation{Name: "task-a", To: "task-c"}, http.StatusOK)
+
+				Expect(result.Tasks).To(HaveLen(1))
+				Expect(result.Tasks[0].ID).To(Equal(taskA.ID))
+				Expect(result.Tasks[0].Name).To(Equal("task-c"))
+				Expect(result.Events).To(HaveLen(1))
+				Expect(result.Events[0].Type).To(BeEquivalentTo(taskpkg.EventTypeRename))
+			})
+		})
+
+		Describe("Delete", func() {
+			It("deletes the task and responds with it", func() {
+				result := perform("/api/v2/delete", api.Operation{Name: "task-a"}, http.StatusOK)
+
+				Expect(result.Tasks).To(Equal([]*taskpkg.Task{taskA}))
+				Expect(result.Events).To(HaveLen(1))
+				Expect(result.Events[0].Type).To(BeEquivalentTo(taskpkg.EventTypeDelete))
+
+				Expect(repo.FindTaskByName("task-a")).To(BeNil())
+			})
+
+			Context("when the task does not exist", func() {
+				It("responds with a 404", func() {
+					rsp, err := post("/api/v2/delete", api.Operation{Name: "task-c"})
+					Expect(err).NotTo(HaveOccurred())
+					defer rsp.Body.Close()
+
+					Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+					assertError(rsp, "unknown task with name 'task-c'")
+				})
+			})
+		})
+
+		Describe("Archive", func() {
+			It("deletes the finished tasks", func() {
+				perform("/api/v2/set-state", api.Operation{Name: "task-b", State: taskpkg.StateFinished}, http.StatusOK)
+				taskB.State = taskpkg.StateFinished
+				taskB.Version++
+
+				result := perform("/api/v2/archive", api.Operation{}, http.StatusOK)
+
+				Expect(result.Tasks).To(Equal([]*taskpkg.Task{taskB}))
+				Expect(result.Events).To(HaveLen(1))
+				Expect(result.Events[0].Title).To(Equal("Deleted task 'task-b'"))
+
+				tasks, err := repo.Tasks()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tasks).To(Equal([]*taskpkg.Task{taskA}))
+			})
+		})
+	})
+
+	Describe("an operation in a context", func() {
+		BeforeEach(func() {
+			Expect(contexts.CreateContext("context-a")).To(Succeed())
+		})
+
+		It("uses the repo of the context", func() {
+			perform("/api/v2/contexts/context-a/create", api.Operation{Name: "task-a"}, http.StatusCreated)
+
+			contextRepo, err := contexts.Repo("context-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contextRepo.FindTaskByName("task-a")).NotTo(BeNil())
+			Expect(repo.FindTaskByName("task-a")).To(BeNil())
+		})
+	})
+})
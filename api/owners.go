@@ -0,0 +1,499 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/query"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+)
+
+// A notOwnerError is returned when a user tries to do something that only the owner of a
+// task.Task can do.
+type notOwnerError struct {
+	message string
+}
+
+func (e *notOwnerError) Error() string {
+	return e.message
+}
+
+// An ownedRepo is a task.Repo as seen by a user: it only has the task.Task's that the user can see
+// (see task.Task.Owner), and the task.Event's and task.Dependency's about them. The task.Task's
+// that the user creates belong to them.
+type ownedRepo struct {
+	task.Repo
+	user string
+}
+
+// sees returns whether the user can see a task.Task.
+func (r *ownedRepo) sees(t *task.Task) bool {
+	return t.Owner == "" || t.Owner == r.user || t.IsSharedWith(r.user)
+}
+
+// hidden returns the IDs of the task.Task's, including the deleted ones, that the user cannot see.
+func (r *ownedRepo) hidden(events []*task.Event) (map[int]bool, error) {
+	hidden := map[int]bool{}
+
+	// The deleted task.Task's are only in the snapshots of the task.Event's that created and
+	// deleted them, the latest of which is the last.
+	for _, e := range events {
+		if (e.Type == task.EventTypeCreate || e.Type == task.EventTypeDelete) && e.Snapshot != "" {
+			var t task.Task
+			if err := json.Unmarshal([]byte(e.Snapshot), &t); err == nil {
+				hidden[e.TaskID] = !r.sees(&t)
+			}
+		}
+	}
+
+	tasks, err := r.Repo.Tasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		hidden[t.ID] = !r.sees(t)
+	}
+
+	return hidden, nil
+}
+
+// hiddenNow is hidden with the task.Event's in the task.Repo that create and delete task.Task's,
+// which are the only ones that hidden needs. It reads every task.Task, so it is only used when
+// they are all needed anyway; see seesTaskIDs.
+func (r *ownedRepo) hiddenNow() (map[int]bool, error) {
+	events, err := query.EventsMatching(r.Repo, &query.EventQuery{
+		Types: []task.EventType{task.EventTypeCreate, task.EventTypeDelete},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.hidden(events)
+}
+
+// seesTaskIDs returns whether the user can see each of the task.Task's with IDs, including the
+// deleted ones, without reading any of the other task.Task's. The IDs that have already been
+// looked up are in the seen map, if there is one, and the rest are added to it.
+func (r *ownedRepo) seesTaskIDs(seen map[int]bool, ids ...int) (bool, error) {
+	if seen == nil {
+		seen = map[int]bool{}
+	}
+
+	for _, id := range ids {
+		sees, ok := seen[id]
+		if !ok {
+			var err error
+			if sees, err = r.seesTaskID(id); err != nil {
+				return false, err
+			}
+			seen[id] = sees
+		}
+
+		if !sees {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// seesTaskID returns whether the user can see the task.Task with an ID. Everyone can see a
+// task.Task that was never recorded.
+func (r *ownedRepo) seesTaskID(id int) (bool, error) {
+	t, err := findTaskEvenIfDeleted(r.Repo, id)
+	if err != nil || t == nil {
+		return err == nil, err
+	}
+	return r.sees(t), nil
+}
+
+// findTaskEvenIfDeleted finds the task.Task with an ID in a task.Repo. If it has been deleted, it
+// is only in the snapshot of the latest task.Event that created or deleted it (see hidden). It
+// returns nil, nil if the task.Task was never recorded.
+func findTaskEvenIfDeleted(repo task.Repo, id int) (*task.Task, error) {
+	t, err := repo.FindTaskByID(id)
+	if err != nil || t != nil {
+		return t, err
+	}
+
+	events, err := query.EventsMatching(repo, &query.EventQuery{
+		TaskID: &id,
+		Types:  []task.EventType{task.EventTypeCreate, task.EventTypeDelete},
+		Limit:  1,
+		Order:  query.OrderDescending,
+	})
+	if err != nil || len(events) == 0 || events[0].Snapshot == "" {
+		return nil, err
+	}
+
+	var snapshot task.Task
+	if err := json.Unmarshal([]byte(events[0].Snapshot), &snapshot); err != nil {
+		return nil, nil
+	}
+	return &snapshot, nil
+}
+
+func (r *ownedRepo) CreateTask(t *task.Task) error {
+	t.Owner = r.user
+	return r.Repo.CreateTask(t)
+}
+
+func (r *ownedRepo) Tasks() ([]*task.Task, error) {
+	tasks, err := r.Repo.Tasks()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make([]*task.Task, 0, len(tasks))
+	for _, t := range tasks {
+		if r.sees(t) {
+			seen = append(seen, t)
+		}
+	}
+	return seen, nil
+}
+
+func (r *ownedRepo) FindTaskByID(id int) (*task.Task, error) {
+	t, err := r.Repo.FindTaskByID(id)
+	if err != nil || t == nil || !r.sees(t) {
+		return nil, err
+	}
+	return t, nil
+}
+
+// FindTaskByName finds the task.Task with a name among the ones that the user can see, since
+// someone else may have one with the same name.
+func (r *ownedRepo) FindTaskByName(name string) (*task.Task, error) {
+	tasks, err := r.Tasks()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasks {
+		if t.Name == name {
+			return t, nil
+		}
+	}
+	return nil, nil
+}
+
+// UpdateTask updates a task.Task that the user can see. Only its owner can change who it belongs
+// to or who it is shared with, although anyone can take a task.Task that belongs to no one.
+func (r *ownedRepo) UpdateTask(t *task.Task) error {
+	current, err := r.FindTaskByID(t.ID)
+	if err != nil {
+		return err
+	} else if current == nil {
+		return fmt.Errorf("unknown task with ID %d", t.ID)
+	}
+
+	if current.Owner != r.user && !(current.Owner == "" && t.Owner == r.user) {
+		if t.Owner != current.Owner || !sameUsers(t.SharedWith, current.SharedWith) {
+			return &notOwnerError{fmt.Sprintf(
+				"only the owner of task '%s' can change who it belongs to or is shared with",
+				current.Name,
+			)}
+		}
+	}
+
+	return r.Repo.UpdateTask(t)
+}
+
+// DeleteTask deletes a task.Task that belongs to the user, or to no one.
+func (r *ownedRepo) DeleteTask(t *task.Task) error {
+	current, err := r.Repo.FindTaskByID(t.ID)
+	if err != nil || current == nil {
+		return err
+	}
+
+	if current.Owner != "" && current.Owner != r.user {
+		return &notOwnerError{fmt.Sprintf("only the owner of task '%s' can delete it", current.Name)}
+	}
+
+	return r.Repo.DeleteTask(t)
+}
+
+func (r *ownedRepo) CreateEvent(e *task.Event) error {
+	if err := r.checkTaskIDs(e.TaskID); err != nil {
+		return err
+	}
+	return r.Repo.CreateEvent(e)
+}
+
+func (r *ownedRepo) Events() ([]*task.Event, error) {
+	events, err := r.Repo.Events()
+	if err != nil {
+		return nil, err
+	}
+
+	hidden, err := r.hidden(events)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make([]*task.Event, 0, len(events))
+	for _, e := range events {
+		if !hidden[e.TaskID] {
+			seen = append(seen, e)
+		}
+	}
+	return seen, nil
+}
+
+// EventsMatching makes the ownedRepo a query.EventRepo. The task.Repo selects the task.Event's
+// (e.g., with an indexed database query), and the ones that the user cannot see are left out.
+// Since that can leave a page short, the pages after it are selected until it is full.
+func (r *ownedRepo) EventsMatching(q *query.EventQuery) ([]*task.Event, error) {
+	// Many of the task.Event's are about the same task.Task's.
+	seesTasks := map[int]bool{}
+
+	page := *q
+	seen := make([]*task.Event, 0)
+	for {
+		events, err := query.EventsMatching(r.Repo, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range events {
+			sees, err := r.seesTaskIDs(seesTasks, e.TaskID)
+			if err != nil {
+				return nil, err
+			}
+
+			if sees {
+				seen = append(seen, e)
+				if q.Limit != 0 && len(seen) == q.Limit {
+					return seen, nil
+				}
+			}
+		}
+
+		if q.Limit == 0 || len(events) < q.Limit {
+			return seen, nil
+		}
+		page.Cursor = &events[len(events)-1].ID
+	}
+}
+
+func (r *ownedRepo) FindEventByID(id int) (*task.Event, error) {
+	e, err := r.Repo.FindEventByID(id)
+	if err != nil || e == nil {
+		return nil, err
+	}
+
+	sees, err := r.seesTaskIDs(nil, e.TaskID)
+	if err != nil || !sees {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (r *ownedRepo) DeleteEvent(e *task.Event) error {
+	current, err := r.FindEventByID(e.ID)
+	if err != nil || current == nil {
+		return err
+	}
+	return r.Repo.DeleteEvent(e)
+}
+
+func (r *ownedRepo) CreateDependency(d *task.Dependency) error {
+	if err := r.checkTaskIDs(d.TaskID, d.DependsOnID); err != nil {
+		return err
+	}
+	return r.Repo.CreateDependency(d)
+}
+
+func (r *ownedRepo) Dependencies() ([]*task.Dependency, error) {
+	dependencies, err := r.Repo.Dependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	hidden, err := r.hiddenNow()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make([]*task.Dependency, 0, len(dependencies))
+	for _, d := range dependencies {
+		if !hidden[d.TaskID] && !hidden[d.DependsOnID] {
+			seen = append(seen, d)
+		}
+	}
+	return seen, nil
+}
+
+func (r *ownedRepo) FindDependencyByID(id int) (*task.Dependency, error) {
+	d, err := r.Repo.FindDependencyByID(id)
+	if err != nil || d == nil {
+		return nil, err
+	}
+
+	sees, err := r.seesTaskIDs(nil, d.TaskID, d.DependsOnID)
+	if err != nil || !sees {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (r *ownedRepo) DeleteDependency(d *task.Dependency) error {
+	current, err := r.FindDependencyByID(d.ID)
+	if err != nil || current == nil {
+		return err
+	}
+	return r.Repo.DeleteDependency(d)
+}
+
+// WithTx makes the ownedRepo a task.Transactor when its task.Repo is one.
+func (r *ownedRepo) WithTx(do func(task.Repo) error) error {
+	transactor, ok := r.Repo.(task.Transactor)
+	if !ok {
+		return do(r)
+	}
+
+	return transactor.WithTx(func(repo task.Repo) error {
+		return do(&ownedRepo{Repo: repo, user: r.user})
+	})
+}
+
+// checkTaskIDs returns an error if the user cannot see one of the task.Task's with IDs.
+func (r *ownedRepo) checkTaskIDs(ids ...int) error {
+	for _, id := range ids {
+		sees, err := r.seesTaskIDs(nil, id)
+		if err != nil {
+			return err
+		} else if !sees {
+			return &notOwnerError{fmt.Sprintf("task with ID %d belongs to someone else", id)}
+		}
+	}
+	return nil
+}
+
+// seesChange returns a function that returns whether the user can see a Change.
+func (r *ownedRepo) seesChange(logger lager.Logger) func(*Change) bool {
+	return func(change *Change) bool {
+		if change.Task != nil {
+			return r.sees(change.Task)
+		}
+
+		ids := []int{change.Dependency.TaskID, change.Dependency.DependsOnID}
+		if change.Event != nil {
+			ids = []int{change.Event.TaskID}
+		}
+
+		sees, err := r.seesTaskIDs(nil, ids...)
+		if err != nil {
+			logger.Error("find-tasks", err)
+			return false
+		}
+		return sees
+	}
+}
+
+// An ownedWebhookRepo is a webhook.Repo as seen by a user: it only has the webhook.Webhook's and
+// webhook.DeadLetter's that belong to the user, or to no one (see webhook.Webhook.Owner). The
+// webhook.Webhook's that the user creates belong to them.
+type ownedWebhookRepo struct {
+	task.Repo
+	webhooks webhook.Repo
+	user     string
+}
+
+// sees returns whether the user can see a webhook.Webhook or webhook.DeadLetter with an owner.
+func (r *ownedWebhookRepo) sees(owner string) bool {
+	return owner == "" || owner == r.user
+}
+
+func (r *ownedWebhookRepo) CreateWebhook(w *webhook.Webhook) error {
+	w.Owner = r.user
+	return r.webhooks.CreateWebhook(w)
+}
+
+func (r *ownedWebhookRepo) Webhooks() ([]*webhook.Webhook, error) {
+	webhooks, err := r.webhooks.Webhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make([]*webhook.Webhook, 0, len(webhooks))
+	for _, w := range webhooks {
+		if r.sees(w.Owner) {
+			seen = append(seen, w)
+		}
+	}
+	return seen, nil
+}
+
+func (r *ownedWebhookRepo) FindWebhookByID(id int) (*webhook.Webhook, error) {
+	w, err := r.webhooks.FindWebhookByID(id)
+	if err != nil || w == nil || !r.sees(w.Owner) {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (r *ownedWebhookRepo) DeleteWebhook(w *webhook.Webhook) error {
+	current, err := r.FindWebhookByID(w.ID)
+	if err != nil || current == nil {
+		return err
+	}
+	return r.webhooks.DeleteWebhook(w)
+}
+
+func (r *ownedWebhookRepo) CreateDeadLetter(d *webhook.DeadLetter) error {
+	d.Owner = r.user
+	return r.webhooks.CreateDeadLetter(d)
+}
+
+func (r *ownedWebhookRepo) DeadLetters() ([]*webhook.DeadLetter, error) {
+	deadLetters, err := r.webhooks.DeadLetters()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make([]*webhook.DeadLetter, 0, len(deadLetters))
+	for _, d := range deadLetters {
+		if r.sees(d.Owner) {
+			seen = append(seen, d)
+		}
+	}
+	return seen, nil
+}
+
+// A dispatchRepo is the webhook.Repo to which a task.Event is dispatched: it only has the
+// webhook.Webhook's whose owners can see the task.Task that the task.Event is about.
+type dispatchRepo struct {
+	webhook.Repo
+	repo  task.Repo
+	event *task.Event
+}
+
+func (r *dispatchRepo) Webhooks() ([]*webhook.Webhook, error) {
+	webhooks, err := r.Repo.Webhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := findTaskEvenIfDeleted(r.repo, r.event.TaskID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make([]*webhook.Webhook, 0, len(webhooks))
+	for _, w := range webhooks {
+		if t == nil || (&ownedRepo{Repo: r.repo, user: w.Owner}).sees(t) {
+			seen = append(seen, w)
+		}
+	}
+	return seen, nil
+}
+
+// sameUsers returns whether two lists of users are the same, where nil and empty are the same.
+func sameUsers(a, b []string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
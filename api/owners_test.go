@@ -0,0 +1,224 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/api/apifakes"
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/memory"
+	"github.com/ankeesler/anwork/user"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+var _ = Describe("Owners", func() {
+	var (
+		repo          taskpkg.Repo
+		authenticator *apifakes.FakeAuthenticator
+
+		process ifrit.Process
+	)
+
+	// as makes the requests that follow for a user.
+	as := func(name string) {
+		authenticator.AuthenticateReturns(name, nil)
+	}
+
+	// create creates a task.Task as a user, and returns it.
+	create := func(name, taskName string) *taskpkg.Task {
+		as(name)
+		rsp, err := post("/api/v1/tasks", taskpkg.Task{Name: taskName, State: taskpkg.StateReady})
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		ExpectWithOffset(1, rsp.StatusCode).To(Equal(http.StatusCreated))
+
+		t, err := repo.FindTaskByName(taskName)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		return t
+	}
+
+	BeforeEach(func() {
+		repo = memory.New()
+		authenticator = &apifakes.FakeAuthenticator{}
+
+		users := repo.(user.Repo)
+		for _, name := range []string{"user-a", "user-b", "user-c"} {
+			Expect(users.CreateUser(&user.User{Name: name})).To(Succeed())
+		}
+
+		a := api.New(lagertest.NewTestLogger("api"), repo, authenticator, api.WithUsers(users))
+		runner := http_server.New("127.0.0.1:12345", a)
+		process = ifrit.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	It("makes the user who creates a task its owner", func() {
+		t := create("user-a", "task-a")
+		Expect(t.Owner).To(Equal("user-a"))
+	})
+
+	It("only shows a user their own tasks, the ones shared with them, and the unowned ones", func() {
+		taskA := create("user-a", "task-a")
+		taskB := create("user-b", "task-b")
+		taskC := create("user-c", "task-c")
+		taskC.SharedWith = []string{"user-a"}
+		Expect(repo.UpdateTask(taskC)).To(Succeed())
+		taskD := &taskpkg.Task{Name: "task-d", State: taskpkg.StateReady}
+		Expect(repo.CreateTask(taskD)).To(Succeed())
+
+		as("user-a")
+		rsp, err := get("/api/v1/tasks")
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		assertTasks(rsp, []*taskpkg.Task{taskA, taskC, taskD})
+
+		rsp, err = get(fmt.Sprintf("/api/v1/tasks/%d", taskB.ID))
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+
+		rsp, err = get("/api/v1/tasks?name=" + taskB.Name)
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+		assertTasks(rsp, []*taskpkg.Task{})
+	})
+
+	It("only shows a user the events and dependencies of the tasks that they can see", func() {
+		taskA := create("user-a", "task-a")
+		taskB := create("user-b", "task-b")
+
+		for _, e := range []*taskpkg.Event{{TaskID: taskA.ID}, {TaskID: taskB.ID}} {
+			Expect(repo.CreateEvent(e)).To(Succeed())
+		}
+		d := &taskpkg.Dependency{TaskID: taskB.ID, DependsOnID: taskA.ID}
+		Expect(repo.CreateDependency(d)).To(Succeed())
+
+		as("user-a")
+		rsp, err := get("/api/v1/events")
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		assertEvents(rsp, []*taskpkg.Event{{ID: 0, TaskID: taskA.ID}})
+
+		rsp, err = get("/api/v1/dependencies")
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		assertDependencies(rsp, []*taskpkg.Dependency{})
+
+		rsp, err = post("/api/v1/events", taskpkg.Event{TaskID: taskB.ID})
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusForbidden))
+		assertError(rsp, fmt.Sprintf("task with ID %d belongs to someone else", taskB.ID))
+	})
+
+	It("fills each page of events with the ones that the user can see", func() {
+		taskA := create("user-a", "task-a")
+		taskB := create("user-b", "task-b")
+
+		for _, id := range []int{taskB.ID, taskA.ID, taskB.ID, taskB.ID, taskA.ID, taskA.ID} {
+			Expect(repo.CreateEvent(&taskpkg.Event{TaskID: id})).To(Succeed())
+		}
+
+		as("user-a")
+		rsp, err := get("/api/v1/events?limit=2")
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		assertEvents(rsp, []*taskpkg.Event{{ID: 1, TaskID: taskA.ID}, {ID: 4, TaskID: taskA.ID}})
+		Expect(rsp.Header.Get("Link")).To(Equal(`</api/v1/events?cursor=4&limit=2>; rel="next"`))
+
+		rsp, err = get("/api/v1/events?cursor=4&limit=2")
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		assertEvents(rsp, []*taskpkg.Event{{ID: 5, TaskID: taskA.ID}})
+	})
+
+	It("keeps the owner of a task, and who it is shared with, when an update leaves them out", func() {
+		t := create("user-a", "task-a")
+		t.SharedWith = []string{"user-b"}
+		Expect(repo.UpdateTask(t)).To(Succeed())
+		path := fmt.Sprintf("/api/v1/tasks/%d", t.ID)
+
+		rsp, err := put(path, taskpkg.Task{Name: "task-a", State: taskpkg.StateRunning})
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+
+		t, err = repo.FindTaskByID(t.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.State).To(BeEquivalentTo(taskpkg.StateRunning))
+		Expect(t.Owner).To(Equal("user-a"))
+		Expect(t.SharedWith).To(Equal([]string{"user-b"}))
+
+		as("user-c")
+		rsp, err = get(path)
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+	})
+
+	It("lets the users with whom a task is shared change it, but not share or delete it", func() {
+		t := create("user-a", "task-a")
+		t.SharedWith = []string{"user-b"}
+		Expect(repo.UpdateTask(t)).To(Succeed())
+		path := fmt.Sprintf("/api/v1/tasks/%d", t.ID)
+
+		as("user-b")
+		t.Priority = 5
+		rsp, err := put(path, t)
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+
+		t.SharedWith = []string{"user-b", "user-c"}
+		rsp, err = put(path, t)
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusForbidden))
+		assertError(rsp, "only the owner of task 'task-a' can change who it belongs to or is shared with")
+
+		rsp, err = deletee(path)
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusForbidden))
+		assertError(rsp, "only the owner of task 'task-a' can delete it")
+
+		t, err = repo.FindTaskByID(t.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(t.Priority).To(Equal(5))
+		Expect(t.SharedWith).To(Equal([]string{"user-b"}))
+
+		as("user-a")
+		rsp, err = deletee(path)
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+	})
+
+	It("gives each user a task with the same name", func() {
+		create("user-a", "task-a")
+
+		as("user-b")
+		rsp, err := post("/api/v2/create", api.Operation{Name: "task-a"})
+		Expect(err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		Expect(rsp.StatusCode).To(Equal(http.StatusCreated))
+
+		tasks, err := repo.Tasks()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tasks).To(HaveLen(2))
+		Expect(tasks[1].Name).To(Equal("task-a"))
+		Expect(tasks[1].Owner).To(Equal("user-b"))
+	})
+})
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/session"
+	"github.com/tedsuo/rata"
+)
+
+// errNoSessions is returned from the session endpoints when the Authenticator is not a
+// SessionManager.
+var errNoSessions = errors.New("sessions are not supported by this authenticator")
+
+// sessionManager responds with a 501 if an Authenticator does not keep sessions, and returns the
+// SessionManager if it does.
+func sessionManager(
+	logger lager.Logger,
+	authenticator Authenticator,
+	w http.ResponseWriter,
+) SessionManager {
+	manager, ok := authenticator.(SessionManager)
+	if !ok {
+		respondWithError(logger, w, http.StatusNotImplemented, errNoSessions)
+		return nil
+	}
+	return manager
+}
+
+// userSessions returns the active session.Session's that a user can see: their own, or all of
+// them, if the user is "" (i.e., when the API has no users).
+func userSessions(manager SessionManager, user string) ([]*session.Session, error) {
+	sessions, err := manager.Sessions()
+	if err != nil {
+		return nil, err
+	}
+
+	if user == "" {
+		return sessions, nil
+	}
+
+	seen := make([]*session.Session, 0, len(sessions))
+	for _, s := range sessions {
+		if s.Subject == user {
+			seen = append(seen, s)
+		}
+	}
+	return seen, nil
+}
+
+type logoutHandler struct {
+	logger        lager.Logger
+	authenticator Authenticator
+}
+
+func (h *logoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	manager := sessionManager(h.logger, h.authenticator, w)
+	if manager == nil {
+		return
+	}
+
+	// The token has already been authenticated, so it is there.
+	token, err, statusCode := bearerToken(r)
+	if err != nil {
+		respondWithError(h.logger, w, statusCode, err)
+		return
+	}
+
+	if err := manager.Revoke(token); err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	h.logger.Debug("logged-out")
+	respond(h.logger, w, http.StatusNoContent, nil)
+}
+
+type getSessionsHandler struct {
+	logger        lager.Logger
+	authenticator Authenticator
+	user          string
+}
+
+func (h *getSessionsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	manager := sessionManager(h.logger, h.authenticator, w)
+	if manager == nil {
+		return
+	}
+
+	sessions, err := userSessions(manager, h.user)
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond(h.logger, w, http.StatusOK, sessions)
+}
+
+type deleteSessionHandler struct {
+	logger        lager.Logger
+	authenticator Authenticator
+	user          string
+}
+
+func (h *deleteSessionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	manager := sessionManager(h.logger, h.authenticator, w)
+	if manager == nil {
+		return
+	}
+
+	sessions, err := userSessions(manager, h.user)
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	id := rata.Param(r, "id")
+	for _, s := range sessions {
+		if s.ID == id {
+			h.logger.Debug("revoking-session", lager.Data{"id": id, "subject": s.Subject})
+			if err := manager.RevokeSession(id); err != nil {
+				respondWithError(h.logger, w, http.StatusInternalServerError, err)
+				return
+			}
+
+			respond(h.logger, w, http.StatusNoContent, nil)
+			return
+		}
+	}
+
+	respondWithError(h.logger, w, http.StatusNotFound, fmt.Errorf("unknown session with ID %s", id))
+}
@@ -0,0 +1,199 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/api/apifakes"
+	"github.com/ankeesler/anwork/session"
+	"github.com/ankeesler/anwork/task/memory"
+	"github.com/ankeesler/anwork/user"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+var _ = Describe("Sessions", func() {
+	var (
+		authenticator api.Authenticator
+		manager       *apifakes.FakeSessionManager
+		options       []api.Option
+
+		process ifrit.Process
+	)
+
+	sessions := []*session.Session{
+		{ID: "session-a", Subject: "user-a", IssuedAt: 100, Expiry: 200},
+		{ID: "session-b", Subject: "user-b", IssuedAt: 110, Expiry: 210},
+		{ID: "session-c", Subject: "user-a", IssuedAt: 120, Expiry: 220},
+	}
+
+	assertSessions := func(rsp *http.Response, expected []*session.Session) {
+		ExpectWithOffset(1, rsp.StatusCode).To(Equal(http.StatusOK))
+
+		var actual []*session.Session
+		ExpectWithOffset(1, json.NewDecoder(rsp.Body).Decode(&actual)).To(Succeed())
+		ExpectWithOffset(1, actual).To(Equal(expected))
+	}
+
+	BeforeEach(func() {
+		manager = &apifakes.FakeSessionManager{}
+		manager.SessionsReturns(sessions, nil)
+		authenticator = manager
+		options = nil
+	})
+
+	JustBeforeEach(func() {
+		a := api.New(lagertest.NewTestLogger("api"), memory.New(), authenticator, options...)
+		runner := http_server.New("127.0.0.1:12345", a)
+		process = ifrit.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	Describe("Logout", func() {
+		It("revokes the token with which the request is made", func() {
+			rsp, err := deletee("/api/v1/auth")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+
+			Expect(manager.AuthenticateCallCount()).To(Equal(1))
+			Expect(manager.RevokeCallCount()).To(Equal(1))
+			Expect(manager.RevokeArgsForCall(0)).To(Equal("some-token"))
+		})
+
+		It("needs a token", func() {
+			req, err := http.NewRequest(http.MethodDelete, "http://127.0.0.1:12345/api/v1/auth", nil)
+			Expect(err).NotTo(HaveOccurred())
+			rsp, err := http.DefaultClient.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusUnauthorized))
+
+			Expect(manager.RevokeCallCount()).To(Equal(0))
+		})
+
+		Context("when the token cannot be revoked", func() {
+			BeforeEach(func() {
+				manager.RevokeReturns(errors.New("some revoke error"))
+			})
+
+			It("returns a 500", func() {
+				rsp, err := deletee("/api/v1/auth")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+				Expect(rsp.StatusCode).To(Equal(http.StatusInternalServerError))
+				assertError(rsp, "some revoke error")
+			})
+		})
+	})
+
+	Describe("Get", func() {
+		It("returns all of the sessions", func() {
+			rsp, err := get("/api/v1/sessions")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			assertSessions(rsp, sessions)
+		})
+
+		Context("when the sessions cannot be listed", func() {
+			BeforeEach(func() {
+				manager.SessionsReturns(nil, errors.New("some sessions error"))
+			})
+
+			It("returns a 500", func() {
+				rsp, err := get("/api/v1/sessions")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+				Expect(rsp.StatusCode).To(Equal(http.StatusInternalServerError))
+				assertError(rsp, "some sessions error")
+			})
+		})
+	})
+
+	Describe("Delete", func() {
+		It("revokes the session", func() {
+			rsp, err := deletee("/api/v1/sessions/session-b")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+
+			Expect(manager.RevokeSessionCallCount()).To(Equal(1))
+			Expect(manager.RevokeSessionArgsForCall(0)).To(Equal("session-b"))
+		})
+
+		It("returns a 404 on an unknown session", func() {
+			rsp, err := deletee("/api/v1/sessions/session-z")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+			assertError(rsp, "unknown session with ID session-z")
+
+			Expect(manager.RevokeSessionCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the API has users", func() {
+		BeforeEach(func() {
+			users := memory.New().(user.Repo)
+			for _, name := range []string{"user-a", "user-b"} {
+				Expect(users.CreateUser(&user.User{Name: name})).To(Succeed())
+			}
+			options = append(options, api.WithUsers(users))
+
+			manager.AuthenticateReturns("user-a", nil)
+		})
+
+		It("only returns the sessions of the user", func() {
+			rsp, err := get("/api/v1/sessions")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			assertSessions(rsp, []*session.Session{sessions[0], sessions[2]})
+		})
+
+		It("only revokes the sessions of the user", func() {
+			rsp, err := deletee("/api/v1/sessions/session-b")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+			assertError(rsp, "unknown session with ID session-b")
+
+			rsp, err = deletee("/api/v1/sessions/session-c")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+
+			Expect(manager.RevokeSessionCallCount()).To(Equal(1))
+			Expect(manager.RevokeSessionArgsForCall(0)).To(Equal("session-c"))
+		})
+	})
+
+	Context("when the authenticator does not keep sessions", func() {
+		BeforeEach(func() {
+			authenticator = &apifakes.FakeAuthenticator{}
+		})
+
+		It("returns a 501", func() {
+			for _, do := range []func() (*http.Response, error){
+				func() (*http.Response, error) { return deletee("/api/v1/auth") },
+				func() (*http.Response, error) { return get("/api/v1/sessions") },
+				func() (*http.Response, error) { return deletee("/api/v1/sessions/session-a") },
+			} {
+				rsp, err := do()
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+				Expect(rsp.StatusCode).To(Equal(http.StatusNotImplemented))
+				assertError(rsp, "sessions are not supported by this authenticator")
+			}
+		})
+	})
+})
@@ -0,0 +1,314 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/query"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+)
+
+// These are the Change.Action's.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// subscriberBufferSize is how many Change's may be waiting to be sent to a subscriber before it is
+// dropped. A dropped subscriber may resume its stream with the Last-Event-ID header.
+const subscriberBufferSize = 64
+
+// A hub delivers each Change that is published in a context to the subscribers of that context.
+// The context of the task.Repo passed to New is "".
+type hub struct {
+	lock        sync.Mutex
+	subscribers map[string]map[chan *Change]bool
+}
+
+func newHub() *hub {
+	return &hub{subscribers: map[string]map[chan *Change]bool{}}
+}
+
+// subscribe returns a channel that receives the Change's published in a context, and a function
+// that stops the subscription. The channel is closed when the subscription stops, or when the
+// subscriber falls too far behind.
+func (h *hub) subscribe(context string) (<-chan *Change, func()) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	changes := make(chan *Change, subscriberBufferSize)
+	if h.subscribers[context] == nil {
+		h.subscribers[context] = map[chan *Change]bool{}
+	}
+	h.subscribers[context][changes] = true
+
+	return changes, func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		h.drop(context, changes)
+	}
+}
+
+// publish sends a Change to each subscriber of a context.
+func (h *hub) publish(context string, change *Change) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	for changes := range h.subscribers[context] {
+		select {
+		case changes <- change:
+		default:
+			h.drop(context, changes)
+		}
+	}
+}
+
+// drop removes a subscriber of a context, if it has not been removed already. The lock must be
+// held.
+func (h *hub) drop(context string, changes chan *Change) {
+	if h.subscribers[context][changes] {
+		delete(h.subscribers[context], changes)
+		close(changes)
+	}
+}
+
+// A stream is the Change's to one task.Repo, i.e., the one passed to New or the one of a context.
+// Each task.Event that is created in the task.Repo is also dispatched to its webhook.Webhook's, if
+// it is a webhook.Repo, whose owners can see the task.Task that the task.Event is about.
+type stream struct {
+	hub     *hub
+	context string
+
+	repo       task.Repo
+	dispatcher *webhook.Dispatcher
+}
+
+func (s stream) publish(change *Change) {
+	s.hub.publish(s.context, change)
+
+	if change.Action == ActionCreate && change.Event != nil {
+		if webhooks, ok := s.repo.(webhook.Repo); ok {
+			repo := &dispatchRepo{Repo: webhooks, repo: s.repo, event: change.Event}
+			s.dispatcher.Dispatch(repo, s.context, change.Event)
+		}
+	}
+}
+
+func (s stream) subscribe() (<-chan *Change, func()) {
+	return s.hub.subscribe(s.context)
+}
+
+// A publishingRepo is a task.Repo that publishes a Change each time that something is created,
+// updated, or deleted through it.
+type publishingRepo struct {
+	task.Repo
+	publish func(*Change)
+}
+
+func (r *publishingRepo) CreateTask(t *task.Task) error {
+	if err := r.Repo.CreateTask(t); err != nil {
+		return err
+	}
+	r.publishTask(ActionCreate, t)
+	return nil
+}
+
+func (r *publishingRepo) UpdateTask(t *task.Task) error {
+	if err := r.Repo.UpdateTask(t); err != nil {
+		return err
+	}
+	r.publishTask(ActionUpdate, t)
+	return nil
+}
+
+func (r *publishingRepo) DeleteTask(t *task.Task) error {
+	if err := r.Repo.DeleteTask(t); err != nil {
+		return err
+	}
+	r.publishTask(ActionDelete, t)
+	return nil
+}
+
+func (r *publishingRepo) CreateEvent(e *task.Event) error {
+	if err := r.Repo.CreateEvent(e); err != nil {
+		return err
+	}
+	r.publishEvent(ActionCreate, e)
+	return nil
+}
+
+func (r *publishingRepo) DeleteEvent(e *task.Event) error {
+	if err := r.Repo.DeleteEvent(e); err != nil {
+		return err
+	}
+	r.publishEvent(ActionDelete, e)
+	return nil
+}
+
+func (r *publishingRepo) CreateDependency(d *task.Dependency) error {
+	if err := r.Repo.CreateDependency(d); err != nil {
+		return err
+	}
+	r.publishDependency(ActionCreate, d)
+	return nil
+}
+
+func (r *publishingRepo) DeleteDependency(d *task.Dependency) error {
+	if err := r.Repo.DeleteDependency(d); err != nil {
+		return err
+	}
+	r.publishDependency(ActionDelete, d)
+	return nil
+}
+
+// WithTx makes the publishingRepo a task.Transactor when its task.Repo is one. The Change's made in
+// the transaction are only published once it succeeds.
+func (r *publishingRepo) WithTx(do func(task.Repo) error) error {
+	transactor, ok := r.Repo.(task.Transactor)
+	if !ok {
+		return do(r)
+	}
+
+	changes := []*Change{}
+	err := transactor.WithTx(func(repo task.Repo) error {
+		return do(&publishingRepo{
+			Repo:    repo,
+			publish: func(change *Change) { changes = append(changes, change) },
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		r.publish(change)
+	}
+	return nil
+}
+
+// EventsMatching makes the publishingRepo a query.EventRepo, so that its task.Repo can find the
+// task.Event's that a query.EventQuery selects itself.
+func (r *publishingRepo) EventsMatching(q *query.EventQuery) ([]*task.Event, error) {
+	return query.EventsMatching(r.Repo, q)
+}
+
+func (r *publishingRepo) publishTask(action string, t *task.Task) {
+	taskCopy := *t
+	r.publish(&Change{Action: action, Task: &taskCopy})
+}
+
+func (r *publishingRepo) publishEvent(action string, e *task.Event) {
+	eventCopy := *e
+	r.publish(&Change{Action: action, Event: &eventCopy})
+}
+
+func (r *publishingRepo) publishDependency(action string, d *task.Dependency) {
+	dependencyCopy := *d
+	r.publish(&Change{Action: action, Dependency: &dependencyCopy})
+}
+
+type streamEventsHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+	stream stream
+	// sees returns whether the user who made the request can see a Change.
+	sees func(*Change) bool
+}
+
+func (h *streamEventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(h.logger, w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	lastEventID, resume := -1, false
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.Atoi(header)
+		if err != nil {
+			respondWithError(h.logger, w, http.StatusBadRequest, fmt.Errorf("invalid Last-Event-ID '%s'", header))
+			return
+		}
+		lastEventID, resume = id, true
+	}
+
+	// Subscribe before reading the missed task.Event's, so that none are created in between.
+	changes, unsubscribe := h.stream.subscribe()
+	defer unsubscribe()
+
+	missed := []*task.Event{}
+	if resume {
+		var err error
+		cursor := lastEventID
+		missed, err = query.EventsMatching(h.repo, &query.EventQuery{Cursor: &cursor})
+		if err != nil {
+			respondWithError(h.logger, w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	h.logger.Debug("streaming", lager.Data{"lastEventID": lastEventID, "missed": len(missed)})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	for _, e := range missed {
+		if err := writeChange(w, &Change{Action: ActionCreate, Event: e}); err != nil {
+			h.logger.Error("write-change", err)
+			return
+		}
+		lastEventID = e.ID
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case change, ok := <-changes:
+			if !ok {
+				h.logger.Debug("dropped")
+				return
+			}
+			if resume && change.Action == ActionCreate && change.Event != nil && change.Event.ID <= lastEventID {
+				// This task.Event was already sent as a missed one.
+				continue
+			}
+			if !h.sees(change) {
+				continue
+			}
+			if err := writeChange(w, change); err != nil {
+				h.logger.Error("write-change", err)
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			h.logger.Debug("done")
+			return
+		}
+	}
+}
+
+// writeChange writes a Change as a server-sent event, which is named after the route that makes
+// that kind of Change, e.g., create_event. Only the Change's that create a task.Event have an ID,
+// since only those can be replayed from the task.Repo.
+func writeChange(w io.Writer, change *Change) error {
+	data, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	if change.Action == ActionCreate && change.Event != nil {
+		if _, err := fmt.Fprintf(w, "id: %d\n", change.Event.ID); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", change.Name(), data)
+	return err
+}
@@ -0,0 +1,289 @@
+package api_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/api/apifakes"
+	"github.com/ankeesler/anwork/query"
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/memory"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+// An eventQueryRecorder is a query.EventRepo that records the query.EventQuery's that it is asked.
+// It fails if all of its task.Event's are read.
+type eventQueryRecorder struct {
+	taskpkg.Repo
+
+	lock     sync.Mutex
+	recorded []query.EventQuery
+}
+
+func (r *eventQueryRecorder) Events() ([]*taskpkg.Event, error) {
+	return nil, errors.New("all of the events were read")
+}
+
+func (r *eventQueryRecorder) EventsMatching(q *query.EventQuery) ([]*taskpkg.Event, error) {
+	r.lock.Lock()
+	r.recorded = append(r.recorded, *q)
+	r.lock.Unlock()
+	return query.EventsMatching(r.Repo, q)
+}
+
+func (r *eventQueryRecorder) queries() []query.EventQuery {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]query.EventQuery{}, r.recorded...)
+}
+
+// A serverSentEvent is one of the events read from the /api/v1/events/stream endpoint.
+type serverSentEvent struct {
+	id, name string
+	change   api.Change
+}
+
+var _ = Describe("Stream", func() {
+	var (
+		repo          taskpkg.Repo
+		contexts      taskpkg.Contexts
+		authenticator *apifakes.FakeAuthenticator
+
+		process ifrit.Process
+	)
+
+	BeforeEach(func() {
+		repo = memory.New()
+		contexts = memory.NewContexts()
+		authenticator = &apifakes.FakeAuthenticator{}
+	})
+
+	JustBeforeEach(func() {
+		a := api.New(lagertest.NewTestLogger("api"), repo, authenticator, api.WithContexts(contexts))
+		runner := http_server.New("127.0.0.1:12345", a)
+		process = ifrit.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		Eventually(process.Wait()).Should(Receive())
+	})
+
+	// stream opens a stream, and returns a channel that receives its serverSentEvent's and a
+	// function that closes it.
+	stream := func(path, lastEventID string) (<-chan serverSentEvent, func()) {
+		req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:12345"+path, nil)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		req.Header.Set("Authorization", "bearer some-token")
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+
+		rsp, err := http.DefaultClient.Do(req)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		ExpectWithOffset(1, rsp.StatusCode).To(Equal(http.StatusOK))
+		ExpectWithOffset(1, rsp.Header.Get("Content-Type")).To(Equal("text/event-stream"))
+
+		events := make(chan serverSentEvent, 100)
+		go func() {
+			defer GinkgoRecover()
+			defer close(events)
+
+			var e serverSentEvent
+			scanner := bufio.NewScanner(rsp.Body)
+			for scanner.Scan() {
+				line := scanner.Text()
+				switch {
+				case line == "":
+					events <- e
+					e = serverSentEvent{}
+				case strings.HasPrefix(line, "id: "):
+					e.id = strings.TrimPrefix(line, "id: ")
+				case strings.HasPrefix(line, "event: "):
+					e.name = strings.TrimPrefix(line, "event: ")
+				case strings.HasPrefix(line, "data: "):
+					Expect(json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e.change)).To(Succeed())
+				}
+			}
+		}()
+		return events, func() { rsp.Body.Close() }
+	}
+
+	It("streams everything that is created, updated, or deleted", func() {
+		events, closeStream := stream("/api/v1/events/stream", "")
+		defer closeStream()
+
+		rsp, err := post("/api/v1/tasks", &taskpkg.Task{Name: "task-a"})
+		Expect(err).NotTo(HaveOccurred())
+		rsp.Body.Close()
+		taskA, err := repo.FindTaskByName("task-a")
+		Expect(err).NotTo(HaveOccurred())
+
+		var e serverSentEvent
+		Eventually(events).Should(Receive(&e))
+		Expect(e.id).To(BeEmpty())
+		Expect(e.name).To(Equal("create_task"))
+		Expect(e.change).To(Equal(api.Change{Action: api.ActionCreate, Task: taskA}))
+
+		taskA.Priority = 5
+		rsp, err = put(fmt.Sprintf("/api/v1/tasks/%d", taskA.ID), taskA)
+		Expect(err).NotTo(HaveOccurred())
+		rsp.Body.Close()
+
+		Eventually(events).Should(Receive(&e))
+		Expect(e.name).To(Equal("update_task"))
+		Expect(e.change.Task.Priority).To(Equal(5))
+
+		rsp, err = post("/api/v1/events", &taskpkg.Event{Title: "event-a"})
+		Expect(err).NotTo(HaveOccurred())
+		rsp.Body.Close()
+		allEvents, err := repo.Events()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(allEvents).To(HaveLen(1))
+
+		Eventually(events).Should(Receive(&e))
+		Expect(e.id).To(Equal(strconv.Itoa(allEvents[0].ID)))
+		Expect(e.name).To(Equal("create_event"))
+		Expect(e.change).To(Equal(api.Change{Action: api.ActionCreate, Event: allEvents[0]}))
+
+		rsp, err = deletee(fmt.Sprintf("/api/v1/events/%d", allEvents[0].ID))
+		Expect(err).NotTo(HaveOccurred())
+		rsp.Body.Close()
+
+		Eventually(events).Should(Receive(&e))
+		Expect(e.id).To(BeEmpty())
+		Expect(e.name).To(Equal("delete_event"))
+
+		rsp, err = post("/api/v1/dependencies", &taskpkg.Dependency{TaskID: taskA.ID, DependsOnID: taskA.ID + 1})
+		Expect(err).NotTo(HaveOccurred())
+		rsp.Body.Close()
+
+		Eventually(events).Should(Receive(&e))
+		Expect(e.name).To(Equal("create_dependency"))
+		Expect(e.change.Dependency.TaskID).To(Equal(taskA.ID))
+
+		rsp, err = deletee(fmt.Sprintf("/api/v1/tasks/%d", taskA.ID))
+		Expect(err).NotTo(HaveOccurred())
+		rsp.Body.Close()
+
+		Eventually(events).Should(Receive(&e))
+		Expect(e.name).To(Equal("delete_task"))
+		Expect(e.change.Task.Name).To(Equal("task-a"))
+	})
+
+	It("streams the changes made by the /api/v2 endpoints", func() {
+		events, closeStream := stream("/api/v1/events/stream", "")
+		defer closeStream()
+
+		rsp, err := post("/api/v2/create", &api.Operation{Name: "task-a"})
+		Expect(err).NotTo(HaveOccurred())
+		rsp.Body.Close()
+
+		var e serverSentEvent
+		Eventually(events).Should(Receive(&e))
+		Expect(e.name).To(Equal("create_task"))
+		Eventually(events).Should(Receive(&e))
+		Expect(e.name).To(Equal("create_event"))
+		Expect(e.change.Event.Title).To(Equal("Created task 'task-a'"))
+	})
+
+	Context("when the Last-Event-ID is sent", func() {
+		var eventA, eventB *taskpkg.Event
+
+		BeforeEach(func() {
+			eventA = &taskpkg.Event{Title: "event-a"}
+			Expect(repo.CreateEvent(eventA)).To(Succeed())
+			eventB = &taskpkg.Event{Title: "event-b"}
+			Expect(repo.CreateEvent(eventB)).To(Succeed())
+		})
+
+		It("first replays the events created after that one", func() {
+			events, closeStream := stream("/api/v1/events/stream", strconv.Itoa(eventA.ID))
+			defer closeStream()
+
+			var e serverSentEvent
+			Eventually(events).Should(Receive(&e))
+			Expect(e.id).To(Equal(strconv.Itoa(eventB.ID)))
+			Expect(e.change).To(Equal(api.Change{Action: api.ActionCreate, Event: eventB}))
+
+			rsp, err := post("/api/v1/events", &taskpkg.Event{Title: "event-c"})
+			Expect(err).NotTo(HaveOccurred())
+			rsp.Body.Close()
+
+			Eventually(events).Should(Receive(&e))
+			Expect(e.change.Event.Title).To(Equal("event-c"))
+			Consistently(events).ShouldNot(Receive())
+		})
+
+		Context("when the repo can find the events itself", func() {
+			var recorder *eventQueryRecorder
+
+			BeforeEach(func() {
+				recorder = &eventQueryRecorder{Repo: repo}
+				repo = recorder
+			})
+
+			It("finds the events created after that one, without reading all of them", func() {
+				events, closeStream := stream("/api/v1/events/stream", strconv.Itoa(eventA.ID))
+				defer closeStream()
+
+				var e serverSentEvent
+				Eventually(events).Should(Receive(&e))
+				Expect(e.change.Event).To(Equal(eventB))
+
+				Expect(recorder.queries()).To(Equal([]query.EventQuery{{Cursor: &eventA.ID}}))
+			})
+		})
+
+		Context("when it is not a number", func() {
+			It("responds with a 400", func() {
+				req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:12345/api/v1/events/stream", nil)
+				Expect(err).NotTo(HaveOccurred())
+				req.Header.Set("Authorization", "bearer some-token")
+				req.Header.Set("Last-Event-ID", "tuna")
+
+				rsp, err := http.DefaultClient.Do(req)
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+				assertError(rsp, "invalid Last-Event-ID 'tuna'")
+			})
+		})
+	})
+
+	Describe("the stream of a context", func() {
+		BeforeEach(func() {
+			Expect(contexts.CreateContext("context-a")).To(Succeed())
+		})
+
+		It("only streams the changes in that context", func() {
+			events, closeStream := stream("/api/v1/contexts/context-a/events/stream", "")
+			defer closeStream()
+
+			rsp, err := post("/api/v1/events", &taskpkg.Event{Title: "event-a"})
+			Expect(err).NotTo(HaveOccurred())
+			rsp.Body.Close()
+			rsp, err = post("/api/v1/contexts/context-a/events", &taskpkg.Event{Title: "event-b"})
+			Expect(err).NotTo(HaveOccurred())
+			rsp.Body.Close()
+
+			var e serverSentEvent
+			Eventually(events).Should(Receive(&e))
+			Expect(e.change.Event.Title).To(Equal("event-b"))
+			Consistently(events).ShouldNot(Receive())
+		})
+	})
+})
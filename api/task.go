@@ -1,11 +1,16 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"reflect"
 	"strconv"
+	"strings"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/ankeesler/anwork/task"
@@ -41,6 +46,68 @@ func findTask(
 	return task, idN
 }
 
+// etag returns the ETag of a task.Task, which changes each time that the task.Task is updated.
+func etag(t *task.Task) string {
+	return strconv.Quote(strconv.Itoa(t.Version))
+}
+
+// ifMatch returns whether the If-Match header of a request, if it has one, matches the ETag of a
+// task.Task.
+func ifMatch(r *http.Request, t *task.Task) bool {
+	values := r.Header.Values("If-Match")
+	if len(values) == 0 {
+		return true
+	}
+
+	for _, tag := range strings.Split(strings.Join(values, ","), ",") {
+		if tag = strings.TrimSpace(tag); tag == "*" || tag == etag(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// findMatchingTask finds the task.Task with an ID in a task.Repo, and checks that it matches the
+// If-Match header of a request. If it does not, an error is returned along with the status code
+// with which to respond.
+func findMatchingTask(
+	logger lager.Logger,
+	repo task.Repo,
+	id int,
+	r *http.Request,
+) (*task.Task, error, int) {
+	t, err := repo.FindTaskByID(id)
+	if err != nil {
+		return nil, err, http.StatusInternalServerError
+	}
+
+	if t == nil {
+		logger.Debug("unknown-task", lager.Data{"id": id})
+		return nil, fmt.Errorf("unknown task with ID %d", id), http.StatusNotFound
+	}
+
+	if !ifMatch(r, t) {
+		logger.Debug("mismatched-task", lager.Data{"task": t, "if-match": r.Header.Values("If-Match")})
+		return nil, fmt.Errorf(
+			"task with ID %d was changed by someone else (its ETag is now %s)",
+			id,
+			etag(t),
+		), http.StatusPreconditionFailed
+	}
+
+	logger.Debug("found-task", lager.Data{"task": t})
+	return t, nil, 0
+}
+
+// transact calls a function with a task.Repo whose changes are all-or-nothing when the task.Repo
+// is a task.Transactor, so that no one else can change a task.Task after the function checks it.
+func transact(repo task.Repo, do func(task.Repo) error) error {
+	if transactor, ok := repo.(task.Transactor); ok {
+		return transactor.WithTx(do)
+	}
+	return do(repo)
+}
+
 type getTaskHandler struct {
 	logger lager.Logger
 	repo   task.Repo
@@ -48,6 +115,7 @@ type getTaskHandler struct {
 
 func (h *getTaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if task, _ := findTask(h.logger, h.repo, w, r); task != nil {
+		w.Header().Set("ETag", etag(task))
 		respond(h.logger, w, http.StatusOK, task)
 	}
 }
@@ -70,14 +138,199 @@ func (h *updateTaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if task, idN := findTask(h.logger, h.repo, w, r); task != nil {
+	idN, err := strconv.Atoi(rata.Param(r, "id"))
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusBadRequest, err)
+		return
+	}
+
+	// The owner of a task.Task, and the users with whom it is shared, are left out of its JSON when
+	// there are none, so they are only changed when the body says what they are.
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		respondWithError(h.logger, w, http.StatusBadRequest, err)
+		return
+	}
+
+	statusCode := http.StatusInternalServerError
+	err = transact(h.repo, func(repo task.Repo) error {
+		current, err, code := findMatchingTask(h.logger, repo, idN, r)
+		if err != nil {
+			statusCode = code
+			return err
+		}
+
+		if !hasField(fields, "owner") {
+			newTask.Owner = current.Owner
+		}
+		if !hasField(fields, "sharedWith") {
+			newTask.SharedWith = current.SharedWith
+		}
+
 		newTask.ID = idN
-		if err := h.repo.UpdateTask(&newTask); err != nil {
-			respondWithError(h.logger, w, http.StatusInternalServerError, err)
-			return
+		return repo.UpdateTask(&newTask)
+	})
+	if err != nil {
+		respondWithError(h.logger, w, statusCode, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(&newTask))
+	respond(h.logger, w, http.StatusNoContent, nil)
+}
+
+// hasField returns whether a JSON object has a field, whose name is matched without regard to case,
+// like encoding/json does.
+func hasField(fields map[string]json.RawMessage, name string) bool {
+	for field := range fields {
+		if strings.EqualFold(field, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// MergePatchContentType is the Content-Type of a JSON merge patch (see RFC 7396), which is how the
+// fields of a task are changed with a PATCH.
+const MergePatchContentType = "application/merge-patch+json"
+
+type patchTaskHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+}
+
+func (h *patchTaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+	if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != MergePatchContentType {
+		respondWithError(
+			h.logger,
+			w,
+			http.StatusUnsupportedMediaType,
+			fmt.Errorf("unsupported patch type '%s' (use '%s')", contentType, MergePatchContentType),
+		)
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var patch map[string]interface{}
+	if err := json.Unmarshal(data, &patch); err != nil || patch == nil {
+		respondWithError(h.logger, w, http.StatusBadRequest, errors.New("patch must be a JSON object"))
+		return
+	}
+
+	idN, err := strconv.Atoi(rata.Param(r, "id"))
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusBadRequest, err)
+		return
+	}
+
+	var newTask *task.Task
+	statusCode := http.StatusInternalServerError
+	err = transact(h.repo, func(repo task.Repo) error {
+		t, err, code := findMatchingTask(h.logger, repo, idN, r)
+		if err != nil {
+			statusCode = code
+			return err
+		}
+
+		newTask, err = applyMergePatch(t, patch)
+		if err != nil {
+			statusCode = http.StatusBadRequest
+			return err
+		}
+
+		return repo.UpdateTask(newTask)
+	})
+	if err != nil {
+		respondWithError(h.logger, w, statusCode, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(newTask))
+	respond(h.logger, w, http.StatusOK, newTask)
+}
+
+// applyMergePatch returns a copy of a task.Task with a JSON merge patch applied to it, or an error
+// if the patch is invalid. The ID and version of a task.Task cannot be patched, and its name and
+// state can only be patched to valid values.
+func applyMergePatch(t *task.Task, patch map[string]interface{}) (*task.Task, error) {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var target map[string]interface{}
+	if err := json.Unmarshal(data, &target); err != nil {
+		return nil, err
+	}
+
+	for _, field := range []string{"id", "version"} {
+		if value, ok := patch[field]; ok && !reflect.DeepEqual(value, target[field]) {
+			return nil, fmt.Errorf("cannot patch the %s of a task", field)
+		}
+	}
+
+	data, err = json.Marshal(mergePatch(target, patch))
+	if err != nil {
+		return nil, err
+	}
+
+	var newTask task.Task
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&newTask); err != nil {
+		return nil, fmt.Errorf("invalid patch: %s", err.Error())
+	}
+	newTask.ID = t.ID
+	newTask.Version = t.Version
+
+	if _, ok := patch["name"]; ok && newTask.Name == "" {
+		return nil, errors.New("missing task name")
+	}
+	if _, ok := patch["state"]; ok {
+		if err := validateState(newTask.State); err != nil {
+			return nil, err
 		}
+	}
+
+	return &newTask, nil
+}
+
+// mergePatch applies a JSON merge patch to a JSON value, as described in RFC 7396: each member of
+// a patch object replaces the member of the target with the same name, a null member removes it,
+// and an object member is itself merged into the target member.
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
 
-		respond(h.logger, w, http.StatusNoContent, nil)
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+		} else {
+			targetObject[name] = mergePatch(targetObject[name], value)
+		}
+	}
+	return targetObject
+}
+
+// validateState returns an error if a task.State is not one of the task.State's.
+func validateState(state task.State) error {
+	switch state {
+	case task.StateReady, task.StateBlocked, task.StateRunning, task.StateFinished:
+		return nil
+	default:
+		return fmt.Errorf("invalid state '%s'", state)
 	}
 }
 
@@ -87,12 +340,28 @@ type deleteTaskHandler struct {
 }
 
 func (h *deleteTaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if task, _ := findTask(h.logger, h.repo, w, r); task != nil {
-		if err := h.repo.DeleteTask(task); err != nil {
-			respondWithError(h.logger, w, http.StatusInternalServerError, err)
-			return
+	idN, err := strconv.Atoi(rata.Param(r, "id"))
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusBadRequest, err)
+		return
+	}
+
+	var deleted *task.Task
+	statusCode := http.StatusInternalServerError
+	err = transact(h.repo, func(repo task.Repo) error {
+		t, err, code := findMatchingTask(h.logger, repo, idN, r)
+		if err != nil {
+			statusCode = code
+			return err
 		}
 
-		respond(h.logger, w, http.StatusNoContent, task)
+		deleted = t
+		return repo.DeleteTask(t)
+	})
+	if err != nil {
+		respondWithError(h.logger, w, statusCode, err)
+		return
 	}
+
+	respond(h.logger, w, http.StatusNoContent, deleted)
 }
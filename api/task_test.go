@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"os"
@@ -11,6 +12,7 @@ import (
 	taskpkg "github.com/ankeesler/anwork/task"
 	"github.com/ankeesler/anwork/task/taskfakes"
 	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
 	. "github.com/onsi/gomega"
 	"github.com/tedsuo/ifrit"
 	"github.com/tedsuo/ifrit/http_server"
@@ -82,7 +84,7 @@ var _ = Describe("Task", func() {
 	Describe("Get", func() {
 		var task *taskpkg.Task
 		BeforeEach(func() {
-			task = &taskpkg.Task{Name: "task-a", ID: 1}
+			task = &taskpkg.Task{Name: "task-a", ID: 1, Version: 3}
 			repo.FindTaskByIDReturnsOnCall(0, task, nil)
 		})
 
@@ -98,14 +100,26 @@ var _ = Describe("Task", func() {
 			Expect(repo.FindTaskByIDArgsForCall(0)).To(Equal(10))
 		})
 
+		It("responds with the version of the task as its ETag", func() {
+			rsp, err := get("/api/v1/tasks/10")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.Header.Get("ETag")).To(Equal(`"3"`))
+		})
+
 		testAllCommonFailures(get)
 	})
 
 	Describe("Put", func() {
 		var task *taskpkg.Task
 		BeforeEach(func() {
-			task = &taskpkg.Task{Name: "task-a", ID: 10}
+			task = &taskpkg.Task{Name: "task-a", ID: 10, Version: 3}
 			repo.FindTaskByIDReturnsOnCall(0, task, nil)
+			repo.UpdateTaskStub = func(t *taskpkg.Task) error {
+				t.Version = 4
+				return nil
+			}
 		})
 
 		It("updates the task", func() {
@@ -120,10 +134,56 @@ var _ = Describe("Task", func() {
 			Expect(repo.FindTaskByIDCallCount()).To(Equal(1))
 			Expect(repo.FindTaskByIDArgsForCall(0)).To(Equal(10))
 
+			newTask.Version = 4
 			Expect(repo.UpdateTaskCallCount()).To(Equal(1))
 			Expect(repo.UpdateTaskArgsForCall(0)).To(Equal(&newTask))
 		})
 
+		It("responds with the new ETag of the task", func() {
+			rsp, err := put("/api/v1/tasks/10", task)
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+			Expect(rsp.Header.Get("ETag")).To(Equal(`"4"`))
+		})
+
+		Context("when the If-Match header matches the ETag of the task", func() {
+			It("updates the task", func() {
+				header := http.Header{"If-Match": {`"2", "3"`}}
+				rsp, err := doWithHeader(http.MethodPut, "/api/v1/tasks/10", task, header)
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+				Expect(repo.UpdateTaskCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the If-Match header is *", func() {
+			It("updates the task", func() {
+				rsp, err := doWithHeader(http.MethodPut, "/api/v1/tasks/10", task, http.Header{"If-Match": {"*"}})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+				Expect(repo.UpdateTaskCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the If-Match header does not match the ETag of the task", func() {
+			It("responds with a 412 and does not update the task", func() {
+				rsp, err := doWithHeader(http.MethodPut, "/api/v1/tasks/10", task, http.Header{"If-Match": {`"2"`}})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusPreconditionFailed))
+				assertError(rsp, `task with ID 10 was changed by someone else (its ETag is now "3")`)
+
+				Expect(repo.UpdateTaskCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when the request body is invalid", func() {
 			It("returns bad request", func() {
 				rsp, err := put("/api/v1/tasks/10", "asdf")
@@ -154,6 +214,125 @@ var _ = Describe("Task", func() {
 		})
 	})
 
+	Describe("Patch", func() {
+		var task *taskpkg.Task
+		BeforeEach(func() {
+			task = &taskpkg.Task{
+				Name:     "task-a",
+				ID:       10,
+				State:    taskpkg.StateReady,
+				Priority: 1,
+				Tags:     []string{"tag-a"},
+				Version:  3,
+			}
+			repo.FindTaskByIDReturnsOnCall(0, task, nil)
+			repo.UpdateTaskStub = func(t *taskpkg.Task) error {
+				t.Version = 4
+				return nil
+			}
+		})
+
+		patch := func(path string, body interface{}, header http.Header) (*http.Response, error) {
+			header.Set("Content-Type", "application/merge-patch+json")
+			return doWithHeader(http.MethodPatch, path, body, header)
+		}
+
+		It("only changes the fields in the patch", func() {
+			rsp, err := patch("/api/v1/tasks/10", map[string]interface{}{"priority": 5, "tags": nil}, http.Header{})
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+
+			newTask := taskpkg.Task{
+				Name:     "task-a",
+				ID:       10,
+				State:    taskpkg.StateReady,
+				Priority: 5,
+				Version:  4,
+			}
+			Expect(repo.UpdateTaskCallCount()).To(Equal(1))
+			Expect(repo.UpdateTaskArgsForCall(0)).To(Equal(&newTask))
+
+			var t taskpkg.Task
+			Expect(json.NewDecoder(rsp.Body).Decode(&t)).To(Succeed())
+			Expect(t).To(Equal(newTask))
+			Expect(rsp.Header.Get("ETag")).To(Equal(`"4"`))
+		})
+
+		Context("when the If-Match header does not match the ETag of the task", func() {
+			It("responds with a 412 and does not update the task", func() {
+				rsp, err := patch("/api/v1/tasks/10", map[string]interface{}{"priority": 5}, http.Header{"If-Match": {`"2"`}})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusPreconditionFailed))
+				Expect(repo.UpdateTaskCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the Content-Type is not a merge patch", func() {
+			It("responds with a 415", func() {
+				rsp, err := doWithHeader(http.MethodPatch, "/api/v1/tasks/10", []interface{}{}, http.Header{
+					"Content-Type": {"application/json-patch+json"},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusUnsupportedMediaType))
+				assertError(rsp, "unsupported patch type 'application/json-patch+json' (use 'application/merge-patch+json')")
+			})
+		})
+
+		DescribeTable(
+			"when the patch is invalid",
+			func(body interface{}, message string) {
+				rsp, err := patch("/api/v1/tasks/10", body, http.Header{})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+				assertError(rsp, message)
+				Expect(repo.UpdateTaskCallCount()).To(Equal(0))
+			},
+			Entry("not an object", "asdf", "patch must be a JSON object"),
+			Entry("invalid state", map[string]interface{}{"state": "Sleeping"}, "invalid state 'Sleeping'"),
+			Entry("removed state", map[string]interface{}{"state": nil}, "invalid state ''"),
+			Entry("removed name", map[string]interface{}{"name": nil}, "missing task name"),
+			Entry("changed ID", map[string]interface{}{"id": 11}, "cannot patch the id of a task"),
+			Entry("changed version", map[string]interface{}{"version": 5}, "cannot patch the version of a task"),
+			Entry(
+				"unknown field",
+				map[string]interface{}{"color": "blue"},
+				`invalid patch: json: unknown field "color"`,
+			),
+			Entry(
+				"wrong type",
+				map[string]interface{}{"priority": "high"},
+				"invalid patch: json: cannot unmarshal string into Go struct field Task.priority of type int",
+			),
+		)
+
+		Context("when the repo fails to update the task", func() {
+			BeforeEach(func() {
+				repo.UpdateTaskReturnsOnCall(0, errors.New("some update failure"))
+			})
+
+			It("responds with a 500 and the error", func() {
+				rsp, err := patch("/api/v1/tasks/10", map[string]interface{}{"priority": 5}, http.Header{})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusInternalServerError))
+				assertError(rsp, "some update failure")
+			})
+		})
+
+		testAllCommonFailures(func(path string) (*http.Response, error) {
+			return patch(path, map[string]interface{}{"priority": 5}, http.Header{})
+		})
+	})
+
 	Describe("Delete", func() {
 		var task *taskpkg.Task
 		BeforeEach(func() {
@@ -175,6 +354,19 @@ var _ = Describe("Task", func() {
 			Expect(repo.DeleteTaskArgsForCall(0)).To(Equal(task))
 		})
 
+		Context("when the If-Match header does not match the ETag of the task", func() {
+			It("responds with a 412 and does not delete the task", func() {
+				rsp, err := doWithHeader(http.MethodDelete, "/api/v1/tasks/10", nil, http.Header{"If-Match": {`"5"`}})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusPreconditionFailed))
+				assertError(rsp, `task with ID 10 was changed by someone else (its ETag is now "0")`)
+
+				Expect(repo.DeleteTaskCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when the repo fails to delete the task", func() {
 			BeforeEach(func() {
 				repo.DeleteTaskReturnsOnCall(0, errors.New("some delete failure"))
@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/query"
 	taskpkg "github.com/ankeesler/anwork/task"
 )
 
@@ -16,6 +17,17 @@ type getTasksHandler struct {
 }
 
 func (h *getTasksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tags := r.URL.Query()["tag"]
+
+	var q *query.Query
+	if where := r.URL.Query().Get("q"); where != "" {
+		var err error
+		if q, err = query.Parse(where); err != nil {
+			respondWithError(h.logger, w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
 	name := r.URL.Query().Get("name")
 	if name != "" {
 		task, err := h.repo.FindTaskByName(name)
@@ -25,22 +37,66 @@ func (h *getTasksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		tasks := make([]*taskpkg.Task, 0, 1)
-		if task != nil {
+		if task != nil && hasTags(task, tags) && (q == nil || q.Matches(task)) {
 			tasks = append(tasks, task)
 		}
 		respond(h.logger, w, http.StatusOK, tasks)
 		return
 	}
 
-	tasks, err := h.repo.Tasks()
+	tasks, err := h.tasks(q)
 	if err != nil {
 		respondWithError(h.logger, w, http.StatusInternalServerError, err)
 		return
 	}
 
+	if len(tags) > 0 {
+		taggedTasks := make([]*taskpkg.Task, 0, len(tasks))
+		for _, task := range tasks {
+			if hasTags(task, tags) {
+				taggedTasks = append(taggedTasks, task)
+			}
+		}
+		tasks = taggedTasks
+	}
+
 	respond(h.logger, w, http.StatusOK, tasks)
 }
 
+// tasks returns the tasks that match the query, or all of the tasks if the query is nil.
+func (h *getTasksHandler) tasks(q *query.Query) ([]*taskpkg.Task, error) {
+	if q == nil {
+		return h.repo.Tasks()
+	}
+
+	if queryRepo, ok := h.repo.(query.Repo); ok {
+		return queryRepo.TasksMatching(q)
+	}
+
+	tasks, err := h.repo.Tasks()
+	if err != nil {
+		return nil, err
+	}
+
+	matchingTasks := make([]*taskpkg.Task, 0, len(tasks))
+	for _, task := range tasks {
+		if q.Matches(task) {
+			matchingTasks = append(matchingTasks, task)
+		}
+	}
+	return matchingTasks, nil
+}
+
+// hasTags returns whether the task has every one of the provided tags.
+func hasTags(task *taskpkg.Task, tags []string) bool {
+	for _, tag := range tags {
+		if !task.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
 type createTaskHandler struct {
 	logger lager.Logger
 	repo   taskpkg.Repo
@@ -65,6 +121,7 @@ func (h *createTaskHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Add("Location", fmt.Sprintf("/api/v1/tasks/%d", task.ID))
+	w.Header().Add("Location", fmt.Sprintf("%s/%d", r.URL.Path, task.ID))
+	w.Header().Set("ETag", etag(&task))
 	respond(h.logger, w, http.StatusCreated, nil)
 }
@@ -112,6 +112,104 @@ var _ = Describe("Tasks", func() {
 				})
 			})
 
+			Context("when the query parameter is 'tag'", func() {
+				BeforeEach(func() {
+					tasks[0].Tags = []string{"tag-a", "tag-b"}
+					tasks[2].Tags = []string{"tag-b"}
+				})
+
+				It("returns the tasks with that tag", func() {
+					rsp, err := get("/api/v1/tasks?tag=tag-b")
+					Expect(err).NotTo(HaveOccurred())
+					defer rsp.Body.Close()
+
+					Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+					assertTasks(rsp, []*taskpkg.Task{tasks[0], tasks[2]})
+				})
+
+				Context("when the query parameter is passed multiple times", func() {
+					It("returns the tasks with all of those tags", func() {
+						rsp, err := get("/api/v1/tasks?tag=tag-b&tag=tag-a")
+						Expect(err).NotTo(HaveOccurred())
+						defer rsp.Body.Close()
+
+						Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+						assertTasks(rsp, []*taskpkg.Task{tasks[0]})
+					})
+				})
+
+				Context("when no tasks have that tag", func() {
+					It("returns an empty array of tasks", func() {
+						rsp, err := get("/api/v1/tasks?tag=tag-c")
+						Expect(err).NotTo(HaveOccurred())
+						defer rsp.Body.Close()
+
+						Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+						assertTasks(rsp, []*taskpkg.Task{})
+					})
+				})
+
+				Context("when the 'name' query parameter is also passed", func() {
+					BeforeEach(func() {
+						repo.FindTaskByNameReturnsOnCall(0, tasks[1], nil)
+					})
+
+					It("returns an empty array if the task does not have the tag", func() {
+						rsp, err := get("/api/v1/tasks?name=task-b&tag=tag-b")
+						Expect(err).NotTo(HaveOccurred())
+						defer rsp.Body.Close()
+
+						Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+						assertTasks(rsp, []*taskpkg.Task{})
+					})
+				})
+			})
+
+			Context("when the query parameter is 'q'", func() {
+				BeforeEach(func() {
+					tasks[0].Priority = 5
+					tasks[1].Priority = 10
+					tasks[2].Priority = 1
+				})
+
+				It("returns the tasks that match the query", func() {
+					rsp, err := get("/api/v1/tasks?q=priority%3C10")
+					Expect(err).NotTo(HaveOccurred())
+					defer rsp.Body.Close()
+
+					Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+					assertTasks(rsp, []*taskpkg.Task{tasks[0], tasks[2]})
+				})
+
+				Context("when the 'name' query parameter is also passed", func() {
+					BeforeEach(func() {
+						repo.FindTaskByNameReturnsOnCall(0, tasks[1], nil)
+					})
+
+					It("returns an empty array if the task does not match the query", func() {
+						rsp, err := get("/api/v1/tasks?name=task-b&q=priority%3C10")
+						Expect(err).NotTo(HaveOccurred())
+						defer rsp.Body.Close()
+
+						Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+						assertTasks(rsp, []*taskpkg.Task{})
+					})
+				})
+
+				Context("when the query is invalid", func() {
+					It("returns a 400 with an error", func() {
+						rsp, err := get("/api/v1/tasks?q=color:red")
+						Expect(err).NotTo(HaveOccurred())
+						defer rsp.Body.Close()
+
+						Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+						assertError(rsp, "invalid query 'color:red': unknown field 'color'")
+
+						Expect(repo.TasksCallCount()).To(Equal(0))
+					})
+				})
+			})
+
 			Context("when the query parameter is not 'name'", func() {
 				It("ignores it and returns the regular respond", func() {
 					rsp, err := get("/api/v1/tasks")
@@ -1,5 +1,7 @@
 package api
 
+import "github.com/ankeesler/anwork/task"
+
 // Error is sent in any non 2XX responses.
 type Error struct {
 	Message string
@@ -9,3 +11,63 @@ type Error struct {
 type Auth struct {
 	Token string
 }
+
+// Credentials are sent in a POST to the /api/v1/auth endpoint to get a token for the user.User
+// with the Name, when the API has users.
+type Credentials struct {
+	Name     string
+	Password string
+}
+
+// Context is sent in a POST to the /api/v1/contexts endpoint. If CopyFrom is not empty, the
+// context is created with a copy of everything in the context named CopyFrom.
+type Context struct {
+	Name     string
+	CopyFrom string
+}
+
+// Operation is sent in a POST to the /api/v2 endpoints, each of which performs an operation of a
+// manager.Manager, e.g., set_state. Name is the name of the task.Task on which the operation is
+// performed, and State, Priority, Note, and To (the new name of the task.Task) are used by the
+// operations that need them. Actor is recorded as the actor of the task.Event's that are created,
+// unless the API has users, in which case the user who made the request is recorded instead.
+type Operation struct {
+	Name     string
+	State    task.State
+	Priority int
+	Note     string
+	To       string
+	Actor    string
+}
+
+// Result is sent in response to a successful POST to the /api/v2 endpoints. Tasks are the
+// task.Task's that the operation changed, as they are afterwards, or as they were before they were
+// deleted. Events are the task.Event's that the operation created.
+type Result struct {
+	Tasks  []*task.Task
+	Events []*task.Event
+}
+
+// Change is sent on the /api/v1/events/stream endpoint each time that something is created,
+// updated, or deleted through the API. Action is one of ActionCreate, ActionUpdate, and
+// ActionDelete, and exactly one of Task, Event, and Dependency is set: it is the thing that was
+// changed, as it is afterwards, or as it was before it was deleted.
+type Change struct {
+	Action     string
+	Task       *task.Task       `json:",omitempty"`
+	Event      *task.Event      `json:",omitempty"`
+	Dependency *task.Dependency `json:",omitempty"`
+}
+
+// Name returns the name of the route that makes the Change, e.g., "create_event" or "update_task",
+// which is also the name of the server-sent event that carries it.
+func (c *Change) Name() string {
+	switch {
+	case c.Task != nil:
+		return c.Action + "_task"
+	case c.Event != nil:
+		return c.Action + "_event"
+	default:
+		return c.Action + "_dependency"
+	}
+}
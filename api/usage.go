@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
 
+	"github.com/ankeesler/anwork/session"
 	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
 )
 
 //go:generate go run ../cmd/genapidoc/main.go ../doc/API.md
@@ -18,42 +21,75 @@ type extraRouteData struct {
 
 var erd = map[string]extraRouteData{
 	"auth": extraRouteData{
-		description: "create (encrypted) authentication token",
+		description: "create (encrypted) authentication token, for the user with the `Name` and `Password` in the optional body (which is required once the API has users)",
+		inputType:   reflect.TypeOf(Credentials{}),
 		outputType:  reflect.TypeOf(""),
 	},
+	"logout": extraRouteData{
+		description: "revoke the authentication token with which the request is made, i.e., log out",
+	},
 	"health": extraRouteData{
 		description: "test the health of the API",
 		outputType:  reflect.TypeOf(""),
 	},
 
+	"get_sessions": extraRouteData{
+		description: "get the active sessions (one for each authentication token) of the user who makes the request, or all of them if the API has no users",
+		outputType:  reflect.SliceOf(reflect.TypeOf(session.Session{})),
+	},
+	"delete_session": extraRouteData{
+		description: "revoke a session, after which its authentication token is no longer accepted",
+	},
+
+	"get_contexts": extraRouteData{
+		description: "get the names of all contexts",
+		outputType:  reflect.SliceOf(reflect.TypeOf("")),
+	},
+	"create_context": extraRouteData{
+		description: "create a context, optionally with a copy of the context named `CopyFrom` (as long as you can see all of its tasks, when the API has users)",
+		inputType:   reflect.TypeOf(Context{}),
+	},
+	"delete_context": extraRouteData{
+		description: "delete a context and everything in it (as long as none of it belongs to someone else, when the API has users)",
+	},
+
 	"get_tasks": extraRouteData{
-		description: "get all tasks",
+		description: "get all tasks, optionally filtered by `name`, `tag`, and/or `q` (a query, e.g., `state:running priority<5`) query parameters",
 		outputType:  reflect.SliceOf(reflect.TypeOf(task.Task{})),
 	},
 	"create_task": extraRouteData{
-		description: "create a task",
+		description: "create a task; the `ETag` header of the response is its version",
 		inputType:   reflect.TypeOf(task.Task{}),
 	},
 	"get_task": extraRouteData{
-		description: "get a task",
+		description: "get a task; the `ETag` header of the response is its version",
 		outputType:  reflect.TypeOf(task.Task{}),
 	},
 	"update_task": extraRouteData{
-		description: "update a task",
+		description: "update a task; send its `ETag` in the `If-Match` header to only update it if no one else has since (otherwise, the response is a 412)",
 		inputType:   reflect.TypeOf(task.Task{}),
 	},
+	"patch_task": extraRouteData{
+		description: "change some fields of a task with a JSON merge patch (RFC 7396, `Content-Type: application/merge-patch+json`), e.g., `{\"priority\": 3}`, and get the changed task; send its `ETag` in the `If-Match` header to only change it if no one else has since (otherwise, the response is a 412)",
+		inputType:   reflect.TypeOf(task.Task{}),
+		outputType:  reflect.TypeOf(task.Task{}),
+	},
 	"delete_task": extraRouteData{
-		description: "delete a task",
+		description: "delete a task; send its `ETag` in the `If-Match` header to only delete it if no one else has changed it since (otherwise, the response is a 412)",
 	},
 
 	"get_events": extraRouteData{
-		description: "get all events",
+		description: "get all events, oldest first; filter them with `task_id`, `type` (repeatable), `since` and `until` (seconds since the epoch, until exclusive), order them with `order=asc|desc`, and page through them with `limit` and `cursor` (the ID of the last event of the previous page), following the `Link` header with `rel=\"next\"`",
 		outputType:  reflect.SliceOf(reflect.TypeOf(task.Event{})),
 	},
 	"create_event": extraRouteData{
 		description: "create an event",
 		inputType:   reflect.TypeOf(task.Event{}),
 	},
+	"stream_events": extraRouteData{
+		description: "stream the changes made through the API as server-sent events; send `Last-Event-ID` to first replay the events created after that one",
+		outputType:  reflect.TypeOf(Change{}),
+	},
 	"get_event": extraRouteData{
 		description: "get an event",
 		outputType:  reflect.TypeOf(task.Event{}),
@@ -61,6 +97,79 @@ var erd = map[string]extraRouteData{
 	"delete_event": extraRouteData{
 		description: "delete an event",
 	},
+
+	"get_dependencies": extraRouteData{
+		description: "get all dependencies",
+		outputType:  reflect.SliceOf(reflect.TypeOf(task.Dependency{})),
+	},
+	"create_dependency": extraRouteData{
+		description: "create a dependency",
+		inputType:   reflect.TypeOf(task.Dependency{}),
+	},
+	"get_dependency": extraRouteData{
+		description: "get a dependency",
+		outputType:  reflect.TypeOf(task.Dependency{}),
+	},
+	"delete_dependency": extraRouteData{
+		description: "delete a dependency",
+	},
+
+	"get_webhooks": extraRouteData{
+		description: "get all webhooks (only your own, and those that belong to no one, when the API has users), without their secrets",
+		outputType:  reflect.SliceOf(reflect.TypeOf(webhook.Webhook{})),
+	},
+	"create_webhook": extraRouteData{
+		description: "create a webhook, to which each event that it matches is POSTed, signed with its `Secret` in the `X-Anwork-Signature` header, as long as its owner can see the task of the event",
+		inputType:   reflect.TypeOf(webhook.Webhook{}),
+	},
+	"get_webhook": extraRouteData{
+		description: "get a webhook, without its secret",
+		outputType:  reflect.TypeOf(webhook.Webhook{}),
+	},
+	"delete_webhook": extraRouteData{
+		description: "delete a webhook",
+	},
+
+	"get_dead_letters": extraRouteData{
+		description: "get all of the events that could not be delivered to a webhook (only your own, and those that belong to no one, when the API has users)",
+		outputType:  reflect.SliceOf(reflect.TypeOf(webhook.DeadLetter{})),
+	},
+
+	"create": extraRouteData{
+		description: "create a task named `Name`, with an event",
+		inputType:   reflect.TypeOf(Operation{}),
+		outputType:  reflect.TypeOf(Result{}),
+	},
+	"set_state": extraRouteData{
+		description: "set the state of the task named `Name` to `State`, with an event, and update the tasks that depend on it",
+		inputType:   reflect.TypeOf(Operation{}),
+		outputType:  reflect.TypeOf(Result{}),
+	},
+	"set_priority": extraRouteData{
+		description: "set the priority of the task named `Name` to `Priority`, with an event",
+		inputType:   reflect.TypeOf(Operation{}),
+		outputType:  reflect.TypeOf(Result{}),
+	},
+	"note": extraRouteData{
+		description: "add the note `Note` to the task named `Name`",
+		inputType:   reflect.TypeOf(Operation{}),
+		outputType:  reflect.TypeOf(Result{}),
+	},
+	"rename": extraRouteData{
+		description: "rename the task named `Name` to `To`, with an event",
+		inputType:   reflect.TypeOf(Operation{}),
+		outputType:  reflect.TypeOf(Result{}),
+	},
+	"delete": extraRouteData{
+		description: "delete the task named `Name` and its dependencies, with an event",
+		inputType:   reflect.TypeOf(Operation{}),
+		outputType:  reflect.TypeOf(Result{}),
+	},
+	"archive": extraRouteData{
+		description: "delete every finished task, with an event for each",
+		inputType:   reflect.TypeOf(Operation{}),
+		outputType:  reflect.TypeOf(Result{}),
+	},
 }
 
 // MarkdownUsage will print usage documentation for the ANWORK API to an io.Writer.
@@ -69,8 +178,13 @@ func MarkdownUsage(output io.Writer) {
 	for _, route := range routes {
 		fmt.Fprintf(output, "### `%s`: `%s %s`\n", route.Name, route.Method, route.Path)
 
-		if extra, ok := erd[route.Name]; ok {
-			fmt.Fprintf(output, "* %s\n", extra.description)
+		name, description := route.Name, "%s"
+		if strings.HasPrefix(name, contextRoutePrefix) {
+			name, description = strings.TrimPrefix(name, contextRoutePrefix), "%s, in a context"
+		}
+
+		if extra, ok := erd[name]; ok {
+			fmt.Fprintf(output, "* "+description+"\n", extra.description)
 			fmt.Fprintf(output, "* input: `%s`\n", typeName(extra.inputType))
 			fmt.Fprintf(output, "* output: `%s`\n", typeName(extra.outputType))
 		} else {
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+	"github.com/tedsuo/rata"
+)
+
+// errNoWebhooks is returned from the webhook endpoints when the task.Repo is not a webhook.Repo.
+var errNoWebhooks = errors.New("webhooks are not supported by this repo")
+
+// webhookRepo responds with a 501 if a task.Repo cannot store webhook.Webhook's, and returns the
+// webhook.Repo if it can.
+func webhookRepo(logger lager.Logger, repo task.Repo, w http.ResponseWriter) webhook.Repo {
+	webhooks, ok := repo.(webhook.Repo)
+	if !ok {
+		respondWithError(logger, w, http.StatusNotImplemented, errNoWebhooks)
+		return nil
+	}
+	return webhooks
+}
+
+// validateWebhook returns an error if a webhook.Webhook cannot be delivered to.
+func validateWebhook(w *webhook.Webhook) error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL '%s'", w.URL)
+	}
+
+	if w.Secret == "" {
+		return errors.New("missing webhook secret")
+	}
+
+	for _, state := range w.States {
+		if err := validateState(state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withoutSecret returns a copy of a webhook.Webhook that can be sent in a response.
+func withoutSecret(w *webhook.Webhook) *webhook.Webhook {
+	c := *w
+	c.Secret = ""
+	return &c
+}
+
+type getWebhooksHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+}
+
+func (h *getWebhooksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	repo := webhookRepo(h.logger, h.repo, w)
+	if repo == nil {
+		return
+	}
+
+	webhooks, err := repo.Webhooks()
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	for i, webhook := range webhooks {
+		webhooks[i] = withoutSecret(webhook)
+	}
+	respond(h.logger, w, http.StatusOK, webhooks)
+}
+
+type createWebhookHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+}
+
+func (h *createWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	repo := webhookRepo(h.logger, h.repo, w)
+	if repo == nil {
+		return
+	}
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var webhook webhook.Webhook
+	if err := json.Unmarshal(data, &webhook); err != nil {
+		respondWithError(h.logger, w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validateWebhook(&webhook); err != nil {
+		respondWithError(h.logger, w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.logger.Debug("creating-webhook", lager.Data{"url": webhook.URL})
+	if err := repo.CreateWebhook(&webhook); err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Add("Location", fmt.Sprintf("%s/%d", r.URL.Path, webhook.ID))
+	respond(h.logger, w, http.StatusCreated, nil)
+}
+
+func findWebhook(
+	logger lager.Logger,
+	repo webhook.Repo,
+	w http.ResponseWriter,
+	r *http.Request,
+) *webhook.Webhook {
+	id := rata.Param(r, "id")
+	idN, err := strconv.Atoi(id)
+	if err != nil {
+		respondWithError(logger, w, http.StatusBadRequest, err)
+		return nil
+	}
+
+	webhook, err := repo.FindWebhookByID(idN)
+	if err != nil {
+		respondWithError(logger, w, http.StatusInternalServerError, err)
+		return nil
+	}
+
+	if webhook == nil {
+		respondWithError(logger, w, http.StatusNotFound, fmt.Errorf("unknown webhook with ID %d", idN))
+		return nil
+	}
+
+	return webhook
+}
+
+type getWebhookHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+}
+
+func (h *getWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	repo := webhookRepo(h.logger, h.repo, w)
+	if repo == nil {
+		return
+	}
+
+	if webhook := findWebhook(h.logger, repo, w, r); webhook != nil {
+		respond(h.logger, w, http.StatusOK, withoutSecret(webhook))
+	}
+}
+
+type deleteWebhookHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+}
+
+func (h *deleteWebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	repo := webhookRepo(h.logger, h.repo, w)
+	if repo == nil {
+		return
+	}
+
+	if webhook := findWebhook(h.logger, repo, w, r); webhook != nil {
+		if err := repo.DeleteWebhook(webhook); err != nil {
+			respondWithError(h.logger, w, http.StatusInternalServerError, err)
+			return
+		}
+
+		respond(h.logger, w, http.StatusNoContent, nil)
+	}
+}
+
+type getDeadLettersHandler struct {
+	logger lager.Logger
+	repo   task.Repo
+}
+
+func (h *getDeadLettersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	repo := webhookRepo(h.logger, h.repo, w)
+	if repo == nil {
+		return
+	}
+
+	deadLetters, err := repo.DeadLetters()
+	if err != nil {
+		respondWithError(h.logger, w, http.StatusInternalServerError, err)
+		return
+	}
+
+	respond(h.logger, w, http.StatusOK, deadLetters)
+}
@@ -0,0 +1,393 @@
+package api_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/ankeesler/anwork/api"
+	"github.com/ankeesler/anwork/api/apifakes"
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/memory"
+	"github.com/ankeesler/anwork/task/taskfakes"
+	"github.com/ankeesler/anwork/user"
+	"github.com/ankeesler/anwork/webhook"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/http_server"
+)
+
+var _ = Describe("Webhooks", func() {
+	var (
+		repo          taskpkg.Repo
+		contexts      taskpkg.Contexts
+		authenticator *apifakes.FakeAuthenticator
+		dispatcher    *webhook.Dispatcher
+		options       []api.Option
+
+		receiver   *httptest.Server
+		deliveries chan *webhook.Delivery
+		statusCode int32
+
+		process ifrit.Process
+	)
+
+	BeforeEach(func() {
+		repo = memory.New()
+		contexts = memory.NewContexts()
+		authenticator = &apifakes.FakeAuthenticator{}
+		dispatcher = webhook.NewDispatcher(
+			lagertest.NewTestLogger("webhooks"),
+			webhook.WithRetries(3, time.Millisecond),
+		)
+		options = nil
+
+		deliveries = make(chan *webhook.Delivery, 100)
+		atomic.StoreInt32(&statusCode, http.StatusOK)
+		receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+
+			body, err := ioutil.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+			Expect(webhook.Verify("some-secret", body, r.Header.Get(webhook.SignatureHeader))).To(BeTrue())
+
+			status := int(atomic.LoadInt32(&statusCode))
+			if status == http.StatusOK {
+				var delivery webhook.Delivery
+				Expect(json.Unmarshal(body, &delivery)).To(Succeed())
+				deliveries <- &delivery
+			}
+			w.WriteHeader(status)
+		}))
+	})
+
+	JustBeforeEach(func() {
+		options = append(options, api.WithContexts(contexts), api.WithDispatcher(dispatcher))
+		a := api.New(lagertest.NewTestLogger("api"), repo, authenticator, options...)
+		runner := http_server.New("127.0.0.1:12345", a)
+		process = ifrit.Invoke(runner)
+	})
+
+	AfterEach(func() {
+		process.Signal(os.Kill)
+		Eventually(process.Wait()).Should(Receive())
+
+		dispatcher.Wait()
+		receiver.Close()
+	})
+
+	// createWebhook POSTs a webhook.Webhook to a path and returns its Location.
+	createWebhook := func(path string, w *webhook.Webhook) string {
+		rsp, err := post(path, w)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		ExpectWithOffset(1, rsp.StatusCode).To(Equal(http.StatusCreated))
+		return rsp.Header.Get("Location")
+	}
+
+	getWebhooks := func(path string) []*webhook.Webhook {
+		rsp, err := get(path)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		defer rsp.Body.Close()
+		ExpectWithOffset(1, rsp.StatusCode).To(Equal(http.StatusOK))
+
+		var webhooks []*webhook.Webhook
+		ExpectWithOffset(1, json.NewDecoder(rsp.Body).Decode(&webhooks)).To(Succeed())
+		return webhooks
+	}
+
+	Describe("Create", func() {
+		It("creates the webhook", func() {
+			location := createWebhook("/api/v1/webhooks", &webhook.Webhook{
+				URL:    receiver.URL,
+				States: []taskpkg.State{taskpkg.StateBlocked},
+				Secret: "some-secret",
+			})
+			Expect(location).To(Equal("/api/v1/webhooks/1"))
+
+			rsp, err := get(location)
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+
+			var w webhook.Webhook
+			Expect(json.NewDecoder(rsp.Body).Decode(&w)).To(Succeed())
+			Expect(w).To(Equal(webhook.Webhook{
+				ID:     1,
+				URL:    receiver.URL,
+				States: []taskpkg.State{taskpkg.StateBlocked},
+			}))
+		})
+
+		It("never responds with the secret", func() {
+			createWebhook("/api/v1/webhooks", &webhook.Webhook{URL: receiver.URL, Secret: "some-secret"})
+			Expect(getWebhooks("/api/v1/webhooks")).To(Equal([]*webhook.Webhook{
+				&webhook.Webhook{ID: 1, URL: receiver.URL},
+			}))
+		})
+
+		Context("when the URL is invalid", func() {
+			It("responds with a 400", func() {
+				rsp, err := post("/api/v1/webhooks", &webhook.Webhook{URL: "ftp://example.com", Secret: "some-secret"})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+				assertError(rsp, "invalid webhook URL 'ftp://example.com'")
+			})
+		})
+
+		Context("when the secret is missing", func() {
+			It("responds with a 400", func() {
+				rsp, err := post("/api/v1/webhooks", &webhook.Webhook{URL: receiver.URL})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+				assertError(rsp, "missing webhook secret")
+			})
+		})
+
+		Context("when a state is invalid", func() {
+			It("responds with a 400", func() {
+				rsp, err := post("/api/v1/webhooks", &webhook.Webhook{
+					URL:    receiver.URL,
+					States: []taskpkg.State{"Sleeping"},
+					Secret: "some-secret",
+				})
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusBadRequest))
+				assertError(rsp, "invalid state 'Sleeping'")
+			})
+		})
+	})
+
+	Describe("Delete", func() {
+		It("deletes the webhook", func() {
+			location := createWebhook("/api/v1/webhooks", &webhook.Webhook{URL: receiver.URL, Secret: "some-secret"})
+
+			rsp, err := deletee(location)
+			Expect(err).NotTo(HaveOccurred())
+			rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusNoContent))
+
+			Expect(getWebhooks("/api/v1/webhooks")).To(BeEmpty())
+		})
+
+		Context("when the webhook does not exist", func() {
+			It("responds with a 404", func() {
+				rsp, err := deletee("/api/v1/webhooks/5")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+
+				Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+				assertError(rsp, "unknown webhook with ID 5")
+			})
+		})
+	})
+
+	Describe("Deliveries", func() {
+		BeforeEach(func() {
+			Expect(repo.CreateTask(&taskpkg.Task{Name: "task-a", State: taskpkg.StateReady})).To(Succeed())
+		})
+
+		JustBeforeEach(func() {
+			createWebhook("/api/v1/webhooks", &webhook.Webhook{
+				URL:        receiver.URL,
+				EventTypes: []taskpkg.EventType{taskpkg.EventTypeSetState},
+				States:     []taskpkg.State{taskpkg.StateBlocked, taskpkg.StateFinished},
+				Secret:     "some-secret",
+			})
+		})
+
+		It("delivers the events that the webhook matches", func() {
+			perform := func(o api.Operation) {
+				rsp, err := post("/api/v2/set-state", o)
+				Expect(err).NotTo(HaveOccurred())
+				rsp.Body.Close()
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+			}
+			perform(api.Operation{Name: "task-a", State: taskpkg.StateRunning})
+			perform(api.Operation{Name: "task-a", State: taskpkg.StateBlocked})
+
+			var delivery *webhook.Delivery
+			Eventually(deliveries).Should(Receive(&delivery))
+			Expect(delivery.WebhookID).To(Equal(1))
+			Expect(delivery.Context).To(BeEmpty())
+			Expect(delivery.Event.Type).To(BeEquivalentTo(taskpkg.EventTypeSetState))
+			Expect(delivery.Event.NewValue).To(Equal("Blocked"))
+
+			dispatcher.Wait()
+			Expect(deliveries).NotTo(Receive())
+		})
+
+		Context("when the webhook does not accept the delivery", func() {
+			BeforeEach(func() {
+				atomic.StoreInt32(&statusCode, http.StatusServiceUnavailable)
+			})
+
+			It("retries, and then records a dead letter", func() {
+				rsp, err := post("/api/v2/set-state", api.Operation{Name: "task-a", State: taskpkg.StateFinished})
+				Expect(err).NotTo(HaveOccurred())
+				rsp.Body.Close()
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+
+				dispatcher.Wait()
+
+				rsp, err = get("/api/v1/dead-letters")
+				Expect(err).NotTo(HaveOccurred())
+				defer rsp.Body.Close()
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+
+				var deadLetters []*webhook.DeadLetter
+				Expect(json.NewDecoder(rsp.Body).Decode(&deadLetters)).To(Succeed())
+				Expect(deadLetters).To(HaveLen(1))
+				Expect(deadLetters[0].WebhookID).To(Equal(1))
+				Expect(deadLetters[0].URL).To(Equal(receiver.URL))
+				Expect(deadLetters[0].Event.NewValue).To(Equal("Finished"))
+				Expect(deadLetters[0].Attempts).To(Equal(3))
+				Expect(deadLetters[0].Error).To(Equal("unexpected response: 503 Service Unavailable"))
+			})
+
+		})
+
+		It("does not let clients record their own dead letters", func() {
+			rsp, err := post("/api/v1/dead-letters", webhook.DeadLetter{WebhookID: 1})
+			Expect(err).NotTo(HaveOccurred())
+			rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusMethodNotAllowed))
+
+			Expect(repo.(webhook.Repo).DeadLetters()).To(BeEmpty())
+		})
+
+		Context("when the event is created in a context", func() {
+			BeforeEach(func() {
+				Expect(contexts.CreateContext("work")).To(Succeed())
+				contextRepo, err := contexts.Repo("work")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(contextRepo.CreateTask(&taskpkg.Task{Name: "task-b", State: taskpkg.StateReady})).To(Succeed())
+			})
+
+			It("only delivers it to the webhooks of that context", func() {
+				createWebhook("/api/v1/contexts/work/webhooks", &webhook.Webhook{
+					URL:    receiver.URL,
+					Secret: "some-secret",
+				})
+
+				rsp, err := post("/api/v2/contexts/work/set-state", api.Operation{Name: "task-b", State: taskpkg.StateBlocked})
+				Expect(err).NotTo(HaveOccurred())
+				rsp.Body.Close()
+				Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+
+				var delivery *webhook.Delivery
+				Eventually(deliveries).Should(Receive(&delivery))
+				Expect(delivery.WebhookID).To(Equal(1))
+				Expect(delivery.Context).To(Equal("work"))
+				Expect(delivery.Event.NewValue).To(Equal("Blocked"))
+
+				dispatcher.Wait()
+				Expect(deliveries).NotTo(Receive())
+			})
+		})
+	})
+
+	Context("when the API has users", func() {
+		// as makes the requests that follow for a user.
+		as := func(name string) {
+			authenticator.AuthenticateReturns(name, nil)
+		}
+
+		BeforeEach(func() {
+			users := repo.(user.Repo)
+			for _, name := range []string{"user-a", "user-b"} {
+				Expect(users.CreateUser(&user.User{Name: name})).To(Succeed())
+			}
+			options = append(options, api.WithUsers(users))
+		})
+
+		It("only shows a user their own webhooks and dead letters, and the ones that belong to no one", func() {
+			as("user-a")
+			createWebhook("/api/v1/webhooks", &webhook.Webhook{URL: "https://example.com/a", Secret: "secret-a"})
+			as("user-b")
+			createWebhook("/api/v1/webhooks", &webhook.Webhook{URL: "https://example.com/b", Secret: "secret-b"})
+			webhooks := repo.(webhook.Repo)
+			Expect(webhooks.CreateWebhook(&webhook.Webhook{URL: "https://example.com/c", Secret: "secret-c"})).To(Succeed())
+			for _, owner := range []string{"user-a", "user-b", ""} {
+				Expect(webhooks.CreateDeadLetter(&webhook.DeadLetter{Owner: owner})).To(Succeed())
+			}
+
+			as("user-a")
+			Expect(getWebhooks("/api/v1/webhooks")).To(Equal([]*webhook.Webhook{
+				{ID: 1, URL: "https://example.com/a", Owner: "user-a"},
+				{ID: 3, URL: "https://example.com/c"},
+			}))
+
+			rsp, err := get("/api/v1/webhooks/2")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+
+			rsp, err = deletee("/api/v1/webhooks/2")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusNotFound))
+			Expect(webhooks.FindWebhookByID(2)).NotTo(BeNil())
+
+			rsp, err = get("/api/v1/dead-letters")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusOK))
+
+			var deadLetters []*webhook.DeadLetter
+			Expect(json.NewDecoder(rsp.Body).Decode(&deadLetters)).To(Succeed())
+			Expect(deadLetters).To(Equal([]*webhook.DeadLetter{{ID: 1, Owner: "user-a"}, {ID: 3}}))
+		})
+
+		It("only delivers the events about a task to the webhooks whose owners can see it", func() {
+			as("user-a")
+			createWebhook("/api/v1/webhooks", &webhook.Webhook{URL: receiver.URL, Secret: "some-secret"})
+			as("user-b")
+			createWebhook("/api/v1/webhooks", &webhook.Webhook{URL: receiver.URL, Secret: "some-secret"})
+			webhooks := repo.(webhook.Repo)
+			Expect(webhooks.CreateWebhook(&webhook.Webhook{URL: receiver.URL, Secret: "some-secret"})).To(Succeed())
+
+			as("user-a")
+			rsp, err := post("/api/v2/create", api.Operation{Name: "task-a"})
+			Expect(err).NotTo(HaveOccurred())
+			rsp.Body.Close()
+			Expect(rsp.StatusCode).To(Equal(http.StatusCreated))
+
+			var delivery *webhook.Delivery
+			Eventually(deliveries).Should(Receive(&delivery))
+			Expect(delivery.WebhookID).To(Equal(1))
+			Expect(delivery.Event.Title).To(Equal("Created task 'task-a'"))
+
+			dispatcher.Wait()
+			Expect(deliveries).NotTo(Receive())
+		})
+	})
+
+	Context("when the repo is not a webhook.Repo", func() {
+		BeforeEach(func() {
+			repo = &taskfakes.FakeRepo{}
+		})
+
+		It("responds with a 501", func() {
+			rsp, err := get("/api/v1/webhooks")
+			Expect(err).NotTo(HaveOccurred())
+			defer rsp.Body.Close()
+
+			Expect(rsp.StatusCode).To(Equal(http.StatusNotImplemented))
+			assertError(rsp, "webhooks are not supported by this repo")
+		})
+	})
+})
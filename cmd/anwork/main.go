@@ -9,6 +9,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/user"
 	"path/filepath"
 
 	"code.cloudfoundry.org/clock"
@@ -19,7 +20,12 @@ import (
 	"github.com/ankeesler/anwork/manager"
 	runner "github.com/ankeesler/anwork/runner"
 	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/eventsource"
 	"github.com/ankeesler/anwork/task/fs"
+	"github.com/ankeesler/anwork/task/sql"
+	"github.com/ankeesler/anwork/webhook"
+	"golang.org/x/crypto/ssh/terminal"
+	_ "modernc.org/sqlite"
 )
 
 var (
@@ -65,9 +71,12 @@ func (dw *debugWriter) Write(data []byte) (int, error) {
 
 func main() {
 	var (
-		context string
-		root    rootFlagValue
-		dw      debugWriter
+		context  string
+		root     rootFlagValue
+		dw       debugWriter
+		schedule string
+		format   string
+		repoType string
 	)
 
 	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
@@ -76,6 +85,10 @@ func main() {
 
 	flags.StringVar(&context, "c", "default-context", "Set the persistence context")
 	flags.Var(&root, "o", "Set the persistence root directory")
+	flags.StringVar(&repoType, "repo", "fs", "Set how tasks are persisted (fs, eventsource, or sqlite)")
+
+	flags.StringVar(&schedule, "schedule", "priority", "Set how tasks are ordered (priority or deadline)")
+	flags.StringVar(&format, "format", "text", "Set the output format (text, json, yaml, csv, or tsv)")
 
 	flags.Usage = func() {
 		fmt.Println("Usage of anwork")
@@ -101,6 +114,22 @@ func main() {
 		os.Exit(0)
 	}
 
+	var scheduling manager.Scheduling
+	switch schedule {
+	case "priority":
+		scheduling = manager.SchedulingPriority
+	case "deadline":
+		scheduling = manager.SchedulingDeadline
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown schedule: '%s'\n", schedule)
+		os.Exit(1)
+	}
+
+	if !validFormat(format) {
+		fmt.Fprintf(os.Stderr, "Unknown format: '%s'\n", format)
+		os.Exit(1)
+	}
+
 	var logLevel lager.LogLevel
 	if dw.debug {
 		logLevel = lager.DEBUG
@@ -112,31 +141,149 @@ func main() {
 	logger.RegisterSink(lager.NewPrettySink(os.Stdout, logLevel))
 
 	var repo task.Repo
+	var contexts task.Contexts
+	var migrateContext runner.ContextMigrator
+	var followEvents runner.EventFollower
+	var webhooks webhook.Repo
+	var login runner.Login
+	var wireManager func(manager.Manager) manager.Manager
 	if address, ok := useApi(); ok {
+		authenticator := wireAuth(logger.Session("wire-auth"))
+		cache := wireCache(logger.Session("wire-cache"))
 		repo = client.New(
 			logger.Session("api-client"),
 			address,
-			wireAuth(logger.Session("wire-auth")),
-			wireCache(logger.Session("wire-cache")),
+			authenticator,
+			cache,
+			client.WithContext(context),
 		)
+		contexts = client.NewContexts(logger.Session("api-client"), address, authenticator, cache)
+		// Only the service delivers events to webhooks, so they are only managed through the API.
+		webhooks = repo.(webhook.Repo)
+		login = func(name string) error {
+			password, err := readPassword(name)
+			if err != nil {
+				return err
+			}
+			return client.Login(logger.Session("api-client"), address, authenticator, cache, name, password)
+		}
+		followEvents = client.NewEventStream(
+			logger.Session("api-client"),
+			address,
+			authenticator,
+			cache,
+			client.WithContext(context),
+		).Follow
+		wireManager = func(m manager.Manager) manager.Manager {
+			return client.NewManager(
+				logger.Session("api-client"),
+				address,
+				authenticator,
+				cache,
+				m,
+				currentUser(),
+				client.WithContext(context),
+			)
+		}
 	} else {
-		repo = fs.New(filepath.Join(root.String(), context))
+		switch repoType {
+		case "fs":
+			contexts = fs.NewContexts(root.String())
+			var err error
+			if repo, err = contexts.Repo(context); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err.Error())
+				os.Exit(1)
+			}
+
+			file := filepath.Join(root.String(), context)
+			migrateContext = func() ([]string, error) { return fs.Migrate(file) }
+		case "eventsource":
+			repo = eventsource.New(filepath.Join(root.String(), context+".events"))
+		case "sqlite":
+			repo = wireSQLiteRepo(logger.Session("wire-sqlite-repo"), filepath.Join(root.String(), context+".db"))
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown repo: '%s'\n", repoType)
+			os.Exit(1)
+		}
 	}
 
 	clock := clock.NewClock()
-	m := manager.New(repo, clock)
+	m := manager.New(
+		repo,
+		clock,
+		manager.WithScheduling(scheduling),
+		manager.WithActor(currentUser()),
+	)
+	if wireManager != nil {
+		// The API performs the operations that it can, so that it creates their events.
+		m = wireManager(m)
+	}
 
-	r := runner.New(&runner.BuildInfo{Hash: buildHash, Date: buildDate}, m, os.Stdout, &dw)
+	r := runner.New(
+		&runner.BuildInfo{Hash: buildHash, Date: buildDate},
+		m,
+		os.Stdout,
+		&dw,
+		runner.WithFormat(runner.Format(format)),
+		runner.WithContextMigrator(migrateContext),
+		runner.WithContexts(contexts),
+		runner.WithEventFollower(followEvents),
+		runner.WithWebhooks(webhooks),
+		runner.WithLogin(login),
+	)
 	if err := r.Run(flags.Args()); err != nil {
 		fmt.Fprintf(os.Stderr, "%s\n", err.Error())
 		os.Exit(1)
 	}
 }
 
+func validFormat(format string) bool {
+	for _, f := range runner.Formats() {
+		if string(f) == format {
+			return true
+		}
+	}
+	return false
+}
+
+// currentUser returns the name of the user running this executable, or "" if it is unknown.
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// readPassword returns the password of a user from the ANWORK_API_PASSWORD env var, or else prompts
+// for it on the terminal.
+func readPassword(name string) (string, error) {
+	if password, ok := os.LookupEnv("ANWORK_API_PASSWORD"); ok {
+		return password, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Password for %s: ", name)
+	password, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("cannot read password: %s", err.Error())
+	}
+	return string(password), nil
+}
+
 func useApi() (string, bool) {
 	return os.LookupEnv("ANWORK_API_ADDRESS")
 }
 
+func wireSQLiteRepo(logger lager.Logger, file string) task.Repo {
+	// Wait for other anwork processes that are using the database, instead of failing right away.
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)", file))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot open database: %s\n", err.Error())
+		os.Exit(1)
+	}
+	return sql.New(logger, db)
+}
+
 func wireAuth(logger lager.Logger) *auth.Client {
 	privateKeyData, ok := os.LookupEnv("ANWORK_API_PRIVATE_KEY")
 	if !ok {
@@ -1,4 +1,20 @@
 // This is the ANWORK service. It runs an HTTP server and serves the ANWORK API.
+//
+// The -repo flag chooses how it stores tasks: in its SQL database ("sql"), in files in /tmp ("fs"),
+// or in memory ("memory"), optionally starting with the tasks in the JSON file passed to the
+// -fixture flag. By default, it uses its SQL database if it has one, and files otherwise.
+//
+// When it is run as "anwork-service migrate [-version N]", it instead migrates the schema of its
+// SQL database to the provided version (the latest version by default), and then exits.
+//
+// The users who can log in to the API are set with the ANWORK_API_USERS env var, which is a
+// comma-separated list of NAME:HASH pairs, where HASH is the hash of the password of the user.
+// Users are only supported by the sql and memory repos. When it is run as
+// "anwork-service hash-password", it instead prints the hash of the password on its stdin, and then
+// exits.
+//
+// Each token that it generates for the API has a session, which is stored with the users (or in
+// memory, for the fs repo), and which is cleaned up every minute once it expires.
 package main
 
 import (
@@ -7,24 +23,56 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
+	"time"
 
 	"code.cloudfoundry.org/clock"
 	"code.cloudfoundry.org/lager"
 	"github.com/ankeesler/anwork/api"
 	"github.com/ankeesler/anwork/api/auth"
+	"github.com/ankeesler/anwork/session"
 	"github.com/ankeesler/anwork/task"
 	"github.com/ankeesler/anwork/task/fs"
+	"github.com/ankeesler/anwork/task/memory"
 	"github.com/ankeesler/anwork/task/sql"
+	"github.com/ankeesler/anwork/user"
 	cfenv "github.com/cloudfoundry-community/go-cfenv"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/grouper"
 	"github.com/tedsuo/ifrit/http_server"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
+	logger := lager.NewLogger("anwork-service")
+	logger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
+	logger.Info("hey")
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(logger.Session("migrate"), os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "hash-password" {
+		hashPassword(logger.Session("hash-password"))
+		return
+	}
+
+	flags := flag.NewFlagSet("anwork-service", flag.ExitOnError)
+	repoType := flags.String(
+		"repo",
+		"",
+		"How tasks are stored (sql, fs, or memory); by default, sql if there is a SQL database, and fs otherwise",
+	)
+	fixture := flags.String("fixture", "", "A JSON file with the tasks with which the memory repo starts")
+	flags.Parse(os.Args[1:])
+
 	var address string
 	if port, ok := os.LookupEnv("PORT"); ok {
 		address = fmt.Sprintf(":%s", port)
@@ -32,46 +80,199 @@ func main() {
 		address = ":12345"
 	}
 
-	logger := lager.NewLogger("anwork-service")
-	logger.RegisterSink(lager.NewPrettySink(os.Stdout, lager.DEBUG))
-	logger.Info("hey")
-
-	repo := wireRepo(logger.Session("wire-repo"))
+	contexts := wireContexts(logger.Session("wire-contexts"), *repoType, *fixture)
+	repo, err := contexts.Repo(task.DefaultContext)
+	if err != nil {
+		logger.Fatal("default-context-failure", err)
+	}
 
 	clock := clock.NewClock()
 	publicKey := getPublicKey(logger.Session("get-public-key"))
 	secret := getSecret(logger.Session("get-secret"))
-	authenticator := auth.NewServer(clock, rand.Reader, publicKey, secret)
+	sessions := wireSessions(logger.Session("wire-sessions"), repo)
+	authenticator := auth.NewServer(clock, rand.Reader, publicKey, secret, sessions)
+
+	options := []api.Option{api.WithContexts(contexts)}
+	if users, ok := wireUsers(logger.Session("wire-users"), repo); ok {
+		options = append(options, api.WithUsers(users))
+	}
 
-	runner := http_server.New(
-		address, api.New(logger.Session("api"), repo, authenticator))
+	runner := grouper.NewParallel(os.Interrupt, grouper.Members{
+		{
+			Name:   "api",
+			Runner: http_server.New(address, api.New(logger.Session("api"), repo, authenticator, options...)),
+		},
+		{
+			Name:   "session-cleaner",
+			Runner: authenticator.Cleaner(logger.Session("session-cleaner"), time.Minute),
+		},
+	})
 	process := ifrit.Invoke(runner)
 	logger.Info("running")
 
 	logger.Fatal("process-exited", <-process.Wait())
 }
 
-func wireRepo(logger lager.Logger) task.Repo {
-	var repo task.Repo
+func migrate(logger lager.Logger, args []string) {
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	version := flags.Int(
+		"version",
+		sql.LatestSchemaVersion(),
+		"The schema version to migrate the database to",
+	)
+	flags.Parse(args)
+
+	dsn, ok := getSQLDSN(logger)
+	if !ok {
+		msg := "no SQL database to migrate; set the ANWORK_API_SQL_DSN env var"
+		logger.Fatal("missing-sql-dsn", errors.New(msg))
+	}
+
+	db := openSQLDB(logger, dsn)
+	defer db.Close(logger)
+
+	from, err := sql.SchemaVersion(logger, db)
+	if err != nil {
+		logger.Fatal("schema-version-failure", err)
+	}
+
+	if err := sql.Migrate(logger, db, *version); err != nil {
+		logger.Fatal("migrate-failure", err)
+	}
+
+	fmt.Printf("Migrated the schema from version %d to version %d\n", from, *version)
+}
+
+func hashPassword(logger lager.Logger) {
+	password, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		logger.Fatal("read-password-failure", err)
+	}
+
+	var u user.User
+	if err := u.SetPassword(strings.TrimRight(string(password), "\r\n")); err != nil {
+		logger.Fatal("hash-password-failure", err)
+	}
+
+	fmt.Println(u.PasswordHash)
+}
+
+// wireUsers stores the users in the ANWORK_API_USERS env var in a task.Repo, if it is a user.Repo.
+func wireUsers(logger lager.Logger, repo task.Repo) (user.Repo, bool) {
+	users, ok := repo.(user.Repo)
+	if !ok {
+		logger.Info("users-not-supported")
+		return nil, false
+	}
+
+	pairs, ok := os.LookupEnv("ANWORK_API_USERS")
+	if !ok || pairs == "" {
+		return users, true
+	}
+
+	for _, pair := range strings.Split(pairs, ",") {
+		fields := strings.SplitN(pair, ":", 2)
+		if len(fields) != 2 {
+			logger.Fatal("invalid-users", fmt.Errorf("invalid user '%s' (use NAME:HASH)", pair))
+		}
+
+		u := &user.User{Name: fields[0], PasswordHash: fields[1]}
+		if err := user.ValidateName(u.Name); err != nil {
+			logger.Fatal("invalid-users", err)
+		}
+
+		// The hash of the password may have changed since the user was stored.
+		existing, err := users.FindUserByName(u.Name)
+		if err != nil {
+			logger.Fatal("find-user-failure", err)
+		} else if existing != nil {
+			if err := users.DeleteUser(existing); err != nil {
+				logger.Fatal("delete-user-failure", err)
+			}
+		}
+
+		if err := users.CreateUser(u); err != nil {
+			logger.Fatal("create-user-failure", err)
+		}
+		logger.Info("stored-user", lager.Data{"name": u.Name})
+	}
+
+	return users, true
+}
+
+// wireSessions returns the session.Repo in which the API stores the session.Session of each token:
+// the task.Repo, if it is a session.Repo, and memory otherwise.
+func wireSessions(logger lager.Logger, repo task.Repo) session.Repo {
+	if sessions, ok := repo.(session.Repo); ok {
+		return sessions
+	}
+
+	logger.Info("storing-sessions-in-memory")
+	return memory.New().(session.Repo)
+}
+
+func wireContexts(logger lager.Logger, repoType, fixture string) task.Contexts {
+	dsn, haveDSN := getSQLDSN(logger)
+	if repoType == "" {
+		if haveDSN {
+			repoType = "sql"
+		} else {
+			repoType = "fs"
+		}
+	}
+
+	if fixture != "" && repoType != "memory" {
+		logger.Fatal("fixture-failure", errors.New("only the memory repo can start with a fixture"))
+	}
+
+	var contexts task.Contexts
+	switch repoType {
+	case "sql":
+		if !haveDSN {
+			msg := "no SQL database; set the ANWORK_API_SQL_DSN env var"
+			logger.Fatal("missing-sql-dsn", errors.New(msg))
+		}
+		contexts = sql.NewContexts(logger.Session("contexts"), openSQLDB(logger, dsn))
+		logger.Info("created-sql-contexts")
+	case "fs":
+		contexts = fs.NewContexts("/tmp")
+		logger.Info("created-fs-contexts")
+	case "memory":
+		var options []memory.Option
+		if fixture != "" {
+			f, err := memory.LoadFixture(fixture)
+			if err != nil {
+				logger.Fatal("fixture-failure", err)
+			}
+			options = append(options, memory.WithFixture(f))
+		}
+		contexts = memory.NewContexts(options...)
+		logger.Info("created-memory-contexts")
+	default:
+		logger.Fatal("unknown-repo", fmt.Errorf("unknown repo: '%s'", repoType))
+	}
+	return contexts
+}
+
+func getSQLDSN(logger lager.Logger) (string, bool) {
 	if dsn, ok := getCFServiceDSN(logger.Session("get-cf-service-dsn")); ok {
-		repo = wireSQLRepo(logger, dsn)
-		logger.Info("created-sql-repo-with-cf-service")
-	} else if dsn, ok := os.LookupEnv("ANWORK_API_SQL_DSN"); ok {
-		repo = wireSQLRepo(logger, dsn)
-		logger.Info("created-sql-repo")
-	} else {
-		repo = fs.New("/tmp/default-context")
-		logger.Info("created-fs-repo")
+		logger.Info("found-cf-service-dsn")
+		return dsn, true
 	}
-	return repo
+	return os.LookupEnv("ANWORK_API_SQL_DSN")
 }
 
-func wireSQLRepo(logger lager.Logger, dsn string) task.Repo {
-	db, err := sql.Open("mysql", dsn)
+func openSQLDB(logger lager.Logger, dsn string) *sql.DB {
+	driverName, ok := os.LookupEnv("ANWORK_API_SQL_DRIVER")
+	if !ok {
+		driverName = "mysql"
+	}
+
+	db, err := sql.Open(driverName, dsn)
 	if err != nil {
 		logger.Fatal("open-db-failure", err)
 	}
-	return sql.New(logger.Session("repo"), db)
+	return db
 }
 
 func getPublicKey(logger lager.Logger) *rsa.PublicKey {
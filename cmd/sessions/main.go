@@ -0,0 +1,140 @@
+// Package sessions contains a utility program for managing the sessions of
+// the ANWORK API, i.e., the tokens that it accepts. It requires that the
+// ANWORK_API_ADDRESS, ANWORK_API_SECRET and ANWORK_API_PRIVATE_KEY
+// environmental variables are set properly, and it uses the same token cache
+// as the anwork CLI.
+//
+// Usage:
+//
+//	sessions list        list the active sessions
+//	sessions revoke ID   revoke the session with the ID
+//	sessions logout      revoke the session of the cached token
+//
+// When the API has users, only the sessions of the user who is logged in are
+// listed and can be revoked.
+package main
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/api/auth"
+	"github.com/ankeesler/anwork/api/client"
+	"github.com/ankeesler/anwork/api/client/cache"
+)
+
+const usage = "usage: sessions list | sessions revoke ID | sessions logout"
+
+func main() {
+	if len(os.Args) < 2 {
+		die(usage)
+	}
+
+	address, ok := os.LookupEnv("ANWORK_API_ADDRESS")
+	if !ok {
+		die("ANWORK_API_ADDRESS must be set")
+	}
+
+	privateKey, err := readPrivateKey()
+	if err != nil {
+		die(fmt.Sprintf("read privatekey: %s", err.Error()))
+	}
+
+	secret, ok := os.LookupEnv("ANWORK_API_SECRET")
+	if !ok {
+		die("ANWORK_API_SECRET must be set")
+	}
+
+	logger := lager.NewLogger("sessions")
+	logger.RegisterSink(lager.NewWriterSink(ioutil.Discard, lager.DEBUG))
+	sessions := client.NewSessions(
+		logger,
+		address,
+		auth.NewClient(clock.NewClock(), privateKey, []byte(secret)),
+		cache.New(getTokenCacheFile()),
+	)
+
+	switch {
+	case os.Args[1] == "list" && len(os.Args) == 2:
+		list(sessions)
+	case os.Args[1] == "revoke" && len(os.Args) == 3:
+		if err := sessions.RevokeSession(os.Args[2]); err != nil {
+			die(fmt.Sprintf("revoke session: %s", err.Error()))
+		}
+		fmt.Println("revoked session", os.Args[2])
+	case os.Args[1] == "logout" && len(os.Args) == 2:
+		if err := sessions.Logout(); err != nil {
+			die(fmt.Sprintf("logout: %s", err.Error()))
+		}
+		fmt.Println("logged out")
+	default:
+		die(usage)
+	}
+}
+
+func die(msg string) {
+	fmt.Println("error:", msg)
+	os.Exit(1)
+}
+
+func list(sessions *client.Sessions) {
+	ss, err := sessions.Sessions()
+	if err != nil {
+		die(fmt.Sprintf("list sessions: %s", err.Error()))
+	}
+
+	for _, s := range ss {
+		subject := s.Subject
+		if subject == "" {
+			subject = "<none>"
+		}
+		fmt.Printf("%s\n", s.ID)
+		fmt.Printf("  sub: %s\n", subject)
+		fmt.Printf("  iat: %d (%s)\n", s.IssuedAt, time.Unix(s.IssuedAt, 0).String())
+		fmt.Printf("  exp: %d (%s)\n", s.Expiry, time.Unix(s.Expiry, 0).String())
+	}
+}
+
+func getTokenCacheFile() string {
+	var dir string
+	if homeDir, ok := os.LookupEnv("HOME"); ok {
+		dir = filepath.Join(homeDir, ".anwork")
+		os.MkdirAll(dir, 0755)
+	} else {
+		dir = "."
+	}
+
+	return filepath.Join(dir, "token-cache")
+}
+
+func readPrivateKey() (*rsa.PrivateKey, error) {
+	privateKeyData, ok := os.LookupEnv("ANWORK_API_PRIVATE_KEY")
+	if !ok {
+		return nil, errors.New("must set ANWORK_API_PRIVATE_KEY")
+	}
+
+	block, _ := pem.Decode([]byte(privateKeyData))
+	if block == nil {
+		return nil, errors.New("failed to decode private key PEM data")
+	}
+	if expected := "RSA PRIVATE KEY"; block.Type != expected {
+		return nil, fmt.Errorf("unexpected PEM type: got %s, expected %s",
+			block.Type, expected)
+	}
+
+	privateKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse private key: %s", err.Error())
+	}
+
+	return privateKey, nil
+}
@@ -0,0 +1,53 @@
+package integration
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Contexts", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+	})
+
+	AfterEach(func() {
+		run(nil, nil, "delete-context", "context-a")
+		run(nil, nil, "delete-context", "context-b")
+	})
+
+	It("creates, copies, lists, and deletes contexts", func() {
+		run(nil, nil, "create-context", "context-a")
+		run(nil, nil, "-c", "context-a", "create", "task-a")
+		run(nil, nil, "copy-context", "context-a", "context-b")
+
+		run(outBuf, errBuf, "list-contexts")
+		Expect(outBuf).To(gbytes.Say("context-a\n"))
+		Expect(outBuf).To(gbytes.Say("context-b\n"))
+
+		run(outBuf, errBuf, "-c", "context-b", "show")
+		Expect(outBuf).To(gbytes.Say("READY tasks:\n  task-a \\(\\d+\\)\n"))
+
+		run(outBuf, errBuf, "show")
+		Expect(outBuf).NotTo(gbytes.Say("task-a"))
+	})
+
+	It("fails to create a context that already exists", func() {
+		run(nil, nil, "create-context", "context-a")
+		runWithStatus(1, outBuf, errBuf, "create-context", "context-a")
+		Expect(errBuf).To(gbytes.Say("cannot create context: context 'context-a' already exists"))
+		run(nil, nil, "create-context", "context-b")
+	})
+
+	It("fails to delete a context that does not exist", func() {
+		run(nil, nil, "create-context", "context-a")
+		run(nil, nil, "create-context", "context-b")
+		runWithStatus(1, outBuf, errBuf, "delete-context", "context-c")
+		Expect(errBuf).To(gbytes.Say("cannot delete context: unknown context 'context-c'"))
+	})
+})
@@ -0,0 +1,81 @@
+package integration
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Dependencies", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+
+		run(nil, nil, "create", "task-a")
+		run(nil, nil, "create", "task-b")
+		run(nil, nil, "create", "task-c")
+	})
+
+	AfterEach(func() {
+		run(nil, nil, "reset")
+	})
+
+	Context("when a task depends on other tasks", func() {
+		BeforeEach(func() {
+			run(nil, nil, "add-dependency", "task-a", "task-b")
+			run(nil, nil, "add-dependency", "task-a", "task-c")
+		})
+		It("shows the task as blocked", func() {
+			run(outBuf, errBuf, "show")
+			Expect(outBuf).To(gbytes.Say("BLOCKED tasks:\n  task-a"))
+		})
+		It("shows the dependencies in the task details", func() {
+			run(outBuf, errBuf, "show", "task-a")
+			Expect(outBuf).To(gbytes.Say("State: BLOCKED\nDepends on: task-b \\(\\d+\\), task-c \\(\\d+\\)"))
+		})
+		It("records the events in the task's journal", func() {
+			run(outBuf, errBuf, "journal", "task-a")
+			Expect(outBuf).To(gbytes.Say("\\[.*\\]: Added dependency of task 'task-a' on task 'task-c'"))
+			Expect(outBuf).To(gbytes.Say("\\[.*\\]: Set state on task 'task-a' from Ready to Blocked"))
+			Expect(outBuf).To(gbytes.Say("\\[.*\\]: Added dependency of task 'task-a' on task 'task-b'"))
+		})
+		It("fails to add a dependency that would create a cycle", func() {
+			runWithStatus(1, outBuf, errBuf, "add-dependency", "task-c", "task-a")
+			Expect(errBuf).To(gbytes.Say("cannot add dependency: task 'task-c' cannot depend on task 'task-a' since it would create a cycle"))
+		})
+
+		Context("when the dependencies are finished", func() {
+			BeforeEach(func() {
+				run(nil, nil, "set-finished", "task-b")
+				run(nil, nil, "set-finished", "task-c")
+			})
+			It("shows the task as ready", func() {
+				run(outBuf, errBuf, "show")
+				Expect(outBuf).To(gbytes.Say("READY tasks:\n  task-a"))
+			})
+			It("records the event in the task's journal", func() {
+				run(outBuf, errBuf, "journal", "task-a")
+				Expect(outBuf).To(gbytes.Say("\\[.*\\]: Set state on task 'task-a' from Blocked to Ready"))
+			})
+		})
+
+		Context("when the dependencies are removed", func() {
+			BeforeEach(func() {
+				run(nil, nil, "remove-dependency", "task-a", "task-b")
+				run(nil, nil, "remove-dependency", "task-a", "task-c")
+			})
+			It("shows the task as ready", func() {
+				run(outBuf, errBuf, "show")
+				Expect(outBuf).To(gbytes.Say("READY tasks:\n  task-a"))
+			})
+			It("no longer shows the dependencies in the task details", func() {
+				run(outBuf, errBuf, "show", "task-a")
+				Expect(outBuf).NotTo(gbytes.Say("Depends on:"))
+			})
+		})
+	})
+})
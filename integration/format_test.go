@@ -0,0 +1,52 @@
+package integration
+
+import (
+	"encoding/json"
+
+	"github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Format", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+
+		run(nil, nil, "create", "task-a")
+		run(nil, nil, "create", "task-b")
+		run(nil, nil, "tag", "task-b", "infra")
+	})
+
+	AfterEach(func() {
+		run(nil, nil, "reset")
+	})
+
+	It("writes the tasks as json", func() {
+		run(outBuf, errBuf, "-format", "json", "show")
+
+		var tasks []*task.Task
+		Expect(json.Unmarshal(outBuf.Contents(), &tasks)).To(Succeed())
+		Expect(tasks).To(HaveLen(2))
+		Expect(tasks[0].Name).To(Equal("task-a"))
+		Expect(tasks[1].Name).To(Equal("task-b"))
+		Expect(tasks[1].Tags).To(Equal([]string{"infra"}))
+	})
+
+	It("writes the journal as csv", func() {
+		run(outBuf, errBuf, "-format", "csv", "journal", "task-b")
+		Expect(outBuf).To(gbytes.Say("id,date,type,taskId,title,oldValue,newValue,note,actor\n"))
+		Expect(outBuf).To(gbytes.Say("\\d+,\\d+,\\d+,\\d+,Added tag 'infra' to task 'task-b',,infra,,"))
+		Expect(outBuf).To(gbytes.Say("\\d+,\\d+,\\d+,\\d+,Created task 'task-b',,task-b,,"))
+	})
+
+	It("fails when the format is unknown", func() {
+		runWithStatus(1, outBuf, errBuf, "-format", "xml", "show")
+		Expect(errBuf).To(gbytes.Say("Unknown format: 'xml'"))
+	})
+})
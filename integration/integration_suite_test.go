@@ -128,11 +128,18 @@ func TestIntegration(t *testing.T) {
 			privateKey, publicKey, secret := generateAPICreds()
 
 			if _, ok := os.LookupEnv("ANWORK_API_ADDRESS"); !ok {
-				cmd := exec.Command(apiBin)
+				// Without a SQL database, the API keeps the tasks in memory.
+				dsn, useSQL := os.LookupEnv("ANWORK_TEST_SQL_DSN")
+				cmd := exec.Command(apiBin, "-repo", "memory")
+				if useSQL {
+					cmd = exec.Command(apiBin, "-repo", "sql")
+				}
 				cmd.Env = []string{"PORT=12346"}
 				cmd.Env = append(cmd.Env, fmt.Sprintf("ANWORK_API_PUBLIC_KEY=%s", publicKey))
 				cmd.Env = append(cmd.Env, fmt.Sprintf("ANWORK_API_SECRET=%s", secret))
-				cmd.Env = append(cmd.Env, fmt.Sprintf("ANWORK_API_SQL_DSN=%s", getSQLDSN()))
+				if useSQL {
+					cmd.Env = append(cmd.Env, fmt.Sprintf("ANWORK_API_SQL_DSN=%s", dsn))
+				}
 
 				Expect(os.Setenv("ANWORK_API_ADDRESS", "127.0.0.1:12346")).To(Succeed())
 				Expect(os.Setenv("ANWORK_API_PRIVATE_KEY", privateKey)).To(Succeed())
@@ -204,11 +211,3 @@ func generateAPICreds() (privateKey, publicKey, secret string) {
 
 	return
 }
-
-func getSQLDSN() string {
-	dsn, ok := os.LookupEnv("ANWORK_TEST_SQL_DSN")
-	if !ok {
-		panic("must set 'ANWORK_TEST_SQL_DSN'")
-	}
-	return dsn
-}
@@ -256,6 +256,35 @@ var _ = Describe("anwork", func() {
 				Expect(outBuf).To(gbytes.Say("\\[.*\\]: Set priority on task 'task-a' from 10 to 15"))
 			})
 		})
+		Context("when setting deadlines on tasks", func() {
+			BeforeEach(func() {
+				run(nil, nil, "set-deadline", "task-a", "2099-12-25")
+				run(nil, nil, "set-deadline", "task-c", "2000-01-01 13:30")
+			})
+			It("properly shows the tasks in order of priority", func() {
+				run(outBuf, errBuf, "show")
+				Expect(outBuf).To(gbytes.Say("READY tasks:\n  task-a.*\n  task-b.*\n  task-c"))
+			})
+			It("properly shows the tasks in order of deadline when asked to", func() {
+				run(outBuf, errBuf, "-schedule", "deadline", "show")
+				Expect(outBuf).To(gbytes.Say("READY tasks:\n  task-c.*\n  task-a.*\n  task-b"))
+			})
+			It("properly records the deadlines", func() {
+				run(outBuf, errBuf, "show", "task-a")
+				Expect(outBuf).To(gbytes.Say("Name: task-a\nID: \\d+\nCreated: .*\nPriority: 10\nState: READY\nDeadline: .* \\(.* remaining\\)"))
+				run(outBuf, errBuf, "show", "task-c")
+				Expect(outBuf).To(gbytes.Say("Name: task-c\nID: \\d+\nCreated: .*\nPriority: 10\nState: READY\nDeadline: .* \\(overdue by .*\\)"))
+			})
+			It("records the events in the global journal", func() {
+				run(outBuf, errBuf, "journal")
+				Expect(outBuf).To(gbytes.Say("\\[.*\\]: Set deadline on task 'task-c' from none to 2000-01-01 13:30"))
+				Expect(outBuf).To(gbytes.Say("\\[.*\\]: Set deadline on task 'task-a' from none to 2099-12-25 23:59"))
+			})
+			It("fails when the schedule is unknown", func() {
+				runWithStatus(1, outBuf, errBuf, "-schedule", "tuna", "show")
+				Expect(errBuf).To(gbytes.Say("Unknown schedule: 'tuna'"))
+			})
+		})
 		Context("when adding a note to tasks", func() {
 			BeforeEach(func() {
 				run(nil, nil, "note", "task-a", "Here is a note")
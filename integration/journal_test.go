@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Journal", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+
+		run(nil, nil, "create", "task-a")
+	})
+
+	AfterEach(func() {
+		run(nil, nil, "reset")
+	})
+
+	Context("when following the journal", func() {
+		It("prints the events as they are created", func() {
+			if !runWithApi {
+				Skip("only the API can be followed")
+			}
+
+			s, err := gexec.Start(exec.Command(anworkBin, "-o", outputDir, "journal", "--follow"), outBuf, errBuf)
+			Expect(err).NotTo(HaveOccurred())
+			defer s.Kill()
+
+			Eventually(outBuf).Should(gbytes.Say("\\[.*\\]: Created task 'task-a'\n"))
+
+			run(nil, nil, "create", "task-b")
+			run(nil, nil, "set-running", "task-b")
+			Eventually(outBuf).Should(gbytes.Say("\\[.*\\]: Created task 'task-b'\n"))
+			Eventually(outBuf).Should(gbytes.Say("\\[.*\\]: Set state on task 'task-b' from Ready to Running\n"))
+			Consistently(s).ShouldNot(gexec.Exit())
+		})
+
+		It("fails without the API", func() {
+			if runWithApi {
+				Skip("the API can be followed")
+			}
+
+			runWithStatus(1, outBuf, errBuf, "journal", "--follow")
+			Expect(errBuf).To(gbytes.Say("cannot follow the journal: only the API can be followed"))
+		})
+	})
+})
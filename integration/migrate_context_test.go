@@ -0,0 +1,53 @@
+package integration
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Migrate Context", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		if runWithApi {
+			Skip("when connecting to API, we ignore the context flag")
+		}
+
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+
+		data, err := ioutil.ReadFile(filepath.Join("data", "v8-context"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.WriteFile(filepath.Join(outputDir, "migrated-context"), data, 0600)).To(Succeed())
+	})
+
+	It("upgrades an old context, backs it up, and reports what changed", func() {
+		run(outBuf, errBuf, "-c", "migrated-context", "migrate-context")
+		Expect(outBuf).To(gbytes.Say("Upgraded from format version 1 to 2\n"))
+		Expect(outBuf).To(gbytes.Say("Added structured fields to 7 events\n"))
+		Expect(outBuf).To(gbytes.Say("Backed up the original file to '.*migrated-context.v1.backup'\n"))
+
+		data, err := ioutil.ReadFile(filepath.Join("data", "v8-context"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ioutil.ReadFile(filepath.Join(outputDir, "migrated-context.v1.backup"))).To(Equal(data))
+
+		run(outBuf, errBuf, "-c", "migrated-context", "migrate-context")
+		Expect(outBuf).To(gbytes.Say("The context already has the latest format\n"))
+
+		run(outBuf, errBuf, "-c", "migrated-context", "show")
+		Expect(outBuf).To(gbytes.Say("RUNNING tasks:\n  task-c \\(2\\)\n"))
+	})
+
+	Context("when the repo is not fs", func() {
+		It("fails", func() {
+			runWithStatus(1, outBuf, errBuf, "-repo", "eventsource", "migrate-context")
+			Expect(errBuf).To(gbytes.Say("cannot migrate context: only contexts in the fs repo can be migrated"))
+		})
+	})
+})
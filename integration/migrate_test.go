@@ -0,0 +1,60 @@
+package integration
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+var _ = Describe("Service Migrate", func() {
+	var (
+		serviceBin     string
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		if runWithApi {
+			Skip("when connecting to API, we ignore the repo flag")
+		}
+
+		var err error
+		serviceBin, err = gexec.Build("github.com/ankeesler/anwork/cmd/service")
+		Expect(err).NotTo(HaveOccurred())
+
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+	})
+
+	AfterEach(func() {
+		run(nil, nil, "-repo", "sqlite", "-c", "service", "reset")
+	})
+
+	migrate := func(args ...string) *gbytes.Buffer {
+		cmd := exec.Command(serviceBin, append([]string{"migrate"}, args...)...)
+		cmd.Env = []string{
+			"ANWORK_API_SQL_DRIVER=sqlite",
+			fmt.Sprintf("ANWORK_API_SQL_DSN=%s", filepath.Join(outputDir, "service.db")),
+		}
+
+		out := gbytes.NewBuffer()
+		s, err := gexec.Start(cmd, out, GinkgoWriter)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(s, "5s").Should(gexec.Exit(0))
+		return out
+	}
+
+	It("migrates the SQL database without losing tasks", func() {
+		run(nil, nil, "-repo", "sqlite", "-c", "service", "create", "task-a")
+
+		Expect(migrate("-version", "1")).To(gbytes.Say("Migrated the schema from version 10 to version 1\n"))
+		Expect(migrate()).To(gbytes.Say("Migrated the schema from version 1 to version 10\n"))
+
+		run(outBuf, errBuf, "-repo", "sqlite", "-c", "service", "show")
+		Expect(outBuf).To(gbytes.Say("READY tasks:\n  task-a \\(\\d+\\)\n"))
+	})
+})
@@ -0,0 +1,45 @@
+package integration
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Queries", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+
+		run(nil, nil, "create", "deploy-service")
+		run(nil, nil, "create", "deploy-website")
+		run(nil, nil, "create", "write-docs")
+		run(nil, nil, "set-priority", "deploy-service", "3")
+		run(nil, nil, "set-running", "deploy-website")
+		run(nil, nil, "tag", "deploy-service", "infra")
+		run(nil, nil, "tag", "deploy-website", "infra")
+	})
+
+	AfterEach(func() {
+		run(nil, nil, "reset")
+	})
+
+	It("only shows the tasks that match the query", func() {
+		run(outBuf, errBuf, "show", "--where", "tag:infra state:ready")
+		Expect(outBuf).To(gbytes.Say("RUNNING tasks:\nBLOCKED tasks:\nREADY tasks:\n  deploy-service \\(\\d+\\)\nFINISHED tasks:\n$"))
+	})
+
+	It("supports comparisons and substrings", func() {
+		run(outBuf, errBuf, "show", "--where", "name~deploy priority<10")
+		Expect(outBuf).To(gbytes.Say("RUNNING tasks:\nBLOCKED tasks:\nREADY tasks:\n  deploy-service \\(\\d+\\)\nFINISHED tasks:\n$"))
+	})
+
+	It("fails with an invalid query", func() {
+		runWithStatus(1, outBuf, errBuf, "show", "--where", "color:red")
+		Expect(errBuf).To(gbytes.Say("invalid query 'color:red': unknown field 'color'"))
+	})
+})
@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"io/ioutil"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Rebuild", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+	})
+
+	AfterEach(func() {
+		run(nil, nil, "reset")
+	})
+
+	It("finds no problems after the tasks are changed", func() {
+		run(nil, nil, "create", "task-a")
+		run(nil, nil, "create", "task-b")
+		run(nil, nil, "add-dependency", "task-a", "task-b")
+		run(nil, nil, "set-finished", "task-b")
+		run(nil, nil, "tag", "task-a", "infra")
+		run(nil, nil, "rename", "task-a", "task-c")
+		run(nil, nil, "delete", "task-b")
+		run(nil, nil, "undo")
+
+		run(outBuf, errBuf, "rebuild")
+		Expect(outBuf).To(gbytes.Say("The tasks match the journal\n"))
+	})
+
+	Context("when the context was written by an older version", func() {
+		BeforeEach(func() {
+			if runWithApi {
+				Skip("when connecting to API, we ignore the context flag")
+			}
+
+			data, err := ioutil.ReadFile(filepath.Join("data", "v9-context"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(filepath.Join(outputDir, "v9-context"), data, 0600)).To(Succeed())
+		})
+
+		It("finds no problems", func() {
+			run(outBuf, errBuf, "-c", "v9-context", "rebuild")
+			Expect(outBuf).To(gbytes.Say("The tasks match the journal\n"))
+		})
+	})
+
+	Context("when the tasks do not match the journal", func() {
+		BeforeEach(func() {
+			if runWithApi {
+				Skip("when connecting to API, we ignore the context flag")
+			}
+
+			data := `{"tasks":[{"name":"task-a","id":0,"startDate":1548087198,"priority":10,"state":"Finished"}],"NextTaskID":1,"events":[{"ID":0,"title":"Created task 'task-a'","date":1548087198,"type":0,"taskid":0,"newValue":"task-a"},{"ID":1,"title":"Note added to task 'task-b': hey","date":1548087198,"type":3,"taskid":1,"note":"hey"}],"NextEventID":2}`
+			Expect(ioutil.WriteFile(filepath.Join(outputDir, "drifted-context"), []byte(data), 0600)).To(Succeed())
+		})
+
+		It("prints the problems and fails", func() {
+			runWithStatus(1, outBuf, errBuf, "-c", "drifted-context", "rebuild")
+			Expect(outBuf).To(gbytes.Say("event 1 \\('Note added to task 'task-b': hey'\\): unknown task with ID 1\n"))
+			Expect(outBuf).To(gbytes.Say("task 'task-a' \\(ID 0\\) has state Finished, but the journal says Ready\n"))
+			Expect(errBuf).To(gbytes.Say("found 2 problems"))
+		})
+	})
+
+	Context("when the tasks are persisted with the eventsource repo", func() {
+		BeforeEach(func() {
+			if runWithApi {
+				Skip("when connecting to API, we ignore the repo flag")
+			}
+		})
+
+		AfterEach(func() {
+			run(nil, nil, "-repo", "eventsource", "reset")
+		})
+
+		It("rebuilds the tasks from the journal", func() {
+			run(nil, nil, "-repo", "eventsource", "create", "task-a")
+			run(nil, nil, "-repo", "eventsource", "create", "task-b")
+			run(nil, nil, "-repo", "eventsource", "set-running", "task-a")
+			run(nil, nil, "-repo", "eventsource", "delete", "task-b")
+
+			run(outBuf, errBuf, "-repo", "eventsource", "show")
+			Expect(outBuf).To(gbytes.Say("RUNNING tasks:\n  task-a \\(\\d+\\)\n"))
+			Expect(outBuf).To(gbytes.Say("READY tasks:\n"))
+
+			run(outBuf, errBuf, "-repo", "eventsource", "rebuild")
+			Expect(outBuf).To(gbytes.Say("The tasks match the journal\n"))
+
+			Expect(filepath.Join(outputDir, "default-context.events")).To(BeAnExistingFile())
+		})
+	})
+
+	Context("when the repo is unknown", func() {
+		It("fails", func() {
+			if runWithApi {
+				Skip("when connecting to API, we ignore the repo flag")
+			}
+
+			runWithStatus(1, outBuf, errBuf, "-repo", "tuna", "show")
+			Expect(errBuf).To(gbytes.Say("Unknown repo: 'tuna'"))
+		})
+	})
+})
@@ -0,0 +1,46 @@
+package integration
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("SQLite", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+	})
+
+	Context("when the repo is sqlite", func() {
+		BeforeEach(func() {
+			if runWithApi {
+				Skip("when connecting to API, we ignore the repo flag")
+			}
+		})
+
+		AfterEach(func() {
+			run(nil, nil, "-repo", "sqlite", "reset")
+		})
+
+		It("stores the tasks in a database next to the other contexts", func() {
+			run(nil, nil, "-repo", "sqlite", "create", "task-a")
+			run(nil, nil, "-repo", "sqlite", "tag", "task-a", "infra")
+			run(nil, nil, "-repo", "sqlite", "set-running", "task-a")
+
+			run(outBuf, errBuf, "-repo", "sqlite", "show", "--where", "tag:infra")
+			Expect(outBuf).To(gbytes.Say("RUNNING tasks:\n  task-a \\(\\d+\\)\n"))
+
+			run(outBuf, errBuf, "-repo", "sqlite", "rebuild")
+			Expect(outBuf).To(gbytes.Say("The tasks match the journal\n"))
+
+			Expect(filepath.Join(outputDir, "default-context.db")).To(BeAnExistingFile())
+		})
+	})
+})
@@ -0,0 +1,65 @@
+package integration
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Tags", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+
+		run(nil, nil, "create", "task-a")
+		run(nil, nil, "create", "task-b")
+		run(nil, nil, "create", "task-c")
+	})
+
+	AfterEach(func() {
+		run(nil, nil, "reset")
+	})
+
+	Context("when tasks are tagged", func() {
+		BeforeEach(func() {
+			run(nil, nil, "tag", "task-a", "infra")
+			run(nil, nil, "tag", "task-c", "infra")
+			run(nil, nil, "tag", "task-c", "web")
+		})
+		It("only shows the tasks with a tag when filtering by that tag", func() {
+			run(outBuf, errBuf, "show", "--tag", "infra")
+			Expect(outBuf).To(gbytes.Say("READY tasks:\n  task-a \\(\\d+\\)\n  task-c \\(\\d+\\)\nFINISHED tasks:\n$"))
+		})
+		It("shows the tags in the task details", func() {
+			run(outBuf, errBuf, "show", "task-c")
+			Expect(outBuf).To(gbytes.Say("Tags: infra, web"))
+		})
+		It("records the events in the task's journal", func() {
+			run(outBuf, errBuf, "journal", "task-c")
+			Expect(outBuf).To(gbytes.Say("\\[.*\\]: Added tag 'web' to task 'task-c'"))
+			Expect(outBuf).To(gbytes.Say("\\[.*\\]: Added tag 'infra' to task 'task-c'"))
+		})
+		It("fails to add a tag that the task already has", func() {
+			runWithStatus(1, outBuf, errBuf, "tag", "task-a", "infra")
+			Expect(errBuf).To(gbytes.Say("cannot add tag: task 'task-a' already has tag 'infra'"))
+		})
+
+		Context("when a tag is removed", func() {
+			BeforeEach(func() {
+				run(nil, nil, "untag", "task-c", "infra")
+			})
+			It("no longer shows the task when filtering by that tag", func() {
+				run(outBuf, errBuf, "show", "--tag=infra")
+				Expect(outBuf).To(gbytes.Say("READY tasks:\n  task-a \\(\\d+\\)\nFINISHED tasks:\n$"))
+			})
+			It("records the event in the task's journal", func() {
+				run(outBuf, errBuf, "journal", "task-c")
+				Expect(outBuf).To(gbytes.Say("\\[.*\\]: Removed tag 'infra' from task 'task-c'"))
+			})
+		})
+	})
+})
@@ -0,0 +1,77 @@
+package integration
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Undo", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+
+		run(nil, nil, "create", "task-a")
+		run(nil, nil, "create", "task-b")
+		run(nil, nil, "set-running", "task-a")
+	})
+
+	AfterEach(func() {
+		run(nil, nil, "reset")
+	})
+
+	It("undoes and redoes a mistaken set-finished", func() {
+		run(nil, nil, "set-finished", "task-a")
+		run(nil, nil, "undo")
+
+		run(outBuf, errBuf, "show")
+		Expect(outBuf).To(gbytes.Say("RUNNING tasks:\n  task-a \\(\\d+\\)\n"))
+
+		run(nil, nil, "redo")
+		run(outBuf, errBuf, "show")
+		Expect(outBuf).To(gbytes.Say("FINISHED tasks:\n  task-a \\(\\d+\\)\n"))
+	})
+
+	It("restores a deleted task", func() {
+		run(nil, nil, "tag", "task-b", "infra")
+		run(nil, nil, "delete", "task-b")
+		run(nil, nil, "undo")
+
+		run(outBuf, errBuf, "show", "task-b")
+		Expect(outBuf).To(gbytes.Say("Name: task-b"))
+		Expect(outBuf).To(gbytes.Say("Tags: infra"))
+	})
+
+	It("undoes more than one operation", func() {
+		run(nil, nil, "undo", "2")
+
+		run(outBuf, errBuf, "show")
+		Expect(outBuf).To(gbytes.Say("READY tasks:\n  task-a \\(\\d+\\)\nFINISHED tasks:\n$"))
+	})
+
+	It("records the undo in the journal", func() {
+		run(nil, nil, "undo")
+
+		run(outBuf, errBuf, "journal", "task-a")
+		Expect(outBuf).To(gbytes.Say("\\[.*\\]: Set state on task 'task-a' from Running to Ready"))
+		Expect(outBuf).To(gbytes.Say("\\[.*\\]: Set state on task 'task-a' from Ready to Running"))
+	})
+
+	It("restores everything after a reset", func() {
+		run(nil, nil, "reset")
+		run(nil, nil, "undo")
+
+		run(outBuf, errBuf, "show")
+		Expect(outBuf).To(gbytes.Say("RUNNING tasks:\n  task-a \\(\\d+\\)\n"))
+		Expect(outBuf).To(gbytes.Say("READY tasks:\n  task-b \\(\\d+\\)\n"))
+	})
+
+	It("fails when there is nothing to redo", func() {
+		runWithStatus(1, outBuf, errBuf, "redo")
+		Expect(errBuf).To(gbytes.Say("cannot redo: nothing to redo"))
+	})
+})
@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/ankeesler/anwork/webhook"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Webhook", func() {
+	var (
+		outBuf, errBuf *gbytes.Buffer
+	)
+
+	BeforeEach(func() {
+		outBuf = gbytes.NewBuffer()
+		errBuf = gbytes.NewBuffer()
+	})
+
+	It("delivers the events that a webhook matches", func() {
+		if !runWithApi {
+			Skip("only the API has webhooks")
+		}
+
+		deliveries := make(chan *webhook.Delivery, 10)
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+
+			body, err := ioutil.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(webhook.Verify("some-secret", body, r.Header.Get(webhook.SignatureHeader))).To(BeTrue())
+
+			var delivery webhook.Delivery
+			Expect(json.Unmarshal(body, &delivery)).To(Succeed())
+			deliveries <- &delivery
+		}))
+		defer receiver.Close()
+
+		run(outBuf, nil, "webhook", "add", receiver.URL, "--states", "blocked,finished", "--secret", "some-secret")
+		Eventually(outBuf).Should(gbytes.Say("1: " + receiver.URL + " \\(states: Blocked,Finished\\)\n"))
+		defer run(nil, nil, "webhook", "remove", "1")
+
+		run(outBuf, nil, "webhook", "list")
+		Eventually(outBuf).Should(gbytes.Say("1: " + receiver.URL + " \\(states: Blocked,Finished\\)\n"))
+
+		run(nil, nil, "create", "task-a")
+		defer run(nil, nil, "reset")
+		run(nil, nil, "set-running", "task-a")
+		run(nil, nil, "set-blocked", "task-a")
+
+		var delivery *webhook.Delivery
+		Eventually(deliveries).Should(Receive(&delivery))
+		Expect(delivery.WebhookID).To(Equal(1))
+		Expect(delivery.Event.Title).To(Equal("Set state on task 'task-a' from Running to Blocked"))
+	})
+
+	It("fails without the API", func() {
+		if runWithApi {
+			Skip("the API has webhooks")
+		}
+
+		runWithStatus(1, outBuf, errBuf, "webhook", "list")
+		Expect(errBuf).To(gbytes.Say("cannot list webhooks: only the API has webhooks"))
+	})
+})
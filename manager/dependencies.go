@@ -0,0 +1,330 @@
+package manager
+
+import (
+	"encoding/json"
+	"fmt"
+
+	taskpkg "github.com/ankeesler/anwork/task"
+)
+
+func (m *manager) AddDependency(name, dependsOn string) error {
+	return m.transact(func(m *manager) error {
+		return m.doWithTask(name, func(task *taskpkg.Task) error {
+			return m.doWithTask(dependsOn, func(dependsOnTask *taskpkg.Task) error {
+				if err := m.addDependency(task, dependsOnTask); err != nil {
+					return err
+				}
+
+				if dependsOnTask.State != taskpkg.StateFinished {
+					return m.updateDependencyState(task)
+				}
+				return nil
+			})
+		})
+	})
+}
+
+func (m *manager) RemoveDependency(name, dependsOn string) error {
+	return m.transact(func(m *manager) error {
+		return m.doWithTask(name, func(task *taskpkg.Task) error {
+			return m.doWithTask(dependsOn, func(dependsOnTask *taskpkg.Task) error {
+				if err := m.removeDependency(task, dependsOnTask); err != nil {
+					return err
+				}
+
+				if dependsOnTask.State != taskpkg.StateFinished {
+					return m.updateDependencyState(task)
+				}
+				return nil
+			})
+		})
+	})
+}
+
+// addDependency makes a task depend on another task and records the change with an Event. It does
+// not change the state of the task; see updateDependencyState.
+func (m *manager) addDependency(task, dependsOnTask *taskpkg.Task) error {
+	dependencies, err := m.repo.Dependencies()
+	if err != nil {
+		return err
+	}
+
+	if findDependency(dependencies, task.ID, dependsOnTask.ID) != nil {
+		return fmt.Errorf("task '%s' already depends on task '%s'", task.Name, dependsOnTask.Name)
+	}
+
+	if task.ID == dependsOnTask.ID ||
+		dependsOnTransitively(dependencies, dependsOnTask.ID, task.ID) {
+		return CyclicDependencyError{Name: task.Name, DependsOn: dependsOnTask.Name}
+	}
+
+	dependency := taskpkg.Dependency{TaskID: task.ID, DependsOnID: dependsOnTask.ID}
+	if err := m.repo.CreateDependency(&dependency); err != nil {
+		return err
+	}
+
+	snapshot, err := json.Marshal(&dependency)
+	if err != nil {
+		return err
+	}
+
+	return m.createEvent(&taskpkg.Event{
+		Title: fmt.Sprintf("Added dependency of task '%s' on task '%s'",
+			task.Name, dependsOnTask.Name),
+		Type:     taskpkg.EventTypeAddDependency,
+		TaskID:   task.ID,
+		NewValue: dependsOnTask.Name,
+		Snapshot: string(snapshot),
+	})
+}
+
+// removeDependency removes the dependency of a task on another task and records the change with an
+// Event. It does not change the state of the task; see updateDependencyState.
+func (m *manager) removeDependency(task, dependsOnTask *taskpkg.Task) error {
+	dependencies, err := m.repo.Dependencies()
+	if err != nil {
+		return err
+	}
+
+	dependency := findDependency(dependencies, task.ID, dependsOnTask.ID)
+	if dependency == nil {
+		return fmt.Errorf("task '%s' does not depend on task '%s'", task.Name, dependsOnTask.Name)
+	}
+
+	snapshot, err := json.Marshal(dependency)
+	if err != nil {
+		return err
+	}
+
+	if err := m.repo.DeleteDependency(dependency); err != nil {
+		return err
+	}
+
+	return m.createEvent(&taskpkg.Event{
+		Title: fmt.Sprintf("Removed dependency of task '%s' on task '%s'",
+			task.Name, dependsOnTask.Name),
+		Type:     taskpkg.EventTypeRemoveDependency,
+		TaskID:   task.ID,
+		OldValue: dependsOnTask.Name,
+		Snapshot: string(snapshot),
+	})
+}
+
+func (m *manager) Dependencies(name string) ([]*taskpkg.Task, error) {
+	var tasks []*taskpkg.Task
+	err := m.doWithTask(name, func(task *taskpkg.Task) error {
+		var err error
+		tasks, err = m.findDependencies(task)
+		return err
+	})
+	return tasks, err
+}
+
+// setState sets the state of a task and records the change with an Event.
+func (m *manager) setState(task *taskpkg.Task, state taskpkg.State) error {
+	oldState := task.State
+	task.State = state
+	if err := m.repo.UpdateTask(task); err != nil {
+		return err
+	}
+
+	return m.createEvent(&taskpkg.Event{
+		Title:    fmt.Sprintf("Set state on task '%s' from %s to %s", task.Name, oldState, state),
+		Type:     taskpkg.EventTypeSetState,
+		TaskID:   task.ID,
+		OldValue: string(oldState),
+		NewValue: string(state),
+	})
+}
+
+// updateDependencyState moves a ready or running task to the blocked state if any of its
+// dependencies are unfinished, or a blocked task to the ready state if all of its dependencies
+// are finished. It should be called whenever one of the dependencies of a task starts or stops
+// being unfinished.
+func (m *manager) updateDependencyState(task *taskpkg.Task) error {
+	dependencies, err := m.findDependencies(task)
+	if err != nil {
+		return err
+	}
+
+	unfinished := false
+	for _, dependency := range dependencies {
+		if dependency.State != taskpkg.StateFinished {
+			unfinished = true
+			break
+		}
+	}
+
+	switch {
+	case unfinished && (task.State == taskpkg.StateReady || task.State == taskpkg.StateRunning):
+		return m.setState(task, taskpkg.StateBlocked)
+	case !unfinished && task.State == taskpkg.StateBlocked:
+		return m.setState(task, taskpkg.StateReady)
+	default:
+		return nil
+	}
+}
+
+// updateDependents calls updateDependencyState on every task that depends on a task.
+func (m *manager) updateDependents(task *taskpkg.Task) error {
+	dependents, err := m.findDependents(task)
+	if err != nil {
+		return err
+	}
+
+	for _, dependent := range dependents {
+		if err := m.updateDependencyState(dependent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteDependencies deletes every dependency to or from a task that is about to be deleted, and
+// records each one with an event so that it can be restored. If the task is not finished, the tasks
+// that depended on it may no longer be blocked.
+func (m *manager) deleteDependencies(task *taskpkg.Task) error {
+	dependents, err := m.findDependents(task)
+	if err != nil {
+		return err
+	}
+
+	dependencies, err := m.repo.Dependencies()
+	if err != nil {
+		return err
+	}
+
+	// The task.Repo may remove deleted dependencies from the slice that it returned, so iterate over
+	// a copy of it.
+	dependencies = append([]*taskpkg.Dependency{}, dependencies...)
+	for _, dependency := range dependencies {
+		if dependency.TaskID != task.ID && dependency.DependsOnID != task.ID {
+			continue
+		}
+
+		dependencyTask, dependsOnTask := task, task
+		if dependency.TaskID != task.ID {
+			dependencyTask, err = m.repo.FindTaskByID(dependency.TaskID)
+		} else {
+			dependsOnTask, err = m.repo.FindTaskByID(dependency.DependsOnID)
+		}
+		if err != nil {
+			return err
+		}
+
+		snapshot, err := json.Marshal(dependency)
+		if err != nil {
+			return err
+		}
+
+		if err := m.repo.DeleteDependency(dependency); err != nil {
+			return err
+		}
+
+		if dependencyTask == nil || dependsOnTask == nil {
+			continue
+		}
+
+		if err := m.createEvent(&taskpkg.Event{
+			Title: fmt.Sprintf("Removed dependency of task '%s' on task '%s'",
+				dependencyTask.Name, dependsOnTask.Name),
+			Type:     taskpkg.EventTypeRemoveDependency,
+			TaskID:   dependencyTask.ID,
+			OldValue: dependsOnTask.Name,
+			Snapshot: string(snapshot),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if task.State != taskpkg.StateFinished {
+		for _, dependent := range dependents {
+			if err := m.updateDependencyState(dependent); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// findDependencies returns the tasks on which a task depends.
+func (m *manager) findDependencies(task *taskpkg.Task) ([]*taskpkg.Task, error) {
+	dependencies, err := m.repo.Dependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int{}
+	for _, dependency := range dependencies {
+		if dependency.TaskID == task.ID {
+			ids = append(ids, dependency.DependsOnID)
+		}
+	}
+
+	return m.findTasksByID(ids)
+}
+
+// findDependents returns the tasks that depend on a task.
+func (m *manager) findDependents(task *taskpkg.Task) ([]*taskpkg.Task, error) {
+	dependencies, err := m.repo.Dependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int{}
+	for _, dependency := range dependencies {
+		if dependency.DependsOnID == task.ID {
+			ids = append(ids, dependency.TaskID)
+		}
+	}
+
+	return m.findTasksByID(ids)
+}
+
+func (m *manager) findTasksByID(ids []int) ([]*taskpkg.Task, error) {
+	tasks := []*taskpkg.Task{}
+	for _, id := range ids {
+		task, err := m.repo.FindTaskByID(id)
+		if err != nil {
+			return nil, err
+		} else if task != nil {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks, nil
+}
+
+func findDependency(dependencies []*taskpkg.Dependency, taskID, dependsOnID int) *taskpkg.Dependency {
+	for _, dependency := range dependencies {
+		if dependency.TaskID == taskID && dependency.DependsOnID == dependsOnID {
+			return dependency
+		}
+	}
+	return nil
+}
+
+// dependsOnTransitively returns whether the task with ID from depends, either directly or
+// indirectly, on the task with ID to.
+func dependsOnTransitively(dependencies []*taskpkg.Dependency, from, to int) bool {
+	visited := map[int]bool{}
+	queue := []int{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if id == to {
+			return true
+		} else if visited[id] {
+			continue
+		}
+		visited[id] = true
+
+		for _, dependency := range dependencies {
+			if dependency.TaskID == id {
+				queue = append(queue, dependency.DependsOnID)
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,362 @@
+package manager_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	managerpkg "github.com/ankeesler/anwork/manager"
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/taskfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dependencies", func() {
+	var (
+		repo    *taskfakes.FakeRepo
+		now     time.Time
+		manager managerpkg.Manager
+
+		tasks        []*taskpkg.Task
+		dependencies []*taskpkg.Dependency
+	)
+
+	BeforeEach(func() {
+		repo = &taskfakes.FakeRepo{}
+
+		now = time.Now()
+		manager = managerpkg.New(repo, fakeclock.NewFakeClock(now))
+
+		tasks = []*taskpkg.Task{
+			&taskpkg.Task{Name: "task-a", ID: 1, State: taskpkg.StateReady},
+			&taskpkg.Task{Name: "task-b", ID: 2, State: taskpkg.StateReady},
+			&taskpkg.Task{Name: "task-c", ID: 3, State: taskpkg.StateRunning},
+			&taskpkg.Task{Name: "task-d", ID: 4, State: taskpkg.StateFinished},
+		}
+		dependencies = []*taskpkg.Dependency{}
+
+		repo.FindTaskByNameStub = func(name string) (*taskpkg.Task, error) {
+			for _, task := range tasks {
+				if task.Name == name {
+					return task, nil
+				}
+			}
+			return nil, nil
+		}
+		repo.FindTaskByIDStub = func(id int) (*taskpkg.Task, error) {
+			for _, task := range tasks {
+				if task.ID == id {
+					return task, nil
+				}
+			}
+			return nil, nil
+		}
+		repo.DependenciesStub = func() ([]*taskpkg.Dependency, error) {
+			return dependencies, nil
+		}
+		repo.CreateDependencyStub = func(dependency *taskpkg.Dependency) error {
+			dependency.ID = len(dependencies) + 100
+			dependencies = append(dependencies, dependency)
+			return nil
+		}
+		repo.DeleteDependencyStub = func(dependency *taskpkg.Dependency) error {
+			for i, d := range dependencies {
+				if d.ID == dependency.ID {
+					dependencies = append(dependencies[:i], dependencies[i+1:]...)
+					break
+				}
+			}
+			return nil
+		}
+	})
+
+	eventTitles := func() []string {
+		titles := []string{}
+		for i := 0; i < repo.CreateEventCallCount(); i++ {
+			titles = append(titles, repo.CreateEventArgsForCall(i).Title)
+		}
+		return titles
+	}
+
+	Describe("AddDependency", func() {
+		It("creates the dependency and adds an event saying the dependency was added", func() {
+			Expect(manager.AddDependency("task-a", "task-d")).To(Succeed())
+
+			Expect(repo.CreateDependencyCallCount()).To(Equal(1))
+			Expect(repo.CreateDependencyArgsForCall(0)).To(Equal(&taskpkg.Dependency{
+				ID:          100,
+				TaskID:      1,
+				DependsOnID: 4,
+			}))
+
+			Expect(repo.CreateEventCallCount()).To(Equal(1))
+			Expect(repo.CreateEventArgsForCall(0)).To(Equal(&taskpkg.Event{
+				Title:    "Added dependency of task 'task-a' on task 'task-d'",
+				Date:     now.Unix(),
+				Type:     taskpkg.EventTypeAddDependency,
+				TaskID:   1,
+				NewValue: "task-d",
+				Snapshot: `{"id":100,"taskid":1,"dependsonid":4}`,
+			}))
+
+			Expect(repo.UpdateTaskCallCount()).To(Equal(0))
+		})
+
+		Context("when the task on which the task depends is not finished", func() {
+			It("moves the task to blocked and adds an event saying so", func() {
+				Expect(manager.AddDependency("task-c", "task-b")).To(Succeed())
+
+				Expect(repo.UpdateTaskCallCount()).To(Equal(1))
+				Expect(repo.UpdateTaskArgsForCall(0).Name).To(Equal("task-c"))
+				Expect(repo.UpdateTaskArgsForCall(0).State).To(BeEquivalentTo(taskpkg.StateBlocked))
+
+				Expect(repo.CreateEventCallCount()).To(Equal(2))
+				cause := repo.CreateEventArgsForCall(0).ID
+				Expect(repo.CreateEventArgsForCall(1)).To(Equal(&taskpkg.Event{
+					Title:    "Set state on task 'task-c' from Running to Blocked",
+					Date:     now.Unix(),
+					Type:     taskpkg.EventTypeSetState,
+					TaskID:   3,
+					OldValue: "Running",
+					NewValue: "Blocked",
+					Cause:    &cause,
+				}))
+			})
+
+			Context("when the task is finished", func() {
+				It("leaves the task alone", func() {
+					Expect(manager.AddDependency("task-d", "task-b")).To(Succeed())
+
+					Expect(repo.UpdateTaskCallCount()).To(Equal(0))
+					Expect(tasks[3].State).To(BeEquivalentTo(taskpkg.StateFinished))
+				})
+			})
+		})
+
+		Context("when the dependency already exists", func() {
+			BeforeEach(func() {
+				Expect(manager.AddDependency("task-a", "task-d")).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				err := manager.AddDependency("task-a", "task-d")
+				Expect(err).To(MatchError("task 'task-a' already depends on task 'task-d'"))
+				Expect(repo.CreateDependencyCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the task depends on itself", func() {
+			It("returns an error", func() {
+				err := manager.AddDependency("task-a", "task-a")
+				Expect(err).To(MatchError("task 'task-a' cannot depend on task 'task-a' since it would create a cycle"))
+				Expect(repo.CreateDependencyCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the dependency would create a cycle", func() {
+			BeforeEach(func() {
+				Expect(manager.AddDependency("task-a", "task-b")).To(Succeed())
+				Expect(manager.AddDependency("task-b", "task-c")).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				err := manager.AddDependency("task-c", "task-a")
+				Expect(err).To(MatchError("task 'task-c' cannot depend on task 'task-a' since it would create a cycle"))
+				Expect(repo.CreateDependencyCallCount()).To(Equal(2))
+			})
+		})
+
+		Context("when the task does not exist", func() {
+			It("returns an error", func() {
+				err := manager.AddDependency("task-a", "task-z")
+				Expect(err).To(MatchError("unknown task with name 'task-z'"))
+			})
+		})
+
+		Context("when getting the dependencies fails", func() {
+			BeforeEach(func() {
+				repo.DependenciesStub = nil
+				repo.DependenciesReturnsOnCall(0, nil, errors.New("some dependencies error"))
+			})
+
+			It("returns the error", func() {
+				err := manager.AddDependency("task-a", "task-b")
+				Expect(err).To(MatchError("some dependencies error"))
+			})
+		})
+
+		Context("when creating the dependency fails", func() {
+			BeforeEach(func() {
+				repo.CreateDependencyStub = nil
+				repo.CreateDependencyReturnsOnCall(0, errors.New("some create dependency error"))
+			})
+
+			It("returns the error", func() {
+				err := manager.AddDependency("task-a", "task-b")
+				Expect(err).To(MatchError("some create dependency error"))
+				Expect(repo.CreateEventCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("RemoveDependency", func() {
+		BeforeEach(func() {
+			Expect(manager.AddDependency("task-a", "task-b")).To(Succeed())
+			Expect(manager.AddDependency("task-a", "task-c")).To(Succeed())
+			Expect(tasks[0].State).To(BeEquivalentTo(taskpkg.StateBlocked))
+		})
+
+		It("deletes the dependency and adds an event saying the dependency was removed", func() {
+			Expect(manager.RemoveDependency("task-a", "task-b")).To(Succeed())
+
+			Expect(repo.DeleteDependencyCallCount()).To(Equal(1))
+			Expect(repo.DeleteDependencyArgsForCall(0)).To(Equal(&taskpkg.Dependency{
+				ID:          100,
+				TaskID:      1,
+				DependsOnID: 2,
+			}))
+			Expect(dependencies).To(HaveLen(1))
+
+			Expect(eventTitles()).To(ContainElement("Removed dependency of task 'task-a' on task 'task-b'"))
+			Expect(tasks[0].State).To(BeEquivalentTo(taskpkg.StateBlocked))
+		})
+
+		Context("when the last unfinished dependency is removed", func() {
+			It("moves the task to ready", func() {
+				Expect(manager.RemoveDependency("task-a", "task-b")).To(Succeed())
+				Expect(manager.RemoveDependency("task-a", "task-c")).To(Succeed())
+
+				Expect(tasks[0].State).To(BeEquivalentTo(taskpkg.StateReady))
+				Expect(eventTitles()).To(HaveLen(6))
+				Expect(eventTitles()[5]).To(Equal("Set state on task 'task-a' from Blocked to Ready"))
+			})
+		})
+
+		Context("when the dependency does not exist", func() {
+			It("returns an error", func() {
+				err := manager.RemoveDependency("task-b", "task-a")
+				Expect(err).To(MatchError("task 'task-b' does not depend on task 'task-a'"))
+				Expect(repo.DeleteDependencyCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when deleting the dependency fails", func() {
+			BeforeEach(func() {
+				repo.DeleteDependencyStub = nil
+				repo.DeleteDependencyReturnsOnCall(0, errors.New("some delete dependency error"))
+			})
+
+			It("returns the error", func() {
+				err := manager.RemoveDependency("task-a", "task-b")
+				Expect(err).To(MatchError("some delete dependency error"))
+			})
+		})
+	})
+
+	Describe("Dependencies", func() {
+		BeforeEach(func() {
+			Expect(manager.AddDependency("task-a", "task-d")).To(Succeed())
+			Expect(manager.AddDependency("task-a", "task-b")).To(Succeed())
+			Expect(manager.AddDependency("task-b", "task-c")).To(Succeed())
+		})
+
+		It("returns the tasks on which a task depends", func() {
+			Expect(manager.Dependencies("task-a")).To(Equal([]*taskpkg.Task{tasks[3], tasks[1]}))
+			Expect(manager.Dependencies("task-b")).To(Equal([]*taskpkg.Task{tasks[2]}))
+			Expect(manager.Dependencies("task-c")).To(BeEmpty())
+		})
+
+		Context("when the task does not exist", func() {
+			It("returns an error", func() {
+				_, err := manager.Dependencies("task-z")
+				Expect(err).To(MatchError("unknown task with name 'task-z'"))
+			})
+		})
+	})
+
+	Describe("automatic state transitions", func() {
+		BeforeEach(func() {
+			Expect(manager.AddDependency("task-a", "task-b")).To(Succeed())
+			Expect(manager.AddDependency("task-a", "task-c")).To(Succeed())
+			Expect(manager.AddDependency("task-c", "task-b")).To(Succeed())
+			Expect(tasks[0].State).To(BeEquivalentTo(taskpkg.StateBlocked))
+			Expect(tasks[2].State).To(BeEquivalentTo(taskpkg.StateBlocked))
+		})
+
+		Context("when all of the dependencies of a task are finished", func() {
+			It("moves the task to ready and records events", func() {
+				Expect(manager.SetState("task-b", taskpkg.StateFinished)).To(Succeed())
+				Expect(tasks[0].State).To(BeEquivalentTo(taskpkg.StateBlocked))
+				Expect(tasks[2].State).To(BeEquivalentTo(taskpkg.StateReady))
+
+				Expect(manager.SetState("task-c", taskpkg.StateFinished)).To(Succeed())
+				Expect(tasks[0].State).To(BeEquivalentTo(taskpkg.StateReady))
+
+				titles := eventTitles()
+				Expect(titles[len(titles)-4:]).To(Equal([]string{
+					"Set state on task 'task-b' from Ready to Finished",
+					"Set state on task 'task-c' from Blocked to Ready",
+					"Set state on task 'task-c' from Ready to Finished",
+					"Set state on task 'task-a' from Blocked to Ready",
+				}))
+			})
+		})
+
+		Context("when a dependency stops being finished", func() {
+			BeforeEach(func() {
+				Expect(manager.SetState("task-b", taskpkg.StateFinished)).To(Succeed())
+				Expect(manager.SetState("task-c", taskpkg.StateRunning)).To(Succeed())
+			})
+
+			It("moves the ready and running tasks that depend on it to blocked", func() {
+				Expect(manager.SetState("task-b", taskpkg.StateRunning)).To(Succeed())
+				Expect(tasks[2].State).To(BeEquivalentTo(taskpkg.StateBlocked))
+				Expect(eventTitles()).To(ContainElement("Set state on task 'task-c' from Running to Blocked"))
+			})
+		})
+
+		Context("when a dependency is deleted", func() {
+			It("deletes the dependencies and unblocks the tasks that depended on it", func() {
+				Expect(manager.Delete("task-b")).To(Succeed())
+
+				Expect(dependencies).To(Equal([]*taskpkg.Dependency{
+					&taskpkg.Dependency{ID: 101, TaskID: 1, DependsOnID: 3},
+				}))
+				Expect(tasks[0].State).To(BeEquivalentTo(taskpkg.StateBlocked))
+				Expect(tasks[2].State).To(BeEquivalentTo(taskpkg.StateReady))
+			})
+
+			It("records the deleted dependencies before the deleted task", func() {
+				Expect(manager.Delete("task-b")).To(Succeed())
+
+				titles := eventTitles()
+				Expect(titles[len(titles)-4:]).To(Equal([]string{
+					"Removed dependency of task 'task-a' on task 'task-b'",
+					"Removed dependency of task 'task-c' on task 'task-b'",
+					"Set state on task 'task-c' from Blocked to Ready",
+					"Deleted task 'task-b'",
+				}))
+			})
+		})
+
+		Context("when a task is updated without changing whether it is finished", func() {
+			It("does not change the state of the tasks that depend on it", func() {
+				Expect(manager.SetState("task-b", taskpkg.StateRunning)).To(Succeed())
+				Expect(tasks[0].State).To(BeEquivalentTo(taskpkg.StateBlocked))
+				Expect(tasks[2].State).To(BeEquivalentTo(taskpkg.StateBlocked))
+			})
+		})
+
+		Context("when the task cannot be updated", func() {
+			BeforeEach(func() {
+				repo.UpdateTaskReturns(errors.New("some update task error"))
+			})
+
+			It("returns the error", func() {
+				err := manager.SetState("task-b", taskpkg.StateFinished)
+				Expect(err).To(MatchError("some update task error"))
+			})
+		})
+	})
+})
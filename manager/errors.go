@@ -1,11 +1,36 @@
 package manager
 
-import "fmt"
+import (
+	"fmt"
 
-type unknownTaskError struct {
-	name string
+	"github.com/ankeesler/anwork/task"
+)
+
+// An UnknownTaskError is returned when there is no task.Task with a name.
+type UnknownTaskError struct {
+	Name string
+}
+
+func (ute UnknownTaskError) Error() string {
+	return fmt.Sprintf("unknown task with name '%s'", ute.Name)
+}
+
+// An InvalidStateError is returned when a task.Task is set to a task.State that does not exist.
+type InvalidStateError struct {
+	State task.State
+}
+
+func (ise InvalidStateError) Error() string {
+	return fmt.Sprintf("invalid state '%s'", ise.State)
+}
+
+// A CyclicDependencyError is returned when a task.Task would depend on itself, directly or
+// transitively.
+type CyclicDependencyError struct {
+	Name, DependsOn string
 }
 
-func (ute unknownTaskError) Error() string {
-	return fmt.Sprintf("unknown task with name '%s'", ute.name)
+func (cde CyclicDependencyError) Error() string {
+	return fmt.Sprintf("task '%s' cannot depend on task '%s' since it would create a cycle",
+		cde.Name, cde.DependsOn)
 }
@@ -3,13 +3,17 @@
 package manager
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+	"unicode"
 
 	"code.cloudfoundry.org/clock"
+	"github.com/ankeesler/anwork/query"
 	"github.com/ankeesler/anwork/task"
 	taskpkg "github.com/ankeesler/anwork/task"
-	multierror "github.com/hashicorp/go-multierror"
 )
 
 //go:generate counterfeiter . Manager
@@ -22,6 +26,8 @@ type Manager interface {
 
 	// Delete a task with a name. Returns an error if the task was not able to be deleted.
 	Delete(name string) error
+	// Delete every task in the task.StateFinished task.State, as a single operation.
+	Archive() error
 
 	// Find a task with an ID.
 	FindByID(id int) (*taskpkg.Task, error)
@@ -34,23 +40,69 @@ type Manager interface {
 	// When multiple tasks have the same priority, the Task's will be ordered by their (unique) ID in
 	// ascending order. This means that the older Task's will come first. This is a conscious decision.
 	// The Task's that have been around the longest are assumed to need to be completed first.
+	//
+	// The priority used for ordering depends on the Scheduling of this manager. See Scheduling.
 	Tasks() ([]*taskpkg.Task, error)
+	// Get the Tasks contained in this manager that match a query.Query, in the same order as Tasks.
+	TasksMatching(q *query.Query) ([]*taskpkg.Task, error)
 
 	// Add a note for a task.
 	Note(name, note string) error
 	// Set the priority of a task.
 	SetPriority(name string, priority int) error
 	// Set the state of a task.
+	//
+	// When a task becomes finished, the blocked tasks that depend on it are moved to the
+	// task.StateReady task.State once all of their dependencies are finished. When a task stops
+	// being finished, the ready or running tasks that depend on it are moved to the
+	// task.StateBlocked task.State. Returns an InvalidStateError if the task.State does not exist.
 	SetState(name string, state taskpkg.State) error
+	// Set the deadline of a task, represented by the number of seconds since January 1, 1970. A
+	// deadline of 0 clears the deadline of the task.
+	SetDeadline(name string, deadline int64) error
+
+	// Add a tag to a task. Returns an error if the tag is empty or contains whitespace, or if the task
+	// already has the tag.
+	AddTag(name, tag string) error
+	// Remove a tag from a task. Returns an error if the task does not have the tag.
+	RemoveTag(name, tag string) error
+
+	// Make a task depend on another task, i.e., the task with the name cannot be finished until the
+	// task with the name dependsOn is finished. Returns an error if the dependency would create a
+	// cycle. If the task with the name dependsOn is not finished, the task with the name is moved to
+	// the task.StateBlocked task.State.
+	AddDependency(name, dependsOn string) error
+	// Remove a dependency of a task on another task. If the task is blocked and all of its remaining
+	// dependencies are finished, the task is moved to the task.StateReady task.State.
+	RemoveDependency(name, dependsOn string) error
+	// Get the tasks on which a task depends.
+	Dependencies(name string) ([]*taskpkg.Task, error)
 
 	// Get the events associated with this manager.
 	Events() ([]*taskpkg.Event, error)
+	// Get the events that a query.EventQuery selects.
+	EventsMatching(q *query.EventQuery) ([]*taskpkg.Event, error)
 
-	// Perform a factory reset, e.g., make this manager new again.
+	// Perform a factory reset, e.g., make this manager new again. The only event left afterwards
+	// records what was reset so that the reset can be undone.
 	Reset() error
 
 	// Rename a task.
 	Rename(from, to string) error
+
+	// Undo the n most recent operations (e.g., Delete or SetState) that have not been undone yet.
+	// Each operation is undone by performing its inverse, which is recorded with its own events.
+	// Notes are not undone. Returns an error if there are fewer than n operations to undo.
+	Undo(n int) error
+	// Redo the most recently undone operation. Returns an error if there is nothing to redo, i.e.,
+	// nothing has been undone since the last operation.
+	Redo() error
+
+	// Replay the events to rebuild the tasks and dependencies that they describe, and compare them to
+	// the tasks and dependencies that are stored. Returns a description of each problem that was
+	// found, e.g., an event that refers to an unknown task or a task whose state does not match the
+	// events.
+	Replay() ([]string, error)
 }
 
 const defaultPriority = 10
@@ -58,46 +110,140 @@ const defaultPriority = 10
 const defaultState = taskpkg.StateReady
 
 type manager struct {
-	repo  taskpkg.Repo
-	clock clock.Clock
+	repo       taskpkg.Repo
+	clock      clock.Clock
+	scheduling Scheduling
+	actor      string
+
+	// This is the operation during which the manager is creating events. See begin.
+	op *operation
+	// This is whether the repo is the task.Repo of a transaction. See transact.
+	tx bool
 }
 
-// New creates a new Manager that will use a task.Repo for CRUD task.Task operations.
-func New(repo taskpkg.Repo, clock clock.Clock) Manager {
-	return &manager{repo: repo, clock: clock}
+// An operation is a single call to a Manager that changes something, e.g., Delete. All of the events
+// created by an operation are linked to the first one so that they can be undone together.
+type operation struct {
+	// This is the ID of the first event created by the operation, or nil if there is none yet.
+	cause *int
+	// This is the ID of the first event of the operation that this operation undoes or redoes.
+	reverts *int
 }
 
-func (m *manager) Create(name string) error {
-	task := taskpkg.Task{
-		Name:      name,
-		StartDate: m.clock.Now().Unix(),
-		Priority:  defaultPriority,
-		State:     defaultState,
+// An Option configures optional behavior of a Manager returned from New.
+type Option func(*manager)
+
+// WithScheduling sets the Scheduling that a Manager uses to order its Task's. By default, a
+// Manager uses SchedulingPriority.
+func WithScheduling(scheduling Scheduling) Option {
+	return func(m *manager) {
+		m.scheduling = scheduling
 	}
-	if err := m.repo.CreateTask(&task); err != nil {
-		return err
+}
+
+// WithActor sets the name of the user that is recorded as the task.Event.Actor of every task.Event
+// that a Manager creates. By default, the actor is "".
+func WithActor(actor string) Option {
+	return func(m *manager) {
+		m.actor = actor
+	}
+}
+
+// New creates a new Manager that will use a task.Repo for CRUD task.Task operations. If the
+// task.Repo is a task.Transactor, each call to the Manager that changes something is made in a
+// transaction, so that it either makes all of its changes or none of them.
+func New(repo taskpkg.Repo, clock clock.Clock, options ...Option) Manager {
+	m := &manager{repo: repo, clock: clock, scheduling: SchedulingPriority}
+	for _, option := range options {
+		option(m)
 	}
+	return m
+}
+
+func (m *manager) Create(name string) error {
+	return m.transact(func(m *manager) error {
+		task := taskpkg.Task{
+			Name:      name,
+			StartDate: m.clock.Now().Unix(),
+			Priority:  defaultPriority,
+			State:     defaultState,
+		}
+		if err := m.repo.CreateTask(&task); err != nil {
+			return err
+		}
+
+		snapshot, err := json.Marshal(&task)
+		if err != nil {
+			return err
+		}
 
-	return m.repo.CreateEvent(&taskpkg.Event{
-		Title:  fmt.Sprintf("Created task '%s'", name),
-		Date:   m.clock.Now().Unix(),
-		Type:   taskpkg.EventTypeCreate,
-		TaskID: task.ID,
+		return m.createEvent(&taskpkg.Event{
+			Title:    fmt.Sprintf("Created task '%s'", name),
+			Type:     taskpkg.EventTypeCreate,
+			TaskID:   task.ID,
+			NewValue: name,
+			Snapshot: string(snapshot),
+		})
 	})
 }
 
 func (m *manager) Delete(name string) error {
-	return m.doWithTask(name, func(task *taskpkg.Task) error {
-		if err := m.repo.DeleteTask(task); err != nil {
+	return m.transact(func(m *manager) error {
+		return m.doWithTask(name, func(task *taskpkg.Task) error {
+			return m.deleteTask(task)
+		})
+	})
+}
+
+func (m *manager) Archive() error {
+	return m.transact(func(m *manager) error {
+		tasks, err := m.repo.Tasks()
+		if err != nil {
 			return err
 		}
 
-		return m.repo.CreateEvent(&taskpkg.Event{
-			Title:  fmt.Sprintf("Deleted task '%s'", name),
-			Date:   m.clock.Now().Unix(),
-			Type:   taskpkg.EventTypeDelete,
-			TaskID: task.ID,
-		})
+		// Deleting a task may change the slice that the repo returned, so find the tasks first.
+		finished := []*taskpkg.Task{}
+		for _, task := range tasks {
+			if task.State == taskpkg.StateFinished {
+				finished = append(finished, task)
+			}
+		}
+
+		for _, task := range finished {
+			if err := m.deleteTask(task); err != nil {
+				return fmt.Errorf("cannot delete task '%s': %w", task.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// deleteTask deletes a task and its dependencies, and records the task in an event so that it can
+// be restored.
+func (m *manager) deleteTask(task *taskpkg.Task) error {
+	snapshot, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	// Delete the dependencies first so that, when this is undone in reverse order, the task is
+	// restored before its dependencies.
+	if err := m.deleteDependencies(task); err != nil {
+		return err
+	}
+
+	if err := m.repo.DeleteTask(task); err != nil {
+		return err
+	}
+
+	return m.createEvent(&taskpkg.Event{
+		Title:    fmt.Sprintf("Deleted task '%s'", task.Name),
+		Type:     taskpkg.EventTypeDelete,
+		TaskID:   task.ID,
+		OldValue: task.Name,
+		Snapshot: string(snapshot),
 	})
 }
 
@@ -115,96 +261,290 @@ func (m *manager) Tasks() ([]*taskpkg.Task, error) {
 		return nil, err
 	}
 
+	m.sort(tasks)
+
+	return tasks, nil
+}
+
+func (m *manager) TasksMatching(q *query.Query) ([]*taskpkg.Task, error) {
+	var tasks []*taskpkg.Task
+	if queryRepo, ok := m.repo.(query.Repo); ok {
+		var err error
+		if tasks, err = queryRepo.TasksMatching(q); err != nil {
+			return nil, err
+		}
+	} else {
+		allTasks, err := m.repo.Tasks()
+		if err != nil {
+			return nil, err
+		}
+
+		tasks = make([]*taskpkg.Task, 0, len(allTasks))
+		for _, task := range allTasks {
+			if q.Matches(task) {
+				tasks = append(tasks, task)
+			}
+		}
+	}
+
+	m.sort(tasks)
+
+	return tasks, nil
+}
+
+// sort orders tasks according to the Scheduling of the manager. See Manager.Tasks.
+func (m *manager) sort(tasks []*taskpkg.Task) {
+	now := m.clock.Now()
+	priority := func(task *taskpkg.Task) int {
+		if m.scheduling == SchedulingDeadline {
+			return EffectivePriority(task, now)
+		}
+		return task.Priority
+	}
+
 	sort.Slice(tasks, func(i, j int) bool {
-		if tasks[i].Priority == tasks[j].Priority {
+		iPriority, jPriority := priority(tasks[i]), priority(tasks[j])
+		if iPriority == jPriority {
 			return tasks[i].ID < tasks[j].ID
 		} else {
-			return tasks[i].Priority < tasks[j].Priority
+			return iPriority < jPriority
 		}
 	})
-
-	return tasks, nil
 }
 
 func (m *manager) Note(name, note string) error {
-	return m.doWithTask(name, func(task *taskpkg.Task) error {
-		return m.repo.CreateEvent(&taskpkg.Event{
-			Title:  fmt.Sprintf("Note added to task '%s': %s", name, note),
-			Date:   m.clock.Now().Unix(),
-			Type:   taskpkg.EventTypeNote,
-			TaskID: task.ID,
+	return m.transact(func(m *manager) error {
+		return m.doWithTask(name, func(task *taskpkg.Task) error {
+			return m.createEvent(&taskpkg.Event{
+				Title:  fmt.Sprintf("Note added to task '%s': %s", name, note),
+				Type:   taskpkg.EventTypeNote,
+				TaskID: task.ID,
+				Note:   note,
+			})
 		})
 	})
 }
 
 func (m *manager) SetPriority(name string, priority int) error {
-	return m.doWithTask(name, func(task *taskpkg.Task) error {
-		oldPriority := task.Priority
-		task.Priority = priority
-		if err := m.repo.UpdateTask(task); err != nil {
-			return err
-		}
-
-		return m.repo.CreateEvent(&taskpkg.Event{
-			Title: fmt.Sprintf("Set priority on task '%s' from %d to %d",
-				name, oldPriority, priority),
-			Date:   m.clock.Now().Unix(),
-			Type:   taskpkg.EventTypeSetPriority,
-			TaskID: task.ID,
+	return m.transact(func(m *manager) error {
+		return m.doWithTask(name, func(task *taskpkg.Task) error {
+			return m.setPriority(task, priority)
 		})
 	})
 }
 
+// setPriority sets the priority of a task and records the change with an event.
+func (m *manager) setPriority(task *taskpkg.Task, priority int) error {
+	oldPriority := task.Priority
+	task.Priority = priority
+	if err := m.repo.UpdateTask(task); err != nil {
+		return err
+	}
+
+	return m.createEvent(&taskpkg.Event{
+		Title: fmt.Sprintf("Set priority on task '%s' from %d to %d",
+			task.Name, oldPriority, priority),
+		Type:     taskpkg.EventTypeSetPriority,
+		TaskID:   task.ID,
+		OldValue: strconv.Itoa(oldPriority),
+		NewValue: strconv.Itoa(priority),
+	})
+}
+
 func (m *manager) SetState(name string, state task.State) error {
-	return m.doWithTask(name, func(task *task.Task) error {
-		oldState := task.State
-		task.State = state
-		if err := m.repo.UpdateTask(task); err != nil {
-			return err
-		}
+	switch state {
+	case taskpkg.StateReady, taskpkg.StateBlocked, taskpkg.StateRunning, taskpkg.StateFinished:
+	default:
+		return InvalidStateError{State: state}
+	}
 
-		return m.repo.CreateEvent(&taskpkg.Event{
-			Title:  fmt.Sprintf("Set state on task '%s' from %s to %s", name, oldState, state),
-			Date:   m.clock.Now().Unix(),
-			Type:   taskpkg.EventTypeSetState,
-			TaskID: task.ID,
+	return m.transact(func(m *manager) error {
+		return m.doWithTask(name, func(task *task.Task) error {
+			wasFinished := task.State == taskpkg.StateFinished
+			if err := m.setState(task, state); err != nil {
+				return err
+			}
+
+			if wasFinished != (state == taskpkg.StateFinished) {
+				return m.updateDependents(task)
+			}
+			return nil
 		})
 	})
 }
 
-func (m *manager) Events() ([]*task.Event, error) {
-	return m.repo.Events()
+func (m *manager) SetDeadline(name string, deadline int64) error {
+	return m.transact(func(m *manager) error {
+		return m.doWithTask(name, func(task *taskpkg.Task) error {
+			return m.setDeadline(task, deadline)
+		})
+	})
 }
 
-func (m *manager) Reset() error {
-	tasks, err := m.repo.Tasks()
-	if err != nil {
+// setDeadline sets the deadline of a task and records the change with an event.
+func (m *manager) setDeadline(task *taskpkg.Task, deadline int64) error {
+	oldDeadline := task.Deadline
+	task.Deadline = deadline
+	if err := m.repo.UpdateTask(task); err != nil {
 		return err
 	}
-	tasksSize := len(tasks)
 
-	events, err := m.repo.Events()
-	if err != nil {
+	return m.createEvent(&taskpkg.Event{
+		Title: fmt.Sprintf("Set deadline on task '%s' from %s to %s",
+			task.Name, formatDeadline(oldDeadline), formatDeadline(deadline)),
+		Type:     taskpkg.EventTypeSetDeadline,
+		TaskID:   task.ID,
+		OldValue: strconv.FormatInt(oldDeadline, 10),
+		NewValue: strconv.FormatInt(deadline, 10),
+	})
+}
+
+func (m *manager) AddTag(name, tag string) error {
+	if tag == "" || strings.IndexFunc(tag, unicode.IsSpace) != -1 {
+		return fmt.Errorf("invalid tag: '%s'", tag)
+	}
+
+	return m.transact(func(m *manager) error {
+		return m.doWithTask(name, func(task *taskpkg.Task) error {
+			return m.addTag(task, tag)
+		})
+	})
+}
+
+// addTag adds a tag to a task and records the change with an event.
+func (m *manager) addTag(task *taskpkg.Task, tag string) error {
+	if task.HasTag(tag) {
+		return fmt.Errorf("task '%s' already has tag '%s'", task.Name, tag)
+	}
+
+	task.Tags = append(task.Tags, tag)
+	sort.Strings(task.Tags)
+	if err := m.repo.UpdateTask(task); err != nil {
 		return err
 	}
-	eventsSize := len(events)
 
-	var result *multierror.Error
-	for i := tasksSize - 1; i >= 0; i-- {
-		result = multierror.Append(result, m.repo.DeleteTask(tasks[i]))
+	return m.createEvent(&taskpkg.Event{
+		Title:    fmt.Sprintf("Added tag '%s' to task '%s'", tag, task.Name),
+		Type:     taskpkg.EventTypeAddTag,
+		TaskID:   task.ID,
+		NewValue: tag,
+	})
+}
+
+func (m *manager) RemoveTag(name, tag string) error {
+	return m.transact(func(m *manager) error {
+		return m.doWithTask(name, func(task *taskpkg.Task) error {
+			return m.removeTag(task, tag)
+		})
+	})
+}
+
+// removeTag removes a tag from a task and records the change with an event.
+func (m *manager) removeTag(task *taskpkg.Task, tag string) error {
+	if !task.HasTag(tag) {
+		return fmt.Errorf("task '%s' does not have tag '%s'", task.Name, tag)
 	}
 
-	for i := eventsSize - 1; i >= 0; i-- {
-		result = multierror.Append(result, m.repo.DeleteEvent(events[i]))
+	var tags []string
+	for _, t := range task.Tags {
+		if t != tag {
+			tags = append(tags, t)
+		}
 	}
+	task.Tags = tags
+	if err := m.repo.UpdateTask(task); err != nil {
+		return err
+	}
+
+	return m.createEvent(&taskpkg.Event{
+		Title:    fmt.Sprintf("Removed tag '%s' from task '%s'", tag, task.Name),
+		Type:     taskpkg.EventTypeRemoveTag,
+		TaskID:   task.ID,
+		OldValue: tag,
+	})
+}
+
+func (m *manager) Events() ([]*task.Event, error) {
+	return m.repo.Events()
+}
 
-	return result.ErrorOrNil()
+func (m *manager) EventsMatching(q *query.EventQuery) ([]*task.Event, error) {
+	return query.EventsMatching(m.repo, q)
+}
+
+func (m *manager) Reset() error {
+	return m.transact(func(m *manager) error {
+		tasks, err := m.repo.Tasks()
+		if err != nil {
+			return err
+		}
+		tasksSize := len(tasks)
+
+		events, err := m.repo.Events()
+		if err != nil {
+			return err
+		}
+		eventsSize := len(events)
+
+		dependencies, err := m.repo.Dependencies()
+		if err != nil {
+			return err
+		}
+
+		snapshot, err := json.Marshal(newResetSnapshot(tasks, events, dependencies))
+		if err != nil {
+			return err
+		}
+
+		for i := tasksSize - 1; i >= 0; i-- {
+			if err := m.repo.DeleteTask(tasks[i]); err != nil {
+				return err
+			}
+		}
+
+		for i := eventsSize - 1; i >= 0; i-- {
+			if err := m.repo.DeleteEvent(events[i]); err != nil {
+				return err
+			}
+		}
+
+		for i := len(dependencies) - 1; i >= 0; i-- {
+			if err := m.repo.DeleteDependency(dependencies[i]); err != nil {
+				return err
+			}
+		}
+
+		return m.createEvent(&taskpkg.Event{
+			Title:    fmt.Sprintf("Reset %d tasks", tasksSize),
+			Type:     taskpkg.EventTypeReset,
+			TaskID:   taskpkg.NoTaskID,
+			Snapshot: string(snapshot),
+		})
+	})
 }
 
 func (m *manager) Rename(from, to string) error {
-	return m.doWithTask(from, func(task *task.Task) error {
-		task.Name = to
-		return m.repo.UpdateTask(task)
+	return m.transact(func(m *manager) error {
+		return m.doWithTask(from, func(task *task.Task) error {
+			return m.rename(task, to)
+		})
+	})
+}
+
+// rename renames a task and records the change with an event.
+func (m *manager) rename(task *taskpkg.Task, name string) error {
+	oldName := task.Name
+	task.Name = name
+	if err := m.repo.UpdateTask(task); err != nil {
+		return err
+	}
+
+	return m.createEvent(&taskpkg.Event{
+		Title:    fmt.Sprintf("Renamed task '%s' to '%s'", oldName, name),
+		Type:     taskpkg.EventTypeRename,
+		TaskID:   task.ID,
+		OldValue: oldName,
+		NewValue: name,
 	})
 }
 
@@ -215,8 +555,62 @@ func (m *manager) doWithTask(name string, do func(*task.Task) error) error {
 	}
 
 	if task == nil {
-		return unknownTaskError{name: name}
+		return UnknownTaskError{Name: name}
 	}
 
 	return do(task)
 }
+
+// transact calls a function with a copy of the manager from begin. Every method of the Manager that
+// changes something should call transact, so that it is a single operation.
+//
+// If the task.Repo of the manager is a task.Transactor, the copy uses the task.Repo of a
+// transaction, so that either all or none of the changes of the operation are made. An operation
+// that is performed during another one, e.g., a Reset that undoes a restore, is part of the same
+// transaction.
+func (m *manager) transact(do func(m *manager) error) error {
+	m = m.begin()
+
+	transactor, ok := m.repo.(taskpkg.Transactor)
+	if !ok || m.tx {
+		return do(m)
+	}
+
+	return transactor.WithTx(func(repo taskpkg.Repo) error {
+		m.repo = repo
+		m.tx = true
+		return do(m)
+	})
+}
+
+// begin returns a copy of the manager that links all of the events that it creates to the first
+// one, i.e., as a single operation. See transact.
+func (m *manager) begin() *manager {
+	op := *m
+	op.op = &operation{}
+	return &op
+}
+
+// createEvent fills in the date, actor, and operation of an event, and then creates it.
+func (m *manager) createEvent(event *taskpkg.Event) error {
+	event.Date = m.clock.Now().Unix()
+	event.Actor = m.actor
+	if m.op != nil {
+		if m.op.cause == nil {
+			event.Reverts = m.op.reverts
+		} else {
+			event.Cause = m.op.cause
+		}
+	}
+
+	if err := m.repo.CreateEvent(event); err != nil {
+		return err
+	}
+
+	if m.op != nil && m.op.cause == nil {
+		id := event.ID
+		m.op.cause = &id
+	}
+
+	return nil
+}
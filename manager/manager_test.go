@@ -1,11 +1,15 @@
 package manager_test
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"code.cloudfoundry.org/clock/fakeclock"
 	managerpkg "github.com/ankeesler/anwork/manager"
+	"github.com/ankeesler/anwork/query"
 	taskpkg "github.com/ankeesler/anwork/task"
 	"github.com/ankeesler/anwork/task/taskfakes"
 	. "github.com/onsi/ginkgo"
@@ -54,11 +58,17 @@ var _ = Describe("Manager", func() {
 			Expect(manager.Create("task-a")).To(Succeed())
 
 			Expect(repo.CreateEventCallCount()).To(Equal(1))
+			snapshot := fmt.Sprintf(
+				`{"name":"task-a","id":10,"startDate":%d,"priority":10,"state":"Ready","deadline":0,"tags":null,"version":0}`,
+				now.Unix(),
+			)
 			Expect(repo.CreateEventArgsForCall(0)).To(Equal(&taskpkg.Event{
-				Title:  "Created task 'task-a'",
-				Date:   now.Unix(),
-				Type:   taskpkg.EventTypeCreate,
-				TaskID: 10,
+				Title:    "Created task 'task-a'",
+				Date:     now.Unix(),
+				Type:     taskpkg.EventTypeCreate,
+				TaskID:   10,
+				NewValue: "task-a",
+				Snapshot: snapshot,
 			}))
 		})
 
@@ -72,6 +82,81 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("WithActor", func() {
+		BeforeEach(func() {
+			manager = managerpkg.New(repo, clock, managerpkg.WithActor("some-user"))
+
+			repo.FindTaskByNameReturns(&taskpkg.Task{Name: "task-a", ID: 10}, nil)
+		})
+
+		It("records the actor on every event", func() {
+			Expect(manager.Create("task-a")).To(Succeed())
+			Expect(manager.Note("task-a", "here is a note")).To(Succeed())
+			Expect(manager.SetPriority("task-a", 5)).To(Succeed())
+			Expect(manager.SetState("task-a", taskpkg.StateRunning)).To(Succeed())
+
+			Expect(repo.CreateEventCallCount()).To(Equal(4))
+			for i := 0; i < repo.CreateEventCallCount(); i++ {
+				Expect(repo.CreateEventArgsForCall(i).Actor).To(Equal("some-user"))
+			}
+		})
+	})
+
+	Describe("when the repo is a task.Transactor", func() {
+		var (
+			txRepo     *taskfakes.FakeRepo
+			transactor *fakeTransactorRepo
+		)
+
+		BeforeEach(func() {
+			txRepo = &taskfakes.FakeRepo{}
+			transactor = &fakeTransactorRepo{FakeRepo: repo, FakeTransactor: &taskfakes.FakeTransactor{}}
+			transactor.WithTxStub = func(do func(taskpkg.Repo) error) error {
+				return do(txRepo)
+			}
+			manager = managerpkg.New(transactor, clock)
+		})
+
+		It("makes each change in a transaction", func() {
+			Expect(manager.Create("task-a")).To(Succeed())
+
+			Expect(transactor.WithTxCallCount()).To(Equal(1))
+			Expect(txRepo.CreateTaskCallCount()).To(Equal(1))
+			Expect(txRepo.CreateEventCallCount()).To(Equal(1))
+			Expect(repo.CreateTaskCallCount()).To(Equal(0))
+			Expect(repo.CreateEventCallCount()).To(Equal(0))
+		})
+
+		It("returns the error of the transaction", func() {
+			txRepo.CreateEventReturns(errors.New("some create event error"))
+			transactor.WithTxStub = func(do func(taskpkg.Repo) error) error {
+				Expect(do(txRepo)).To(MatchError("some create event error"))
+				return errors.New("some tx error")
+			}
+
+			Expect(manager.Create("task-a")).To(MatchError("some tx error"))
+		})
+
+		It("makes an operation that is performed during another one in the same transaction", func() {
+			// Redoing the undo of a reset performs a Reset.
+			txRepo.EventsReturns([]*taskpkg.Event{&taskpkg.Event{ID: 1, Type: taskpkg.EventTypeRestore}}, nil)
+
+			Expect(manager.Redo()).To(Succeed())
+
+			Expect(transactor.WithTxCallCount()).To(Equal(1))
+			Expect(txRepo.CreateEventCallCount()).To(Equal(1))
+			Expect(txRepo.CreateEventArgsForCall(0).Type).To(BeEquivalentTo(taskpkg.EventTypeReset))
+		})
+
+		It("does not read in a transaction", func() {
+			_, err := manager.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(transactor.WithTxCallCount()).To(Equal(0))
+			Expect(repo.TasksCallCount()).To(Equal(1))
+		})
+	})
+
 	Describe("Delete", func() {
 		BeforeEach(func() {
 			repo.FindTaskByNameReturnsOnCall(0, &taskpkg.Task{Name: "task-a", ID: 10}, nil)
@@ -95,10 +180,12 @@ var _ = Describe("Manager", func() {
 
 			Expect(repo.CreateEventCallCount()).To(Equal(1))
 			Expect(repo.CreateEventArgsForCall(0)).To(Equal(&taskpkg.Event{
-				Title:  "Deleted task 'task-a'",
-				Date:   now.Unix(),
-				Type:   taskpkg.EventTypeDelete,
-				TaskID: 10,
+				Title:    "Deleted task 'task-a'",
+				Date:     now.Unix(),
+				Type:     taskpkg.EventTypeDelete,
+				TaskID:   10,
+				OldValue: "task-a",
+				Snapshot: `{"name":"task-a","id":10,"startDate":0,"priority":0,"state":"","deadline":0,"tags":null,"version":0}`,
 			}))
 		})
 
@@ -139,6 +226,52 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("Archive", func() {
+		var tasks []*taskpkg.Task
+		BeforeEach(func() {
+			tasks = []*taskpkg.Task{
+				&taskpkg.Task{Name: "task-a", ID: 1, State: taskpkg.StateFinished},
+				&taskpkg.Task{Name: "task-b", ID: 2, State: taskpkg.StateBlocked},
+				&taskpkg.Task{Name: "task-c", ID: 3, State: taskpkg.StateFinished},
+			}
+			repo.TasksReturns(tasks, nil)
+		})
+
+		It("deletes the finished tasks as a single operation", func() {
+			Expect(manager.Archive()).To(Succeed())
+
+			Expect(repo.DeleteTaskCallCount()).To(Equal(2))
+			Expect(repo.DeleteTaskArgsForCall(0)).To(Equal(tasks[0]))
+			Expect(repo.DeleteTaskArgsForCall(1)).To(Equal(tasks[2]))
+
+			Expect(repo.CreateEventCallCount()).To(Equal(2))
+			Expect(repo.CreateEventArgsForCall(0).Title).To(Equal("Deleted task 'task-a'"))
+			Expect(repo.CreateEventArgsForCall(1).Title).To(Equal("Deleted task 'task-c'"))
+			Expect(repo.CreateEventArgsForCall(1).Cause).NotTo(BeNil())
+		})
+
+		Context("when a task cannot be deleted", func() {
+			BeforeEach(func() {
+				repo.DeleteTaskReturnsOnCall(0, errors.New("some delete error"))
+			})
+
+			It("stops and returns an error", func() {
+				Expect(manager.Archive()).To(MatchError("cannot delete task 'task-a': some delete error"))
+				Expect(repo.DeleteTaskCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the repo fails to get the tasks", func() {
+			BeforeEach(func() {
+				repo.TasksReturns(nil, errors.New("some tasks error"))
+			})
+
+			It("returns an error", func() {
+				Expect(manager.Archive()).To(MatchError("some tasks error"))
+			})
+		})
+	})
+
 	Describe("FindByID", func() {
 		var task *taskpkg.Task
 		BeforeEach(func() {
@@ -265,7 +398,7 @@ var _ = Describe("Manager", func() {
 				repo.TasksReturnsOnCall(0, tasksCopied, nil)
 			})
 
-			It("returns the tasks in order of highest priority and then newest id", func() {
+			It("returns the tasks in order of highest priority and then lowest id", func() {
 				tasksSorted, err := manager.Tasks()
 				Expect(err).NotTo(HaveOccurred())
 				Expect(tasksSorted).To(HaveLen(4))
@@ -277,6 +410,142 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("TasksMatching", func() {
+		var (
+			tasks []*taskpkg.Task
+			q     *query.Query
+		)
+		BeforeEach(func() {
+			tasks = []*taskpkg.Task{
+				&taskpkg.Task{Name: "task-a", Priority: 40, ID: 1, Tags: []string{"infra"}},
+				&taskpkg.Task{Name: "task-b", Priority: 30, ID: 2},
+				&taskpkg.Task{Name: "task-c", Priority: 20, ID: 3, Tags: []string{"infra"}},
+			}
+			repo.TasksReturnsOnCall(0, tasks, nil)
+
+			var err error
+			q, err = query.Parse("tag:infra")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns the tasks that match the query in order of highest priority", func() {
+			t, err := manager.TasksMatching(q)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t).To(Equal([]*taskpkg.Task{tasks[2], tasks[0]}))
+
+			Expect(repo.TasksCallCount()).To(Equal(1))
+		})
+
+		Context("when the repo fails to get the tasks", func() {
+			BeforeEach(func() {
+				repo.TasksReturnsOnCall(0, nil, errors.New("some tasks error"))
+			})
+
+			It("returns the error", func() {
+				_, err := manager.TasksMatching(q)
+				Expect(err).To(MatchError("some tasks error"))
+			})
+		})
+
+		Context("when the repo can find the tasks that match the query itself", func() {
+			var queryRepo *fakeQueryRepo
+			BeforeEach(func() {
+				queryRepo = &fakeQueryRepo{
+					FakeRepo: repo,
+					tasks:    []*taskpkg.Task{tasks[0], tasks[2]},
+				}
+				manager = managerpkg.New(queryRepo, clock)
+			})
+
+			It("asks the repo for the tasks that match the query", func() {
+				t, err := manager.TasksMatching(q)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(t).To(Equal([]*taskpkg.Task{tasks[2], tasks[0]}))
+
+				Expect(queryRepo.queries).To(Equal([]*query.Query{q}))
+				Expect(repo.TasksCallCount()).To(Equal(0))
+			})
+
+			Context("when the repo fails to find the tasks", func() {
+				BeforeEach(func() {
+					queryRepo.err = errors.New("some query error")
+				})
+
+				It("returns the error", func() {
+					_, err := manager.TasksMatching(q)
+					Expect(err).To(MatchError("some query error"))
+				})
+			})
+		})
+	})
+
+	Describe("Tasks with deadline scheduling", func() {
+		var tasks []*taskpkg.Task
+		BeforeEach(func() {
+			manager = managerpkg.New(repo, clock, managerpkg.WithScheduling(managerpkg.SchedulingDeadline))
+
+			day := time.Hour * 24
+			tasks = []*taskpkg.Task{
+				&taskpkg.Task{Name: "task-a", Priority: 10, ID: 1},
+				&taskpkg.Task{Name: "task-b", Priority: 12, ID: 2, Deadline: now.Add(day * 2).Unix()},
+				&taskpkg.Task{Name: "task-c", Priority: 10, ID: 3, Deadline: now.Add(day * 30).Unix()},
+				&taskpkg.Task{Name: "task-d", Priority: 14, ID: 4, Deadline: now.Add(-day).Unix()},
+				&taskpkg.Task{Name: "task-e", Priority: 11, ID: 5, Deadline: now.Add(-day).Unix(),
+					State: taskpkg.StateFinished},
+			}
+			tasksCopied := make([]*taskpkg.Task, len(tasks))
+			copy(tasksCopied, tasks)
+			repo.TasksReturnsOnCall(0, tasksCopied, nil)
+		})
+
+		It("returns the tasks in order of effective priority and then lowest id", func() {
+			tasksSorted, err := manager.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasksSorted).To(Equal([]*taskpkg.Task{
+				tasks[3], // 14 - 8 = 6
+				tasks[1], // 12 - 5 = 7
+				tasks[0], // 10
+				tasks[2], // 10, deadline far away
+				tasks[4], // 11, finished
+			}))
+		})
+	})
+
+	Describe("EffectivePriority", func() {
+		var task *taskpkg.Task
+		BeforeEach(func() {
+			task = &taskpkg.Task{Priority: 10}
+		})
+
+		It("returns the priority when there is no deadline", func() {
+			Expect(managerpkg.EffectivePriority(task, now)).To(Equal(10))
+		})
+
+		It("returns the priority when the deadline is more than a week away", func() {
+			task.Deadline = now.Add(time.Hour * 24 * 7).Unix()
+			Expect(managerpkg.EffectivePriority(task, now)).To(Equal(10))
+		})
+
+		It("lowers the priority by one for each day within a week of the deadline", func() {
+			task.Deadline = now.Add(time.Hour * 24 * 6).Unix()
+			Expect(managerpkg.EffectivePriority(task, now)).To(Equal(9))
+
+			task.Deadline = now.Add(time.Hour).Unix()
+			Expect(managerpkg.EffectivePriority(task, now)).To(Equal(3))
+		})
+
+		It("keeps lowering the priority for each day that the task is overdue", func() {
+			task.Deadline = now.Add(time.Hour * 24 * -3).Unix()
+			Expect(managerpkg.EffectivePriority(task, now)).To(Equal(0))
+		})
+
+		It("returns the priority when the task is finished", func() {
+			task.Deadline = now.Add(time.Hour * 24 * -3).Unix()
+			task.State = taskpkg.StateFinished
+			Expect(managerpkg.EffectivePriority(task, now)).To(Equal(10))
+		})
+	})
+
 	Describe("Note", func() {
 		BeforeEach(func() {
 			repo.FindTaskByNameReturnsOnCall(0, &taskpkg.Task{Name: "task-a", ID: 10}, nil)
@@ -294,6 +563,7 @@ var _ = Describe("Manager", func() {
 				Date:   clock.Now().Unix(),
 				Type:   taskpkg.EventTypeNote,
 				TaskID: 10,
+				Note:   "here is a note",
 			}))
 		})
 
@@ -355,10 +625,12 @@ var _ = Describe("Manager", func() {
 
 			Expect(repo.CreateEventCallCount()).To(Equal(1))
 			Expect(repo.CreateEventArgsForCall(0)).To(Equal(&taskpkg.Event{
-				Title:  "Set priority on task 'task-a' from 20 to 30",
-				Date:   clock.Now().Unix(),
-				Type:   taskpkg.EventTypeSetPriority,
-				TaskID: 10,
+				Title:    "Set priority on task 'task-a' from 20 to 30",
+				Date:     clock.Now().Unix(),
+				Type:     taskpkg.EventTypeSetPriority,
+				TaskID:   10,
+				OldValue: "20",
+				NewValue: "30",
 			}))
 
 			Expect(repo.UpdateTaskCallCount()).To(Equal(1))
@@ -441,10 +713,12 @@ var _ = Describe("Manager", func() {
 
 			Expect(repo.CreateEventCallCount()).To(Equal(1))
 			Expect(repo.CreateEventArgsForCall(0)).To(Equal(&taskpkg.Event{
-				Title:  "Set state on task 'task-a' from Running to Blocked",
-				Date:   clock.Now().Unix(),
-				Type:   taskpkg.EventTypeSetState,
-				TaskID: 10,
+				Title:    "Set state on task 'task-a' from Running to Blocked",
+				Date:     clock.Now().Unix(),
+				Type:     taskpkg.EventTypeSetState,
+				TaskID:   10,
+				OldValue: "Running",
+				NewValue: "Blocked",
 			}))
 
 			Expect(repo.UpdateTaskCallCount()).To(Equal(1))
@@ -481,6 +755,16 @@ var _ = Describe("Manager", func() {
 			})
 		})
 
+		Context("the state does not exist", func() {
+			It("returns an error without updating the task", func() {
+				err := manager.SetState("task-a", "Sleeping")
+				Expect(err).To(MatchError(managerpkg.InvalidStateError{State: "Sleeping"}))
+				Expect(err).To(MatchError("invalid state 'Sleeping'"))
+				Expect(repo.UpdateTaskCallCount()).To(Equal(0))
+				Expect(repo.CreateEventCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("the task cannot be updated", func() {
 			BeforeEach(func() {
 				repo.UpdateTaskReturnsOnCall(0, errors.New("some update task error"))
@@ -506,6 +790,249 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("SetDeadline", func() {
+		var deadline time.Time
+		BeforeEach(func() {
+			deadline = time.Date(2018, time.December, 25, 13, 30, 0, 0, time.Local)
+			repo.FindTaskByNameReturnsOnCall(0,
+				&taskpkg.Task{
+					Name:      "task-a",
+					ID:        10,
+					Priority:  20,
+					State:     taskpkg.StateRunning,
+					StartDate: 123,
+				},
+				nil)
+		})
+
+		It("updates the task and adds an event saying the deadline was updated", func() {
+			Expect(manager.SetDeadline("task-a", deadline.Unix())).To(Succeed())
+
+			Expect(repo.FindTaskByNameCallCount()).To(Equal(1))
+			Expect(repo.FindTaskByNameArgsForCall(0)).To(Equal("task-a"))
+
+			Expect(repo.CreateEventCallCount()).To(Equal(1))
+			Expect(repo.CreateEventArgsForCall(0)).To(Equal(&taskpkg.Event{
+				Title:    "Set deadline on task 'task-a' from none to 2018-12-25 13:30",
+				Date:     clock.Now().Unix(),
+				Type:     taskpkg.EventTypeSetDeadline,
+				TaskID:   10,
+				OldValue: "0",
+				NewValue: strconv.FormatInt(deadline.Unix(), 10),
+			}))
+
+			Expect(repo.UpdateTaskCallCount()).To(Equal(1))
+			Expect(repo.UpdateTaskArgsForCall(0)).To(Equal(&taskpkg.Task{
+				Name:      "task-a",
+				ID:        10,
+				Priority:  20,
+				State:     taskpkg.StateRunning,
+				StartDate: 123,
+				Deadline:  deadline.Unix(),
+			}))
+		})
+
+		Context("when the deadline is cleared", func() {
+			BeforeEach(func() {
+				repo.FindTaskByNameReturnsOnCall(0,
+					&taskpkg.Task{
+						Name:     "task-a",
+						ID:       10,
+						Deadline: deadline.Unix(),
+					},
+					nil)
+			})
+
+			It("updates the task and adds an event saying the deadline was cleared", func() {
+				Expect(manager.SetDeadline("task-a", 0)).To(Succeed())
+
+				Expect(repo.CreateEventCallCount()).To(Equal(1))
+				Expect(repo.CreateEventArgsForCall(0).Title).To(Equal(
+					"Set deadline on task 'task-a' from 2018-12-25 13:30 to none"))
+
+				Expect(repo.UpdateTaskCallCount()).To(Equal(1))
+				Expect(repo.UpdateTaskArgsForCall(0).Deadline).To(BeZero())
+			})
+		})
+
+		Context("the find by name call fails", func() {
+			BeforeEach(func() {
+				repo.FindTaskByNameReturnsOnCall(0, nil, errors.New("some find by name error"))
+			})
+
+			It("returns the error", func() {
+				err := manager.SetDeadline("task-a", deadline.Unix())
+				Expect(err).To(MatchError("some find by name error"))
+			})
+		})
+
+		Context("the task does not exist", func() {
+			BeforeEach(func() {
+				repo.FindTaskByNameReturnsOnCall(0, nil, nil)
+			})
+
+			It("returns the error", func() {
+				err := manager.SetDeadline("task-a", deadline.Unix())
+				Expect(err).To(MatchError("unknown task with name 'task-a'"))
+			})
+		})
+
+		Context("the task cannot be updated", func() {
+			BeforeEach(func() {
+				repo.UpdateTaskReturnsOnCall(0, errors.New("some update task error"))
+			})
+
+			It("returns the error", func() {
+				err := manager.SetDeadline("task-a", deadline.Unix())
+				Expect(err).To(MatchError("some update task error"))
+			})
+		})
+
+		Context("the event cannot be added", func() {
+			BeforeEach(func() {
+				repo.CreateEventReturnsOnCall(0, errors.New("some create event error"))
+			})
+
+			It("returns the error", func() {
+				err := manager.SetDeadline("task-a", deadline.Unix())
+				Expect(err).To(MatchError("some create event error"))
+			})
+		})
+	})
+
+	Describe("AddTag", func() {
+		BeforeEach(func() {
+			repo.FindTaskByNameReturnsOnCall(0,
+				&taskpkg.Task{
+					Name: "task-a",
+					ID:   10,
+					Tags: []string{"tag-c", "tag-e"},
+				},
+				nil)
+		})
+
+		It("updates the task with the tag in sorted order and adds an event", func() {
+			Expect(manager.AddTag("task-a", "tag-d")).To(Succeed())
+
+			Expect(repo.UpdateTaskCallCount()).To(Equal(1))
+			Expect(repo.UpdateTaskArgsForCall(0)).To(Equal(&taskpkg.Task{
+				Name: "task-a",
+				ID:   10,
+				Tags: []string{"tag-c", "tag-d", "tag-e"},
+			}))
+
+			Expect(repo.CreateEventCallCount()).To(Equal(1))
+			Expect(repo.CreateEventArgsForCall(0)).To(Equal(&taskpkg.Event{
+				Title:    "Added tag 'tag-d' to task 'task-a'",
+				Date:     now.Unix(),
+				Type:     taskpkg.EventTypeAddTag,
+				TaskID:   10,
+				NewValue: "tag-d",
+			}))
+		})
+
+		Context("when the task already has the tag", func() {
+			It("returns an error", func() {
+				err := manager.AddTag("task-a", "tag-c")
+				Expect(err).To(MatchError("task 'task-a' already has tag 'tag-c'"))
+				Expect(repo.UpdateTaskCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the tag is invalid", func() {
+			It("returns an error", func() {
+				Expect(manager.AddTag("task-a", "")).To(MatchError("invalid tag: ''"))
+				Expect(manager.AddTag("task-a", "tag a")).To(MatchError("invalid tag: 'tag a'"))
+				Expect(repo.FindTaskByNameCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the task does not exist", func() {
+			BeforeEach(func() {
+				repo.FindTaskByNameReturnsOnCall(0, nil, nil)
+			})
+
+			It("returns an error", func() {
+				err := manager.AddTag("task-a", "tag-d")
+				Expect(err).To(MatchError("unknown task with name 'task-a'"))
+			})
+		})
+
+		Context("when the task cannot be updated", func() {
+			BeforeEach(func() {
+				repo.UpdateTaskReturnsOnCall(0, errors.New("some update task error"))
+			})
+
+			It("returns the error", func() {
+				err := manager.AddTag("task-a", "tag-d")
+				Expect(err).To(MatchError("some update task error"))
+				Expect(repo.CreateEventCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the event cannot be added", func() {
+			BeforeEach(func() {
+				repo.CreateEventReturnsOnCall(0, errors.New("some create event error"))
+			})
+
+			It("returns the error", func() {
+				err := manager.AddTag("task-a", "tag-d")
+				Expect(err).To(MatchError("some create event error"))
+			})
+		})
+	})
+
+	Describe("RemoveTag", func() {
+		BeforeEach(func() {
+			repo.FindTaskByNameReturnsOnCall(0,
+				&taskpkg.Task{
+					Name: "task-a",
+					ID:   10,
+					Tags: []string{"tag-c", "tag-e"},
+				},
+				nil)
+		})
+
+		It("updates the task without the tag and adds an event", func() {
+			Expect(manager.RemoveTag("task-a", "tag-c")).To(Succeed())
+
+			Expect(repo.UpdateTaskCallCount()).To(Equal(1))
+			Expect(repo.UpdateTaskArgsForCall(0)).To(Equal(&taskpkg.Task{
+				Name: "task-a",
+				ID:   10,
+				Tags: []string{"tag-e"},
+			}))
+
+			Expect(repo.CreateEventCallCount()).To(Equal(1))
+			Expect(repo.CreateEventArgsForCall(0)).To(Equal(&taskpkg.Event{
+				Title:    "Removed tag 'tag-c' from task 'task-a'",
+				Date:     now.Unix(),
+				Type:     taskpkg.EventTypeRemoveTag,
+				TaskID:   10,
+				OldValue: "tag-c",
+			}))
+		})
+
+		Context("when the task does not have the tag", func() {
+			It("returns an error", func() {
+				err := manager.RemoveTag("task-a", "tag-d")
+				Expect(err).To(MatchError("task 'task-a' does not have tag 'tag-d'"))
+				Expect(repo.UpdateTaskCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the task cannot be updated", func() {
+			BeforeEach(func() {
+				repo.UpdateTaskReturnsOnCall(0, errors.New("some update task error"))
+			})
+
+			It("returns the error", func() {
+				err := manager.RemoveTag("task-a", "tag-c")
+				Expect(err).To(MatchError("some update task error"))
+			})
+		})
+	})
+
 	Describe("Events", func() {
 		var events []*taskpkg.Event
 		BeforeEach(func() {
@@ -538,6 +1065,38 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("EventsMatching", func() {
+		var events []*taskpkg.Event
+		BeforeEach(func() {
+			events = []*taskpkg.Event{
+				&taskpkg.Event{Title: "event-a", ID: 1, TaskID: 1},
+				&taskpkg.Event{Title: "event-b", ID: 2, TaskID: 2},
+				&taskpkg.Event{Title: "event-c", ID: 3, TaskID: 1},
+			}
+			repo.EventsReturnsOnCall(0, events, nil)
+		})
+
+		It("returns the events that the query selects", func() {
+			taskID := 1
+			e, err := manager.EventsMatching(&query.EventQuery{TaskID: &taskID, Order: query.OrderDescending})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(e).To(Equal([]*taskpkg.Event{events[2], events[0]}))
+
+			Expect(repo.EventsCallCount()).To(Equal(1))
+		})
+
+		Context("when the repo fails to get the events", func() {
+			BeforeEach(func() {
+				repo.EventsReturnsOnCall(0, nil, errors.New("some events error"))
+			})
+
+			It("returns the error", func() {
+				_, err := manager.EventsMatching(&query.EventQuery{})
+				Expect(err).To(MatchError("some events error"))
+			})
+		})
+	})
+
 	Describe("Reset", func() {
 		var tasks []*taskpkg.Task
 		var tasksSize int
@@ -578,6 +1137,35 @@ var _ = Describe("Manager", func() {
 			}
 		})
 
+		It("adds an event that records everything that was reset", func() {
+			Expect(manager.Reset()).To(Succeed())
+
+			Expect(repo.CreateEventCallCount()).To(Equal(1))
+			event := repo.CreateEventArgsForCall(0)
+			Expect(event.Title).To(Equal("Reset 3 tasks"))
+			Expect(event.Type).To(BeEquivalentTo(taskpkg.EventTypeReset))
+			Expect(event.TaskID).To(Equal(taskpkg.NoTaskID))
+
+			var snapshot struct {
+				Tasks  []*taskpkg.Task  `json:"tasks"`
+				Events []*taskpkg.Event `json:"events"`
+			}
+			Expect(json.Unmarshal([]byte(event.Snapshot), &snapshot)).To(Succeed())
+			Expect(snapshot.Tasks).To(Equal(tasks))
+			Expect(snapshot.Events).To(Equal(events))
+		})
+
+		Context("when a delete fails", func() {
+			BeforeEach(func() {
+				repo.DeleteTaskReturnsOnCall(0, errors.New("some delete task error"))
+			})
+
+			It("does not add an event", func() {
+				Expect(manager.Reset()).NotTo(Succeed())
+				Expect(repo.CreateEventCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("getting the tasks fails", func() {
 			BeforeEach(func() {
 				repo.TasksReturnsOnCall(0, nil, errors.New("some tasks error"))
@@ -613,28 +1201,14 @@ var _ = Describe("Manager", func() {
 		Context("at least one of the deletes fails", func() {
 			BeforeEach(func() {
 				repo.DeleteTaskReturnsOnCall(2, errors.New("some delete task error"))
-				repo.DeleteEventReturnsOnCall(1, errors.New("some delete event error"))
 			})
 
-			It("does its best to delete the stuff it can and returns an error", func() {
-				err := manager.Reset()
-				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("some delete task error"))
-				Expect(err.Error()).To(ContainSubstring("some delete event error"))
+			It("stops and returns the error", func() {
+				Expect(manager.Reset()).To(MatchError("some delete task error"))
 
-				Expect(repo.TasksCallCount()).To(Equal(1))
-				j := 0
-				for i := tasksSize - 1; i >= 0; i-- {
-					Expect(repo.DeleteTaskArgsForCall(j)).To(Equal(tasks[i]))
-					j++
-				}
-
-				Expect(repo.EventsCallCount()).To(Equal(1))
-				j = 0
-				for i := eventsSize - 1; i >= 0; i-- {
-					Expect(repo.DeleteEventArgsForCall(j)).To(Equal(events[i]))
-					j++
-				}
+				Expect(repo.DeleteTaskCallCount()).To(Equal(3))
+				Expect(repo.DeleteEventCallCount()).To(Equal(0))
+				Expect(repo.CreateEventCallCount()).To(Equal(0))
 			})
 		})
 	})
@@ -668,6 +1242,20 @@ var _ = Describe("Manager", func() {
 			}))
 		})
 
+		It("adds an event that the task was renamed", func() {
+			Expect(manager.Rename("task-a", "new-task-a")).To(Succeed())
+
+			Expect(repo.CreateEventCallCount()).To(Equal(1))
+			Expect(repo.CreateEventArgsForCall(0)).To(Equal(&taskpkg.Event{
+				Title:    "Renamed task 'task-a' to 'new-task-a'",
+				Date:     now.Unix(),
+				Type:     taskpkg.EventTypeRename,
+				TaskID:   10,
+				OldValue: "task-a",
+				NewValue: "new-task-a",
+			}))
+		})
+
 		Context("the find by name call fails", func() {
 			BeforeEach(func() {
 				repo.FindTaskByNameReturnsOnCall(0, nil, errors.New("some find by name error"))
@@ -705,3 +1293,23 @@ var _ = Describe("Manager", func() {
 		})
 	})
 })
+
+// fakeQueryRepo is a task.Repo that also implements query.Repo.
+type fakeQueryRepo struct {
+	*taskfakes.FakeRepo
+
+	tasks   []*taskpkg.Task
+	err     error
+	queries []*query.Query
+}
+
+func (r *fakeQueryRepo) TasksMatching(q *query.Query) ([]*taskpkg.Task, error) {
+	r.queries = append(r.queries, q)
+	return r.tasks, r.err
+}
+
+// fakeTransactorRepo is a task.Repo that also implements task.Transactor.
+type fakeTransactorRepo struct {
+	*taskfakes.FakeRepo
+	*taskfakes.FakeTransactor
+}
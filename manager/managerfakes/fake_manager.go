@@ -5,10 +5,45 @@ import (
 	sync "sync"
 
 	manager "github.com/ankeesler/anwork/manager"
+	query "github.com/ankeesler/anwork/query"
 	task "github.com/ankeesler/anwork/task"
 )
 
 type FakeManager struct {
+	AddDependencyStub        func(string, string) error
+	addDependencyMutex       sync.RWMutex
+	addDependencyArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	addDependencyReturns struct {
+		result1 error
+	}
+	addDependencyReturnsOnCall map[int]struct {
+		result1 error
+	}
+	AddTagStub        func(string, string) error
+	addTagMutex       sync.RWMutex
+	addTagArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	addTagReturns struct {
+		result1 error
+	}
+	addTagReturnsOnCall map[int]struct {
+		result1 error
+	}
+	ArchiveStub        func() error
+	archiveMutex       sync.RWMutex
+	archiveArgsForCall []struct {
+	}
+	archiveReturns struct {
+		result1 error
+	}
+	archiveReturnsOnCall map[int]struct {
+		result1 error
+	}
 	CreateStub        func(string) error
 	createMutex       sync.RWMutex
 	createArgsForCall []struct {
@@ -31,6 +66,19 @@ type FakeManager struct {
 	deleteReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DependenciesStub        func(string) ([]*task.Task, error)
+	dependenciesMutex       sync.RWMutex
+	dependenciesArgsForCall []struct {
+		arg1 string
+	}
+	dependenciesReturns struct {
+		result1 []*task.Task
+		result2 error
+	}
+	dependenciesReturnsOnCall map[int]struct {
+		result1 []*task.Task
+		result2 error
+	}
 	EventsStub        func() ([]*task.Event, error)
 	eventsMutex       sync.RWMutex
 	eventsArgsForCall []struct {
@@ -43,6 +91,19 @@ type FakeManager struct {
 		result1 []*task.Event
 		result2 error
 	}
+	EventsMatchingStub        func(*query.EventQuery) ([]*task.Event, error)
+	eventsMatchingMutex       sync.RWMutex
+	eventsMatchingArgsForCall []struct {
+		arg1 *query.EventQuery
+	}
+	eventsMatchingReturns struct {
+		result1 []*task.Event
+		result2 error
+	}
+	eventsMatchingReturnsOnCall map[int]struct {
+		result1 []*task.Event
+		result2 error
+	}
 	FindByIDStub        func(int) (*task.Task, error)
 	findByIDMutex       sync.RWMutex
 	findByIDArgsForCall []struct {
@@ -81,6 +142,40 @@ type FakeManager struct {
 	noteReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RedoStub        func() error
+	redoMutex       sync.RWMutex
+	redoArgsForCall []struct {
+	}
+	redoReturns struct {
+		result1 error
+	}
+	redoReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RemoveDependencyStub        func(string, string) error
+	removeDependencyMutex       sync.RWMutex
+	removeDependencyArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	removeDependencyReturns struct {
+		result1 error
+	}
+	removeDependencyReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RemoveTagStub        func(string, string) error
+	removeTagMutex       sync.RWMutex
+	removeTagArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	removeTagReturns struct {
+		result1 error
+	}
+	removeTagReturnsOnCall map[int]struct {
+		result1 error
+	}
 	RenameStub        func(string, string) error
 	renameMutex       sync.RWMutex
 	renameArgsForCall []struct {
@@ -93,6 +188,18 @@ type FakeManager struct {
 	renameReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ReplayStub        func() ([]string, error)
+	replayMutex       sync.RWMutex
+	replayArgsForCall []struct {
+	}
+	replayReturns struct {
+		result1 []string
+		result2 error
+	}
+	replayReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
 	ResetStub        func() error
 	resetMutex       sync.RWMutex
 	resetArgsForCall []struct {
@@ -103,6 +210,18 @@ type FakeManager struct {
 	resetReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetDeadlineStub        func(string, int64) error
+	setDeadlineMutex       sync.RWMutex
+	setDeadlineArgsForCall []struct {
+		arg1 string
+		arg2 int64
+	}
+	setDeadlineReturns struct {
+		result1 error
+	}
+	setDeadlineReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetPriorityStub        func(string, int) error
 	setPriorityMutex       sync.RWMutex
 	setPriorityArgsForCall []struct {
@@ -139,10 +258,208 @@ type FakeManager struct {
 		result1 []*task.Task
 		result2 error
 	}
+	TasksMatchingStub        func(*query.Query) ([]*task.Task, error)
+	tasksMatchingMutex       sync.RWMutex
+	tasksMatchingArgsForCall []struct {
+		arg1 *query.Query
+	}
+	tasksMatchingReturns struct {
+		result1 []*task.Task
+		result2 error
+	}
+	tasksMatchingReturnsOnCall map[int]struct {
+		result1 []*task.Task
+		result2 error
+	}
+	UndoStub        func(int) error
+	undoMutex       sync.RWMutex
+	undoArgsForCall []struct {
+		arg1 int
+	}
+	undoReturns struct {
+		result1 error
+	}
+	undoReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeManager) AddDependency(arg1 string, arg2 string) error {
+	fake.addDependencyMutex.Lock()
+	ret, specificReturn := fake.addDependencyReturnsOnCall[len(fake.addDependencyArgsForCall)]
+	fake.addDependencyArgsForCall = append(fake.addDependencyArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("AddDependency", []interface{}{arg1, arg2})
+	fake.addDependencyMutex.Unlock()
+	if fake.AddDependencyStub != nil {
+		return fake.AddDependencyStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.addDependencyReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) AddDependencyCallCount() int {
+	fake.addDependencyMutex.RLock()
+	defer fake.addDependencyMutex.RUnlock()
+	return len(fake.addDependencyArgsForCall)
+}
+
+func (fake *FakeManager) AddDependencyCalls(stub func(string, string) error) {
+	fake.addDependencyMutex.Lock()
+	defer fake.addDependencyMutex.Unlock()
+	fake.AddDependencyStub = stub
+}
+
+func (fake *FakeManager) AddDependencyArgsForCall(i int) (string, string) {
+	fake.addDependencyMutex.RLock()
+	defer fake.addDependencyMutex.RUnlock()
+	argsForCall := fake.addDependencyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeManager) AddDependencyReturns(result1 error) {
+	fake.addDependencyMutex.Lock()
+	defer fake.addDependencyMutex.Unlock()
+	fake.AddDependencyStub = nil
+	fake.addDependencyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) AddDependencyReturnsOnCall(i int, result1 error) {
+	fake.addDependencyMutex.Lock()
+	defer fake.addDependencyMutex.Unlock()
+	fake.AddDependencyStub = nil
+	if fake.addDependencyReturnsOnCall == nil {
+		fake.addDependencyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addDependencyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) AddTag(arg1 string, arg2 string) error {
+	fake.addTagMutex.Lock()
+	ret, specificReturn := fake.addTagReturnsOnCall[len(fake.addTagArgsForCall)]
+	fake.addTagArgsForCall = append(fake.addTagArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("AddTag", []interface{}{arg1, arg2})
+	fake.addTagMutex.Unlock()
+	if fake.AddTagStub != nil {
+		return fake.AddTagStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.addTagReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) AddTagCallCount() int {
+	fake.addTagMutex.RLock()
+	defer fake.addTagMutex.RUnlock()
+	return len(fake.addTagArgsForCall)
+}
+
+func (fake *FakeManager) AddTagCalls(stub func(string, string) error) {
+	fake.addTagMutex.Lock()
+	defer fake.addTagMutex.Unlock()
+	fake.AddTagStub = stub
+}
+
+func (fake *FakeManager) AddTagArgsForCall(i int) (string, string) {
+	fake.addTagMutex.RLock()
+	defer fake.addTagMutex.RUnlock()
+	argsForCall := fake.addTagArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeManager) AddTagReturns(result1 error) {
+	fake.addTagMutex.Lock()
+	defer fake.addTagMutex.Unlock()
+	fake.AddTagStub = nil
+	fake.addTagReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) AddTagReturnsOnCall(i int, result1 error) {
+	fake.addTagMutex.Lock()
+	defer fake.addTagMutex.Unlock()
+	fake.AddTagStub = nil
+	if fake.addTagReturnsOnCall == nil {
+		fake.addTagReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addTagReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) Archive() error {
+	fake.archiveMutex.Lock()
+	ret, specificReturn := fake.archiveReturnsOnCall[len(fake.archiveArgsForCall)]
+	fake.archiveArgsForCall = append(fake.archiveArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Archive", []interface{}{})
+	fake.archiveMutex.Unlock()
+	if fake.ArchiveStub != nil {
+		return fake.ArchiveStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.archiveReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) ArchiveCallCount() int {
+	fake.archiveMutex.RLock()
+	defer fake.archiveMutex.RUnlock()
+	return len(fake.archiveArgsForCall)
+}
+
+func (fake *FakeManager) ArchiveCalls(stub func() error) {
+	fake.archiveMutex.Lock()
+	defer fake.archiveMutex.Unlock()
+	fake.ArchiveStub = stub
+}
+
+func (fake *FakeManager) ArchiveReturns(result1 error) {
+	fake.archiveMutex.Lock()
+	defer fake.archiveMutex.Unlock()
+	fake.ArchiveStub = nil
+	fake.archiveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) ArchiveReturnsOnCall(i int, result1 error) {
+	fake.archiveMutex.Lock()
+	defer fake.archiveMutex.Unlock()
+	fake.ArchiveStub = nil
+	if fake.archiveReturnsOnCall == nil {
+		fake.archiveReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.archiveReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeManager) Create(arg1 string) error {
 	fake.createMutex.Lock()
 	ret, specificReturn := fake.createReturnsOnCall[len(fake.createArgsForCall)]
@@ -263,6 +580,69 @@ func (fake *FakeManager) DeleteReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeManager) Dependencies(arg1 string) ([]*task.Task, error) {
+	fake.dependenciesMutex.Lock()
+	ret, specificReturn := fake.dependenciesReturnsOnCall[len(fake.dependenciesArgsForCall)]
+	fake.dependenciesArgsForCall = append(fake.dependenciesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Dependencies", []interface{}{arg1})
+	fake.dependenciesMutex.Unlock()
+	if fake.DependenciesStub != nil {
+		return fake.DependenciesStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.dependenciesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) DependenciesCallCount() int {
+	fake.dependenciesMutex.RLock()
+	defer fake.dependenciesMutex.RUnlock()
+	return len(fake.dependenciesArgsForCall)
+}
+
+func (fake *FakeManager) DependenciesCalls(stub func(string) ([]*task.Task, error)) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = stub
+}
+
+func (fake *FakeManager) DependenciesArgsForCall(i int) string {
+	fake.dependenciesMutex.RLock()
+	defer fake.dependenciesMutex.RUnlock()
+	argsForCall := fake.dependenciesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeManager) DependenciesReturns(result1 []*task.Task, result2 error) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = nil
+	fake.dependenciesReturns = struct {
+		result1 []*task.Task
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) DependenciesReturnsOnCall(i int, result1 []*task.Task, result2 error) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = nil
+	if fake.dependenciesReturnsOnCall == nil {
+		fake.dependenciesReturnsOnCall = make(map[int]struct {
+			result1 []*task.Task
+			result2 error
+		})
+	}
+	fake.dependenciesReturnsOnCall[i] = struct {
+		result1 []*task.Task
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeManager) Events() ([]*task.Event, error) {
 	fake.eventsMutex.Lock()
 	ret, specificReturn := fake.eventsReturnsOnCall[len(fake.eventsArgsForCall)]
@@ -318,6 +698,69 @@ func (fake *FakeManager) EventsReturnsOnCall(i int, result1 []*task.Event, resul
 	}{result1, result2}
 }
 
+func (fake *FakeManager) EventsMatching(arg1 *query.EventQuery) ([]*task.Event, error) {
+	fake.eventsMatchingMutex.Lock()
+	ret, specificReturn := fake.eventsMatchingReturnsOnCall[len(fake.eventsMatchingArgsForCall)]
+	fake.eventsMatchingArgsForCall = append(fake.eventsMatchingArgsForCall, struct {
+		arg1 *query.EventQuery
+	}{arg1})
+	fake.recordInvocation("EventsMatching", []interface{}{arg1})
+	fake.eventsMatchingMutex.Unlock()
+	if fake.EventsMatchingStub != nil {
+		return fake.EventsMatchingStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.eventsMatchingReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) EventsMatchingCallCount() int {
+	fake.eventsMatchingMutex.RLock()
+	defer fake.eventsMatchingMutex.RUnlock()
+	return len(fake.eventsMatchingArgsForCall)
+}
+
+func (fake *FakeManager) EventsMatchingCalls(stub func(*query.EventQuery) ([]*task.Event, error)) {
+	fake.eventsMatchingMutex.Lock()
+	defer fake.eventsMatchingMutex.Unlock()
+	fake.EventsMatchingStub = stub
+}
+
+func (fake *FakeManager) EventsMatchingArgsForCall(i int) *query.EventQuery {
+	fake.eventsMatchingMutex.RLock()
+	defer fake.eventsMatchingMutex.RUnlock()
+	argsForCall := fake.eventsMatchingArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeManager) EventsMatchingReturns(result1 []*task.Event, result2 error) {
+	fake.eventsMatchingMutex.Lock()
+	defer fake.eventsMatchingMutex.Unlock()
+	fake.EventsMatchingStub = nil
+	fake.eventsMatchingReturns = struct {
+		result1 []*task.Event
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) EventsMatchingReturnsOnCall(i int, result1 []*task.Event, result2 error) {
+	fake.eventsMatchingMutex.Lock()
+	defer fake.eventsMatchingMutex.Unlock()
+	fake.EventsMatchingStub = nil
+	if fake.eventsMatchingReturnsOnCall == nil {
+		fake.eventsMatchingReturnsOnCall = make(map[int]struct {
+			result1 []*task.Event
+			result2 error
+		})
+	}
+	fake.eventsMatchingReturnsOnCall[i] = struct {
+		result1 []*task.Event
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeManager) FindByID(arg1 int) (*task.Task, error) {
 	fake.findByIDMutex.Lock()
 	ret, specificReturn := fake.findByIDReturnsOnCall[len(fake.findByIDArgsForCall)]
@@ -505,6 +948,180 @@ func (fake *FakeManager) NoteReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeManager) Redo() error {
+	fake.redoMutex.Lock()
+	ret, specificReturn := fake.redoReturnsOnCall[len(fake.redoArgsForCall)]
+	fake.redoArgsForCall = append(fake.redoArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Redo", []interface{}{})
+	fake.redoMutex.Unlock()
+	if fake.RedoStub != nil {
+		return fake.RedoStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.redoReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) RedoCallCount() int {
+	fake.redoMutex.RLock()
+	defer fake.redoMutex.RUnlock()
+	return len(fake.redoArgsForCall)
+}
+
+func (fake *FakeManager) RedoCalls(stub func() error) {
+	fake.redoMutex.Lock()
+	defer fake.redoMutex.Unlock()
+	fake.RedoStub = stub
+}
+
+func (fake *FakeManager) RedoReturns(result1 error) {
+	fake.redoMutex.Lock()
+	defer fake.redoMutex.Unlock()
+	fake.RedoStub = nil
+	fake.redoReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) RedoReturnsOnCall(i int, result1 error) {
+	fake.redoMutex.Lock()
+	defer fake.redoMutex.Unlock()
+	fake.RedoStub = nil
+	if fake.redoReturnsOnCall == nil {
+		fake.redoReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.redoReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) RemoveDependency(arg1 string, arg2 string) error {
+	fake.removeDependencyMutex.Lock()
+	ret, specificReturn := fake.removeDependencyReturnsOnCall[len(fake.removeDependencyArgsForCall)]
+	fake.removeDependencyArgsForCall = append(fake.removeDependencyArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("RemoveDependency", []interface{}{arg1, arg2})
+	fake.removeDependencyMutex.Unlock()
+	if fake.RemoveDependencyStub != nil {
+		return fake.RemoveDependencyStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.removeDependencyReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) RemoveDependencyCallCount() int {
+	fake.removeDependencyMutex.RLock()
+	defer fake.removeDependencyMutex.RUnlock()
+	return len(fake.removeDependencyArgsForCall)
+}
+
+func (fake *FakeManager) RemoveDependencyCalls(stub func(string, string) error) {
+	fake.removeDependencyMutex.Lock()
+	defer fake.removeDependencyMutex.Unlock()
+	fake.RemoveDependencyStub = stub
+}
+
+func (fake *FakeManager) RemoveDependencyArgsForCall(i int) (string, string) {
+	fake.removeDependencyMutex.RLock()
+	defer fake.removeDependencyMutex.RUnlock()
+	argsForCall := fake.removeDependencyArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeManager) RemoveDependencyReturns(result1 error) {
+	fake.removeDependencyMutex.Lock()
+	defer fake.removeDependencyMutex.Unlock()
+	fake.RemoveDependencyStub = nil
+	fake.removeDependencyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) RemoveDependencyReturnsOnCall(i int, result1 error) {
+	fake.removeDependencyMutex.Lock()
+	defer fake.removeDependencyMutex.Unlock()
+	fake.RemoveDependencyStub = nil
+	if fake.removeDependencyReturnsOnCall == nil {
+		fake.removeDependencyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeDependencyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) RemoveTag(arg1 string, arg2 string) error {
+	fake.removeTagMutex.Lock()
+	ret, specificReturn := fake.removeTagReturnsOnCall[len(fake.removeTagArgsForCall)]
+	fake.removeTagArgsForCall = append(fake.removeTagArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("RemoveTag", []interface{}{arg1, arg2})
+	fake.removeTagMutex.Unlock()
+	if fake.RemoveTagStub != nil {
+		return fake.RemoveTagStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.removeTagReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) RemoveTagCallCount() int {
+	fake.removeTagMutex.RLock()
+	defer fake.removeTagMutex.RUnlock()
+	return len(fake.removeTagArgsForCall)
+}
+
+func (fake *FakeManager) RemoveTagCalls(stub func(string, string) error) {
+	fake.removeTagMutex.Lock()
+	defer fake.removeTagMutex.Unlock()
+	fake.RemoveTagStub = stub
+}
+
+func (fake *FakeManager) RemoveTagArgsForCall(i int) (string, string) {
+	fake.removeTagMutex.RLock()
+	defer fake.removeTagMutex.RUnlock()
+	argsForCall := fake.removeTagArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeManager) RemoveTagReturns(result1 error) {
+	fake.removeTagMutex.Lock()
+	defer fake.removeTagMutex.Unlock()
+	fake.RemoveTagStub = nil
+	fake.removeTagReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) RemoveTagReturnsOnCall(i int, result1 error) {
+	fake.removeTagMutex.Lock()
+	defer fake.removeTagMutex.Unlock()
+	fake.RemoveTagStub = nil
+	if fake.removeTagReturnsOnCall == nil {
+		fake.removeTagReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.removeTagReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeManager) Rename(arg1 string, arg2 string) error {
 	fake.renameMutex.Lock()
 	ret, specificReturn := fake.renameReturnsOnCall[len(fake.renameArgsForCall)]
@@ -566,6 +1183,61 @@ func (fake *FakeManager) RenameReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeManager) Replay() ([]string, error) {
+	fake.replayMutex.Lock()
+	ret, specificReturn := fake.replayReturnsOnCall[len(fake.replayArgsForCall)]
+	fake.replayArgsForCall = append(fake.replayArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Replay", []interface{}{})
+	fake.replayMutex.Unlock()
+	if fake.ReplayStub != nil {
+		return fake.ReplayStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.replayReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) ReplayCallCount() int {
+	fake.replayMutex.RLock()
+	defer fake.replayMutex.RUnlock()
+	return len(fake.replayArgsForCall)
+}
+
+func (fake *FakeManager) ReplayCalls(stub func() ([]string, error)) {
+	fake.replayMutex.Lock()
+	defer fake.replayMutex.Unlock()
+	fake.ReplayStub = stub
+}
+
+func (fake *FakeManager) ReplayReturns(result1 []string, result2 error) {
+	fake.replayMutex.Lock()
+	defer fake.replayMutex.Unlock()
+	fake.ReplayStub = nil
+	fake.replayReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) ReplayReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.replayMutex.Lock()
+	defer fake.replayMutex.Unlock()
+	fake.ReplayStub = nil
+	if fake.replayReturnsOnCall == nil {
+		fake.replayReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.replayReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeManager) Reset() error {
 	fake.resetMutex.Lock()
 	ret, specificReturn := fake.resetReturnsOnCall[len(fake.resetArgsForCall)]
@@ -618,6 +1290,67 @@ func (fake *FakeManager) ResetReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeManager) SetDeadline(arg1 string, arg2 int64) error {
+	fake.setDeadlineMutex.Lock()
+	ret, specificReturn := fake.setDeadlineReturnsOnCall[len(fake.setDeadlineArgsForCall)]
+	fake.setDeadlineArgsForCall = append(fake.setDeadlineArgsForCall, struct {
+		arg1 string
+		arg2 int64
+	}{arg1, arg2})
+	fake.recordInvocation("SetDeadline", []interface{}{arg1, arg2})
+	fake.setDeadlineMutex.Unlock()
+	if fake.SetDeadlineStub != nil {
+		return fake.SetDeadlineStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.setDeadlineReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) SetDeadlineCallCount() int {
+	fake.setDeadlineMutex.RLock()
+	defer fake.setDeadlineMutex.RUnlock()
+	return len(fake.setDeadlineArgsForCall)
+}
+
+func (fake *FakeManager) SetDeadlineCalls(stub func(string, int64) error) {
+	fake.setDeadlineMutex.Lock()
+	defer fake.setDeadlineMutex.Unlock()
+	fake.SetDeadlineStub = stub
+}
+
+func (fake *FakeManager) SetDeadlineArgsForCall(i int) (string, int64) {
+	fake.setDeadlineMutex.RLock()
+	defer fake.setDeadlineMutex.RUnlock()
+	argsForCall := fake.setDeadlineArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeManager) SetDeadlineReturns(result1 error) {
+	fake.setDeadlineMutex.Lock()
+	defer fake.setDeadlineMutex.Unlock()
+	fake.SetDeadlineStub = nil
+	fake.setDeadlineReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) SetDeadlineReturnsOnCall(i int, result1 error) {
+	fake.setDeadlineMutex.Lock()
+	defer fake.setDeadlineMutex.Unlock()
+	fake.SetDeadlineStub = nil
+	if fake.setDeadlineReturnsOnCall == nil {
+		fake.setDeadlineReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setDeadlineReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeManager) SetPriority(arg1 string, arg2 int) error {
 	fake.setPriorityMutex.Lock()
 	ret, specificReturn := fake.setPriorityReturnsOnCall[len(fake.setPriorityArgsForCall)]
@@ -795,31 +1528,178 @@ func (fake *FakeManager) TasksReturnsOnCall(i int, result1 []*task.Task, result2
 	}{result1, result2}
 }
 
+func (fake *FakeManager) TasksMatching(arg1 *query.Query) ([]*task.Task, error) {
+	fake.tasksMatchingMutex.Lock()
+	ret, specificReturn := fake.tasksMatchingReturnsOnCall[len(fake.tasksMatchingArgsForCall)]
+	fake.tasksMatchingArgsForCall = append(fake.tasksMatchingArgsForCall, struct {
+		arg1 *query.Query
+	}{arg1})
+	fake.recordInvocation("TasksMatching", []interface{}{arg1})
+	fake.tasksMatchingMutex.Unlock()
+	if fake.TasksMatchingStub != nil {
+		return fake.TasksMatchingStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.tasksMatchingReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeManager) TasksMatchingCallCount() int {
+	fake.tasksMatchingMutex.RLock()
+	defer fake.tasksMatchingMutex.RUnlock()
+	return len(fake.tasksMatchingArgsForCall)
+}
+
+func (fake *FakeManager) TasksMatchingCalls(stub func(*query.Query) ([]*task.Task, error)) {
+	fake.tasksMatchingMutex.Lock()
+	defer fake.tasksMatchingMutex.Unlock()
+	fake.TasksMatchingStub = stub
+}
+
+func (fake *FakeManager) TasksMatchingArgsForCall(i int) *query.Query {
+	fake.tasksMatchingMutex.RLock()
+	defer fake.tasksMatchingMutex.RUnlock()
+	argsForCall := fake.tasksMatchingArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeManager) TasksMatchingReturns(result1 []*task.Task, result2 error) {
+	fake.tasksMatchingMutex.Lock()
+	defer fake.tasksMatchingMutex.Unlock()
+	fake.TasksMatchingStub = nil
+	fake.tasksMatchingReturns = struct {
+		result1 []*task.Task
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) TasksMatchingReturnsOnCall(i int, result1 []*task.Task, result2 error) {
+	fake.tasksMatchingMutex.Lock()
+	defer fake.tasksMatchingMutex.Unlock()
+	fake.TasksMatchingStub = nil
+	if fake.tasksMatchingReturnsOnCall == nil {
+		fake.tasksMatchingReturnsOnCall = make(map[int]struct {
+			result1 []*task.Task
+			result2 error
+		})
+	}
+	fake.tasksMatchingReturnsOnCall[i] = struct {
+		result1 []*task.Task
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeManager) Undo(arg1 int) error {
+	fake.undoMutex.Lock()
+	ret, specificReturn := fake.undoReturnsOnCall[len(fake.undoArgsForCall)]
+	fake.undoArgsForCall = append(fake.undoArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	fake.recordInvocation("Undo", []interface{}{arg1})
+	fake.undoMutex.Unlock()
+	if fake.UndoStub != nil {
+		return fake.UndoStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.undoReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeManager) UndoCallCount() int {
+	fake.undoMutex.RLock()
+	defer fake.undoMutex.RUnlock()
+	return len(fake.undoArgsForCall)
+}
+
+func (fake *FakeManager) UndoCalls(stub func(int) error) {
+	fake.undoMutex.Lock()
+	defer fake.undoMutex.Unlock()
+	fake.UndoStub = stub
+}
+
+func (fake *FakeManager) UndoArgsForCall(i int) int {
+	fake.undoMutex.RLock()
+	defer fake.undoMutex.RUnlock()
+	argsForCall := fake.undoArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeManager) UndoReturns(result1 error) {
+	fake.undoMutex.Lock()
+	defer fake.undoMutex.Unlock()
+	fake.UndoStub = nil
+	fake.undoReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeManager) UndoReturnsOnCall(i int, result1 error) {
+	fake.undoMutex.Lock()
+	defer fake.undoMutex.Unlock()
+	fake.UndoStub = nil
+	if fake.undoReturnsOnCall == nil {
+		fake.undoReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.undoReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeManager) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.addDependencyMutex.RLock()
+	defer fake.addDependencyMutex.RUnlock()
+	fake.addTagMutex.RLock()
+	defer fake.addTagMutex.RUnlock()
+	fake.archiveMutex.RLock()
+	defer fake.archiveMutex.RUnlock()
 	fake.createMutex.RLock()
 	defer fake.createMutex.RUnlock()
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
+	fake.dependenciesMutex.RLock()
+	defer fake.dependenciesMutex.RUnlock()
 	fake.eventsMutex.RLock()
 	defer fake.eventsMutex.RUnlock()
+	fake.eventsMatchingMutex.RLock()
+	defer fake.eventsMatchingMutex.RUnlock()
 	fake.findByIDMutex.RLock()
 	defer fake.findByIDMutex.RUnlock()
 	fake.findByNameMutex.RLock()
 	defer fake.findByNameMutex.RUnlock()
 	fake.noteMutex.RLock()
 	defer fake.noteMutex.RUnlock()
+	fake.redoMutex.RLock()
+	defer fake.redoMutex.RUnlock()
+	fake.removeDependencyMutex.RLock()
+	defer fake.removeDependencyMutex.RUnlock()
+	fake.removeTagMutex.RLock()
+	defer fake.removeTagMutex.RUnlock()
 	fake.renameMutex.RLock()
 	defer fake.renameMutex.RUnlock()
+	fake.replayMutex.RLock()
+	defer fake.replayMutex.RUnlock()
 	fake.resetMutex.RLock()
 	defer fake.resetMutex.RUnlock()
+	fake.setDeadlineMutex.RLock()
+	defer fake.setDeadlineMutex.RUnlock()
 	fake.setPriorityMutex.RLock()
 	defer fake.setPriorityMutex.RUnlock()
 	fake.setStateMutex.RLock()
 	defer fake.setStateMutex.RUnlock()
 	fake.tasksMutex.RLock()
 	defer fake.tasksMutex.RUnlock()
+	fake.tasksMatchingMutex.RLock()
+	defer fake.tasksMatchingMutex.RUnlock()
+	fake.undoMutex.RLock()
+	defer fake.undoMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"fmt"
+	"reflect"
+
+	taskpkg "github.com/ankeesler/anwork/task"
+)
+
+func (m *manager) Replay() ([]string, error) {
+	events, err := m.repo.Events()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := m.repo.Tasks()
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies, err := m.repo.Dependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	projection, errs := taskpkg.Replay(events)
+
+	problems := []string{}
+	for _, err := range errs {
+		problems = append(problems, err.Error())
+	}
+
+	for _, task := range tasks {
+		replayed := projection.FindTaskByID(task.ID)
+		if replayed == nil {
+			problems = append(problems, fmt.Sprintf("task '%s' (ID %d) is not in the journal", task.Name, task.ID))
+			continue
+		}
+		problems = append(problems, diffTasks(task, replayed)...)
+	}
+
+	for _, replayed := range projection.Tasks {
+		if task := findTask(tasks, replayed.ID); task == nil {
+			problems = append(problems, fmt.Sprintf("task '%s' (ID %d) is missing", replayed.Name, replayed.ID))
+		}
+	}
+
+	for _, dependency := range dependencies {
+		if !hasDependency(projection.Dependencies, dependency) {
+			problems = append(problems, fmt.Sprintf("dependency of task %d on task %d is not in the journal",
+				dependency.TaskID, dependency.DependsOnID))
+		}
+	}
+
+	for _, replayed := range projection.Dependencies {
+		if !hasDependency(dependencies, replayed) {
+			problems = append(problems, fmt.Sprintf("dependency of task %d on task %d is missing",
+				replayed.TaskID, replayed.DependsOnID))
+		}
+	}
+
+	return problems, nil
+}
+
+// diffTasks describes how a stored task differs from what the journal says it should be.
+func diffTasks(task, replayed *taskpkg.Task) []string {
+	problems := []string{}
+	differs := func(field string, value, replayedValue interface{}) {
+		if !reflect.DeepEqual(value, replayedValue) {
+			problems = append(problems, fmt.Sprintf("task '%s' (ID %d) has %s %v, but the journal says %v",
+				task.Name, task.ID, field, value, replayedValue))
+		}
+	}
+
+	differs("name", task.Name, replayed.Name)
+	differs("start date", task.StartDate, replayed.StartDate)
+	differs("priority", task.Priority, replayed.Priority)
+	differs("state", task.State, replayed.State)
+	differs("deadline", task.Deadline, replayed.Deadline)
+	differs("tags", normalizeTags(task.Tags), normalizeTags(replayed.Tags))
+
+	return problems
+}
+
+// normalizeTags makes a task with no tags equal to a task with an empty list of tags.
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+func findTask(tasks []*taskpkg.Task, id int) *taskpkg.Task {
+	for _, task := range tasks {
+		if task.ID == id {
+			return task
+		}
+	}
+	return nil
+}
+
+func hasDependency(dependencies []*taskpkg.Dependency, dependency *taskpkg.Dependency) bool {
+	for _, d := range dependencies {
+		if d.TaskID == dependency.TaskID && d.DependsOnID == dependency.DependsOnID {
+			return true
+		}
+	}
+	return false
+}
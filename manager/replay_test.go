@@ -0,0 +1,94 @@
+package manager_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	managerpkg "github.com/ankeesler/anwork/manager"
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/taskfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Replay", func() {
+	var (
+		repo    *taskfakes.FakeRepo
+		manager managerpkg.Manager
+	)
+
+	BeforeEach(func() {
+		repo = &taskfakes.FakeRepo{}
+		manager = managerpkg.New(repo, fakeclock.NewFakeClock(time.Now()))
+
+		repo.EventsReturns([]*taskpkg.Event{
+			&taskpkg.Event{ID: 0, Date: 100, Type: taskpkg.EventTypeCreate, TaskID: 0, NewValue: "task-a"},
+			&taskpkg.Event{ID: 1, Date: 200, Type: taskpkg.EventTypeCreate, TaskID: 1, NewValue: "task-b"},
+			&taskpkg.Event{ID: 2, Type: taskpkg.EventTypeAddDependency, TaskID: 0, NewValue: "task-b"},
+			&taskpkg.Event{ID: 3, Type: taskpkg.EventTypeSetState, TaskID: 0, NewValue: "Blocked"},
+		}, nil)
+	})
+
+	Context("when the tasks match the journal", func() {
+		BeforeEach(func() {
+			repo.TasksReturns([]*taskpkg.Task{
+				&taskpkg.Task{Name: "task-a", ID: 0, StartDate: 100, Priority: 10, State: taskpkg.StateBlocked},
+				&taskpkg.Task{Name: "task-b", ID: 1, StartDate: 200, Priority: 10, State: taskpkg.StateReady},
+			}, nil)
+			repo.DependenciesReturns([]*taskpkg.Dependency{
+				&taskpkg.Dependency{ID: 5, TaskID: 0, DependsOnID: 1},
+			}, nil)
+		})
+
+		It("finds no problems", func() {
+			problems, err := manager.Replay()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+		})
+	})
+
+	Context("when the tasks do not match the journal", func() {
+		BeforeEach(func() {
+			repo.TasksReturns([]*taskpkg.Task{
+				&taskpkg.Task{Name: "task-a", ID: 0, StartDate: 100, Priority: 10, State: taskpkg.StateFinished},
+				&taskpkg.Task{Name: "task-c", ID: 2, StartDate: 300, Priority: 10, State: taskpkg.StateReady},
+			}, nil)
+			repo.DependenciesReturns([]*taskpkg.Dependency{
+				&taskpkg.Dependency{ID: 5, TaskID: 2, DependsOnID: 0},
+			}, nil)
+
+			events, _ := repo.Events()
+			repo.EventsReturns(append(events, &taskpkg.Event{
+				ID:     4,
+				Title:  "Note added to task 'task-z'",
+				Type:   taskpkg.EventTypeNote,
+				TaskID: 9,
+			}), nil)
+		})
+
+		It("describes each problem", func() {
+			problems, err := manager.Replay()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(Equal([]string{
+				"event 4 ('Note added to task 'task-z''): unknown task with ID 9",
+				"task 'task-a' (ID 0) has state Finished, but the journal says Blocked",
+				"task 'task-c' (ID 2) is not in the journal",
+				"task 'task-b' (ID 1) is missing",
+				"dependency of task 2 on task 0 is not in the journal",
+				"dependency of task 0 on task 1 is missing",
+			}))
+		})
+	})
+
+	Context("when the repo fails to return the events", func() {
+		BeforeEach(func() {
+			repo.EventsReturns(nil, errors.New("some error"))
+		})
+
+		It("returns the error", func() {
+			_, err := manager.Replay()
+			Expect(err).To(MatchError("some error"))
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package manager
+
+import (
+	"time"
+
+	taskpkg "github.com/ankeesler/anwork/task"
+)
+
+// A Scheduling describes how a Manager orders its task.Task's.
+type Scheduling int
+
+// These are the ways that a Manager can order its task.Task's.
+const (
+	// SchedulingPriority orders task.Task's by their static priority.
+	SchedulingPriority Scheduling = iota
+	// SchedulingDeadline orders task.Task's by their effective priority, which rises as their
+	// deadline approaches. See EffectivePriority.
+	SchedulingDeadline
+)
+
+// deadlineHorizon is how long before its deadline a task.Task starts to gain effective priority.
+const deadlineHorizon = time.Hour * 24 * 7
+
+// EffectivePriority returns the priority of a task.Task adjusted for the proximity of its
+// deadline at the provided time.
+//
+// A task.Task without a deadline, a task.Task that is finished, or a task.Task whose deadline is
+// further away than one week has an effective priority equal to its priority. Otherwise, the
+// effective priority is lowered (i.e., made more important) by one for every day within that week
+// that has passed. Overdue task.Task's continue to gain importance for every day that they are
+// late.
+func EffectivePriority(task *taskpkg.Task, now time.Time) int {
+	if task.Deadline == 0 || task.State == taskpkg.StateFinished {
+		return task.Priority
+	}
+
+	remaining := time.Duration(task.Deadline-now.Unix()) * time.Second
+	if remaining >= deadlineHorizon {
+		return task.Priority
+	}
+
+	day := time.Hour * 24
+	boost := int((deadlineHorizon - remaining) / day)
+	if (deadlineHorizon-remaining)%day != 0 {
+		boost++
+	}
+	return task.Priority - boost
+}
+
+func formatDeadline(deadline int64) string {
+	if deadline == 0 {
+		return "none"
+	}
+	return time.Unix(deadline, 0).Format("2006-01-02 15:04")
+}
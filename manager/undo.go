@@ -0,0 +1,449 @@
+package manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	taskpkg "github.com/ankeesler/anwork/task"
+)
+
+// A change is the list of events created by a single operation, in the order in which they were
+// created. The first event is the one to which the rest are linked with their Cause.
+type change []*taskpkg.Event
+
+// A resetSnapshot is everything that was deleted by a Reset, so that it can be restored.
+type resetSnapshot struct {
+	Tasks        []*taskpkg.Task       `json:"tasks"`
+	Events       []*taskpkg.Event      `json:"events"`
+	Dependencies []*taskpkg.Dependency `json:"dependencies"`
+}
+
+func newResetSnapshot(
+	tasks []*taskpkg.Task,
+	events []*taskpkg.Event,
+	dependencies []*taskpkg.Dependency,
+) *resetSnapshot {
+	// Only the most recent reset can be undone. Drop the snapshots of older resets so that each
+	// snapshot does not contain every snapshot before it.
+	snapshotEvents := make([]*taskpkg.Event, len(events))
+	for i, event := range events {
+		if event.Type == taskpkg.EventTypeReset {
+			eventCopy := *event
+			eventCopy.Snapshot = ""
+			event = &eventCopy
+		}
+		snapshotEvents[i] = event
+	}
+
+	return &resetSnapshot{Tasks: tasks, Events: snapshotEvents, Dependencies: dependencies}
+}
+
+func (m *manager) Undo(n int) error {
+	if n < 1 {
+		return fmt.Errorf("invalid number of operations to undo: %d", n)
+	}
+
+	return m.transact(func(m *manager) error {
+		events, err := m.repo.Events()
+		if err != nil {
+			return err
+		}
+
+		undoable, _ := history(events)
+		if len(undoable) == 0 {
+			return errors.New("nothing to undo")
+		} else if len(undoable) < n {
+			return fmt.Errorf("cannot undo %d operations, only %d can be undone", n, len(undoable))
+		}
+
+		for i := 0; i < n; i++ {
+			// Each undo adds events to the journal, so read the journal again for the next one.
+			if i > 0 {
+				if events, err = m.repo.Events(); err != nil {
+					return err
+				}
+				undoable, _ = history(events)
+			}
+
+			if err := m.revert(undoable[len(undoable)-1], events); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (m *manager) Redo() error {
+	return m.transact(func(m *manager) error {
+		events, err := m.repo.Events()
+		if err != nil {
+			return err
+		}
+
+		_, redoable := history(events)
+		if len(redoable) == 0 {
+			return errors.New("nothing to redo")
+		}
+
+		return m.revert(redoable[len(redoable)-1], events)
+	})
+}
+
+// history replays a journal to find the changes that can be undone and the changes that can be
+// redone, both from oldest to newest.
+//
+// Undoing a change reverts it with a new change, which can then be redone by reverting it too. Any
+// other change makes it impossible to redo what was undone before it.
+func history(events []*taskpkg.Event) (undoable, redoable []change) {
+	changes := []change{}
+	indices := map[int]int{}
+	for _, event := range events {
+		if event.Cause != nil {
+			if i, ok := indices[*event.Cause]; ok {
+				changes[i] = append(changes[i], event)
+				continue
+			}
+		}
+
+		indices[event.ID] = len(changes)
+		changes = append(changes, change{event})
+	}
+
+	reverts := func(c change, changes []change) bool {
+		return c[0].Reverts != nil &&
+			len(changes) > 0 &&
+			changes[len(changes)-1][0].ID == *c[0].Reverts
+	}
+
+	for _, c := range changes {
+		switch {
+		case reverts(c, undoable):
+			undoable = undoable[:len(undoable)-1]
+			redoable = append(redoable, c)
+		case reverts(c, redoable):
+			redoable = redoable[:len(redoable)-1]
+			undoable = append(undoable, c)
+		case c[0].Type == taskpkg.EventTypeRestore:
+			// A restore undoes a reset, which was removed from the journal by the restore.
+			redoable = append(redoable, c)
+		case c[0].Type == taskpkg.EventTypeNote:
+			// Notes do not change anything, so there is nothing to undo.
+		default:
+			undoable = append(undoable, c)
+			redoable = nil
+		}
+	}
+
+	return undoable, redoable
+}
+
+// revert performs the inverse of a change as a new operation, which is linked to the change.
+func (m *manager) revert(c change, events []*taskpkg.Event) error {
+	switch c[0].Type {
+	case taskpkg.EventTypeReset:
+		return m.restore(c[0])
+	case taskpkg.EventTypeRestore:
+		return m.Reset()
+	}
+
+	m = m.begin()
+	reverts := c[0].ID
+	m.op.reverts = &reverts
+
+	taskIDs := restoredTaskIDs(events)
+	for i := len(c) - 1; i >= 0; i-- {
+		if err := m.revertEvent(c[i], taskIDs); err != nil {
+			return fmt.Errorf("cannot revert '%s': %w", c[i].Title, err)
+		}
+	}
+
+	return nil
+}
+
+// revertEvent performs the inverse of a single event. The taskIDs are used to find the tasks that
+// have been restored since the event took place; see restoredTaskIDs.
+func (m *manager) revertEvent(event *taskpkg.Event, taskIDs map[int]int) error {
+	switch event.Type {
+	case taskpkg.EventTypeNote:
+		return nil
+	case taskpkg.EventTypeDelete:
+		return m.restoreTask(event, taskIDs)
+	}
+
+	taskID, err := resolveTaskID(taskIDs, event.TaskID)
+	if err != nil {
+		return err
+	}
+
+	task, err := m.findTaskByID(taskID)
+	if err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case taskpkg.EventTypeCreate:
+		return m.deleteTask(task)
+
+	case taskpkg.EventTypeSetState:
+		return m.setState(task, taskpkg.State(event.OldValue))
+
+	case taskpkg.EventTypeSetPriority:
+		priority, err := strconv.Atoi(event.OldValue)
+		if err != nil {
+			return fmt.Errorf("invalid priority: '%s'", event.OldValue)
+		}
+		return m.setPriority(task, priority)
+
+	case taskpkg.EventTypeSetDeadline:
+		deadline, err := strconv.ParseInt(event.OldValue, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid deadline: '%s'", event.OldValue)
+		}
+		return m.setDeadline(task, deadline)
+
+	case taskpkg.EventTypeAddDependency:
+		dependsOnTask, err := m.findDependsOnTask(event, event.NewValue, taskIDs)
+		if err != nil {
+			return err
+		}
+		return m.removeDependency(task, dependsOnTask)
+
+	case taskpkg.EventTypeRemoveDependency:
+		dependsOnTask, err := m.findDependsOnTask(event, event.OldValue, taskIDs)
+		if err != nil {
+			return err
+		}
+		return m.addDependency(task, dependsOnTask)
+
+	case taskpkg.EventTypeAddTag:
+		return m.removeTag(task, event.NewValue)
+
+	case taskpkg.EventTypeRemoveTag:
+		return m.addTag(task, event.OldValue)
+
+	case taskpkg.EventTypeRename:
+		return m.rename(task, event.OldValue)
+
+	default:
+		return fmt.Errorf("unknown event type: %d", event.Type)
+	}
+}
+
+// findDependsOnTask finds the task on which the task of a dependency event depends (or depended).
+// Older events did not record its ID, so they can only find it by its name.
+func (m *manager) findDependsOnTask(
+	event *taskpkg.Event,
+	name string,
+	taskIDs map[int]int,
+) (*taskpkg.Task, error) {
+	id, ok := event.DependsOnID()
+	if !ok {
+		return m.findTaskByName(name)
+	}
+
+	id, err := resolveTaskID(taskIDs, id)
+	if err != nil {
+		return nil, err
+	}
+	return m.findTaskByID(id)
+}
+
+// restoreTask recreates a task that was deleted by an event. The restored task gets a new ID, which
+// is added to the taskIDs.
+func (m *manager) restoreTask(event *taskpkg.Event, taskIDs map[int]int) error {
+	if event.Snapshot == "" {
+		return errors.New("the deleted task was not recorded")
+	}
+
+	var task taskpkg.Task
+	if err := json.Unmarshal([]byte(event.Snapshot), &task); err != nil {
+		return err
+	}
+
+	if err := m.repo.CreateTask(&task); err != nil {
+		return err
+	}
+	taskIDs[event.TaskID] = task.ID
+
+	snapshot, err := json.Marshal(&task)
+	if err != nil {
+		return err
+	}
+
+	return m.createEvent(&taskpkg.Event{
+		Title:    fmt.Sprintf("Created task '%s'", task.Name),
+		Type:     taskpkg.EventTypeCreate,
+		TaskID:   task.ID,
+		OldValue: strconv.Itoa(event.TaskID),
+		NewValue: task.Name,
+		Snapshot: string(snapshot),
+	})
+}
+
+// restore undoes a reset by recreating everything that it deleted. Since the reset must be the most
+// recent change that has not been undone, every event in the journal since the reset belongs to a
+// change that has been undone, so the journal is replaced with the one from before the reset.
+//
+// The restored tasks, events, and dependencies get new IDs, so the references between them are
+// updated.
+func (m *manager) restore(reset *taskpkg.Event) error {
+	m = m.begin()
+
+	if reset.Snapshot == "" {
+		return fmt.Errorf("cannot revert '%s': what was reset was not recorded", reset.Title)
+	}
+
+	var snapshot resetSnapshot
+	if err := json.Unmarshal([]byte(reset.Snapshot), &snapshot); err != nil {
+		return err
+	}
+
+	events, err := m.repo.Events()
+	if err != nil {
+		return err
+	}
+
+	for i := len(events) - 1; i >= 0; i-- {
+		if err := m.repo.DeleteEvent(events[i]); err != nil {
+			return err
+		}
+	}
+
+	taskIDs := map[int]int{}
+	for _, task := range snapshot.Tasks {
+		oldID := task.ID
+		if err := m.repo.CreateTask(task); err != nil {
+			return err
+		}
+		taskIDs[oldID] = task.ID
+	}
+
+	for _, dependency := range snapshot.Dependencies {
+		if err := restoreDependency(dependency, taskIDs); err != nil {
+			return err
+		}
+		if err := m.repo.CreateDependency(dependency); err != nil {
+			return err
+		}
+	}
+
+	eventIDs := map[int]int{}
+	restoreEventID := func(id *int) *int {
+		if id == nil {
+			return nil
+		} else if newID, ok := eventIDs[*id]; ok {
+			return &newID
+		}
+		return id
+	}
+	for _, event := range snapshot.Events {
+		oldID := event.ID
+		if event.TaskID != taskpkg.NoTaskID {
+			if event.TaskID, err = resolveTaskID(taskIDs, event.TaskID); err != nil {
+				return err
+			}
+		}
+		if _, ok := event.DependsOnID(); ok {
+			if event.Snapshot, err = restoreDependencySnapshot(event.Snapshot, taskIDs); err != nil {
+				return err
+			}
+		}
+		event.Cause = restoreEventID(event.Cause)
+		event.Reverts = restoreEventID(event.Reverts)
+		if err := m.repo.CreateEvent(event); err != nil {
+			return err
+		}
+		eventIDs[oldID] = event.ID
+	}
+
+	return m.createEvent(&taskpkg.Event{
+		Title:    fmt.Sprintf("Restored %d tasks", len(snapshot.Tasks)),
+		Type:     taskpkg.EventTypeRestore,
+		TaskID:   taskpkg.NoTaskID,
+		NewValue: strconv.Itoa(len(snapshot.Tasks)),
+	})
+}
+
+// restoredTaskIDs returns the new ID of each task that was restored after being deleted, keyed by
+// the ID that it had before it was deleted.
+func restoredTaskIDs(events []*taskpkg.Event) map[int]int {
+	taskIDs := map[int]int{}
+	for _, event := range events {
+		if id, ok := event.RestoredFrom(); ok {
+			taskIDs[id] = event.TaskID
+		}
+	}
+	return taskIDs
+}
+
+// restoreDependency changes the IDs of the tasks of a dependency to their current IDs.
+func restoreDependency(dependency *taskpkg.Dependency, taskIDs map[int]int) error {
+	var err error
+	if dependency.TaskID, err = resolveTaskID(taskIDs, dependency.TaskID); err != nil {
+		return err
+	}
+	dependency.DependsOnID, err = resolveTaskID(taskIDs, dependency.DependsOnID)
+	return err
+}
+
+// restoreDependencySnapshot returns the Snapshot of a dependency event with the current IDs of its
+// tasks.
+func restoreDependencySnapshot(snapshot string, taskIDs map[int]int) (string, error) {
+	var dependency taskpkg.Dependency
+	if err := json.Unmarshal([]byte(snapshot), &dependency); err != nil {
+		return "", err
+	}
+
+	if err := restoreDependency(&dependency, taskIDs); err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(&dependency)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// resolveTaskID follows the taskIDs to find the current ID of a task, which may have been deleted
+// and restored any number of times. It returns an error if the taskIDs lead back to an ID that they
+// have already visited, since they would never find the current ID.
+func resolveTaskID(taskIDs map[int]int, id int) (int, error) {
+	oldID := id
+	visited := map[int]bool{}
+	for {
+		newID, ok := taskIDs[id]
+		if !ok || newID == id {
+			return id, nil
+		}
+
+		visited[id] = true
+		if visited[newID] {
+			return 0, fmt.Errorf("cannot find the current ID of task %d: its IDs form a cycle", oldID)
+		}
+		id = newID
+	}
+}
+
+func (m *manager) findTaskByID(id int) (*taskpkg.Task, error) {
+	task, err := m.repo.FindTaskByID(id)
+	if err != nil {
+		return nil, err
+	} else if task == nil {
+		return nil, fmt.Errorf("unknown task with ID %d", id)
+	}
+	return task, nil
+}
+
+func (m *manager) findTaskByName(name string) (*taskpkg.Task, error) {
+	task, err := m.repo.FindTaskByName(name)
+	if err != nil {
+		return nil, err
+	} else if task == nil {
+		return nil, UnknownTaskError{Name: name}
+	}
+	return task, nil
+}
@@ -0,0 +1,435 @@
+package manager_test
+
+import (
+	"encoding/json"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	managerpkg "github.com/ankeesler/anwork/manager"
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/taskfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Undo", func() {
+	var (
+		repo    *taskfakes.FakeRepo
+		manager managerpkg.Manager
+
+		tasks        []*taskpkg.Task
+		events       []*taskpkg.Event
+		dependencies []*taskpkg.Dependency
+	)
+
+	BeforeEach(func() {
+		repo = &taskfakes.FakeRepo{}
+		manager = managerpkg.New(repo, fakeclock.NewFakeClock(time.Now()))
+
+		tasks = []*taskpkg.Task{}
+		events = []*taskpkg.Event{}
+		dependencies = []*taskpkg.Dependency{}
+		nextTaskID, nextEventID, nextDependencyID := 0, 0, 0
+
+		repo.CreateTaskStub = func(task *taskpkg.Task) error {
+			task.ID = nextTaskID
+			nextTaskID++
+			tasks = append(tasks, task)
+			return nil
+		}
+		repo.TasksStub = func() ([]*taskpkg.Task, error) {
+			return tasks, nil
+		}
+		repo.FindTaskByNameStub = func(name string) (*taskpkg.Task, error) {
+			for _, task := range tasks {
+				if task.Name == name {
+					return task, nil
+				}
+			}
+			return nil, nil
+		}
+		repo.FindTaskByIDStub = func(id int) (*taskpkg.Task, error) {
+			for _, task := range tasks {
+				if task.ID == id {
+					return task, nil
+				}
+			}
+			return nil, nil
+		}
+		repo.DeleteTaskStub = func(task *taskpkg.Task) error {
+			for i, t := range tasks {
+				if t.ID == task.ID {
+					tasks = append(tasks[:i], tasks[i+1:]...)
+					break
+				}
+			}
+			return nil
+		}
+		repo.CreateEventStub = func(event *taskpkg.Event) error {
+			event.ID = nextEventID
+			nextEventID++
+			events = append(events, event)
+			return nil
+		}
+		repo.EventsStub = func() ([]*taskpkg.Event, error) {
+			return events, nil
+		}
+		repo.DeleteEventStub = func(event *taskpkg.Event) error {
+			for i, e := range events {
+				if e.ID == event.ID {
+					events = append(events[:i], events[i+1:]...)
+					break
+				}
+			}
+			return nil
+		}
+		repo.CreateDependencyStub = func(dependency *taskpkg.Dependency) error {
+			dependency.ID = nextDependencyID
+			nextDependencyID++
+			dependencies = append(dependencies, dependency)
+			return nil
+		}
+		repo.DependenciesStub = func() ([]*taskpkg.Dependency, error) {
+			return dependencies, nil
+		}
+		repo.DeleteDependencyStub = func(dependency *taskpkg.Dependency) error {
+			for i, d := range dependencies {
+				if d.ID == dependency.ID {
+					dependencies = append(dependencies[:i], dependencies[i+1:]...)
+					break
+				}
+			}
+			return nil
+		}
+
+		Expect(manager.Create("task-a")).To(Succeed())
+		Expect(manager.Create("task-b")).To(Succeed())
+		Expect(manager.AddDependency("task-a", "task-b")).To(Succeed())
+	})
+
+	findTask := func(name string) *taskpkg.Task {
+		task, err := manager.FindByName(name)
+		Expect(err).NotTo(HaveOccurred())
+		return task
+	}
+
+	// snapshot returns a copy of the tasks so that they can be compared after they are changed.
+	snapshot := func() []taskpkg.Task {
+		copies := make([]taskpkg.Task, len(tasks))
+		for i, task := range tasks {
+			copies[i] = *task
+		}
+		return copies
+	}
+
+	withoutIDs := func(tasks []taskpkg.Task) []taskpkg.Task {
+		for i := range tasks {
+			tasks[i].ID = 0
+		}
+		return tasks
+	}
+
+	lastEvent := func() *taskpkg.Event {
+		return events[len(events)-1]
+	}
+
+	It("reverts each kind of operation", func() {
+		ops := map[string]func() error{
+			"create":            func() error { return manager.Create("task-c") },
+			"set-state":         func() error { return manager.SetState("task-b", taskpkg.StateRunning) },
+			"set-priority":      func() error { return manager.SetPriority("task-a", 5) },
+			"set-deadline":      func() error { return manager.SetDeadline("task-a", 12345) },
+			"add-tag":           func() error { return manager.AddTag("task-a", "infra") },
+			"rename":            func() error { return manager.Rename("task-a", "task-z") },
+			"add-dependency":    func() error { return manager.AddDependency("task-b", "task-a") },
+			"remove-dependency": func() error { return manager.RemoveDependency("task-a", "task-b") },
+		}
+		for name, op := range ops {
+			before, beforeDependencies := snapshot(), len(dependencies)
+
+			if name == "add-dependency" {
+				Expect(manager.RemoveDependency("task-a", "task-b")).To(Succeed(), name)
+				before, beforeDependencies = snapshot(), len(dependencies)
+			}
+
+			Expect(op()).To(Succeed(), name)
+			Expect(snapshot()).NotTo(Equal(before), name)
+
+			Expect(manager.Undo(1)).To(Succeed(), name)
+			Expect(snapshot()).To(Equal(before), name)
+			Expect(dependencies).To(HaveLen(beforeDependencies), name)
+
+			if name == "add-dependency" {
+				Expect(manager.AddDependency("task-a", "task-b")).To(Succeed(), name)
+			}
+		}
+	})
+
+	It("records the inverse operation with an event that refers to what it reverts", func() {
+		Expect(manager.SetPriority("task-a", 5)).To(Succeed())
+		setPriority := lastEvent()
+
+		Expect(manager.Undo(1)).To(Succeed())
+		Expect(lastEvent().Title).To(Equal("Set priority on task 'task-a' from 5 to 10"))
+		Expect(lastEvent().OldValue).To(Equal("5"))
+		Expect(lastEvent().NewValue).To(Equal("10"))
+		Expect(lastEvent().Reverts).To(Equal(&setPriority.ID))
+	})
+
+	Context("when a dependency is changed", func() {
+		var addDependency *taskpkg.Event
+
+		BeforeEach(func() {
+			for _, event := range events {
+				if event.Type == taskpkg.EventTypeAddDependency {
+					addDependency = event
+				}
+			}
+			Expect(addDependency).NotTo(BeNil())
+		})
+
+		It("finds the other task by its ID, not its name", func() {
+			addDependency.NewValue = "task-unknown"
+
+			Expect(manager.Undo(1)).To(Succeed())
+			Expect(dependencies).To(BeEmpty())
+		})
+
+		Context("when the event did not record the ID of the other task", func() {
+			BeforeEach(func() {
+				addDependency.Snapshot = ""
+			})
+
+			It("finds the other task by its name", func() {
+				Expect(manager.Undo(1)).To(Succeed())
+				Expect(dependencies).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when the restored IDs of a task form a cycle", func() {
+		BeforeEach(func() {
+			cause := events[0].ID
+			events = append(events,
+				&taskpkg.Event{ID: 100, Type: taskpkg.EventTypeCreate, TaskID: 0, OldValue: "1", Cause: &cause},
+				&taskpkg.Event{ID: 101, Type: taskpkg.EventTypeCreate, TaskID: 1, OldValue: "0", Cause: &cause},
+			)
+			Expect(manager.SetPriority("task-a", 5)).To(Succeed())
+		})
+
+		It("returns an error", func() {
+			Expect(manager.Undo(1)).To(MatchError(
+				"cannot revert 'Set priority on task 'task-a' from 10 to 5': " +
+					"cannot find the current ID of task 0: its IDs form a cycle",
+			))
+		})
+	})
+
+	Context("when an operation changes more than one task", func() {
+		It("undoes all of the changes together", func() {
+			before := snapshot()
+			Expect(manager.SetState("task-b", taskpkg.StateFinished)).To(Succeed())
+			Expect(findTask("task-a").State).To(BeEquivalentTo(taskpkg.StateReady))
+
+			Expect(manager.Undo(1)).To(Succeed())
+			Expect(snapshot()).To(Equal(before))
+			Expect(findTask("task-a").State).To(BeEquivalentTo(taskpkg.StateBlocked))
+
+			cause := events[len(events)-2].ID
+			Expect(lastEvent().Cause).To(Equal(&cause))
+		})
+	})
+
+	Context("when a task is deleted", func() {
+		var before []taskpkg.Task
+		var deleted *taskpkg.Event
+
+		BeforeEach(func() {
+			Expect(manager.SetPriority("task-b", 3)).To(Succeed())
+			Expect(manager.AddTag("task-b", "infra")).To(Succeed())
+			before = snapshot()
+
+			Expect(manager.Delete("task-b")).To(Succeed())
+			deleted = lastEvent()
+			Expect(findTask("task-b")).To(BeNil())
+			Expect(dependencies).To(BeEmpty())
+		})
+
+		It("restores the task and its dependencies with a new ID", func() {
+			Expect(manager.Undo(1)).To(Succeed())
+
+			restored := findTask("task-b")
+			Expect(restored).NotTo(BeNil())
+			Expect(restored.ID).NotTo(Equal(deleted.TaskID))
+			Expect(withoutIDs(snapshot())).To(Equal(withoutIDs(before)))
+			Expect(manager.Dependencies("task-a")).To(Equal([]*taskpkg.Task{restored}))
+
+			var create *taskpkg.Event
+			for _, event := range events {
+				if event.Type == taskpkg.EventTypeCreate && event.TaskID == restored.ID {
+					create = event
+				}
+			}
+			Expect(create).NotTo(BeNil())
+			restoredFrom, ok := create.RestoredFrom()
+			Expect(ok).To(BeTrue())
+			Expect(restoredFrom).To(Equal(deleted.TaskID))
+		})
+
+		It("can undo the operations on the task from before it was deleted", func() {
+			Expect(manager.Undo(3)).To(Succeed())
+			Expect(findTask("task-b").Priority).To(Equal(10))
+			Expect(findTask("task-b").Tags).To(BeEmpty())
+		})
+
+		It("can redo the deletion, and then undo it again", func() {
+			Expect(manager.Undo(1)).To(Succeed())
+			Expect(manager.Redo()).To(Succeed())
+			Expect(findTask("task-b")).To(BeNil())
+
+			Expect(manager.Undo(2)).To(Succeed())
+			Expect(findTask("task-b").Tags).To(BeEmpty())
+			Expect(findTask("task-b").Priority).To(Equal(3))
+		})
+
+		Context("when the deleted task was not recorded", func() {
+			BeforeEach(func() {
+				deleted.Snapshot = ""
+			})
+
+			It("returns an error", func() {
+				Expect(manager.Undo(1)).To(MatchError(
+					"cannot revert 'Deleted task 'task-b'': the deleted task was not recorded",
+				))
+			})
+		})
+	})
+
+	Describe("undoing more than one operation", func() {
+		It("undoes the most recent operations first, skipping notes", func() {
+			before := snapshot()
+			Expect(manager.SetState("task-a", taskpkg.StateRunning)).To(Succeed())
+			Expect(manager.Note("task-a", "here is a note")).To(Succeed())
+			Expect(manager.SetPriority("task-a", 1)).To(Succeed())
+
+			Expect(manager.Undo(2)).To(Succeed())
+			Expect(snapshot()).To(Equal(before))
+			Expect(events).To(ContainElement(WithTransform(
+				func(e *taskpkg.Event) string { return e.Note },
+				Equal("here is a note"),
+			)))
+		})
+
+		Context("when there are not enough operations", func() {
+			It("returns an error without undoing anything", func() {
+				before := snapshot()
+				Expect(manager.Undo(4)).To(MatchError("cannot undo 4 operations, only 3 can be undone"))
+				Expect(snapshot()).To(Equal(before))
+			})
+		})
+
+		Context("when n is not positive", func() {
+			It("returns an error", func() {
+				Expect(manager.Undo(0)).To(MatchError("invalid number of operations to undo: 0"))
+			})
+		})
+
+		Context("when everything has been undone", func() {
+			It("returns an error", func() {
+				Expect(manager.Undo(3)).To(Succeed())
+				Expect(tasks).To(BeEmpty())
+				Expect(manager.Undo(1)).To(MatchError("nothing to undo"))
+			})
+		})
+	})
+
+	Describe("Redo", func() {
+		It("redoes the most recently undone operations, newest first", func() {
+			Expect(manager.SetPriority("task-a", 1)).To(Succeed())
+			Expect(manager.SetPriority("task-a", 2)).To(Succeed())
+			Expect(manager.Undo(2)).To(Succeed())
+			Expect(findTask("task-a").Priority).To(Equal(10))
+
+			Expect(manager.Redo()).To(Succeed())
+			Expect(findTask("task-a").Priority).To(Equal(1))
+			Expect(manager.Redo()).To(Succeed())
+			Expect(findTask("task-a").Priority).To(Equal(2))
+			Expect(manager.Redo()).To(MatchError("nothing to redo"))
+
+			Expect(manager.Undo(1)).To(Succeed())
+			Expect(findTask("task-a").Priority).To(Equal(1))
+		})
+
+		Context("when an operation happens after an undo", func() {
+			It("cannot redo what was undone", func() {
+				Expect(manager.SetPriority("task-a", 1)).To(Succeed())
+				Expect(manager.Undo(1)).To(Succeed())
+				Expect(manager.SetPriority("task-b", 2)).To(Succeed())
+				Expect(manager.Redo()).To(MatchError("nothing to redo"))
+			})
+		})
+
+		Context("when nothing has been undone", func() {
+			It("returns an error", func() {
+				Expect(manager.Redo()).To(MatchError("nothing to redo"))
+			})
+		})
+	})
+
+	Describe("Reset", func() {
+		var before []taskpkg.Task
+		var beforeEvents []string
+
+		BeforeEach(func() {
+			before = snapshot()
+			for _, event := range events {
+				beforeEvents = append(beforeEvents, event.Title)
+			}
+
+			Expect(manager.Reset()).To(Succeed())
+			Expect(tasks).To(BeEmpty())
+			Expect(dependencies).To(BeEmpty())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Type).To(BeEquivalentTo(taskpkg.EventTypeReset))
+		})
+
+		It("restores the tasks, dependencies, and journal", func() {
+			Expect(manager.Undo(1)).To(Succeed())
+
+			Expect(withoutIDs(snapshot())).To(Equal(withoutIDs(before)))
+			Expect(manager.Dependencies("task-a")).To(Equal([]*taskpkg.Task{findTask("task-b")}))
+
+			titles := []string{}
+			for _, event := range events {
+				titles = append(titles, event.Title)
+			}
+			Expect(titles).To(Equal(append(beforeEvents, "Restored 2 tasks")))
+		})
+
+		It("can undo the operations from before the reset", func() {
+			Expect(manager.Undo(1)).To(Succeed())
+			Expect(manager.Undo(1)).To(Succeed())
+			Expect(manager.Dependencies("task-a")).To(BeEmpty())
+		})
+
+		It("can redo the reset", func() {
+			Expect(manager.Undo(1)).To(Succeed())
+			Expect(manager.Redo()).To(Succeed())
+			Expect(tasks).To(BeEmpty())
+			Expect(events).To(HaveLen(1))
+		})
+
+		It("does not keep the snapshots of older resets", func() {
+			Expect(manager.Create("task-c")).To(Succeed())
+			Expect(manager.Reset()).To(Succeed())
+
+			var snapshot struct {
+				Events []*taskpkg.Event `json:"events"`
+			}
+			Expect(json.Unmarshal([]byte(events[0].Snapshot), &snapshot)).To(Succeed())
+			Expect(snapshot.Events).To(HaveLen(2))
+			Expect(snapshot.Events[0].Type).To(BeEquivalentTo(taskpkg.EventTypeReset))
+			Expect(snapshot.Events[0].Snapshot).To(BeEmpty())
+		})
+	})
+})
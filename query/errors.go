@@ -0,0 +1,12 @@
+package query
+
+import "fmt"
+
+type syntaxError struct {
+	query  string
+	reason string
+}
+
+func (se *syntaxError) Error() string {
+	return fmt.Sprintf("invalid query '%s': %s", se.query, se.reason)
+}
@@ -0,0 +1,122 @@
+package query
+
+import (
+	"sort"
+
+	"github.com/ankeesler/anwork/task"
+)
+
+// An Order is the order in which an EventQuery returns task.Event's.
+type Order string
+
+// These are the Order's of an EventQuery. OrderAscending returns the oldest task.Event (i.e., the
+// one with the lowest ID) first, and OrderDescending returns the newest task.Event first.
+const (
+	OrderAscending  Order = "asc"
+	OrderDescending Order = "desc"
+)
+
+// An EventQuery selects a page of the task.Event's in a task.Repo. Each field that is not set
+// matches every task.Event.
+type EventQuery struct {
+	// Only the task.Event's about the task.Task with this ID match, if it is set.
+	TaskID *int
+	// Only the task.Event's of these types match, if there are any.
+	Types []task.EventType
+	// Only the task.Event's that took place at or after Since, and before Until, match, if they
+	// are not 0. Both are represented by the number of seconds since January 1, 1970.
+	Since, Until int64
+
+	// This is the ID of the last task.Event of the previous page. The page continues after it, in
+	// the Order of the EventQuery. If it is not set, the page starts at the first task.Event. It is
+	// a pointer, like TaskID, since 0 is the ID of a task.Event in some task.Repo's.
+	Cursor *int
+	// This is the most task.Event's that are returned, if it is not 0.
+	Limit int
+	// This is the Order of the task.Event's. By default, it is OrderAscending.
+	Order Order
+}
+
+// Matches returns whether a task.Event matches the TaskID, Types, Since, and Until of the
+// EventQuery.
+func (q *EventQuery) Matches(e *task.Event) bool {
+	if q.TaskID != nil && *q.TaskID != e.TaskID {
+		return false
+	}
+
+	if len(q.Types) > 0 {
+		found := false
+		for _, t := range q.Types {
+			if t == e.Type {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if q.Since != 0 && e.Date < q.Since {
+		return false
+	}
+	if q.Until != 0 && e.Date >= q.Until {
+		return false
+	}
+
+	return true
+}
+
+// Select returns the page of task.Event's that the EventQuery selects from task.Event's that are
+// ordered by ID, lowest first.
+func (q *EventQuery) Select(events []*task.Event) []*task.Event {
+	descending := q.Order == OrderDescending
+
+	// The page starts right after the Cursor, which can be found without looking at every
+	// task.Event since they are ordered by ID.
+	start, end, step := 0, len(events), 1
+	if descending {
+		start, end, step = len(events)-1, -1, -1
+	}
+	if q.Cursor != nil {
+		cursor := *q.Cursor
+		if descending {
+			start = sort.Search(len(events), func(i int) bool { return events[i].ID >= cursor }) - 1
+		} else {
+			start = sort.Search(len(events), func(i int) bool { return events[i].ID > cursor })
+		}
+	}
+
+	selected := make([]*task.Event, 0)
+	for i := start; i != end; i += step {
+		if q.Limit != 0 && len(selected) == q.Limit {
+			break
+		}
+		if q.Matches(events[i]) {
+			selected = append(selected, events[i])
+		}
+	}
+	return selected
+}
+
+// An EventRepo is a task.Repo that can find the task.Event's that an EventQuery selects itself,
+// e.g., with an indexed database query. A task.Repo does not need to implement this interface;
+// see EventsMatching.
+type EventRepo interface {
+	// Get the task.Event's that an EventQuery selects, in its Order.
+	EventsMatching(q *EventQuery) ([]*task.Event, error)
+}
+
+// EventsMatching returns the task.Event's in a task.Repo that an EventQuery selects. If the
+// task.Repo is an EventRepo, it finds them itself. Otherwise, they are selected from all of its
+// task.Event's.
+func EventsMatching(repo task.Repo, q *EventQuery) ([]*task.Event, error) {
+	if eventRepo, ok := repo.(EventRepo); ok {
+		return eventRepo.EventsMatching(q)
+	}
+
+	events, err := repo.Events()
+	if err != nil {
+		return nil, err
+	}
+	return q.Select(events), nil
+}
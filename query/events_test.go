@@ -0,0 +1,124 @@
+package query_test
+
+import (
+	"errors"
+
+	"github.com/ankeesler/anwork/query"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/taskfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("EventQuery", func() {
+	var events []*task.Event
+
+	BeforeEach(func() {
+		events = []*task.Event{
+			&task.Event{ID: 1, TaskID: 1, Type: task.EventTypeCreate, Date: 100},
+			&task.Event{ID: 2, TaskID: 2, Type: task.EventTypeCreate, Date: 200},
+			&task.Event{ID: 4, TaskID: 1, Type: task.EventTypeSetState, Date: 300},
+			&task.Event{ID: 5, TaskID: 2, Type: task.EventTypeNote, Date: 400},
+			&task.Event{ID: 7, TaskID: 1, Type: task.EventTypeSetState, Date: 500},
+		}
+	})
+
+	ids := func(events []*task.Event) []int {
+		ids := make([]int, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+		return ids
+	}
+
+	It("selects every event when it is empty", func() {
+		Expect(ids((&query.EventQuery{}).Select(events))).To(Equal([]int{1, 2, 4, 5, 7}))
+	})
+
+	It("selects the events about a task", func() {
+		taskID := 2
+		q := &query.EventQuery{TaskID: &taskID}
+		Expect(ids(q.Select(events))).To(Equal([]int{2, 5}))
+	})
+
+	It("selects the events of some types", func() {
+		q := &query.EventQuery{Types: []task.EventType{task.EventTypeSetState, task.EventTypeNote}}
+		Expect(ids(q.Select(events))).To(Equal([]int{4, 5, 7}))
+	})
+
+	It("selects the events between since and until", func() {
+		q := &query.EventQuery{Since: 200, Until: 500}
+		Expect(ids(q.Select(events))).To(Equal([]int{2, 4, 5}))
+	})
+
+	It("selects the newest events first", func() {
+		q := &query.EventQuery{Order: query.OrderDescending}
+		Expect(ids(q.Select(events))).To(Equal([]int{7, 5, 4, 2, 1}))
+	})
+
+	Describe("pages", func() {
+		// cursor returns a query.EventQuery.Cursor.
+		cursor := func(id int) *int { return &id }
+
+		It("selects the events after the cursor, up to the limit", func() {
+			q := &query.EventQuery{Limit: 2}
+			Expect(ids(q.Select(events))).To(Equal([]int{1, 2}))
+
+			q.Cursor = cursor(2)
+			Expect(ids(q.Select(events))).To(Equal([]int{4, 5}))
+
+			q.Cursor = cursor(5)
+			Expect(ids(q.Select(events))).To(Equal([]int{7}))
+
+			q.Cursor = cursor(7)
+			Expect(q.Select(events)).To(BeEmpty())
+		})
+
+		It("selects the events before the cursor when the order is descending", func() {
+			q := &query.EventQuery{Limit: 2, Order: query.OrderDescending}
+			Expect(ids(q.Select(events))).To(Equal([]int{7, 5}))
+
+			q.Cursor = cursor(5)
+			Expect(ids(q.Select(events))).To(Equal([]int{4, 2}))
+
+			q.Cursor = cursor(2)
+			Expect(ids(q.Select(events))).To(Equal([]int{1}))
+		})
+
+		It("continues after a cursor that is no longer an event", func() {
+			q := &query.EventQuery{Cursor: cursor(3)}
+			Expect(ids(q.Select(events))).To(Equal([]int{4, 5, 7}))
+
+			q = &query.EventQuery{Cursor: cursor(3), Order: query.OrderDescending}
+			Expect(ids(q.Select(events))).To(Equal([]int{2, 1}))
+		})
+
+		It("applies the limit to the matching events", func() {
+			taskID := 1
+			q := &query.EventQuery{TaskID: &taskID, Limit: 2}
+			Expect(ids(q.Select(events))).To(Equal([]int{1, 4}))
+		})
+	})
+
+	Describe("EventsMatching", func() {
+		var repo *taskfakes.FakeRepo
+
+		BeforeEach(func() {
+			repo = &taskfakes.FakeRepo{}
+			repo.EventsReturns(events, nil)
+		})
+
+		It("selects the events from all of the events in the repo", func() {
+			q := &query.EventQuery{Types: []task.EventType{task.EventTypeCreate}}
+			selected, err := query.EventsMatching(repo, q)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ids(selected)).To(Equal([]int{1, 2}))
+		})
+
+		It("returns the error from the repo", func() {
+			repo.EventsReturns(nil, errors.New("some error"))
+			_, err := query.EventsMatching(repo, &query.EventQuery{})
+			Expect(err).To(MatchError("some error"))
+		})
+	})
+})
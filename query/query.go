@@ -0,0 +1,358 @@
+// Package query contains a small language for filtering task.Task's.
+//
+// A Query is made up of whitespace-separated terms, all of which must match a task.Task for the
+// Query to match the task.Task. Each term is made up of a field, an operator, and a value, e.g.,
+// "priority<5". Values that contain whitespace can be surrounded by double quotes, e.g.,
+// name~"mow the lawn".
+//
+// These are the fields and the operators that they support.
+//
+//	state     :, !=                 (ready, blocked, running, or finished)
+//	priority  :, !=, <, <=, >, >=   (an integer)
+//	tag       :, !=                 (a tag; ":" means the task.Task has the tag)
+//	name      :, !=, ~              (a name; "~" means the name contains the value)
+//	created   :, !=, <, <=, >, >=   (a date, i.e., 2019-01-31 or "2019-01-31 13:30")
+//	deadline  :, !=, <, <=, >, >=   (a date; task.Task's without a deadline never match)
+//
+// The ":" operator can also be written as "=". Dates are in local time. A date without a time
+// refers to the start of that day, so "created>2019-01-31" matches task.Task's created any time
+// after midnight on January 31, 2019. The ":" and "!=" operators compare with the whole day (or
+// the whole minute, for a date with a time), so "created:2019-01-31" matches task.Task's created
+// any time on January 31, 2019.
+//
+// For example, the Query "state:running priority<5 tag:infra" matches the running task.Task's
+// with a priority less than 5 that are tagged with "infra".
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankeesler/anwork/task"
+)
+
+// A Field is the property of a task.Task that a Term is about.
+type Field string
+
+// These are the Field's that can be used in a Term.
+const (
+	FieldState    Field = "state"
+	FieldPriority Field = "priority"
+	FieldTag      Field = "tag"
+	FieldName     Field = "name"
+	FieldCreated  Field = "created"
+	FieldDeadline Field = "deadline"
+)
+
+// An Op is the comparison that a Term makes between a Field and a value.
+type Op string
+
+// These are the Op's that can be used in a Term. OpContains only applies to strings.
+const (
+	OpEqual          Op = ":"
+	OpNotEqual       Op = "!="
+	OpLess           Op = "<"
+	OpLessOrEqual    Op = "<="
+	OpGreater        Op = ">"
+	OpGreaterOrEqual Op = ">="
+	OpContains       Op = "~"
+)
+
+// These are the strings that can be written as operators in a term, longest first so that, e.g.,
+// "<=" is found before "<". The "=" operator is another way to write OpEqual.
+var opStrings = []string{"!=", "<=", ">=", ":", "=", "<", ">", "~"}
+
+// These are the Op's that each Field supports.
+var fieldOps = map[Field][]Op{
+	FieldState:    []Op{OpEqual, OpNotEqual},
+	FieldPriority: []Op{OpEqual, OpNotEqual, OpLess, OpLessOrEqual, OpGreater, OpGreaterOrEqual},
+	FieldTag:      []Op{OpEqual, OpNotEqual},
+	FieldName:     []Op{OpEqual, OpNotEqual, OpContains},
+	FieldCreated:  []Op{OpEqual, OpNotEqual, OpLess, OpLessOrEqual, OpGreater, OpGreaterOrEqual},
+	FieldDeadline: []Op{OpEqual, OpNotEqual, OpLess, OpLessOrEqual, OpGreater, OpGreaterOrEqual},
+}
+
+// A Term is a single comparison in a Query, e.g., "priority<5".
+type Term struct {
+	Field Field
+	Op    Op
+
+	// This is the value as it was written in the Query.
+	Value string
+
+	// These are the parsed forms of the Value. For FieldState, State is set. For FieldPriority,
+	// Number is set to the priority. For FieldCreated and FieldDeadline, Number is set to the
+	// start of the date and Until to the start of the next day (or minute, for a date with a
+	// time), both in seconds since January 1, 1970.
+	State  task.State
+	Number int64
+	Until  int64
+}
+
+// A Query is a list of Term's that must all match a task.Task.
+type Query struct {
+	Terms []Term
+}
+
+// Parse parses a Query from a string. An empty string results in a Query that matches every
+// task.Task.
+func Parse(s string) (*Query, error) {
+	words, err := split(s)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Query{Terms: make([]Term, 0, len(words))}
+	for _, word := range words {
+		term, err := parseTerm(word)
+		if err != nil {
+			return nil, err
+		}
+		q.Terms = append(q.Terms, term)
+	}
+
+	return q, nil
+}
+
+// String returns a string that can be passed to Parse to get the same Query.
+func (q *Query) String() string {
+	terms := make([]string, len(q.Terms))
+	for i, term := range q.Terms {
+		terms[i] = term.String()
+	}
+	return strings.Join(terms, " ")
+}
+
+// String returns the string form of the Term, i.e., "priority<5".
+func (t *Term) String() string {
+	value := t.Value
+	if value == "" || strings.ContainsAny(value, " \t\n\"") {
+		value = strconv.Quote(value)
+	}
+	return fmt.Sprintf("%s%s%s", t.Field, t.Op, value)
+}
+
+// Matches returns whether or not the task.Task matches every Term in the Query.
+func (q *Query) Matches(t *task.Task) bool {
+	for i := range q.Terms {
+		if !q.Terms[i].Matches(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches returns whether or not the task.Task matches the Term.
+func (t *Term) Matches(tk *task.Task) bool {
+	switch t.Field {
+	case FieldState:
+		return compareEquality(t.Op, tk.State == t.State)
+	case FieldPriority:
+		return compareNumber(t.Op, int64(tk.Priority), t.Number)
+	case FieldTag:
+		return compareEquality(t.Op, tk.HasTag(t.Value))
+	case FieldName:
+		if t.Op == OpContains {
+			return strings.Contains(tk.Name, t.Value)
+		}
+		return compareEquality(t.Op, tk.Name == t.Value)
+	case FieldCreated:
+		return compareDate(t.Op, tk.StartDate, t.Number, t.Until)
+	case FieldDeadline:
+		return tk.Deadline != 0 && compareDate(t.Op, tk.Deadline, t.Number, t.Until)
+	default:
+		return false
+	}
+}
+
+func compareEquality(op Op, equal bool) bool {
+	if op == OpNotEqual {
+		return !equal
+	}
+	return equal
+}
+
+func compareNumber(op Op, actual, expected int64) bool {
+	switch op {
+	case OpEqual:
+		return actual == expected
+	case OpNotEqual:
+		return actual != expected
+	case OpLess:
+		return actual < expected
+	case OpLessOrEqual:
+		return actual <= expected
+	case OpGreater:
+		return actual > expected
+	case OpGreaterOrEqual:
+		return actual >= expected
+	default:
+		return false
+	}
+}
+
+// compareDate compares a date with the one that starts at start and ends before until (see
+// Term.Until).
+func compareDate(op Op, actual, start, until int64) bool {
+	switch op {
+	case OpEqual:
+		return actual >= start && actual < until
+	case OpNotEqual:
+		return actual < start || actual >= until
+	default:
+		return compareNumber(op, actual, start)
+	}
+}
+
+// split splits a string into words separated by whitespace, where double quotes can be used to
+// include whitespace in a word.
+func split(s string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord, inQuotes := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"':
+			inWord, inQuotes = true, !inQuotes
+			word.WriteByte(c)
+		case c == '\\' && inQuotes && i+1 < len(s):
+			word.WriteByte(c)
+			i++
+			word.WriteByte(s[i])
+		case (c == ' ' || c == '\t' || c == '\n') && !inQuotes:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			inWord = true
+			word.WriteByte(c)
+		}
+	}
+
+	if inQuotes {
+		return nil, &syntaxError{query: s, reason: "unterminated quote"}
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+
+	return words, nil
+}
+
+func parseTerm(word string) (Term, error) {
+	index := strings.IndexAny(word, ":=!<>~")
+	if index == -1 {
+		return Term{}, &syntaxError{query: word, reason: "missing operator"}
+	}
+
+	var op Op
+	for _, opString := range opStrings {
+		if strings.HasPrefix(word[index:], opString) {
+			op = Op(opString)
+			break
+		}
+	}
+	if op == "" {
+		return Term{}, &syntaxError{query: word, reason: "missing operator"}
+	}
+
+	term := Term{Field: Field(strings.ToLower(word[:index])), Op: op}
+	if op == "=" {
+		term.Op = OpEqual
+	}
+
+	value := word[index+len(op):]
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return Term{}, &syntaxError{query: word, reason: "invalid quoted value"}
+		}
+		value = unquoted
+	} else if strings.Contains(value, `"`) {
+		return Term{}, &syntaxError{query: word, reason: "invalid quoted value"}
+	}
+	term.Value = value
+
+	supportedOps, ok := fieldOps[term.Field]
+	if !ok {
+		return Term{}, &syntaxError{query: word, reason: fmt.Sprintf("unknown field '%s'", term.Field)}
+	}
+	if !containsOp(supportedOps, term.Op) {
+		return Term{}, &syntaxError{
+			query:  word,
+			reason: fmt.Sprintf("field '%s' does not support operator '%s'", term.Field, term.Op),
+		}
+	}
+
+	if err := parseValue(&term); err != nil {
+		return Term{}, &syntaxError{query: word, reason: err.Error()}
+	}
+
+	return term, nil
+}
+
+func parseValue(term *Term) error {
+	switch term.Field {
+	case FieldState:
+		for _, state := range []task.State{
+			task.StateReady,
+			task.StateBlocked,
+			task.StateRunning,
+			task.StateFinished,
+		} {
+			if strings.EqualFold(term.Value, string(state)) {
+				term.State = state
+				return nil
+			}
+		}
+		return fmt.Errorf("invalid state '%s'", term.Value)
+
+	case FieldPriority:
+		priority, err := strconv.Atoi(term.Value)
+		if err != nil {
+			return fmt.Errorf("invalid priority '%s'", term.Value)
+		}
+		term.Number = int64(priority)
+
+	case FieldCreated, FieldDeadline:
+		start, until, err := parseDate(term.Value)
+		if err != nil {
+			return fmt.Errorf("invalid date '%s'", term.Value)
+		}
+		term.Number, term.Until = start, until
+
+	case FieldTag, FieldName:
+		if term.Value == "" {
+			return fmt.Errorf("missing %s", term.Field)
+		}
+	}
+
+	return nil
+}
+
+// parseDate returns the start of a date, and the start of the next minute or day, depending on
+// whether or not it has a time.
+func parseDate(value string) (int64, int64, error) {
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local); err == nil {
+		return t.Unix(), t.Add(time.Minute).Unix(), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		// Not every day is 24 hours long in local time.
+		return t.Unix(), t.AddDate(0, 0, 1).Unix(), nil
+	}
+	return 0, 0, fmt.Errorf("invalid date '%s'", value)
+}
+
+func containsOp(ops []Op, op Op) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
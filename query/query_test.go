@@ -0,0 +1,184 @@
+package query_test
+
+import (
+	"time"
+
+	"github.com/ankeesler/anwork/query"
+	"github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Query", func() {
+	var (
+		jan1, jan2, jan15, feb1 int64
+		tasks                   []*task.Task
+	)
+
+	BeforeEach(func() {
+		jan1 = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.Local).Unix()
+		jan2 = time.Date(2026, time.January, 2, 0, 0, 0, 0, time.Local).Unix()
+		jan15 = time.Date(2026, time.January, 15, 13, 30, 0, 0, time.Local).Unix()
+		feb1 = time.Date(2026, time.February, 1, 0, 0, 0, 0, time.Local).Unix()
+
+		tasks = []*task.Task{
+			&task.Task{
+				Name:      "deploy-service",
+				ID:        1,
+				StartDate: jan1,
+				Priority:  3,
+				State:     task.StateRunning,
+				Tags:      []string{"infra"},
+			},
+			&task.Task{
+				Name:      "write docs",
+				ID:        2,
+				StartDate: jan15,
+				Priority:  10,
+				State:     task.StateReady,
+				Deadline:  feb1,
+			},
+			&task.Task{
+				Name:      "deploy-website",
+				ID:        3,
+				StartDate: feb1,
+				Priority:  5,
+				State:     task.StateFinished,
+				Tags:      []string{"infra", "web"},
+				Deadline:  jan15,
+			},
+		}
+	})
+
+	matching := func(s string) []string {
+		q, err := query.Parse(s)
+		Expect(err).NotTo(HaveOccurred())
+
+		names := []string{}
+		for _, t := range tasks {
+			if q.Matches(t) {
+				names = append(names, t.Name)
+			}
+		}
+		return names
+	}
+
+	Describe("Parse", func() {
+		It("parses each term of the query", func() {
+			q, err := query.Parse(`state:running  priority<=5 tag!=web name~"deploy serv" created>2026-01-01`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(q.Terms).To(Equal([]query.Term{
+				query.Term{Field: query.FieldState, Op: query.OpEqual, Value: "running", State: task.StateRunning},
+				query.Term{Field: query.FieldPriority, Op: query.OpLessOrEqual, Value: "5", Number: 5},
+				query.Term{Field: query.FieldTag, Op: query.OpNotEqual, Value: "web"},
+				query.Term{Field: query.FieldName, Op: query.OpContains, Value: "deploy serv"},
+				query.Term{Field: query.FieldCreated, Op: query.OpGreater, Value: "2026-01-01", Number: jan1, Until: jan2},
+			}))
+		})
+
+		It("treats '=' like ':'", func() {
+			q, err := query.Parse("priority=5")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(q.Terms[0].Op).To(Equal(query.OpEqual))
+		})
+
+		It("parses an empty query", func() {
+			q, err := query.Parse("   ")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(q.Terms).To(BeEmpty())
+		})
+
+		Context("when the query is invalid", func() {
+			It("returns a helpful error", func() {
+				_, err := query.Parse("state")
+				Expect(err).To(MatchError("invalid query 'state': missing operator"))
+
+				_, err = query.Parse("color:red")
+				Expect(err).To(MatchError("invalid query 'color:red': unknown field 'color'"))
+
+				_, err = query.Parse("state<ready")
+				Expect(err).To(MatchError("invalid query 'state<ready': field 'state' does not support operator '<'"))
+
+				_, err = query.Parse("state:sleeping")
+				Expect(err).To(MatchError("invalid query 'state:sleeping': invalid state 'sleeping'"))
+
+				_, err = query.Parse("priority>high")
+				Expect(err).To(MatchError("invalid query 'priority>high': invalid priority 'high'"))
+
+				_, err = query.Parse("created>yesterday")
+				Expect(err).To(MatchError("invalid query 'created>yesterday': invalid date 'yesterday'"))
+
+				_, err = query.Parse("tag:")
+				Expect(err).To(MatchError("invalid query 'tag:': missing tag"))
+
+				_, err = query.Parse(`name:"deploy`)
+				Expect(err).To(MatchError(`invalid query 'name:"deploy': unterminated quote`))
+			})
+		})
+	})
+
+	Describe("String", func() {
+		It("returns a string that parses to the same query", func() {
+			q, err := query.Parse(`state=Running name~"deploy serv" deadline<"2026-01-15 13:30"`)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(q.String()).To(Equal(`state:Running name~"deploy serv" deadline<"2026-01-15 13:30"`))
+
+			sameQ, err := query.Parse(q.String())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(sameQ).To(Equal(q))
+		})
+	})
+
+	Describe("Matches", func() {
+		It("matches tasks by state", func() {
+			Expect(matching("state:running")).To(Equal([]string{"deploy-service"}))
+			Expect(matching("state!=running")).To(Equal([]string{"write docs", "deploy-website"}))
+		})
+
+		It("matches tasks by priority", func() {
+			Expect(matching("priority<5")).To(Equal([]string{"deploy-service"}))
+			Expect(matching("priority<=5")).To(Equal([]string{"deploy-service", "deploy-website"}))
+			Expect(matching("priority>5")).To(Equal([]string{"write docs"}))
+			Expect(matching("priority>=5")).To(Equal([]string{"write docs", "deploy-website"}))
+			Expect(matching("priority:5")).To(Equal([]string{"deploy-website"}))
+		})
+
+		It("matches tasks by tag", func() {
+			Expect(matching("tag:infra")).To(Equal([]string{"deploy-service", "deploy-website"}))
+			Expect(matching("tag!=web")).To(Equal([]string{"deploy-service", "write docs"}))
+		})
+
+		It("matches tasks by name", func() {
+			Expect(matching("name~deploy")).To(Equal([]string{"deploy-service", "deploy-website"}))
+			Expect(matching(`name:"write docs"`)).To(Equal([]string{"write docs"}))
+			Expect(matching(`name!="write docs"`)).To(Equal([]string{"deploy-service", "deploy-website"}))
+		})
+
+		It("matches tasks by creation date", func() {
+			Expect(matching("created>2026-01-01")).To(Equal([]string{"write docs", "deploy-website"}))
+			Expect(matching(`created<"2026-01-15 13:30"`)).To(Equal([]string{"deploy-service"}))
+		})
+
+		It("matches tasks created or due any time on a date with ':' and '!='", func() {
+			Expect(matching("created:2026-01-15")).To(Equal([]string{"write docs"}))
+			Expect(matching("created!=2026-01-15")).To(Equal([]string{"deploy-service", "deploy-website"}))
+			Expect(matching(`created:"2026-01-15 13:30"`)).To(Equal([]string{"write docs"}))
+			Expect(matching(`created:"2026-01-15 13:29"`)).To(BeEmpty())
+			Expect(matching("deadline:2026-01-15")).To(Equal([]string{"deploy-website"}))
+			Expect(matching("deadline!=2026-01-15")).To(Equal([]string{"write docs"}))
+		})
+
+		It("matches tasks by deadline, ignoring tasks without a deadline", func() {
+			Expect(matching("deadline<2026-02-01")).To(Equal([]string{"deploy-website"}))
+			Expect(matching("deadline!=2026-02-01")).To(Equal([]string{"deploy-website"}))
+		})
+
+		It("requires every term to match", func() {
+			Expect(matching("tag:infra priority>3 name~deploy")).To(Equal([]string{"deploy-website"}))
+		})
+
+		It("matches every task with an empty query", func() {
+			Expect(matching("")).To(HaveLen(3))
+		})
+	})
+})
@@ -0,0 +1,12 @@
+package query
+
+import "github.com/ankeesler/anwork/task"
+
+// A Repo is a task.Repo that can find the task.Task's that match a Query itself, e.g., by
+// translating the Query into a database query. A task.Repo does not need to implement this
+// interface; the task.Task's that match a Query can always be found by calling Query.Matches on
+// each of the task.Task's in the task.Repo.
+type Repo interface {
+	// Get the task.Task's that match a Query, in no particular order.
+	TasksMatching(q *Query) ([]*task.Task, error)
+}
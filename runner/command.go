@@ -1,6 +1,8 @@
 package runner
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -9,8 +11,11 @@ import (
 	"strings"
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	"github.com/ankeesler/anwork/manager"
+	"github.com/ankeesler/anwork/query"
 	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
 )
 
 //go:generate go run ../cmd/genclidoc/main.go ../doc/CLI.md
@@ -34,11 +39,81 @@ type command struct {
 	// This slice holds the name(s) of the argument(s) that is(/are) expected by the Command.
 	Args []string
 
+	// This slice holds the option(s) (e.g., "--tag infra") that may be passed to the Command.
+	Options []option
+
 	// This is the functionality that runs when this Command is invoked. Note that args[0] is
 	// always the Name of the command. The o parameter to this function is an output
 	// stream to which all output should be written. The function should returns a non-nil error
 	// iff an error occured.
 	Action func(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error
+
+	// These are the values of the options that were passed to the Command, keyed by option Name.
+	// Boolean options are given the value "true".
+	optionValues map[string]string
+	// This is the Format in which the Command writes its result, if it has one.
+	format Format
+	// This is how the Command migrates the context, or nil if the context cannot be migrated.
+	migrateContext ContextMigrator
+	// These are the contexts that the Command lists, creates, copies, or deletes, or nil if there
+	// are none.
+	contexts task.Contexts
+	// This is how the Command follows the journal, or nil if it cannot be followed.
+	followEvents EventFollower
+	// These are the webhooks that the Command adds, lists, or removes, or nil if there are none.
+	webhooks webhook.Repo
+	// This is how the Command logs in, or nil if there is nothing to log in to.
+	login Login
+	// This is how the Command tells the time.
+	clock clock.Clock
+}
+
+// An option is passed to a Command via "--name value", "--name=value", or, if the option does
+// not take a value, "--name".
+type option struct {
+	Name, Description string
+
+	// This is the name of the value that the option expects, or "" if the option is a boolean.
+	Value string
+}
+
+// Usage returns the usage string of an option, i.e., "[--tag tag]".
+func (o *option) Usage() string {
+	if o.Value == "" {
+		return fmt.Sprintf("[--%s]", o.Name)
+	}
+	return fmt.Sprintf("[--%s %s]", o.Name, o.Value)
+}
+
+// Find the option with the provided name.
+func (c *command) findOption(name string) *option {
+	for _, o := range c.Options {
+		if o.Name == name {
+			return &o
+		}
+	}
+	return nil
+}
+
+// Write the result of the Command in the Format of the Command.
+func (c *command) write(o io.Writer, r result) error {
+	return formatters[c.format](o, r)
+}
+
+// Write more of the result of the Command, after the part that was already written, e.g., when the
+// Command follows the journal. A table is not given another header.
+func (c *command) writeMore(o io.Writer, r result) error {
+	if comma, ok := tableCommas[c.format]; ok {
+		_, rows := r.table()
+		return writeRows(o, comma, rows)
+	}
+	return c.write(o, r)
+}
+
+// Get the value of the option with the provided name, and whether or not it was passed.
+func (c *command) option(name string) (string, bool) {
+	value, ok := c.optionValues[name]
+	return value, ok
 }
 
 // These are the Command's used by the anwork application.
@@ -85,7 +160,15 @@ var commands = []command{
 		Alias:       "s",
 		Description: "Show the current tasks, or the details of a specific task",
 		Args:        []string{"[task-name]"},
-		Action:      showAction,
+		Options: []option{
+			option{Name: "tag", Value: "tag", Description: "Only show tasks with this tag"},
+			option{
+				Name:        "where",
+				Value:       "query",
+				Description: "Only show tasks that match this query, e.g., 'state:running priority<5 tag:infra created>2019-01-31 name~deploy'",
+			},
+		},
+		Action: showAction,
 	},
 	command{
 		Name:        "note",
@@ -100,6 +183,36 @@ var commands = []command{
 		Args:        []string{"task-name", "priority"},
 		Action:      setPriorityAction,
 	},
+	command{
+		Name:        "set-deadline",
+		Description: "Set the deadline of a task (YYYY-MM-DD or 'YYYY-MM-DD HH:MM'), or 'none' to clear it",
+		Args:        []string{"task-name", "deadline"},
+		Action:      setDeadlineAction,
+	},
+	command{
+		Name:        "add-dependency",
+		Description: "Make a task depend on another task, blocking it until the other task is finished",
+		Args:        []string{"task-name", "dependency-name"},
+		Action:      addDependencyAction,
+	},
+	command{
+		Name:        "remove-dependency",
+		Description: "Remove the dependency of a task on another task",
+		Args:        []string{"task-name", "dependency-name"},
+		Action:      removeDependencyAction,
+	},
+	command{
+		Name:        "tag",
+		Description: "Add a tag to a task",
+		Args:        []string{"task-name", "tag"},
+		Action:      tagAction,
+	},
+	command{
+		Name:        "untag",
+		Description: "Remove a tag from a task",
+		Args:        []string{"task-name", "tag"},
+		Action:      untagAction,
+	},
 	command{
 		Name:        "set-running",
 		Alias:       "sr",
@@ -133,7 +246,10 @@ var commands = []command{
 		Alias:       "j",
 		Description: "Show the journal; optionally pass a task name to only show events for that task",
 		Args:        []string{"[task-name]"},
-		Action:      journalAction,
+		Options: []option{
+			option{Name: "follow", Description: "Keep printing events as they are created; only works with the API"},
+		},
+		Action: journalAction,
 	},
 	command{
 		Name:        "archive",
@@ -147,12 +263,91 @@ var commands = []command{
 		Args:        []string{"from", "to"},
 		Action:      renameAction,
 	},
+	command{
+		Name:        "undo",
+		Description: "Undo the last operation, or the last n operations",
+		Args:        []string{"[n]"},
+		Action:      undoAction,
+	},
+	command{
+		Name:        "redo",
+		Description: "Redo the last undone operation",
+		Args:        []string{},
+		Action:      redoAction,
+	},
+	command{
+		Name:        "rebuild",
+		Description: "Check that the tasks match what the journal says by replaying it",
+		Args:        []string{},
+		Action:      rebuildAction,
+	},
+	command{
+		Name:        "migrate-context",
+		Description: "Upgrade the context to its latest format and describe what changed",
+		Args:        []string{},
+		Action:      migrateContextAction,
+	},
+	command{
+		Name:        "list-contexts",
+		Description: "Print the names of the contexts",
+		Args:        []string{},
+		Action:      listContextsAction,
+	},
+	command{
+		Name:        "create-context",
+		Description: "Create an empty context",
+		Args:        []string{"name"},
+		Action:      createContextAction,
+	},
+	command{
+		Name:        "copy-context",
+		Description: "Create a context with a copy of the tasks, journal, and dependencies of another context",
+		Args:        []string{"from", "to"},
+		Action:      copyContextAction,
+	},
+	command{
+		Name:        "delete-context",
+		Description: "Delete a context and everything in it",
+		Args:        []string{"name"},
+		Action:      deleteContextAction,
+	},
+	command{
+		Name:        "webhook",
+		Description: "Add a webhook to which events are sent, list the webhooks, or remove one by its ID; only works with the API, and not when it uses the fs repo",
+		Args:        []string{"add|list|remove", "[url|id]"},
+		Options: []option{
+			option{
+				Name:        "secret",
+				Value:       "secret",
+				Description: "Sign the events sent to the added webhook with this secret; by default, one is generated and printed",
+			},
+			option{
+				Name:        "types",
+				Value:       "types",
+				Description: "Only send these comma-separated types of events to the added webhook, e.g., set-state,note",
+			},
+			option{
+				Name:        "states",
+				Value:       "states",
+				Description: "Only send the set-state events that move a task to one of these comma-separated states to the added webhook, e.g., blocked,finished",
+			},
+		},
+		Action: webhookAction,
+	},
+	command{
+		Name:        "login",
+		Description: "Log in to the API as a user, whose password is read from the ANWORK_API_PASSWORD env var or prompted for; only works with the API",
+		Args:        []string{"name"},
+		Action:      loginAction,
+	},
 }
 
-// Find the command with the provided name.
+// Find the command with the provided name. It returns a copy of the command, so that the settings
+// that a Runner gives it are never written onto the commands that every Runner shares.
 func findCommand(name string) *command {
-	for _, c := range commands {
-		if c.Name == name || c.Alias == name {
+	for i := range commands {
+		if commands[i].Name == name || commands[i].Alias == name {
+			c := commands[i]
 			return &c
 		}
 	}
@@ -202,11 +397,40 @@ func formatDuration(duration time.Duration) string {
 	return fmt.Sprintf("%s", duration.String())
 }
 
+// Parse a deadline, which is either a date (i.e., "2018-12-25"), a date and a time (i.e.,
+// "2018-12-25 13:30"), or "none". A date without a time refers to the end of that day. The
+// deadline "none" is returned as 0.
+func parseDeadline(str string) (int64, error) {
+	if str == "none" {
+		return 0, nil
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02 15:04", str, time.Local); err == nil {
+		return date.Unix(), nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", str, time.Local)
+	if err != nil {
+		return 0, fmt.Errorf("invalid deadline: '%s'", str)
+	}
+	endOfDay := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 0, 0, time.Local)
+	return endOfDay.Unix(), nil
+}
+
+func formatTimeRemaining(deadline int64, now time.Time) string {
+	remaining := time.Unix(deadline, 0).Sub(now).Round(time.Minute)
+	if remaining < 0 {
+		return fmt.Sprintf("overdue by %s", formatDuration(-remaining))
+	}
+	return fmt.Sprintf("%s remaining", formatDuration(remaining))
+}
+
 func versionAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
-	fmt.Fprintln(o, "ANWORK Version =", Version)
-	fmt.Fprintln(o, "ANWORK Build Hash =", buildInfo.Hash)
-	fmt.Fprintln(o, "ANWORK Build Date =", buildInfo.Date)
-	return nil
+	return cmd.write(o, &versionResult{
+		Version: Version,
+		Hash:    buildInfo.Hash,
+		Date:    buildInfo.Date,
+	})
 }
 
 func resetAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
@@ -228,18 +452,16 @@ func resetAction(cmd *command, args []string, o io.Writer, m manager.Manager, bu
 }
 
 func findCreateEvent(m manager.Manager, taskID int) (*task.Event, error) {
-	events, err := m.Events()
-	if err != nil {
+	events, err := m.EventsMatching(&query.EventQuery{
+		TaskID: &taskID,
+		Types:  []task.EventType{task.EventTypeCreate},
+		Limit:  1,
+	})
+	if err != nil || len(events) == 0 {
 		return nil, err
 	}
 
-	for _, e := range events {
-		if e.Type == task.EventTypeCreate && e.TaskID == taskID {
-			return e, nil
-		}
-	}
-
-	return nil, nil
+	return events[0], nil
 }
 
 func summaryAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
@@ -247,34 +469,38 @@ func summaryAction(cmd *command, args []string, o io.Writer, m manager.Manager,
 	if err != nil {
 		return fmt.Errorf("Cannot convert days %s to number: %s", args[1], err.Error())
 	}
-	_ = daysNum
 
-	now := time.Now()
-	es, err := m.Events()
+	// Only the events after the start of the days are within them.
+	since := cmd.clock.Now().Add(-time.Duration(daysNum*24) * time.Hour)
+	es, err := m.EventsMatching(&query.EventQuery{
+		Types: []task.EventType{task.EventTypeSetState},
+		Since: since.Unix() + 1,
+		Order: query.OrderDescending,
+	})
 	if err != nil {
 		return err
 	}
 
-	for i := len(es) - 1; i >= 0; i-- {
-		e := es[i]
-		isFinished := e.Type == task.EventTypeSetState && strings.Contains(e.Title, "to Finished")
-		eDate := time.Unix(e.Date, 0)
-		isWithinDays := eDate.Add(time.Duration(daysNum*24) * time.Hour).After(now)
-		if isFinished && isWithinDays {
-			createE, err := findCreateEvent(m, e.TaskID)
-			if err != nil {
-				return err
-			}
+	r := &summaryResult{entries: []*summaryEntry{}}
+	for _, e := range es {
+		if e.NewValue != string(task.StateFinished) {
+			continue
+		}
 
-			fmt.Fprintf(o, "[%s]: %s\n", formatDate(e.Date), e.Title)
-			if createE != nil {
-				createEDate := time.Unix(createE.Date, 0)
-				fmt.Fprintf(o, "  took %s\n", formatDuration(eDate.Sub(createEDate)))
-			}
+		createE, err := findCreateEvent(m, e.TaskID)
+		if err != nil {
+			return err
+		}
+
+		entry := &summaryEntry{Event: e}
+		if createE != nil {
+			took := e.Date - createE.Date
+			entry.Took = &took
 		}
+		r.entries = append(r.entries, entry)
 	}
 
-	return nil
+	return cmd.write(o, r)
 }
 
 func createAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
@@ -329,36 +555,47 @@ func deleteAllAction(cmd *command, args []string, o io.Writer, m manager.Manager
 
 func showAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
 	if len(args) == 1 {
-		tasks, err := m.Tasks()
-		if err != nil {
-			return err
+		var tasks []*task.Task
+		if where, ok := cmd.option("where"); ok {
+			q, err := query.Parse(where)
+			if err != nil {
+				return err
+			}
+
+			if tasks, err = m.TasksMatching(q); err != nil {
+				return err
+			}
+		} else {
+			var err error
+			if tasks, err = m.Tasks(); err != nil {
+				return err
+			}
 		}
 
-		printer := func(state task.State) {
-			fmt.Fprintf(o, "%s tasks:\n", strings.ToUpper(string(state)))
+		if tag, ok := cmd.option("tag"); ok {
+			taggedTasks := make([]*task.Task, 0, len(tasks))
 			for _, task := range tasks {
-				if task.State == state {
-					fmt.Fprintf(o, "  %s (%d)\n", task.Name, task.ID)
+				if task.HasTag(tag) {
+					taggedTasks = append(taggedTasks, task)
 				}
 			}
+			tasks = taggedTasks
 		}
-		printer(task.StateRunning)
-		printer(task.StateBlocked)
-		printer(task.StateReady)
-		printer(task.StateFinished)
+
+		return cmd.write(o, &tasksResult{tasks: tasks})
 	} else {
 		t, err := parseTaskSpec(args[1], m)
 		if err != nil {
 			return err
 		}
 
-		fmt.Fprintf(o, "Name: %s\n", t.Name)
-		fmt.Fprintf(o, "ID: %d\n", t.ID)
-		fmt.Fprintf(o, "Created: %s\n", formatDate(t.StartDate))
-		fmt.Fprintf(o, "Priority: %d\n", t.Priority)
-		fmt.Fprintf(o, "State: %s\n", strings.ToUpper(string(t.State)))
+		dependencies, err := m.Dependencies(t.Name)
+		if err != nil {
+			return err
+		}
+
+		return cmd.write(o, &taskDetailsResult{Task: t, DependsOn: dependencies, now: cmd.clock.Now()})
 	}
-	return nil
 }
 
 func noteAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
@@ -368,7 +605,7 @@ func noteAction(cmd *command, args []string, o io.Writer, m manager.Manager, bui
 	}
 
 	if err = m.Note(t.Name, args[2]); err != nil {
-		return fmt.Errorf("cannot add note: %s", err.Error())
+		return fmt.Errorf("cannot add note: %w", err)
 	}
 
 	return nil
@@ -386,7 +623,87 @@ func setPriorityAction(cmd *command, args []string, o io.Writer, m manager.Manag
 	}
 
 	if err := m.SetPriority(t.Name, prio); err != nil {
-		return fmt.Errorf("cannot set priority: %s", err.Error())
+		return fmt.Errorf("cannot set priority: %w", err)
+	}
+
+	return nil
+}
+
+func setDeadlineAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	t, err := parseTaskSpec(args[1], m)
+	if err != nil {
+		return err
+	}
+
+	deadline, err := parseDeadline(args[2])
+	if err != nil {
+		return fmt.Errorf("cannot set deadline: %w", err)
+	}
+
+	if err := m.SetDeadline(t.Name, deadline); err != nil {
+		return fmt.Errorf("cannot set deadline: %w", err)
+	}
+
+	return nil
+}
+
+func addDependencyAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	t, err := parseTaskSpec(args[1], m)
+	if err != nil {
+		return err
+	}
+
+	dependsOn, err := parseTaskSpec(args[2], m)
+	if err != nil {
+		return err
+	}
+
+	if err := m.AddDependency(t.Name, dependsOn.Name); err != nil {
+		return fmt.Errorf("cannot add dependency: %w", err)
+	}
+
+	return nil
+}
+
+func removeDependencyAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	t, err := parseTaskSpec(args[1], m)
+	if err != nil {
+		return err
+	}
+
+	dependsOn, err := parseTaskSpec(args[2], m)
+	if err != nil {
+		return err
+	}
+
+	if err := m.RemoveDependency(t.Name, dependsOn.Name); err != nil {
+		return fmt.Errorf("cannot remove dependency: %w", err)
+	}
+
+	return nil
+}
+
+func tagAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	t, err := parseTaskSpec(args[1], m)
+	if err != nil {
+		return err
+	}
+
+	if err := m.AddTag(t.Name, args[2]); err != nil {
+		return fmt.Errorf("cannot add tag: %w", err)
+	}
+
+	return nil
+}
+
+func untagAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	t, err := parseTaskSpec(args[1], m)
+	if err != nil {
+		return err
+	}
+
+	if err := m.RemoveTag(t.Name, args[2]); err != nil {
+		return fmt.Errorf("cannot remove tag: %w", err)
 	}
 
 	return nil
@@ -413,7 +730,7 @@ func setStateAction(cmd *command, args []string, o io.Writer, m manager.Manager,
 	}
 
 	if err := m.SetState(t.Name, state); err != nil {
-		return fmt.Errorf("cannot set state: %s", err.Error())
+		return fmt.Errorf("cannot set state: %w", err)
 	}
 
 	return nil
@@ -429,63 +746,312 @@ func journalAction(cmd *command, args []string, o io.Writer, m manager.Manager,
 		}
 	}
 
-	es, err := m.Events()
+	q := &query.EventQuery{Order: query.OrderDescending}
+	if t != nil {
+		q.TaskID = &t.ID
+	}
+	es, err := m.EventsMatching(q)
 	if err != nil {
 		return err
 	}
 
-	for i := len(es) - 1; i >= 0; i-- {
-		e := es[i]
-		if t == nil || t.ID == e.TaskID {
-			fmt.Fprintf(o, "[%s]: %s\n", formatDate(e.Date), e.Title)
-		}
+	_, follow := cmd.option("follow")
+	if follow && cmd.followEvents == nil {
+		return errors.New("cannot follow the journal: only the API can be followed")
 	}
-	return nil
+
+	r := &eventsResult{events: es}
+	if err := cmd.write(o, r); err != nil || !follow {
+		return err
+	}
+
+	// The journal is followed from the newest event, which might not be about the task.
+	newest, err := m.EventsMatching(&query.EventQuery{Limit: 1, Order: query.OrderDescending})
+	if err != nil {
+		return err
+	}
+	lastEventID := -1
+	if len(newest) > 0 {
+		lastEventID = newest[0].ID
+	}
+
+	return cmd.followEvents(lastEventID, func(e *task.Event) error {
+		if t != nil && t.ID != e.TaskID {
+			return nil
+		}
+		return cmd.writeMore(o, &eventsResult{events: []*task.Event{e}})
+	})
 }
 
 func archiveAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
-	tasks, err := m.Tasks()
+	return m.Archive()
+}
+
+func renameAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	from, err := parseTaskSpec(args[1], m)
 	if err != nil {
 		return err
 	}
 
-	taskNames := make([]string, len(tasks))
-	taskStates := make([]task.State, len(tasks))
-	for i := range tasks {
-		taskNames[i] = tasks[i].Name
-		taskStates[i] = tasks[i].State
+	fromName := from.Name
+	toName := args[2]
+	if err := m.Rename(fromName, toName); err != nil {
+		return fmt.Errorf("unable to rename task %s to %s: %w", fromName, toName, err)
 	}
 
-	errMsgs := []string{}
-	for i, taskName := range taskNames {
-		if taskStates[i] == task.StateFinished {
-			if err := m.Delete(taskName); err != nil {
-				msg := fmt.Sprintf("\n\tunable to delete task %s: %s", taskName, err.Error())
-				errMsgs = append(errMsgs, msg)
-			}
+	return nil
+}
+
+func undoAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	n := 1
+	if len(args) > 1 {
+		var err error
+		if n, err = strconv.Atoi(args[1]); err != nil || n < 1 {
+			return fmt.Errorf("cannot undo: invalid number of operations: '%s'", args[1])
 		}
 	}
 
-	if len(errMsgs) > 0 {
-		return errors.New(strings.Join(errMsgs, ""))
+	if err := m.Undo(n); err != nil {
+		return fmt.Errorf("cannot undo: %w", err)
 	}
 
 	return nil
 }
 
-func renameAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
-	from, err := parseTaskSpec(args[1], m)
+func redoAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	if err := m.Redo(); err != nil {
+		return fmt.Errorf("cannot redo: %w", err)
+	}
+
+	return nil
+}
+
+func rebuildAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	problems, err := m.Replay()
 	if err != nil {
+		return fmt.Errorf("cannot rebuild: %s", err.Error())
+	}
+
+	if err := cmd.write(o, &problemsResult{problems: problems}); err != nil {
 		return err
 	}
 
-	fromName := from.Name
-	toName := args[2]
-	if err := m.Rename(fromName, toName); err != nil {
-		return fmt.Errorf("unable to rename task %s to %s: %s", fromName, toName, err.Error())
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problems", len(problems))
+	}
+
+	return nil
+}
+
+func migrateContextAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	if cmd.migrateContext == nil {
+		return errors.New("cannot migrate context: only contexts in the fs repo can be migrated")
+	}
+
+	changes, err := cmd.migrateContext()
+	if err != nil {
+		return fmt.Errorf("cannot migrate context: %s", err.Error())
+	}
+
+	return cmd.write(o, &changesResult{changes: changes})
+}
+
+// errNoContexts is returned from the context commands when the Runner has no task.Contexts.
+var errNoContexts = errors.New("only the fs repo and the API have contexts")
+
+func listContextsAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	if cmd.contexts == nil {
+		return fmt.Errorf("cannot list contexts: %s", errNoContexts.Error())
+	}
+
+	names, err := cmd.contexts.Contexts()
+	if err != nil {
+		return fmt.Errorf("cannot list contexts: %s", err.Error())
+	}
+
+	return cmd.write(o, &contextsResult{names: names})
+}
+
+func createContextAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	if cmd.contexts == nil {
+		return fmt.Errorf("cannot create context: %s", errNoContexts.Error())
 	}
 
-	m.Note(args[2], fmt.Sprintf("Renamed task '%s' to '%s'", fromName, toName))
+	if err := cmd.contexts.CreateContext(args[1]); err != nil {
+		return fmt.Errorf("cannot create context: %s", err.Error())
+	}
+
+	return nil
+}
+
+func copyContextAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	if cmd.contexts == nil {
+		return fmt.Errorf("cannot copy context: %s", errNoContexts.Error())
+	}
+
+	if err := cmd.contexts.CopyContext(args[1], args[2]); err != nil {
+		return fmt.Errorf("cannot copy context: %s", err.Error())
+	}
+
+	return nil
+}
+
+func deleteContextAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	if cmd.contexts == nil {
+		return fmt.Errorf("cannot delete context: %s", errNoContexts.Error())
+	}
+
+	if err := cmd.contexts.DeleteContext(args[1]); err != nil {
+		return fmt.Errorf("cannot delete context: %s", err.Error())
+	}
+
+	return nil
+}
+
+// errNoWebhooks is returned from the webhook command when the Runner has no webhook.Repo.
+var errNoWebhooks = errors.New("only the API has webhooks")
+
+// These are the names of the task.EventType's that can be passed to "webhook add --types".
+var eventTypeNames = map[string]task.EventType{
+	"create":            task.EventTypeCreate,
+	"delete":            task.EventTypeDelete,
+	"set-state":         task.EventTypeSetState,
+	"note":              task.EventTypeNote,
+	"set-priority":      task.EventTypeSetPriority,
+	"set-deadline":      task.EventTypeSetDeadline,
+	"add-dependency":    task.EventTypeAddDependency,
+	"remove-dependency": task.EventTypeRemoveDependency,
+	"tag":               task.EventTypeAddTag,
+	"untag":             task.EventTypeRemoveTag,
+	"rename":            task.EventTypeRename,
+	"reset":             task.EventTypeReset,
+	"restore":           task.EventTypeRestore,
+}
+
+func webhookAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	switch {
+	case args[1] == "add" && len(args) == 3:
+		return addWebhook(cmd, args[2], o)
+	case args[1] == "list" && len(args) == 2:
+		return listWebhooks(cmd, o)
+	case args[1] == "remove" && len(args) == 3:
+		return removeWebhook(cmd, args[2])
+	default:
+		return fmt.Errorf("expected 'add url', 'list', or 'remove id', got '%s'", strings.Join(args[1:], " "))
+	}
+}
+
+func addWebhook(cmd *command, url string, o io.Writer) error {
+	if cmd.webhooks == nil {
+		return fmt.Errorf("cannot add webhook: %s", errNoWebhooks.Error())
+	}
+
+	w := &webhook.Webhook{URL: url}
+	if types, ok := cmd.option("types"); ok {
+		for _, name := range strings.Split(types, ",") {
+			eventType, ok := eventTypeNames[name]
+			if !ok {
+				return fmt.Errorf("cannot add webhook: unknown event type '%s'", name)
+			}
+			w.EventTypes = append(w.EventTypes, eventType)
+		}
+	}
+	if states, ok := cmd.option("states"); ok {
+		for _, name := range strings.Split(states, ",") {
+			state, err := parseState(name)
+			if err != nil {
+				return fmt.Errorf("cannot add webhook: %s", err.Error())
+			}
+			w.States = append(w.States, state)
+		}
+	}
+
+	secret, ok := cmd.option("secret")
+	generated := !ok
+	if generated {
+		var err error
+		if secret, err = generateSecret(); err != nil {
+			return fmt.Errorf("cannot add webhook: %s", err.Error())
+		}
+	}
+	w.Secret = secret
+
+	if err := cmd.webhooks.CreateWebhook(w); err != nil {
+		return fmt.Errorf("cannot add webhook: %s", err.Error())
+	}
+
+	// Only print the secret if nobody knows it yet.
+	added := *w
+	if !generated {
+		added.Secret = ""
+	}
+	return cmd.write(o, &webhooksResult{webhooks: []*webhook.Webhook{&added}})
+}
+
+func listWebhooks(cmd *command, o io.Writer) error {
+	if cmd.webhooks == nil {
+		return fmt.Errorf("cannot list webhooks: %s", errNoWebhooks.Error())
+	}
+
+	webhooks, err := cmd.webhooks.Webhooks()
+	if err != nil {
+		return fmt.Errorf("cannot list webhooks: %s", err.Error())
+	}
+
+	return cmd.write(o, &webhooksResult{webhooks: webhooks})
+}
+
+func removeWebhook(cmd *command, id string) error {
+	if cmd.webhooks == nil {
+		return fmt.Errorf("cannot remove webhook: %s", errNoWebhooks.Error())
+	}
+
+	idN, err := strconv.Atoi(id)
+	if err != nil {
+		return fmt.Errorf("cannot remove webhook: invalid ID '%s'", id)
+	}
+
+	w, err := cmd.webhooks.FindWebhookByID(idN)
+	if err != nil {
+		return fmt.Errorf("cannot remove webhook: %s", err.Error())
+	} else if w == nil {
+		return fmt.Errorf("cannot remove webhook: unknown webhook with ID %d", idN)
+	}
+
+	if err := cmd.webhooks.DeleteWebhook(w); err != nil {
+		return fmt.Errorf("cannot remove webhook: %s", err.Error())
+	}
+
+	return nil
+}
+
+func loginAction(cmd *command, args []string, o io.Writer, m manager.Manager, buildInfo *BuildInfo) error {
+	if cmd.login == nil {
+		return errors.New("cannot log in: only the API has users")
+	}
+
+	if err := cmd.login(args[1]); err != nil {
+		return fmt.Errorf("cannot log in: %s", err.Error())
+	}
 
 	return nil
 }
+
+// parseState returns the task.State with a name, ignoring case, e.g., "blocked".
+func parseState(name string) (task.State, error) {
+	for _, state := range []task.State{task.StateReady, task.StateBlocked, task.StateRunning, task.StateFinished} {
+		if strings.EqualFold(name, string(state)) {
+			return state, nil
+		}
+	}
+	return "", fmt.Errorf("unknown state '%s'", name)
+}
+
+// generateSecret returns a random secret with which a webhook can be signed.
+func generateSecret() (string, error) {
+	secret := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -6,9 +6,15 @@ import (
 	"os"
 	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/ankeesler/anwork/api/client"
 	"github.com/ankeesler/anwork/manager/managerfakes"
+	"github.com/ankeesler/anwork/query"
 	"github.com/ankeesler/anwork/runner"
 	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/taskfakes"
+	"github.com/ankeesler/anwork/webhook"
+	"github.com/ankeesler/anwork/webhook/webhookfakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
@@ -106,10 +112,16 @@ var _ = Describe("Command", func() {
 	})
 
 	Describe("summary", func() {
+		var now time.Time
+
 		BeforeEach(func() {
-			twoDaysAgo := time.Now().Add(-1 * (time.Hour * 24 * 2))
-			tenDaysAgo := time.Now().Add(-1 * (time.Hour * 24 * 10))
-			manager.EventsReturns([]*task.Event{
+			now = time.Now()
+			r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter,
+				runner.WithClock(fakeclock.NewFakeClock(now)))
+
+			twoDaysAgo := now.Add(-1 * (time.Hour * 24 * 2))
+			tenDaysAgo := now.Add(-1 * (time.Hour * 24 * 10))
+			events := []*task.Event{
 				&task.Event{
 					Type:   task.EventTypeSetState,
 					Title:  "foo",
@@ -122,18 +134,23 @@ var _ = Describe("Command", func() {
 					TaskID: 5,
 				},
 				&task.Event{
-					Type:   task.EventTypeSetState,
-					Title:  "task-a changed to Finished",
-					Date:   twoDaysAgo.Unix(),
-					TaskID: 5,
+					Type:     task.EventTypeSetState,
+					Title:    "task-a changed to Finished",
+					NewValue: string(task.StateFinished),
+					Date:     twoDaysAgo.Unix(),
+					TaskID:   5,
 				},
 				&task.Event{
-					Type:   task.EventTypeSetState,
-					Title:  "task-b changed to Finished",
-					Date:   tenDaysAgo.Unix(),
-					TaskID: 10,
+					Type:     task.EventTypeSetState,
+					Title:    "task-b changed to Finished",
+					NewValue: string(task.StateFinished),
+					Date:     tenDaysAgo.Unix(),
+					TaskID:   10,
 				},
-			}, nil)
+			}
+			manager.EventsMatchingStub = func(q *query.EventQuery) ([]*task.Event, error) {
+				return q.Select(events), nil
+			}
 		})
 
 		It("shows the tasks that have been completed in the provided number of days", func() {
@@ -150,6 +167,25 @@ var _ = Describe("Command", func() {
 			Eventually(stdoutWriter).ShouldNot(gbytes.Say("task-b changed to Finished"))
 		})
 
+		It("only gets the state changes within the provided number of days", func() {
+			Expect(r.Run([]string{"summary", "5"})).To(Succeed())
+
+			q := manager.EventsMatchingArgsForCall(0)
+			Expect(q.Types).To(Equal([]task.EventType{task.EventTypeSetState}))
+			Expect(q.Since).To(Equal(now.Add(-5*24*time.Hour).Unix() + 1))
+		})
+
+		Context("when getting the events fails", func() {
+			BeforeEach(func() {
+				manager.EventsMatchingReturns(nil, errors.New("some events error"))
+			})
+
+			It("returns the error", func() {
+				err := r.Run([]string{"summary", "5"})
+				Expect(err).To(MatchError("Command 'summary' failed: some events error"))
+			})
+		})
+
 		Context("when the number of days is invalid", func() {
 			It("doesn't display anything", func() {
 				err := r.Run([]string{"summary", "tuna"})
@@ -358,6 +394,7 @@ var _ = Describe("Command", func() {
 						Name:  "task-a",
 						ID:    10,
 						State: task.StateRunning,
+						Tags:  []string{"infra"},
 					},
 					&task.Task{
 						Name:     "task-b",
@@ -369,6 +406,7 @@ var _ = Describe("Command", func() {
 						Name:  "task-c",
 						ID:    30,
 						State: task.StateReady,
+						Tags:  []string{"infra", "web"},
 					},
 					&task.Task{
 						Name:  "task-d",
@@ -410,6 +448,198 @@ State: READY`
 					Expect(stdoutWriter).To(gbytes.Say(expectedOutput))
 				})
 			})
+
+			Context("when a task with tags is passed", func() {
+				It("prints the tags of the task", func() {
+					Expect(r.Run([]string{"show", "task-c"})).To(Succeed())
+					expectedOutput := `Name: task-c
+ID: 30
+Created: \w+ \w+ \d\d? \d\d:\d\d
+Priority: 0
+State: READY
+Tags: infra, web`
+					Expect(stdoutWriter).To(gbytes.Say(expectedOutput))
+				})
+			})
+
+			Context("when the --tag option is passed", func() {
+				It("only prints out the tasks with that tag", func() {
+					Expect(r.Run([]string{"show", "--tag", "infra"})).To(Succeed())
+					expectedOutput := `RUNNING tasks:
+  task-a \(10\)
+BLOCKED tasks:
+READY tasks:
+  task-c \(30\)
+FINISHED tasks:
+$`
+					Expect(stdoutWriter).To(gbytes.Say(expectedOutput))
+				})
+
+				It("accepts the --tag=value form of the option", func() {
+					Expect(r.Run([]string{"show", "--tag=web"})).To(Succeed())
+					expectedOutput := `RUNNING tasks:
+BLOCKED tasks:
+READY tasks:
+  task-c \(30\)
+FINISHED tasks:
+$`
+					Expect(stdoutWriter).To(gbytes.Say(expectedOutput))
+				})
+
+				Context("when the option does not have a value", func() {
+					It("returns a helpful error", func() {
+						err := r.Run([]string{"show", "--tag"})
+						Expect(err).To(MatchError("Option '--tag' passed to command 'show' expects a value: tag"))
+					})
+				})
+			})
+
+			Context("when the --where option is passed", func() {
+				BeforeEach(func() {
+					manager.TasksMatchingReturnsOnCall(0, []*task.Task{
+						&task.Task{Name: "task-a", ID: 10, State: task.StateRunning},
+						&task.Task{Name: "task-c", ID: 30, State: task.StateReady},
+					}, nil)
+				})
+
+				It("only prints out the tasks that match the query", func() {
+					Expect(r.Run([]string{"show", "--where", "tag:infra priority<5"})).To(Succeed())
+
+					Expect(manager.TasksCallCount()).To(Equal(0))
+					Expect(manager.TasksMatchingCallCount()).To(Equal(1))
+					Expect(manager.TasksMatchingArgsForCall(0).String()).To(Equal("tag:infra priority<5"))
+
+					expectedOutput := `RUNNING tasks:
+  task-a \(10\)
+BLOCKED tasks:
+READY tasks:
+  task-c \(30\)
+FINISHED tasks:
+$`
+					Expect(stdoutWriter).To(gbytes.Say(expectedOutput))
+				})
+
+				Context("when the query is invalid", func() {
+					It("returns a helpful error", func() {
+						err := r.Run([]string{"show", "--where", "color:red"})
+						Expect(err).To(MatchError("Command 'show' failed: invalid query 'color:red': unknown field 'color'"))
+						Expect(manager.TasksMatchingCallCount()).To(Equal(0))
+					})
+				})
+
+				Context("when the manager fails to get the tasks", func() {
+					BeforeEach(func() {
+						manager.TasksMatchingReturnsOnCall(0, nil, errors.New("some query error"))
+					})
+
+					It("returns the error", func() {
+						err := r.Run([]string{"show", "--where", "tag:infra"})
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("some query error"))
+					})
+				})
+			})
+
+			Context("when an unknown option is passed", func() {
+				It("returns a helpful error", func() {
+					err := r.Run([]string{"show", "--bogus"})
+					Expect(err).To(MatchError("Unknown option passed to command 'show': '--bogus'"))
+				})
+			})
+		})
+
+		Context("when the task has dependencies", func() {
+			BeforeEach(func() {
+				manager.FindByNameReturnsOnCall(0,
+					&task.Task{
+						Name:     "task-a",
+						ID:       10,
+						State:    task.StateBlocked,
+						Priority: 3,
+					},
+					nil,
+				)
+				manager.DependenciesReturnsOnCall(0,
+					[]*task.Task{
+						&task.Task{Name: "task-b", ID: 20},
+						&task.Task{Name: "task-c", ID: 30},
+					},
+					nil,
+				)
+			})
+
+			It("prints out the tasks on which the task depends", func() {
+				Expect(r.Run([]string{"show", "task-a"})).To(Succeed())
+				Expect(manager.DependenciesArgsForCall(0)).To(Equal("task-a"))
+				expectedOutput := `Name: task-a
+ID: 10
+Created: \w+ \w+ \d\d? \d\d:\d\d
+Priority: 3
+State: BLOCKED
+Depends on: task-b \(20\), task-c \(30\)`
+				Expect(stdoutWriter).To(gbytes.Say(expectedOutput))
+			})
+
+			Context("when getting the dependencies fails", func() {
+				BeforeEach(func() {
+					manager.DependenciesReturnsOnCall(0, nil, errors.New("some dependencies error"))
+				})
+
+				It("returns the error", func() {
+					err := r.Run([]string{"show", "task-a"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("some dependencies error"))
+				})
+			})
+		})
+
+		Context("when the task has a deadline", func() {
+			var now time.Time
+
+			BeforeEach(func() {
+				now = time.Now()
+				r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter,
+					runner.WithClock(fakeclock.NewFakeClock(now)))
+
+				manager.FindByNameReturnsOnCall(0,
+					&task.Task{
+						Name:     "task-a",
+						ID:       10,
+						State:    task.StateReady,
+						Priority: 3,
+						Deadline: now.Add(time.Hour*49 + time.Minute*30).Unix(),
+					},
+					nil,
+				)
+			})
+
+			It("prints out the deadline and the time remaining", func() {
+				Expect(r.Run([]string{"show", "task-a"})).To(Succeed())
+				expectedOutput := `Name: task-a
+ID: 10
+Created: \w+ \w+ \d\d? \d\d:\d\d
+Priority: 3
+State: READY
+Deadline: \w+ \w+ \d\d? \d\d:\d\d \(49h30m0s remaining\)`
+				Expect(stdoutWriter).To(gbytes.Say(expectedOutput))
+			})
+
+			Context("when the deadline has passed", func() {
+				BeforeEach(func() {
+					manager.FindByNameReturnsOnCall(0,
+						&task.Task{
+							Name:     "task-a",
+							Deadline: now.Add(-time.Hour * 3).Unix(),
+						},
+						nil,
+					)
+				})
+
+				It("prints out how overdue the task is", func() {
+					Expect(r.Run([]string{"show", "task-a"})).To(Succeed())
+					Expect(stdoutWriter).To(gbytes.Say(`Deadline: .* \(overdue by 3h0m0s\)`))
+				})
+			})
 		})
 
 		Context("when a task spec is passed", func() {
@@ -648,27 +878,37 @@ State: READY`
 		})
 	})
 
-	Describe("set-<state>", func() {
-		BeforeEach(func() {
-			manager.FindByNameReturns(&task.Task{Name: "task-a"}, nil)
-		})
+	Describe("set-deadline", func() {
+		Context("when the task exists", func() {
+			BeforeEach(func() {
+				manager.FindByNameReturnsOnCall(0, &task.Task{Name: "task-a"}, nil)
+			})
 
-		It("sets the state correctly for all valid states", func() {
-			states := []task.State{
-				task.StateRunning,
-				task.StateBlocked,
-				task.StateReady,
-				task.StateFinished,
-			}
-			stateStrings := []string{"running", "blocked", "ready", "finished"}
-			for i, state := range states {
-				stateString := stateStrings[i]
-				cmd := fmt.Sprintf("set-%s", stateString)
-				Expect(r.Run([]string{cmd, "task-a"})).To(Succeed(), "Command = %s", cmd)
-				name, stateArg := manager.SetStateArgsForCall(i)
+			It("sets the deadline on the task to the end of the day", func() {
+				Expect(r.Run([]string{"set-deadline", "task-a", "2018-12-25"})).To(Succeed())
+
+				name, deadline := manager.SetDeadlineArgsForCall(0)
 				Expect(name).To(Equal("task-a"))
-				Expect(stateArg).To(Equal(state), "Command = %s", cmd)
-			}
+				Expect(deadline).To(Equal(time.Date(2018, time.December, 25, 23, 59, 0, 0, time.Local).Unix()))
+			})
+
+			Context("when a time is passed", func() {
+				It("sets the deadline on the task to that time", func() {
+					Expect(r.Run([]string{"set-deadline", "task-a", "2018-12-25 13:30"})).To(Succeed())
+
+					_, deadline := manager.SetDeadlineArgsForCall(0)
+					Expect(deadline).To(Equal(time.Date(2018, time.December, 25, 13, 30, 0, 0, time.Local).Unix()))
+				})
+			})
+
+			Context("when 'none' is passed", func() {
+				It("clears the deadline on the task", func() {
+					Expect(r.Run([]string{"set-deadline", "task-a", "none"})).To(Succeed())
+
+					_, deadline := manager.SetDeadlineArgsForCall(0)
+					Expect(deadline).To(BeZero())
+				})
+			})
 		})
 
 		Context("when we fail to find the task", func() {
@@ -677,33 +917,34 @@ State: READY`
 			})
 
 			It("returns the error", func() {
-				err := r.Run([]string{"set-running", "task-a"})
+				err := r.Run([]string{"set-deadline", "task-a", "2018-12-25"})
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("some find error"))
 			})
 		})
 
-		Context("when manager.SetState returns an error", func() {
+		Context("when the manager fails to set the deadline", func() {
 			BeforeEach(func() {
-				manager.SetStateReturns(errors.New("failed to set state"))
+				manager.FindByNameReturnsOnCall(0, &task.Task{Name: "task-a"}, nil)
+				manager.SetDeadlineReturnsOnCall(0, errors.New("task does not exist"))
 			})
 
-			It("prints the error to the user", func() {
-				err := r.Run([]string{"set-running", "task-a"})
+			It("displays the error to the user", func() {
+				err := r.Run([]string{"set-deadline", "task-a", "2018-12-25"})
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("cannot set state: failed to set state"))
+				Expect(err.Error()).To(ContainSubstring("cannot set deadline: task does not exist"))
 			})
 		})
 
-		Context("when the task does not exist", func() {
+		Context("when the second argument is not a date", func() {
 			BeforeEach(func() {
-				manager.FindByNameReturns(nil, nil)
+				manager.FindByNameReturnsOnCall(0, &task.Task{Name: "task-a"}, nil)
 			})
 
-			It("prints the error to the user", func() {
-				err := r.Run([]string{"set-running", "task-a"})
+			It("displays the error to the user", func() {
+				err := r.Run([]string{"set-deadline", "task-a", "tuna"})
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("unknown task: task-a"))
+				Expect(err.Error()).To(ContainSubstring("cannot set deadline: invalid deadline: 'tuna'"))
 			})
 		})
 
@@ -712,81 +953,317 @@ State: READY`
 				manager.FindByIDReturnsOnCall(0, &task.Task{Name: "task-a"}, nil)
 			})
 
-			It("parses the task spec and sets the state on the task", func() {
-				Expect(r.Run([]string{"set-running", "@1"})).To(Succeed())
+			It("parses the task spec and sets the deadline on the task", func() {
+				Expect(r.Run([]string{"set-deadline", "@1", "none"})).To(Succeed())
 
 				Expect(manager.FindByIDArgsForCall(0)).To(Equal(1))
 
-				name, state := manager.SetStateArgsForCall(0)
+				name, _ := manager.SetDeadlineArgsForCall(0)
 				Expect(name).To(Equal("task-a"))
-				Expect(state).To(Equal(task.State(task.StateRunning)))
 			})
+		})
+	})
 
-			Context("when the task spec is totally bogus", func() {
-				It("returns a helpful error", func() {
-					err := r.Run([]string{"set-running", "@tuna"})
-					Expect(err).To(HaveOccurred())
-					Expect(err.Error()).To(ContainSubstring("cannot parse task ID"))
-				})
+	Describe("add-dependency", func() {
+		BeforeEach(func() {
+			manager.FindByNameStub = func(name string) (*task.Task, error) {
+				return &task.Task{Name: name}, nil
+			}
+		})
+
+		It("makes the task depend on the other task", func() {
+			Expect(r.Run([]string{"add-dependency", "task-a", "task-b"})).To(Succeed())
+
+			Expect(manager.AddDependencyCallCount()).To(Equal(1))
+			name, dependsOn := manager.AddDependencyArgsForCall(0)
+			Expect(name).To(Equal("task-a"))
+			Expect(dependsOn).To(Equal("task-b"))
+		})
+
+		Context("when we fail to find the other task", func() {
+			BeforeEach(func() {
+				manager.FindByNameStub = func(name string) (*task.Task, error) {
+					if name == "task-a" {
+						return &task.Task{Name: name}, nil
+					}
+					return nil, nil
+				}
 			})
 
-			Context("when the task spec is not a valid task ID", func() {
-				BeforeEach(func() {
-					manager.FindByIDReturnsOnCall(0, nil, nil)
-				})
+			It("returns an error", func() {
+				err := r.Run([]string{"add-dependency", "task-a", "task-b"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("unknown task: task-b"))
+				Expect(manager.AddDependencyCallCount()).To(Equal(0))
+			})
+		})
 
-				It("returns a helpful error", func() {
-					err := r.Run([]string{"set-running", "@1"})
-					Expect(err).To(HaveOccurred())
-					Expect(err.Error()).To(ContainSubstring("unknown task ID in task spec: 1"))
-				})
+		Context("when the manager fails to add the dependency", func() {
+			BeforeEach(func() {
+				manager.AddDependencyReturnsOnCall(0, errors.New("some cycle error"))
+			})
+
+			It("displays the error to the user", func() {
+				err := r.Run([]string{"add-dependency", "task-a", "task-b"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("cannot add dependency: some cycle error"))
+			})
+		})
+
+		Context("when task specs are passed", func() {
+			BeforeEach(func() {
+				manager.FindByIDStub = func(id int) (*task.Task, error) {
+					return &task.Task{Name: fmt.Sprintf("task-%d", id), ID: id}, nil
+				}
+			})
+
+			It("parses the task specs and makes the task depend on the other task", func() {
+				Expect(r.Run([]string{"add-dependency", "@1", "@2"})).To(Succeed())
+
+				name, dependsOn := manager.AddDependencyArgsForCall(0)
+				Expect(name).To(Equal("task-1"))
+				Expect(dependsOn).To(Equal("task-2"))
 			})
 		})
 	})
 
-	Describe("journal", func() {
+	Describe("remove-dependency", func() {
 		BeforeEach(func() {
-			manager.EventsReturnsOnCall(0, []*task.Event{
-				&task.Event{
-					TaskID: 1,
-					Title:  "event-a",
-				},
-				&task.Event{
-					TaskID: 5,
-					Title:  "event-b",
-				},
-				&task.Event{
-					TaskID: 1,
-					Title:  "event-c",
-				},
-				&task.Event{
-					TaskID: 5,
-					Title:  "event-d",
-				},
-			},
-				nil,
-			)
+			manager.FindByNameStub = func(name string) (*task.Task, error) {
+				return &task.Task{Name: name}, nil
+			}
 		})
 
-		Context("when no task name is passed", func() {
-			It("prints all journal entries in correct order", func() {
-				Expect(r.Run([]string{"journal"})).To(Succeed())
-				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-d"))
-				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-c"))
-				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-b"))
-				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-a"))
-			})
+		It("removes the dependency of the task on the other task", func() {
+			Expect(r.Run([]string{"remove-dependency", "task-a", "task-b"})).To(Succeed())
+
+			Expect(manager.RemoveDependencyCallCount()).To(Equal(1))
+			name, dependsOn := manager.RemoveDependencyArgsForCall(0)
+			Expect(name).To(Equal("task-a"))
+			Expect(dependsOn).To(Equal("task-b"))
 		})
 
-		Context("when events fails", func() {
+		Context("when the manager fails to remove the dependency", func() {
 			BeforeEach(func() {
-				manager.EventsReturnsOnCall(0, nil, errors.New("some error"))
+				manager.RemoveDependencyReturnsOnCall(0, errors.New("some dependency error"))
 			})
 
-			It("returns the error", func() {
-				err := r.Run([]string{"journal"})
+			It("displays the error to the user", func() {
+				err := r.Run([]string{"remove-dependency", "task-a", "task-b"})
 				Expect(err).To(HaveOccurred())
-				Expect(err.Error()).To(ContainSubstring("some error"))
+				Expect(err.Error()).To(ContainSubstring("cannot remove dependency: some dependency error"))
+			})
+		})
+	})
+
+	Describe("tag", func() {
+		BeforeEach(func() {
+			manager.FindByNameReturns(&task.Task{Name: "task-a"}, nil)
+		})
+
+		It("adds the tag to the task", func() {
+			Expect(r.Run([]string{"tag", "task-a", "infra"})).To(Succeed())
+
+			Expect(manager.AddTagCallCount()).To(Equal(1))
+			name, tag := manager.AddTagArgsForCall(0)
+			Expect(name).To(Equal("task-a"))
+			Expect(tag).To(Equal("infra"))
+		})
+
+		Context("when the manager fails to add the tag", func() {
+			BeforeEach(func() {
+				manager.AddTagReturnsOnCall(0, errors.New("some tag error"))
+			})
+
+			It("displays the error to the user", func() {
+				err := r.Run([]string{"tag", "task-a", "infra"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("cannot add tag: some tag error"))
+			})
+		})
+
+		Context("when someone else changed the task first", func() {
+			BeforeEach(func() {
+				manager.AddTagReturnsOnCall(0, &client.ConflictError{Name: "task-a", ID: 1, Version: 3})
+			})
+
+			It("tells the user to run the command again", func() {
+				err := r.Run([]string{"tag", "task-a", "infra"})
+				Expect(err).To(MatchError("Command 'tag' failed: cannot add tag: task 'task-a' (ID 1) was changed " +
+					"by someone else after version 3 was read (run it again to change the latest version of the task)"))
+			})
+		})
+	})
+
+	Describe("untag", func() {
+		BeforeEach(func() {
+			manager.FindByNameReturns(&task.Task{Name: "task-a"}, nil)
+		})
+
+		It("removes the tag from the task", func() {
+			Expect(r.Run([]string{"untag", "task-a", "infra"})).To(Succeed())
+
+			Expect(manager.RemoveTagCallCount()).To(Equal(1))
+			name, tag := manager.RemoveTagArgsForCall(0)
+			Expect(name).To(Equal("task-a"))
+			Expect(tag).To(Equal("infra"))
+		})
+
+		Context("when the manager fails to remove the tag", func() {
+			BeforeEach(func() {
+				manager.RemoveTagReturnsOnCall(0, errors.New("some tag error"))
+			})
+
+			It("displays the error to the user", func() {
+				err := r.Run([]string{"untag", "task-a", "infra"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("cannot remove tag: some tag error"))
+			})
+		})
+	})
+
+	Describe("set-<state>", func() {
+		BeforeEach(func() {
+			manager.FindByNameReturns(&task.Task{Name: "task-a"}, nil)
+		})
+
+		It("sets the state correctly for all valid states", func() {
+			states := []task.State{
+				task.StateRunning,
+				task.StateBlocked,
+				task.StateReady,
+				task.StateFinished,
+			}
+			stateStrings := []string{"running", "blocked", "ready", "finished"}
+			for i, state := range states {
+				stateString := stateStrings[i]
+				cmd := fmt.Sprintf("set-%s", stateString)
+				Expect(r.Run([]string{cmd, "task-a"})).To(Succeed(), "Command = %s", cmd)
+				name, stateArg := manager.SetStateArgsForCall(i)
+				Expect(name).To(Equal("task-a"))
+				Expect(stateArg).To(Equal(state), "Command = %s", cmd)
+			}
+		})
+
+		Context("when we fail to find the task", func() {
+			BeforeEach(func() {
+				manager.FindByNameReturnsOnCall(0, nil, errors.New("some find error"))
+			})
+
+			It("returns the error", func() {
+				err := r.Run([]string{"set-running", "task-a"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("some find error"))
+			})
+		})
+
+		Context("when manager.SetState returns an error", func() {
+			BeforeEach(func() {
+				manager.SetStateReturns(errors.New("failed to set state"))
+			})
+
+			It("prints the error to the user", func() {
+				err := r.Run([]string{"set-running", "task-a"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("cannot set state: failed to set state"))
+			})
+		})
+
+		Context("when the task does not exist", func() {
+			BeforeEach(func() {
+				manager.FindByNameReturns(nil, nil)
+			})
+
+			It("prints the error to the user", func() {
+				err := r.Run([]string{"set-running", "task-a"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("unknown task: task-a"))
+			})
+		})
+
+		Context("when a task spec is passed", func() {
+			BeforeEach(func() {
+				manager.FindByIDReturnsOnCall(0, &task.Task{Name: "task-a"}, nil)
+			})
+
+			It("parses the task spec and sets the state on the task", func() {
+				Expect(r.Run([]string{"set-running", "@1"})).To(Succeed())
+
+				Expect(manager.FindByIDArgsForCall(0)).To(Equal(1))
+
+				name, state := manager.SetStateArgsForCall(0)
+				Expect(name).To(Equal("task-a"))
+				Expect(state).To(Equal(task.State(task.StateRunning)))
+			})
+
+			Context("when the task spec is totally bogus", func() {
+				It("returns a helpful error", func() {
+					err := r.Run([]string{"set-running", "@tuna"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("cannot parse task ID"))
+				})
+			})
+
+			Context("when the task spec is not a valid task ID", func() {
+				BeforeEach(func() {
+					manager.FindByIDReturnsOnCall(0, nil, nil)
+				})
+
+				It("returns a helpful error", func() {
+					err := r.Run([]string{"set-running", "@1"})
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(ContainSubstring("unknown task ID in task spec: 1"))
+				})
+			})
+		})
+	})
+
+	Describe("journal", func() {
+		var events []*task.Event
+
+		BeforeEach(func() {
+			events = []*task.Event{
+				&task.Event{
+					TaskID: 1,
+					Title:  "event-a",
+				},
+				&task.Event{
+					TaskID: 5,
+					Title:  "event-b",
+				},
+				&task.Event{
+					TaskID: 1,
+					Title:  "event-c",
+				},
+				&task.Event{
+					TaskID: 5,
+					Title:  "event-d",
+				},
+			}
+			manager.EventsMatchingStub = func(q *query.EventQuery) ([]*task.Event, error) {
+				return q.Select(events), nil
+			}
+		})
+
+		Context("when no task name is passed", func() {
+			It("prints all journal entries in correct order", func() {
+				Expect(r.Run([]string{"journal"})).To(Succeed())
+				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-d"))
+				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-c"))
+				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-b"))
+				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-a"))
+			})
+		})
+
+		Context("when events fails", func() {
+			BeforeEach(func() {
+				manager.EventsMatchingReturns(nil, errors.New("some error"))
+			})
+
+			It("returns the error", func() {
+				err := r.Run([]string{"journal"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("some error"))
 			})
 		})
 
@@ -805,6 +1282,9 @@ State: READY`
 					Expect(r.Run([]string{"journal", "task-a"})).To(Succeed())
 					Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-c"))
 					Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-a"))
+
+					Expect(manager.EventsMatchingCallCount()).To(Equal(1))
+					Expect(*manager.EventsMatchingArgsForCall(0).TaskID).To(Equal(1))
 				})
 			})
 
@@ -832,26 +1312,6 @@ State: READY`
 		Context("when a task spec is passed", func() {
 			BeforeEach(func() {
 				manager.FindByIDReturnsOnCall(0, &task.Task{Name: "task-a", ID: 1}, nil)
-				manager.EventsReturnsOnCall(0, []*task.Event{
-					&task.Event{
-						TaskID: 1,
-						Title:  "event-a",
-					},
-					&task.Event{
-						TaskID: 5,
-						Title:  "event-b",
-					},
-					&task.Event{
-						TaskID: 1,
-						Title:  "event-c",
-					},
-					&task.Event{
-						TaskID: 5,
-						Title:  "event-d",
-					},
-				},
-					nil,
-				)
 			})
 
 			It("parses the task spec and displays the journal", func() {
@@ -882,59 +1342,116 @@ State: READY`
 				})
 			})
 		})
-	})
 
-	Describe("archive", func() {
-		Context("when there are no tasks", func() {
-			It("does not tell the manager to delete anything", func() {
-				Expect(r.Run([]string{"archive"})).To(Succeed())
-				Expect(manager.DeleteCallCount()).To(Equal(0))
-			})
-		})
+		Context("when --follow is passed", func() {
+			var (
+				lastEventID int
+				created     []*task.Event
+			)
 
-		Context("when there are multiple tasks", func() {
 			BeforeEach(func() {
-				tasks := []*task.Task{
-					&task.Task{Name: "task-a", State: task.StateFinished},
-					&task.Task{Name: "task-b", State: task.StateBlocked},
-					&task.Task{Name: "task-c", State: task.StateFinished},
+				events = []*task.Event{
+					&task.Event{ID: 3, TaskID: 1, Title: "event-a"},
+					&task.Event{ID: 4, TaskID: 5, Title: "event-b"},
 				}
-				manager.TasksReturnsOnCall(0, tasks, nil)
+
+				lastEventID = 0
+				created = []*task.Event{
+					&task.Event{ID: 5, TaskID: 1, Title: "event-c"},
+					&task.Event{ID: 6, TaskID: 5, Title: "event-d"},
+				}
+				r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter, runner.WithEventFollower(
+					func(id int, handle func(*task.Event) error) error {
+						lastEventID = id
+						for _, e := range created {
+							if err := handle(e); err != nil {
+								return err
+							}
+						}
+						return errors.New("some follow error")
+					},
+				))
 			})
 
-			Context("when the manager deletes stuff happily", func() {
+			It("prints the journal, and then each event as it is created", func() {
+				err := r.Run([]string{"journal", "--follow"})
+				Expect(err).To(MatchError("Command 'journal' failed: some follow error"))
+
+				Expect(lastEventID).To(Equal(4))
+				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-b\n"))
+				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-a\n"))
+				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-c\n"))
+				Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-d\n"))
+			})
+
+			Context("when a task name is passed", func() {
 				BeforeEach(func() {
-					manager.DeleteReturnsOnCall(0, nil)
-					manager.DeleteReturnsOnCall(1, nil)
+					manager.FindByNameReturnsOnCall(0, &task.Task{ID: 1}, nil)
 				})
 
-				It("calls delete on each finished task", func() {
-					Expect(r.Run([]string{"archive"})).To(Succeed())
-					Expect(manager.DeleteCallCount()).To(Equal(2))
-					Expect(manager.DeleteArgsForCall(0)).To(Equal("task-a"))
-					Expect(manager.DeleteArgsForCall(1)).To(Equal("task-c"))
+				It("only prints the events for that task, but follows the newest event", func() {
+					Expect(r.Run([]string{"journal", "task-a", "--follow"})).NotTo(Succeed())
+					Expect(lastEventID).To(Equal(4))
+					Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-a\n"))
+					Expect(stdoutWriter).To(gbytes.Say("\\[.*\\]: event-c\n"))
+					Expect(stdoutWriter).NotTo(gbytes.Say("event-d"))
 				})
 			})
 
-			Context("when the manager fails to delete a task", func() {
+			Context("when the format is a table", func() {
 				BeforeEach(func() {
-					manager.DeleteReturnsOnCall(0, errors.New("delete failure 0"))
-					manager.DeleteReturnsOnCall(1, nil)
+					r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter,
+						runner.WithFormat(runner.FormatCSV),
+						runner.WithEventFollower(func(id int, handle func(*task.Event) error) error {
+							return handle(created[0])
+						}),
+					)
 				})
 
-				It("notifies the user of which task was not able to be deleted", func() {
-					err := r.Run([]string{"archive"})
-					Expect(err).To(HaveOccurred())
-					Expect(err.Error()).To(ContainSubstring("unable to delete task task-a: delete failure 0"))
+				It("only prints the header once", func() {
+					Expect(r.Run([]string{"journal", "--follow"})).To(Succeed())
+					Expect(string(stdoutWriter.Contents())).To(Equal(
+						"id,date,type,taskId,title,oldValue,newValue,note,actor\n" +
+							"4,0,0,5,event-b,,,,\n" +
+							"3,0,0,1,event-a,,,,\n" +
+							"5,0,0,1,event-c,,,,\n",
+					))
+				})
+			})
 
-					Expect(manager.DeleteCallCount()).To(Equal(2))
-					Expect(manager.DeleteArgsForCall(0)).To(Equal("task-a"))
-					Expect(manager.DeleteArgsForCall(1)).To(Equal("task-c"))
+			Context("when the runner cannot follow the journal", func() {
+				BeforeEach(func() {
+					r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter)
+				})
+
+				It("fails", func() {
+					err := r.Run([]string{"journal", "--follow"})
+					Expect(err).To(MatchError(
+						"Command 'journal' failed: cannot follow the journal: only the API can be followed"))
 				})
 			})
 		})
 	})
 
+	Describe("archive", func() {
+		It("tells the manager to archive", func() {
+			Expect(r.Run([]string{"archive"})).To(Succeed())
+			Expect(manager.ArchiveCallCount()).To(Equal(1))
+		})
+
+		Context("when the manager fails to archive", func() {
+			BeforeEach(func() {
+				manager.ArchiveReturns(errors.New("some archive error"))
+			})
+
+			It("returns the error", func() {
+				err := r.Run([]string{"archive"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("some archive error"))
+			})
+		})
+	})
+
 	Describe("rename", func() {
 		Context("the manager succeeds", func() {
 			BeforeEach(func() {
@@ -942,16 +1459,14 @@ State: READY`
 				manager.RenameReturnsOnCall(0, nil)
 			})
 
-			It("calls the manager and adds a note and succeeds", func() {
+			It("calls the manager and succeeds", func() {
 				Expect(r.Run([]string{"rename", "task-a", "task-d"})).To(Succeed())
 
 				from, to := manager.RenameArgsForCall(0)
 				Expect(from).To(Equal("task-a"))
 				Expect(to).To(Equal("task-d"))
 
-				name, note := manager.NoteArgsForCall(0)
-				Expect(name).To(Equal("task-d"))
-				Expect(note).To(Equal("Renamed task 'task-a' to 'task-d'"))
+				Expect(manager.NoteCallCount()).To(Equal(0))
 			})
 		})
 
@@ -992,4 +1507,442 @@ State: READY`
 			})
 		})
 	})
+
+	Describe("undo", func() {
+		It("undoes the last operation", func() {
+			Expect(r.Run([]string{"undo"})).To(Succeed())
+			Expect(manager.UndoCallCount()).To(Equal(1))
+			Expect(manager.UndoArgsForCall(0)).To(Equal(1))
+		})
+
+		It("undoes the last n operations", func() {
+			Expect(r.Run([]string{"undo", "3"})).To(Succeed())
+			Expect(manager.UndoCallCount()).To(Equal(1))
+			Expect(manager.UndoArgsForCall(0)).To(Equal(3))
+		})
+
+		Context("when n is not a positive number", func() {
+			It("returns an error", func() {
+				for _, n := range []string{"tuna", "0", "-1"} {
+					err := r.Run([]string{"undo", n})
+					Expect(err).To(MatchError(
+						"Command 'undo' failed: cannot undo: invalid number of operations: '" + n + "'",
+					))
+				}
+				Expect(manager.UndoCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the manager fails to undo", func() {
+			BeforeEach(func() {
+				manager.UndoReturnsOnCall(0, errors.New("nothing to undo"))
+			})
+
+			It("returns the error", func() {
+				err := r.Run([]string{"undo"})
+				Expect(err).To(MatchError("Command 'undo' failed: cannot undo: nothing to undo"))
+			})
+		})
+	})
+
+	Describe("redo", func() {
+		It("redoes the last undone operation", func() {
+			Expect(r.Run([]string{"redo"})).To(Succeed())
+			Expect(manager.RedoCallCount()).To(Equal(1))
+		})
+
+		Context("when the manager fails to redo", func() {
+			BeforeEach(func() {
+				manager.RedoReturnsOnCall(0, errors.New("nothing to redo"))
+			})
+
+			It("returns the error", func() {
+				err := r.Run([]string{"redo"})
+				Expect(err).To(MatchError("Command 'redo' failed: cannot redo: nothing to redo"))
+			})
+		})
+	})
+
+	Describe("rebuild", func() {
+		Context("when the tasks match the journal", func() {
+			BeforeEach(func() {
+				manager.ReplayReturnsOnCall(0, []string{}, nil)
+			})
+
+			It("says so", func() {
+				Expect(r.Run([]string{"rebuild"})).To(Succeed())
+				Expect(manager.ReplayCallCount()).To(Equal(1))
+				Eventually(stdoutWriter).Should(gbytes.Say("The tasks match the journal\n"))
+			})
+		})
+
+		Context("when the tasks do not match the journal", func() {
+			BeforeEach(func() {
+				manager.ReplayReturnsOnCall(0, []string{
+					"task 'task-a' (ID 1) has state Finished, but the journal says Ready",
+					"task 'task-b' (ID 2) is missing",
+				}, nil)
+			})
+
+			It("prints the problems and fails", func() {
+				err := r.Run([]string{"rebuild"})
+				Expect(err).To(MatchError("Command 'rebuild' failed: found 2 problems"))
+				Eventually(stdoutWriter).Should(gbytes.Say(
+					"task 'task-a' \\(ID 1\\) has state Finished, but the journal says Ready\n"))
+				Eventually(stdoutWriter).Should(gbytes.Say("task 'task-b' \\(ID 2\\) is missing\n"))
+			})
+		})
+
+		Context("when the manager fails to replay the journal", func() {
+			BeforeEach(func() {
+				manager.ReplayReturnsOnCall(0, nil, errors.New("some error"))
+			})
+
+			It("returns the error", func() {
+				err := r.Run([]string{"rebuild"})
+				Expect(err).To(MatchError("Command 'rebuild' failed: cannot rebuild: some error"))
+			})
+		})
+	})
+
+	Describe("migrate-context", func() {
+		var (
+			changes []string
+			err     error
+		)
+
+		BeforeEach(func() {
+			changes, err = nil, nil
+			r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter, runner.WithContextMigrator(
+				func() ([]string, error) {
+					return changes, err
+				},
+			))
+		})
+
+		Context("when the context is migrated", func() {
+			BeforeEach(func() {
+				changes = []string{"Upgraded from format version 1 to 2", "Backed up the original file"}
+			})
+
+			It("prints the changes", func() {
+				Expect(r.Run([]string{"migrate-context"})).To(Succeed())
+				Eventually(stdoutWriter).Should(gbytes.Say("Upgraded from format version 1 to 2\n"))
+				Eventually(stdoutWriter).Should(gbytes.Say("Backed up the original file\n"))
+			})
+		})
+
+		Context("when the context already has the latest format", func() {
+			It("says so", func() {
+				Expect(r.Run([]string{"migrate-context"})).To(Succeed())
+				Eventually(stdoutWriter).Should(gbytes.Say("The context already has the latest format\n"))
+			})
+		})
+
+		Context("when the context fails to be migrated", func() {
+			BeforeEach(func() {
+				err = errors.New("some error")
+			})
+
+			It("returns the error", func() {
+				err := r.Run([]string{"migrate-context"})
+				Expect(err).To(MatchError("Command 'migrate-context' failed: cannot migrate context: some error"))
+			})
+		})
+
+		Context("when the runner cannot migrate its context", func() {
+			BeforeEach(func() {
+				r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter)
+			})
+
+			It("fails", func() {
+				err := r.Run([]string{"migrate-context"})
+				Expect(err).To(MatchError(
+					"Command 'migrate-context' failed: cannot migrate context: only contexts in the fs repo can be migrated"))
+			})
+		})
+	})
+
+	Describe("contexts", func() {
+		var contexts *taskfakes.FakeContexts
+
+		BeforeEach(func() {
+			contexts = &taskfakes.FakeContexts{}
+			r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter, runner.WithContexts(contexts))
+		})
+
+		Describe("list-contexts", func() {
+			BeforeEach(func() {
+				contexts.ContextsReturns([]string{"context-a", "context-b"}, nil)
+			})
+
+			It("prints the names of the contexts", func() {
+				Expect(r.Run([]string{"list-contexts"})).To(Succeed())
+				Eventually(stdoutWriter).Should(gbytes.Say("context-a\ncontext-b\n"))
+			})
+
+			Context("when listing the contexts fails", func() {
+				BeforeEach(func() {
+					contexts.ContextsReturns(nil, errors.New("some error"))
+				})
+
+				It("returns the error", func() {
+					err := r.Run([]string{"list-contexts"})
+					Expect(err).To(MatchError("Command 'list-contexts' failed: cannot list contexts: some error"))
+				})
+			})
+		})
+
+		Describe("create-context", func() {
+			It("creates the context", func() {
+				Expect(r.Run([]string{"create-context", "context-a"})).To(Succeed())
+				Expect(contexts.CreateContextCallCount()).To(Equal(1))
+				Expect(contexts.CreateContextArgsForCall(0)).To(Equal("context-a"))
+			})
+
+			Context("when the context already exists", func() {
+				BeforeEach(func() {
+					contexts.CreateContextReturns(&task.DuplicateContextError{Name: "context-a"})
+				})
+
+				It("returns the error", func() {
+					err := r.Run([]string{"create-context", "context-a"})
+					Expect(err).To(MatchError(
+						"Command 'create-context' failed: cannot create context: context 'context-a' already exists"))
+				})
+			})
+		})
+
+		Describe("copy-context", func() {
+			It("copies the context", func() {
+				Expect(r.Run([]string{"copy-context", "context-a", "context-b"})).To(Succeed())
+				Expect(contexts.CopyContextCallCount()).To(Equal(1))
+				from, to := contexts.CopyContextArgsForCall(0)
+				Expect(from).To(Equal("context-a"))
+				Expect(to).To(Equal("context-b"))
+			})
+
+			Context("when the context does not exist", func() {
+				BeforeEach(func() {
+					contexts.CopyContextReturns(&task.UnknownContextError{Name: "context-a"})
+				})
+
+				It("returns the error", func() {
+					err := r.Run([]string{"copy-context", "context-a", "context-b"})
+					Expect(err).To(MatchError(
+						"Command 'copy-context' failed: cannot copy context: unknown context 'context-a'"))
+				})
+			})
+		})
+
+		Describe("delete-context", func() {
+			It("deletes the context", func() {
+				Expect(r.Run([]string{"delete-context", "context-a"})).To(Succeed())
+				Expect(contexts.DeleteContextCallCount()).To(Equal(1))
+				Expect(contexts.DeleteContextArgsForCall(0)).To(Equal("context-a"))
+			})
+
+			Context("when deleting the context fails", func() {
+				BeforeEach(func() {
+					contexts.DeleteContextReturns(errors.New("some error"))
+				})
+
+				It("returns the error", func() {
+					err := r.Run([]string{"delete-context", "context-a"})
+					Expect(err).To(MatchError("Command 'delete-context' failed: cannot delete context: some error"))
+				})
+			})
+		})
+
+		Context("when the runner has no contexts", func() {
+			BeforeEach(func() {
+				r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter)
+			})
+
+			It("fails", func() {
+				err := r.Run([]string{"list-contexts"})
+				Expect(err).To(MatchError(
+					"Command 'list-contexts' failed: cannot list contexts: only the fs repo and the API have contexts"))
+			})
+		})
+	})
+
+	Describe("webhook", func() {
+		var webhooks *webhookfakes.FakeRepo
+
+		BeforeEach(func() {
+			webhooks = &webhookfakes.FakeRepo{}
+			webhooks.CreateWebhookStub = func(w *webhook.Webhook) error {
+				w.ID = 3
+				return nil
+			}
+			r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter, runner.WithWebhooks(webhooks))
+		})
+
+		Describe("add", func() {
+			It("adds the webhook with the filters and secret", func() {
+				Expect(r.Run([]string{
+					"webhook",
+					"add",
+					"https://example.com/hook",
+					"--types", "set-state,note",
+					"--states", "blocked,Finished",
+					"--secret", "some-secret",
+				})).To(Succeed())
+
+				Expect(webhooks.CreateWebhookCallCount()).To(Equal(1))
+				Expect(webhooks.CreateWebhookArgsForCall(0)).To(Equal(&webhook.Webhook{
+					ID:         3,
+					URL:        "https://example.com/hook",
+					EventTypes: []task.EventType{task.EventTypeSetState, task.EventTypeNote},
+					States:     []task.State{task.StateBlocked, task.StateFinished},
+					Secret:     "some-secret",
+				}))
+				Eventually(stdoutWriter).Should(gbytes.Say(
+					`3: https://example.com/hook \(types: set-state,note\) \(states: Blocked,Finished\)\n`))
+				Expect(stdoutWriter.Contents()).NotTo(ContainSubstring("some-secret"))
+			})
+
+			It("generates and prints a secret when none is passed", func() {
+				Expect(r.Run([]string{"webhook", "add", "https://example.com/hook"})).To(Succeed())
+
+				Expect(webhooks.CreateWebhookCallCount()).To(Equal(1))
+				secret := webhooks.CreateWebhookArgsForCall(0).Secret
+				Expect(secret).To(HaveLen(32))
+				Eventually(stdoutWriter).Should(gbytes.Say("3: https://example.com/hook\n  secret: " + secret + "\n"))
+			})
+
+			It("fails on an unknown event type", func() {
+				err := r.Run([]string{"webhook", "add", "https://example.com/hook", "--types", "set-mood"})
+				Expect(err).To(MatchError("Command 'webhook' failed: cannot add webhook: unknown event type 'set-mood'"))
+				Expect(webhooks.CreateWebhookCallCount()).To(Equal(0))
+			})
+
+			It("fails on an unknown state", func() {
+				err := r.Run([]string{"webhook", "add", "https://example.com/hook", "--states", "sleeping"})
+				Expect(err).To(MatchError("Command 'webhook' failed: cannot add webhook: unknown state 'sleeping'"))
+				Expect(webhooks.CreateWebhookCallCount()).To(Equal(0))
+			})
+
+			Context("when adding the webhook fails", func() {
+				BeforeEach(func() {
+					webhooks.CreateWebhookStub = nil
+					webhooks.CreateWebhookReturns(errors.New("some error"))
+				})
+
+				It("returns the error", func() {
+					err := r.Run([]string{"webhook", "add", "https://example.com/hook"})
+					Expect(err).To(MatchError("Command 'webhook' failed: cannot add webhook: some error"))
+				})
+			})
+		})
+
+		Describe("list", func() {
+			BeforeEach(func() {
+				webhooks.WebhooksReturns([]*webhook.Webhook{
+					&webhook.Webhook{ID: 1, URL: "https://example.com/a"},
+					&webhook.Webhook{ID: 2, URL: "https://example.com/b", States: []task.State{task.StateBlocked}},
+				}, nil)
+			})
+
+			It("prints the webhooks", func() {
+				Expect(r.Run([]string{"webhook", "list"})).To(Succeed())
+				Eventually(stdoutWriter).Should(gbytes.Say(
+					"1: https://example.com/a\n2: https://example.com/b \\(states: Blocked\\)\n"))
+			})
+
+			Context("when the repo of the API does not support webhooks", func() {
+				BeforeEach(func() {
+					webhooks.WebhooksReturns(nil, &client.NotSupportedError{Message: "webhooks are not supported by this repo"})
+				})
+
+				It("says so", func() {
+					err := r.Run([]string{"webhook", "list"})
+					Expect(err).To(MatchError("Command 'webhook' failed: cannot list webhooks: webhooks are not supported by this repo"))
+				})
+			})
+		})
+
+		Describe("remove", func() {
+			BeforeEach(func() {
+				webhooks.FindWebhookByIDReturns(&webhook.Webhook{ID: 2, URL: "https://example.com/b"}, nil)
+			})
+
+			It("removes the webhook", func() {
+				Expect(r.Run([]string{"webhook", "remove", "2"})).To(Succeed())
+				Expect(webhooks.FindWebhookByIDArgsForCall(0)).To(Equal(2))
+				Expect(webhooks.DeleteWebhookCallCount()).To(Equal(1))
+				Expect(webhooks.DeleteWebhookArgsForCall(0).ID).To(Equal(2))
+			})
+
+			Context("when the webhook does not exist", func() {
+				BeforeEach(func() {
+					webhooks.FindWebhookByIDReturns(nil, nil)
+				})
+
+				It("fails", func() {
+					err := r.Run([]string{"webhook", "remove", "2"})
+					Expect(err).To(MatchError("Command 'webhook' failed: cannot remove webhook: unknown webhook with ID 2"))
+					Expect(webhooks.DeleteWebhookCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		It("fails on an unknown subcommand", func() {
+			err := r.Run([]string{"webhook", "list", "everything"})
+			Expect(err).To(MatchError("Command 'webhook' failed: expected 'add url', 'list', or 'remove id', got 'list everything'"))
+		})
+
+		Context("when the runner has no webhooks", func() {
+			BeforeEach(func() {
+				r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter)
+			})
+
+			It("fails", func() {
+				err := r.Run([]string{"webhook", "list"})
+				Expect(err).To(MatchError("Command 'webhook' failed: cannot list webhooks: only the API has webhooks"))
+			})
+		})
+	})
+
+	Describe("login", func() {
+		var names []string
+
+		BeforeEach(func() {
+			names = nil
+			login := func(name string) error {
+				names = append(names, name)
+				return nil
+			}
+			r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter, runner.WithLogin(login))
+		})
+
+		It("logs in as the user", func() {
+			Expect(r.Run([]string{"login", "user-a"})).To(Succeed())
+			Expect(names).To(Equal([]string{"user-a"}))
+		})
+
+		Context("when logging in fails", func() {
+			BeforeEach(func() {
+				login := func(name string) error { return errors.New("some error") }
+				r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter, runner.WithLogin(login))
+			})
+
+			It("fails", func() {
+				err := r.Run([]string{"login", "user-a"})
+				Expect(err).To(MatchError("Command 'login' failed: cannot log in: some error"))
+			})
+		})
+
+		Context("when the runner cannot log in", func() {
+			BeforeEach(func() {
+				r = runner.New(&runner.BuildInfo{}, manager, stdoutWriter, debugWriter)
+			})
+
+			It("fails", func() {
+				err := r.Run([]string{"login", "user-a"})
+				Expect(err).To(MatchError("Command 'login' failed: cannot log in: only the API has users"))
+			})
+		})
+	})
 })
@@ -0,0 +1,453 @@
+package runner
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// A Format describes how a Runner writes the results of its commands, e.g., the tasks printed by
+// the show command.
+type Format string
+
+// These are the Format's that a Runner supports. FormatText is meant for humans, and the rest are
+// meant for scripts.
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatCSV  Format = "csv"
+	FormatTSV  Format = "tsv"
+)
+
+// Formats returns the Format's that a Runner supports.
+func Formats() []Format {
+	return []Format{FormatText, FormatJSON, FormatYAML, FormatCSV, FormatTSV}
+}
+
+// A result is the structured output of a command.
+type result interface {
+	// Write the result in a human-readable form.
+	writeText(w io.Writer)
+	// Get the data that should be written in a structured form, e.g., JSON.
+	data() interface{}
+	// Get the result as a table, i.e., a header row and some data rows.
+	table() ([]string, [][]string)
+}
+
+// A formatter writes a result in a Format.
+type formatter func(w io.Writer, r result) error
+
+// These are the formatter's for each Format.
+var formatters = map[Format]formatter{
+	FormatText: func(w io.Writer, r result) error {
+		r.writeText(w)
+		return nil
+	},
+	FormatJSON: func(w io.Writer, r result) error {
+		data, err := json.MarshalIndent(r.data(), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	},
+	FormatYAML: func(w io.Writer, r result) error {
+		// Go through JSON so that the YAML uses the same field names as the JSON.
+		jsonData, err := json.Marshal(r.data())
+		if err != nil {
+			return err
+		}
+
+		var value interface{}
+		if err := yaml.Unmarshal(jsonData, &value); err != nil {
+			return err
+		}
+
+		data, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	},
+	FormatCSV: func(w io.Writer, r result) error {
+		return writeTable(w, tableCommas[FormatCSV], r)
+	},
+	FormatTSV: func(w io.Writer, r result) error {
+		return writeTable(w, tableCommas[FormatTSV], r)
+	},
+}
+
+// These are the separators of the Format's that write a result as a table.
+var tableCommas = map[Format]rune{
+	FormatCSV: ',',
+	FormatTSV: '\t',
+}
+
+func writeTable(w io.Writer, comma rune, r result) error {
+	header, rows := r.table()
+	return writeRows(w, comma, append([][]string{header}, rows...))
+}
+
+func writeRows(w io.Writer, comma rune, rows [][]string) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+type versionResult struct {
+	Version int    `json:"version"`
+	Hash    string `json:"hash"`
+	Date    string `json:"date"`
+}
+
+func (r *versionResult) writeText(w io.Writer) {
+	fmt.Fprintln(w, "ANWORK Version =", r.Version)
+	fmt.Fprintln(w, "ANWORK Build Hash =", r.Hash)
+	fmt.Fprintln(w, "ANWORK Build Date =", r.Date)
+}
+
+func (r *versionResult) data() interface{} {
+	return r
+}
+
+func (r *versionResult) table() ([]string, [][]string) {
+	return []string{"version", "hash", "date"},
+		[][]string{[]string{strconv.Itoa(r.Version), r.Hash, r.Date}}
+}
+
+var taskTableHeader = []string{"id", "name", "state", "priority", "startDate", "deadline", "tags"}
+
+func taskTableRow(t *task.Task) []string {
+	return []string{
+		strconv.Itoa(t.ID),
+		t.Name,
+		string(t.State),
+		strconv.Itoa(t.Priority),
+		strconv.FormatInt(t.StartDate, 10),
+		strconv.FormatInt(t.Deadline, 10),
+		strings.Join(t.Tags, " "),
+	}
+}
+
+// A tasksResult is a list of tasks, grouped by state when written as text.
+type tasksResult struct {
+	tasks []*task.Task
+}
+
+func (r *tasksResult) writeText(w io.Writer) {
+	printer := func(state task.State) {
+		fmt.Fprintf(w, "%s tasks:\n", strings.ToUpper(string(state)))
+		for _, task := range r.tasks {
+			if task.State == state {
+				fmt.Fprintf(w, "  %s (%d)\n", task.Name, task.ID)
+			}
+		}
+	}
+	printer(task.StateRunning)
+	printer(task.StateBlocked)
+	printer(task.StateReady)
+	printer(task.StateFinished)
+}
+
+func (r *tasksResult) data() interface{} {
+	return r.tasks
+}
+
+func (r *tasksResult) table() ([]string, [][]string) {
+	rows := make([][]string, len(r.tasks))
+	for i, t := range r.tasks {
+		rows[i] = taskTableRow(t)
+	}
+	return taskTableHeader, rows
+}
+
+// A taskDetailsResult is a task and the tasks on which it depends.
+type taskDetailsResult struct {
+	*task.Task
+	DependsOn []*task.Task `json:"dependsOn"`
+
+	now time.Time
+}
+
+func (r *taskDetailsResult) writeText(w io.Writer) {
+	t := r.Task
+	fmt.Fprintf(w, "Name: %s\n", t.Name)
+	fmt.Fprintf(w, "ID: %d\n", t.ID)
+	fmt.Fprintf(w, "Created: %s\n", formatDate(t.StartDate))
+	fmt.Fprintf(w, "Priority: %d\n", t.Priority)
+	fmt.Fprintf(w, "State: %s\n", strings.ToUpper(string(t.State)))
+	if t.Deadline != 0 {
+		fmt.Fprintf(w, "Deadline: %s (%s)\n", formatDate(t.Deadline),
+			formatTimeRemaining(t.Deadline, r.now))
+	}
+
+	if len(r.DependsOn) > 0 {
+		names := make([]string, len(r.DependsOn))
+		for i, dependency := range r.DependsOn {
+			names[i] = fmt.Sprintf("%s (%d)", dependency.Name, dependency.ID)
+		}
+		fmt.Fprintf(w, "Depends on: %s\n", strings.Join(names, ", "))
+	}
+
+	if len(t.Tags) > 0 {
+		fmt.Fprintf(w, "Tags: %s\n", strings.Join(t.Tags, ", "))
+	}
+}
+
+func (r *taskDetailsResult) data() interface{} {
+	return r
+}
+
+func (r *taskDetailsResult) table() ([]string, [][]string) {
+	dependsOn := make([]string, len(r.DependsOn))
+	for i, dependency := range r.DependsOn {
+		dependsOn[i] = strconv.Itoa(dependency.ID)
+	}
+
+	header := append(append([]string{}, taskTableHeader...), "dependsOn")
+	row := append(taskTableRow(r.Task), strings.Join(dependsOn, " "))
+	return header, [][]string{row}
+}
+
+// An eventsResult is a list of events, i.e., the journal.
+type eventsResult struct {
+	events []*task.Event
+}
+
+func (r *eventsResult) writeText(w io.Writer) {
+	for _, e := range r.events {
+		fmt.Fprintf(w, "[%s]: %s\n", formatDate(e.Date), e.Title)
+	}
+}
+
+func (r *eventsResult) data() interface{} {
+	return r.events
+}
+
+func (r *eventsResult) table() ([]string, [][]string) {
+	header := []string{
+		"id", "date", "type", "taskId", "title", "oldValue", "newValue", "note", "actor",
+	}
+	rows := make([][]string, len(r.events))
+	for i, e := range r.events {
+		rows[i] = []string{
+			strconv.Itoa(e.ID),
+			strconv.FormatInt(e.Date, 10),
+			strconv.Itoa(int(e.Type)),
+			strconv.Itoa(e.TaskID),
+			e.Title,
+			e.OldValue,
+			e.NewValue,
+			e.Note,
+			e.Actor,
+		}
+	}
+	return header, rows
+}
+
+// A summaryResult is a list of the tasks that were finished recently.
+type summaryResult struct {
+	entries []*summaryEntry
+}
+
+// A summaryEntry is an event that says that a task was finished.
+type summaryEntry struct {
+	Event *task.Event `json:"event"`
+
+	// This is the number of seconds that it took to finish the task, or nil if it is unknown.
+	Took *int64 `json:"took,omitempty"`
+}
+
+func (r *summaryResult) writeText(w io.Writer) {
+	for _, entry := range r.entries {
+		fmt.Fprintf(w, "[%s]: %s\n", formatDate(entry.Event.Date), entry.Event.Title)
+		if entry.Took != nil {
+			fmt.Fprintf(w, "  took %s\n", formatDuration(time.Duration(*entry.Took)*time.Second))
+		}
+	}
+}
+
+func (r *summaryResult) data() interface{} {
+	return r.entries
+}
+
+func (r *summaryResult) table() ([]string, [][]string) {
+	header := []string{"date", "taskId", "title", "took"}
+	rows := make([][]string, len(r.entries))
+	for i, entry := range r.entries {
+		took := ""
+		if entry.Took != nil {
+			took = strconv.FormatInt(*entry.Took, 10)
+		}
+		rows[i] = []string{
+			strconv.FormatInt(entry.Event.Date, 10),
+			strconv.Itoa(entry.Event.TaskID),
+			entry.Event.Title,
+			took,
+		}
+	}
+	return header, rows
+}
+
+// A problemsResult is a list of the ways in which the tasks do not match the journal.
+type problemsResult struct {
+	problems []string
+}
+
+func (r *problemsResult) writeText(w io.Writer) {
+	if len(r.problems) == 0 {
+		fmt.Fprintln(w, "The tasks match the journal")
+		return
+	}
+
+	for _, problem := range r.problems {
+		fmt.Fprintln(w, problem)
+	}
+}
+
+func (r *problemsResult) data() interface{} {
+	return r.problems
+}
+
+func (r *problemsResult) table() ([]string, [][]string) {
+	rows := make([][]string, len(r.problems))
+	for i, problem := range r.problems {
+		rows[i] = []string{problem}
+	}
+	return []string{"problem"}, rows
+}
+
+// A changesResult is a list of the changes that were made to migrate a context.
+type changesResult struct {
+	changes []string
+}
+
+func (r *changesResult) writeText(w io.Writer) {
+	if len(r.changes) == 0 {
+		fmt.Fprintln(w, "The context already has the latest format")
+		return
+	}
+
+	for _, change := range r.changes {
+		fmt.Fprintln(w, change)
+	}
+}
+
+func (r *changesResult) data() interface{} {
+	if r.changes == nil {
+		return []string{}
+	}
+	return r.changes
+}
+
+func (r *changesResult) table() ([]string, [][]string) {
+	rows := make([][]string, len(r.changes))
+	for i, change := range r.changes {
+		rows[i] = []string{change}
+	}
+	return []string{"change"}, rows
+}
+
+// A contextsResult is a list of the names of the contexts.
+type contextsResult struct {
+	names []string
+}
+
+func (r *contextsResult) writeText(w io.Writer) {
+	for _, name := range r.names {
+		fmt.Fprintln(w, name)
+	}
+}
+
+func (r *contextsResult) data() interface{} {
+	if r.names == nil {
+		return []string{}
+	}
+	return r.names
+}
+
+func (r *contextsResult) table() ([]string, [][]string) {
+	rows := make([][]string, len(r.names))
+	for i, name := range r.names {
+		rows[i] = []string{name}
+	}
+	return []string{"context"}, rows
+}
+
+// A webhooksResult is a list of webhooks. The secret of a webhook is only written if it is set.
+type webhooksResult struct {
+	webhooks []*webhook.Webhook
+}
+
+func (r *webhooksResult) writeText(w io.Writer) {
+	for _, webhook := range r.webhooks {
+		fmt.Fprintf(w, "%d: %s", webhook.ID, webhook.URL)
+		if len(webhook.EventTypes) > 0 {
+			fmt.Fprintf(w, " (types: %s)", eventTypesString(webhook.EventTypes))
+		}
+		if len(webhook.States) > 0 {
+			fmt.Fprintf(w, " (states: %s)", statesString(webhook.States))
+		}
+		fmt.Fprintln(w)
+		if webhook.Secret != "" {
+			fmt.Fprintf(w, "  secret: %s\n", webhook.Secret)
+		}
+	}
+}
+
+func (r *webhooksResult) data() interface{} {
+	if r.webhooks == nil {
+		return []*webhook.Webhook{}
+	}
+	return r.webhooks
+}
+
+func (r *webhooksResult) table() ([]string, [][]string) {
+	rows := make([][]string, len(r.webhooks))
+	for i, webhook := range r.webhooks {
+		rows[i] = []string{
+			strconv.Itoa(webhook.ID),
+			webhook.URL,
+			eventTypesString(webhook.EventTypes),
+			statesString(webhook.States),
+			webhook.Secret,
+		}
+	}
+	return []string{"id", "url", "types", "states", "secret"}, rows
+}
+
+// eventTypesString returns the names of task.EventType's, e.g., "set-state,note".
+func eventTypesString(eventTypes []task.EventType) string {
+	names := make([]string, len(eventTypes))
+	for i, eventType := range eventTypes {
+		names[i] = strconv.Itoa(int(eventType))
+		for name, t := range eventTypeNames {
+			if t == eventType {
+				names[i] = name
+			}
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// statesString returns task.State's, e.g., "Blocked,Finished".
+func statesString(states []task.State) string {
+	names := make([]string, len(states))
+	for i, state := range states {
+		names[i] = string(state)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,211 @@
+package runner_test
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ankeesler/anwork/manager/managerfakes"
+	"github.com/ankeesler/anwork/query"
+	"github.com/ankeesler/anwork/runner"
+	"github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Format", func() {
+	var (
+		stdoutWriter, debugWriter *gbytes.Buffer
+		manager                   *managerfakes.FakeManager
+		tasks                     []*task.Task
+		events                    []*task.Event
+		bi                        *runner.BuildInfo
+	)
+
+	BeforeEach(func() {
+		manager = &managerfakes.FakeManager{}
+
+		stdoutWriter = gbytes.NewBuffer()
+		debugWriter = gbytes.NewBuffer()
+
+		bi = &runner.BuildInfo{
+			Hash: "abc123",
+			Date: "February 22, 1992",
+		}
+
+		tasks = []*task.Task{
+			&task.Task{
+				Name:      "task-a",
+				ID:        10,
+				StartDate: 100,
+				Priority:  1,
+				State:     task.StateRunning,
+				Tags:      []string{"infra", "web"},
+			},
+			&task.Task{
+				Name:      "task, b",
+				ID:        20,
+				StartDate: 200,
+				Priority:  2,
+				State:     task.StateReady,
+				Deadline:  300,
+			},
+		}
+		manager.TasksReturns(tasks, nil)
+
+		finishedDate := time.Now().Add(-time.Hour).Unix()
+		events = []*task.Event{
+			&task.Event{
+				ID:       1,
+				Title:    "Created task 'task-a'",
+				Date:     finishedDate - 90,
+				Type:     task.EventTypeCreate,
+				TaskID:   10,
+				NewValue: "task-a",
+			},
+			&task.Event{
+				ID:       2,
+				Title:    "Set state on task 'task-a' from Running to Finished",
+				Date:     finishedDate,
+				Type:     task.EventTypeSetState,
+				TaskID:   10,
+				OldValue: "Running",
+				NewValue: "Finished",
+				Actor:    "some-user",
+			},
+		}
+		manager.EventsMatchingStub = func(q *query.EventQuery) ([]*task.Event, error) {
+			return q.Select(events), nil
+		}
+	})
+
+	run := func(format runner.Format, args ...string) {
+		r := runner.New(bi, manager, stdoutWriter, debugWriter, runner.WithFormat(format))
+		Expect(r.Run(args)).To(Succeed())
+	}
+
+	Describe("json", func() {
+		It("writes the tasks as a JSON array", func() {
+			run(runner.FormatJSON, "show")
+
+			var actualTasks []*task.Task
+			Expect(json.Unmarshal(stdoutWriter.Contents(), &actualTasks)).To(Succeed())
+			Expect(actualTasks).To(Equal(tasks))
+		})
+
+		It("writes the details of a task, including its dependencies", func() {
+			manager.FindByNameReturns(tasks[0], nil)
+			manager.DependenciesReturns([]*task.Task{tasks[1]}, nil)
+			run(runner.FormatJSON, "show", "task-a")
+
+			var details struct {
+				Name      string       `json:"name"`
+				ID        int          `json:"id"`
+				Tags      []string     `json:"tags"`
+				DependsOn []*task.Task `json:"dependsOn"`
+			}
+			Expect(json.Unmarshal(stdoutWriter.Contents(), &details)).To(Succeed())
+			Expect(details.Name).To(Equal("task-a"))
+			Expect(details.ID).To(Equal(10))
+			Expect(details.Tags).To(Equal([]string{"infra", "web"}))
+			Expect(details.DependsOn).To(Equal([]*task.Task{tasks[1]}))
+		})
+
+		It("writes the journal as a JSON array, newest event first", func() {
+			run(runner.FormatJSON, "journal")
+
+			var actualEvents []*task.Event
+			Expect(json.Unmarshal(stdoutWriter.Contents(), &actualEvents)).To(Succeed())
+			Expect(actualEvents).To(Equal([]*task.Event{events[1], events[0]}))
+		})
+
+		It("writes the summary with the number of seconds each task took", func() {
+			run(runner.FormatJSON, "summary", "1")
+
+			var entries []struct {
+				Event *task.Event `json:"event"`
+				Took  int64       `json:"took"`
+			}
+			Expect(json.Unmarshal(stdoutWriter.Contents(), &entries)).To(Succeed())
+			Expect(entries).To(HaveLen(1))
+			Expect(entries[0].Event).To(Equal(events[1]))
+			Expect(entries[0].Took).To(Equal(int64(90)))
+		})
+
+		It("writes the version as a JSON object", func() {
+			run(runner.FormatJSON, "version")
+			Expect(stdoutWriter.Contents()).To(MatchJSON(
+				`{"version": 9, "hash": "abc123", "date": "February 22, 1992"}`,
+			))
+		})
+
+		It("writes an empty array when there are no results", func() {
+			manager.EventsMatchingReturns([]*task.Event{}, nil)
+			run(runner.FormatJSON, "journal")
+			Expect(stdoutWriter.Contents()).To(MatchJSON(`[]`))
+		})
+	})
+
+	Describe("yaml", func() {
+		It("writes the results using the same field names as json", func() {
+			run(runner.FormatYAML, "version")
+			Expect(string(stdoutWriter.Contents())).To(Equal(
+				"date: February 22, 1992\nhash: abc123\nversion: 9\n",
+			))
+		})
+
+		It("writes lists of results", func() {
+			run(runner.FormatYAML, "show")
+			Expect(stdoutWriter).To(gbytes.Say("- deadline: 0\n  id: 10\n  name: task-a\n"))
+			Expect(stdoutWriter).To(gbytes.Say("- deadline: 300\n  id: 20\n  name: task, b\n"))
+		})
+	})
+
+	Describe("csv", func() {
+		It("writes the tasks as a table with a header", func() {
+			run(runner.FormatCSV, "show")
+			Expect(string(stdoutWriter.Contents())).To(Equal(
+				"id,name,state,priority,startDate,deadline,tags\n" +
+					"10,task-a,Running,1,100,0,infra web\n" +
+					"20,\"task, b\",Ready,2,200,300,\n",
+			))
+		})
+
+		It("writes the journal as a table with a header", func() {
+			run(runner.FormatCSV, "journal")
+			Expect(stdoutWriter).To(gbytes.Say(
+				"id,date,type,taskId,title,oldValue,newValue,note,actor\n" +
+					"2,\\d+,2,10,Set state on task 'task-a' from Running to Finished,Running,Finished,,some-user\n" +
+					"1,\\d+,0,10,Created task 'task-a',,task-a,,\n",
+			))
+		})
+	})
+
+	Describe("tsv", func() {
+		It("writes the tasks as a table with a header, separated by tabs", func() {
+			run(runner.FormatTSV, "show")
+			Expect(string(stdoutWriter.Contents())).To(Equal(
+				"id\tname\tstate\tpriority\tstartDate\tdeadline\ttags\n" +
+					"10\ttask-a\tRunning\t1\t100\t0\tinfra web\n" +
+					"20\ttask, b\tReady\t2\t200\t300\t\n",
+			))
+		})
+	})
+
+	Context("when the format is unknown", func() {
+		It("returns an error", func() {
+			r := runner.New(bi, manager, stdoutWriter, debugWriter, runner.WithFormat("xml"))
+			Expect(r.Run([]string{"show"})).To(MatchError("Unknown format: 'xml'"))
+		})
+	})
+
+	Context("when the command fails", func() {
+		It("does not write anything", func() {
+			manager.TasksReturns(nil, errors.New("some tasks error"))
+			r := runner.New(bi, manager, stdoutWriter, debugWriter, runner.WithFormat(runner.FormatJSON))
+			Expect(r.Run([]string{"show"})).NotTo(Succeed())
+			Expect(stdoutWriter.Contents()).To(BeEmpty())
+		})
+	})
+})
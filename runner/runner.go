@@ -5,17 +5,26 @@
 package runner
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strings"
 
+	"code.cloudfoundry.org/clock"
+	"github.com/ankeesler/anwork/api/client"
 	"github.com/ankeesler/anwork/manager"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
 )
 
 // Print the usage of every anwork runner command to the provided output writer.
 func Usage(output io.Writer) {
 	for _, c := range commands {
-		fmt.Fprintf(output, "  %s %s\n", c.Name, strings.Join(c.Args, " "))
+		usage := append([]string{}, c.Args...)
+		for _, o := range c.Options {
+			usage = append(usage, o.Usage())
+		}
+		fmt.Fprintf(output, "  %s %s\n", c.Name, strings.Join(usage, " "))
 		fmt.Fprintf(output, "        %s", c.Description)
 		if c.Alias != "" {
 			fmt.Fprintf(output, " (alias: %s)", c.Alias)
@@ -39,6 +48,10 @@ func MarkdownUsage(output io.Writer) {
 		if c.Alias != "" {
 			fmt.Fprintf(output, "* Alias: `%s`\n", c.Alias)
 		}
+
+		for _, o := range c.Options {
+			fmt.Fprintf(output, "* Option `%s`: %s\n", o.Usage(), o.Description)
+		}
 	}
 }
 
@@ -53,18 +66,107 @@ type Runner struct {
 	buildInfo                 *BuildInfo
 	manager                   manager.Manager
 	stdoutWriter, debugWriter io.Writer
+	format                    Format
+	migrateContext            ContextMigrator
+	contexts                  task.Contexts
+	followEvents              EventFollower
+	webhooks                  webhook.Repo
+	login                     Login
+	clock                     clock.Clock
+}
+
+// An Option configures optional behavior of a Runner returned from New.
+type Option func(*Runner)
+
+// WithFormat sets the Format in which a Runner writes the results of its commands. By default, a
+// Runner uses FormatText.
+func WithFormat(format Format) Option {
+	return func(a *Runner) {
+		a.format = format
+	}
+}
+
+// A ContextMigrator upgrades the context in which tasks are stored to its latest format, and
+// returns a description of each change that it made.
+type ContextMigrator func() ([]string, error)
+
+// WithContextMigrator sets the ContextMigrator that is used by the migrate-context command. By
+// default, a Runner cannot migrate its context.
+func WithContextMigrator(migrateContext ContextMigrator) Option {
+	return func(a *Runner) {
+		a.migrateContext = migrateContext
+	}
+}
+
+// WithContexts sets the task.Contexts that are used by the commands that list, create, copy, and
+// delete contexts. By default, a Runner cannot do any of those things.
+func WithContexts(contexts task.Contexts) Option {
+	return func(a *Runner) {
+		a.contexts = contexts
+	}
+}
+
+// An EventFollower calls a function with each task.Event that is created after the one with the
+// provided ID, in order, until the function returns an error, which the EventFollower then
+// returns.
+type EventFollower func(lastEventID int, handle func(*task.Event) error) error
+
+// WithEventFollower sets the EventFollower that is used by "journal --follow". By default, a Runner
+// cannot follow the journal.
+func WithEventFollower(followEvents EventFollower) Option {
+	return func(a *Runner) {
+		a.followEvents = followEvents
+	}
+}
+
+// WithWebhooks sets the webhook.Repo that is used by the webhook command. By default, a Runner
+// cannot add, list, or remove webhooks.
+func WithWebhooks(webhooks webhook.Repo) Option {
+	return func(a *Runner) {
+		a.webhooks = webhooks
+	}
+}
+
+// A Login logs in as the user with the provided name, so that the commands that follow are run as
+// that user.
+type Login func(name string) error
+
+// WithLogin sets the Login that is used by the login command. By default, a Runner cannot log in.
+func WithLogin(login Login) Option {
+	return func(a *Runner) {
+		a.login = login
+	}
+}
+
+// WithClock sets the clock.Clock with which a Runner tells the time, e.g., how long remains until the
+// deadline of a task. By default, the real time is used.
+func WithClock(clock clock.Clock) Option {
+	return func(a *Runner) {
+		a.clock = clock
+	}
 }
 
 // New creates a new Runner. The manager.Manager will be used to perform the task
 // operations. The Runner will write its regular output to the stdoutWriter and its
 // debug output to the debugWriter.
-func New(buildInfo *BuildInfo, manager manager.Manager, stdoutWriter, debugWriter io.Writer) *Runner {
-	return &Runner{
+func New(
+	buildInfo *BuildInfo,
+	manager manager.Manager,
+	stdoutWriter, debugWriter io.Writer,
+	options ...Option,
+) *Runner {
+	a := &Runner{
 		buildInfo:    buildInfo,
 		manager:      manager,
 		stdoutWriter: stdoutWriter,
 		debugWriter:  debugWriter,
+		format:       FormatText,
+		clock:        clock.NewClock(),
+	}
+	for _, option := range options {
+		option(a)
 	}
+	return a
 }
 
 // Run the functionality specified via the arguments. The Runner will parse the args
@@ -76,6 +178,11 @@ func (a *Runner) Run(args []string) error {
 		return fmt.Errorf("Unknown command: '%s'", args[0])
 	}
 
+	args, err := parseOptions(cmd, args)
+	if err != nil {
+		return err
+	}
+
 	if !validateArgs(cmd, args) {
 		return fmt.Errorf("Invalid argument passed to command '%s':\n\tGot: %s\n\tExpected: %s",
 			cmd.Name, args[1:], cmd.Args)
@@ -83,7 +190,26 @@ func (a *Runner) Run(args []string) error {
 
 	a.debug("Manager is %s\n", a.manager)
 
+	if _, ok := formatters[a.format]; !ok {
+		return fmt.Errorf("Unknown format: '%s'", a.format)
+	}
+	cmd.format = a.format
+	cmd.migrateContext = a.migrateContext
+	cmd.contexts = a.contexts
+	cmd.followEvents = a.followEvents
+	cmd.webhooks = a.webhooks
+	cmd.login = a.login
+	cmd.clock = a.clock
+
 	if err := cmd.Action(cmd, args, a.stdoutWriter, a.manager, a.buildInfo); err != nil {
+		var conflict *client.ConflictError
+		if errors.As(err, &conflict) {
+			return fmt.Errorf(
+				"Command '%s' failed: %s (run it again to change the latest version of the task)",
+				args[0],
+				err.Error(),
+			)
+		}
 		return fmt.Errorf("Command '%s' failed: %s", args[0], err.Error())
 	}
 
@@ -109,3 +235,45 @@ func validateArgs(cmd *command, args []string) bool {
 
 	return false
 }
+
+// parseOptions removes the options from the args and stores them in the command. It returns the
+// remaining args.
+func parseOptions(cmd *command, args []string) ([]string, error) {
+	cmd.optionValues = map[string]string{}
+	remainingArgs := []string{args[0]}
+	for i := 1; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "--") {
+			remainingArgs = append(remainingArgs, args[i])
+			continue
+		}
+
+		name, value := strings.TrimPrefix(args[i], "--"), ""
+		hasValue := false
+		if equals := strings.Index(name, "="); equals != -1 {
+			name, value, hasValue = name[:equals], name[equals+1:], true
+		}
+
+		o := cmd.findOption(name)
+		if o == nil {
+			return nil, fmt.Errorf("Unknown option passed to command '%s': '%s'", cmd.Name, args[i])
+		}
+
+		if o.Value == "" {
+			if hasValue {
+				return nil, fmt.Errorf("Option '--%s' passed to command '%s' does not take a value",
+					name, cmd.Name)
+			}
+			value = "true"
+		} else if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("Option '--%s' passed to command '%s' expects a value: %s",
+					name, cmd.Name, o.Value)
+			}
+			i++
+			value = args[i]
+		}
+
+		cmd.optionValues[name] = value
+	}
+	return remainingArgs, nil
+}
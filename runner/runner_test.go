@@ -2,6 +2,8 @@ package runner_test
 
 import (
 	"errors"
+	"strings"
+	"sync"
 
 	"github.com/ankeesler/anwork/manager/managerfakes"
 	"github.com/ankeesler/anwork/runner"
@@ -113,13 +115,42 @@ var _ = Describe("AnworkRunner", func() {
 		})
 	})
 
+	Context("when Runners with different settings run the same command at the same time", func() {
+		It("runs the command with each Runner's own settings", func() {
+			bi := &runner.BuildInfo{Hash: "abc123", Date: "February 22, 1992"}
+			jsonWriter, textWriter := gbytes.NewBuffer(), gbytes.NewBuffer()
+			jsonRunner := runner.New(bi, manager, jsonWriter, debugWriter, runner.WithFormat(runner.FormatJSON))
+			textRunner := runner.New(bi, manager, textWriter, debugWriter)
+
+			const runs = 20
+			var wg sync.WaitGroup
+			for i := 0; i < runs; i++ {
+				wg.Add(2)
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(jsonRunner.Run([]string{"version"})).To(Succeed())
+				}()
+				go func() {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(textRunner.Run([]string{"version"})).To(Succeed())
+				}()
+			}
+			wg.Wait()
+
+			Expect(strings.Count(string(jsonWriter.Contents()), "abc123")).To(Equal(runs))
+			Expect(string(textWriter.Contents())).NotTo(ContainSubstring("{"))
+		})
+	})
+
 	Describe("Usage", func() {
 		It("prints the usage information for every command in a command line format", func() {
 			buffer := gbytes.NewBuffer()
 			runner.Usage(buffer)
 			Expect(buffer).To(gbytes.Say("  create task-name"))
 			Expect(buffer).To(gbytes.Say("   Create a new task \\(alias: c\\)"))
-			Expect(buffer).To(gbytes.Say("  show \\[task-name\\]"))
+			Expect(buffer).To(gbytes.Say("  show \\[task-name\\] \\[--tag tag\\]"))
 			Expect(buffer).To(gbytes.Say("   Show the current tasks, or the details of a specific task \\(alias: s\\)"))
 			Expect(buffer).To(gbytes.Say("  set-running task-name"))
 			Expect(buffer).To(gbytes.Say("   Mark a task as running \\(alias: sr\\)"))
@@ -138,6 +169,7 @@ var _ = Describe("AnworkRunner", func() {
 			Expect(buffer).To(gbytes.Say("### `anwork show \\[task-name\\]`"))
 			Expect(buffer).To(gbytes.Say("\\* Show the current tasks, or the details of a specific task\n"))
 			Expect(buffer).To(gbytes.Say("\\* Alias: `s`"))
+			Expect(buffer).To(gbytes.Say("\\* Option `\\[--tag tag\\]`: Only show tasks with this tag"))
 			Expect(buffer).To(gbytes.Say("### `anwork set-running task-name`"))
 			Expect(buffer).To(gbytes.Say("\\* Mark a task as running\n"))
 			Expect(buffer).To(gbytes.Say("\\* Alias: `sr`"))
@@ -0,0 +1,50 @@
+// Package session contains the sessions of the people who use the ANWORK API.
+//
+// A Session is started each time that the API generates a token, and it lasts until the token
+// expires, or until the Session is revoked, e.g., when its user logs out. A token is only valid
+// while its Session is in the Repo.
+package session
+
+// A Session is the life of one token generated by the API.
+type Session struct {
+	// This is the JWT ID (jti) of the token. Every Session has a different ID.
+	ID string `json:"id"`
+
+	// This is the subject of the token, i.e., the name of the user.User for whom it was generated,
+	// or "" if it was generated for no one in particular.
+	Subject string `json:"subject"`
+
+	// This is when the token was generated, represented by the number of seconds since January 1,
+	// 1970.
+	IssuedAt int64 `json:"issuedAt"`
+
+	// This is when the token expires, represented by the number of seconds since January 1, 1970.
+	Expiry int64 `json:"expiry"`
+}
+
+// Expired returns whether the Session has expired at a time, represented by the number of seconds
+// since January 1, 1970.
+func (s *Session) Expired(now int64) bool {
+	return s.Expiry <= now
+}
+
+//go:generate counterfeiter . Repo
+
+// Repo is an object that stores Session's. Unlike task.Task's, Session's do not belong to a
+// context.
+type Repo interface {
+	// CreateSession stores a Session. It returns an error if there is already a Session with the
+	// same ID.
+	CreateSession(*Session) error
+	// Sessions returns all of the Session's in this Repo, in the order in which they were issued.
+	Sessions() ([]*Session, error)
+	// FindSessionByID tries to find a Session with the provided ID. If the Session does not exist,
+	// it will return nil, nil.
+	FindSessionByID(string) (*Session, error)
+	// DeleteSession deletes the Session with the provided ID. If the Session does not exist, this
+	// function will return nil.
+	DeleteSession(*Session) error
+	// DeleteExpiredSessions deletes every Session that has expired at a time, represented by the
+	// number of seconds since January 1, 1970.
+	DeleteExpiredSessions(now int64) error
+}
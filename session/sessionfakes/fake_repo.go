@@ -0,0 +1,403 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package sessionfakes
+
+import (
+	sync "sync"
+
+	session "github.com/ankeesler/anwork/session"
+)
+
+type FakeRepo struct {
+	CreateSessionStub        func(*session.Session) error
+	createSessionMutex       sync.RWMutex
+	createSessionArgsForCall []struct {
+		arg1 *session.Session
+	}
+	createSessionReturns struct {
+		result1 error
+	}
+	createSessionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteExpiredSessionsStub        func(int64) error
+	deleteExpiredSessionsMutex       sync.RWMutex
+	deleteExpiredSessionsArgsForCall []struct {
+		arg1 int64
+	}
+	deleteExpiredSessionsReturns struct {
+		result1 error
+	}
+	deleteExpiredSessionsReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteSessionStub        func(*session.Session) error
+	deleteSessionMutex       sync.RWMutex
+	deleteSessionArgsForCall []struct {
+		arg1 *session.Session
+	}
+	deleteSessionReturns struct {
+		result1 error
+	}
+	deleteSessionReturnsOnCall map[int]struct {
+		result1 error
+	}
+	FindSessionByIDStub        func(string) (*session.Session, error)
+	findSessionByIDMutex       sync.RWMutex
+	findSessionByIDArgsForCall []struct {
+		arg1 string
+	}
+	findSessionByIDReturns struct {
+		result1 *session.Session
+		result2 error
+	}
+	findSessionByIDReturnsOnCall map[int]struct {
+		result1 *session.Session
+		result2 error
+	}
+	SessionsStub        func() ([]*session.Session, error)
+	sessionsMutex       sync.RWMutex
+	sessionsArgsForCall []struct {
+	}
+	sessionsReturns struct {
+		result1 []*session.Session
+		result2 error
+	}
+	sessionsReturnsOnCall map[int]struct {
+		result1 []*session.Session
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRepo) CreateSession(arg1 *session.Session) error {
+	fake.createSessionMutex.Lock()
+	ret, specificReturn := fake.createSessionReturnsOnCall[len(fake.createSessionArgsForCall)]
+	fake.createSessionArgsForCall = append(fake.createSessionArgsForCall, struct {
+		arg1 *session.Session
+	}{arg1})
+	fake.recordInvocation("CreateSession", []interface{}{arg1})
+	fake.createSessionMutex.Unlock()
+	if fake.CreateSessionStub != nil {
+		return fake.CreateSessionStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.createSessionReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepo) CreateSessionCallCount() int {
+	fake.createSessionMutex.RLock()
+	defer fake.createSessionMutex.RUnlock()
+	return len(fake.createSessionArgsForCall)
+}
+
+func (fake *FakeRepo) CreateSessionCalls(stub func(*session.Session) error) {
+	fake.createSessionMutex.Lock()
+	defer fake.createSessionMutex.Unlock()
+	fake.CreateSessionStub = stub
+}
+
+func (fake *FakeRepo) CreateSessionArgsForCall(i int) *session.Session {
+	fake.createSessionMutex.RLock()
+	defer fake.createSessionMutex.RUnlock()
+	argsForCall := fake.createSessionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) CreateSessionReturns(result1 error) {
+	fake.createSessionMutex.Lock()
+	defer fake.createSessionMutex.Unlock()
+	fake.CreateSessionStub = nil
+	fake.createSessionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) CreateSessionReturnsOnCall(i int, result1 error) {
+	fake.createSessionMutex.Lock()
+	defer fake.createSessionMutex.Unlock()
+	fake.CreateSessionStub = nil
+	if fake.createSessionReturnsOnCall == nil {
+		fake.createSessionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createSessionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) DeleteExpiredSessions(arg1 int64) error {
+	fake.deleteExpiredSessionsMutex.Lock()
+	ret, specificReturn := fake.deleteExpiredSessionsReturnsOnCall[len(fake.deleteExpiredSessionsArgsForCall)]
+	fake.deleteExpiredSessionsArgsForCall = append(fake.deleteExpiredSessionsArgsForCall, struct {
+		arg1 int64
+	}{arg1})
+	fake.recordInvocation("DeleteExpiredSessions", []interface{}{arg1})
+	fake.deleteExpiredSessionsMutex.Unlock()
+	if fake.DeleteExpiredSessionsStub != nil {
+		return fake.DeleteExpiredSessionsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.deleteExpiredSessionsReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepo) DeleteExpiredSessionsCallCount() int {
+	fake.deleteExpiredSessionsMutex.RLock()
+	defer fake.deleteExpiredSessionsMutex.RUnlock()
+	return len(fake.deleteExpiredSessionsArgsForCall)
+}
+
+func (fake *FakeRepo) DeleteExpiredSessionsCalls(stub func(int64) error) {
+	fake.deleteExpiredSessionsMutex.Lock()
+	defer fake.deleteExpiredSessionsMutex.Unlock()
+	fake.DeleteExpiredSessionsStub = stub
+}
+
+func (fake *FakeRepo) DeleteExpiredSessionsArgsForCall(i int) int64 {
+	fake.deleteExpiredSessionsMutex.RLock()
+	defer fake.deleteExpiredSessionsMutex.RUnlock()
+	argsForCall := fake.deleteExpiredSessionsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) DeleteExpiredSessionsReturns(result1 error) {
+	fake.deleteExpiredSessionsMutex.Lock()
+	defer fake.deleteExpiredSessionsMutex.Unlock()
+	fake.DeleteExpiredSessionsStub = nil
+	fake.deleteExpiredSessionsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) DeleteExpiredSessionsReturnsOnCall(i int, result1 error) {
+	fake.deleteExpiredSessionsMutex.Lock()
+	defer fake.deleteExpiredSessionsMutex.Unlock()
+	fake.DeleteExpiredSessionsStub = nil
+	if fake.deleteExpiredSessionsReturnsOnCall == nil {
+		fake.deleteExpiredSessionsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteExpiredSessionsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) DeleteSession(arg1 *session.Session) error {
+	fake.deleteSessionMutex.Lock()
+	ret, specificReturn := fake.deleteSessionReturnsOnCall[len(fake.deleteSessionArgsForCall)]
+	fake.deleteSessionArgsForCall = append(fake.deleteSessionArgsForCall, struct {
+		arg1 *session.Session
+	}{arg1})
+	fake.recordInvocation("DeleteSession", []interface{}{arg1})
+	fake.deleteSessionMutex.Unlock()
+	if fake.DeleteSessionStub != nil {
+		return fake.DeleteSessionStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.deleteSessionReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepo) DeleteSessionCallCount() int {
+	fake.deleteSessionMutex.RLock()
+	defer fake.deleteSessionMutex.RUnlock()
+	return len(fake.deleteSessionArgsForCall)
+}
+
+func (fake *FakeRepo) DeleteSessionCalls(stub func(*session.Session) error) {
+	fake.deleteSessionMutex.Lock()
+	defer fake.deleteSessionMutex.Unlock()
+	fake.DeleteSessionStub = stub
+}
+
+func (fake *FakeRepo) DeleteSessionArgsForCall(i int) *session.Session {
+	fake.deleteSessionMutex.RLock()
+	defer fake.deleteSessionMutex.RUnlock()
+	argsForCall := fake.deleteSessionArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) DeleteSessionReturns(result1 error) {
+	fake.deleteSessionMutex.Lock()
+	defer fake.deleteSessionMutex.Unlock()
+	fake.DeleteSessionStub = nil
+	fake.deleteSessionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) DeleteSessionReturnsOnCall(i int, result1 error) {
+	fake.deleteSessionMutex.Lock()
+	defer fake.deleteSessionMutex.Unlock()
+	fake.DeleteSessionStub = nil
+	if fake.deleteSessionReturnsOnCall == nil {
+		fake.deleteSessionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteSessionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) FindSessionByID(arg1 string) (*session.Session, error) {
+	fake.findSessionByIDMutex.Lock()
+	ret, specificReturn := fake.findSessionByIDReturnsOnCall[len(fake.findSessionByIDArgsForCall)]
+	fake.findSessionByIDArgsForCall = append(fake.findSessionByIDArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("FindSessionByID", []interface{}{arg1})
+	fake.findSessionByIDMutex.Unlock()
+	if fake.FindSessionByIDStub != nil {
+		return fake.FindSessionByIDStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.findSessionByIDReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepo) FindSessionByIDCallCount() int {
+	fake.findSessionByIDMutex.RLock()
+	defer fake.findSessionByIDMutex.RUnlock()
+	return len(fake.findSessionByIDArgsForCall)
+}
+
+func (fake *FakeRepo) FindSessionByIDCalls(stub func(string) (*session.Session, error)) {
+	fake.findSessionByIDMutex.Lock()
+	defer fake.findSessionByIDMutex.Unlock()
+	fake.FindSessionByIDStub = stub
+}
+
+func (fake *FakeRepo) FindSessionByIDArgsForCall(i int) string {
+	fake.findSessionByIDMutex.RLock()
+	defer fake.findSessionByIDMutex.RUnlock()
+	argsForCall := fake.findSessionByIDArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) FindSessionByIDReturns(result1 *session.Session, result2 error) {
+	fake.findSessionByIDMutex.Lock()
+	defer fake.findSessionByIDMutex.Unlock()
+	fake.FindSessionByIDStub = nil
+	fake.findSessionByIDReturns = struct {
+		result1 *session.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) FindSessionByIDReturnsOnCall(i int, result1 *session.Session, result2 error) {
+	fake.findSessionByIDMutex.Lock()
+	defer fake.findSessionByIDMutex.Unlock()
+	fake.FindSessionByIDStub = nil
+	if fake.findSessionByIDReturnsOnCall == nil {
+		fake.findSessionByIDReturnsOnCall = make(map[int]struct {
+			result1 *session.Session
+			result2 error
+		})
+	}
+	fake.findSessionByIDReturnsOnCall[i] = struct {
+		result1 *session.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) Sessions() ([]*session.Session, error) {
+	fake.sessionsMutex.Lock()
+	ret, specificReturn := fake.sessionsReturnsOnCall[len(fake.sessionsArgsForCall)]
+	fake.sessionsArgsForCall = append(fake.sessionsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Sessions", []interface{}{})
+	fake.sessionsMutex.Unlock()
+	if fake.SessionsStub != nil {
+		return fake.SessionsStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.sessionsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepo) SessionsCallCount() int {
+	fake.sessionsMutex.RLock()
+	defer fake.sessionsMutex.RUnlock()
+	return len(fake.sessionsArgsForCall)
+}
+
+func (fake *FakeRepo) SessionsCalls(stub func() ([]*session.Session, error)) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = stub
+}
+
+func (fake *FakeRepo) SessionsReturns(result1 []*session.Session, result2 error) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = nil
+	fake.sessionsReturns = struct {
+		result1 []*session.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) SessionsReturnsOnCall(i int, result1 []*session.Session, result2 error) {
+	fake.sessionsMutex.Lock()
+	defer fake.sessionsMutex.Unlock()
+	fake.SessionsStub = nil
+	if fake.sessionsReturnsOnCall == nil {
+		fake.sessionsReturnsOnCall = make(map[int]struct {
+			result1 []*session.Session
+			result2 error
+		})
+	}
+	fake.sessionsReturnsOnCall[i] = struct {
+		result1 []*session.Session
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createSessionMutex.RLock()
+	defer fake.createSessionMutex.RUnlock()
+	fake.deleteExpiredSessionsMutex.RLock()
+	defer fake.deleteExpiredSessionsMutex.RUnlock()
+	fake.deleteSessionMutex.RLock()
+	defer fake.deleteSessionMutex.RUnlock()
+	fake.findSessionByIDMutex.RLock()
+	defer fake.findSessionByIDMutex.RUnlock()
+	fake.sessionsMutex.RLock()
+	defer fake.sessionsMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRepo) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ session.Repo = new(FakeRepo)
@@ -0,0 +1,121 @@
+// Package sessiontest contains conformance tests that every task.Repo that is also a session.Repo
+// should pass.
+package sessiontest
+
+import (
+	"github.com/ankeesler/anwork/session"
+	taskpkg "github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// RunRepoTests will run a set of tests to verify that the provided repo is a valid session.Repo
+// implementation. The createRepoFunc must return a repo that reads and writes the same sessions
+// each time that it is called within a test.
+func RunRepoTests(createRepoFunc func() taskpkg.Repo) {
+	var (
+		repo                         session.Repo
+		sessionA, sessionB, sessionC *session.Session
+	)
+	BeforeEach(func() {
+		var ok bool
+		repo, ok = createRepoFunc().(session.Repo)
+		Expect(ok).To(BeTrue(), "repo is not a session.Repo")
+
+		sessionA = &session.Session{ID: "session-a", Subject: "user-a", IssuedAt: 100, Expiry: 200}
+		sessionB = &session.Session{ID: "session-b", Subject: "", IssuedAt: 150, Expiry: 250}
+		sessionC = &session.Session{ID: "session-c", Subject: "user-a", IssuedAt: 150, Expiry: 300}
+	})
+
+	Describe("CreateSession", func() {
+		It("stores the sessions, in the order in which they were issued", func() {
+			Expect(repo.CreateSession(sessionC)).To(Succeed())
+			Expect(repo.CreateSession(sessionA)).To(Succeed())
+			Expect(repo.CreateSession(sessionB)).To(Succeed())
+
+			Expect(createRepoFunc().(session.Repo).Sessions()).To(Equal(
+				[]*session.Session{sessionA, sessionB, sessionC},
+			))
+		})
+
+		It("stores a copy of the session", func() {
+			Expect(repo.CreateSession(sessionA)).To(Succeed())
+			sessionA.Subject = "user-b"
+
+			s, err := repo.FindSessionByID("session-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(s.Subject).To(Equal("user-a"))
+		})
+
+		Context("when there is already a session with the same ID", func() {
+			It("returns an error", func() {
+				Expect(repo.CreateSession(sessionA)).To(Succeed())
+				Expect(repo.CreateSession(&session.Session{ID: "session-a"})).NotTo(Succeed())
+
+				Expect(repo.Sessions()).To(Equal([]*session.Session{sessionA}))
+			})
+		})
+	})
+
+	Describe("Sessions", func() {
+		It("returns no sessions when there are none", func() {
+			Expect(repo.Sessions()).To(BeEmpty())
+		})
+	})
+
+	Describe("FindSessionByID", func() {
+		BeforeEach(func() {
+			Expect(repo.CreateSession(sessionA)).To(Succeed())
+			Expect(repo.CreateSession(sessionB)).To(Succeed())
+		})
+
+		It("finds the session", func() {
+			Expect(repo.FindSessionByID("session-b")).To(Equal(sessionB))
+		})
+
+		Context("when the session does not exist", func() {
+			It("returns nil, nil", func() {
+				s, err := repo.FindSessionByID("session-c")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(s).To(BeNil())
+			})
+		})
+	})
+
+	Describe("DeleteSession", func() {
+		BeforeEach(func() {
+			Expect(repo.CreateSession(sessionA)).To(Succeed())
+			Expect(repo.CreateSession(sessionB)).To(Succeed())
+		})
+
+		It("deletes the session", func() {
+			Expect(repo.DeleteSession(sessionA)).To(Succeed())
+			Expect(repo.Sessions()).To(Equal([]*session.Session{sessionB}))
+		})
+
+		Context("when the session does not exist", func() {
+			It("succeeds", func() {
+				Expect(repo.DeleteSession(&session.Session{ID: "session-c"})).To(Succeed())
+				Expect(repo.Sessions()).To(HaveLen(2))
+			})
+		})
+	})
+
+	Describe("DeleteExpiredSessions", func() {
+		BeforeEach(func() {
+			Expect(repo.CreateSession(sessionA)).To(Succeed())
+			Expect(repo.CreateSession(sessionB)).To(Succeed())
+			Expect(repo.CreateSession(sessionC)).To(Succeed())
+		})
+
+		It("deletes the sessions that have expired", func() {
+			Expect(repo.DeleteExpiredSessions(250)).To(Succeed())
+			Expect(repo.Sessions()).To(Equal([]*session.Session{sessionC}))
+		})
+
+		It("keeps every session when none have expired", func() {
+			Expect(repo.DeleteExpiredSessions(100)).To(Succeed())
+			Expect(repo.Sessions()).To(HaveLen(3))
+		})
+	})
+}
@@ -0,0 +1,197 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultContext is the name of the context that is used when no context is chosen.
+const DefaultContext = "default-context"
+
+//go:generate counterfeiter . Contexts
+
+// Contexts is an object that keeps a separate Repo for each context, e.g., for each project on
+// which someone is working.
+type Contexts interface {
+	// Repo returns the Repo for a context. The context is created when something is first
+	// created in the Repo, so the Repo of a context that does not exist is empty. Returns an
+	// error if the name of the context is invalid; see ValidateContextName.
+	Repo(name string) (Repo, error)
+
+	// Contexts returns the names of the contexts that exist, in alphabetical order.
+	Contexts() ([]string, error)
+	// CreateContext creates an empty context. Returns a *DuplicateContextError if the context
+	// already exists.
+	CreateContext(name string) error
+	// CopyContext creates a context with a copy of the Task's, Event's, and Dependency's of another
+	// context. Returns an *UnknownContextError if the context from does not exist, or a
+	// *DuplicateContextError if the context to already exists.
+	CopyContext(from, to string) error
+	// DeleteContext deletes a context and everything in it. Returns an *UnknownContextError if the
+	// context does not exist.
+	DeleteContext(name string) error
+}
+
+// UnknownContextError is returned from Contexts when a context does not exist.
+type UnknownContextError struct {
+	Name string
+}
+
+func (e *UnknownContextError) Error() string {
+	return fmt.Sprintf("unknown context '%s'", e.Name)
+}
+
+// DuplicateContextError is returned from Contexts when a context already exists.
+type DuplicateContextError struct {
+	Name string
+}
+
+func (e *DuplicateContextError) Error() string {
+	return fmt.Sprintf("context '%s' already exists", e.Name)
+}
+
+// ValidateContextName returns an error if a context cannot have a name. The name of a context must
+// not be empty, must not start with a '.', and must not contain a '/', a '\', or whitespace, so
+// that it can be used as the name of a file and as part of a URL path.
+func ValidateContextName(name string) error {
+	if name == "" ||
+		strings.HasPrefix(name, ".") ||
+		strings.ContainsAny(name, `/\`) ||
+		strings.IndexFunc(name, unicode.IsSpace) != -1 {
+		return fmt.Errorf("invalid context name '%s'", name)
+	}
+	return nil
+}
+
+// CopyRepo creates the Task's, Event's, and Dependency's of one Repo in another Repo, which
+// should be empty. Since the Repo to which they are copied assigns their IDs, the IDs with which
+// the Event's and Dependency's refer to Task's and Event's are changed to match.
+//
+// The Task's in the Snapshot of an EventTypeReset Event are copied as they are, since they only
+// refer to each other.
+func CopyRepo(from, to Repo) error {
+	tasks, err := from.Tasks()
+	if err != nil {
+		return err
+	}
+
+	taskIDs := make(map[int]int)
+	for _, task := range tasks {
+		taskCopy := *task
+		if err := to.CreateTask(&taskCopy); err != nil {
+			return err
+		}
+		taskIDs[task.ID] = taskCopy.ID
+	}
+
+	events, err := from.Events()
+	if err != nil {
+		return err
+	}
+
+	eventIDs := make(map[int]int)
+	for _, event := range events {
+		eventCopy := *event
+		if id, ok := taskIDs[event.TaskID]; ok {
+			eventCopy.TaskID = id
+		}
+		eventCopy.Cause = copyID(event.Cause, eventIDs)
+		eventCopy.Reverts = copyID(event.Reverts, eventIDs)
+		if restoredFrom, ok := event.RestoredFrom(); ok {
+			if id, ok := taskIDs[restoredFrom]; ok {
+				eventCopy.OldValue = strconv.Itoa(id)
+			}
+		}
+		if event.Snapshot != "" && (event.Type == EventTypeCreate || event.Type == EventTypeDelete) {
+			if eventCopy.Snapshot, err = copySnapshot(event.Snapshot, taskIDs); err != nil {
+				return err
+			}
+		}
+		if _, ok := event.DependsOnID(); ok {
+			if eventCopy.Snapshot, err = copyDependencySnapshot(event.Snapshot, taskIDs); err != nil {
+				return err
+			}
+		}
+
+		if err := to.CreateEvent(&eventCopy); err != nil {
+			return err
+		}
+		eventIDs[event.ID] = eventCopy.ID
+	}
+
+	dependencies, err := from.Dependencies()
+	if err != nil {
+		return err
+	}
+
+	for _, dependency := range dependencies {
+		dependencyCopy := *dependency
+		if id, ok := taskIDs[dependency.TaskID]; ok {
+			dependencyCopy.TaskID = id
+		}
+		if id, ok := taskIDs[dependency.DependsOnID]; ok {
+			dependencyCopy.DependsOnID = id
+		}
+		if err := to.CreateDependency(&dependencyCopy); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyID returns the copy of an optional ID, e.g., Event.Cause.
+func copyID(id *int, ids map[int]int) *int {
+	if id == nil {
+		return nil
+	}
+
+	copied := *id
+	if newID, ok := ids[*id]; ok {
+		copied = newID
+	}
+	return &copied
+}
+
+// copySnapshot returns a copy of the Snapshot of a Task with the ID of its copy.
+func copySnapshot(snapshot string, taskIDs map[int]int) (string, error) {
+	var task Task
+	if err := json.Unmarshal([]byte(snapshot), &task); err != nil {
+		return "", err
+	}
+
+	if id, ok := taskIDs[task.ID]; ok {
+		task.ID = id
+	}
+
+	data, err := json.Marshal(&task)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// copyDependencySnapshot returns a copy of the Snapshot of a dependency Event with the IDs of the
+// copies of its Task's.
+func copyDependencySnapshot(snapshot string, taskIDs map[int]int) (string, error) {
+	var dependency Dependency
+	if err := json.Unmarshal([]byte(snapshot), &dependency); err != nil {
+		return "", err
+	}
+
+	if id, ok := taskIDs[dependency.TaskID]; ok {
+		dependency.TaskID = id
+	}
+	if id, ok := taskIDs[dependency.DependsOnID]; ok {
+		dependency.DependsOnID = id
+	}
+
+	data, err := json.Marshal(&dependency)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,13 @@
+package eventsource_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestEventsource(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Eventsource Suite")
+}
@@ -0,0 +1,503 @@
+// Package eventsource contains a task.Repo implementation in which the task.Event's are the only
+// thing that is stored. The task.Task's and task.Dependency's are a task.Projection of the
+// task.Event's.
+//
+// The task.Event's are stored in a log, which is only ever appended to: deleting a task.Event adds a
+// record to the log that the task.Event was deleted. So that the task.Projection does not need to be
+// rebuilt from every task.Event each time the log is loaded, a snapshot of the task.Projection is
+// stored next to the log every so often.
+package eventsource
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/ankeesler/anwork/task"
+)
+
+// This is the number of records that are added to the log before the snapshot is updated.
+const snapshotInterval = 100
+
+// A record is a single entry in the log: either a task.Event was created, or one was deleted.
+type record struct {
+	Event   *task.Event `json:"event,omitempty"`
+	Deleted *int        `json:"deleted,omitempty"`
+}
+
+// A snapshot is the task.Projection of the first Records records in the log.
+type snapshot struct {
+	Records    int              `json:"records"`
+	Projection *task.Projection `json:"projection"`
+}
+
+type repo struct {
+	file         string
+	snapshotFile string
+
+	events      []*task.Event
+	nextEventID int
+	// This is the number of records in the log.
+	records int
+	// This is the largest task.Task ID that any task.Event in the log has ever referred to.
+	maxTaskID int
+
+	// The persisted task.Projection is the one that the task.Event's in the log describe. The other
+	// task.Projection also includes the changes that have been made since the task.Event's that
+	// record them were created.
+	persisted, projection *task.Projection
+	// This is whether the task.Projection's need to be rebuilt, e.g., because a task.Event was
+	// deleted.
+	stale bool
+	// This is the number of records in the log when the snapshot was last updated.
+	snapshotRecords int
+
+	loaded bool
+}
+
+// New returns a task.Repo that stores task.Event's in a log in the provided file, and snapshots of
+// the task.Task's and task.Dependency's in another file next to it.
+//
+// Creating, updating, or deleting a task.Task or task.Dependency only changes what this task.Repo
+// returns until it is loaded again. The change is stored by creating the task.Event that records
+// it. A task.Event that refers to a task.Task that does not exist cannot be created.
+//
+// This task.Repo is NOT thread-safe.
+func New(file string) task.Repo {
+	return &repo{file: file, snapshotFile: file + ".snapshot", maxTaskID: -1}
+}
+
+func (r *repo) CreateTask(t *task.Task) error {
+	if err := r.ensureProjected(); err != nil {
+		return err
+	}
+
+	t.ID = r.projection.NextTaskID
+	t.Version = 1
+	r.projection.NextTaskID++
+
+	taskCopy := copyTask(t)
+	r.projection.Tasks = append(r.projection.Tasks, taskCopy)
+
+	return nil
+}
+
+func (r *repo) Tasks() ([]*task.Task, error) {
+	if err := r.ensureProjected(); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*task.Task, len(r.projection.Tasks))
+	for i, t := range r.projection.Tasks {
+		tasks[i] = copyTask(t)
+	}
+	return tasks, nil
+}
+
+func (r *repo) FindTaskByID(id int) (*task.Task, error) {
+	if err := r.ensureProjected(); err != nil {
+		return nil, err
+	}
+
+	if t := r.projection.FindTaskByID(id); t != nil {
+		return copyTask(t), nil
+	}
+	return nil, nil
+}
+
+func (r *repo) FindTaskByName(name string) (*task.Task, error) {
+	if err := r.ensureProjected(); err != nil {
+		return nil, err
+	}
+
+	if t := r.projection.FindTaskByName(name); t != nil {
+		return copyTask(t), nil
+	}
+	return nil, nil
+}
+
+func (r *repo) UpdateTask(t *task.Task) error {
+	if err := r.ensureProjected(); err != nil {
+		return err
+	}
+
+	existing := r.projection.FindTaskByID(t.ID)
+	if existing == nil {
+		return fmt.Errorf("unknown task with name '%s' and id %d", t.Name, t.ID)
+	}
+
+	t.Version = existing.Version + 1
+	*existing = *copyTask(t)
+
+	return nil
+}
+
+func (r *repo) DeleteTask(t *task.Task) error {
+	if err := r.ensureProjected(); err != nil {
+		return err
+	}
+
+	for i, existing := range r.projection.Tasks {
+		if existing.ID == t.ID {
+			r.projection.Tasks = append(r.projection.Tasks[:i], r.projection.Tasks[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (r *repo) CreateEvent(event *task.Event) error {
+	if err := r.ensureProjected(); err != nil {
+		return err
+	}
+
+	event.ID = r.nextEventID
+	if err := r.persisted.Apply(event); err != nil {
+		return fmt.Errorf("invalid event '%s': %s", event.Title, err.Error())
+	}
+
+	if err := r.append(&record{Event: event}); err != nil {
+		r.stale = true
+		return err
+	}
+	r.nextEventID++
+	r.events = append(r.events, event)
+	r.noteTaskID(event.TaskID)
+
+	// The change that the task.Event records has usually already been made, but if the
+	// task.Projection's no longer agree, start over from the one that is persisted.
+	if err := r.projection.Apply(event); err != nil {
+		r.projection = copyProjection(r.persisted)
+	}
+
+	if r.records-r.snapshotRecords >= snapshotInterval {
+		return r.writeSnapshot()
+	}
+	return nil
+}
+
+func (r *repo) Events() ([]*task.Event, error) {
+	if err := r.ensureLoaded(); err != nil {
+		return nil, err
+	}
+
+	return r.events, nil
+}
+
+func (r *repo) FindEventByID(id int) (*task.Event, error) {
+	if err := r.ensureLoaded(); err != nil {
+		return nil, err
+	}
+
+	if index := r.findEvent(id); index != -1 {
+		return r.events[index], nil
+	}
+	return nil, nil
+}
+
+func (r *repo) DeleteEvent(event *task.Event) error {
+	if err := r.ensureLoaded(); err != nil {
+		return err
+	}
+
+	index := r.findEvent(event.ID)
+	if index == -1 {
+		return nil
+	}
+
+	id := event.ID
+	if err := r.append(&record{Deleted: &id}); err != nil {
+		return err
+	}
+	r.events = append(r.events[:index], r.events[index+1:]...)
+
+	// The rest of the task.Event's may describe a different task.Projection without this one, so
+	// it is rebuilt the next time that it is needed.
+	r.stale = true
+
+	return nil
+}
+
+func (r *repo) CreateDependency(dependency *task.Dependency) error {
+	if err := r.ensureProjected(); err != nil {
+		return err
+	}
+
+	dependency.ID = r.projection.NextDependencyID
+	r.projection.NextDependencyID++
+
+	dependencyCopy := *dependency
+	r.projection.Dependencies = append(r.projection.Dependencies, &dependencyCopy)
+
+	return nil
+}
+
+func (r *repo) Dependencies() ([]*task.Dependency, error) {
+	if err := r.ensureProjected(); err != nil {
+		return nil, err
+	}
+
+	dependencies := make([]*task.Dependency, len(r.projection.Dependencies))
+	for i, dependency := range r.projection.Dependencies {
+		dependencyCopy := *dependency
+		dependencies[i] = &dependencyCopy
+	}
+	return dependencies, nil
+}
+
+func (r *repo) FindDependencyByID(id int) (*task.Dependency, error) {
+	if err := r.ensureProjected(); err != nil {
+		return nil, err
+	}
+
+	if index := r.findDependency(id); index != -1 {
+		dependencyCopy := *r.projection.Dependencies[index]
+		return &dependencyCopy, nil
+	}
+	return nil, nil
+}
+
+func (r *repo) DeleteDependency(dependency *task.Dependency) error {
+	if err := r.ensureProjected(); err != nil {
+		return err
+	}
+
+	if index := r.findDependency(dependency.ID); index != -1 {
+		dependencies := r.projection.Dependencies
+		r.projection.Dependencies = append(dependencies[:index], dependencies[index+1:]...)
+	}
+
+	return nil
+}
+
+func (r *repo) findEvent(id int) int {
+	for i, e := range r.events {
+		if e.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *repo) findDependency(id int) int {
+	for i, d := range r.projection.Dependencies {
+		if d.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// noteTaskID remembers the task.Task ID that a task.Event refers to, so that the ID is not given to
+// another task.Task after the projection is rebuilt without that task.Event.
+func (r *repo) noteTaskID(id int) {
+	if id > r.maxTaskID {
+		r.maxTaskID = id
+	}
+}
+
+// append adds a record to the end of the log.
+func (r *repo) append(rec *record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(r.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	r.records++
+
+	return f.Close()
+}
+
+func (r *repo) writeSnapshot() error {
+	data, err := json.Marshal(&snapshot{Records: r.records, Projection: r.persisted})
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(r.snapshotFile, data, 0600); err != nil {
+		return err
+	}
+	r.snapshotRecords = r.records
+
+	return nil
+}
+
+// ensureProjected loads the log and rebuilds the projection if it is stale.
+func (r *repo) ensureProjected() error {
+	if err := r.ensureLoaded(); err != nil {
+		return err
+	}
+
+	if r.stale {
+		if err := r.project(r.events); err != nil {
+			return err
+		}
+		r.stale = false
+		return r.writeSnapshot()
+	}
+
+	return nil
+}
+
+// project rebuilds the task.Projection's from the provided task.Event's.
+func (r *repo) project(events []*task.Event) error {
+	persisted, errs := task.Replay(events)
+	if len(errs) > 0 {
+		return fmt.Errorf("cannot replay events: %s", errs[0].Error())
+	}
+
+	r.usePersisted(persisted)
+
+	return nil
+}
+
+// usePersisted sets the persisted task.Projection, and makes the other task.Projection agree with
+// it.
+func (r *repo) usePersisted(persisted *task.Projection) {
+	if persisted.NextTaskID <= r.maxTaskID {
+		persisted.NextTaskID = r.maxTaskID + 1
+	}
+	r.persisted = persisted
+	r.projection = copyProjection(persisted)
+}
+
+func (r *repo) ensureLoaded() error {
+	if r.loaded {
+		return nil
+	}
+
+	records, err := r.readLog()
+	if err != nil {
+		return err
+	}
+
+	snap, err := r.readSnapshot()
+	if err != nil {
+		return err
+	}
+	if snap != nil && (snap.Projection == nil || snap.Records > len(records)) {
+		// The snapshot does not belong to this log.
+		snap = nil
+	}
+
+	// The snapshot can only be used if none of the task.Event's that it includes have been deleted
+	// since it was taken.
+	replayFrom := 0
+	if snap != nil {
+		replayFrom = snap.Records
+	}
+	for i, rec := range records {
+		if rec.Event != nil {
+			r.events = append(r.events, rec.Event)
+			r.nextEventID = rec.Event.ID + 1
+			r.noteTaskID(rec.Event.TaskID)
+		} else if rec.Deleted != nil {
+			if index := r.findEvent(*rec.Deleted); index != -1 {
+				r.events = append(r.events[:index], r.events[index+1:]...)
+			}
+			if i >= replayFrom {
+				snap = nil
+			}
+		}
+	}
+	r.records = len(records)
+
+	if snap != nil {
+		for _, rec := range records[snap.Records:] {
+			if err := snap.Projection.Apply(rec.Event); err != nil {
+				return fmt.Errorf("cannot replay event %d: %s", rec.Event.ID, err.Error())
+			}
+		}
+		r.usePersisted(snap.Projection)
+		r.snapshotRecords = snap.Records
+	} else {
+		if err := r.project(r.events); err != nil {
+			return err
+		}
+		if len(records) > 0 {
+			if err := r.writeSnapshot(); err != nil {
+				return err
+			}
+		}
+	}
+
+	r.loaded = true
+
+	return nil
+}
+
+func (r *repo) readLog() ([]*record, error) {
+	f, err := os.Open(r.file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	records := []*record{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 64*1024*1024)
+	for scanner.Scan() {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("invalid record %d in log: %s", len(records), err.Error())
+		}
+		records = append(records, &rec)
+	}
+
+	return records, scanner.Err()
+}
+
+func (r *repo) readSnapshot() (*snapshot, error) {
+	data, err := ioutil.ReadFile(r.snapshotFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		// The snapshot is only an optimization, so rebuild it from the log.
+		return nil, nil
+	}
+	return &snap, nil
+}
+
+func copyProjection(p *task.Projection) *task.Projection {
+	projectionCopy := *p
+	projectionCopy.Tasks = make([]*task.Task, len(p.Tasks))
+	for i, t := range p.Tasks {
+		projectionCopy.Tasks[i] = copyTask(t)
+	}
+	projectionCopy.Dependencies = make([]*task.Dependency, len(p.Dependencies))
+	for i, dependency := range p.Dependencies {
+		dependencyCopy := *dependency
+		projectionCopy.Dependencies[i] = &dependencyCopy
+	}
+	return &projectionCopy
+}
+
+func copyTask(t *task.Task) *task.Task {
+	taskCopy := *t
+	if t.Tags != nil {
+		taskCopy.Tags = append([]string{}, t.Tags...)
+	}
+	if t.SharedWith != nil {
+		taskCopy.SharedWith = append([]string{}, t.SharedWith...)
+	}
+	return &taskCopy
+}
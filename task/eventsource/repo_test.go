@@ -0,0 +1,218 @@
+package eventsource_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/ankeesler/anwork/manager"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/eventsource"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Event-Sourced Task Repo", func() {
+	var (
+		dir, file string
+		repo      task.Repo
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "eventsource-task-repo-test")
+		Expect(err).NotTo(HaveOccurred())
+		file = filepath.Join(dir, "test-context")
+
+		repo = eventsource.New(file)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	createTask := func(repo task.Repo, name string) *task.Task {
+		t := &task.Task{Name: name, Priority: 10, State: task.StateReady}
+		Expect(repo.CreateTask(t)).To(Succeed())
+		Expect(repo.CreateEvent(&task.Event{Type: task.EventTypeCreate, TaskID: t.ID, NewValue: name})).To(Succeed())
+		return t
+	}
+
+	Context("when tasks are created with their events", func() {
+		var taskA, taskB *task.Task
+
+		BeforeEach(func() {
+			taskA = createTask(repo, "task-a")
+			taskB = createTask(repo, "task-b")
+		})
+
+		It("returns them from another repo", func() {
+			tasks, err := eventsource.New(file).Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(Equal([]*task.Task{taskA, taskB}))
+		})
+
+		It("returns copies of the tasks", func() {
+			t, err := repo.FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			t.Name = "task-c"
+
+			t, err = repo.FindTaskByID(taskA.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t.Name).To(Equal("task-a"))
+		})
+
+		Context("when a task is updated without an event", func() {
+			BeforeEach(func() {
+				taskA.State = task.StateFinished
+				Expect(repo.UpdateTask(taskA)).To(Succeed())
+			})
+
+			It("returns the update from this repo", func() {
+				t, err := repo.FindTaskByID(taskA.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(t.State).To(BeEquivalentTo(task.StateFinished))
+			})
+
+			It("does not return the update from another repo", func() {
+				t, err := eventsource.New(file).FindTaskByID(taskA.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(t.State).To(BeEquivalentTo(task.StateReady))
+			})
+		})
+
+		Context("when a task is updated with an event", func() {
+			BeforeEach(func() {
+				taskA.State = task.StateFinished
+				Expect(repo.UpdateTask(taskA)).To(Succeed())
+				Expect(repo.CreateEvent(&task.Event{
+					Type:     task.EventTypeSetState,
+					TaskID:   taskA.ID,
+					OldValue: "Ready",
+					NewValue: "Finished",
+				})).To(Succeed())
+			})
+
+			It("returns the update from another repo", func() {
+				t, err := eventsource.New(file).FindTaskByID(taskA.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(t.State).To(BeEquivalentTo(task.StateFinished))
+			})
+		})
+
+		Context("when the event that created a task is deleted", func() {
+			BeforeEach(func() {
+				events, err := repo.Events()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(repo.DeleteEvent(events[1])).To(Succeed())
+			})
+
+			It("rebuilds the tasks without it", func() {
+				for _, r := range []task.Repo{repo, eventsource.New(file)} {
+					tasks, err := r.Tasks()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(tasks).To(Equal([]*task.Task{taskA}))
+				}
+			})
+
+			It("does not give its ID to a new task", func() {
+				taskC := createTask(eventsource.New(file), "task-c")
+				Expect(taskC.ID).To(Equal(taskB.ID + 1))
+			})
+		})
+	})
+
+	Context("when an event refers to a task that does not exist", func() {
+		It("fails to create it", func() {
+			err := repo.CreateEvent(&task.Event{Title: "event-a", Type: task.EventTypeNote, TaskID: 5})
+			Expect(err).To(MatchError("invalid event 'event-a': unknown task with ID 5"))
+
+			events, err := eventsource.New(file).Events()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(BeEmpty())
+		})
+	})
+
+	Context("when there are enough events to take a snapshot", func() {
+		BeforeEach(func() {
+			for i := 0; i < 100; i++ {
+				createTask(repo, fmt.Sprintf("task-%d", i))
+			}
+		})
+
+		It("loads the tasks from the snapshot", func() {
+			data, err := ioutil.ReadFile(file + ".snapshot")
+			Expect(err).NotTo(HaveOccurred())
+
+			var snapshot map[string]interface{}
+			Expect(json.Unmarshal(data, &snapshot)).To(Succeed())
+			Expect(snapshot["records"]).To(BeEquivalentTo(100))
+
+			// The snapshot is trusted, so changing it changes the tasks.
+			projection := snapshot["projection"].(map[string]interface{})
+			projection["tasks"] = projection["tasks"].([]interface{})[:1]
+			data, err = json.Marshal(snapshot)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ioutil.WriteFile(file+".snapshot", data, 0600)).To(Succeed())
+
+			createTask(repo, "task-100")
+
+			tasks, err := eventsource.New(file).Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(2))
+			Expect(tasks[0].Name).To(Equal("task-0"))
+			Expect(tasks[1].Name).To(Equal("task-100"))
+		})
+
+		Context("when the snapshot is invalid", func() {
+			BeforeEach(func() {
+				Expect(ioutil.WriteFile(file+".snapshot", []byte("{"), 0600)).To(Succeed())
+			})
+
+			It("rebuilds the tasks from the events", func() {
+				tasks, err := eventsource.New(file).Tasks()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tasks).To(HaveLen(100))
+			})
+		})
+	})
+
+	Context("when it is used by a manager", func() {
+		It("keeps the tasks in sync with the journal", func() {
+			m := manager.New(repo, fakeclock.NewFakeClock(time.Now()))
+			Expect(m.Create("task-a")).To(Succeed())
+			Expect(m.Create("task-b")).To(Succeed())
+			Expect(m.Create("task-c")).To(Succeed())
+			Expect(m.AddDependency("task-a", "task-b")).To(Succeed())
+			Expect(m.SetState("task-b", task.StateFinished)).To(Succeed())
+			Expect(m.AddTag("task-a", "tag-a")).To(Succeed())
+			Expect(m.Rename("task-c", "task-d")).To(Succeed())
+			Expect(m.Delete("task-b")).To(Succeed())
+			Expect(m.Undo(1)).To(Succeed())
+			Expect(m.Reset()).To(Succeed())
+			Expect(m.Undo(1)).To(Succeed())
+
+			expected, err := m.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expected).To(HaveLen(3))
+
+			m = manager.New(eventsource.New(file), fakeclock.NewFakeClock(time.Now()))
+			tasks, err := m.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(Equal(expected))
+
+			dependencies, err := m.Dependencies("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dependencies).To(HaveLen(1))
+			Expect(dependencies[0].Name).To(Equal("task-b"))
+
+			problems, err := m.Replay()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(problems).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,197 @@
+package fs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ankeesler/anwork/task"
+)
+
+type contexts struct {
+	dir string
+
+	// These are the repos that have been returned from Repo, keyed by context, so that there is
+	// only one repo for each file.
+	repos map[string]*repo
+	lock  sync.Mutex
+}
+
+// NewContexts returns a task.Contexts that stores each context in a file, named after the context,
+// in a directory. The file of a context is the same file that a task.Repo returned from New uses.
+func NewContexts(dir string) task.Contexts {
+	return &contexts{dir: dir, repos: make(map[string]*repo)}
+}
+
+func (c *contexts) Repo(name string) (task.Repo, error) {
+	if err := task.ValidateContextName(name); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.repo(name), nil
+}
+
+func (c *contexts) Contexts() ([]string, error) {
+	infos, err := ioutil.ReadDir(c.dir)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, info := range infos {
+		if info.Mode().IsRegular() && c.isContext(info.Name()) {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (c *contexts) CreateContext(name string) error {
+	return c.create(name, nil)
+}
+
+func (c *contexts) CopyContext(from, to string) error {
+	if err := task.ValidateContextName(from); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	fromRepo := c.repo(from)
+	c.lock.Unlock()
+
+	fromRepo.lock.Lock()
+	defer fromRepo.lock.Unlock()
+
+	if !c.exists(from) {
+		return &task.UnknownContextError{Name: from}
+	} else if from == to {
+		return &task.DuplicateContextError{Name: to}
+	}
+	if err := fromRepo.ensureLoaded(); err != nil {
+		return err
+	}
+
+	// Copy the contents so that the repos do not share any task.Task's.
+	data, err := json.Marshal(&fromRepo.contents)
+	if err != nil {
+		return err
+	}
+
+	return c.create(to, data)
+}
+
+func (c *contexts) DeleteContext(name string) error {
+	if err := task.ValidateContextName(name); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	r := c.repo(name)
+	c.lock.Unlock()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !c.exists(name) {
+		return &task.UnknownContextError{Name: name}
+	}
+
+	unlock, err := lockFile(r.file + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := os.Remove(r.file); err != nil {
+		return err
+	}
+	r.loaded = false
+
+	return nil
+}
+
+// create creates the file of a context with the JSON encoding of some contents, or with no
+// contents if the data is nil.
+func (c *contexts) create(name string, data []byte) error {
+	if err := task.ValidateContextName(name); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	r := c.repo(name)
+	c.lock.Unlock()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if c.exists(name) {
+		return &task.DuplicateContextError{Name: name}
+	}
+
+	if err := r.ensureLoaded(); err != nil {
+		return err
+	}
+	if data != nil {
+		if err := json.Unmarshal(data, &r.contents); err != nil {
+			return err
+		}
+	}
+
+	return r.commit()
+}
+
+// repo returns the repo for a context. The lock must be held.
+func (c *contexts) repo(name string) *repo {
+	r, ok := c.repos[name]
+	if !ok {
+		r = &repo{file: filepath.Join(c.dir, name)}
+		c.repos[name] = r
+	}
+	return r
+}
+
+func (c *contexts) exists(name string) bool {
+	_, err := os.Stat(filepath.Join(c.dir, name))
+	return err == nil
+}
+
+// isContext returns whether a file in the directory is the file of a context, as opposed to,
+// e.g., a lock file, a backup, or a token cache. The file of a context holds a JSON object with
+// tasks or a version.
+func (c *contexts) isContext(name string) bool {
+	if task.ValidateContextName(name) != nil {
+		return false
+	}
+
+	// These files are kept next to the file of a context, e.g., "default-context.v1.backup".
+	if strings.HasSuffix(name, ".lock") ||
+		strings.HasSuffix(name, ".backup") ||
+		strings.Contains(name, ".tmp") {
+		return false
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(c.dir, name))
+	if err != nil {
+		return false
+	}
+
+	var layout map[string]json.RawMessage
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return false
+	}
+
+	_, hasTasks := layout["tasks"]
+	_, hasVersion := layout["version"]
+	return hasTasks || hasVersion
+}
@@ -37,3 +37,31 @@ type duplicateEventError struct {
 func (dee *duplicateEventError) Error() string {
 	return fmt.Sprintf("duplicate event with title '%s' and date %d", dee.title, dee.date)
 }
+
+type modifiedFileError struct {
+	file string
+}
+
+func (mfe *modifiedFileError) Error() string {
+	return fmt.Sprintf("file '%s' was modified by someone else since it was loaded", mfe.file)
+}
+
+type newerFormatError struct {
+	version int
+}
+
+func (nfe *newerFormatError) Error() string {
+	return fmt.Sprintf(
+		"file has format version %d, which is newer than the latest version %d",
+		nfe.version,
+		formatVersion,
+	)
+}
+
+type unknownFormatError struct {
+	version int
+}
+
+func (ufe *unknownFormatError) Error() string {
+	return fmt.Sprintf("unknown format version %d", ufe.version)
+}
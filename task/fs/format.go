@@ -0,0 +1,138 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ankeesler/anwork/task"
+)
+
+// formatVersion is the version of the layout of the file of a repo. It must be incremented, and
+// an upgrader must be added for the previous version, whenever the layout changes. Files that
+// were written before the layout had a version are version 1.
+const formatVersion = 2
+
+// An upgrader converts the layout of a file (its top-level JSON fields) from one version to the
+// next. It returns a description of each change that it made.
+type upgrader func(layout map[string]json.RawMessage) ([]string, error)
+
+// upgraders are keyed by the version of the layout that they upgrade.
+var upgraders = map[int]upgrader{
+	1: upgradeStructuredEvents,
+}
+
+// upgradeStructuredEvents gives the events that were written before task.Event's carried
+// structured fields those fields by parsing their titles.
+func upgradeStructuredEvents(layout map[string]json.RawMessage) ([]string, error) {
+	data, ok := layout["events"]
+	if !ok {
+		return nil, nil
+	}
+
+	var events []*task.Event
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, err
+	}
+
+	upgraded := 0
+	for _, event := range events {
+		if task.UpgradeEvent(event) {
+			upgraded++
+		}
+	}
+	if upgraded == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+	layout["events"] = data
+
+	return []string{fmt.Sprintf("Added structured fields to %d events", upgraded)}, nil
+}
+
+// upgrade converts the data of a file into the latest layout. It returns the upgraded data, the
+// version of the layout of the provided data, and a description of each change that was made.
+func upgrade(data []byte) ([]byte, int, []string, error) {
+	var layout map[string]json.RawMessage
+	if err := json.Unmarshal(data, &layout); err != nil {
+		return nil, 0, nil, err
+	}
+
+	version := 1
+	if data, ok := layout["version"]; ok {
+		if err := json.Unmarshal(data, &version); err != nil {
+			return nil, 0, nil, err
+		}
+	}
+
+	if version > formatVersion {
+		return nil, 0, nil, &newerFormatError{version: version}
+	} else if version == formatVersion {
+		return data, version, nil, nil
+	}
+
+	changes := []string{}
+	for v := version; v < formatVersion; v++ {
+		u, ok := upgraders[v]
+		if !ok {
+			return nil, 0, nil, &unknownFormatError{version: v}
+		}
+
+		upgradeChanges, err := u(layout)
+		if err != nil {
+			return nil, 0, nil, fmt.Errorf("cannot upgrade from format version %d: %s", v, err.Error())
+		}
+
+		changes = append(changes, fmt.Sprintf("Upgraded from format version %d to %d", v, v+1))
+		changes = append(changes, upgradeChanges...)
+	}
+
+	layout["version"] = json.RawMessage(fmt.Sprintf("%d", formatVersion))
+	upgraded, err := json.Marshal(layout)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
+	return upgraded, version, changes, nil
+}
+
+// backUp writes the data of a file, as it was before it was upgraded from a version, next to the
+// file. It returns the name of the backup. An existing backup is never replaced, since it holds
+// the oldest data.
+func backUp(file string, data []byte, version int) (string, error) {
+	backup := fmt.Sprintf("%s.v%d.backup", file, version)
+	f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return backup, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return "", err
+	}
+	return backup, f.Close()
+}
+
+// Migrate upgrades the file of a task.Repo returned from New to the latest layout, and returns a
+// description of each change that was made. The original file is backed up next to it, e.g.,
+// "some-context.v1.backup" for a file with the layout from version 1.
+//
+// A task.Repo upgrades its file the same way the first time that it is used, so Migrate only
+// needs to be called to find out what changed.
+func Migrate(file string) ([]string, error) {
+	r := &repo{file: file}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.load()
+}
@@ -1,12 +1,15 @@
 package fs_test
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/ankeesler/anwork/task"
 	"github.com/ankeesler/anwork/task/fs"
+	"github.com/ankeesler/anwork/task/repotest"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -26,10 +29,290 @@ var _ = Describe("FS Task Repo", func() {
 		Expect(os.RemoveAll(dir)).To(Succeed())
 	})
 
-	task.RunRepoTests(func() task.Repo {
+	repotest.RunRepoTests(func() task.Repo {
 		return fs.New(file)
 	})
 
+	Describe("IDs", func() {
+		repotest.RunIDTests(func() task.Repo {
+			return fs.New(file)
+		})
+	})
+
+	Describe("WithTx", func() {
+		repotest.RunTransactorTests(func() task.Repo {
+			return fs.New(file)
+		})
+	})
+
+	Describe("Contexts", func() {
+		repotest.RunContextsTests(func() task.Contexts {
+			return fs.NewContexts(dir)
+		})
+
+		It("only lists the files of contexts", func() {
+			contexts := fs.NewContexts(dir)
+			Expect(contexts.CreateContext("context-a")).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "token-cache"), []byte("some-token"), 0600)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "context-a.v1.backup"), []byte(`{"tasks":[]}`), 0600)).To(Succeed())
+
+			names, err := contexts.Contexts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(Equal([]string{"context-a"}))
+		})
+	})
+
+	Context("when the file contains dependencies with camel-cased keys", func() {
+		BeforeEach(func() {
+			data := `{"tasks":[{"name":"task-a","id":0,"state":"Ready"},{"name":"task-b","id":1,"state":"Blocked"}],"NextTaskID":2,"events":[],"NextEventID":0,"dependencies":[{"id":0,"taskId":1,"dependsOnId":0}],"NextDependencyID":1}`
+			Expect(ioutil.WriteFile(file, []byte(data), 0600)).To(Succeed())
+		})
+
+		It("reads them, and writes them with lowercase keys", func() {
+			repo := fs.New(file)
+			Expect(repo.Dependencies()).To(Equal([]*task.Dependency{
+				&task.Dependency{ID: 0, TaskID: 1, DependsOnID: 0},
+			}))
+
+			Expect(repo.CreateDependency(&task.Dependency{TaskID: 0, DependsOnID: 1})).To(Succeed())
+			data, err := ioutil.ReadFile(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring(`{"id":0,"taskid":1,"dependsonid":0}`))
+		})
+	})
+
+	Context("when the file contains events without structured fields", func() {
+		BeforeEach(func() {
+			data := `{"tasks":[{"name":"task-a","id":0,"startDate":1548087198,"priority":10,"state":"Finished"}],"NextTaskID":1,"events":[{"ID":0,"title":"Created task 'task-a'","date":1548087198,"type":0,"taskid":0},{"ID":1,"title":"Set state on task 'task-a' from Ready to Finished","date":1548087198,"type":2,"taskid":0}],"NextEventID":2}`
+			Expect(ioutil.WriteFile(file, []byte(data), 0600)).To(Succeed())
+		})
+
+		It("upgrades the events by parsing their titles", func() {
+			events, err := fs.New(file).Events()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(Equal([]*task.Event{
+				&task.Event{
+					ID:       0,
+					Title:    "Created task 'task-a'",
+					Date:     1548087198,
+					Type:     task.EventTypeCreate,
+					TaskID:   0,
+					NewValue: "task-a",
+				},
+				&task.Event{
+					ID:       1,
+					Title:    "Set state on task 'task-a' from Ready to Finished",
+					Date:     1548087198,
+					Type:     task.EventTypeSetState,
+					TaskID:   0,
+					OldValue: "Ready",
+					NewValue: "Finished",
+				},
+			}))
+		})
+
+		It("saves the upgraded events", func() {
+			_, err := fs.New(file).Events()
+			Expect(err).NotTo(HaveOccurred())
+
+			data, err := ioutil.ReadFile(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(ContainSubstring(`"oldValue":"Ready","newValue":"Finished"`))
+			Expect(string(data)).To(ContainSubstring(`"version":2`))
+		})
+
+		It("backs up the original file", func() {
+			original, err := ioutil.ReadFile(file)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = fs.New(file).Events()
+			Expect(err).NotTo(HaveOccurred())
+
+			backup, err := ioutil.ReadFile(file + ".v1.backup")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(backup).To(Equal(original))
+		})
+
+		It("describes the changes when it is migrated", func() {
+			changes, err := fs.Migrate(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(Equal([]string{
+				"Upgraded from format version 1 to 2",
+				"Added structured fields to 2 events",
+				fmt.Sprintf("Backed up the original file to '%s.v1.backup'", file),
+			}))
+
+			changes, err = fs.Migrate(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changes).To(BeEmpty())
+		})
+	})
+
+	Context("when the file has a newer format version", func() {
+		BeforeEach(func() {
+			data := `{"version":100,"tasks":[]}`
+			Expect(ioutil.WriteFile(file, []byte(data), 0600)).To(Succeed())
+		})
+
+		It("fails to load it", func() {
+			_, err := fs.New(file).Tasks()
+			Expect(err).To(MatchError("file has format version 100, which is newer than the latest version 2"))
+		})
+	})
+
+	Context("when a task is created", func() {
+		BeforeEach(func() {
+			Expect(fs.New(file).CreateTask(&task.Task{Name: "task-a"})).To(Succeed())
+		})
+
+		It("does not leave any temporary files behind", func() {
+			infos, err := ioutil.ReadDir(dir)
+			Expect(err).NotTo(HaveOccurred())
+
+			names := []string{}
+			for _, info := range infos {
+				names = append(names, info.Name())
+			}
+			Expect(names).To(ConsistOf("test-context", "test-context.lock"))
+		})
+
+		It("only lets the owner read and write the file", func() {
+			info, err := os.Stat(file)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		})
+	})
+
+	Context("when the file is modified after it is loaded", func() {
+		var repo task.Repo
+
+		BeforeEach(func() {
+			repo = fs.New(file)
+			Expect(repo.CreateTask(&task.Task{Name: "task-a"})).To(Succeed())
+
+			Expect(fs.New(file).CreateTask(&task.Task{Name: "task-b"})).To(Succeed())
+		})
+
+		It("fails to write the file", func() {
+			err := repo.CreateTask(&task.Task{Name: "task-c"})
+			Expect(err).To(MatchError(fmt.Sprintf("file '%s' was modified by someone else since it was loaded", file)))
+
+			tasks, err := fs.New(file).Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(2))
+			Expect(tasks[0].Name).To(Equal("task-a"))
+			Expect(tasks[1].Name).To(Equal("task-b"))
+		})
+
+		It("loads the file again after failing to write it", func() {
+			Expect(repo.CreateTask(&task.Task{Name: "task-c"})).NotTo(Succeed())
+			Expect(repo.CreateTask(&task.Task{Name: "task-c"})).To(Succeed())
+
+			tasks, err := fs.New(file).Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(3))
+			Expect(tasks[2].Name).To(Equal("task-c"))
+			Expect(tasks[2].ID).To(Equal(2))
+		})
+	})
+
+	Context("when a transaction cannot be committed", func() {
+		var repo task.Repo
+
+		BeforeEach(func() {
+			repo = fs.New(file)
+			Expect(repo.CreateTask(&task.Task{Name: "task-a"})).To(Succeed())
+
+			// The file cannot be locked, so it cannot be written.
+			Expect(os.Remove(file + ".lock")).To(Succeed())
+			Expect(os.Mkdir(file+".lock", 0700)).To(Succeed())
+		})
+
+		It("keeps none of the changes that were made in the transaction", func() {
+			err := repo.(task.Transactor).WithTx(func(tx task.Repo) error {
+				if err := tx.CreateTask(&task.Task{Name: "task-b"}); err != nil {
+					return err
+				}
+				return tx.CreateEvent(&task.Event{Title: "task-b created", TaskID: 1})
+			})
+			Expect(err).To(HaveOccurred())
+
+			tasks, err := repo.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(1))
+			Expect(tasks[0].Name).To(Equal("task-a"))
+			Expect(repo.Events()).To(BeEmpty())
+
+			Expect(os.Remove(file + ".lock")).To(Succeed())
+			Expect(repo.CreateTask(&task.Task{Name: "task-c"})).To(Succeed())
+			tasks, err = fs.New(file).Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(2))
+			Expect(tasks[1].Name).To(Equal("task-c"))
+			Expect(tasks[1].ID).To(Equal(1))
+		})
+	})
+
+	Context("when the file is deleted after it is loaded", func() {
+		It("fails to write the file", func() {
+			repo := fs.New(file)
+			Expect(repo.CreateTask(&task.Task{Name: "task-a"})).To(Succeed())
+			Expect(os.Remove(file)).To(Succeed())
+
+			err := repo.CreateTask(&task.Task{Name: "task-b"})
+			Expect(err).To(MatchError(fmt.Sprintf("file '%s' was modified by someone else since it was loaded", file)))
+		})
+	})
+
+	Context("when it is used concurrently", func() {
+		It("does not lose any tasks created with the same repo", func() {
+			repo := fs.New(file)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(repo.CreateTask(&task.Task{Name: fmt.Sprintf("task-%d", i)})).To(Succeed())
+				}(i)
+			}
+			wg.Wait()
+
+			tasks, err := fs.New(file).Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(10))
+		})
+
+		It("does not lose any tasks created with different repos", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					// Like someone running a command again, try again when the file has changed.
+					repo := fs.New(file)
+					Eventually(func() error {
+						return repo.CreateTask(&task.Task{Name: fmt.Sprintf("task-%d", i)})
+					}).Should(Succeed())
+				}(i)
+			}
+			wg.Wait()
+
+			tasks, err := fs.New(file).Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(10))
+
+			ids := map[int]bool{}
+			for _, t := range tasks {
+				ids[t.ID] = true
+			}
+			Expect(ids).To(HaveLen(10))
+		})
+	})
+
 	Context("when file is invalid", func() {
 		It("fails to run operations", func() {
 			repo := fs.New("/this/file/totally/does/not/exist")
@@ -4,73 +4,114 @@ package fs
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"sync"
 
+	"github.com/ankeesler/anwork/query"
 	"github.com/ankeesler/anwork/task"
 )
 
-type repo struct {
+// The contents of a repo are what is stored in its file.
+type contents struct {
+	// This is the version of the layout of the file; see formatVersion.
+	Version int `json:"version"`
+
 	MyTasks    []*task.Task `json:"tasks"`
 	NextTaskID int
 
 	MyEvents    []*task.Event `json:"events"`
 	NextEventID int
 
+	MyDependencies   []*task.Dependency `json:"dependencies"`
+	NextDependencyID int
+}
+
+type repo struct {
+	contents
+
 	file   string
 	loaded bool
+	// This is the file as it was when it was last loaded or committed, or nil if it did not exist.
+	// It is used to notice when the file is changed by someone else.
+	info os.FileInfo
+	// This is whether the repo is a copy of another repo that is used in WithTx, whose changes are
+	// written to the file by the other repo.
+	tx bool
+
+	lock sync.Mutex
 }
 
 // New returns a task.Repo that stores task.Task's on the local filesystem.
 //
-// This task.Repo is NOT thread-safe.
+// This task.Repo is thread-safe. It can also be used by more than one process at once: the file
+// is locked while it is being written, and writing fails if the file has changed since it was
+// loaded. After such a failure, the file is loaded again the next time the task.Repo is used. It
+// is also a task.Transactor, which writes the file once at the end of the transaction, and a
+// query.EventRepo.
 func New(file string) task.Repo {
 	return &repo{file: file}
 }
 
 func (r *repo) CreateTask(task *task.Task) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	if err := r.ensureLoaded(); err != nil {
 		return err
 	}
 
 	task.ID = r.NextTaskID
+	task.Version = 1
 	r.NextTaskID++
 
-	r.MyTasks = append(r.MyTasks, task)
+	r.MyTasks = append(r.MyTasks, copyTask(task))
 
 	return r.commit()
 }
 
 func (r *repo) Tasks() ([]*task.Task, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	if err := r.ensureLoaded(); err != nil {
 		return nil, err
 	}
 
-	return r.MyTasks, nil
+	tasks := make([]*task.Task, len(r.MyTasks))
+	for i, t := range r.MyTasks {
+		tasks[i] = copyTask(t)
+	}
+	return tasks, nil
 }
 
 func (r *repo) FindTaskByID(id int) (*task.Task, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	if err := r.ensureLoaded(); err != nil {
 		return nil, err
 	}
 
-	for _, task := range r.MyTasks {
-		if task.ID == id {
-			return task, nil
-		}
+	if t := r.findTask(id); t != nil {
+		return copyTask(t), nil
 	}
-
 	return nil, nil
 }
 
 func (r *repo) FindTaskByName(name string) (*task.Task, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	if err := r.ensureLoaded(); err != nil {
 		return nil, err
 	}
 
 	for _, task := range r.MyTasks {
 		if task.Name == name {
-			return task, nil
+			return copyTask(task), nil
 		}
 	}
 
@@ -78,23 +119,28 @@ func (r *repo) FindTaskByName(name string) (*task.Task, error) {
 }
 
 func (r *repo) UpdateTask(task *task.Task) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	if err := r.ensureLoaded(); err != nil {
 		return err
 	}
 
-	t, err := r.FindTaskByID(task.ID)
-	if err != nil {
-		panic(err)
-	} else if t == nil {
+	t := r.findTask(task.ID)
+	if t == nil {
 		return &unknownTaskError{name: task.Name, id: task.ID}
 	}
 
-	*t = *task
+	task.Version = t.Version + 1
+	*t = *copyTask(task)
 
 	return r.commit()
 }
 
 func (r *repo) DeleteTask(task *task.Task) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	if err := r.ensureLoaded(); err != nil {
 		return err
 	}
@@ -116,6 +162,9 @@ func (r *repo) DeleteTask(task *task.Task) error {
 }
 
 func (r *repo) CreateEvent(event *task.Event) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	if err := r.ensureLoaded(); err != nil {
 		return err
 	}
@@ -123,29 +172,53 @@ func (r *repo) CreateEvent(event *task.Event) error {
 	event.ID = r.NextEventID
 	r.NextEventID++
 
-	r.MyEvents = append(r.MyEvents, event)
+	r.MyEvents = append(r.MyEvents, copyEvent(event))
 
 	return r.commit()
 }
 
 func (r *repo) Events() ([]*task.Event, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.ensureLoaded(); err != nil {
+		return nil, err
+	}
+
+	return copyEvents(r.MyEvents), nil
+}
+
+func (r *repo) EventsMatching(q *query.EventQuery) ([]*task.Event, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	if err := r.ensureLoaded(); err != nil {
 		return nil, err
 	}
 
-	return r.MyEvents, nil
+	return copyEvents(q.Select(r.MyEvents)), nil
 }
 
 func (r *repo) FindEventByID(id int) (*task.Event, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.ensureLoaded(); err != nil {
+		return nil, err
+	}
+
 	index := r.findEvent(id)
 	if index == -1 {
 		return nil, nil
 	} else {
-		return r.MyEvents[index], nil
+		return copyEvent(r.MyEvents[index]), nil
 	}
 }
 
 func (r *repo) DeleteEvent(event *task.Event) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	if err := r.ensureLoaded(); err != nil {
 		return err
 	}
@@ -160,6 +233,132 @@ func (r *repo) DeleteEvent(event *task.Event) error {
 	}
 }
 
+func (r *repo) CreateDependency(dependency *task.Dependency) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.ensureLoaded(); err != nil {
+		return err
+	}
+
+	dependency.ID = r.NextDependencyID
+	r.NextDependencyID++
+
+	r.MyDependencies = append(r.MyDependencies, copyDependency(dependency))
+
+	return r.commit()
+}
+
+func (r *repo) Dependencies() ([]*task.Dependency, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.ensureLoaded(); err != nil {
+		return nil, err
+	}
+
+	dependencies := make([]*task.Dependency, len(r.MyDependencies))
+	for i, d := range r.MyDependencies {
+		dependencies[i] = copyDependency(d)
+	}
+	return dependencies, nil
+}
+
+func (r *repo) FindDependencyByID(id int) (*task.Dependency, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.ensureLoaded(); err != nil {
+		return nil, err
+	}
+
+	index := r.findDependency(id)
+	if index == -1 {
+		return nil, nil
+	} else {
+		return copyDependency(r.MyDependencies[index]), nil
+	}
+}
+
+func (r *repo) DeleteDependency(dependency *task.Dependency) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.ensureLoaded(); err != nil {
+		return err
+	}
+
+	index := r.findDependency(dependency.ID)
+
+	if index != -1 {
+		r.MyDependencies = append(r.MyDependencies[:index], r.MyDependencies[index+1:]...)
+		return r.commit()
+	} else {
+		return nil
+	}
+}
+
+// WithTx calls a function with a copy of the repo, whose contents are committed once the function
+// succeeds, and which only replace the contents of the repo once they are committed. The repo is locked
+// until the function returns.
+func (r *repo) WithTx(do func(task.Repo) error) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if err := r.ensureLoaded(); err != nil {
+		return err
+	}
+
+	// The copy changes its task.Task's in place, so it gets its own, which are thrown away if the
+	// function fails.
+	data, err := json.Marshal(&r.contents)
+	if err != nil {
+		return err
+	}
+	tx := &repo{file: r.file, loaded: true, tx: true}
+	if err := json.Unmarshal(data, &tx.contents); err != nil {
+		return err
+	}
+
+	if err := do(tx); err != nil {
+		return err
+	}
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	// If the contents of the transaction cannot be written, the repo keeps the contents that it had
+	// before the transaction, so that none of the transaction is seen.
+	before := r.contents
+	r.contents = tx.contents
+	if err := r.commit(); err != nil {
+		r.contents = before
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) findTask(id int) *task.Task {
+	for _, task := range r.MyTasks {
+		if task.ID == id {
+			return task
+		}
+	}
+	return nil
+}
+
+func (r *repo) findDependency(id int) int {
+	index := -1
+	for i, d := range r.MyDependencies {
+		if d.ID == id {
+			index = i
+			break
+		}
+	}
+	return index
+}
+
 func (r *repo) findEvent(id int) int {
 	index := -1
 	for i, e := range r.MyEvents {
@@ -171,13 +370,122 @@ func (r *repo) findEvent(id int) int {
 	return index
 }
 
+// copyTask returns a copy of a task.Task. The objects in the contents of a repo must only be changed
+// by the repo, so it only ever stores and returns copies of them.
+func copyTask(t *task.Task) *task.Task {
+	c := *t
+	if t.Tags != nil {
+		c.Tags = append([]string{}, t.Tags...)
+	}
+	if t.SharedWith != nil {
+		c.SharedWith = append([]string{}, t.SharedWith...)
+	}
+	return &c
+}
+
+func copyEvent(e *task.Event) *task.Event {
+	c := *e
+	if e.Cause != nil {
+		cause := *e.Cause
+		c.Cause = &cause
+	}
+	if e.Reverts != nil {
+		reverts := *e.Reverts
+		c.Reverts = &reverts
+	}
+	return &c
+}
+
+func copyEvents(events []*task.Event) []*task.Event {
+	copies := make([]*task.Event, len(events))
+	for i, e := range events {
+		copies[i] = copyEvent(e)
+	}
+	return copies
+}
+
+func copyDependency(d *task.Dependency) *task.Dependency {
+	c := *d
+	return &c
+}
+
+// commit writes the contents of the repo to its file. The contents are written to a temporary file
+// which then replaces the file, so that the file is never partially written.
 func (r *repo) commit() error {
-	data, err := json.Marshal(r)
+	if r.tx {
+		return nil
+	}
+
+	r.Version = formatVersion
+	data, err := json.Marshal(&r.contents)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(r.file + ".lock")
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	return ioutil.WriteFile(r.file, data, 0600)
+	if err := r.checkUnmodified(); err != nil {
+		// The changes that were just made were based on old contents, so throw them away.
+		r.loaded = false
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(r.file), filepath.Base(r.file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp.Name(), r.file); err != nil {
+		return err
+	}
+	if err := syncDir(filepath.Dir(r.file)); err != nil {
+		return err
+	}
+
+	r.info, err = os.Stat(r.file)
+	return err
+}
+
+// checkUnmodified returns an error if the file has changed since it was last loaded or committed.
+func (r *repo) checkUnmodified() error {
+	info, err := os.Stat(r.file)
+	if os.IsNotExist(err) {
+		if r.info == nil {
+			return nil
+		}
+		return &modifiedFileError{file: r.file}
+	} else if err != nil {
+		return err
+	}
+
+	if r.info == nil ||
+		!os.SameFile(info, r.info) ||
+		info.Size() != r.info.Size() ||
+		!info.ModTime().Equal(r.info.ModTime()) {
+		return &modifiedFileError{file: r.file}
+	}
+
+	return nil
 }
 
 func (r *repo) ensureLoaded() error {
@@ -185,18 +493,69 @@ func (r *repo) ensureLoaded() error {
 		return nil
 	}
 
-	if _, err := os.Stat(r.file); err == nil {
-		data, err := ioutil.ReadFile(r.file)
+	_, err := r.load()
+	return err
+}
+
+// load reads the contents of the repo from its file. If the file has an older layout, it is backed
+// up and upgraded; a description of each change that was made is returned.
+func (r *repo) load() ([]string, error) {
+	r.contents = contents{}
+	r.info = nil
+
+	var changes []string
+	f, err := os.Open(r.file)
+	if err == nil {
+		defer f.Close()
+
+		if r.info, err = f.Stat(); err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, err
+		}
+
+		upgraded, version, upgradeChanges, err := upgrade(data)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
-		if err := json.Unmarshal(data, r); err != nil {
-			return err
+		if err := json.Unmarshal(upgraded, &r.contents); err != nil {
+			return nil, err
 		}
+
+		if version < formatVersion {
+			backup, err := backUp(r.file, data, version)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(upgradeChanges, fmt.Sprintf("Backed up the original file to '%s'", backup))
+
+			if err := r.commit(); err != nil {
+				return nil, err
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
 	}
 
 	r.loaded = true
 
+	return changes, nil
+}
+
+// syncDir makes sure that a change to the entries of a directory, e.g., a rename, is stored.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// Not every platform can sync a directory, and the rename has happened either way.
+	d.Sync()
+
 	return nil
 }
@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/ankeesler/anwork/task"
+)
+
+type contexts struct {
+	repos map[string]*repo
+	lock  sync.Mutex
+}
+
+// NewContexts returns a task.Contexts that stores each context in memory. The Option's configure the
+// task.Repo of the task.DefaultContext, e.g., to start it with a Fixture.
+func NewContexts(options ...Option) task.Contexts {
+	c := &contexts{repos: make(map[string]*repo)}
+	if len(options) > 0 {
+		c.repos[task.DefaultContext] = newRepo(options...)
+	}
+	return c
+}
+
+func (c *contexts) Repo(name string) (task.Repo, error) {
+	if err := task.ValidateContextName(name); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.repo(name), nil
+}
+
+func (c *contexts) Contexts() ([]string, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	names := []string{}
+	for name, r := range c.repos {
+		r.lock.Lock()
+		if r.created {
+			names = append(names, name)
+		}
+		r.lock.Unlock()
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (c *contexts) CreateContext(name string) error {
+	if err := task.ValidateContextName(name); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	r := c.repo(name)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.created {
+		return &task.DuplicateContextError{Name: name}
+	}
+	r.created = true
+
+	return nil
+}
+
+func (c *contexts) CopyContext(from, to string) error {
+	if err := task.ValidateContextName(from); err != nil {
+		return err
+	} else if err := task.ValidateContextName(to); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	fromRepo := c.repo(from)
+	fromRepo.lock.Lock()
+	defer fromRepo.lock.Unlock()
+
+	if !fromRepo.created {
+		return &task.UnknownContextError{Name: from}
+	} else if from == to {
+		return &task.DuplicateContextError{Name: to}
+	}
+
+	toRepo := c.repo(to)
+	toRepo.lock.Lock()
+	defer toRepo.lock.Unlock()
+
+	if toRepo.created {
+		return &task.DuplicateContextError{Name: to}
+	}
+	fromRepo.copyTo(toRepo)
+
+	return nil
+}
+
+func (c *contexts) DeleteContext(name string) error {
+	if err := task.ValidateContextName(name); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	r := c.repo(name)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.created {
+		return &task.UnknownContextError{Name: name}
+	}
+	r.clear()
+	r.hooks.clear()
+
+	return nil
+}
+
+// repo returns the repo for a context. The lock must be held.
+func (c *contexts) repo(name string) *repo {
+	r, ok := c.repos[name]
+	if !ok {
+		r = newRepo()
+		c.repos[name] = r
+	}
+	return r
+}
@@ -0,0 +1,176 @@
+package memory_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ankeesler/anwork/session/sessiontest"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/memory"
+	"github.com/ankeesler/anwork/task/repotest"
+	"github.com/ankeesler/anwork/user/usertest"
+	"github.com/ankeesler/anwork/webhook/webhooktest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Memory Task Repo", func() {
+	var repo task.Repo
+
+	BeforeEach(func() {
+		repo = memory.New()
+	})
+
+	repotest.RunRepoTests(func() task.Repo {
+		return repo
+	})
+
+	Describe("IDs", func() {
+		repotest.RunIDTests(func() task.Repo {
+			return repo
+		})
+	})
+
+	Describe("WithTx", func() {
+		repotest.RunTransactorTests(func() task.Repo {
+			return repo
+		})
+	})
+
+	Describe("Webhooks", func() {
+		webhooktest.RunRepoTests(func() task.Repo {
+			return repo
+		})
+	})
+
+	Describe("Users", func() {
+		usertest.RunRepoTests(func() task.Repo {
+			return repo
+		})
+	})
+
+	Describe("Sessions", func() {
+		sessiontest.RunRepoTests(func() task.Repo {
+			return repo
+		})
+	})
+
+	Describe("Contexts", func() {
+		repotest.RunContextsTests(func() task.Contexts {
+			return memory.NewContexts()
+		})
+
+		It("starts the default context with the options", func() {
+			fixture := &memory.Fixture{Tasks: []*task.Task{&task.Task{Name: "task-a", ID: 5}}}
+			contexts := memory.NewContexts(memory.WithFixture(fixture))
+
+			names, err := contexts.Contexts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(Equal([]string{task.DefaultContext}))
+
+			repo, err := contexts.Repo(task.DefaultContext)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repo.FindTaskByName("task-a")).To(Equal(fixture.Tasks[0]))
+		})
+	})
+
+	It("stores and returns copies of the objects", func() {
+		t := &task.Task{Name: "task-a", Tags: []string{"tag-a"}}
+		Expect(repo.CreateTask(t)).To(Succeed())
+		t.Tags[0] = "tag-b"
+
+		found, err := repo.FindTaskByID(t.ID)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found.Tags).To(Equal([]string{"tag-a"}))
+
+		found.Name = "task-b"
+		Expect(repo.FindTaskByName("task-b")).To(BeNil())
+	})
+
+	Describe("WithFixture", func() {
+		var fixture *memory.Fixture
+
+		BeforeEach(func() {
+			dir, err := ioutil.TempDir("", "memory-task-repo-test")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+
+			// This is the layout of the file of a task.Repo in the fs package.
+			data := `{"version":2,"tasks":[{"name":"task-a","id":3,"state":"Ready"}],"NextTaskID":4,"events":[{"ID":7,"title":"Created task 'task-a'","type":0,"taskid":3}],"NextEventID":8,"dependencies":null}`
+			file := filepath.Join(dir, "fixture")
+			Expect(ioutil.WriteFile(file, []byte(data), 0600)).To(Succeed())
+
+			fixture, err = memory.LoadFixture(file)
+			Expect(err).NotTo(HaveOccurred())
+
+			repo = memory.New(memory.WithFixture(fixture))
+		})
+
+		It("starts the repo with the contents of the fixture", func() {
+			tasks, err := repo.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(Equal([]*task.Task{&task.Task{Name: "task-a", ID: 3, State: task.StateReady}}))
+
+			events, err := repo.Events()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].ID).To(Equal(7))
+		})
+
+		It("gives new objects IDs after the IDs in the fixture", func() {
+			t := &task.Task{Name: "task-b"}
+			Expect(repo.CreateTask(t)).To(Succeed())
+			Expect(t.ID).To(Equal(4))
+
+			e := &task.Event{Title: "event-b"}
+			Expect(repo.CreateEvent(e)).To(Succeed())
+			Expect(e.ID).To(Equal(8))
+
+			d := &task.Dependency{TaskID: 3, DependsOnID: 4}
+			Expect(repo.CreateDependency(d)).To(Succeed())
+			Expect(d.ID).To(Equal(0))
+		})
+
+		Context("when the fixture cannot be parsed", func() {
+			It("returns an error", func() {
+				dir, err := ioutil.TempDir("", "memory-task-repo-test")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.RemoveAll(dir)
+
+				file := filepath.Join(dir, "fixture")
+				Expect(ioutil.WriteFile(file, []byte("tuna"), 0600)).To(Succeed())
+
+				_, err = memory.LoadFixture(file)
+				Expect(err).To(MatchError(ContainSubstring("cannot parse fixture '" + file + "'")))
+			})
+		})
+	})
+
+	Context("when it is used concurrently", func() {
+		It("does not lose any tasks", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					Expect(repo.CreateTask(&task.Task{Name: fmt.Sprintf("task-%d", i)})).To(Succeed())
+				}(i)
+			}
+			wg.Wait()
+
+			tasks, err := repo.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(10))
+
+			ids := map[int]bool{}
+			for _, t := range tasks {
+				ids[t.ID] = true
+			}
+			Expect(ids).To(HaveLen(10))
+		})
+	})
+})
@@ -0,0 +1,376 @@
+// Package memory contains a task.Repo implementation which stores task.Task's in memory, e.g.,
+// for tests and for servers whose tasks do not need to outlive them.
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/ankeesler/anwork/query"
+	"github.com/ankeesler/anwork/task"
+)
+
+// A Fixture is the task.Task's, task.Event's, and task.Dependency's with which a repo starts. Its
+// JSON encoding is the same as the file of a task.Repo in the fs package, so the file of a
+// context can be used as a Fixture.
+type Fixture struct {
+	Tasks        []*task.Task       `json:"tasks"`
+	Events       []*task.Event      `json:"events"`
+	Dependencies []*task.Dependency `json:"dependencies"`
+}
+
+// LoadFixture reads a Fixture from a JSON file.
+func LoadFixture(file string) (*Fixture, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("cannot parse fixture '%s': %s", file, err.Error())
+	}
+
+	return &fixture, nil
+}
+
+type repo struct {
+	tasks        []*task.Task
+	events       []*task.Event
+	dependencies []*task.Dependency
+
+	nextTaskID, nextEventID, nextDependencyID int
+
+	// This is whether something has been created in the repo since it was made or cleared, i.e.,
+	// whether its context exists.
+	created bool
+
+	hooks    *webhooks
+	accounts *users
+	logins   *sessions
+
+	lock sync.Mutex
+}
+
+// An Option configures the task.Repo returned from New.
+type Option func(*repo)
+
+// WithFixture starts a task.Repo with a copy of the contents of a Fixture. The IDs of the
+// task.Task's, task.Event's, and task.Dependency's that are created later follow the largest IDs
+// in the Fixture.
+func WithFixture(fixture *Fixture) Option {
+	return func(r *repo) {
+		for _, t := range fixture.Tasks {
+			r.tasks = append(r.tasks, copyTask(t))
+			r.nextTaskID = nextID(r.nextTaskID, t.ID)
+		}
+		for _, e := range fixture.Events {
+			r.events = append(r.events, copyEvent(e))
+			r.nextEventID = nextID(r.nextEventID, e.ID)
+		}
+		for _, d := range fixture.Dependencies {
+			r.dependencies = append(r.dependencies, copyDependency(d))
+			r.nextDependencyID = nextID(r.nextDependencyID, d.ID)
+		}
+		r.created = true
+	}
+}
+
+// New returns a task.Repo that stores task.Task's in memory. Like the task.Repo in the fs package,
+// it gives the first task.Task, task.Event, and task.Dependency the ID 0.
+//
+// This task.Repo is thread-safe. It stores copies of the objects that are passed to it, and returns
+// copies of the objects that it stores, so that callers cannot change its contents by accident. It
+// is also a task.Transactor, a query.EventRepo, a webhook.Repo, a user.Repo, and a
+// session.Repo.
+func New(options ...Option) task.Repo {
+	return newRepo(options...)
+}
+
+func newRepo(options ...Option) *repo {
+	r := &repo{hooks: newWebhooks(), accounts: newUsers(), logins: newSessions()}
+	r.clear()
+	for _, option := range options {
+		option(r)
+	}
+	return r
+}
+
+func (r *repo) CreateTask(task *task.Task) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	task.ID = r.nextTaskID
+	task.Version = 1
+	r.nextTaskID++
+
+	r.tasks = append(r.tasks, copyTask(task))
+	r.created = true
+
+	return nil
+}
+
+func (r *repo) Tasks() ([]*task.Task, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	tasks := make([]*task.Task, len(r.tasks))
+	for i, t := range r.tasks {
+		tasks[i] = copyTask(t)
+	}
+	return tasks, nil
+}
+
+func (r *repo) FindTaskByID(id int) (*task.Task, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if index := r.findTask(id); index != -1 {
+		return copyTask(r.tasks[index]), nil
+	}
+	return nil, nil
+}
+
+func (r *repo) FindTaskByName(name string) (*task.Task, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, t := range r.tasks {
+		if t.Name == name {
+			return copyTask(t), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *repo) UpdateTask(task *task.Task) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	index := r.findTask(task.ID)
+	if index == -1 {
+		return fmt.Errorf("unknown task with name '%s' and id %d", task.Name, task.ID)
+	}
+
+	task.Version = r.tasks[index].Version + 1
+	r.tasks[index] = copyTask(task)
+
+	return nil
+}
+
+func (r *repo) DeleteTask(task *task.Task) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if index := r.findTask(task.ID); index != -1 {
+		r.tasks = append(r.tasks[:index], r.tasks[index+1:]...)
+	}
+	return nil
+}
+
+func (r *repo) CreateEvent(event *task.Event) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	event.ID = r.nextEventID
+	r.nextEventID++
+
+	r.events = append(r.events, copyEvent(event))
+	r.created = true
+
+	return nil
+}
+
+func (r *repo) Events() ([]*task.Event, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	events := make([]*task.Event, len(r.events))
+	for i, e := range r.events {
+		events[i] = copyEvent(e)
+	}
+	return events, nil
+}
+
+func (r *repo) EventsMatching(q *query.EventQuery) ([]*task.Event, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	events := q.Select(r.events)
+	for i, e := range events {
+		events[i] = copyEvent(e)
+	}
+	return events, nil
+}
+
+func (r *repo) FindEventByID(id int) (*task.Event, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if index := r.findEvent(id); index != -1 {
+		return copyEvent(r.events[index]), nil
+	}
+	return nil, nil
+}
+
+func (r *repo) DeleteEvent(event *task.Event) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if index := r.findEvent(event.ID); index != -1 {
+		r.events = append(r.events[:index], r.events[index+1:]...)
+	}
+	return nil
+}
+
+func (r *repo) CreateDependency(dependency *task.Dependency) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	dependency.ID = r.nextDependencyID
+	r.nextDependencyID++
+
+	r.dependencies = append(r.dependencies, copyDependency(dependency))
+	r.created = true
+
+	return nil
+}
+
+func (r *repo) Dependencies() ([]*task.Dependency, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	dependencies := make([]*task.Dependency, len(r.dependencies))
+	for i, d := range r.dependencies {
+		dependencies[i] = copyDependency(d)
+	}
+	return dependencies, nil
+}
+
+func (r *repo) FindDependencyByID(id int) (*task.Dependency, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if index := r.findDependency(id); index != -1 {
+		return copyDependency(r.dependencies[index]), nil
+	}
+	return nil, nil
+}
+
+func (r *repo) DeleteDependency(dependency *task.Dependency) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if index := r.findDependency(dependency.ID); index != -1 {
+		r.dependencies = append(r.dependencies[:index], r.dependencies[index+1:]...)
+	}
+	return nil
+}
+
+// WithTx calls a function with a copy of the repo, which replaces the contents of the repo if the
+// function succeeds. The repo is locked until the function returns.
+func (r *repo) WithTx(do func(task.Repo) error) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	tx := newRepo()
+	r.copyTo(tx)
+	tx.hooks = r.hooks
+	tx.accounts = r.accounts
+	tx.logins = r.logins
+
+	if err := do(tx); err != nil {
+		return err
+	}
+
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+
+	tx.copyTo(r)
+
+	return nil
+}
+
+// clear removes everything from the repo. The lock must be held.
+func (r *repo) clear() {
+	r.tasks = []*task.Task{}
+	r.events = []*task.Event{}
+	r.dependencies = []*task.Dependency{}
+	r.nextTaskID, r.nextEventID, r.nextDependencyID = 0, 0, 0
+	r.created = false
+}
+
+// copyTo replaces the contents of another repo with a copy of the contents of this repo. The locks
+// of both repos must be held.
+func (r *repo) copyTo(to *repo) {
+	to.clear()
+	WithFixture(&Fixture{Tasks: r.tasks, Events: r.events, Dependencies: r.dependencies})(to)
+	to.nextTaskID, to.nextEventID, to.nextDependencyID = r.nextTaskID, r.nextEventID, r.nextDependencyID
+	to.created = r.created
+}
+
+func (r *repo) findTask(id int) int {
+	for i, t := range r.tasks {
+		if t.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *repo) findEvent(id int) int {
+	for i, e := range r.events {
+		if e.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *repo) findDependency(id int) int {
+	for i, d := range r.dependencies {
+		if d.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextID returns the next ID to use after an existing ID.
+func nextID(next, id int) int {
+	if id >= next {
+		return id + 1
+	}
+	return next
+}
+
+func copyTask(t *task.Task) *task.Task {
+	c := *t
+	if t.Tags != nil {
+		c.Tags = append([]string{}, t.Tags...)
+	}
+	if t.SharedWith != nil {
+		c.SharedWith = append([]string{}, t.SharedWith...)
+	}
+	return &c
+}
+
+func copyEvent(e *task.Event) *task.Event {
+	c := *e
+	if e.Cause != nil {
+		cause := *e.Cause
+		c.Cause = &cause
+	}
+	if e.Reverts != nil {
+		reverts := *e.Reverts
+		c.Reverts = &reverts
+	}
+	return &c
+}
+
+func copyDependency(d *task.Dependency) *task.Dependency {
+	c := *d
+	return &c
+}
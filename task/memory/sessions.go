@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ankeesler/anwork/session"
+)
+
+// sessions are the session.Session's of a repo. Like its users, they are kept apart from its
+// task.Task's, task.Event's, and task.Dependency's.
+type sessions struct {
+	sessions map[string]*session.Session
+
+	lock sync.Mutex
+}
+
+func newSessions() *sessions {
+	return &sessions{sessions: make(map[string]*session.Session)}
+}
+
+func (r *repo) CreateSession(s *session.Session) error {
+	r.logins.lock.Lock()
+	defer r.logins.lock.Unlock()
+
+	if _, ok := r.logins.sessions[s.ID]; ok {
+		return fmt.Errorf("session '%s' already exists", s.ID)
+	}
+
+	c := *s
+	r.logins.sessions[s.ID] = &c
+
+	return nil
+}
+
+func (r *repo) Sessions() ([]*session.Session, error) {
+	r.logins.lock.Lock()
+	defer r.logins.lock.Unlock()
+
+	sessions := make([]*session.Session, 0, len(r.logins.sessions))
+	for _, s := range r.logins.sessions {
+		c := *s
+		sessions = append(sessions, &c)
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if sessions[i].IssuedAt != sessions[j].IssuedAt {
+			return sessions[i].IssuedAt < sessions[j].IssuedAt
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions, nil
+}
+
+func (r *repo) FindSessionByID(id string) (*session.Session, error) {
+	r.logins.lock.Lock()
+	defer r.logins.lock.Unlock()
+
+	s, ok := r.logins.sessions[id]
+	if !ok {
+		return nil, nil
+	}
+
+	c := *s
+	return &c, nil
+}
+
+func (r *repo) DeleteSession(s *session.Session) error {
+	r.logins.lock.Lock()
+	defer r.logins.lock.Unlock()
+
+	delete(r.logins.sessions, s.ID)
+	return nil
+}
+
+func (r *repo) DeleteExpiredSessions(now int64) error {
+	r.logins.lock.Lock()
+	defer r.logins.lock.Unlock()
+
+	for id, s := range r.logins.sessions {
+		if s.Expired(now) {
+			delete(r.logins.sessions, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ankeesler/anwork/user"
+)
+
+// users are the user.User's of a repo. Like its webhooks, they are kept apart from its task.Task's,
+// task.Event's, and task.Dependency's, so that they are neither copied into, nor replaced by, a
+// transaction or a copy of its context.
+type users struct {
+	users map[string]*user.User
+
+	lock sync.Mutex
+}
+
+func newUsers() *users {
+	return &users{users: make(map[string]*user.User)}
+}
+
+func (r *repo) CreateUser(u *user.User) error {
+	r.accounts.lock.Lock()
+	defer r.accounts.lock.Unlock()
+
+	if _, ok := r.accounts.users[u.Name]; ok {
+		return fmt.Errorf("user '%s' already exists", u.Name)
+	}
+
+	c := *u
+	r.accounts.users[u.Name] = &c
+
+	return nil
+}
+
+func (r *repo) Users() ([]*user.User, error) {
+	r.accounts.lock.Lock()
+	defer r.accounts.lock.Unlock()
+
+	users := make([]*user.User, 0, len(r.accounts.users))
+	for _, u := range r.accounts.users {
+		c := *u
+		users = append(users, &c)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	return users, nil
+}
+
+func (r *repo) FindUserByName(name string) (*user.User, error) {
+	r.accounts.lock.Lock()
+	defer r.accounts.lock.Unlock()
+
+	u, ok := r.accounts.users[name]
+	if !ok {
+		return nil, nil
+	}
+
+	c := *u
+	return &c, nil
+}
+
+func (r *repo) DeleteUser(u *user.User) error {
+	r.accounts.lock.Lock()
+	defer r.accounts.lock.Unlock()
+
+	delete(r.accounts.users, u.Name)
+	return nil
+}
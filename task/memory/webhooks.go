@@ -0,0 +1,126 @@
+package memory
+
+import (
+	"sync"
+
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+)
+
+// webhooks are the webhook.Webhook's and webhook.DeadLetter's of a repo. They are kept apart from
+// its task.Task's, task.Event's, and task.Dependency's, so that they are neither copied into, nor
+// replaced by, a transaction or a copy of its context.
+type webhooks struct {
+	webhooks    []*webhook.Webhook
+	deadLetters []*webhook.DeadLetter
+
+	nextWebhookID, nextDeadLetterID int
+
+	lock sync.Mutex
+}
+
+func newWebhooks() *webhooks {
+	w := &webhooks{}
+	w.clear()
+	return w
+}
+
+// clear removes every webhook.Webhook and webhook.DeadLetter.
+func (w *webhooks) clear() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.webhooks = []*webhook.Webhook{}
+	w.deadLetters = []*webhook.DeadLetter{}
+	w.nextWebhookID, w.nextDeadLetterID = 1, 1
+}
+
+func (r *repo) CreateWebhook(w *webhook.Webhook) error {
+	r.hooks.lock.Lock()
+	defer r.hooks.lock.Unlock()
+
+	w.ID = r.hooks.nextWebhookID
+	r.hooks.nextWebhookID++
+
+	r.hooks.webhooks = append(r.hooks.webhooks, copyWebhook(w))
+
+	return nil
+}
+
+func (r *repo) Webhooks() ([]*webhook.Webhook, error) {
+	r.hooks.lock.Lock()
+	defer r.hooks.lock.Unlock()
+
+	webhooks := make([]*webhook.Webhook, len(r.hooks.webhooks))
+	for i, w := range r.hooks.webhooks {
+		webhooks[i] = copyWebhook(w)
+	}
+	return webhooks, nil
+}
+
+func (r *repo) FindWebhookByID(id int) (*webhook.Webhook, error) {
+	r.hooks.lock.Lock()
+	defer r.hooks.lock.Unlock()
+
+	for _, w := range r.hooks.webhooks {
+		if w.ID == id {
+			return copyWebhook(w), nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *repo) DeleteWebhook(w *webhook.Webhook) error {
+	r.hooks.lock.Lock()
+	defer r.hooks.lock.Unlock()
+
+	for i, existing := range r.hooks.webhooks {
+		if existing.ID == w.ID {
+			r.hooks.webhooks = append(r.hooks.webhooks[:i], r.hooks.webhooks[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (r *repo) CreateDeadLetter(d *webhook.DeadLetter) error {
+	r.hooks.lock.Lock()
+	defer r.hooks.lock.Unlock()
+
+	d.ID = r.hooks.nextDeadLetterID
+	r.hooks.nextDeadLetterID++
+
+	r.hooks.deadLetters = append(r.hooks.deadLetters, copyDeadLetter(d))
+
+	return nil
+}
+
+func (r *repo) DeadLetters() ([]*webhook.DeadLetter, error) {
+	r.hooks.lock.Lock()
+	defer r.hooks.lock.Unlock()
+
+	deadLetters := make([]*webhook.DeadLetter, len(r.hooks.deadLetters))
+	for i, d := range r.hooks.deadLetters {
+		deadLetters[i] = copyDeadLetter(d)
+	}
+	return deadLetters, nil
+}
+
+func copyWebhook(w *webhook.Webhook) *webhook.Webhook {
+	c := *w
+	if w.EventTypes != nil {
+		c.EventTypes = append([]task.EventType{}, w.EventTypes...)
+	}
+	if w.States != nil {
+		c.States = append([]task.State{}, w.States...)
+	}
+	return &c
+}
+
+func copyDeadLetter(d *webhook.DeadLetter) *webhook.DeadLetter {
+	c := *d
+	if d.Event != nil {
+		c.Event = copyEvent(d.Event)
+	}
+	return &c
+}
@@ -0,0 +1,231 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// These are the values given to a Task by an EventTypeCreate Event that was recorded before Create
+// Event's carried a Snapshot of the Task, i.e., the values that new Task's have always been given.
+const (
+	legacyPriority = 10
+	legacyState    = StateReady
+)
+
+// A Projection is the state of the Task's and Dependency's that is described by a journal of
+// Event's. Each Event is applied to the Projection in the order in which the Event's took place.
+type Projection struct {
+	Tasks        []*Task       `json:"tasks"`
+	Dependencies []*Dependency `json:"dependencies"`
+
+	// These are the next unused IDs, which are never less than one more than the largest ID that
+	// the Projection has seen.
+	NextTaskID       int `json:"nextTaskId"`
+	NextDependencyID int `json:"nextDependencyId"`
+}
+
+// Replay applies the Event's, in order, to an empty Projection. An Event that cannot be applied is
+// skipped, and an error is returned for it.
+func Replay(events []*Event) (*Projection, []error) {
+	p := &Projection{}
+	var errs []error
+	for _, event := range events {
+		if err := p.Apply(event); err != nil {
+			errs = append(errs, fmt.Errorf("event %d ('%s'): %s", event.ID, event.Title, err.Error()))
+		}
+	}
+	return p, errs
+}
+
+// Apply changes the Projection in the way that the Event says that the Task's and Dependency's
+// changed. If the Event does not make sense for the Projection (e.g., it refers to a Task that does
+// not exist), an error is returned and the Projection is not changed.
+//
+// Applying an Event whose change has already been made to the Projection (e.g., an
+// EventTypeSetState Event for a Task that is already in the new State) succeeds, so an Event can be
+// applied after the change that it records. The Task.Version of a Task is only incremented when an
+// Event changes it.
+func (p *Projection) Apply(event *Event) error {
+	switch event.Type {
+	case EventTypeCreate:
+		return p.applyCreate(event)
+	case EventTypeDelete:
+		p.deleteTask(event.TaskID)
+		return nil
+	case EventTypeReset, EventTypeRestore:
+		// The Event's that change the Task's are recorded separately.
+		return nil
+	}
+
+	task := p.FindTaskByID(event.TaskID)
+	if task == nil {
+		return fmt.Errorf("unknown task with ID %d", event.TaskID)
+	}
+
+	switch event.Type {
+	case EventTypeNote:
+		return nil
+
+	case EventTypeSetState:
+		if task.State != State(event.NewValue) {
+			task.State = State(event.NewValue)
+			task.Version++
+		}
+		return nil
+
+	case EventTypeSetPriority:
+		priority, err := strconv.Atoi(event.NewValue)
+		if err != nil {
+			return fmt.Errorf("invalid priority: '%s'", event.NewValue)
+		}
+		if task.Priority != priority {
+			task.Priority = priority
+			task.Version++
+		}
+		return nil
+
+	case EventTypeSetDeadline:
+		deadline, err := strconv.ParseInt(event.NewValue, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid deadline: '%s'", event.NewValue)
+		}
+		if task.Deadline != deadline {
+			task.Deadline = deadline
+			task.Version++
+		}
+		return nil
+
+	case EventTypeAddDependency:
+		dependsOn := p.FindTaskByName(event.NewValue)
+		if dependsOn == nil {
+			return fmt.Errorf("unknown task with name '%s'", event.NewValue)
+		}
+		if p.findDependency(task.ID, dependsOn.ID) == -1 {
+			p.Dependencies = append(p.Dependencies, &Dependency{
+				ID:          p.NextDependencyID,
+				TaskID:      task.ID,
+				DependsOnID: dependsOn.ID,
+			})
+			p.NextDependencyID++
+		}
+		return nil
+
+	case EventTypeRemoveDependency:
+		dependsOn := p.FindTaskByName(event.OldValue)
+		if dependsOn == nil {
+			return fmt.Errorf("unknown task with name '%s'", event.OldValue)
+		}
+		if i := p.findDependency(task.ID, dependsOn.ID); i != -1 {
+			p.Dependencies = append(p.Dependencies[:i], p.Dependencies[i+1:]...)
+		}
+		return nil
+
+	case EventTypeAddTag:
+		if !task.HasTag(event.NewValue) {
+			task.Tags = append(task.Tags, event.NewValue)
+			sort.Strings(task.Tags)
+			task.Version++
+		}
+		return nil
+
+	case EventTypeRemoveTag:
+		for i, tag := range task.Tags {
+			if tag == event.OldValue {
+				task.Tags = append(task.Tags[:i], task.Tags[i+1:]...)
+				task.Version++
+				break
+			}
+		}
+		return nil
+
+	case EventTypeRename:
+		if other := p.FindTaskByName(event.NewValue); other != nil && other.ID != task.ID {
+			return fmt.Errorf("duplicate task with name '%s'", event.NewValue)
+		}
+		if task.Name != event.NewValue {
+			task.Name = event.NewValue
+			task.Version++
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown event type: %d", event.Type)
+	}
+}
+
+func (p *Projection) applyCreate(event *Event) error {
+	if other := p.FindTaskByName(event.NewValue); other != nil && other.ID != event.TaskID {
+		return fmt.Errorf("duplicate task with name '%s'", event.NewValue)
+	}
+
+	task := &Task{StartDate: event.Date, Priority: legacyPriority, State: legacyState}
+	if event.Snapshot != "" {
+		if err := json.Unmarshal([]byte(event.Snapshot), task); err != nil {
+			return fmt.Errorf("invalid snapshot: %s", err.Error())
+		}
+	}
+	task.ID = event.TaskID
+	task.Name = event.NewValue
+	task.Version = 1
+
+	if existing := p.FindTaskByID(task.ID); existing != nil {
+		*existing = *task
+	} else {
+		p.Tasks = append(p.Tasks, task)
+	}
+	if task.ID >= p.NextTaskID {
+		p.NextTaskID = task.ID + 1
+	}
+
+	return nil
+}
+
+// deleteTask deletes a Task and the Dependency's on or of it, if they exist.
+func (p *Projection) deleteTask(id int) {
+	for i, task := range p.Tasks {
+		if task.ID == id {
+			p.Tasks = append(p.Tasks[:i], p.Tasks[i+1:]...)
+			break
+		}
+	}
+
+	dependencies := p.Dependencies[:0]
+	for _, dependency := range p.Dependencies {
+		if dependency.TaskID != id && dependency.DependsOnID != id {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+	p.Dependencies = dependencies
+}
+
+// FindTaskByID returns the Task in the Projection with the provided ID, or nil if there is none.
+func (p *Projection) FindTaskByID(id int) *Task {
+	for _, task := range p.Tasks {
+		if task.ID == id {
+			return task
+		}
+	}
+	return nil
+}
+
+// FindTaskByName returns the Task in the Projection with the provided name, or nil if there is
+// none.
+func (p *Projection) FindTaskByName(name string) *Task {
+	for _, task := range p.Tasks {
+		if task.Name == name {
+			return task
+		}
+	}
+	return nil
+}
+
+func (p *Projection) findDependency(taskID, dependsOnID int) int {
+	for i, dependency := range p.Dependencies {
+		if dependency.TaskID == taskID && dependency.DependsOnID == dependsOnID {
+			return i
+		}
+	}
+	return -1
+}
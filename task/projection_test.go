@@ -0,0 +1,128 @@
+package task_test
+
+import (
+	"github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Projection", func() {
+	var events []*task.Event
+
+	BeforeEach(func() {
+		events = []*task.Event{
+			&task.Event{
+				ID:       0,
+				Date:     100,
+				Type:     task.EventTypeCreate,
+				TaskID:   0,
+				NewValue: "task-a",
+				Snapshot: `{"name":"task-a","id":0,"startDate":100,"priority":10,"state":"Ready"}`,
+			},
+			&task.Event{
+				ID:       1,
+				Date:     200,
+				Type:     task.EventTypeCreate,
+				TaskID:   1,
+				NewValue: "task-b",
+			},
+			&task.Event{ID: 2, Type: task.EventTypeSetState, TaskID: 0, OldValue: "Ready", NewValue: "Running"},
+			&task.Event{ID: 3, Type: task.EventTypeSetPriority, TaskID: 1, OldValue: "10", NewValue: "5"},
+			&task.Event{ID: 4, Type: task.EventTypeSetDeadline, TaskID: 1, OldValue: "0", NewValue: "300"},
+			&task.Event{ID: 5, Type: task.EventTypeAddTag, TaskID: 0, NewValue: "tag-b"},
+			&task.Event{ID: 6, Type: task.EventTypeAddTag, TaskID: 0, NewValue: "tag-a"},
+			&task.Event{ID: 7, Type: task.EventTypeRemoveTag, TaskID: 0, OldValue: "tag-b"},
+			&task.Event{ID: 8, Type: task.EventTypeRename, TaskID: 1, OldValue: "task-b", NewValue: "task-c"},
+			&task.Event{ID: 9, Type: task.EventTypeAddDependency, TaskID: 0, NewValue: "task-c"},
+			&task.Event{ID: 10, Type: task.EventTypeNote, TaskID: 0, Note: "some note"},
+		}
+	})
+
+	It("replays the events into tasks and dependencies", func() {
+		p, errs := task.Replay(events)
+		Expect(errs).To(BeEmpty())
+		Expect(p.Tasks).To(Equal([]*task.Task{
+			&task.Task{
+				Name:      "task-a",
+				ID:        0,
+				StartDate: 100,
+				Priority:  10,
+				State:     task.StateRunning,
+				Tags:      []string{"tag-a"},
+				Version:   5,
+			},
+			&task.Task{
+				Name:      "task-c",
+				ID:        1,
+				StartDate: 200,
+				Priority:  5,
+				State:     task.StateReady,
+				Deadline:  300,
+				Version:   4,
+			},
+		}))
+		Expect(p.Dependencies).To(Equal([]*task.Dependency{
+			&task.Dependency{ID: 0, TaskID: 0, DependsOnID: 1},
+		}))
+		Expect(p.NextTaskID).To(Equal(2))
+		Expect(p.NextDependencyID).To(Equal(1))
+	})
+
+	Context("when a task is deleted", func() {
+		BeforeEach(func() {
+			events = append(events, &task.Event{ID: 11, Type: task.EventTypeDelete, TaskID: 1, OldValue: "task-c"})
+		})
+
+		It("removes the task and its dependencies", func() {
+			p, errs := task.Replay(events)
+			Expect(errs).To(BeEmpty())
+			Expect(p.Tasks).To(HaveLen(1))
+			Expect(p.Tasks[0].Name).To(Equal("task-a"))
+			Expect(p.Dependencies).To(BeEmpty())
+			Expect(p.NextTaskID).To(Equal(2))
+		})
+	})
+
+	Context("when an event refers to a task that does not exist", func() {
+		BeforeEach(func() {
+			events = append(events,
+				&task.Event{ID: 11, Title: "event-11", Type: task.EventTypeSetState, TaskID: 5, NewValue: "Finished"},
+				&task.Event{ID: 12, Title: "event-12", Type: task.EventTypeAddDependency, TaskID: 0, NewValue: "task-z"},
+			)
+		})
+
+		It("skips the events and returns an error for each", func() {
+			p, errs := task.Replay(events)
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0]).To(MatchError("event 11 ('event-11'): unknown task with ID 5"))
+			Expect(errs[1]).To(MatchError("event 12 ('event-12'): unknown task with name 'task-z'"))
+			Expect(p.Tasks).To(HaveLen(2))
+			Expect(p.Dependencies).To(HaveLen(1))
+		})
+	})
+
+	Describe("Apply", func() {
+		It("succeeds when the change has already been made", func() {
+			p, errs := task.Replay(events)
+			Expect(errs).To(BeEmpty())
+
+			for _, event := range events[2:] {
+				Expect(p.Apply(event)).To(Succeed())
+			}
+
+			// Only tag-b, which had been removed, is added and removed again.
+			replayed, _ := task.Replay(events)
+			replayed.Tasks[0].Version += 2
+			Expect(p).To(Equal(replayed))
+		})
+
+		It("does not change the projection when it fails", func() {
+			p, errs := task.Replay(events)
+			Expect(errs).To(BeEmpty())
+
+			err := p.Apply(&task.Event{Type: task.EventTypeRename, TaskID: 0, NewValue: "task-c"})
+			Expect(err).To(MatchError("duplicate task with name 'task-c'"))
+			Expect(p.FindTaskByID(0).Name).To(Equal("task-a"))
+		})
+	})
+})
@@ -2,9 +2,10 @@ package task
 
 //go:generate counterfeiter . Repo
 
-// Repo is an object that allows CRUD operations on Task's and Event's.
+// Repo is an object that allows CRUD operations on Task's, Event's, and Dependency's.
 type Repo interface {
-	// CreateTask creates a Task. The Task.ID field is set by the Repo.
+	// CreateTask creates a Task. The Task.ID field is set by the Repo, and the Task.Version field
+	// is set to 1.
 	CreateTask(*Task) error
 	// Tasks returns all of the Task's in this Repo.
 	Tasks() ([]*Task, error)
@@ -15,7 +16,9 @@ type Repo interface {
 	// exist, it will return nil, nil.
 	FindTaskByName(string) (*Task, error)
 	// UpdateTask finds the Task in the Repo with the provided ID and updates its
-	// values to those provided.
+	// values to those provided. The Task.Version field is set by the Repo to one more than
+	// the version of the Task in the Repo. A Repo may refuse to update a Task whose version
+	// is older than the one in the Repo, i.e., that someone else has updated since it was read.
 	UpdateTask(*Task) error
 	// DeleteTask deletes a Task with the provided ID.
 	// If the Task does not exist, this function will return nil.
@@ -31,4 +34,28 @@ type Repo interface {
 	// DeleteEvent deletes an Event with the provided ID.
 	// If the Event does not exist, this function will return nil.
 	DeleteEvent(*Event) error
+
+	// CreateDependency creates a Dependency. The Dependency.ID field is set by the Repo.
+	CreateDependency(*Dependency) error
+	// Dependencies returns all of the Dependency's in this Repo.
+	Dependencies() ([]*Dependency, error)
+	// FindDependencyByID will try to find a Dependency with the provided ID. If the
+	// Dependency does not exist, it will return nil, nil.
+	FindDependencyByID(int) (*Dependency, error)
+	// DeleteDependency deletes a Dependency with the provided ID.
+	// If the Dependency does not exist, this function will return nil.
+	DeleteDependency(*Dependency) error
+}
+
+//go:generate counterfeiter . Transactor
+
+// Transactor is implemented by a Repo that can make a number of changes all-or-nothing, e.g., in
+// a transaction of a SQL database.
+type Transactor interface {
+	// WithTx calls a function with a Repo whose changes are only kept if the function returns nil.
+	// If the function returns an error, none of its changes are kept, and the error is returned.
+	//
+	// The function must only use the Repo that it is given, and not the Repo that implements the
+	// Transactor, which may be locked until the function returns.
+	WithTx(func(Repo) error) error
 }
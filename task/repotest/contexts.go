@@ -0,0 +1,203 @@
+package repotest
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	taskpkg "github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// RunContextsTests will run a set of tests to verify that the provided contexts
+// are a valid task.Contexts implementation.
+func RunContextsTests(createContextsFunc func() taskpkg.Contexts) {
+	var contexts taskpkg.Contexts
+
+	BeforeEach(func() {
+		contexts = createContextsFunc()
+	})
+
+	repo := func(name string) taskpkg.Repo {
+		repo, err := contexts.Repo(name)
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		return repo
+	}
+
+	names := func() []string {
+		names, err := contexts.Contexts()
+		ExpectWithOffset(1, err).NotTo(HaveOccurred())
+		return names
+	}
+
+	Describe("Repo", func() {
+		It("returns an empty repo for a context that does not exist", func() {
+			tasks, err := repo("context-a").Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(BeEmpty())
+			Expect(names()).NotTo(ContainElement("context-a"))
+		})
+
+		It("creates the context when something is created in it", func() {
+			Expect(repo("context-a").CreateTask(&taskpkg.Task{Name: "task-a"})).To(Succeed())
+			Expect(names()).To(ContainElement("context-a"))
+		})
+
+		It("keeps the tasks of each context separate", func() {
+			Expect(repo("context-a").CreateTask(&taskpkg.Task{Name: "task-a"})).To(Succeed())
+			Expect(repo("context-b").CreateTask(&taskpkg.Task{Name: "task-b"})).To(Succeed())
+
+			tasks, err := repo("context-a").Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(1))
+			Expect(tasks[0].Name).To(Equal("task-a"))
+
+			task, err := repo("context-b").FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(task).To(BeNil())
+		})
+
+		It("fails when the name of the context is invalid", func() {
+			_, err := contexts.Repo("../context-a")
+			Expect(err).To(MatchError("invalid context name '../context-a'"))
+		})
+	})
+
+	Describe("CreateContext", func() {
+		It("creates an empty context", func() {
+			Expect(contexts.CreateContext("context-b")).To(Succeed())
+			Expect(contexts.CreateContext("context-a")).To(Succeed())
+
+			all := names()
+			Expect(all).To(ContainElement("context-a"))
+			Expect(all).To(ContainElement("context-b"))
+			Expect(sort.StringsAreSorted(all)).To(BeTrue())
+
+			tasks, err := repo("context-a").Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(BeEmpty())
+		})
+
+		It("fails when the context already exists", func() {
+			Expect(contexts.CreateContext("context-a")).To(Succeed())
+			Expect(contexts.CreateContext("context-a")).To(MatchError(
+				&taskpkg.DuplicateContextError{Name: "context-a"}))
+		})
+
+		It("fails when the name of the context is invalid", func() {
+			Expect(contexts.CreateContext("context a")).To(MatchError("invalid context name 'context a'"))
+		})
+	})
+
+	Describe("CopyContext", func() {
+		var taskA, taskB *taskpkg.Task
+
+		BeforeEach(func() {
+			from := repo("context-a")
+
+			// Make the IDs of the copied tasks differ from the IDs of the original tasks in repos
+			// that share IDs between contexts.
+			Expect(repo("context-c").CreateTask(&taskpkg.Task{Name: "task-c"})).To(Succeed())
+
+			taskA = &taskpkg.Task{Name: "task-a", Tags: []string{"tag-a"}}
+			Expect(from.CreateTask(taskA)).To(Succeed())
+			taskB = &taskpkg.Task{Name: "task-b"}
+			Expect(from.CreateTask(taskB)).To(Succeed())
+			Expect(from.CreateDependency(&taskpkg.Dependency{TaskID: taskA.ID, DependsOnID: taskB.ID})).To(Succeed())
+
+			create := &taskpkg.Event{
+				Title:    "Created task 'task-a'",
+				Type:     taskpkg.EventTypeCreate,
+				TaskID:   taskA.ID,
+				NewValue: "task-a",
+				Snapshot: `{"name":"task-a","id":` + strconv.Itoa(taskA.ID) + `}`,
+			}
+			Expect(from.CreateEvent(create)).To(Succeed())
+			Expect(from.CreateEvent(&taskpkg.Event{
+				Title:  "Note added to task 'task-a'",
+				Type:   taskpkg.EventTypeNote,
+				TaskID: taskA.ID,
+				Cause:  &create.ID,
+			})).To(Succeed())
+			Expect(from.CreateEvent(&taskpkg.Event{
+				Title:    "Added dependency of task 'task-a' on task 'task-b'",
+				Type:     taskpkg.EventTypeAddDependency,
+				TaskID:   taskA.ID,
+				NewValue: "task-b",
+				Snapshot: `{"taskid":` + strconv.Itoa(taskA.ID) + `,"dependsonid":` + strconv.Itoa(taskB.ID) + `}`,
+			})).To(Succeed())
+
+			Expect(contexts.CopyContext("context-a", "context-b")).To(Succeed())
+		})
+
+		It("copies the tasks, events, and dependencies, and their references to each other", func() {
+			to := repo("context-b")
+
+			tasks, err := to.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(2))
+			Expect(tasks[0].Name).To(Equal("task-a"))
+			Expect(tasks[0].Tags).To(Equal([]string{"tag-a"}))
+			Expect(tasks[1].Name).To(Equal("task-b"))
+
+			dependencies, err := to.Dependencies()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(dependencies).To(HaveLen(1))
+			Expect(dependencies[0].TaskID).To(Equal(tasks[0].ID))
+			Expect(dependencies[0].DependsOnID).To(Equal(tasks[1].ID))
+
+			events, err := to.Events()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(3))
+			Expect(events[0].TaskID).To(Equal(tasks[0].ID))
+			var snapshot taskpkg.Task
+			Expect(json.Unmarshal([]byte(events[0].Snapshot), &snapshot)).To(Succeed())
+			Expect(snapshot.ID).To(Equal(tasks[0].ID))
+			Expect(events[1].TaskID).To(Equal(tasks[0].ID))
+			Expect(events[1].Cause).To(Equal(&events[0].ID))
+			dependsOnID, ok := events[2].DependsOnID()
+			Expect(ok).To(BeTrue())
+			Expect(dependsOnID).To(Equal(tasks[1].ID))
+		})
+
+		It("leaves the original context alone", func() {
+			tasks, err := repo("context-a").Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(Equal([]*taskpkg.Task{taskA, taskB}))
+			Expect(names()).To(ContainElement("context-b"))
+		})
+
+		It("fails when the context to copy does not exist", func() {
+			Expect(contexts.CopyContext("context-z", "context-y")).To(MatchError(
+				&taskpkg.UnknownContextError{Name: "context-z"}))
+		})
+
+		It("fails when the context to create already exists", func() {
+			Expect(contexts.CopyContext("context-a", "context-b")).To(MatchError(
+				&taskpkg.DuplicateContextError{Name: "context-b"}))
+		})
+	})
+
+	Describe("DeleteContext", func() {
+		BeforeEach(func() {
+			Expect(repo("context-a").CreateTask(&taskpkg.Task{Name: "task-a"})).To(Succeed())
+			Expect(repo("context-b").CreateTask(&taskpkg.Task{Name: "task-b"})).To(Succeed())
+		})
+
+		It("deletes the context and everything in it", func() {
+			Expect(contexts.DeleteContext("context-a")).To(Succeed())
+			Expect(names()).NotTo(ContainElement("context-a"))
+			Expect(names()).To(ContainElement("context-b"))
+
+			tasks, err := repo("context-a").Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(BeEmpty())
+		})
+
+		It("fails when the context does not exist", func() {
+			Expect(contexts.DeleteContext("context-z")).To(MatchError(
+				&taskpkg.UnknownContextError{Name: "context-z"}))
+		})
+	})
+}
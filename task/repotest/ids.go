@@ -0,0 +1,36 @@
+package repotest
+
+import (
+	"fmt"
+
+	taskpkg "github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// RunIDTests will run a set of tests to verify that the provided repo numbers its tasks, events,
+// and dependencies in the same way as the task.Repo in the fs package, i.e., starting at 0. A file
+// of that task.Repo can be loaded into any repo that passes these tests (see memory.LoadFixture)
+// and keep its IDs. The createRepoFunc must return an empty repo.
+func RunIDTests(createRepoFunc func() taskpkg.Repo) {
+	var repo taskpkg.Repo
+	BeforeEach(func() {
+		repo = createRepoFunc()
+	})
+
+	It("gives the first task, event, and dependency the ID 0, and counts up from there", func() {
+		for i := 0; i < 2; i++ {
+			t := &taskpkg.Task{Name: fmt.Sprintf("task-%d", i)}
+			Expect(repo.CreateTask(t)).To(Succeed())
+			Expect(t.ID).To(Equal(i))
+
+			e := &taskpkg.Event{Title: "event"}
+			Expect(repo.CreateEvent(e)).To(Succeed())
+			Expect(e.ID).To(Equal(i))
+
+			d := &taskpkg.Dependency{TaskID: 0, DependsOnID: 1}
+			Expect(repo.CreateDependency(d)).To(Succeed())
+			Expect(d.ID).To(Equal(i))
+		}
+	})
+}
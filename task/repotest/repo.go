@@ -1,28 +1,52 @@
-package task
+// Package repotest contains conformance tests that every task.Repo and task.Contexts
+// implementation should pass.
+package repotest
 
 import (
+	"github.com/ankeesler/anwork/query"
+	taskpkg "github.com/ankeesler/anwork/task"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
 
 // RunRepoTests will run a set of tests to verify that the provided repo
-// is a valid Repo implementation.
-func RunRepoTests(createRepoFunc func() Repo) {
+// is a valid task.Repo implementation. The createRepoFunc must return a repo
+// that reads and writes the same tasks, events, and dependencies each time
+// that it is called within a test.
+func RunRepoTests(createRepoFunc func() taskpkg.Repo) {
 	var (
-		repo                   Repo
-		taskA, taskB, taskC    *Task
-		eventA, eventB, eventC *Event
+		repo                                  taskpkg.Repo
+		taskA, taskB, taskC                   *taskpkg.Task
+		eventA, eventB, eventC                *taskpkg.Event
+		dependencyA, dependencyB, dependencyC *taskpkg.Dependency
 	)
 	BeforeEach(func() {
 		repo = createRepoFunc()
 
-		taskA = &Task{Name: "task-a"}
-		taskB = &Task{Name: "task-b"}
-		taskC = &Task{Name: "task-c"}
-
-		eventA = &Event{Title: "event-a"}
-		eventB = &Event{Title: "event-b"}
-		eventC = &Event{Title: "event-c"}
+		taskA = &taskpkg.Task{Name: "task-a"}
+		taskB = &taskpkg.Task{Name: "task-b", Deadline: 1545778200}
+		taskC = &taskpkg.Task{Name: "task-c", Tags: []string{"tag-a", "tag-b"}}
+
+		eventA = &taskpkg.Event{Title: "event-a", Type: taskpkg.EventTypeDelete, Snapshot: `{"name":"task-a"}`}
+		eventB = &taskpkg.Event{
+			Title:    "event-b",
+			Type:     taskpkg.EventTypeSetState,
+			OldValue: "Ready",
+			NewValue: "Running",
+			Actor:    "some-user",
+		}
+		cause, reverts := 10, 0
+		eventC = &taskpkg.Event{
+			Title:   "event-c",
+			Type:    taskpkg.EventTypeNote,
+			Note:    "some note",
+			Cause:   &cause,
+			Reverts: &reverts,
+		}
+
+		dependencyA = &taskpkg.Dependency{TaskID: 1, DependsOnID: 2}
+		dependencyB = &taskpkg.Dependency{TaskID: 1, DependsOnID: 3}
+		dependencyC = &taskpkg.Dependency{TaskID: 2, DependsOnID: 3}
 	})
 
 	Describe("CreateTask", func() {
@@ -46,6 +70,28 @@ func RunRepoTests(createRepoFunc func() Repo) {
 				Expect(tasks[1].ID).NotTo(Equal(tasks[2].ID))
 				Expect(tasks[2].ID).NotTo(Equal(tasks[0].ID))
 			})
+			It("sets the ID on the task that it was given", func() {
+				task, err := repo.FindTaskByID(taskB.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(task).To(Equal(taskB))
+			})
+			It("sets the version of each to 1", func() {
+				Expect(taskA.Version).To(Equal(1))
+				Expect(taskB.Version).To(Equal(1))
+				Expect(taskC.Version).To(Equal(1))
+			})
+		})
+		Context("when a task has an owner", func() {
+			It("stores the owner and the users with whom it is shared", func() {
+				taskD := &taskpkg.Task{Name: "task-d", Owner: "user-a", SharedWith: []string{"user-b", "user-c"}}
+				Expect(repo.CreateTask(taskD)).To(Succeed())
+				Expect(repo.FindTaskByID(taskD.ID)).To(Equal(taskD))
+
+				taskD.Owner = "user-b"
+				taskD.SharedWith = nil
+				Expect(repo.UpdateTask(taskD)).To(Succeed())
+				Expect(repo.FindTaskByID(taskD.ID)).To(Equal(taskD))
+			})
 		})
 		Context("when a task with that ID already exists", func() {
 			BeforeEach(func() {
@@ -91,10 +137,43 @@ func RunRepoTests(createRepoFunc func() Repo) {
 			It("returns then with Tasks()", func() {
 				tasks, err := repo.Tasks()
 				Expect(err).NotTo(HaveOccurred())
+				Expect(tasks).To(HaveLen(3))
 				Expect(*tasks[0]).To(Equal(*taskA))
 				Expect(*tasks[1]).To(Equal(*taskB))
 				Expect(*tasks[2]).To(Equal(*taskC))
 			})
+			It("keeps the tasks in the order in which they were created", func() {
+				newTaskA := *taskA
+				newTaskA.Priority = 20
+				Expect(repo.UpdateTask(&newTaskA)).To(Succeed())
+				Expect(repo.DeleteTask(taskB)).To(Succeed())
+
+				tasks, err := repo.Tasks()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tasks).To(Equal([]*taskpkg.Task{&newTaskA, taskC}))
+			})
+			It("returns copies of the tasks, which can be changed without changing the repo", func() {
+				tasks, err := repo.Tasks()
+				Expect(err).NotTo(HaveOccurred())
+				tasks[2].Tags[0] = "tag-z"
+
+				task, err := repo.FindTaskByID(taskA.ID)
+				Expect(err).NotTo(HaveOccurred())
+				task.Priority = 20
+
+				task, err = repo.FindTaskByName("task-b")
+				Expect(err).NotTo(HaveOccurred())
+				task.Name = "task-z"
+
+				taskC.Tags[1] = "tag-z"
+
+				tasks, err = repo.Tasks()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tasks).To(HaveLen(3))
+				Expect(tasks[0].Priority).To(BeZero())
+				Expect(tasks[1].Name).To(Equal("task-b"))
+				Expect(tasks[2].Tags).To(Equal([]string{"tag-a", "tag-b"}))
+			})
 		})
 	})
 
@@ -161,6 +240,14 @@ func RunRepoTests(createRepoFunc func() Repo) {
 				taskB.ID = 999
 				Expect(repo.UpdateTask(taskB)).NotTo(Succeed())
 			})
+			It("does not create the task", func() {
+				taskB.ID = 999
+				Expect(repo.UpdateTask(taskB)).NotTo(Succeed())
+
+				tasks, err := repo.Tasks()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tasks).To(Equal([]*taskpkg.Task{taskA, taskC}))
+			})
 		})
 
 		Context("when the task exists", func() {
@@ -193,6 +280,21 @@ func RunRepoTests(createRepoFunc func() Repo) {
 				Expect(tasks).To(HaveLen(2))
 			})
 
+			It("increments the version of the task each time that it is updated", func() {
+				newTaskB := *taskB
+				newTaskB.Priority = 5
+				Expect(repo.UpdateTask(&newTaskB)).To(Succeed())
+				Expect(newTaskB.Version).To(Equal(2))
+
+				newTaskB.Priority = 6
+				Expect(repo.UpdateTask(&newTaskB)).To(Succeed())
+				Expect(newTaskB.Version).To(Equal(3))
+
+				task, err := repo.FindTaskByID(taskB.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(task.Version).To(Equal(3))
+			})
+
 			Context("when the task is updated with the same data", func() {
 				It("succeeds", func() {
 					Expect(repo.UpdateTask(taskA)).To(Succeed())
@@ -226,17 +328,25 @@ func RunRepoTests(createRepoFunc func() Repo) {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(task).To(BeNil())
 
-				task, err = repo.FindTaskByID(2)
+				task, err = repo.FindTaskByID(taskB.ID)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(task).To(BeNil())
 			})
+			It("succeeds when the task is deleted again", func() {
+				Expect(repo.DeleteTask(taskB)).To(Succeed())
+				Expect(repo.DeleteTask(taskB)).To(Succeed())
+
+				tasks, err := repo.Tasks()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tasks).To(Equal([]*taskpkg.Task{taskA}))
+			})
 		})
 	})
 
 	Describe("CreateEvent", func() {
 		Context("when events are created", func() {
 			BeforeEach(func() {
-				task := Task{Name: "task"}
+				task := taskpkg.Task{Name: "task"}
 				Expect(repo.CreateTask(&task)).To(Succeed())
 
 				eventA.TaskID = task.ID
@@ -256,7 +366,7 @@ func RunRepoTests(createRepoFunc func() Repo) {
 		})
 		Context("when an event with that ID already exists", func() {
 			BeforeEach(func() {
-				task := Task{Name: "task"}
+				task := taskpkg.Task{Name: "task"}
 				Expect(repo.CreateTask(&task)).To(Succeed())
 
 				eventA.TaskID = task.ID
@@ -298,7 +408,7 @@ func RunRepoTests(createRepoFunc func() Repo) {
 		})
 		Context("events exist", func() {
 			BeforeEach(func() {
-				task := Task{Name: "task"}
+				task := taskpkg.Task{Name: "task"}
 				Expect(repo.CreateTask(&task)).To(Succeed())
 
 				eventA.TaskID = task.ID
@@ -321,7 +431,7 @@ func RunRepoTests(createRepoFunc func() Repo) {
 	Describe("FindEventByID", func() {
 		Context("when the event does not exist", func() {
 			BeforeEach(func() {
-				task := Task{Name: "task"}
+				task := taskpkg.Task{Name: "task"}
 				Expect(repo.CreateTask(&task)).To(Succeed())
 
 				eventA.TaskID = task.ID
@@ -338,7 +448,7 @@ func RunRepoTests(createRepoFunc func() Repo) {
 
 		Context("when the event exists", func() {
 			BeforeEach(func() {
-				task := Task{Name: "task"}
+				task := taskpkg.Task{Name: "task"}
 				Expect(repo.CreateTask(&task)).To(Succeed())
 
 				eventA.TaskID = task.ID
@@ -358,7 +468,7 @@ func RunRepoTests(createRepoFunc func() Repo) {
 	Describe("DeleteEvent", func() {
 		Context("when the event does not exist", func() {
 			BeforeEach(func() {
-				task := Task{Name: "task"}
+				task := taskpkg.Task{Name: "task"}
 				Expect(repo.CreateTask(&task)).To(Succeed())
 
 				eventA.TaskID = task.ID
@@ -374,7 +484,7 @@ func RunRepoTests(createRepoFunc func() Repo) {
 
 		Context("when the event exists", func() {
 			BeforeEach(func() {
-				task := Task{Name: "task"}
+				task := taskpkg.Task{Name: "task"}
 				Expect(repo.CreateTask(&task)).To(Succeed())
 
 				eventA.TaskID = task.ID
@@ -391,6 +501,185 @@ func RunRepoTests(createRepoFunc func() Repo) {
 				Expect(events).To(HaveLen(1))
 				Expect(*events[0]).To(Equal(*eventA))
 			})
+			It("succeeds when the event is deleted again", func() {
+				Expect(repo.DeleteEvent(eventA)).To(Succeed())
+				Expect(repo.DeleteEvent(eventA)).To(Succeed())
+
+				events, err := repo.Events()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(events).To(Equal([]*taskpkg.Event{eventB}))
+			})
+		})
+	})
+
+	Describe("EventsMatching", func() {
+		var ids func(*query.EventQuery) []int
+
+		BeforeEach(func() {
+			Expect(repo.CreateTask(taskA)).To(Succeed())
+			Expect(repo.CreateTask(taskB)).To(Succeed())
+
+			eventA.TaskID, eventA.Date = taskA.ID, 100
+			eventB.TaskID, eventB.Date = taskB.ID, 200
+			eventC.TaskID, eventC.Date = taskA.ID, 300
+			Expect(repo.CreateEvent(eventA)).To(Succeed())
+			Expect(repo.CreateEvent(eventB)).To(Succeed())
+			Expect(repo.CreateEvent(eventC)).To(Succeed())
+
+			ids = func(q *query.EventQuery) []int {
+				events, err := query.EventsMatching(repo, q)
+				ExpectWithOffset(1, err).NotTo(HaveOccurred())
+
+				ids := make([]int, len(events))
+				for i, e := range events {
+					ids[i] = e.ID
+				}
+				return ids
+			}
+		})
+
+		It("returns the events that match", func() {
+			events, err := query.EventsMatching(repo, &query.EventQuery{TaskID: &taskB.ID})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(*events[0]).To(Equal(*eventB))
+
+			Expect(ids(&query.EventQuery{TaskID: &taskA.ID})).To(Equal([]int{eventA.ID, eventC.ID}))
+			Expect(ids(&query.EventQuery{
+				Types: []taskpkg.EventType{taskpkg.EventTypeSetState, taskpkg.EventTypeNote},
+			})).To(Equal([]int{eventB.ID, eventC.ID}))
+			Expect(ids(&query.EventQuery{Since: 200})).To(Equal([]int{eventB.ID, eventC.ID}))
+			Expect(ids(&query.EventQuery{Until: 300})).To(Equal([]int{eventA.ID, eventB.ID}))
+		})
+
+		It("returns no events when none match", func() {
+			events, err := query.EventsMatching(repo, &query.EventQuery{Since: 1000})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(BeEmpty())
+		})
+
+		It("returns pages of events in order", func() {
+			q := &query.EventQuery{Limit: 2}
+			Expect(ids(q)).To(Equal([]int{eventA.ID, eventB.ID}))
+			q.Cursor = &eventB.ID
+			Expect(ids(q)).To(Equal([]int{eventC.ID}))
+
+			q = &query.EventQuery{Limit: 2, Order: query.OrderDescending}
+			Expect(ids(q)).To(Equal([]int{eventC.ID, eventB.ID}))
+			q.Cursor = &eventB.ID
+			Expect(ids(q)).To(Equal([]int{eventA.ID}))
+		})
+
+		It("pages through every event one at a time, whatever their IDs are", func() {
+			// Some repos give the first event the ID 0, which is a cursor like any other ID.
+			for _, order := range []query.Order{query.OrderAscending, query.OrderDescending} {
+				q := &query.EventQuery{Limit: 1, Order: order}
+				var paged []int
+				for page := 0; page < 4; page++ {
+					pageIDs := ids(q)
+					if len(pageIDs) == 0 {
+						break
+					}
+					paged = append(paged, pageIDs...)
+					q.Cursor = &pageIDs[0]
+				}
+
+				expected := []int{eventA.ID, eventB.ID, eventC.ID}
+				if order == query.OrderDescending {
+					expected = []int{eventC.ID, eventB.ID, eventA.ID}
+				}
+				Expect(paged).To(Equal(expected), "order %s", order)
+			}
+		})
+	})
+
+	Describe("CreateDependency", func() {
+		Context("when dependencies are created", func() {
+			BeforeEach(func() {
+				Expect(repo.CreateDependency(dependencyA)).To(Succeed())
+				Expect(repo.CreateDependency(dependencyB)).To(Succeed())
+				Expect(repo.CreateDependency(dependencyC)).To(Succeed())
+			})
+			It("returns them with Dependencies()", func() {
+				dependencies, err := repo.Dependencies()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependencies).To(HaveLen(3))
+				Expect(*dependencies[0]).To(Equal(*dependencyA))
+				Expect(*dependencies[1]).To(Equal(*dependencyB))
+				Expect(*dependencies[2]).To(Equal(*dependencyC))
+			})
+			It("gives each a unique ID", func() {
+				Expect(dependencyA.ID).NotTo(Equal(dependencyB.ID))
+				Expect(dependencyB.ID).NotTo(Equal(dependencyC.ID))
+				Expect(dependencyC.ID).NotTo(Equal(dependencyA.ID))
+			})
+		})
+	})
+
+	Describe("Dependencies", func() {
+		Context("when no dependencies exist", func() {
+			It("returns no dependencies", func() {
+				dependencies, err := repo.Dependencies()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependencies).To(HaveLen(0))
+			})
+		})
+	})
+
+	Describe("FindDependencyByID", func() {
+		BeforeEach(func() {
+			Expect(repo.CreateDependency(dependencyA)).To(Succeed())
+			Expect(repo.CreateDependency(dependencyB)).To(Succeed())
+		})
+
+		Context("when the dependency does not exist", func() {
+			It("returns nil and nil error", func() {
+				dependency, err := repo.FindDependencyByID(99)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependency).To(BeNil())
+			})
+		})
+
+		Context("when the dependency exists", func() {
+			It("returns the dependency", func() {
+				dependency, err := repo.FindDependencyByID(dependencyB.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependency).ToNot(BeNil())
+				Expect(*dependency).To(Equal(*dependencyB))
+			})
+		})
+	})
+
+	Describe("DeleteDependency", func() {
+		BeforeEach(func() {
+			Expect(repo.CreateDependency(dependencyA)).To(Succeed())
+			Expect(repo.CreateDependency(dependencyB)).To(Succeed())
+		})
+
+		Context("when the dependency does not exist", func() {
+			It("returns success since the dependency isn't there", func() {
+				dependencyC.ID = 999
+				Expect(repo.DeleteDependency(dependencyC)).To(Succeed())
+			})
+		})
+
+		Context("when the dependency exists", func() {
+			It("deletes the dependency", func() {
+				Expect(repo.DeleteDependency(dependencyA)).To(Succeed())
+
+				dependencies, err := repo.Dependencies()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependencies).To(HaveLen(1))
+				Expect(*dependencies[0]).To(Equal(*dependencyB))
+			})
+			It("succeeds when the dependency is deleted again", func() {
+				Expect(repo.DeleteDependency(dependencyA)).To(Succeed())
+				Expect(repo.DeleteDependency(dependencyA)).To(Succeed())
+
+				dependencies, err := repo.Dependencies()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependencies).To(Equal([]*taskpkg.Dependency{dependencyB}))
+			})
 		})
 	})
 
@@ -409,6 +698,15 @@ func RunRepoTests(createRepoFunc func() Repo) {
 				Expect(*tasks[1]).To(Equal(*taskB))
 				Expect(*tasks[2]).To(Equal(*taskC))
 			})
+			It("returns them from another repo with FindTaskByID() and FindTaskByName()", func() {
+				task, err := createRepoFunc().FindTaskByID(taskB.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(task).To(Equal(taskB))
+
+				task, err = createRepoFunc().FindTaskByName("task-c")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(task).To(Equal(taskC))
+			})
 			It("another repo makes new tasks with new IDs", func() {
 				repo2 := createRepoFunc()
 				anotherTaskA := *taskA
@@ -424,10 +722,11 @@ func RunRepoTests(createRepoFunc func() Repo) {
 			})
 
 			Context("when a task is updated with one repo", func() {
-				var newTaskB Task
+				var newTaskB taskpkg.Task
 				BeforeEach(func() {
 					newTaskB = *taskB
 					newTaskB.Name = "new-task-b"
+					newTaskB.Tags = []string{"tag-c"}
 					Expect(repo.UpdateTask(&newTaskB)).To(Succeed())
 				})
 				It("returns them from another repo with Tasks()", func() {
@@ -455,7 +754,7 @@ func RunRepoTests(createRepoFunc func() Repo) {
 		})
 		Context("when events are created with one repo", func() {
 			BeforeEach(func() {
-				task := Task{Name: "task"}
+				task := taskpkg.Task{Name: "task"}
 				Expect(repo.CreateTask(&task)).To(Succeed())
 
 				eventA.TaskID = task.ID
@@ -473,6 +772,11 @@ func RunRepoTests(createRepoFunc func() Repo) {
 				Expect(*events[1]).To(Equal(*eventB))
 				Expect(*events[2]).To(Equal(*eventC))
 			})
+			It("returns them from another repo with FindEventByID()", func() {
+				event, err := createRepoFunc().FindEventByID(eventB.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(event).To(Equal(eventB))
+			})
 			Context("when events are deleted with one repo", func() {
 				BeforeEach(func() {
 					Expect(repo.DeleteEvent(eventC)).To(Succeed())
@@ -487,5 +791,24 @@ func RunRepoTests(createRepoFunc func() Repo) {
 				})
 			})
 		})
+		Context("when dependencies are created with one repo", func() {
+			BeforeEach(func() {
+				Expect(repo.CreateDependency(dependencyA)).To(Succeed())
+				Expect(repo.CreateDependency(dependencyB)).To(Succeed())
+			})
+			It("returns them from another repo with FindDependencyByID()", func() {
+				dependency, err := createRepoFunc().FindDependencyByID(dependencyB.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependency).To(Equal(dependencyB))
+			})
+			It("returns them from another repo with Dependencies()", func() {
+				repo2 := createRepoFunc()
+				dependencies, err := repo2.Dependencies()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(dependencies).To(HaveLen(2))
+				Expect(*dependencies[0]).To(Equal(*dependencyA))
+				Expect(*dependencies[1]).To(Equal(*dependencyB))
+			})
+		})
 	})
 }
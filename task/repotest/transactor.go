@@ -0,0 +1,108 @@
+package repotest
+
+import (
+	"errors"
+
+	taskpkg "github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// RunTransactorTests will run a set of tests to verify that the provided repo is a valid
+// task.Transactor implementation. The createRepoFunc must return a repo that reads and writes the
+// same tasks, events, and dependencies each time that it is called within a test.
+func RunTransactorTests(createRepoFunc func() taskpkg.Repo) {
+	var (
+		repo       taskpkg.Repo
+		transactor taskpkg.Transactor
+		taskA      *taskpkg.Task
+	)
+	BeforeEach(func() {
+		repo = createRepoFunc()
+
+		var ok bool
+		transactor, ok = repo.(taskpkg.Transactor)
+		Expect(ok).To(BeTrue(), "repo is not a task.Transactor")
+
+		taskA = &taskpkg.Task{Name: "task-a"}
+		Expect(repo.CreateTask(taskA)).To(Succeed())
+	})
+
+	Context("when the function succeeds", func() {
+		It("keeps all of the changes", func() {
+			Expect(transactor.WithTx(func(tx taskpkg.Repo) error {
+				task, err := tx.FindTaskByName("task-a")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(task).To(Equal(taskA))
+
+				task.Priority = 5
+				if err := tx.UpdateTask(task); err != nil {
+					return err
+				}
+				if err := tx.CreateTask(&taskpkg.Task{Name: "task-b"}); err != nil {
+					return err
+				}
+				return tx.CreateEvent(&taskpkg.Event{Title: "event-a"})
+			})).To(Succeed())
+
+			repo = createRepoFunc()
+
+			tasks, err := repo.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(2))
+			Expect(tasks[0].Priority).To(Equal(5))
+			Expect(tasks[1].Name).To(Equal("task-b"))
+
+			events, err := repo.Events()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Title).To(Equal("event-a"))
+		})
+	})
+
+	Context("when the function fails", func() {
+		It("keeps none of the changes and returns the error", func() {
+			Expect(transactor.WithTx(func(tx taskpkg.Repo) error {
+				task, err := tx.FindTaskByName("task-a")
+				Expect(err).NotTo(HaveOccurred())
+
+				task.Priority = 5
+				if err := tx.UpdateTask(task); err != nil {
+					return err
+				}
+				if err := tx.CreateTask(&taskpkg.Task{Name: "task-b"}); err != nil {
+					return err
+				}
+				if err := tx.CreateEvent(&taskpkg.Event{Title: "event-a"}); err != nil {
+					return err
+				}
+				if err := tx.DeleteTask(taskA); err != nil {
+					return err
+				}
+				return errors.New("some tx error")
+			})).To(MatchError("some tx error"))
+
+			repo = createRepoFunc()
+
+			tasks, err := repo.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(Equal([]*taskpkg.Task{taskA}))
+
+			events, err := repo.Events()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(BeEmpty())
+		})
+
+		It("can still be changed afterwards", func() {
+			Expect(transactor.WithTx(func(tx taskpkg.Repo) error {
+				return errors.New("some tx error")
+			})).NotTo(Succeed())
+
+			Expect(repo.CreateTask(&taskpkg.Task{Name: "task-b"})).To(Succeed())
+
+			tasks, err := repo.Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(2))
+		})
+	})
+}
@@ -0,0 +1,217 @@
+package sql
+
+import (
+	"context"
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/task"
+)
+
+type contexts struct {
+	logger lager.Logger
+
+	db *DB
+
+	// These are the repos that have been returned from Repo, keyed by context, so that the tables
+	// are only migrated once for each context.
+	repos map[string]*repo
+	lock  sync.Mutex
+}
+
+// NewContexts returns a task.Contexts that stores the task.Task's of every context in the same
+// SQL database. Each row has a context column, and the contexts table holds the contexts that
+// exist. The task.Repo returned from New uses the task.DefaultContext.
+func NewContexts(logger lager.Logger, db *DB) task.Contexts {
+	return &contexts{logger: logger, db: db, repos: make(map[string]*repo)}
+}
+
+func (c *contexts) Repo(name string) (task.Repo, error) {
+	if err := task.ValidateContextName(name); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.repo(name), nil
+}
+
+func (c *contexts) Contexts() ([]string, error) {
+	logger := c.logger.Session("contexts")
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	if err := Migrate(logger, c.db, LatestSchemaVersion()); err != nil {
+		logger.Error("migrate", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	rows, err := c.db.Query(ctx, logger, `SELECT name FROM contexts ORDER BY name`)
+	if err != nil {
+		logger.Error("query", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := []string{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			logger.Error("scan", err)
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("rows", err)
+		return nil, err
+	}
+
+	return names, nil
+}
+
+func (c *contexts) CreateContext(name string) error {
+	logger := c.logger.Session("create-context", lager.Data{"name": name})
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	if err := task.ValidateContextName(name); err != nil {
+		return err
+	}
+
+	if err := Migrate(logger, c.db, LatestSchemaVersion()); err != nil {
+		logger.Error("migrate", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	if exists, err := contextExists(ctx, logger, c.db, name); err != nil {
+		logger.Error("context-exists", err)
+		return err
+	} else if exists {
+		return &task.DuplicateContextError{Name: name}
+	}
+
+	if _, err := c.db.Exec(ctx, logger, `INSERT INTO contexts (name) VALUES (?)`, name); err != nil {
+		logger.Error("insert", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *contexts) CopyContext(from, to string) error {
+	logger := c.logger.Session("copy-context", lager.Data{"from": from, "to": to})
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	if err := task.ValidateContextName(from); err != nil {
+		return err
+	}
+
+	if err := c.ensureExists(logger, from); err != nil {
+		return err
+	}
+
+	if err := c.CreateContext(to); err != nil {
+		return err
+	}
+
+	fromRepo, err := c.Repo(from)
+	if err != nil {
+		return err
+	}
+	toRepo, err := c.Repo(to)
+	if err != nil {
+		return err
+	}
+
+	// Since the IDs of the rows are shared between contexts, the copies get new IDs.
+	if err := task.CopyRepo(fromRepo, toRepo); err != nil {
+		logger.Error("copy-repo", err)
+		return err
+	}
+
+	return nil
+}
+
+func (c *contexts) DeleteContext(name string) error {
+	logger := c.logger.Session("delete-context", lager.Data{"name": name})
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	if err := task.ValidateContextName(name); err != nil {
+		return err
+	}
+
+	if err := c.ensureExists(logger, name); err != nil {
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	for _, q := range []string{
+		`DELETE FROM task_tags WHERE task_id IN (SELECT id FROM tasks WHERE context = ?)`,
+		`DELETE FROM tasks WHERE context = ?`,
+		`DELETE FROM events WHERE context = ?`,
+		`DELETE FROM dependencies WHERE context = ?`,
+		`DELETE FROM webhooks WHERE context = ?`,
+		`DELETE FROM dead_letters WHERE context = ?`,
+		`DELETE FROM contexts WHERE name = ?`,
+	} {
+		if _, err := c.db.Exec(ctx, logger, q, name); err != nil {
+			logger.Error("exec", err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ensureExists returns an *task.UnknownContextError if a context does not exist.
+func (c *contexts) ensureExists(logger lager.Logger, name string) error {
+	if err := Migrate(logger, c.db, LatestSchemaVersion()); err != nil {
+		logger.Error("migrate", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	exists, err := contextExists(ctx, logger, c.db, name)
+	if err != nil {
+		logger.Error("context-exists", err)
+		return err
+	} else if !exists {
+		return &task.UnknownContextError{Name: name}
+	}
+
+	return nil
+}
+
+// repo returns the repo for a context. The lock must be held.
+func (c *contexts) repo(name string) *repo {
+	r, ok := c.repos[name]
+	if !ok {
+		r = &repo{logger: c.logger, db: c.db, context: name}
+		c.repos[name] = r
+	}
+	return r
+}
+
+// contextExists returns whether a context is in the contexts table.
+func contextExists(ctx context.Context, logger lager.Logger, db *DB, name string) (bool, error) {
+	var count int
+	q := `SELECT COUNT(*) FROM contexts WHERE name = ?`
+	if err := db.QueryRow(ctx, logger, q, name).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
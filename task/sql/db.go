@@ -3,7 +3,6 @@ package sql
 import (
 	"context"
 	stdlibsql "database/sql"
-	"time"
 
 	"code.cloudfoundry.org/lager"
 )
@@ -11,22 +10,71 @@ import (
 // DB is a dumb wrapper around a stdlib sql.DB.
 //
 // All of its functions simply log what they are doing and then call down
-// to the corresponding stdlib sql.DB function.
+// to the corresponding stdlib sql.DB function. Queries use ? placeholders,
+// which are rewritten into the placeholders of the dialect of the DB.
+//
+// A DB returned from Begin makes all of its queries in a transaction.
 type DB struct {
-	db *stdlibsql.DB
+	db      *stdlibsql.DB
+	dialect dialect
+
+	// This is either the stdlib sql.DB or, in a transaction, the stdlib sql.Tx.
+	conn conn
+	tx   *stdlibsql.Tx
+}
+
+// A conn is what a stdlib sql.DB and a stdlib sql.Tx have in common.
+type conn interface {
+	ExecContext(context.Context, string, ...interface{}) (stdlibsql.Result, error)
+	QueryContext(context.Context, string, ...interface{}) (*stdlibsql.Rows, error)
+	QueryRowContext(context.Context, string, ...interface{}) *stdlibsql.Row
+	PrepareContext(context.Context, string) (*stdlibsql.Stmt, error)
 }
 
 // Open creates a DB via a driverName and a dataSourceName. It calls the stdlib
 // sql.Open function.
+//
+// The driverName also decides the dialect of SQL that is used with the DB. The supported
+// driverName's are "mysql" (e.g., github.com/go-sql-driver/mysql), "postgres" (e.g.,
+// github.com/lib/pq), and "sqlite" (e.g., modernc.org/sqlite). The driver must be imported by
+// the caller.
 func Open(driverName, dataSourceName string) (*DB, error) {
+	dialect, err := findDialect(driverName)
+	if err != nil {
+		return nil, err
+	}
+
 	db, err := stdlibsql.Open(driverName, dataSourceName)
 	if err != nil {
 		return nil, err
 	}
 
-	db.SetConnMaxLifetime(time.Second)
+	dialect.configure(db)
+
+	return &DB{db: db, dialect: dialect, conn: db}, nil
+}
+
+// Begin starts a transaction. The returned DB makes its queries in the transaction until Commit or
+// Rollback is called on it.
+func (db *DB) Begin(ctx context.Context, logger lager.Logger) (*DB, error) {
+	logger.Debug("begin")
+
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DB{db: db.db, dialect: db.dialect, conn: tx, tx: tx}, nil
+}
+
+func (db *DB) Commit(logger lager.Logger) error {
+	logger.Debug("commit")
+	return db.tx.Commit()
+}
 
-	return &DB{db: db}, nil
+func (db *DB) Rollback(logger lager.Logger) error {
+	logger.Debug("rollback")
+	return db.tx.Rollback()
 }
 
 func (db *DB) Exec(
@@ -37,11 +85,7 @@ func (db *DB) Exec(
 ) (stdlibsql.Result, error) {
 	logger.Debug("exec", lager.Data{"query": query, "args": args})
 
-	if args == nil {
-		return db.db.ExecContext(ctx, query)
-	} else {
-		return db.db.ExecContext(ctx, query, args)
-	}
+	return db.conn.ExecContext(ctx, db.dialect.bind(query), args...)
 }
 
 func (db *DB) Query(
@@ -52,11 +96,7 @@ func (db *DB) Query(
 ) (*stdlibsql.Rows, error) {
 	logger.Debug("query", lager.Data{"query": query, "args": args})
 
-	if args == nil {
-		return db.db.QueryContext(ctx, query)
-	} else {
-		return db.db.QueryContext(ctx, query, args)
-	}
+	return db.conn.QueryContext(ctx, db.dialect.bind(query), args...)
 }
 
 func (db *DB) QueryRow(
@@ -67,11 +107,7 @@ func (db *DB) QueryRow(
 ) *stdlibsql.Row {
 	logger.Debug("query", lager.Data{"query-row": query, "args": args})
 
-	if args == nil {
-		return db.db.QueryRowContext(ctx, query)
-	} else {
-		return db.db.QueryRowContext(ctx, query, args...)
-	}
+	return db.conn.QueryRowContext(ctx, db.dialect.bind(query), args...)
 }
 
 func (db *DB) Close(logger lager.Logger) error {
@@ -86,7 +122,7 @@ func (db *DB) Prepare(
 ) (*stmt, error) {
 	logger.Debug("prepare", lager.Data{"query": query})
 
-	s, err := db.db.PrepareContext(ctx, query)
+	s, err := db.conn.PrepareContext(ctx, db.dialect.bind(query))
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +144,16 @@ func (s *stmt) Exec(
 	return s.stmt.ExecContext(ctx, args...)
 }
 
+func (s *stmt) Query(
+	ctx context.Context,
+	logger lager.Logger,
+	args ...interface{},
+) (*stdlibsql.Rows, error) {
+	logger.Debug("query", lager.Data{"args": args})
+
+	return s.stmt.QueryContext(ctx, args...)
+}
+
 func (s *stmt) Close(logger lager.Logger) error {
 	logger.Debug("close")
 	return s.stmt.Close()
@@ -0,0 +1,207 @@
+package sql
+
+import (
+	stdlibsql "database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A dialect is the flavor of SQL spoken by a database. The repo only uses SQL that every dialect
+// understands, except for what a dialect provides here.
+type dialect interface {
+	// configure sets up the connection pool of a stdlib sql.DB that speaks the dialect.
+	configure(db *stdlibsql.DB)
+
+	// idColumn is the definition of an integer primary key column that is assigned automatically.
+	idColumn() string
+
+	// columnExistsQuery returns a query for the number of columns with the name given by its only
+	// placeholder in a table.
+	columnExistsQuery(table string) string
+
+	// dropIndexStatement returns a statement that drops an index from a table.
+	dropIndexStatement(table, index string) string
+
+	// textType is the type of a column that holds text that may be longer than a varchar.
+	textType() string
+
+	// likeEscape is what follows a LIKE pattern so that a backslash escapes the next character.
+	likeEscape() string
+
+	// bind rewrites the ? placeholders in a query into the placeholders of the dialect.
+	bind(query string) string
+
+	// returningID reports whether an INSERT statement can end with "RETURNING id" to return the
+	// ID of the row that it inserted. Otherwise, the ID is the sql.Result's LastInsertId.
+	returningID() bool
+
+	// transactionalDDL reports whether the statements that change the schema (e.g., CREATE TABLE)
+	// can be rolled back in a transaction. Otherwise, each of them is committed as soon as it runs.
+	transactionalDDL() bool
+}
+
+// dialects are the dialect's that are supported, keyed by the name of their database/sql driver.
+var dialects = map[string]dialect{
+	"mysql":    mysqlDialect{},
+	"postgres": postgresDialect{},
+	"sqlite":   sqliteDialect{},
+}
+
+func findDialect(driverName string) (dialect, error) {
+	d, ok := dialects[driverName]
+	if !ok {
+		return nil, fmt.Errorf("unsupported driver: '%s'", driverName)
+	}
+	return d, nil
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) configure(db *stdlibsql.DB) {
+	db.SetConnMaxLifetime(time.Second)
+}
+
+func (mysqlDialect) idColumn() string {
+	return "id int NOT NULL PRIMARY KEY AUTO_INCREMENT"
+}
+
+func (mysqlDialect) columnExistsQuery(table string) string {
+	return fmt.Sprintf(`
+SELECT COUNT(*) FROM information_schema.columns
+WHERE table_schema = DATABASE() AND table_name = '%s' AND column_name = ?
+`, table)
+}
+
+func (mysqlDialect) dropIndexStatement(table, index string) string {
+	return fmt.Sprintf("DROP INDEX %s ON %s", index, table)
+}
+
+func (mysqlDialect) textType() string {
+	return "mediumtext"
+}
+
+func (mysqlDialect) likeEscape() string {
+	// MySQL escapes LIKE patterns with a backslash by default.
+	return ""
+}
+
+func (mysqlDialect) bind(query string) string {
+	return query
+}
+
+func (mysqlDialect) returningID() bool {
+	return false
+}
+
+func (mysqlDialect) transactionalDDL() bool {
+	return false
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) configure(db *stdlibsql.DB) {
+	db.SetConnMaxLifetime(time.Second)
+}
+
+func (postgresDialect) idColumn() string {
+	return "id SERIAL PRIMARY KEY"
+}
+
+func (postgresDialect) columnExistsQuery(table string) string {
+	return fmt.Sprintf(`
+SELECT COUNT(*) FROM information_schema.columns
+WHERE table_schema = current_schema() AND table_name = '%s' AND column_name = ?
+`, table)
+}
+
+func (postgresDialect) dropIndexStatement(table, index string) string {
+	return "DROP INDEX " + index
+}
+
+func (postgresDialect) textType() string {
+	return "text"
+}
+
+func (postgresDialect) likeEscape() string {
+	// PostgreSQL escapes LIKE patterns with a backslash by default.
+	return ""
+}
+
+func (postgresDialect) bind(query string) string {
+	return bindNumbered(query)
+}
+
+func (postgresDialect) returningID() bool {
+	return true
+}
+
+func (postgresDialect) transactionalDDL() bool {
+	return true
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) configure(db *stdlibsql.DB) {
+	// A SQLite database is a single file (or, for ":memory:", a single connection), so share one
+	// connection that is never closed.
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+}
+
+func (sqliteDialect) idColumn() string {
+	// AUTOINCREMENT keeps SQLite from reusing the IDs of deleted rows.
+	return "id INTEGER PRIMARY KEY AUTOINCREMENT"
+}
+
+func (sqliteDialect) columnExistsQuery(table string) string {
+	return fmt.Sprintf(`SELECT COUNT(*) FROM pragma_table_info('%s') WHERE name = ?`, table)
+}
+
+func (sqliteDialect) dropIndexStatement(table, index string) string {
+	return "DROP INDEX " + index
+}
+
+func (sqliteDialect) textType() string {
+	return "text"
+}
+
+func (sqliteDialect) likeEscape() string {
+	return ` ESCAPE '\'`
+}
+
+func (sqliteDialect) bind(query string) string {
+	return query
+}
+
+func (sqliteDialect) returningID() bool {
+	return true
+}
+
+func (sqliteDialect) transactionalDDL() bool {
+	return true
+}
+
+// bindNumbered rewrites the ? placeholders in a query into $1, $2, etc. A ? inside of a quoted
+// string is left alone.
+func bindNumbered(query string) string {
+	var b strings.Builder
+	n := 0
+	quote := rune(0)
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,577 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/task"
+)
+
+// A migration changes the schema of the database from one version to the next (up), or back
+// again (down).
+type migration struct {
+	name string
+	up   func(ctx context.Context, logger lager.Logger, db *DB) error
+	down func(ctx context.Context, logger lager.Logger, db *DB) error
+}
+
+// migrationTimeout is how long a migration may take. A migration may change every row of a table
+// (e.g., see upgradeEvents), so it gets much longer than a query (see makeCtx).
+const migrationTimeout = time.Minute * 10
+
+// migrations are the changes that have been made to the schema of the database, in order. The
+// version of the schema after a migration is applied is the index of the migration plus one, so
+// migrations must only ever be added to the end of this list.
+var migrations = []migration{
+	{
+		// The tables were created before there were migrations, so this migration also upgrades
+		// the events tables that were created by older versions of this package (see createTables).
+		// Their tasks tables are upgraded by add-task-deadlines.
+		name: "create-tables",
+		up:   createTables,
+		down: statements(func(d dialect) []string {
+			return []string{
+				"DROP TABLE task_tags",
+				"DROP TABLE dependencies",
+				"DROP TABLE events",
+				"DROP TABLE tasks",
+			}
+		}),
+	},
+	{
+		name: "add-indexes",
+		up: statements(func(d dialect) []string {
+			return []string{
+				"CREATE INDEX tasks_name_index ON tasks (name)",
+				"CREATE INDEX events_task_id_index ON events (task_id)",
+				"CREATE INDEX dependencies_task_id_index ON dependencies (task_id)",
+			}
+		}),
+		down: statements(func(d dialect) []string {
+			return []string{
+				d.dropIndexStatement("dependencies", "dependencies_task_id_index"),
+				d.dropIndexStatement("events", "events_task_id_index"),
+				d.dropIndexStatement("tasks", "tasks_name_index"),
+			}
+		}),
+	},
+	{
+		// The tasks, events, and dependencies that existed before there were contexts are moved
+		// to the task.DefaultContext.
+		name: "add-contexts",
+		up: statements(func(d dialect) []string {
+			column := "context varchar(255) NOT NULL DEFAULT '" + task.DefaultContext + "'"
+			return []string{
+				"CREATE TABLE contexts (name varchar(255) NOT NULL PRIMARY KEY)",
+				"ALTER TABLE tasks ADD COLUMN " + column,
+				"ALTER TABLE events ADD COLUMN " + column,
+				"ALTER TABLE dependencies ADD COLUMN " + column,
+				"CREATE INDEX tasks_context_index ON tasks (context)",
+				"CREATE INDEX events_context_index ON events (context)",
+				"CREATE INDEX dependencies_context_index ON dependencies (context)",
+				`
+INSERT INTO contexts (name)
+SELECT context FROM tasks UNION SELECT context FROM events UNION SELECT context FROM dependencies
+`,
+			}
+		}),
+		down: statements(func(d dialect) []string {
+			return []string{
+				d.dropIndexStatement("dependencies", "dependencies_context_index"),
+				d.dropIndexStatement("events", "events_context_index"),
+				d.dropIndexStatement("tasks", "tasks_context_index"),
+				"ALTER TABLE dependencies DROP COLUMN context",
+				"ALTER TABLE events DROP COLUMN context",
+				"ALTER TABLE tasks DROP COLUMN context",
+				"DROP TABLE contexts",
+			}
+		}),
+	},
+	{
+		// The event of a dead letter is stored as JSON, since it may have been deleted from the
+		// events table.
+		name: "add-webhooks",
+		up: statements(func(d dialect) []string {
+			return []string{
+				`
+CREATE TABLE webhooks (
+  ` + d.idColumn() + `,
+  context varchar(255) NOT NULL,
+  url varchar(1024) NOT NULL,
+  event_types varchar(255) NOT NULL DEFAULT '',
+  states varchar(255) NOT NULL DEFAULT '',
+  secret varchar(255) NOT NULL
+)
+`,
+				`
+CREATE TABLE dead_letters (
+  ` + d.idColumn() + `,
+  context varchar(255) NOT NULL,
+  webhook_id int NOT NULL,
+  url varchar(1024) NOT NULL,
+  event ` + d.textType() + ` NOT NULL,
+  attempts int NOT NULL,
+  error varchar(1024) NOT NULL,
+  date bigint NOT NULL
+)
+`,
+				"CREATE INDEX webhooks_context_index ON webhooks (context)",
+				"CREATE INDEX dead_letters_context_index ON dead_letters (context)",
+			}
+		}),
+		down: statements(func(d dialect) []string {
+			return []string{
+				"DROP TABLE dead_letters",
+				"DROP TABLE webhooks",
+			}
+		}),
+	},
+	{
+		// These let the events of a context be found by their date or type without reading all of
+		// them; see EventsMatching.
+		name: "add-event-indexes",
+		up: statements(func(d dialect) []string {
+			return []string{
+				"CREATE INDEX events_context_date_index ON events (context, date)",
+				"CREATE INDEX events_context_type_index ON events (context, type)",
+			}
+		}),
+		down: statements(func(d dialect) []string {
+			return []string{
+				d.dropIndexStatement("events", "events_context_type_index"),
+				d.dropIndexStatement("events", "events_context_date_index"),
+			}
+		}),
+	},
+	{
+		// Existing tasks start at the version that new tasks are created with.
+		name: "add-task-versions",
+		up: statements(func(d dialect) []string {
+			return []string{"ALTER TABLE tasks ADD COLUMN version int NOT NULL DEFAULT 1"}
+		}),
+		down: statements(func(d dialect) []string {
+			return []string{"ALTER TABLE tasks DROP COLUMN version"}
+		}),
+	},
+	{
+		// The users are not in a context, since everyone logs in to the API as the same user
+		// whichever context they use. Existing tasks have no owner, so everyone can still see them.
+		name: "add-users",
+		up: statements(func(d dialect) []string {
+			return []string{
+				`
+CREATE TABLE users (
+  name varchar(255) NOT NULL PRIMARY KEY,
+  password_hash varchar(255) NOT NULL
+)
+`,
+				"ALTER TABLE tasks ADD COLUMN owner varchar(255) NOT NULL DEFAULT ''",
+				"ALTER TABLE tasks ADD COLUMN shared_with varchar(1024) NOT NULL DEFAULT ''",
+			}
+		}),
+		down: statements(func(d dialect) []string {
+			return []string{
+				"ALTER TABLE tasks DROP COLUMN shared_with",
+				"ALTER TABLE tasks DROP COLUMN owner",
+				"DROP TABLE users",
+			}
+		}),
+	},
+	{
+		// Like the users, the sessions are not in a context. The expiry is indexed, since the
+		// expired sessions are deleted periodically.
+		name: "add-sessions",
+		up: statements(func(d dialect) []string {
+			return []string{
+				`
+CREATE TABLE sessions (
+  id varchar(255) NOT NULL PRIMARY KEY,
+  subject varchar(255) NOT NULL,
+  issued_at bigint NOT NULL,
+  expiry bigint NOT NULL
+)
+`,
+				"CREATE INDEX sessions_expiry_index ON sessions (expiry)",
+			}
+		}),
+		down: statements(func(d dialect) []string {
+			return []string{
+				"DROP TABLE sessions",
+			}
+		}),
+	},
+	{
+		// Existing webhooks and dead letters have no owner, like the tasks that existed before
+		// there were users.
+		name: "add-webhook-owners",
+		up: statements(func(d dialect) []string {
+			return []string{
+				"ALTER TABLE webhooks ADD COLUMN owner varchar(255) NOT NULL DEFAULT ''",
+				"ALTER TABLE dead_letters ADD COLUMN owner varchar(255) NOT NULL DEFAULT ''",
+			}
+		}),
+		down: statements(func(d dialect) []string {
+			return []string{
+				"ALTER TABLE dead_letters DROP COLUMN owner",
+				"ALTER TABLE webhooks DROP COLUMN owner",
+			}
+		}),
+	},
+	{
+		// The deadline column is in the tasks table that create-tables creates, but not in the
+		// ones that were created by older versions of this package.
+		name: "add-task-deadlines",
+		up: func(ctx context.Context, logger lager.Logger, db *DB) error {
+			_, err := addColumns(ctx, logger, db, "tasks", "deadline bigint NOT NULL DEFAULT 0")
+			return err
+		},
+		// The column is kept, since create-tables creates it.
+		down: func(ctx context.Context, logger lager.Logger, db *DB) error {
+			return nil
+		},
+	},
+}
+
+// LatestSchemaVersion returns the version of the schema of the database that the task.Repo
+// returned from New uses.
+func LatestSchemaVersion() int {
+	return len(migrations)
+}
+
+// SchemaVersion returns the version of the schema of the database. A database without any
+// tables has version 0.
+func SchemaVersion(logger lager.Logger, db *DB) (int, error) {
+	logger = logger.Session("schema-version")
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	if err := createSchemaVersionTable(ctx, logger, db); err != nil {
+		logger.Error("create-schema-version-table", err)
+		return 0, err
+	}
+
+	return schemaVersion(ctx, logger, db)
+}
+
+// Migrate applies or reverts migrations until the schema of the database is at the provided
+// version. The version of the schema is stored in the schema_version table.
+//
+// The task.Repo returned from New migrates the database to the LatestSchemaVersion the first time
+// that it is used, so Migrate only needs to be called to revert migrations.
+func Migrate(logger lager.Logger, db *DB, version int) error {
+	logger = logger.Session("migrate", lager.Data{"version": version})
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	if version < 0 || version > LatestSchemaVersion() {
+		return fmt.Errorf(
+			"unknown schema version %d (the latest version is %d)",
+			version,
+			LatestSchemaVersion(),
+		)
+	}
+
+	current, err := SchemaVersion(logger, db)
+	if err != nil {
+		return err
+	} else if current > LatestSchemaVersion() {
+		return fmt.Errorf(
+			"schema version %d is newer than the latest version %d",
+			current,
+			LatestSchemaVersion(),
+		)
+	}
+
+	for ; current < version; current++ {
+		if err := migrate(logger, db, current+1, true); err != nil {
+			return err
+		}
+	}
+
+	for ; current > version; current-- {
+		if err := migrate(logger, db, current, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrate applies (up) or reverts (down) the migration that results in the provided schema
+// version, and records the new version of the schema. If the dialect can roll back changes to the
+// schema, both are done in one transaction, so that a migration that fails part of the way through
+// can be run again.
+func migrate(logger lager.Logger, db *DB, version int, up bool) error {
+	m := migrations[version-1]
+	logger = logger.Session("migration", lager.Data{"version": version, "name": m.name, "up": up})
+	logger.Info("begin")
+	defer logger.Info("end")
+
+	ctx, cancel := context.WithTimeout(context.Background(), migrationTimeout)
+	defer cancel()
+
+	var err error
+	if db.dialect.transactionalDDL() {
+		var tx *DB
+		if tx, err = db.Begin(ctx, logger); err == nil {
+			if err = runMigration(ctx, logger, tx, m, version, up); err != nil {
+				if rollbackErr := tx.Rollback(logger); rollbackErr != nil {
+					logger.Error("rollback", rollbackErr)
+				}
+			} else {
+				err = tx.Commit(logger)
+			}
+		}
+	} else {
+		err = runMigration(ctx, logger, db, m, version, up)
+	}
+	if err != nil {
+		logger.Error("failed", err)
+		return fmt.Errorf("migration %d ('%s') failed: %s", version, m.name, err.Error())
+	}
+
+	return nil
+}
+
+// runMigration applies or reverts a migration and records the new version of the schema.
+func runMigration(
+	ctx context.Context,
+	logger lager.Logger,
+	db *DB,
+	m migration,
+	version int,
+	up bool,
+) error {
+	var err error
+	if up {
+		err = m.up(ctx, logger, db)
+		if err == nil {
+			q := `INSERT INTO schema_version (version, name, date) VALUES (?, ?, ?)`
+			_, err = db.Exec(ctx, logger, q, version, m.name, time.Now().Unix())
+		}
+	} else {
+		err = m.down(ctx, logger, db)
+		if err == nil {
+			_, err = db.Exec(ctx, logger, `DELETE FROM schema_version WHERE version = ?`, version)
+		}
+	}
+	return err
+}
+
+func createSchemaVersionTable(ctx context.Context, logger lager.Logger, db *DB) error {
+	q := `
+CREATE TABLE IF NOT EXISTS schema_version (
+  version int NOT NULL PRIMARY KEY,
+  name varchar(255) NOT NULL,
+  date bigint NOT NULL
+)
+`
+	_, err := db.Exec(ctx, logger, q)
+	return err
+}
+
+func schemaVersion(ctx context.Context, logger lager.Logger, db *DB) (int, error) {
+	var version int
+	q := `SELECT COALESCE(MAX(version), 0) FROM schema_version`
+	if err := db.QueryRow(ctx, logger, q).Scan(&version); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// statements returns a migration function that executes the statements of a dialect, in order.
+func statements(
+	f func(d dialect) []string,
+) func(ctx context.Context, logger lager.Logger, db *DB) error {
+	return func(ctx context.Context, logger lager.Logger, db *DB) error {
+		for _, q := range f(db.dialect) {
+			if _, err := db.Exec(ctx, logger, q); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func createTables(ctx context.Context, logger lager.Logger, db *DB) error {
+	id := db.dialect.idColumn()
+	tables := []struct{ name, q string }{
+		{"tasks", `
+CREATE TABLE IF NOT EXISTS tasks (
+  ` + id + `,
+  name varchar(255) NOT NULL,
+  start_date bigint NOT NULL,
+  priority int NOT NULL,
+  state varchar(16) NOT NULL,
+  deadline bigint NOT NULL DEFAULT 0
+)
+`},
+		{"events", `
+CREATE TABLE IF NOT EXISTS events (
+  ` + id + `,
+  title varchar(255) NOT NULL,
+  date bigint NOT NULL,
+  type int NOT NULL,
+  task_id int NOT NULL,
+  old_value varchar(255) NOT NULL DEFAULT '',
+  new_value varchar(255) NOT NULL DEFAULT '',
+  note varchar(1024) NOT NULL DEFAULT '',
+  actor varchar(255) NOT NULL DEFAULT '',
+  cause int NULL,
+  reverts int NULL,
+  snapshot ` + db.dialect.textType() + ` NULL
+)
+`},
+		{"dependencies", `
+CREATE TABLE IF NOT EXISTS dependencies (
+  ` + id + `,
+  task_id int NOT NULL,
+  depends_on_id int NOT NULL
+)
+`},
+		{"task-tags", `
+CREATE TABLE IF NOT EXISTS task_tags (
+  task_id int NOT NULL,
+  tag varchar(255) NOT NULL,
+  PRIMARY KEY (task_id, tag)
+)
+`},
+	}
+	for _, table := range tables {
+		if _, err := db.Exec(ctx, logger, table.q); err != nil {
+			logger.Error("create-"+table.name+"-table", err)
+			return err
+		}
+	}
+
+	if err := upgradeEvents(ctx, logger, db); err != nil {
+		logger.Error("upgrade-events", err)
+		return err
+	}
+
+	return nil
+}
+
+// upgradeEvents adds the event columns to an events table that was created before they existed.
+// The structured event columns are filled in for each existing event by parsing its title.
+func upgradeEvents(ctx context.Context, logger lager.Logger, db *DB) error {
+	structured, err := addColumns(ctx, logger, db, "events",
+		"old_value varchar(255) NOT NULL DEFAULT ''",
+		"new_value varchar(255) NOT NULL DEFAULT ''",
+		"note varchar(1024) NOT NULL DEFAULT ''",
+		"actor varchar(255) NOT NULL DEFAULT ''",
+	)
+	if err != nil {
+		return err
+	}
+
+	if _, err := addColumns(ctx, logger, db, "events",
+		"cause int NULL",
+		"reverts int NULL",
+		"snapshot "+db.dialect.textType()+" NULL",
+	); err != nil {
+		return err
+	}
+
+	if !structured {
+		return nil
+	}
+
+	rows, err := db.Query(ctx, logger, "SELECT "+eventColumns+" FROM events")
+	if err != nil {
+		return err
+	}
+
+	events := make([]*task.Event, 0)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		if task.UpgradeEvent(event) {
+			events = append(events, event)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+
+	q := `UPDATE events SET old_value = ?, new_value = ?, note = ? WHERE id = ?`
+	stmt, err := db.Prepare(ctx, logger, q)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close(logger)
+
+	for _, event := range events {
+		if _, err := stmt.Exec(
+			ctx,
+			logger,
+			event.OldValue,
+			event.NewValue,
+			event.Note,
+			event.ID,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addColumns adds the columns (e.g., "reverts int NULL") to a table if it does not have the first
+// of them, and returns whether it did.
+func addColumns(
+	ctx context.Context,
+	logger lager.Logger,
+	db *DB,
+	table string,
+	columns ...string,
+) (bool, error) {
+	stmt, err := db.Prepare(ctx, logger, db.dialect.columnExistsQuery(table))
+	if err != nil {
+		return false, err
+	}
+	defer stmt.Close(logger)
+
+	rows, err := stmt.Query(ctx, logger, strings.Fields(columns[0])[0])
+	if err != nil {
+		return false, err
+	}
+
+	var count int
+	if rows.Next() {
+		if err := rows.Scan(&count); err != nil {
+			rows.Close()
+			return false, err
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	} else if count > 0 {
+		return false, nil
+	}
+
+	// Not every dialect can add more than one column with a single ALTER TABLE statement.
+	for _, column := range columns {
+		if _, err := db.Exec(ctx, logger, "ALTER TABLE "+table+" ADD COLUMN "+column); err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,230 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/query"
+	"github.com/ankeesler/anwork/task"
+)
+
+// These are the SQL comparison operators for each query.Op, except for query.OpContains.
+var sqlOps = map[query.Op]string{
+	query.OpEqual:          "=",
+	query.OpNotEqual:       "<>",
+	query.OpLess:           "<",
+	query.OpLessOrEqual:    "<=",
+	query.OpGreater:        ">",
+	query.OpGreaterOrEqual: ">=",
+}
+
+func (r *repo) TasksMatching(q *query.Query) ([]*task.Task, error) {
+	logger := r.logger.Session("tasks-matching")
+	logger.Debug("begin", lager.Data{"query": q.String()})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	where, args := whereClause(r.db.dialect, r.context, q)
+	stmt, err := r.db.Prepare(ctx, logger, "SELECT "+taskColumns+" FROM tasks"+where+" ORDER BY id")
+	if err != nil {
+		logger.Error("prepare", err)
+		return nil, err
+	}
+	defer stmt.Close(logger)
+
+	rows, err := stmt.Query(ctx, logger, args...)
+	if err != nil {
+		logger.Error("query", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := make([]*task.Task, 0)
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			logger.Error("rows-scan", err)
+			return nil, err
+		}
+
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("rows-next", err)
+		return nil, err
+	}
+
+	if err := r.loadTags(ctx, logger, tasks...); err != nil {
+		logger.Error("load-tags", err)
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// whereClause translates a query.Query into a WHERE clause (with a leading space) for the tasks in
+// a context, and the arguments for its placeholders.
+func whereClause(d dialect, context string, q *query.Query) (string, []interface{}) {
+	conditions := []string{"context = ?"}
+	args := []interface{}{context}
+	for _, term := range q.Terms {
+		switch term.Field {
+		case query.FieldState:
+			conditions = append(conditions, fmt.Sprintf("state %s ?", sqlOps[term.Op]))
+			args = append(args, string(term.State))
+
+		case query.FieldPriority:
+			conditions = append(conditions, fmt.Sprintf("priority %s ?", sqlOps[term.Op]))
+			args = append(args, term.Number)
+
+		case query.FieldTag:
+			in := "IN"
+			if term.Op == query.OpNotEqual {
+				in = "NOT IN"
+			}
+			conditions = append(
+				conditions,
+				fmt.Sprintf("id %s (SELECT task_id FROM task_tags WHERE tag = ?)", in),
+			)
+			args = append(args, term.Value)
+
+		case query.FieldName:
+			if term.Op == query.OpContains {
+				conditions = append(conditions, "name LIKE ?"+d.likeEscape())
+				args = append(args, "%"+escapeLike(term.Value)+"%")
+			} else {
+				conditions = append(conditions, fmt.Sprintf("name %s ?", sqlOps[term.Op]))
+				args = append(args, term.Value)
+			}
+
+		case query.FieldCreated:
+			condition, dateArgs := dateCondition("start_date", term)
+			conditions = append(conditions, condition)
+			args = append(args, dateArgs...)
+
+		case query.FieldDeadline:
+			// Tasks without a deadline never match a deadline term.
+			condition, dateArgs := dateCondition("deadline", term)
+			conditions = append(conditions, "(deadline <> 0 AND "+condition+")")
+			args = append(args, dateArgs...)
+		}
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// dateCondition translates a query.Term about a date column into a condition, and the arguments
+// for its placeholders. Like query.Term.Matches, it compares ":" and "!=" with the whole date.
+func dateCondition(column string, term query.Term) (string, []interface{}) {
+	switch term.Op {
+	case query.OpEqual:
+		return fmt.Sprintf("(%s >= ? AND %s < ?)", column, column), []interface{}{term.Number, term.Until}
+	case query.OpNotEqual:
+		return fmt.Sprintf("(%s < ? OR %s >= ?)", column, column), []interface{}{term.Number, term.Until}
+	default:
+		return fmt.Sprintf("%s %s ?", column, sqlOps[term.Op]), []interface{}{term.Number}
+	}
+}
+
+// escapeLike escapes the characters in a string that have a special meaning in a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func (r *repo) EventsMatching(q *query.EventQuery) ([]*task.Event, error) {
+	logger := r.logger.Session("events-matching")
+	logger.Debug("begin", lager.Data{"query": q})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	where, args := eventWhereClause(r.context, q)
+	order := " ORDER BY id"
+	if q.Order == query.OrderDescending {
+		order = " ORDER BY id DESC"
+	}
+	limit := ""
+	if q.Limit != 0 {
+		limit = " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := r.db.Query(ctx, logger, "SELECT "+eventColumns+" FROM events"+where+order+limit, args...)
+	if err != nil {
+		logger.Error("query", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]*task.Event, 0)
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			logger.Error("rows-scan", err)
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("rows-next", err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// eventWhereClause translates a query.EventQuery into a WHERE clause (with a leading space) for the
+// events in a context, and the arguments for its placeholders.
+func eventWhereClause(context string, q *query.EventQuery) (string, []interface{}) {
+	conditions := []string{"context = ?"}
+	args := []interface{}{context}
+
+	if q.TaskID != nil {
+		conditions = append(conditions, "task_id = ?")
+		args = append(args, *q.TaskID)
+	}
+
+	if len(q.Types) > 0 {
+		placeholders := make([]string, len(q.Types))
+		for i, t := range q.Types {
+			placeholders[i] = "?"
+			args = append(args, t)
+		}
+		conditions = append(conditions, "type IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	if q.Since != 0 {
+		conditions = append(conditions, "date >= ?")
+		args = append(args, q.Since)
+	}
+	if q.Until != 0 {
+		conditions = append(conditions, "date < ?")
+		args = append(args, q.Until)
+	}
+
+	if q.Cursor != nil {
+		if q.Order == query.OrderDescending {
+			conditions = append(conditions, "id < ?")
+		} else {
+			conditions = append(conditions, "id > ?")
+		}
+		args = append(args, *q.Cursor)
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
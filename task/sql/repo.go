@@ -6,23 +6,34 @@ import (
 	"context"
 	stdlibsql "database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"code.cloudfoundry.org/lager"
 	"github.com/ankeesler/anwork/task"
 )
 
+const taskColumns = `id, name, start_date, priority, state, deadline, version, owner, shared_with`
+
+const eventColumns = `id, title, date, type, task_id, old_value, new_value, note, actor, cause, reverts, snapshot`
+
+const dependencyColumns = `id, task_id, depends_on_id`
+
 type repo struct {
 	logger lager.Logger
 
 	db *DB
+	// This is the context of the task.Task's, task.Event's, and task.Dependency's in the repo.
+	context string
 
 	tablesCreated bool
 }
 
-// New returns a task.Repo that stores task.Task's in an SQL database.
+// New returns a task.Repo that stores task.Task's in an SQL database, in the task.DefaultContext.
+// See NewContexts for a task.Repo in another context. The task.Repo is also a task.Transactor, a
+// query.EventRepo, and a webhook.Repo.
 func New(logger lager.Logger, db *DB) task.Repo {
-	return &repo{logger: logger, db: db}
+	return &repo{logger: logger, db: db, context: task.DefaultContext}
 }
 
 func (r *repo) CreateTask(task *task.Task) error {
@@ -38,33 +49,38 @@ func (r *repo) CreateTask(task *task.Task) error {
 	ctx, cancel := makeCtx()
 	defer cancel()
 
-	q := `INSERT INTO tasks (name, start_date, priority, state) VALUES (?, ?, ?, ?)`
-	stmt, err := r.db.Prepare(ctx, logger, q)
-	if err != nil {
-		logger.Error("prepare", err)
+	if err := r.ensureContextExists(ctx, logger); err != nil {
+		logger.Error("ensure-context-exists", err)
 		return err
 	}
-	defer stmt.Close(logger)
 
-	result, err := stmt.Exec(
+	q := `
+INSERT INTO tasks (context, name, start_date, priority, state, deadline, version, owner, shared_with)
+VALUES (?, ?, ?, ?, ?, ?, 1, ?, ?)`
+	id, err := r.insert(
 		ctx,
 		logger,
+		q,
+		r.context,
 		task.Name,
 		task.StartDate,
 		task.Priority,
 		task.State,
+		task.Deadline,
+		task.Owner,
+		strings.Join(task.SharedWith, ","),
 	)
 	if err != nil {
-		logger.Error("exec", err)
+		logger.Error("insert", err)
 		return err
 	}
+	task.ID = id
+	task.Version = 1
 
-	id, err := result.LastInsertId()
-	if err != nil {
-		logger.Error("result-last-insert-id", err)
+	if err := r.saveTags(ctx, logger, task); err != nil {
+		logger.Error("save-tags", err)
 		return err
 	}
-	task.ID = int(id)
 
 	return nil
 }
@@ -81,7 +97,8 @@ func (r *repo) Tasks() ([]*task.Task, error) {
 
 	ctx, cancel := makeCtx()
 	defer cancel()
-	rows, err := r.db.Query(ctx, logger, "SELECT * FROM tasks")
+	q := "SELECT " + taskColumns + " FROM tasks WHERE context = ? ORDER BY id"
+	rows, err := r.db.Query(ctx, logger, q, r.context)
 	if err != nil {
 		logger.Error("get-tasks", err)
 		return nil, err
@@ -98,14 +115,8 @@ func (r *repo) Tasks() ([]*task.Task, error) {
 			}
 		}
 
-		task := new(task.Task)
-		if err := rows.Scan(
-			&task.ID,
-			&task.Name,
-			&task.StartDate,
-			&task.Priority,
-			&task.State,
-		); err != nil {
+		task, err := scanTask(rows)
+		if err != nil {
 			logger.Error("rows-scan", err)
 			return nil, err
 		}
@@ -113,6 +124,11 @@ func (r *repo) Tasks() ([]*task.Task, error) {
 		tasks = append(tasks, task)
 	}
 
+	if err := r.loadTags(ctx, logger, tasks...); err != nil {
+		logger.Error("load-tags", err)
+		return nil, err
+	}
+
 	return tasks, nil
 }
 
@@ -129,17 +145,11 @@ func (r *repo) FindTaskByID(id int) (*task.Task, error) {
 	ctx, cancel := makeCtx()
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT * FROM tasks WHERE id = %d`, id)
-	row := r.db.QueryRow(ctx, logger, q)
+	q := `SELECT ` + taskColumns + ` FROM tasks WHERE id = ? AND context = ?`
+	row := r.db.QueryRow(ctx, logger, q, id, r.context)
 
-	task := new(task.Task)
-	if err := row.Scan(
-		&task.ID,
-		&task.Name,
-		&task.StartDate,
-		&task.Priority,
-		&task.State,
-	); err != nil {
+	task, err := scanTask(row)
+	if err != nil {
 		if err == stdlibsql.ErrNoRows {
 			return nil, nil
 		} else {
@@ -148,6 +158,11 @@ func (r *repo) FindTaskByID(id int) (*task.Task, error) {
 		}
 	}
 
+	if err := r.loadTags(ctx, logger, task); err != nil {
+		logger.Error("load-tags", err)
+		return nil, err
+	}
+
 	return task, nil
 }
 
@@ -164,17 +179,11 @@ func (r *repo) FindTaskByName(name string) (*task.Task, error) {
 	ctx, cancel := makeCtx()
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT * FROM tasks WHERE name = '%s'`, name)
-	row := r.db.QueryRow(ctx, logger, q)
+	q := `SELECT ` + taskColumns + ` FROM tasks WHERE name = ? AND context = ?`
+	row := r.db.QueryRow(ctx, logger, q, name, r.context)
 
-	task := new(task.Task)
-	if err := row.Scan(
-		&task.ID,
-		&task.Name,
-		&task.StartDate,
-		&task.Priority,
-		&task.State,
-	); err != nil {
+	task, err := scanTask(row)
+	if err != nil {
 		if err == stdlibsql.ErrNoRows {
 			return nil, nil
 		} else {
@@ -183,6 +192,11 @@ func (r *repo) FindTaskByName(name string) (*task.Task, error) {
 		}
 	}
 
+	if err := r.loadTags(ctx, logger, task); err != nil {
+		logger.Error("load-tags", err)
+		return nil, err
+	}
+
 	return task, nil
 }
 
@@ -199,23 +213,43 @@ func (r *repo) UpdateTask(task *task.Task) error {
 	ctx, cancel := makeCtx()
 	defer cancel()
 
-	if exists, err := r.taskExists(ctx, logger, task.ID); err != nil {
-		logger.Error("task-exists", err)
+	version, err := r.taskVersion(ctx, logger, task.ID)
+	if err != nil {
+		logger.Error("task-version", err)
 		return err
-	} else if !exists {
+	} else if version == 0 {
 		return fmt.Errorf("unknown task with id %d", task.ID)
 	}
 
-	q := fmt.Sprintf(`
+	q := `
 UPDATE tasks
-SET name = '%s', start_date = %d, priority = %d, state = '%s'
-WHERE id = %d`,
-		task.Name, task.StartDate, task.Priority, task.State, task.ID)
-	_, err := r.db.Exec(ctx, logger, q)
+SET name = ?, start_date = ?, priority = ?, state = ?, deadline = ?, version = version + 1,
+  owner = ?, shared_with = ?
+WHERE id = ? AND context = ?`
+	_, err = r.db.Exec(
+		ctx,
+		logger,
+		q,
+		task.Name,
+		task.StartDate,
+		task.Priority,
+		task.State,
+		task.Deadline,
+		task.Owner,
+		strings.Join(task.SharedWith, ","),
+		task.ID,
+		r.context,
+	)
 	if err != nil {
 		logger.Error("exec", err)
 		return err
 	}
+	task.Version = version + 1
+
+	if err := r.saveTags(ctx, logger, task); err != nil {
+		logger.Error("save-tags", err)
+		return err
+	}
 
 	return nil
 }
@@ -233,8 +267,14 @@ func (r *repo) DeleteTask(task *task.Task) error {
 	ctx, cancel := makeCtx()
 	defer cancel()
 
-	q := fmt.Sprintf(`DELETE FROM tasks WHERE id = %d`, task.ID)
-	_, err := r.db.Exec(ctx, logger, q)
+	q := `DELETE FROM task_tags WHERE task_id IN (SELECT id FROM tasks WHERE id = ? AND context = ?)`
+	_, err := r.db.Exec(ctx, logger, q, task.ID, r.context)
+	if err != nil {
+		logger.Error("exec-tags", err)
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, logger, `DELETE FROM tasks WHERE id = ? AND context = ?`, task.ID, r.context)
 	if err != nil {
 		logger.Error("exec", err)
 		return err
@@ -256,33 +296,37 @@ func (r *repo) CreateEvent(event *task.Event) error {
 	ctx, cancel := makeCtx()
 	defer cancel()
 
-	q := `INSERT INTO events (title, date, type, task_id) VALUES (?, ?, ?, ?)`
-	stmt, err := r.db.Prepare(ctx, logger, q)
-	if err != nil {
-		logger.Error("prepare", err)
+	if err := r.ensureContextExists(ctx, logger); err != nil {
+		logger.Error("ensure-context-exists", err)
 		return err
 	}
-	defer stmt.Close(logger)
 
-	result, err := stmt.Exec(
+	q := `
+INSERT INTO events
+  (context, title, date, type, task_id, old_value, new_value, note, actor, cause, reverts, snapshot)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	id, err := r.insert(
 		ctx,
 		logger,
+		q,
+		r.context,
 		event.Title,
 		event.Date,
 		event.Type,
 		event.TaskID,
+		event.OldValue,
+		event.NewValue,
+		event.Note,
+		event.Actor,
+		nullableID(event.Cause),
+		nullableID(event.Reverts),
+		event.Snapshot,
 	)
 	if err != nil {
-		logger.Error("exec", err)
-		return err
-	}
-
-	id, err := result.LastInsertId()
-	if err != nil {
-		logger.Error("last-insert-id", err)
+		logger.Error("insert", err)
 		return err
 	}
-	event.ID = int(id)
+	event.ID = id
 
 	return nil
 }
@@ -299,7 +343,8 @@ func (r *repo) Events() ([]*task.Event, error) {
 
 	ctx, cancel := makeCtx()
 	defer cancel()
-	rows, err := r.db.Query(ctx, logger, "SELECT * FROM events")
+	q := "SELECT " + eventColumns + " FROM events WHERE context = ? ORDER BY id"
+	rows, err := r.db.Query(ctx, logger, q, r.context)
 	if err != nil {
 		logger.Error("get-events", err)
 		return nil, err
@@ -316,14 +361,8 @@ func (r *repo) Events() ([]*task.Event, error) {
 			}
 		}
 
-		event := new(task.Event)
-		if err := rows.Scan(
-			&event.ID,
-			&event.Title,
-			&event.Date,
-			&event.Type,
-			&event.TaskID,
-		); err != nil {
+		event, err := scanEvent(rows)
+		if err != nil {
 			logger.Error("rows-scan", err)
 			return nil, err
 		}
@@ -347,17 +386,11 @@ func (r *repo) FindEventByID(id int) (*task.Event, error) {
 	ctx, cancel := makeCtx()
 	defer cancel()
 
-	q := fmt.Sprintf(`SELECT * FROM events WHERE id = %d`, id)
-	row := r.db.QueryRow(ctx, logger, q)
+	q := `SELECT ` + eventColumns + ` FROM events WHERE id = ? AND context = ?`
+	row := r.db.QueryRow(ctx, logger, q, id, r.context)
 
-	event := new(task.Event)
-	if err := row.Scan(
-		&event.ID,
-		&event.Title,
-		&event.Date,
-		&event.Type,
-		&event.TaskID,
-	); err != nil {
+	event, err := scanEvent(row)
+	if err != nil {
 		if err == stdlibsql.ErrNoRows {
 			return nil, nil
 		} else {
@@ -382,8 +415,8 @@ func (r *repo) DeleteEvent(event *task.Event) error {
 	ctx, cancel := makeCtx()
 	defer cancel()
 
-	q := fmt.Sprintf(`DELETE FROM events WHERE id = %d`, event.ID)
-	_, err := r.db.Exec(ctx, logger, q)
+	q := `DELETE FROM events WHERE id = ? AND context = ?`
+	_, err := r.db.Exec(ctx, logger, q, event.ID, r.context)
 	if err != nil {
 		logger.Error("exec", err)
 		return err
@@ -392,82 +425,375 @@ func (r *repo) DeleteEvent(event *task.Event) error {
 	return nil
 }
 
-func (r *repo) ensureTablesExist(logger lager.Logger) error {
-	if r.tablesCreated {
-		return nil
+func (r *repo) CreateDependency(dependency *task.Dependency) error {
+	logger := r.logger.Session("create-dependency")
+	logger.Debug("begin", lager.Data{"dependency": dependency})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	if err := r.ensureContextExists(ctx, logger); err != nil {
+		logger.Error("ensure-context-exists", err)
+		return err
 	}
 
-	if exists, err := r.tablesExist(logger); err != nil {
+	q := `INSERT INTO dependencies (context, task_id, depends_on_id) VALUES (?, ?, ?)`
+	id, err := r.insert(ctx, logger, q, r.context, dependency.TaskID, dependency.DependsOnID)
+	if err != nil {
+		logger.Error("insert", err)
 		return err
-	} else if !exists {
-		ctx, cancel := makeCtx()
-		defer cancel()
-
-		q := `
-CREATE TABLE tasks (
-  id int NOT NULL PRIMARY KEY AUTO_INCREMENT,
-  name varchar(255) NOT NULL,
-  start_date bigint NOT NULL,
-  priority int NOT NULL,
-  state varchar(16) NOT NULL
-)
-`
-		_, err = r.db.Exec(ctx, logger, q)
-		if err != nil {
-			r.logger.Error("create-tasks-table", err)
-			return err
+	}
+	dependency.ID = id
+
+	return nil
+}
+
+func (r *repo) Dependencies() ([]*task.Dependency, error) {
+	logger := r.logger.Session("dependencies")
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+	q := "SELECT " + dependencyColumns + " FROM dependencies WHERE context = ? ORDER BY id"
+	rows, err := r.db.Query(ctx, logger, q, r.context)
+	if err != nil {
+		logger.Error("get-dependencies", err)
+		return nil, err
+	}
+
+	dependencies := make([]*task.Dependency, 0)
+	for {
+		if !rows.Next() {
+			if err := rows.Err(); err != nil {
+				logger.Error("rows-next", err)
+				return nil, err
+			} else {
+				break
+			}
 		}
 
-		q = `
-CREATE TABLE events (
-  id int NOT NULL PRIMARY KEY AUTO_INCREMENT,
-  title varchar(255) NOT NULL,
-  date bigint NOT NULL,
-  type int NOT NULL,
-  task_id int NOT NULL
-)
-`
-		_, err = r.db.Exec(ctx, logger, q)
-		if err != nil {
-			r.logger.Error("create-events-table", err)
-			return err
+		dependency := new(task.Dependency)
+		if err := rows.Scan(
+			&dependency.ID,
+			&dependency.TaskID,
+			&dependency.DependsOnID,
+		); err != nil {
+			logger.Error("rows-scan", err)
+			return nil, err
 		}
+
+		dependencies = append(dependencies, dependency)
 	}
 
-	r.tablesCreated = true
+	return dependencies, nil
+}
 
-	return nil
+func (r *repo) FindDependencyByID(id int) (*task.Dependency, error) {
+	logger := r.logger.Session("find-dependency-by-id")
+	logger.Debug("begin", lager.Data{"id": id})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	q := `SELECT ` + dependencyColumns + ` FROM dependencies WHERE id = ? AND context = ?`
+	row := r.db.QueryRow(ctx, logger, q, id, r.context)
+
+	dependency := new(task.Dependency)
+	if err := row.Scan(
+		&dependency.ID,
+		&dependency.TaskID,
+		&dependency.DependsOnID,
+	); err != nil {
+		if err == stdlibsql.ErrNoRows {
+			return nil, nil
+		} else {
+			logger.Error("scan", err)
+			return nil, err
+		}
+	}
+
+	return dependency, nil
 }
 
-func (r *repo) tablesExist(logger lager.Logger) (bool, error) {
+func (r *repo) DeleteDependency(dependency *task.Dependency) error {
+	logger := r.logger.Session("delete-dependency")
+	logger.Debug("begin", lager.Data{"dependency": dependency})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
 	ctx, cancel := makeCtx()
 	defer cancel()
 
-	q := `SHOW TABLES`
-	rows, err := r.db.Query(ctx, logger, q)
+	q := `DELETE FROM dependencies WHERE id = ? AND context = ?`
+	_, err := r.db.Exec(ctx, logger, q, dependency.ID, r.context)
+	if err != nil {
+		logger.Error("exec", err)
+		return err
+	}
 
+	return nil
+}
+
+// WithTx calls a function with a repo whose queries are made in a transaction, which is committed
+// if the function succeeds and rolled back otherwise.
+func (r *repo) WithTx(do func(task.Repo) error) error {
+	logger := r.logger.Session("with-tx")
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	// The tables are migrated outside of the transaction, since some databases cannot roll back
+	// changes to tables anyway.
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
+	// The transaction lasts as long as the function, so it does not get the timeout of a query.
+	tx, err := r.db.Begin(context.Background(), logger)
 	if err != nil {
-		return false, err
-	} else if !rows.Next() {
-		return false, nil
-	} else {
-		return true, nil
+		logger.Error("begin", err)
+		return err
+	}
+
+	if err := do(&repo{logger: r.logger, db: tx, context: r.context, tablesCreated: true}); err != nil {
+		if rollbackErr := tx.Rollback(logger); rollbackErr != nil {
+			logger.Error("rollback", rollbackErr)
+		}
+		return err
 	}
+
+	if err := tx.Commit(logger); err != nil {
+		logger.Error("commit", err)
+		return err
+	}
+
+	return nil
 }
 
-func (r *repo) taskExists(
+func (r *repo) ensureTablesExist(logger lager.Logger) error {
+	if r.tablesCreated {
+		return nil
+	}
+
+	if err := Migrate(logger, r.db, LatestSchemaVersion()); err != nil {
+		r.logger.Error("migrate", err)
+		return err
+	}
+
+	r.tablesCreated = true
+
+	return nil
+}
+
+// taskVersion returns the task.Task.Version of a task.Task, or 0 if the task.Task does not exist.
+func (r *repo) taskVersion(
 	ctx context.Context,
 	logger lager.Logger,
 	id int,
-) (bool, error) {
-	q := fmt.Sprintf("SELECT id FROM tasks WHERE id = %d", id)
-	if err := r.db.QueryRow(ctx, logger, q).Scan(&id); err == stdlibsql.ErrNoRows {
-		return false, nil
+) (int, error) {
+	var version int
+	q := `SELECT version FROM tasks WHERE id = ? AND context = ?`
+	if err := r.db.QueryRow(ctx, logger, q, id, r.context).Scan(&version); err == stdlibsql.ErrNoRows {
+		return 0, nil
 	} else if err != nil {
-		return false, err
+		return 0, err
 	} else {
-		return true, nil
+		return version, nil
+	}
+}
+
+// saveTags replaces the tags of a task.Task in the task_tags table with its current tags.
+func (r *repo) saveTags(ctx context.Context, logger lager.Logger, task *task.Task) error {
+	q := `DELETE FROM task_tags WHERE task_id = ?`
+	if _, err := r.db.Exec(ctx, logger, q, task.ID); err != nil {
+		return err
+	}
+
+	if len(task.Tags) == 0 {
+		return nil
+	}
+
+	stmt, err := r.db.Prepare(ctx, logger, `INSERT INTO task_tags (task_id, tag) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close(logger)
+
+	for _, tag := range task.Tags {
+		if _, err := stmt.Exec(ctx, logger, task.ID, tag); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// loadTags sets the tags of the provided task.Task's from the task_tags table.
+func (r *repo) loadTags(ctx context.Context, logger lager.Logger, tasks ...*task.Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	q := `
+SELECT task_id, tag FROM task_tags
+WHERE task_id IN (SELECT id FROM tasks WHERE context = ?)
+ORDER BY tag`
+	args := []interface{}{r.context}
+	if len(tasks) == 1 {
+		q = `SELECT task_id, tag FROM task_tags WHERE task_id = ? ORDER BY tag`
+		args = []interface{}{tasks[0].ID}
+	}
+	rows, err := r.db.Query(ctx, logger, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	tasksByID := make(map[int]*task.Task)
+	for _, task := range tasks {
+		tasksByID[task.ID] = task
+	}
+
+	for rows.Next() {
+		var id int
+		var tag string
+		if err := rows.Scan(&id, &tag); err != nil {
+			return err
+		}
+
+		if task, ok := tasksByID[id]; ok {
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+
+	return rows.Err()
+}
+
+// ensureContextExists adds the context of the repo to the contexts table if it is not there.
+func (r *repo) ensureContextExists(ctx context.Context, logger lager.Logger) error {
+	exists, err := contextExists(ctx, logger, r.db, r.context)
+	if err != nil || exists {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, logger, `INSERT INTO contexts (name) VALUES (?)`, r.context)
+	return err
+}
+
+// insert executes an INSERT statement and returns the ID of the row that it inserted.
+func (r *repo) insert(
+	ctx context.Context,
+	logger lager.Logger,
+	q string,
+	args ...interface{},
+) (int, error) {
+	var id int
+	if r.db.dialect.returningID() {
+		err := r.db.QueryRow(ctx, logger, q+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := r.db.Exec(ctx, logger, q, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	lastInsertID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	id = int(lastInsertID)
+
+	return id, nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTask(s scanner) (*task.Task, error) {
+	task := new(task.Task)
+	var sharedWith string
+	if err := s.Scan(
+		&task.ID,
+		&task.Name,
+		&task.StartDate,
+		&task.Priority,
+		&task.State,
+		&task.Deadline,
+		&task.Version,
+		&task.Owner,
+		&sharedWith,
+	); err != nil {
+		return nil, err
+	}
+	task.SharedWith = splitList(sharedWith)
+	return task, nil
+}
+
+func scanEvent(s scanner) (*task.Event, error) {
+	event := new(task.Event)
+	var cause, reverts stdlibsql.NullInt64
+	var snapshot stdlibsql.NullString
+	if err := s.Scan(
+		&event.ID,
+		&event.Title,
+		&event.Date,
+		&event.Type,
+		&event.TaskID,
+		&event.OldValue,
+		&event.NewValue,
+		&event.Note,
+		&event.Actor,
+		&cause,
+		&reverts,
+		&snapshot,
+	); err != nil {
+		return nil, err
+	}
+	event.Cause = scannedID(cause)
+	event.Reverts = scannedID(reverts)
+	event.Snapshot = snapshot.String
+	return event, nil
+}
+
+// nullableID converts an optional ID, e.g., task.Event.Cause, into a value for a nullable column.
+func nullableID(id *int) stdlibsql.NullInt64 {
+	if id == nil {
+		return stdlibsql.NullInt64{}
+	}
+	return stdlibsql.NullInt64{Int64: int64(*id), Valid: true}
+}
+
+// scannedID converts a value from a nullable column into an optional ID; see nullableID.
+func scannedID(id stdlibsql.NullInt64) *int {
+	if !id.Valid {
+		return nil
 	}
+	value := int(id.Int64)
+	return &value
 }
 
 func makeCtx() (context.Context, func()) {
@@ -0,0 +1,145 @@
+package sql
+
+import (
+	stdlibsql "database/sql"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/session"
+)
+
+const sessionColumns = `id, subject, issued_at, expiry`
+
+func (r *repo) CreateSession(s *session.Session) error {
+	logger := r.logger.Session("create-session")
+	logger.Debug("begin", lager.Data{"id": s.ID})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	q := `INSERT INTO sessions (` + sessionColumns + `) VALUES (?, ?, ?, ?)`
+	if _, err := r.db.Exec(ctx, logger, q, s.ID, s.Subject, s.IssuedAt, s.Expiry); err != nil {
+		logger.Error("exec", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) Sessions() ([]*session.Session, error) {
+	logger := r.logger.Session("sessions")
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	q := "SELECT " + sessionColumns + " FROM sessions ORDER BY issued_at, id"
+	rows, err := r.db.Query(ctx, logger, q)
+	if err != nil {
+		logger.Error("query", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]*session.Session, 0)
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			logger.Error("scan", err)
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("rows", err)
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *repo) FindSessionByID(id string) (*session.Session, error) {
+	logger := r.logger.Session("find-session-by-id")
+	logger.Debug("begin", lager.Data{"id": id})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	q := `SELECT ` + sessionColumns + ` FROM sessions WHERE id = ?`
+	s, err := scanSession(r.db.QueryRow(ctx, logger, q, id))
+	if err == stdlibsql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		logger.Error("scan", err)
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (r *repo) DeleteSession(s *session.Session) error {
+	logger := r.logger.Session("delete-session")
+	logger.Debug("begin", lager.Data{"id": s.ID})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	if _, err := r.db.Exec(ctx, logger, `DELETE FROM sessions WHERE id = ?`, s.ID); err != nil {
+		logger.Error("exec", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) DeleteExpiredSessions(now int64) error {
+	logger := r.logger.Session("delete-expired-sessions")
+	logger.Debug("begin", lager.Data{"now": now})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	if _, err := r.db.Exec(ctx, logger, `DELETE FROM sessions WHERE expiry <= ?`, now); err != nil {
+		logger.Error("exec", err)
+		return err
+	}
+
+	return nil
+}
+
+func scanSession(s scanner) (*session.Session, error) {
+	sess := new(session.Session)
+	if err := s.Scan(&sess.ID, &sess.Subject, &sess.IssuedAt, &sess.Expiry); err != nil {
+		return nil, err
+	}
+	return sess, nil
+}
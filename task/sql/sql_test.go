@@ -3,50 +3,366 @@ package sql_test
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"time"
 
 	"code.cloudfoundry.org/lager"
 	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/ankeesler/anwork/query"
+	"github.com/ankeesler/anwork/session/sessiontest"
 	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/task/repotest"
 	"github.com/ankeesler/anwork/task/sql"
+	"github.com/ankeesler/anwork/user/usertest"
+	"github.com/ankeesler/anwork/webhook/webhooktest"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	_ "modernc.org/sqlite"
 )
 
 // TODO: improve performance by combining setup queries?
 
 var _ = Describe("SQL Repo", func() {
+	Describe("with MySQL", func() {
+		var dsn string
+
+		runSQLRepoTests(func(logger lager.Logger) *sql.DB {
+			var ok bool
+			dsn, ok = os.LookupEnv("ANWORK_TEST_SQL_DSN")
+			if !ok {
+				Skip("ANWORK_TEST_SQL_DSN env var must be set to test with MySQL")
+			}
+
+			db, err := sql.Open("mysql", dsn)
+			Expect(err).NotTo(HaveOccurred())
+			return db
+		}, func(logger lager.Logger, db *sql.DB) {
+			cleanTestDB(logger, db, dsn)
+		}, false)
+	})
+
+	Describe("with PostgreSQL", func() {
+		runSQLRepoTests(func(logger lager.Logger) *sql.DB {
+			dsn, ok := os.LookupEnv("ANWORK_TEST_POSTGRES_DSN")
+			if !ok {
+				Skip("ANWORK_TEST_POSTGRES_DSN env var must be set to test with PostgreSQL")
+			}
+
+			db, err := sql.Open("postgres", dsn)
+			Expect(err).NotTo(HaveOccurred())
+			return db
+		}, func(logger lager.Logger, db *sql.DB) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+			defer cancel()
+
+			_, err := db.Exec(
+				ctx,
+				logger,
+				"DROP TABLE IF EXISTS tasks, events, dependencies, task_tags, contexts, webhooks, dead_letters, users, sessions, schema_version",
+			)
+			Expect(err).NotTo(HaveOccurred())
+		}, true)
+	})
+
+	Describe("with SQLite", func() {
+		var dir string
+
+		runSQLRepoTests(func(logger lager.Logger) *sql.DB {
+			var err error
+			dir, err = ioutil.TempDir("", "sql-task-repo-test")
+			Expect(err).NotTo(HaveOccurred())
+
+			db, err := sql.Open("sqlite", filepath.Join(dir, "test.db"))
+			Expect(err).NotTo(HaveOccurred())
+			return db
+		}, func(logger lager.Logger, db *sql.DB) {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		}, true)
+	})
+
+	Context("when the driver is not supported", func() {
+		It("fails to open the database", func() {
+			_, err := sql.Open("tuna", "some-dsn")
+			Expect(err).To(MatchError("unsupported driver: 'tuna'"))
+		})
+	})
+})
+
+// runSQLRepoTests runs the tests of the sql task.Repo against the DB returned by openDB. The
+// cleanDB function is called after each test to remove what the test stored. The transactionalDDL
+// flag says whether the database can roll back changes to its schema.
+func runSQLRepoTests(
+	openDB func(lager.Logger) *sql.DB,
+	cleanDB func(lager.Logger, *sql.DB),
+	transactionalDDL bool,
+) {
 	var (
 		logger lager.Logger
 
-		dsn string
-		db  *sql.DB
+		db *sql.DB
 	)
 
 	BeforeEach(func() {
 		logger = lagertest.NewTestLogger("sql")
-
-		var ok bool
-		dsn, ok = os.LookupEnv("ANWORK_TEST_SQL_DSN")
-		Expect(ok).To(BeTrue(), "ANWORK_TEST_SQL_DSN env var must be set!")
-
-		var err error
-		db, err = sql.Open("mysql", dsn)
-		Expect(err).NotTo(HaveOccurred())
+		db = openDB(logger)
 	})
 
 	AfterEach(func() {
+		if db == nil {
+			return
+		}
 		logger = logger.Session("after-each")
-		cleanTestDB(logger, db, dsn)
+		cleanDB(logger, db)
 		db.Close(logger)
+		db = nil
 	})
 
-	task.RunRepoTests(func() task.Repo {
+	repotest.RunRepoTests(func() task.Repo {
 		return sql.New(logger, db)
 	})
 
+	Describe("WithTx", func() {
+		repotest.RunTransactorTests(func() task.Repo {
+			return sql.New(logger, db)
+		})
+	})
+
+	Describe("Webhooks", func() {
+		webhooktest.RunRepoTests(func() task.Repo {
+			return sql.New(logger, db)
+		})
+	})
+
+	Describe("Users", func() {
+		usertest.RunRepoTests(func() task.Repo {
+			return sql.New(logger, db)
+		})
+	})
+
+	Describe("Sessions", func() {
+		sessiontest.RunRepoTests(func() task.Repo {
+			return sql.New(logger, db)
+		})
+	})
+
+	Describe("Contexts", func() {
+		repotest.RunContextsTests(func() task.Contexts {
+			return sql.NewContexts(logger, db)
+		})
+
+		It("puts the tasks of the repo from New in the default context", func() {
+			Expect(sql.New(logger, db).CreateTask(&task.Task{Name: "task-a"})).To(Succeed())
+
+			contexts := sql.NewContexts(logger, db)
+			names, err := contexts.Contexts()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(names).To(Equal([]string{task.DefaultContext}))
+
+			repo, err := contexts.Repo(task.DefaultContext)
+			Expect(err).NotTo(HaveOccurred())
+			t, err := repo.FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t).NotTo(BeNil())
+		})
+	})
+
+	Describe("TasksMatching", func() {
+		var repo task.Repo
+		BeforeEach(func() {
+			repo = sql.New(logger, db)
+			for _, t := range []*task.Task{
+				&task.Task{Name: "deploy-service", Priority: 3, State: task.StateRunning, Tags: []string{"infra"}},
+				&task.Task{Name: "write_docs", Priority: 10, State: task.StateReady, Deadline: 100},
+				&task.Task{Name: "deploy-website", Priority: 5, State: task.StateFinished, Tags: []string{"infra", "web"}},
+			} {
+				Expect(repo.CreateTask(t)).To(Succeed())
+			}
+		})
+
+		matching := func(s string) []string {
+			q, err := query.Parse(s)
+			Expect(err).NotTo(HaveOccurred())
+
+			tasks, err := repo.(query.Repo).TasksMatching(q)
+			Expect(err).NotTo(HaveOccurred())
+
+			names := []string{}
+			for _, t := range tasks {
+				names = append(names, t.Name)
+			}
+			return names
+		}
+
+		It("translates the query into a database query", func() {
+			Expect(matching("")).To(ConsistOf("deploy-service", "write_docs", "deploy-website"))
+			Expect(matching("state:running")).To(ConsistOf("deploy-service"))
+			Expect(matching("priority>=5")).To(ConsistOf("write_docs", "deploy-website"))
+			Expect(matching("tag:infra tag!=web")).To(ConsistOf("deploy-service"))
+			Expect(matching("name~deploy")).To(ConsistOf("deploy-service", "deploy-website"))
+			Expect(matching("name~_")).To(ConsistOf("write_docs"))
+			Expect(matching("name:deploy-website")).To(ConsistOf("deploy-website"))
+			Expect(matching("created>2000-01-01")).To(BeEmpty())
+			Expect(matching("deadline>2000-01-01")).To(BeEmpty())
+			Expect(matching("deadline<2000-01-01")).To(ConsistOf("write_docs"))
+		})
+
+		It("matches tasks created or due any time on a date with ':' and '!='", func() {
+			midDay := time.Date(2026, time.January, 15, 13, 30, 0, 0, time.Local).Unix()
+			t := &task.Task{Name: "mid-day", StartDate: midDay, State: task.StateReady, Deadline: midDay}
+			Expect(repo.CreateTask(t)).To(Succeed())
+
+			Expect(matching("created:2026-01-15")).To(ConsistOf("mid-day"))
+			Expect(matching("created!=2026-01-15")).To(ConsistOf("deploy-service", "write_docs", "deploy-website"))
+			Expect(matching(`created:"2026-01-15 13:30"`)).To(ConsistOf("mid-day"))
+			Expect(matching("deadline:2026-01-15")).To(ConsistOf("mid-day"))
+			Expect(matching("deadline!=2026-01-15")).To(ConsistOf("write_docs"))
+		})
+
+		It("loads the tags of the tasks", func() {
+			q, err := query.Parse("name:deploy-website")
+			Expect(err).NotTo(HaveOccurred())
+
+			tasks, err := repo.(query.Repo).TasksMatching(q)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(HaveLen(1))
+			Expect(tasks[0].Tags).To(Equal([]string{"infra", "web"}))
+		})
+	})
+
+	Describe("Migrate", func() {
+		var repo task.Repo
+
+		BeforeEach(func() {
+			repo = sql.New(logger, db)
+			Expect(repo.CreateTask(&task.Task{Name: "task-a", State: task.StateReady})).To(Succeed())
+		})
+
+		It("migrates the database to the latest schema version when the repo is used", func() {
+			version, err := sql.SchemaVersion(logger, db)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal(sql.LatestSchemaVersion()))
+		})
+
+		It("reverts and reapplies migrations without losing tasks", func() {
+			Expect(sql.Migrate(logger, db, 1)).To(Succeed())
+			version, err := sql.SchemaVersion(logger, db)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal(1))
+
+			Expect(sql.Migrate(logger, db, sql.LatestSchemaVersion())).To(Succeed())
+			version, err = sql.SchemaVersion(logger, db)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal(sql.LatestSchemaVersion()))
+
+			t, err := sql.New(logger, db).FindTaskByName("task-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t).NotTo(BeNil())
+		})
+
+		It("drops the tables when every migration is reverted", func() {
+			Expect(sql.Migrate(logger, db, 0)).To(Succeed())
+			version, err := sql.SchemaVersion(logger, db)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(version).To(Equal(0))
+
+			tasks, err := sql.New(logger, db).Tasks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tasks).To(BeEmpty())
+		})
+
+		It("fails to migrate to an unknown version", func() {
+			err := sql.Migrate(logger, db, sql.LatestSchemaVersion()+1)
+			Expect(err).To(MatchError(fmt.Sprintf(
+				"unknown schema version %d (the latest version is %d)",
+				sql.LatestSchemaVersion()+1,
+				sql.LatestSchemaVersion(),
+			)))
+		})
+
+		Context("when a migration fails part of the way through", func() {
+			BeforeEach(func() {
+				if !transactionalDDL {
+					Skip("the database cannot roll back changes to its schema")
+				}
+
+				Expect(sql.Migrate(logger, db, 4)).To(Succeed())
+
+				// The add-event-indexes migration fails to create its second index.
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				_, err := db.Exec(
+					ctx,
+					logger.Session("before-each"),
+					"CREATE INDEX events_context_type_index ON events (context)",
+				)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("rolls back the migration, so that it can be run again", func() {
+				err := sql.Migrate(logger, db, 5)
+				Expect(err).To(MatchError(ContainSubstring("migration 5 ('add-event-indexes') failed")))
+				version, err := sql.SchemaVersion(logger, db)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(version).To(Equal(4))
+
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				_, err = db.Exec(ctx, logger, "DROP INDEX events_context_type_index")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(sql.Migrate(logger, db, 5)).To(Succeed())
+			})
+		})
+
+		Context("when the schema is newer than the repo", func() {
+			BeforeEach(func() {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+				defer cancel()
+				_, err := db.Exec(
+					ctx,
+					logger.Session("before-each"),
+					"INSERT INTO schema_version (version, name, date) VALUES (100, 'from-the-future', 0)",
+				)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fails to use the database", func() {
+				_, err := sql.New(logger, db).Tasks()
+				Expect(err).To(MatchError(fmt.Sprintf(
+					"schema version 100 is newer than the latest version %d",
+					sql.LatestSchemaVersion(),
+				)))
+			})
+		})
+	})
+
+	Context("when values contain quotes and placeholders", func() {
+		It("stores them as they are", func() {
+			repo := sql.New(logger, db)
+			t := &task.Task{Name: "it's-a-task?", State: task.StateReady}
+			Expect(repo.CreateTask(t)).To(Succeed())
+			Expect(repo.CreateTask(&task.Task{Name: "' OR '1'='1", State: task.StateReady})).To(Succeed())
+
+			found, err := repo.FindTaskByName("it's-a-task?")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(t))
+
+			t.Name = `"task-b" $1 ?`
+			Expect(repo.UpdateTask(t)).To(Succeed())
+			found, err = repo.FindTaskByID(t.ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found.Name).To(Equal(`"task-b" $1 ?`))
+
+			found, err = repo.FindTaskByName("' OR '1'='1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found.Name).To(Equal("' OR '1'='1"))
+		})
+	})
+
 	Context("when db is in a weird state", func() {
 		BeforeEach(func() {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
@@ -65,6 +381,90 @@ var _ = Describe("SQL Repo", func() {
 		})
 	})
 
+	Context("when the events table was created before events had structured fields", func() {
+		BeforeEach(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+			defer cancel()
+
+			logger := logger.Session("before-each")
+			for _, q := range []string{
+				"CREATE TABLE events (id int, title varchar(255), date bigint, type int, task_id int)",
+				"INSERT INTO events VALUES (1, 'Set state on task ''task-a'' from Ready to Running', 5, 2, 0)",
+			} {
+				_, err := db.Exec(ctx, logger, q)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("adds the columns and upgrades the events by parsing their titles", func() {
+			events, err := sql.New(logger, db).Events()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(Equal([]*task.Event{
+				&task.Event{
+					ID:       1,
+					Title:    "Set state on task 'task-a' from Ready to Running",
+					Date:     5,
+					Type:     task.EventTypeSetState,
+					TaskID:   0,
+					OldValue: "Ready",
+					NewValue: "Running",
+				},
+			}))
+		})
+	})
+
+	Context("when the tables were created before tasks had deadlines", func() {
+		BeforeEach(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
+			defer cancel()
+
+			// These are the tables that the first version of this package created.
+			logger := logger.Session("before-each")
+			for _, q := range []string{
+				`
+CREATE TABLE tasks (
+  id int NOT NULL PRIMARY KEY,
+  name varchar(255) NOT NULL,
+  start_date bigint NOT NULL,
+  priority int NOT NULL,
+  state varchar(16) NOT NULL
+)
+`,
+				`
+CREATE TABLE events (
+  id int NOT NULL PRIMARY KEY,
+  title varchar(255) NOT NULL,
+  date bigint NOT NULL,
+  type int NOT NULL,
+  task_id int NOT NULL
+)
+`,
+				"INSERT INTO tasks VALUES (1, 'task-a', 5, 2, 'Ready')",
+			} {
+				_, err := db.Exec(ctx, logger, q)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("adds the deadline column, and reads and writes the tasks", func() {
+			repo := sql.New(logger, db)
+
+			t, err := repo.FindTaskByID(1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t).NotTo(BeNil())
+			Expect(t.Name).To(Equal("task-a"))
+			Expect(t.Deadline).To(BeZero())
+
+			t.Deadline = 10
+			Expect(repo.UpdateTask(t)).To(Succeed())
+
+			t, err = repo.FindTaskByID(1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(t.Deadline).To(Equal(int64(10)))
+			Expect(repo.Tasks()).To(HaveLen(1))
+		})
+	})
+
 	Context("benchmarking", func() {
 		Measure("CRUD'ing 10 tasks with one repo", func(b Benchmarker) {
 			repo := sql.New(logger, db)
@@ -91,7 +491,7 @@ var _ = Describe("SQL Repo", func() {
 			Expect(runtime.Seconds()).To(BeNumerically("<", 3))
 		}, 5)
 	})
-})
+}
 
 func cleanTestDB(logger lager.Logger, db *sql.DB, dsn string) {
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*3)
@@ -0,0 +1,123 @@
+package sql
+
+import (
+	stdlibsql "database/sql"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/user"
+)
+
+const userColumns = `name, password_hash`
+
+func (r *repo) CreateUser(u *user.User) error {
+	logger := r.logger.Session("create-user")
+	logger.Debug("begin", lager.Data{"name": u.Name})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	q := `INSERT INTO users (name, password_hash) VALUES (?, ?)`
+	if _, err := r.db.Exec(ctx, logger, q, u.Name, u.PasswordHash); err != nil {
+		logger.Error("exec", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) Users() ([]*user.User, error) {
+	logger := r.logger.Session("users")
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, logger, "SELECT "+userColumns+" FROM users ORDER BY name")
+	if err != nil {
+		logger.Error("query", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]*user.User, 0)
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			logger.Error("scan", err)
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("rows", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *repo) FindUserByName(name string) (*user.User, error) {
+	logger := r.logger.Session("find-user-by-name")
+	logger.Debug("begin", lager.Data{"name": name})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	q := `SELECT ` + userColumns + ` FROM users WHERE name = ?`
+	u, err := scanUser(r.db.QueryRow(ctx, logger, q, name))
+	if err == stdlibsql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		logger.Error("scan", err)
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (r *repo) DeleteUser(u *user.User) error {
+	logger := r.logger.Session("delete-user")
+	logger.Debug("begin", lager.Data{"name": u.Name})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	if _, err := r.db.Exec(ctx, logger, `DELETE FROM users WHERE name = ?`, u.Name); err != nil {
+		logger.Error("exec", err)
+		return err
+	}
+
+	return nil
+}
+
+func scanUser(s scanner) (*user.User, error) {
+	u := new(user.User)
+	if err := s.Scan(&u.Name, &u.PasswordHash); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
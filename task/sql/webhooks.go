@@ -0,0 +1,292 @@
+package sql
+
+import (
+	stdlibsql "database/sql"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+)
+
+const webhookColumns = `id, url, event_types, states, secret, owner`
+
+const deadLetterColumns = `id, webhook_id, url, event, attempts, error, date, owner`
+
+func (r *repo) CreateWebhook(w *webhook.Webhook) error {
+	logger := r.logger.Session("create-webhook")
+	logger.Debug("begin", lager.Data{"url": w.URL})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	if err := r.ensureContextExists(ctx, logger); err != nil {
+		logger.Error("ensure-context-exists", err)
+		return err
+	}
+
+	q := `
+INSERT INTO webhooks (context, url, event_types, states, secret, owner)
+VALUES (?, ?, ?, ?, ?, ?)`
+	id, err := r.insert(
+		ctx,
+		logger,
+		q,
+		r.context,
+		w.URL,
+		joinEventTypes(w.EventTypes),
+		joinStates(w.States),
+		w.Secret,
+		w.Owner,
+	)
+	if err != nil {
+		logger.Error("insert", err)
+		return err
+	}
+	w.ID = id
+
+	return nil
+}
+
+func (r *repo) Webhooks() ([]*webhook.Webhook, error) {
+	logger := r.logger.Session("webhooks")
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	q := "SELECT " + webhookColumns + " FROM webhooks WHERE context = ? ORDER BY id"
+	rows, err := r.db.Query(ctx, logger, q, r.context)
+	if err != nil {
+		logger.Error("query", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	webhooks := make([]*webhook.Webhook, 0)
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			logger.Error("scan", err)
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("rows", err)
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (r *repo) FindWebhookByID(id int) (*webhook.Webhook, error) {
+	logger := r.logger.Session("find-webhook-by-id")
+	logger.Debug("begin", lager.Data{"id": id})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	q := `SELECT ` + webhookColumns + ` FROM webhooks WHERE id = ? AND context = ?`
+	w, err := scanWebhook(r.db.QueryRow(ctx, logger, q, id, r.context))
+	if err == stdlibsql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		logger.Error("scan", err)
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (r *repo) DeleteWebhook(w *webhook.Webhook) error {
+	logger := r.logger.Session("delete-webhook")
+	logger.Debug("begin", lager.Data{"id": w.ID})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	q := `DELETE FROM webhooks WHERE id = ? AND context = ?`
+	if _, err := r.db.Exec(ctx, logger, q, w.ID, r.context); err != nil {
+		logger.Error("exec", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) CreateDeadLetter(d *webhook.DeadLetter) error {
+	logger := r.logger.Session("create-dead-letter")
+	logger.Debug("begin", lager.Data{"webhook": d.WebhookID})
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return err
+	}
+
+	event, err := json.Marshal(d.Event)
+	if err != nil {
+		logger.Error("marshal-event", err)
+		return err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	if err := r.ensureContextExists(ctx, logger); err != nil {
+		logger.Error("ensure-context-exists", err)
+		return err
+	}
+
+	q := `
+INSERT INTO dead_letters (context, webhook_id, url, event, attempts, error, date, owner)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	id, err := r.insert(
+		ctx,
+		logger,
+		q,
+		r.context,
+		d.WebhookID,
+		d.URL,
+		string(event),
+		d.Attempts,
+		d.Error,
+		d.Date,
+		d.Owner,
+	)
+	if err != nil {
+		logger.Error("insert", err)
+		return err
+	}
+	d.ID = id
+
+	return nil
+}
+
+func (r *repo) DeadLetters() ([]*webhook.DeadLetter, error) {
+	logger := r.logger.Session("dead-letters")
+	logger.Debug("begin")
+	defer logger.Debug("end")
+
+	if err := r.ensureTablesExist(logger); err != nil {
+		logger.Error("ensure-tables", err)
+		return nil, err
+	}
+
+	ctx, cancel := makeCtx()
+	defer cancel()
+
+	q := "SELECT " + deadLetterColumns + " FROM dead_letters WHERE context = ? ORDER BY id"
+	rows, err := r.db.Query(ctx, logger, q, r.context)
+	if err != nil {
+		logger.Error("query", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	deadLetters := make([]*webhook.DeadLetter, 0)
+	for rows.Next() {
+		d := new(webhook.DeadLetter)
+		var event string
+		if err := rows.Scan(
+			&d.ID,
+			&d.WebhookID,
+			&d.URL,
+			&event,
+			&d.Attempts,
+			&d.Error,
+			&d.Date,
+			&d.Owner,
+		); err != nil {
+			logger.Error("scan", err)
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(event), &d.Event); err != nil {
+			logger.Error("unmarshal-event", err)
+			return nil, err
+		}
+
+		deadLetters = append(deadLetters, d)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error("rows", err)
+		return nil, err
+	}
+
+	return deadLetters, nil
+}
+
+func scanWebhook(s scanner) (*webhook.Webhook, error) {
+	w := new(webhook.Webhook)
+	var eventTypes, states string
+	if err := s.Scan(&w.ID, &w.URL, &eventTypes, &states, &w.Secret, &w.Owner); err != nil {
+		return nil, err
+	}
+
+	for _, t := range splitList(eventTypes) {
+		n, err := strconv.Atoi(t)
+		if err != nil {
+			return nil, err
+		}
+		w.EventTypes = append(w.EventTypes, task.EventType(n))
+	}
+	for _, s := range splitList(states) {
+		w.States = append(w.States, task.State(s))
+	}
+
+	return w, nil
+}
+
+// joinEventTypes stores the webhook.Webhook.EventTypes in a column, e.g., "2,3".
+func joinEventTypes(eventTypes []task.EventType) string {
+	values := make([]string, len(eventTypes))
+	for i, t := range eventTypes {
+		values[i] = strconv.Itoa(int(t))
+	}
+	return strings.Join(values, ",")
+}
+
+// joinStates stores the webhook.Webhook.States in a column, e.g., "Blocked,Finished".
+func joinStates(states []task.State) string {
+	values := make([]string, len(states))
+	for i, s := range states {
+		values[i] = string(s)
+	}
+	return strings.Join(values, ",")
+}
+
+// splitList returns the values that were stored with joinEventTypes or joinStates.
+func splitList(column string) []string {
+	if column == "" {
+		return nil
+	}
+	return strings.Split(column, ",")
+}
@@ -1,14 +1,23 @@
-// Package task contains the domain objects for anwork: Task's and Event's.
+// Package task contains the domain objects for anwork: Task's, Event's, and Dependency's.
 //
 // A Task is something that someone is working on. It could be something like "mow the lawn" or "buy
 // sister a holiday present."
 //
 // Every Task is in one of a number of different State's: Ready, Blocked, Running, or Finished. A
-// Task also has a priority which describes its relative importance to all other Task's.
+// Task also has a priority which describes its relative importance to all other Task's, and it may
+// have a deadline by which it should be finished. Task's can be grouped together with tags.
 //
-// An Event is something that happened to a Task.
+// An Event is something that happened to a Task. The Event's that have happened make up a journal,
+// which can be replayed to find the Task's and Dependency's that it describes; see Projection.
+//
+// A Dependency says that one Task cannot be finished until another Task is finished.
 package task
 
+import (
+	"encoding/json"
+	"strconv"
+)
+
 // A State describes the status of some Task.
 type State string
 
@@ -39,20 +48,75 @@ type Task struct {
 	// This is the State of the Task. See State* for possible values. A Task can go through any
 	// number of State changes over the course of its life.
 	State State `json:"state"`
+
+	// This is when the Task is due, represented by the number of seconds since January 1, 1970. A
+	// value of 0 means that the Task does not have a deadline.
+	Deadline int64 `json:"deadline"`
+
+	// These are the tags (i.e., "infra" or "project-x") that have been applied to the Task, in sorted
+	// order. Tags can be used to group Task's across State's.
+	Tags []string `json:"tags"`
+
+	// This is the revision of the Task. It is set to 1 when the Task is created, and each Repo adds
+	// 1 to it every time that the Task is updated, so that someone who changes a Task can tell if
+	// someone else changed it first.
+	Version int `json:"version"`
+
+	// This is the name of the user who owns the Task, or "" if no one does, e.g., because it was
+	// not created through the API by a user who logged in. Only its owner, and the users with whom
+	// it is shared, can see a Task that has an owner.
+	Owner string `json:"owner,omitempty"`
+
+	// These are the names of the other users with whom the owner of the Task has shared it. They
+	// can see and change the Task, but only its owner can delete it, or change who it is shared
+	// with.
+	SharedWith []string `json:"sharedWith,omitempty"`
+}
+
+// IsSharedWith returns whether or not the Task has been shared with the provided user.
+func (t *Task) IsSharedWith(user string) bool {
+	for _, name := range t.SharedWith {
+		if name == user {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTag returns whether or not the Task has been given the provided tag.
+func (t *Task) HasTag(tag string) bool {
+	for _, myTag := range t.Tags {
+		if myTag == tag {
+			return true
+		}
+	}
+	return false
 }
 
 // An EventType describes the type of Event that took place in the Manager.
 type EventType int
 
-// These are the types of Event's that can occur.
+// These are the types of Event's that can occur. The comment next to each EventType describes the
+// Event's OldValue and NewValue.
 const (
-	EventTypeCreate = iota
-	EventTypeDelete
-	EventTypeSetState
-	EventTypeNote
-	EventTypeSetPriority
+	EventTypeCreate           = iota // NewValue is the name. Snapshot is the Task. OldValue: see RestoredFrom.
+	EventTypeDelete                  // OldValue is the name of the Task. Snapshot is the Task.
+	EventTypeSetState                // OldValue and NewValue are State's.
+	EventTypeNote                    // Note is the body of the note.
+	EventTypeSetPriority             // OldValue and NewValue are priorities.
+	EventTypeSetDeadline             // OldValue and NewValue are deadlines (0 means no deadline).
+	EventTypeAddDependency           // NewValue is the name of the Task it depends on. Snapshot: see DependsOnID.
+	EventTypeRemoveDependency        // OldValue is the name of the Task it depended on. Snapshot: see DependsOnID.
+	EventTypeAddTag                  // NewValue is the tag.
+	EventTypeRemoveTag               // OldValue is the tag.
+	EventTypeRename                  // OldValue and NewValue are names.
+	EventTypeReset                   // Snapshot is everything that was reset. TaskID is NoTaskID.
+	EventTypeRestore                 // NewValue is the number of restored Task's. TaskID is NoTaskID.
 )
 
+// NoTaskID is the TaskID of an Event that is not about any one Task, e.g., an EventTypeReset Event.
+const NoTaskID = -1
+
 // An Event is something that took place. Each Event is associated with only one Task.
 type Event struct {
 	// Unique identifier for the Event.
@@ -65,4 +129,67 @@ type Event struct {
 	Type EventType `json:"type"`
 	// The ID of the Task to which this Event refers.
 	TaskID int `json:"taskid"`
+
+	// The value of the changed property of the Task before the Event took place, e.g., the old State
+	// for an EventTypeSetState Event. See the EventType's for the meaning of this value.
+	OldValue string `json:"oldValue"`
+	// The value of the changed property of the Task after the Event took place, e.g., the new State
+	// for an EventTypeSetState Event. See the EventType's for the meaning of this value.
+	NewValue string `json:"newValue"`
+	// The body of the note for an EventTypeNote Event.
+	Note string `json:"note"`
+	// The name of the user that caused the Event to take place, or "" if it is unknown.
+	Actor string `json:"actor"`
+
+	// The ID of the first Event that was created by the same operation as this Event (e.g., when
+	// finishing a Task unblocks another Task), or nil if this Event is the first.
+	Cause *int `json:"cause,omitempty"`
+	// The ID of the first Event of the operation that this Event undoes or redoes, or nil if this
+	// Event was not created by an undo or redo. Only the first Event of an operation sets this.
+	Reverts *int `json:"reverts,omitempty"`
+	// The JSON encoding of the Task that an EventTypeCreate Event created, or of what an
+	// EventTypeDelete Event (the Task) or an EventTypeReset Event (everything) removed, so that it
+	// can be restored.
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+// RestoredFrom returns the ID that a Task had before it was deleted if this EventTypeCreate Event
+// restored it, e.g., by undoing the deletion. The ID is stored as the OldValue of the Event.
+func (e *Event) RestoredFrom() (int, bool) {
+	if e.Type != EventTypeCreate || e.OldValue == "" {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(e.OldValue)
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// DependsOnID returns the ID of the Task on which the Task depends (or depended) if this
+// EventTypeAddDependency or EventTypeRemoveDependency Event recorded it. The Dependency is stored as
+// the Snapshot of the Event; older Event's only have the name of the Task.
+func (e *Event) DependsOnID() (int, bool) {
+	if (e.Type != EventTypeAddDependency && e.Type != EventTypeRemoveDependency) ||
+		e.Snapshot == "" {
+		return 0, false
+	}
+
+	var dependency Dependency
+	if err := json.Unmarshal([]byte(e.Snapshot), &dependency); err != nil {
+		return 0, false
+	}
+	return dependency.DependsOnID, true
+}
+
+// A Dependency says that one Task depends on another Task, i.e., the Task cannot be finished until
+// the Task on which it depends is finished.
+type Dependency struct {
+	// Unique identifier for the Dependency.
+	ID int `json:"id"`
+	// The ID of the Task which depends on another Task.
+	TaskID int `json:"taskid"`
+	// The ID of the Task on which the Task with ID TaskID depends.
+	DependsOnID int `json:"dependsonid"`
 }
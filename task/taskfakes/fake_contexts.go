@@ -0,0 +1,405 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package taskfakes
+
+import (
+	sync "sync"
+
+	task "github.com/ankeesler/anwork/task"
+)
+
+type FakeContexts struct {
+	ContextsStub        func() ([]string, error)
+	contextsMutex       sync.RWMutex
+	contextsArgsForCall []struct {
+	}
+	contextsReturns struct {
+		result1 []string
+		result2 error
+	}
+	contextsReturnsOnCall map[int]struct {
+		result1 []string
+		result2 error
+	}
+	CopyContextStub        func(string, string) error
+	copyContextMutex       sync.RWMutex
+	copyContextArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	copyContextReturns struct {
+		result1 error
+	}
+	copyContextReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CreateContextStub        func(string) error
+	createContextMutex       sync.RWMutex
+	createContextArgsForCall []struct {
+		arg1 string
+	}
+	createContextReturns struct {
+		result1 error
+	}
+	createContextReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteContextStub        func(string) error
+	deleteContextMutex       sync.RWMutex
+	deleteContextArgsForCall []struct {
+		arg1 string
+	}
+	deleteContextReturns struct {
+		result1 error
+	}
+	deleteContextReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RepoStub        func(string) (task.Repo, error)
+	repoMutex       sync.RWMutex
+	repoArgsForCall []struct {
+		arg1 string
+	}
+	repoReturns struct {
+		result1 task.Repo
+		result2 error
+	}
+	repoReturnsOnCall map[int]struct {
+		result1 task.Repo
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeContexts) Contexts() ([]string, error) {
+	fake.contextsMutex.Lock()
+	ret, specificReturn := fake.contextsReturnsOnCall[len(fake.contextsArgsForCall)]
+	fake.contextsArgsForCall = append(fake.contextsArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Contexts", []interface{}{})
+	fake.contextsMutex.Unlock()
+	if fake.ContextsStub != nil {
+		return fake.ContextsStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.contextsReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeContexts) ContextsCallCount() int {
+	fake.contextsMutex.RLock()
+	defer fake.contextsMutex.RUnlock()
+	return len(fake.contextsArgsForCall)
+}
+
+func (fake *FakeContexts) ContextsCalls(stub func() ([]string, error)) {
+	fake.contextsMutex.Lock()
+	defer fake.contextsMutex.Unlock()
+	fake.ContextsStub = stub
+}
+
+func (fake *FakeContexts) ContextsReturns(result1 []string, result2 error) {
+	fake.contextsMutex.Lock()
+	defer fake.contextsMutex.Unlock()
+	fake.ContextsStub = nil
+	fake.contextsReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContexts) ContextsReturnsOnCall(i int, result1 []string, result2 error) {
+	fake.contextsMutex.Lock()
+	defer fake.contextsMutex.Unlock()
+	fake.ContextsStub = nil
+	if fake.contextsReturnsOnCall == nil {
+		fake.contextsReturnsOnCall = make(map[int]struct {
+			result1 []string
+			result2 error
+		})
+	}
+	fake.contextsReturnsOnCall[i] = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContexts) CopyContext(arg1 string, arg2 string) error {
+	fake.copyContextMutex.Lock()
+	ret, specificReturn := fake.copyContextReturnsOnCall[len(fake.copyContextArgsForCall)]
+	fake.copyContextArgsForCall = append(fake.copyContextArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.recordInvocation("CopyContext", []interface{}{arg1, arg2})
+	fake.copyContextMutex.Unlock()
+	if fake.CopyContextStub != nil {
+		return fake.CopyContextStub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.copyContextReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeContexts) CopyContextCallCount() int {
+	fake.copyContextMutex.RLock()
+	defer fake.copyContextMutex.RUnlock()
+	return len(fake.copyContextArgsForCall)
+}
+
+func (fake *FakeContexts) CopyContextCalls(stub func(string, string) error) {
+	fake.copyContextMutex.Lock()
+	defer fake.copyContextMutex.Unlock()
+	fake.CopyContextStub = stub
+}
+
+func (fake *FakeContexts) CopyContextArgsForCall(i int) (string, string) {
+	fake.copyContextMutex.RLock()
+	defer fake.copyContextMutex.RUnlock()
+	argsForCall := fake.copyContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeContexts) CopyContextReturns(result1 error) {
+	fake.copyContextMutex.Lock()
+	defer fake.copyContextMutex.Unlock()
+	fake.CopyContextStub = nil
+	fake.copyContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContexts) CopyContextReturnsOnCall(i int, result1 error) {
+	fake.copyContextMutex.Lock()
+	defer fake.copyContextMutex.Unlock()
+	fake.CopyContextStub = nil
+	if fake.copyContextReturnsOnCall == nil {
+		fake.copyContextReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.copyContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContexts) CreateContext(arg1 string) error {
+	fake.createContextMutex.Lock()
+	ret, specificReturn := fake.createContextReturnsOnCall[len(fake.createContextArgsForCall)]
+	fake.createContextArgsForCall = append(fake.createContextArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("CreateContext", []interface{}{arg1})
+	fake.createContextMutex.Unlock()
+	if fake.CreateContextStub != nil {
+		return fake.CreateContextStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.createContextReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeContexts) CreateContextCallCount() int {
+	fake.createContextMutex.RLock()
+	defer fake.createContextMutex.RUnlock()
+	return len(fake.createContextArgsForCall)
+}
+
+func (fake *FakeContexts) CreateContextCalls(stub func(string) error) {
+	fake.createContextMutex.Lock()
+	defer fake.createContextMutex.Unlock()
+	fake.CreateContextStub = stub
+}
+
+func (fake *FakeContexts) CreateContextArgsForCall(i int) string {
+	fake.createContextMutex.RLock()
+	defer fake.createContextMutex.RUnlock()
+	argsForCall := fake.createContextArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeContexts) CreateContextReturns(result1 error) {
+	fake.createContextMutex.Lock()
+	defer fake.createContextMutex.Unlock()
+	fake.CreateContextStub = nil
+	fake.createContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContexts) CreateContextReturnsOnCall(i int, result1 error) {
+	fake.createContextMutex.Lock()
+	defer fake.createContextMutex.Unlock()
+	fake.CreateContextStub = nil
+	if fake.createContextReturnsOnCall == nil {
+		fake.createContextReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContexts) DeleteContext(arg1 string) error {
+	fake.deleteContextMutex.Lock()
+	ret, specificReturn := fake.deleteContextReturnsOnCall[len(fake.deleteContextArgsForCall)]
+	fake.deleteContextArgsForCall = append(fake.deleteContextArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("DeleteContext", []interface{}{arg1})
+	fake.deleteContextMutex.Unlock()
+	if fake.DeleteContextStub != nil {
+		return fake.DeleteContextStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.deleteContextReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeContexts) DeleteContextCallCount() int {
+	fake.deleteContextMutex.RLock()
+	defer fake.deleteContextMutex.RUnlock()
+	return len(fake.deleteContextArgsForCall)
+}
+
+func (fake *FakeContexts) DeleteContextCalls(stub func(string) error) {
+	fake.deleteContextMutex.Lock()
+	defer fake.deleteContextMutex.Unlock()
+	fake.DeleteContextStub = stub
+}
+
+func (fake *FakeContexts) DeleteContextArgsForCall(i int) string {
+	fake.deleteContextMutex.RLock()
+	defer fake.deleteContextMutex.RUnlock()
+	argsForCall := fake.deleteContextArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeContexts) DeleteContextReturns(result1 error) {
+	fake.deleteContextMutex.Lock()
+	defer fake.deleteContextMutex.Unlock()
+	fake.DeleteContextStub = nil
+	fake.deleteContextReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContexts) DeleteContextReturnsOnCall(i int, result1 error) {
+	fake.deleteContextMutex.Lock()
+	defer fake.deleteContextMutex.Unlock()
+	fake.DeleteContextStub = nil
+	if fake.deleteContextReturnsOnCall == nil {
+		fake.deleteContextReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteContextReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeContexts) Repo(arg1 string) (task.Repo, error) {
+	fake.repoMutex.Lock()
+	ret, specificReturn := fake.repoReturnsOnCall[len(fake.repoArgsForCall)]
+	fake.repoArgsForCall = append(fake.repoArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("Repo", []interface{}{arg1})
+	fake.repoMutex.Unlock()
+	if fake.RepoStub != nil {
+		return fake.RepoStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.repoReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeContexts) RepoCallCount() int {
+	fake.repoMutex.RLock()
+	defer fake.repoMutex.RUnlock()
+	return len(fake.repoArgsForCall)
+}
+
+func (fake *FakeContexts) RepoCalls(stub func(string) (task.Repo, error)) {
+	fake.repoMutex.Lock()
+	defer fake.repoMutex.Unlock()
+	fake.RepoStub = stub
+}
+
+func (fake *FakeContexts) RepoArgsForCall(i int) string {
+	fake.repoMutex.RLock()
+	defer fake.repoMutex.RUnlock()
+	argsForCall := fake.repoArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeContexts) RepoReturns(result1 task.Repo, result2 error) {
+	fake.repoMutex.Lock()
+	defer fake.repoMutex.Unlock()
+	fake.RepoStub = nil
+	fake.repoReturns = struct {
+		result1 task.Repo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContexts) RepoReturnsOnCall(i int, result1 task.Repo, result2 error) {
+	fake.repoMutex.Lock()
+	defer fake.repoMutex.Unlock()
+	fake.RepoStub = nil
+	if fake.repoReturnsOnCall == nil {
+		fake.repoReturnsOnCall = make(map[int]struct {
+			result1 task.Repo
+			result2 error
+		})
+	}
+	fake.repoReturnsOnCall[i] = struct {
+		result1 task.Repo
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeContexts) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.contextsMutex.RLock()
+	defer fake.contextsMutex.RUnlock()
+	fake.copyContextMutex.RLock()
+	defer fake.copyContextMutex.RUnlock()
+	fake.createContextMutex.RLock()
+	defer fake.createContextMutex.RUnlock()
+	fake.deleteContextMutex.RLock()
+	defer fake.deleteContextMutex.RUnlock()
+	fake.repoMutex.RLock()
+	defer fake.repoMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeContexts) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ task.Contexts = new(FakeContexts)
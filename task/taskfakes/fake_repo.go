@@ -8,6 +8,17 @@ import (
 )
 
 type FakeRepo struct {
+	CreateDependencyStub        func(*task.Dependency) error
+	createDependencyMutex       sync.RWMutex
+	createDependencyArgsForCall []struct {
+		arg1 *task.Dependency
+	}
+	createDependencyReturns struct {
+		result1 error
+	}
+	createDependencyReturnsOnCall map[int]struct {
+		result1 error
+	}
 	CreateEventStub        func(*task.Event) error
 	createEventMutex       sync.RWMutex
 	createEventArgsForCall []struct {
@@ -30,6 +41,17 @@ type FakeRepo struct {
 	createTaskReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteDependencyStub        func(*task.Dependency) error
+	deleteDependencyMutex       sync.RWMutex
+	deleteDependencyArgsForCall []struct {
+		arg1 *task.Dependency
+	}
+	deleteDependencyReturns struct {
+		result1 error
+	}
+	deleteDependencyReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteEventStub        func(*task.Event) error
 	deleteEventMutex       sync.RWMutex
 	deleteEventArgsForCall []struct {
@@ -52,6 +74,18 @@ type FakeRepo struct {
 	deleteTaskReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DependenciesStub        func() ([]*task.Dependency, error)
+	dependenciesMutex       sync.RWMutex
+	dependenciesArgsForCall []struct {
+	}
+	dependenciesReturns struct {
+		result1 []*task.Dependency
+		result2 error
+	}
+	dependenciesReturnsOnCall map[int]struct {
+		result1 []*task.Dependency
+		result2 error
+	}
 	EventsStub        func() ([]*task.Event, error)
 	eventsMutex       sync.RWMutex
 	eventsArgsForCall []struct {
@@ -64,6 +98,19 @@ type FakeRepo struct {
 		result1 []*task.Event
 		result2 error
 	}
+	FindDependencyByIDStub        func(int) (*task.Dependency, error)
+	findDependencyByIDMutex       sync.RWMutex
+	findDependencyByIDArgsForCall []struct {
+		arg1 int
+	}
+	findDependencyByIDReturns struct {
+		result1 *task.Dependency
+		result2 error
+	}
+	findDependencyByIDReturnsOnCall map[int]struct {
+		result1 *task.Dependency
+		result2 error
+	}
 	FindEventByIDStub        func(int) (*task.Event, error)
 	findEventByIDMutex       sync.RWMutex
 	findEventByIDArgsForCall []struct {
@@ -130,6 +177,66 @@ type FakeRepo struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FakeRepo) CreateDependency(arg1 *task.Dependency) error {
+	fake.createDependencyMutex.Lock()
+	ret, specificReturn := fake.createDependencyReturnsOnCall[len(fake.createDependencyArgsForCall)]
+	fake.createDependencyArgsForCall = append(fake.createDependencyArgsForCall, struct {
+		arg1 *task.Dependency
+	}{arg1})
+	fake.recordInvocation("CreateDependency", []interface{}{arg1})
+	fake.createDependencyMutex.Unlock()
+	if fake.CreateDependencyStub != nil {
+		return fake.CreateDependencyStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.createDependencyReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepo) CreateDependencyCallCount() int {
+	fake.createDependencyMutex.RLock()
+	defer fake.createDependencyMutex.RUnlock()
+	return len(fake.createDependencyArgsForCall)
+}
+
+func (fake *FakeRepo) CreateDependencyCalls(stub func(*task.Dependency) error) {
+	fake.createDependencyMutex.Lock()
+	defer fake.createDependencyMutex.Unlock()
+	fake.CreateDependencyStub = stub
+}
+
+func (fake *FakeRepo) CreateDependencyArgsForCall(i int) *task.Dependency {
+	fake.createDependencyMutex.RLock()
+	defer fake.createDependencyMutex.RUnlock()
+	argsForCall := fake.createDependencyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) CreateDependencyReturns(result1 error) {
+	fake.createDependencyMutex.Lock()
+	defer fake.createDependencyMutex.Unlock()
+	fake.CreateDependencyStub = nil
+	fake.createDependencyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) CreateDependencyReturnsOnCall(i int, result1 error) {
+	fake.createDependencyMutex.Lock()
+	defer fake.createDependencyMutex.Unlock()
+	fake.CreateDependencyStub = nil
+	if fake.createDependencyReturnsOnCall == nil {
+		fake.createDependencyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createDependencyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRepo) CreateEvent(arg1 *task.Event) error {
 	fake.createEventMutex.Lock()
 	ret, specificReturn := fake.createEventReturnsOnCall[len(fake.createEventArgsForCall)]
@@ -250,6 +357,66 @@ func (fake *FakeRepo) CreateTaskReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRepo) DeleteDependency(arg1 *task.Dependency) error {
+	fake.deleteDependencyMutex.Lock()
+	ret, specificReturn := fake.deleteDependencyReturnsOnCall[len(fake.deleteDependencyArgsForCall)]
+	fake.deleteDependencyArgsForCall = append(fake.deleteDependencyArgsForCall, struct {
+		arg1 *task.Dependency
+	}{arg1})
+	fake.recordInvocation("DeleteDependency", []interface{}{arg1})
+	fake.deleteDependencyMutex.Unlock()
+	if fake.DeleteDependencyStub != nil {
+		return fake.DeleteDependencyStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.deleteDependencyReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepo) DeleteDependencyCallCount() int {
+	fake.deleteDependencyMutex.RLock()
+	defer fake.deleteDependencyMutex.RUnlock()
+	return len(fake.deleteDependencyArgsForCall)
+}
+
+func (fake *FakeRepo) DeleteDependencyCalls(stub func(*task.Dependency) error) {
+	fake.deleteDependencyMutex.Lock()
+	defer fake.deleteDependencyMutex.Unlock()
+	fake.DeleteDependencyStub = stub
+}
+
+func (fake *FakeRepo) DeleteDependencyArgsForCall(i int) *task.Dependency {
+	fake.deleteDependencyMutex.RLock()
+	defer fake.deleteDependencyMutex.RUnlock()
+	argsForCall := fake.deleteDependencyArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) DeleteDependencyReturns(result1 error) {
+	fake.deleteDependencyMutex.Lock()
+	defer fake.deleteDependencyMutex.Unlock()
+	fake.DeleteDependencyStub = nil
+	fake.deleteDependencyReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) DeleteDependencyReturnsOnCall(i int, result1 error) {
+	fake.deleteDependencyMutex.Lock()
+	defer fake.deleteDependencyMutex.Unlock()
+	fake.DeleteDependencyStub = nil
+	if fake.deleteDependencyReturnsOnCall == nil {
+		fake.deleteDependencyReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteDependencyReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeRepo) DeleteEvent(arg1 *task.Event) error {
 	fake.deleteEventMutex.Lock()
 	ret, specificReturn := fake.deleteEventReturnsOnCall[len(fake.deleteEventArgsForCall)]
@@ -370,6 +537,61 @@ func (fake *FakeRepo) DeleteTaskReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeRepo) Dependencies() ([]*task.Dependency, error) {
+	fake.dependenciesMutex.Lock()
+	ret, specificReturn := fake.dependenciesReturnsOnCall[len(fake.dependenciesArgsForCall)]
+	fake.dependenciesArgsForCall = append(fake.dependenciesArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Dependencies", []interface{}{})
+	fake.dependenciesMutex.Unlock()
+	if fake.DependenciesStub != nil {
+		return fake.DependenciesStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.dependenciesReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepo) DependenciesCallCount() int {
+	fake.dependenciesMutex.RLock()
+	defer fake.dependenciesMutex.RUnlock()
+	return len(fake.dependenciesArgsForCall)
+}
+
+func (fake *FakeRepo) DependenciesCalls(stub func() ([]*task.Dependency, error)) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = stub
+}
+
+func (fake *FakeRepo) DependenciesReturns(result1 []*task.Dependency, result2 error) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = nil
+	fake.dependenciesReturns = struct {
+		result1 []*task.Dependency
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) DependenciesReturnsOnCall(i int, result1 []*task.Dependency, result2 error) {
+	fake.dependenciesMutex.Lock()
+	defer fake.dependenciesMutex.Unlock()
+	fake.DependenciesStub = nil
+	if fake.dependenciesReturnsOnCall == nil {
+		fake.dependenciesReturnsOnCall = make(map[int]struct {
+			result1 []*task.Dependency
+			result2 error
+		})
+	}
+	fake.dependenciesReturnsOnCall[i] = struct {
+		result1 []*task.Dependency
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRepo) Events() ([]*task.Event, error) {
 	fake.eventsMutex.Lock()
 	ret, specificReturn := fake.eventsReturnsOnCall[len(fake.eventsArgsForCall)]
@@ -425,6 +647,69 @@ func (fake *FakeRepo) EventsReturnsOnCall(i int, result1 []*task.Event, result2
 	}{result1, result2}
 }
 
+func (fake *FakeRepo) FindDependencyByID(arg1 int) (*task.Dependency, error) {
+	fake.findDependencyByIDMutex.Lock()
+	ret, specificReturn := fake.findDependencyByIDReturnsOnCall[len(fake.findDependencyByIDArgsForCall)]
+	fake.findDependencyByIDArgsForCall = append(fake.findDependencyByIDArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	fake.recordInvocation("FindDependencyByID", []interface{}{arg1})
+	fake.findDependencyByIDMutex.Unlock()
+	if fake.FindDependencyByIDStub != nil {
+		return fake.FindDependencyByIDStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.findDependencyByIDReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepo) FindDependencyByIDCallCount() int {
+	fake.findDependencyByIDMutex.RLock()
+	defer fake.findDependencyByIDMutex.RUnlock()
+	return len(fake.findDependencyByIDArgsForCall)
+}
+
+func (fake *FakeRepo) FindDependencyByIDCalls(stub func(int) (*task.Dependency, error)) {
+	fake.findDependencyByIDMutex.Lock()
+	defer fake.findDependencyByIDMutex.Unlock()
+	fake.FindDependencyByIDStub = stub
+}
+
+func (fake *FakeRepo) FindDependencyByIDArgsForCall(i int) int {
+	fake.findDependencyByIDMutex.RLock()
+	defer fake.findDependencyByIDMutex.RUnlock()
+	argsForCall := fake.findDependencyByIDArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) FindDependencyByIDReturns(result1 *task.Dependency, result2 error) {
+	fake.findDependencyByIDMutex.Lock()
+	defer fake.findDependencyByIDMutex.Unlock()
+	fake.FindDependencyByIDStub = nil
+	fake.findDependencyByIDReturns = struct {
+		result1 *task.Dependency
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) FindDependencyByIDReturnsOnCall(i int, result1 *task.Dependency, result2 error) {
+	fake.findDependencyByIDMutex.Lock()
+	defer fake.findDependencyByIDMutex.Unlock()
+	fake.FindDependencyByIDStub = nil
+	if fake.findDependencyByIDReturnsOnCall == nil {
+		fake.findDependencyByIDReturnsOnCall = make(map[int]struct {
+			result1 *task.Dependency
+			result2 error
+		})
+	}
+	fake.findDependencyByIDReturnsOnCall[i] = struct {
+		result1 *task.Dependency
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeRepo) FindEventByID(arg1 int) (*task.Event, error) {
 	fake.findEventByIDMutex.Lock()
 	ret, specificReturn := fake.findEventByIDReturnsOnCall[len(fake.findEventByIDArgsForCall)]
@@ -732,16 +1017,24 @@ func (fake *FakeRepo) UpdateTaskReturnsOnCall(i int, result1 error) {
 func (fake *FakeRepo) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.createDependencyMutex.RLock()
+	defer fake.createDependencyMutex.RUnlock()
 	fake.createEventMutex.RLock()
 	defer fake.createEventMutex.RUnlock()
 	fake.createTaskMutex.RLock()
 	defer fake.createTaskMutex.RUnlock()
+	fake.deleteDependencyMutex.RLock()
+	defer fake.deleteDependencyMutex.RUnlock()
 	fake.deleteEventMutex.RLock()
 	defer fake.deleteEventMutex.RUnlock()
 	fake.deleteTaskMutex.RLock()
 	defer fake.deleteTaskMutex.RUnlock()
+	fake.dependenciesMutex.RLock()
+	defer fake.dependenciesMutex.RUnlock()
 	fake.eventsMutex.RLock()
 	defer fake.eventsMutex.RUnlock()
+	fake.findDependencyByIDMutex.RLock()
+	defer fake.findDependencyByIDMutex.RUnlock()
 	fake.findEventByIDMutex.RLock()
 	defer fake.findEventByIDMutex.RUnlock()
 	fake.findTaskByIDMutex.RLock()
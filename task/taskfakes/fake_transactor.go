@@ -0,0 +1,110 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package taskfakes
+
+import (
+	sync "sync"
+
+	task "github.com/ankeesler/anwork/task"
+)
+
+type FakeTransactor struct {
+	WithTxStub        func(func(task.Repo) error) error
+	withTxMutex       sync.RWMutex
+	withTxArgsForCall []struct {
+		arg1 func(task.Repo) error
+	}
+	withTxReturns struct {
+		result1 error
+	}
+	withTxReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeTransactor) WithTx(arg1 func(task.Repo) error) error {
+	fake.withTxMutex.Lock()
+	ret, specificReturn := fake.withTxReturnsOnCall[len(fake.withTxArgsForCall)]
+	fake.withTxArgsForCall = append(fake.withTxArgsForCall, struct {
+		arg1 func(task.Repo) error
+	}{arg1})
+	fake.recordInvocation("WithTx", []interface{}{arg1})
+	fake.withTxMutex.Unlock()
+	if fake.WithTxStub != nil {
+		return fake.WithTxStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.withTxReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeTransactor) WithTxCallCount() int {
+	fake.withTxMutex.RLock()
+	defer fake.withTxMutex.RUnlock()
+	return len(fake.withTxArgsForCall)
+}
+
+func (fake *FakeTransactor) WithTxCalls(stub func(func(task.Repo) error) error) {
+	fake.withTxMutex.Lock()
+	defer fake.withTxMutex.Unlock()
+	fake.WithTxStub = stub
+}
+
+func (fake *FakeTransactor) WithTxArgsForCall(i int) func(task.Repo) error {
+	fake.withTxMutex.RLock()
+	defer fake.withTxMutex.RUnlock()
+	argsForCall := fake.withTxArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeTransactor) WithTxReturns(result1 error) {
+	fake.withTxMutex.Lock()
+	defer fake.withTxMutex.Unlock()
+	fake.WithTxStub = nil
+	fake.withTxReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeTransactor) WithTxReturnsOnCall(i int, result1 error) {
+	fake.withTxMutex.Lock()
+	defer fake.withTxMutex.Unlock()
+	fake.WithTxStub = nil
+	if fake.withTxReturnsOnCall == nil {
+		fake.withTxReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.withTxReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeTransactor) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.withTxMutex.RLock()
+	defer fake.withTxMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeTransactor) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ task.Transactor = new(FakeTransactor)
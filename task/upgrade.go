@@ -0,0 +1,143 @@
+package task
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// An eventTitleParser recovers the structured fields of an Event from its Title.
+type eventTitleParser struct {
+	pattern *regexp.Regexp
+	parse   func(event *Event, matches []string) bool
+}
+
+// These are the formats of the Title's written for each EventType before Event's carried
+// structured fields.
+var eventTitleParsers = map[EventType]eventTitleParser{
+	EventTypeCreate: eventTitleParser{
+		pattern: regexp.MustCompile(`^Created task '(.*)'$`),
+		parse: func(event *Event, matches []string) bool {
+			event.NewValue = matches[1]
+			return true
+		},
+	},
+	EventTypeDelete: eventTitleParser{
+		pattern: regexp.MustCompile(`^Deleted task '(.*)'$`),
+		parse: func(event *Event, matches []string) bool {
+			event.OldValue = matches[1]
+			return true
+		},
+	},
+	EventTypeSetState: eventTitleParser{
+		pattern: regexp.MustCompile(`^Set state on task '.*' from (\w+) to (\w+)$`),
+		parse: func(event *Event, matches []string) bool {
+			event.OldValue, event.NewValue = matches[1], matches[2]
+			return true
+		},
+	},
+	EventTypeNote: eventTitleParser{
+		pattern: regexp.MustCompile(`(?s)^Note added to task '.*?': (.*)$`),
+		parse: func(event *Event, matches []string) bool {
+			event.Note = matches[1]
+			return true
+		},
+	},
+	EventTypeSetPriority: eventTitleParser{
+		pattern: regexp.MustCompile(`^Set priority on task '.*' from (-?\d+) to (-?\d+)$`),
+		parse: func(event *Event, matches []string) bool {
+			event.OldValue, event.NewValue = matches[1], matches[2]
+			return true
+		},
+	},
+	EventTypeSetDeadline: eventTitleParser{
+		pattern: regexp.MustCompile(
+			`^Set deadline on task '.*' from (none|\d{4}-\d\d-\d\d \d\d:\d\d) to (none|\d{4}-\d\d-\d\d \d\d:\d\d)$`,
+		),
+		parse: func(event *Event, matches []string) bool {
+			oldDeadline, ok := parseTitleDeadline(matches[1])
+			if !ok {
+				return false
+			}
+			newDeadline, ok := parseTitleDeadline(matches[2])
+			if !ok {
+				return false
+			}
+			event.OldValue, event.NewValue = oldDeadline, newDeadline
+			return true
+		},
+	},
+	EventTypeAddDependency: eventTitleParser{
+		pattern: regexp.MustCompile(`^Added dependency of task '.*' on task '(.*)'$`),
+		parse: func(event *Event, matches []string) bool {
+			event.NewValue = matches[1]
+			return true
+		},
+	},
+	EventTypeRemoveDependency: eventTitleParser{
+		pattern: regexp.MustCompile(`^Removed dependency of task '.*' on task '(.*)'$`),
+		parse: func(event *Event, matches []string) bool {
+			event.OldValue = matches[1]
+			return true
+		},
+	},
+	EventTypeAddTag: eventTitleParser{
+		pattern: regexp.MustCompile(`^Added tag '(\S+)' to task '.*'$`),
+		parse: func(event *Event, matches []string) bool {
+			event.NewValue = matches[1]
+			return true
+		},
+	},
+	EventTypeRemoveTag: eventTitleParser{
+		pattern: regexp.MustCompile(`^Removed tag '(\S+)' from task '.*'$`),
+		parse: func(event *Event, matches []string) bool {
+			event.OldValue = matches[1]
+			return true
+		},
+	},
+}
+
+// UpgradeEvent fills in the structured fields (OldValue, NewValue, and Note) of an Event that was
+// written before Event's carried them by parsing its Title. It returns true iff the Event was
+// changed.
+//
+// An Event that already has any of its structured fields set, or whose Title cannot be parsed, is
+// left alone. The Actor of an upgraded Event is left empty since it was never recorded.
+func UpgradeEvent(event *Event) bool {
+	if event.OldValue != "" || event.NewValue != "" || event.Note != "" {
+		return false
+	}
+
+	parser, ok := eventTitleParsers[event.Type]
+	if !ok {
+		return false
+	}
+
+	matches := parser.pattern.FindStringSubmatch(event.Title)
+	if matches == nil {
+		return false
+	}
+
+	upgraded := *event
+	if !parser.parse(&upgraded, matches) || upgraded == *event {
+		return false
+	}
+	*event = upgraded
+
+	return true
+}
+
+// parseTitleDeadline converts a deadline from the format used in a Title ("none" or
+// "2006-01-02 15:04" in local time) to the format used in a structured Event field.
+func parseTitleDeadline(deadline string) (string, bool) {
+	if deadline == "none" {
+		return "0", true
+	}
+
+	t, err := time.ParseInLocation("2006-01-02 15:04", deadline, time.Local)
+	if err != nil {
+		return "", false
+	}
+
+	return strconv.FormatInt(t.Unix(), 10), true
+}
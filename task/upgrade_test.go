@@ -0,0 +1,89 @@
+package task_test
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/ankeesler/anwork/task"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("UpgradeEvent", func() {
+	deadline := strconv.FormatInt(time.Date(2018, time.December, 25, 13, 30, 0, 0, time.Local).Unix(), 10)
+
+	It("parses the titles of old events into their structured fields", func() {
+		cases := []struct {
+			eventType task.EventType
+			title     string
+			expected  task.Event
+		}{
+			{task.EventTypeCreate,
+				"Created task 'task-a'",
+				task.Event{NewValue: "task-a"}},
+			{task.EventTypeDelete,
+				"Deleted task 'task-a'",
+				task.Event{OldValue: "task-a"}},
+			{task.EventTypeSetState,
+				"Set state on task 'task-a' from Running to Finished",
+				task.Event{OldValue: "Running", NewValue: "Finished"}},
+			{task.EventTypeNote,
+				"Note added to task 'task-a': here is a note: with a colon",
+				task.Event{Note: "here is a note: with a colon"}},
+			{task.EventTypeSetPriority,
+				"Set priority on task 'task-a' from 10 to -5",
+				task.Event{OldValue: "10", NewValue: "-5"}},
+			{task.EventTypeSetDeadline,
+				"Set deadline on task 'task-a' from none to 2018-12-25 13:30",
+				task.Event{OldValue: "0", NewValue: deadline}},
+			{task.EventTypeAddDependency,
+				"Added dependency of task 'task-a' on task 'task-b'",
+				task.Event{NewValue: "task-b"}},
+			{task.EventTypeRemoveDependency,
+				"Removed dependency of task 'task-a' on task 'task-b'",
+				task.Event{OldValue: "task-b"}},
+			{task.EventTypeAddTag,
+				"Added tag 'infra' to task 'task-a'",
+				task.Event{NewValue: "infra"}},
+			{task.EventTypeRemoveTag,
+				"Removed tag 'infra' from task 'task-a'",
+				task.Event{OldValue: "infra"}},
+		}
+		for _, c := range cases {
+			event := &task.Event{ID: 1, Title: c.title, Date: 123, Type: c.eventType, TaskID: 2}
+			Expect(task.UpgradeEvent(event)).To(BeTrue(), c.title)
+
+			c.expected.ID, c.expected.Title, c.expected.Date = 1, c.title, 123
+			c.expected.Type, c.expected.TaskID = c.eventType, 2
+			Expect(*event).To(Equal(c.expected))
+		}
+	})
+
+	Context("when the event already has structured fields", func() {
+		It("leaves the event alone", func() {
+			event := &task.Event{
+				Title:    "Created task 'task-a'",
+				Type:     task.EventTypeCreate,
+				NewValue: "task-b",
+			}
+			Expect(task.UpgradeEvent(event)).To(BeFalse())
+			Expect(event.NewValue).To(Equal("task-b"))
+		})
+	})
+
+	Context("when the title cannot be parsed", func() {
+		It("leaves the event alone", func() {
+			event := &task.Event{Title: "some unknown title", Type: task.EventTypeSetState}
+			Expect(task.UpgradeEvent(event)).To(BeFalse())
+			Expect(*event).To(Equal(task.Event{Title: "some unknown title", Type: task.EventTypeSetState}))
+		})
+	})
+
+	Context("when the title does not match the type of the event", func() {
+		It("leaves the event alone", func() {
+			event := &task.Event{Title: "Created task 'task-a'", Type: task.EventTypeDelete}
+			Expect(task.UpgradeEvent(event)).To(BeFalse())
+			Expect(event.OldValue).To(BeEmpty())
+		})
+	})
+})
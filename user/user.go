@@ -0,0 +1,68 @@
+// Package user contains the accounts of the people who use the ANWORK API.
+//
+// A User logs in to the API with their name and password, and then only sees the task.Task's that
+// they own, the task.Task's that have been shared with them, and the task.Task's that have no owner.
+// Once a Repo has any User's, everyone has to log in as one of them.
+package user
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// A User is someone who logs in to the API.
+type User struct {
+	// This is the name with which the User logs in, e.g., "andrew". Every User has a different
+	// name; see ValidateName.
+	Name string `json:"name"`
+
+	// This is the bcrypt hash of the password of the User; see SetPassword.
+	PasswordHash string `json:"passwordHash"`
+}
+
+// SetPassword sets the PasswordHash of the User to the hash of a password.
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("cannot hash password: %s", err.Error())
+	}
+	u.PasswordHash = string(hash)
+	return nil
+}
+
+// CheckPassword returns whether a password is the password of the User.
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+// ValidateName returns an error if a User cannot have a name. The name of a User must only contain
+// letters, digits, '.', '_', and '-', so that it can be used in a list of names, e.g., the users
+// with whom a task.Task is shared.
+func ValidateName(name string) error {
+	valid := name != "" && strings.IndexFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("._-", r))
+	}) == -1
+	if !valid {
+		return fmt.Errorf("invalid user name '%s'", name)
+	}
+	return nil
+}
+
+//go:generate counterfeiter . Repo
+
+// A Repo stores User's. It is implemented by a task.Repo that can store them next to its
+// task.Task's.
+type Repo interface {
+	// CreateUser creates a User. It returns an error if there is already a User with the same name.
+	CreateUser(*User) error
+	// Users returns all of the User's in this Repo, ordered by name.
+	Users() ([]*User, error)
+	// FindUserByName tries to find a User with the provided name. If the User does not exist, it
+	// will return nil, nil.
+	FindUserByName(string) (*User, error)
+	// DeleteUser deletes the User with the provided name. If the User does not exist, this function
+	// will return nil.
+	DeleteUser(*User) error
+}
@@ -0,0 +1,43 @@
+package user_test
+
+import (
+	"github.com/ankeesler/anwork/user"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("User", func() {
+	Describe("SetPassword", func() {
+		It("only lets the user log in with that password", func() {
+			u := &user.User{Name: "andrew"}
+			Expect(u.SetPassword("some-password")).To(Succeed())
+			Expect(u.PasswordHash).NotTo(ContainSubstring("some-password"))
+
+			Expect(u.CheckPassword("some-password")).To(BeTrue())
+			Expect(u.CheckPassword("some-other-password")).To(BeFalse())
+			Expect(u.CheckPassword("")).To(BeFalse())
+		})
+	})
+
+	It("does not let a user without a password log in", func() {
+		Expect((&user.User{Name: "andrew"}).CheckPassword("")).To(BeFalse())
+	})
+
+	DescribeTable(
+		"ValidateName",
+		func(name string, valid bool) {
+			if valid {
+				Expect(user.ValidateName(name)).To(Succeed())
+			} else {
+				Expect(user.ValidateName(name)).To(MatchError("invalid user name '" + name + "'"))
+			}
+		},
+		Entry("letters", "andrew", true),
+		Entry("letters, digits, and punctuation", "andrew.k_2-b", true),
+		Entry("empty", "", false),
+		Entry("whitespace", "andrew k", false),
+		Entry("a comma", "andrew,bob", false),
+		Entry("a slash", "andrew/bob", false),
+	)
+})
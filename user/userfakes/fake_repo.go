@@ -0,0 +1,330 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package userfakes
+
+import (
+	sync "sync"
+
+	user "github.com/ankeesler/anwork/user"
+)
+
+type FakeRepo struct {
+	CreateUserStub        func(*user.User) error
+	createUserMutex       sync.RWMutex
+	createUserArgsForCall []struct {
+		arg1 *user.User
+	}
+	createUserReturns struct {
+		result1 error
+	}
+	createUserReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteUserStub        func(*user.User) error
+	deleteUserMutex       sync.RWMutex
+	deleteUserArgsForCall []struct {
+		arg1 *user.User
+	}
+	deleteUserReturns struct {
+		result1 error
+	}
+	deleteUserReturnsOnCall map[int]struct {
+		result1 error
+	}
+	FindUserByNameStub        func(string) (*user.User, error)
+	findUserByNameMutex       sync.RWMutex
+	findUserByNameArgsForCall []struct {
+		arg1 string
+	}
+	findUserByNameReturns struct {
+		result1 *user.User
+		result2 error
+	}
+	findUserByNameReturnsOnCall map[int]struct {
+		result1 *user.User
+		result2 error
+	}
+	UsersStub        func() ([]*user.User, error)
+	usersMutex       sync.RWMutex
+	usersArgsForCall []struct {
+	}
+	usersReturns struct {
+		result1 []*user.User
+		result2 error
+	}
+	usersReturnsOnCall map[int]struct {
+		result1 []*user.User
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRepo) CreateUser(arg1 *user.User) error {
+	fake.createUserMutex.Lock()
+	ret, specificReturn := fake.createUserReturnsOnCall[len(fake.createUserArgsForCall)]
+	fake.createUserArgsForCall = append(fake.createUserArgsForCall, struct {
+		arg1 *user.User
+	}{arg1})
+	fake.recordInvocation("CreateUser", []interface{}{arg1})
+	fake.createUserMutex.Unlock()
+	if fake.CreateUserStub != nil {
+		return fake.CreateUserStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.createUserReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepo) CreateUserCallCount() int {
+	fake.createUserMutex.RLock()
+	defer fake.createUserMutex.RUnlock()
+	return len(fake.createUserArgsForCall)
+}
+
+func (fake *FakeRepo) CreateUserCalls(stub func(*user.User) error) {
+	fake.createUserMutex.Lock()
+	defer fake.createUserMutex.Unlock()
+	fake.CreateUserStub = stub
+}
+
+func (fake *FakeRepo) CreateUserArgsForCall(i int) *user.User {
+	fake.createUserMutex.RLock()
+	defer fake.createUserMutex.RUnlock()
+	argsForCall := fake.createUserArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) CreateUserReturns(result1 error) {
+	fake.createUserMutex.Lock()
+	defer fake.createUserMutex.Unlock()
+	fake.CreateUserStub = nil
+	fake.createUserReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) CreateUserReturnsOnCall(i int, result1 error) {
+	fake.createUserMutex.Lock()
+	defer fake.createUserMutex.Unlock()
+	fake.CreateUserStub = nil
+	if fake.createUserReturnsOnCall == nil {
+		fake.createUserReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createUserReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) DeleteUser(arg1 *user.User) error {
+	fake.deleteUserMutex.Lock()
+	ret, specificReturn := fake.deleteUserReturnsOnCall[len(fake.deleteUserArgsForCall)]
+	fake.deleteUserArgsForCall = append(fake.deleteUserArgsForCall, struct {
+		arg1 *user.User
+	}{arg1})
+	fake.recordInvocation("DeleteUser", []interface{}{arg1})
+	fake.deleteUserMutex.Unlock()
+	if fake.DeleteUserStub != nil {
+		return fake.DeleteUserStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.deleteUserReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepo) DeleteUserCallCount() int {
+	fake.deleteUserMutex.RLock()
+	defer fake.deleteUserMutex.RUnlock()
+	return len(fake.deleteUserArgsForCall)
+}
+
+func (fake *FakeRepo) DeleteUserCalls(stub func(*user.User) error) {
+	fake.deleteUserMutex.Lock()
+	defer fake.deleteUserMutex.Unlock()
+	fake.DeleteUserStub = stub
+}
+
+func (fake *FakeRepo) DeleteUserArgsForCall(i int) *user.User {
+	fake.deleteUserMutex.RLock()
+	defer fake.deleteUserMutex.RUnlock()
+	argsForCall := fake.deleteUserArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) DeleteUserReturns(result1 error) {
+	fake.deleteUserMutex.Lock()
+	defer fake.deleteUserMutex.Unlock()
+	fake.DeleteUserStub = nil
+	fake.deleteUserReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) DeleteUserReturnsOnCall(i int, result1 error) {
+	fake.deleteUserMutex.Lock()
+	defer fake.deleteUserMutex.Unlock()
+	fake.DeleteUserStub = nil
+	if fake.deleteUserReturnsOnCall == nil {
+		fake.deleteUserReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteUserReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) FindUserByName(arg1 string) (*user.User, error) {
+	fake.findUserByNameMutex.Lock()
+	ret, specificReturn := fake.findUserByNameReturnsOnCall[len(fake.findUserByNameArgsForCall)]
+	fake.findUserByNameArgsForCall = append(fake.findUserByNameArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	fake.recordInvocation("FindUserByName", []interface{}{arg1})
+	fake.findUserByNameMutex.Unlock()
+	if fake.FindUserByNameStub != nil {
+		return fake.FindUserByNameStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.findUserByNameReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepo) FindUserByNameCallCount() int {
+	fake.findUserByNameMutex.RLock()
+	defer fake.findUserByNameMutex.RUnlock()
+	return len(fake.findUserByNameArgsForCall)
+}
+
+func (fake *FakeRepo) FindUserByNameCalls(stub func(string) (*user.User, error)) {
+	fake.findUserByNameMutex.Lock()
+	defer fake.findUserByNameMutex.Unlock()
+	fake.FindUserByNameStub = stub
+}
+
+func (fake *FakeRepo) FindUserByNameArgsForCall(i int) string {
+	fake.findUserByNameMutex.RLock()
+	defer fake.findUserByNameMutex.RUnlock()
+	argsForCall := fake.findUserByNameArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) FindUserByNameReturns(result1 *user.User, result2 error) {
+	fake.findUserByNameMutex.Lock()
+	defer fake.findUserByNameMutex.Unlock()
+	fake.FindUserByNameStub = nil
+	fake.findUserByNameReturns = struct {
+		result1 *user.User
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) FindUserByNameReturnsOnCall(i int, result1 *user.User, result2 error) {
+	fake.findUserByNameMutex.Lock()
+	defer fake.findUserByNameMutex.Unlock()
+	fake.FindUserByNameStub = nil
+	if fake.findUserByNameReturnsOnCall == nil {
+		fake.findUserByNameReturnsOnCall = make(map[int]struct {
+			result1 *user.User
+			result2 error
+		})
+	}
+	fake.findUserByNameReturnsOnCall[i] = struct {
+		result1 *user.User
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) Users() ([]*user.User, error) {
+	fake.usersMutex.Lock()
+	ret, specificReturn := fake.usersReturnsOnCall[len(fake.usersArgsForCall)]
+	fake.usersArgsForCall = append(fake.usersArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Users", []interface{}{})
+	fake.usersMutex.Unlock()
+	if fake.UsersStub != nil {
+		return fake.UsersStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.usersReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepo) UsersCallCount() int {
+	fake.usersMutex.RLock()
+	defer fake.usersMutex.RUnlock()
+	return len(fake.usersArgsForCall)
+}
+
+func (fake *FakeRepo) UsersCalls(stub func() ([]*user.User, error)) {
+	fake.usersMutex.Lock()
+	defer fake.usersMutex.Unlock()
+	fake.UsersStub = stub
+}
+
+func (fake *FakeRepo) UsersReturns(result1 []*user.User, result2 error) {
+	fake.usersMutex.Lock()
+	defer fake.usersMutex.Unlock()
+	fake.UsersStub = nil
+	fake.usersReturns = struct {
+		result1 []*user.User
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) UsersReturnsOnCall(i int, result1 []*user.User, result2 error) {
+	fake.usersMutex.Lock()
+	defer fake.usersMutex.Unlock()
+	fake.UsersStub = nil
+	if fake.usersReturnsOnCall == nil {
+		fake.usersReturnsOnCall = make(map[int]struct {
+			result1 []*user.User
+			result2 error
+		})
+	}
+	fake.usersReturnsOnCall[i] = struct {
+		result1 []*user.User
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createUserMutex.RLock()
+	defer fake.createUserMutex.RUnlock()
+	fake.deleteUserMutex.RLock()
+	defer fake.deleteUserMutex.RUnlock()
+	fake.findUserByNameMutex.RLock()
+	defer fake.findUserByNameMutex.RUnlock()
+	fake.usersMutex.RLock()
+	defer fake.usersMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRepo) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ user.Repo = new(FakeRepo)
@@ -0,0 +1,99 @@
+// Package usertest contains conformance tests that every task.Repo that is also a user.Repo should
+// pass.
+package usertest
+
+import (
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/user"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// RunRepoTests will run a set of tests to verify that the provided repo is a valid user.Repo
+// implementation. The createRepoFunc must return a repo that reads and writes the same users each
+// time that it is called within a test.
+func RunRepoTests(createRepoFunc func() taskpkg.Repo) {
+	var (
+		repo         user.Repo
+		userA, userB *user.User
+	)
+	BeforeEach(func() {
+		var ok bool
+		repo, ok = createRepoFunc().(user.Repo)
+		Expect(ok).To(BeTrue(), "repo is not a user.Repo")
+
+		userA = &user.User{Name: "user-a", PasswordHash: "hash-a"}
+		userB = &user.User{Name: "user-b", PasswordHash: "hash-b"}
+	})
+
+	Describe("CreateUser", func() {
+		It("stores the users, ordered by name", func() {
+			Expect(repo.CreateUser(userB)).To(Succeed())
+			Expect(repo.CreateUser(userA)).To(Succeed())
+
+			Expect(createRepoFunc().(user.Repo).Users()).To(Equal([]*user.User{userA, userB}))
+		})
+
+		It("stores a copy of the user", func() {
+			Expect(repo.CreateUser(userA)).To(Succeed())
+			userA.PasswordHash = "some-other-hash"
+
+			u, err := repo.FindUserByName("user-a")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(u.PasswordHash).To(Equal("hash-a"))
+		})
+
+		Context("when there is already a user with the same name", func() {
+			It("returns an error", func() {
+				Expect(repo.CreateUser(userA)).To(Succeed())
+				Expect(repo.CreateUser(&user.User{Name: "user-a"})).NotTo(Succeed())
+
+				Expect(repo.Users()).To(Equal([]*user.User{userA}))
+			})
+		})
+	})
+
+	Describe("Users", func() {
+		It("returns no users when there are none", func() {
+			Expect(repo.Users()).To(BeEmpty())
+		})
+	})
+
+	Describe("FindUserByName", func() {
+		BeforeEach(func() {
+			Expect(repo.CreateUser(userA)).To(Succeed())
+			Expect(repo.CreateUser(userB)).To(Succeed())
+		})
+
+		It("finds the user", func() {
+			Expect(repo.FindUserByName("user-b")).To(Equal(userB))
+		})
+
+		Context("when the user does not exist", func() {
+			It("returns nil, nil", func() {
+				u, err := repo.FindUserByName("user-c")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(u).To(BeNil())
+			})
+		})
+	})
+
+	Describe("DeleteUser", func() {
+		BeforeEach(func() {
+			Expect(repo.CreateUser(userA)).To(Succeed())
+			Expect(repo.CreateUser(userB)).To(Succeed())
+		})
+
+		It("deletes the user", func() {
+			Expect(repo.DeleteUser(userA)).To(Succeed())
+			Expect(repo.Users()).To(Equal([]*user.User{userB}))
+		})
+
+		Context("when the user does not exist", func() {
+			It("succeeds", func() {
+				Expect(repo.DeleteUser(&user.User{Name: "user-c"})).To(Succeed())
+				Expect(repo.Users()).To(HaveLen(2))
+			})
+		})
+	})
+}
@@ -0,0 +1,168 @@
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/lager"
+	"github.com/ankeesler/anwork/task"
+)
+
+// A Dispatcher delivers task.Event's to the Webhook's in a Repo, in the background.
+type Dispatcher struct {
+	logger lager.Logger
+	clock  clock.Clock
+	client *http.Client
+
+	attempts int
+	backoff  time.Duration
+
+	deliveries sync.WaitGroup
+}
+
+// An Option configures optional behavior of a Dispatcher returned from NewDispatcher.
+type Option func(*Dispatcher)
+
+// WithClock sets the clock.Clock with which a Dispatcher waits between attempts to make a Delivery,
+// and dates DeadLetter's. By default, the real time is used.
+func WithClock(clock clock.Clock) Option {
+	return func(d *Dispatcher) {
+		d.clock = clock
+	}
+}
+
+// WithRetries sets how many times a Dispatcher tries to make a Delivery before it records a
+// DeadLetter, and how long it waits after the first attempt. It waits twice as long after each
+// attempt after that. By default, a Delivery is tried 5 times, starting with a 1 second wait.
+func WithRetries(attempts int, backoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.attempts = attempts
+		d.backoff = backoff
+	}
+}
+
+// WithHTTPClient sets the http.Client that makes each Delivery. By default, an http.Client that
+// gives up on a Delivery after 10 seconds is used.
+func WithHTTPClient(client *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// NewDispatcher creates a new Dispatcher.
+func NewDispatcher(logger lager.Logger, options ...Option) *Dispatcher {
+	d := &Dispatcher{
+		logger:   logger,
+		clock:    clock.NewClock(),
+		client:   &http.Client{Timeout: time.Second * 10},
+		attempts: 5,
+		backoff:  time.Second,
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// Dispatch delivers a task.Event to each Webhook in a Repo that it matches. The task.Event is in a
+// context, which is sent in each Delivery. Dispatch returns before the Delivery's are made.
+func (d *Dispatcher) Dispatch(repo Repo, context string, event *task.Event) {
+	d.deliveries.Add(1)
+	go func() {
+		defer d.deliveries.Done()
+
+		logger := d.logger.Session("dispatch", lager.Data{"context": context, "event": event.ID})
+		webhooks, err := repo.Webhooks()
+		if err != nil {
+			logger.Error("webhooks", err)
+			return
+		}
+
+		for _, webhook := range webhooks {
+			if webhook.Matches(event) {
+				d.deliveries.Add(1)
+				go func(webhook *Webhook) {
+					defer d.deliveries.Done()
+					d.deliver(logger, repo, webhook, &Delivery{
+						WebhookID: webhook.ID,
+						Context:   context,
+						Event:     event,
+					})
+				}(webhook)
+			}
+		}
+	}()
+}
+
+// Wait waits until each Delivery that has been dispatched has been made or recorded as a
+// DeadLetter.
+func (d *Dispatcher) Wait() {
+	d.deliveries.Wait()
+}
+
+// deliver tries to make a Delivery until it succeeds, or records a DeadLetter if it never does.
+func (d *Dispatcher) deliver(logger lager.Logger, repo Repo, webhook *Webhook, delivery *Delivery) {
+	logger = logger.Session("deliver", lager.Data{"webhook": webhook.ID, "url": webhook.URL})
+
+	body, err := json.Marshal(delivery)
+	if err != nil {
+		logger.Error("marshal", err)
+		return
+	}
+
+	backoff := d.backoff
+	attempt := 1
+	for ; ; attempt++ {
+		if err = d.post(webhook, body); err == nil {
+			logger.Debug("delivered", lager.Data{"attempt": attempt})
+			return
+		}
+		logger.Info("failed", lager.Data{"attempt": attempt, "error": err.Error()})
+
+		if attempt >= d.attempts {
+			break
+		}
+		d.clock.Sleep(backoff)
+		backoff *= 2
+	}
+
+	deadLetter := &DeadLetter{
+		WebhookID: webhook.ID,
+		URL:       webhook.URL,
+		Owner:     webhook.Owner,
+		Event:     delivery.Event,
+		Attempts:  attempt,
+		Error:     err.Error(),
+		Date:      d.clock.Now().Unix(),
+	}
+	if err := repo.CreateDeadLetter(deadLetter); err != nil {
+		logger.Error("create-dead-letter", err)
+	}
+}
+
+// post POSTs the body of a Delivery to a Webhook, and returns an error unless it responds with a
+// 2XX status.
+func (d *Dispatcher) post(webhook *Webhook, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, body))
+
+	rsp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	rsp.Body.Close()
+
+	if rsp.StatusCode < 200 || rsp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response: %s", rsp.Status)
+	}
+	return nil
+}
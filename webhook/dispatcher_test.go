@@ -0,0 +1,150 @@
+package webhook_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+	"github.com/ankeesler/anwork/webhook/webhookfakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dispatcher", func() {
+	var (
+		clock *fakeclock.FakeClock
+		repo  *webhookfakes.FakeRepo
+		event *task.Event
+
+		receiver   *httptest.Server
+		attempts   int32
+		failures   int32
+		deliveries chan *webhook.Delivery
+
+		dispatcher *webhook.Dispatcher
+	)
+
+	BeforeEach(func() {
+		clock = fakeclock.NewFakeClock(time.Unix(1545778200, 0))
+		event = &task.Event{ID: 5, Title: "some event", Type: task.EventTypeSetState, NewValue: "Blocked"}
+
+		atomic.StoreInt32(&attempts, 0)
+		atomic.StoreInt32(&failures, 0)
+		deliveries = make(chan *webhook.Delivery, 10)
+		receiver = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer GinkgoRecover()
+
+			body, err := ioutil.ReadAll(r.Body)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(webhook.Verify("some-secret", body, r.Header.Get(webhook.SignatureHeader))).To(BeTrue())
+
+			if atomic.AddInt32(&attempts, 1) <= atomic.LoadInt32(&failures) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+
+			var delivery webhook.Delivery
+			Expect(json.Unmarshal(body, &delivery)).To(Succeed())
+			deliveries <- &delivery
+		}))
+
+		repo = &webhookfakes.FakeRepo{}
+		repo.WebhooksReturns([]*webhook.Webhook{
+			{ID: 1, URL: receiver.URL, Secret: "some-secret", Owner: "user-a"},
+			{ID: 2, URL: receiver.URL, Secret: "some-secret", EventTypes: []task.EventType{task.EventTypeNote}},
+		}, nil)
+
+		dispatcher = webhook.NewDispatcher(
+			lagertest.NewTestLogger("dispatcher"),
+			webhook.WithClock(clock),
+			webhook.WithRetries(3, time.Second),
+		)
+	})
+
+	AfterEach(func() {
+		dispatcher.Wait()
+		receiver.Close()
+	})
+
+	// attempted returns how many times a delivery has been tried.
+	attempted := func() int32 {
+		return atomic.LoadInt32(&attempts)
+	}
+
+	It("delivers an event to the webhooks that it matches", func() {
+		dispatcher.Dispatch(repo, "some-context", event)
+		dispatcher.Wait()
+
+		var delivery *webhook.Delivery
+		Expect(deliveries).To(Receive(&delivery))
+		Expect(delivery).To(Equal(&webhook.Delivery{WebhookID: 1, Context: "some-context", Event: event}))
+		Expect(deliveries).NotTo(Receive())
+
+		Expect(attempted()).To(BeEquivalentTo(1))
+		Expect(repo.CreateDeadLetterCallCount()).To(Equal(0))
+	})
+
+	Context("when the webhook does not accept the delivery at first", func() {
+		BeforeEach(func() {
+			atomic.StoreInt32(&failures, 1)
+		})
+
+		It("tries again after the backoff", func() {
+			dispatcher.Dispatch(repo, "", event)
+			Eventually(attempted).Should(BeEquivalentTo(1))
+
+			Eventually(clock.WatcherCount).Should(Equal(1))
+			clock.Increment(time.Second - time.Millisecond)
+			Consistently(attempted).Should(BeEquivalentTo(1))
+			clock.Increment(time.Millisecond)
+
+			Eventually(deliveries).Should(Receive())
+			dispatcher.Wait()
+			Expect(attempted()).To(BeEquivalentTo(2))
+			Expect(repo.CreateDeadLetterCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the webhook never accepts the delivery", func() {
+		BeforeEach(func() {
+			atomic.StoreInt32(&failures, 100)
+		})
+
+		It("doubles the backoff after each attempt, and then records a dead letter", func() {
+			dispatcher.Dispatch(repo, "", event)
+			Eventually(attempted).Should(BeEquivalentTo(1))
+
+			Eventually(clock.WatcherCount).Should(Equal(1))
+			clock.Increment(time.Second)
+			Eventually(attempted).Should(BeEquivalentTo(2))
+
+			Eventually(clock.WatcherCount).Should(Equal(1))
+			clock.Increment(time.Second)
+			Consistently(attempted).Should(BeEquivalentTo(2))
+			clock.Increment(time.Second)
+			Eventually(attempted).Should(BeEquivalentTo(3))
+
+			dispatcher.Wait()
+			Expect(attempted()).To(BeEquivalentTo(3))
+			Expect(deliveries).NotTo(Receive())
+
+			Expect(repo.CreateDeadLetterCallCount()).To(Equal(1))
+			Expect(repo.CreateDeadLetterArgsForCall(0)).To(Equal(&webhook.DeadLetter{
+				WebhookID: 1,
+				URL:       receiver.URL,
+				Owner:     "user-a",
+				Event:     event,
+				Attempts:  3,
+				Error:     "unexpected response: 503 Service Unavailable",
+				Date:      clock.Now().Unix(),
+			}))
+		})
+	})
+})
@@ -0,0 +1,144 @@
+// Package webhook delivers the task.Event's in a task.Repo to the URLs of Webhook's, e.g., so that a
+// chat room is told when a task.Task is blocked or finished.
+//
+// Each task.Event is POSTed to each Webhook that it matches as a JSON Delivery, which is signed with
+// the secret of the Webhook; see Sign. A Delivery that cannot be made is retried, and it is recorded
+// as a DeadLetter if it still cannot be made.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/ankeesler/anwork/task"
+)
+
+// SignatureHeader is the header of a Delivery that holds its signature; see Sign.
+const SignatureHeader = "X-Anwork-Signature"
+
+// A Webhook is a URL to which the task.Event's that it matches are delivered.
+type Webhook struct {
+	// This is a unique ID. Every Webhook has a different ID.
+	ID int `json:"id"`
+
+	// This is where the task.Event's are POSTed, e.g., "https://chat.example.com/hooks/abc123".
+	URL string `json:"url"`
+
+	// These are the types of the task.Event's that are delivered. If there are none, every type of
+	// task.Event is delivered.
+	EventTypes []task.EventType `json:"eventTypes,omitempty"`
+
+	// These are the states to which a task.EventTypeSetState task.Event must move a task.Task for it
+	// to be delivered. If there are any, no other type of task.Event is delivered.
+	States []task.State `json:"states,omitempty"`
+
+	// This is the secret with which each Delivery is signed. It is never sent back by the API.
+	Secret string `json:"secret,omitempty"`
+
+	// This is the name of the user who owns the Webhook, or "" if no one does, e.g., because it was
+	// not created through the API by a user who logged in. Only its owner can see a Webhook that has
+	// an owner, and only the task.Event's about the task.Task's that its owner can see (see
+	// task.Task.Owner) are delivered to it.
+	Owner string `json:"owner,omitempty"`
+}
+
+// Matches returns whether a task.Event should be delivered to the Webhook.
+func (w *Webhook) Matches(e *task.Event) bool {
+	if len(w.EventTypes) > 0 {
+		found := false
+		for _, t := range w.EventTypes {
+			if t == e.Type {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(w.States) > 0 {
+		if e.Type != task.EventTypeSetState {
+			return false
+		}
+		for _, s := range w.States {
+			if string(s) == e.NewValue {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// A Delivery is the JSON body of the POST that delivers a task.Event to a Webhook.
+type Delivery struct {
+	// This is the ID of the Webhook.
+	WebhookID int `json:"webhookId"`
+
+	// This is the context of the task.Event, or "" if it is in the task.Repo of the API.
+	Context string `json:"context,omitempty"`
+
+	// This is the task.Event that happened.
+	Event *task.Event `json:"event"`
+}
+
+// A DeadLetter records a Delivery that could not be made, even after it was retried.
+type DeadLetter struct {
+	// This is a unique ID. Every DeadLetter has a different ID.
+	ID int `json:"id"`
+
+	// These are the ID, the URL, and the owner of the Webhook to which the Delivery could not be
+	// made.
+	WebhookID int    `json:"webhookId"`
+	URL       string `json:"url"`
+	Owner     string `json:"owner,omitempty"`
+
+	// This is the task.Event that could not be delivered.
+	Event *task.Event `json:"event"`
+
+	// This is how many times the Delivery was tried, and why the last try failed.
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+
+	// This is when the Delivery was given up on, represented by the number of seconds since January
+	// 1, 1970.
+	Date int64 `json:"date"`
+}
+
+//go:generate counterfeiter . Repo
+
+// A Repo stores Webhook's and DeadLetter's. It is implemented by a task.Repo that can store them
+// next to its task.Task's, e.g., in the same context.
+type Repo interface {
+	// CreateWebhook creates a Webhook. The Webhook.ID field is set by the Repo.
+	CreateWebhook(*Webhook) error
+	// Webhooks returns all of the Webhook's in this Repo.
+	Webhooks() ([]*Webhook, error)
+	// FindWebhookByID tries to find a Webhook with the provided ID. If the Webhook does not exist,
+	// it will return nil, nil.
+	FindWebhookByID(int) (*Webhook, error)
+	// DeleteWebhook deletes a Webhook with the provided ID.
+	// If the Webhook does not exist, this function will return nil.
+	DeleteWebhook(*Webhook) error
+
+	// CreateDeadLetter creates a DeadLetter. The DeadLetter.ID field is set by the Repo.
+	CreateDeadLetter(*DeadLetter) error
+	// DeadLetters returns all of the DeadLetter's in this Repo.
+	DeadLetters() ([]*DeadLetter, error)
+}
+
+// Sign returns the signature of the body of a Delivery, which is sent in the SignatureHeader. It is
+// "sha256=" followed by the hex encoding of the HMAC-SHA256 of the body, keyed with the secret of
+// the Webhook.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns whether a signature (see Sign) was made with a secret for a body.
+func Verify(secret string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, body)), []byte(signature))
+}
@@ -0,0 +1,87 @@
+package webhook_test
+
+import (
+	"strings"
+
+	"github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Webhook", func() {
+	Describe("Matches", func() {
+		var (
+			create   = &task.Event{Type: task.EventTypeCreate}
+			note     = &task.Event{Type: task.EventTypeNote, Note: "Blocked"}
+			blocked  = &task.Event{Type: task.EventTypeSetState, OldValue: "Ready", NewValue: "Blocked"}
+			finished = &task.Event{Type: task.EventTypeSetState, OldValue: "Blocked", NewValue: "Finished"}
+		)
+
+		It("matches every event when it has no event types or states", func() {
+			w := &webhook.Webhook{}
+			for _, e := range []*task.Event{create, note, blocked, finished} {
+				Expect(w.Matches(e)).To(BeTrue())
+			}
+		})
+
+		It("only matches the events of its event types", func() {
+			w := &webhook.Webhook{EventTypes: []task.EventType{task.EventTypeCreate, task.EventTypeNote}}
+			Expect(w.Matches(create)).To(BeTrue())
+			Expect(w.Matches(note)).To(BeTrue())
+			Expect(w.Matches(blocked)).To(BeFalse())
+		})
+
+		It("only matches the events that move a task to one of its states", func() {
+			w := &webhook.Webhook{States: []task.State{task.StateBlocked}}
+			Expect(w.Matches(blocked)).To(BeTrue())
+			Expect(w.Matches(finished)).To(BeFalse())
+			Expect(w.Matches(create)).To(BeFalse())
+			Expect(w.Matches(note)).To(BeFalse())
+		})
+
+		It("matches the events that have one of its event types and move a task to one of its states", func() {
+			w := &webhook.Webhook{
+				EventTypes: []task.EventType{task.EventTypeSetState},
+				States:     []task.State{task.StateBlocked, task.StateFinished},
+			}
+			Expect(w.Matches(blocked)).To(BeTrue())
+			Expect(w.Matches(finished)).To(BeTrue())
+			Expect(w.Matches(note)).To(BeFalse())
+
+			w.EventTypes = []task.EventType{task.EventTypeNote}
+			Expect(w.Matches(blocked)).To(BeFalse())
+			Expect(w.Matches(note)).To(BeFalse())
+		})
+	})
+
+	Describe("Sign and Verify", func() {
+		body := []byte(`{"webhookId":1,"event":{"title":"some event"}}`)
+
+		It("signs a body with the hex HMAC-SHA256 of the secret", func() {
+			signature := webhook.Sign("some-secret", body)
+			Expect(signature).To(HavePrefix("sha256="))
+			Expect(strings.TrimPrefix(signature, "sha256=")).To(MatchRegexp("^[0-9a-f]{64}$"))
+
+			Expect(webhook.Sign("some-secret", body)).To(Equal(signature))
+			Expect(webhook.Verify("some-secret", body, signature)).To(BeTrue())
+		})
+
+		It("does not verify a body that was tampered with", func() {
+			signature := webhook.Sign("some-secret", body)
+			tampered := []byte(strings.Replace(string(body), "some event", "some other event", 1))
+			Expect(webhook.Verify("some-secret", tampered, signature)).To(BeFalse())
+		})
+
+		It("does not verify a signature that was made with another secret", func() {
+			signature := webhook.Sign("some-other-secret", body)
+			Expect(webhook.Verify("some-secret", body, signature)).To(BeFalse())
+		})
+
+		It("does not verify a malformed signature", func() {
+			signature := webhook.Sign("some-secret", body)
+			Expect(webhook.Verify("some-secret", body, "")).To(BeFalse())
+			Expect(webhook.Verify("some-secret", body, strings.TrimPrefix(signature, "sha256="))).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,472 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package webhookfakes
+
+import (
+	sync "sync"
+
+	webhook "github.com/ankeesler/anwork/webhook"
+)
+
+type FakeRepo struct {
+	CreateDeadLetterStub        func(*webhook.DeadLetter) error
+	createDeadLetterMutex       sync.RWMutex
+	createDeadLetterArgsForCall []struct {
+		arg1 *webhook.DeadLetter
+	}
+	createDeadLetterReturns struct {
+		result1 error
+	}
+	createDeadLetterReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CreateWebhookStub        func(*webhook.Webhook) error
+	createWebhookMutex       sync.RWMutex
+	createWebhookArgsForCall []struct {
+		arg1 *webhook.Webhook
+	}
+	createWebhookReturns struct {
+		result1 error
+	}
+	createWebhookReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeadLettersStub        func() ([]*webhook.DeadLetter, error)
+	deadLettersMutex       sync.RWMutex
+	deadLettersArgsForCall []struct {
+	}
+	deadLettersReturns struct {
+		result1 []*webhook.DeadLetter
+		result2 error
+	}
+	deadLettersReturnsOnCall map[int]struct {
+		result1 []*webhook.DeadLetter
+		result2 error
+	}
+	DeleteWebhookStub        func(*webhook.Webhook) error
+	deleteWebhookMutex       sync.RWMutex
+	deleteWebhookArgsForCall []struct {
+		arg1 *webhook.Webhook
+	}
+	deleteWebhookReturns struct {
+		result1 error
+	}
+	deleteWebhookReturnsOnCall map[int]struct {
+		result1 error
+	}
+	FindWebhookByIDStub        func(int) (*webhook.Webhook, error)
+	findWebhookByIDMutex       sync.RWMutex
+	findWebhookByIDArgsForCall []struct {
+		arg1 int
+	}
+	findWebhookByIDReturns struct {
+		result1 *webhook.Webhook
+		result2 error
+	}
+	findWebhookByIDReturnsOnCall map[int]struct {
+		result1 *webhook.Webhook
+		result2 error
+	}
+	WebhooksStub        func() ([]*webhook.Webhook, error)
+	webhooksMutex       sync.RWMutex
+	webhooksArgsForCall []struct {
+	}
+	webhooksReturns struct {
+		result1 []*webhook.Webhook
+		result2 error
+	}
+	webhooksReturnsOnCall map[int]struct {
+		result1 []*webhook.Webhook
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeRepo) CreateDeadLetter(arg1 *webhook.DeadLetter) error {
+	fake.createDeadLetterMutex.Lock()
+	ret, specificReturn := fake.createDeadLetterReturnsOnCall[len(fake.createDeadLetterArgsForCall)]
+	fake.createDeadLetterArgsForCall = append(fake.createDeadLetterArgsForCall, struct {
+		arg1 *webhook.DeadLetter
+	}{arg1})
+	fake.recordInvocation("CreateDeadLetter", []interface{}{arg1})
+	fake.createDeadLetterMutex.Unlock()
+	if fake.CreateDeadLetterStub != nil {
+		return fake.CreateDeadLetterStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.createDeadLetterReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepo) CreateDeadLetterCallCount() int {
+	fake.createDeadLetterMutex.RLock()
+	defer fake.createDeadLetterMutex.RUnlock()
+	return len(fake.createDeadLetterArgsForCall)
+}
+
+func (fake *FakeRepo) CreateDeadLetterCalls(stub func(*webhook.DeadLetter) error) {
+	fake.createDeadLetterMutex.Lock()
+	defer fake.createDeadLetterMutex.Unlock()
+	fake.CreateDeadLetterStub = stub
+}
+
+func (fake *FakeRepo) CreateDeadLetterArgsForCall(i int) *webhook.DeadLetter {
+	fake.createDeadLetterMutex.RLock()
+	defer fake.createDeadLetterMutex.RUnlock()
+	argsForCall := fake.createDeadLetterArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) CreateDeadLetterReturns(result1 error) {
+	fake.createDeadLetterMutex.Lock()
+	defer fake.createDeadLetterMutex.Unlock()
+	fake.CreateDeadLetterStub = nil
+	fake.createDeadLetterReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) CreateDeadLetterReturnsOnCall(i int, result1 error) {
+	fake.createDeadLetterMutex.Lock()
+	defer fake.createDeadLetterMutex.Unlock()
+	fake.CreateDeadLetterStub = nil
+	if fake.createDeadLetterReturnsOnCall == nil {
+		fake.createDeadLetterReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createDeadLetterReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) CreateWebhook(arg1 *webhook.Webhook) error {
+	fake.createWebhookMutex.Lock()
+	ret, specificReturn := fake.createWebhookReturnsOnCall[len(fake.createWebhookArgsForCall)]
+	fake.createWebhookArgsForCall = append(fake.createWebhookArgsForCall, struct {
+		arg1 *webhook.Webhook
+	}{arg1})
+	fake.recordInvocation("CreateWebhook", []interface{}{arg1})
+	fake.createWebhookMutex.Unlock()
+	if fake.CreateWebhookStub != nil {
+		return fake.CreateWebhookStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.createWebhookReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepo) CreateWebhookCallCount() int {
+	fake.createWebhookMutex.RLock()
+	defer fake.createWebhookMutex.RUnlock()
+	return len(fake.createWebhookArgsForCall)
+}
+
+func (fake *FakeRepo) CreateWebhookCalls(stub func(*webhook.Webhook) error) {
+	fake.createWebhookMutex.Lock()
+	defer fake.createWebhookMutex.Unlock()
+	fake.CreateWebhookStub = stub
+}
+
+func (fake *FakeRepo) CreateWebhookArgsForCall(i int) *webhook.Webhook {
+	fake.createWebhookMutex.RLock()
+	defer fake.createWebhookMutex.RUnlock()
+	argsForCall := fake.createWebhookArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) CreateWebhookReturns(result1 error) {
+	fake.createWebhookMutex.Lock()
+	defer fake.createWebhookMutex.Unlock()
+	fake.CreateWebhookStub = nil
+	fake.createWebhookReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) CreateWebhookReturnsOnCall(i int, result1 error) {
+	fake.createWebhookMutex.Lock()
+	defer fake.createWebhookMutex.Unlock()
+	fake.CreateWebhookStub = nil
+	if fake.createWebhookReturnsOnCall == nil {
+		fake.createWebhookReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createWebhookReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) DeadLetters() ([]*webhook.DeadLetter, error) {
+	fake.deadLettersMutex.Lock()
+	ret, specificReturn := fake.deadLettersReturnsOnCall[len(fake.deadLettersArgsForCall)]
+	fake.deadLettersArgsForCall = append(fake.deadLettersArgsForCall, struct {
+	}{})
+	fake.recordInvocation("DeadLetters", []interface{}{})
+	fake.deadLettersMutex.Unlock()
+	if fake.DeadLettersStub != nil {
+		return fake.DeadLettersStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.deadLettersReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepo) DeadLettersCallCount() int {
+	fake.deadLettersMutex.RLock()
+	defer fake.deadLettersMutex.RUnlock()
+	return len(fake.deadLettersArgsForCall)
+}
+
+func (fake *FakeRepo) DeadLettersCalls(stub func() ([]*webhook.DeadLetter, error)) {
+	fake.deadLettersMutex.Lock()
+	defer fake.deadLettersMutex.Unlock()
+	fake.DeadLettersStub = stub
+}
+
+func (fake *FakeRepo) DeadLettersReturns(result1 []*webhook.DeadLetter, result2 error) {
+	fake.deadLettersMutex.Lock()
+	defer fake.deadLettersMutex.Unlock()
+	fake.DeadLettersStub = nil
+	fake.deadLettersReturns = struct {
+		result1 []*webhook.DeadLetter
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) DeadLettersReturnsOnCall(i int, result1 []*webhook.DeadLetter, result2 error) {
+	fake.deadLettersMutex.Lock()
+	defer fake.deadLettersMutex.Unlock()
+	fake.DeadLettersStub = nil
+	if fake.deadLettersReturnsOnCall == nil {
+		fake.deadLettersReturnsOnCall = make(map[int]struct {
+			result1 []*webhook.DeadLetter
+			result2 error
+		})
+	}
+	fake.deadLettersReturnsOnCall[i] = struct {
+		result1 []*webhook.DeadLetter
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) DeleteWebhook(arg1 *webhook.Webhook) error {
+	fake.deleteWebhookMutex.Lock()
+	ret, specificReturn := fake.deleteWebhookReturnsOnCall[len(fake.deleteWebhookArgsForCall)]
+	fake.deleteWebhookArgsForCall = append(fake.deleteWebhookArgsForCall, struct {
+		arg1 *webhook.Webhook
+	}{arg1})
+	fake.recordInvocation("DeleteWebhook", []interface{}{arg1})
+	fake.deleteWebhookMutex.Unlock()
+	if fake.DeleteWebhookStub != nil {
+		return fake.DeleteWebhookStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.deleteWebhookReturns
+	return fakeReturns.result1
+}
+
+func (fake *FakeRepo) DeleteWebhookCallCount() int {
+	fake.deleteWebhookMutex.RLock()
+	defer fake.deleteWebhookMutex.RUnlock()
+	return len(fake.deleteWebhookArgsForCall)
+}
+
+func (fake *FakeRepo) DeleteWebhookCalls(stub func(*webhook.Webhook) error) {
+	fake.deleteWebhookMutex.Lock()
+	defer fake.deleteWebhookMutex.Unlock()
+	fake.DeleteWebhookStub = stub
+}
+
+func (fake *FakeRepo) DeleteWebhookArgsForCall(i int) *webhook.Webhook {
+	fake.deleteWebhookMutex.RLock()
+	defer fake.deleteWebhookMutex.RUnlock()
+	argsForCall := fake.deleteWebhookArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) DeleteWebhookReturns(result1 error) {
+	fake.deleteWebhookMutex.Lock()
+	defer fake.deleteWebhookMutex.Unlock()
+	fake.DeleteWebhookStub = nil
+	fake.deleteWebhookReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) DeleteWebhookReturnsOnCall(i int, result1 error) {
+	fake.deleteWebhookMutex.Lock()
+	defer fake.deleteWebhookMutex.Unlock()
+	fake.DeleteWebhookStub = nil
+	if fake.deleteWebhookReturnsOnCall == nil {
+		fake.deleteWebhookReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteWebhookReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeRepo) FindWebhookByID(arg1 int) (*webhook.Webhook, error) {
+	fake.findWebhookByIDMutex.Lock()
+	ret, specificReturn := fake.findWebhookByIDReturnsOnCall[len(fake.findWebhookByIDArgsForCall)]
+	fake.findWebhookByIDArgsForCall = append(fake.findWebhookByIDArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	fake.recordInvocation("FindWebhookByID", []interface{}{arg1})
+	fake.findWebhookByIDMutex.Unlock()
+	if fake.FindWebhookByIDStub != nil {
+		return fake.FindWebhookByIDStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.findWebhookByIDReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepo) FindWebhookByIDCallCount() int {
+	fake.findWebhookByIDMutex.RLock()
+	defer fake.findWebhookByIDMutex.RUnlock()
+	return len(fake.findWebhookByIDArgsForCall)
+}
+
+func (fake *FakeRepo) FindWebhookByIDCalls(stub func(int) (*webhook.Webhook, error)) {
+	fake.findWebhookByIDMutex.Lock()
+	defer fake.findWebhookByIDMutex.Unlock()
+	fake.FindWebhookByIDStub = stub
+}
+
+func (fake *FakeRepo) FindWebhookByIDArgsForCall(i int) int {
+	fake.findWebhookByIDMutex.RLock()
+	defer fake.findWebhookByIDMutex.RUnlock()
+	argsForCall := fake.findWebhookByIDArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeRepo) FindWebhookByIDReturns(result1 *webhook.Webhook, result2 error) {
+	fake.findWebhookByIDMutex.Lock()
+	defer fake.findWebhookByIDMutex.Unlock()
+	fake.FindWebhookByIDStub = nil
+	fake.findWebhookByIDReturns = struct {
+		result1 *webhook.Webhook
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) FindWebhookByIDReturnsOnCall(i int, result1 *webhook.Webhook, result2 error) {
+	fake.findWebhookByIDMutex.Lock()
+	defer fake.findWebhookByIDMutex.Unlock()
+	fake.FindWebhookByIDStub = nil
+	if fake.findWebhookByIDReturnsOnCall == nil {
+		fake.findWebhookByIDReturnsOnCall = make(map[int]struct {
+			result1 *webhook.Webhook
+			result2 error
+		})
+	}
+	fake.findWebhookByIDReturnsOnCall[i] = struct {
+		result1 *webhook.Webhook
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) Webhooks() ([]*webhook.Webhook, error) {
+	fake.webhooksMutex.Lock()
+	ret, specificReturn := fake.webhooksReturnsOnCall[len(fake.webhooksArgsForCall)]
+	fake.webhooksArgsForCall = append(fake.webhooksArgsForCall, struct {
+	}{})
+	fake.recordInvocation("Webhooks", []interface{}{})
+	fake.webhooksMutex.Unlock()
+	if fake.WebhooksStub != nil {
+		return fake.WebhooksStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	fakeReturns := fake.webhooksReturns
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeRepo) WebhooksCallCount() int {
+	fake.webhooksMutex.RLock()
+	defer fake.webhooksMutex.RUnlock()
+	return len(fake.webhooksArgsForCall)
+}
+
+func (fake *FakeRepo) WebhooksCalls(stub func() ([]*webhook.Webhook, error)) {
+	fake.webhooksMutex.Lock()
+	defer fake.webhooksMutex.Unlock()
+	fake.WebhooksStub = stub
+}
+
+func (fake *FakeRepo) WebhooksReturns(result1 []*webhook.Webhook, result2 error) {
+	fake.webhooksMutex.Lock()
+	defer fake.webhooksMutex.Unlock()
+	fake.WebhooksStub = nil
+	fake.webhooksReturns = struct {
+		result1 []*webhook.Webhook
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) WebhooksReturnsOnCall(i int, result1 []*webhook.Webhook, result2 error) {
+	fake.webhooksMutex.Lock()
+	defer fake.webhooksMutex.Unlock()
+	fake.WebhooksStub = nil
+	if fake.webhooksReturnsOnCall == nil {
+		fake.webhooksReturnsOnCall = make(map[int]struct {
+			result1 []*webhook.Webhook
+			result2 error
+		})
+	}
+	fake.webhooksReturnsOnCall[i] = struct {
+		result1 []*webhook.Webhook
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeRepo) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createDeadLetterMutex.RLock()
+	defer fake.createDeadLetterMutex.RUnlock()
+	fake.createWebhookMutex.RLock()
+	defer fake.createWebhookMutex.RUnlock()
+	fake.deadLettersMutex.RLock()
+	defer fake.deadLettersMutex.RUnlock()
+	fake.deleteWebhookMutex.RLock()
+	defer fake.deleteWebhookMutex.RUnlock()
+	fake.findWebhookByIDMutex.RLock()
+	defer fake.findWebhookByIDMutex.RUnlock()
+	fake.webhooksMutex.RLock()
+	defer fake.webhooksMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeRepo) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ webhook.Repo = new(FakeRepo)
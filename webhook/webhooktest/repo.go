@@ -0,0 +1,168 @@
+// Package webhooktest contains conformance tests that every task.Repo that is also a webhook.Repo
+// should pass.
+package webhooktest
+
+import (
+	taskpkg "github.com/ankeesler/anwork/task"
+	"github.com/ankeesler/anwork/webhook"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// RunRepoTests will run a set of tests to verify that the provided repo is a valid webhook.Repo
+// implementation. The createRepoFunc must return a repo that reads and writes the same webhooks
+// and dead letters each time that it is called within a test.
+func RunRepoTests(createRepoFunc func() taskpkg.Repo) {
+	var (
+		repo                     webhook.Repo
+		webhookA, webhookB       *webhook.Webhook
+		deadLetterA, deadLetterB *webhook.DeadLetter
+	)
+	createWebhooks := func() {
+		ExpectWithOffset(1, repo.CreateWebhook(webhookA)).To(Succeed())
+		ExpectWithOffset(1, repo.CreateWebhook(webhookB)).To(Succeed())
+	}
+	BeforeEach(func() {
+		var ok bool
+		repo, ok = createRepoFunc().(webhook.Repo)
+		Expect(ok).To(BeTrue(), "repo is not a webhook.Repo")
+
+		webhookA = &webhook.Webhook{URL: "https://example.com/a", Secret: "secret-a"}
+		webhookB = &webhook.Webhook{
+			URL:        "https://example.com/b",
+			EventTypes: []taskpkg.EventType{taskpkg.EventTypeSetState, taskpkg.EventTypeNote},
+			States:     []taskpkg.State{taskpkg.StateBlocked, taskpkg.StateFinished},
+			Secret:     "secret-b",
+			Owner:      "user-b",
+		}
+
+		deadLetterA = &webhook.DeadLetter{
+			WebhookID: 1,
+			URL:       "https://example.com/a",
+			Event:     &taskpkg.Event{ID: 3, Title: "event-a", Type: taskpkg.EventTypeNote, Note: "some note"},
+			Attempts:  5,
+			Error:     "some error",
+			Date:      1545778200,
+			Owner:     "user-a",
+		}
+		deadLetterB = &webhook.DeadLetter{
+			WebhookID: 2,
+			URL:       "https://example.com/b",
+			Event:     &taskpkg.Event{ID: 4, Title: "event-b"},
+			Attempts:  1,
+			Error:     "some other error",
+		}
+	})
+
+	Describe("CreateWebhook", func() {
+		BeforeEach(func() {
+			createWebhooks()
+		})
+
+		It("gives each webhook a different ID", func() {
+			Expect(webhookA.ID).NotTo(Equal(webhookB.ID))
+		})
+
+		It("returns them with Webhooks()", func() {
+			webhooks, err := createRepoFunc().(webhook.Repo).Webhooks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(webhooks).To(Equal([]*webhook.Webhook{webhookA, webhookB}))
+		})
+	})
+
+	Describe("Webhooks", func() {
+		Context("when no webhooks exist", func() {
+			It("returns no webhooks", func() {
+				webhooks, err := repo.Webhooks()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(webhooks).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("FindWebhookByID", func() {
+		BeforeEach(func() {
+			createWebhooks()
+		})
+
+		Context("when the webhook does not exist", func() {
+			It("returns nil and nil error", func() {
+				w, err := repo.FindWebhookByID(99)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(w).To(BeNil())
+			})
+		})
+
+		Context("when the webhook exists", func() {
+			It("returns the webhook", func() {
+				w, err := repo.FindWebhookByID(webhookB.ID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(w).To(Equal(webhookB))
+			})
+		})
+	})
+
+	Describe("DeleteWebhook", func() {
+		BeforeEach(func() {
+			createWebhooks()
+		})
+
+		It("deletes the webhook", func() {
+			Expect(repo.DeleteWebhook(webhookA)).To(Succeed())
+
+			webhooks, err := repo.Webhooks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(webhooks).To(Equal([]*webhook.Webhook{webhookB}))
+		})
+
+		It("succeeds when the webhook does not exist", func() {
+			Expect(repo.DeleteWebhook(webhookA)).To(Succeed())
+			Expect(repo.DeleteWebhook(webhookA)).To(Succeed())
+		})
+	})
+
+	Describe("CreateDeadLetter", func() {
+		BeforeEach(func() {
+			Expect(repo.CreateDeadLetter(deadLetterA)).To(Succeed())
+			Expect(repo.CreateDeadLetter(deadLetterB)).To(Succeed())
+		})
+
+		It("gives each dead letter a different ID", func() {
+			Expect(deadLetterA.ID).NotTo(Equal(deadLetterB.ID))
+		})
+
+		It("returns them with DeadLetters()", func() {
+			deadLetters, err := createRepoFunc().(webhook.Repo).DeadLetters()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deadLetters).To(Equal([]*webhook.DeadLetter{deadLetterA, deadLetterB}))
+		})
+	})
+
+	Describe("DeadLetters", func() {
+		Context("when no dead letters exist", func() {
+			It("returns no dead letters", func() {
+				deadLetters, err := repo.DeadLetters()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deadLetters).To(BeEmpty())
+			})
+		})
+	})
+
+	Context("when the repo is a task.Transactor", func() {
+		It("keeps the webhooks when a transaction succeeds", func() {
+			transactor, ok := repo.(taskpkg.Transactor)
+			if !ok {
+				Skip("repo is not a task.Transactor")
+			}
+
+			createWebhooks()
+			Expect(transactor.WithTx(func(tx taskpkg.Repo) error {
+				return tx.CreateTask(&taskpkg.Task{Name: "task-a"})
+			})).To(Succeed())
+
+			webhooks, err := repo.Webhooks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(webhooks).To(HaveLen(2))
+		})
+	})
+}